@@ -0,0 +1,307 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-co-op/gocron/v2"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errMuteUsage      = errors.New("usage: /mute <ticker|hashtag|keyword|provider> <value> <duration>, e.g. /mute ticker GME 2d")
+	errMuteKind       = errors.New("unknown mute kind, use one of: " + strings.Join(archivist.MuteKinds, ", "))
+	errUnmuteUsage    = errors.New("usage: /unmute <id>, see /mutes for the list of IDs")
+	errDurationFormat = errors.New("invalid duration, use Go format with optional days (e.g. 30m, 6h, 2d)")
+	errModelUsage     = errors.New("usage: /model [<model>|auto]")
+	errModelDisabled  = errors.New("compose model choice is not configured")
+)
+
+// Bot handles commands sent to the admin chat (e.g. runtime mute rules and pauses of the news jobs).
+// Messages from any other chat are ignored.
+type Bot struct {
+	api        *tgbotapi.BotAPI             // Telegram bot API (the same bot that publishes the news)
+	chatID     string                       // admin chat ID (numeric ID or @username)
+	archivist  *archivist.Archivist         // archivist that will be used to store mute rules
+	bandit     *composer.Bandit             // bandit that chooses the Compose model (optional)
+	composer   *composer.Composer           // composer that answers the `/ask` questions about the archive (optional)
+	scheduler  gocron.Scheduler             // scheduler of the jobs previewed with `/schedule` (optional)
+	channelID  string                       // channel whose calendar events are previewed with `/schedule`
+	queue      *publisher.QueueMetrics      // metrics of the publish queue of the channel shown with `/schedule` (optional)
+	jobs       []string                     // keys of the jobs that can be paused with `/pause` (optional)
+	status     statusReporter               // reporter of the jobs status shown with `/status` (optional)
+	retractors []publisher.RetractPublisher // publishers of the channels whose news can be retracted with `/retract` (optional)
+	approvals  bool                         // are the news held for moderation released with `/approve`
+	logger     *slog.Logger                 // special logger for the bot
+}
+
+// NewBot creates a new Bot instance that will accept commands only from the chatID.
+func NewBot(api *tgbotapi.BotAPI, chatID string, archivist *archivist.Archivist) *Bot {
+	return &Bot{
+		api:       api,
+		chatID:    chatID,
+		archivist: archivist,
+		logger:    slog.Default(),
+	}
+}
+
+// WithBandit enables the `/model` command to show the Compose model stats and override the model choice.
+func (b *Bot) WithBandit(bandit *composer.Bandit) *Bot {
+	b.bandit = bandit
+	return b
+}
+
+// Run starts long polling of the bot updates and blocks until the updates channel is closed.
+func (b *Bot) Run() error {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	updates, err := b.api.GetUpdatesChan(u)
+	if err != nil {
+		return fmt.Errorf("[admin] failed to get updates channel: %w", err)
+	}
+
+	for update := range updates {
+		if update.Message == nil || !update.Message.IsCommand() || !b.isAdminChat(update.Message.Chat) {
+			continue
+		}
+
+		b.handle(update.Message)
+	}
+
+	return nil
+}
+
+// Stop stops receiving updates.
+func (b *Bot) Stop() {
+	b.api.StopReceivingUpdates()
+}
+
+// isAdminChat returns true if the chat is the admin chat.
+func (b *Bot) isAdminChat(chat *tgbotapi.Chat) bool {
+	if chat == nil {
+		return false
+	}
+
+	return strconv.FormatInt(chat.ID, 10) == b.chatID ||
+		(chat.UserName != "" && "@"+chat.UserName == b.chatID)
+}
+
+// handle executes the command and replies with its result.
+func (b *Bot) handle(msg *tgbotapi.Message) {
+	timeout := 10 * time.Second
+	if msg.Command() == "ask" {
+		timeout = askTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	hub := sentry.CurrentHub().Clone()
+	defer hub.Flush(2 * time.Second)
+
+	author := ""
+	if msg.From != nil {
+		author = msg.From.UserName
+	}
+
+	var reply string
+	var err error
+	switch msg.Command() {
+	case "mute":
+		reply, err = b.mute(ctx, msg.CommandArguments(), author)
+	case "mutes":
+		reply, err = b.mutes(ctx)
+	case "unmute":
+		reply, err = b.unmute(ctx, msg.CommandArguments())
+	case "model":
+		reply, err = b.model(msg.CommandArguments())
+	case "schedule":
+		reply, err = b.schedule(ctx, msg.CommandArguments())
+	case "status":
+		reply, err = b.jobsStatus()
+	case "pause":
+		reply, err = b.pause(ctx, msg.CommandArguments(), author)
+	case "resume":
+		reply, err = b.resume(ctx, msg.CommandArguments())
+	case "pauses":
+		reply, err = b.pauses(ctx)
+	case "ask":
+		reply, err = b.ask(ctx, msg.CommandArguments())
+	case "flagged":
+		reply, err = b.flagged(ctx)
+	case "approve":
+		reply, err = b.approve(ctx, msg.CommandArguments())
+	case "retract":
+		reply, err = b.retract(ctx, msg.CommandArguments())
+	default:
+		return
+	}
+
+	if err != nil {
+		b.logger.Info("[admin] command failed", "command", msg.Command(), "error", err)
+		reply = err.Error()
+		if !isUsageError(err) {
+			utils.CaptureSentryException("adminCommandError", hub, err)
+		}
+	}
+
+	if _, err := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, reply)); err != nil {
+		e := fmt.Errorf("[admin] failed to send reply: %w", err)
+		b.logger.Error(e.Error())
+		utils.CaptureSentryException("adminReplyError", hub, e)
+	}
+}
+
+// mute creates a new mute rule from the command arguments.
+func (b *Bot) mute(ctx context.Context, args, author string) (string, error) {
+	m, err := parseMute(args, time.Now())
+	if err != nil {
+		return "", err
+	}
+	m.CreatedBy = author
+
+	if err := b.archivist.Entities.Mutes.Create(ctx, m); err != nil {
+		return "", fmt.Errorf("[admin] failed to create mute: %w", err)
+	}
+
+	return fmt.Sprintf("Muted %s %q until %s (id: %s)", m.Kind, m.Value, m.Until.UTC().Format(time.DateTime), m.ID), nil
+}
+
+// mutes lists all active mute rules.
+func (b *Bot) mutes(ctx context.Context) (string, error) {
+	mutes, err := b.archivist.Entities.Mutes.FindActive(ctx, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to find mutes: %w", err)
+	}
+
+	if len(mutes) == 0 {
+		return "No active mutes", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Active mutes:")
+	for _, m := range mutes {
+		sb.WriteString(fmt.Sprintf("\n%s %q until %s (id: %s)", m.Kind, m.Value, m.Until.UTC().Format(time.DateTime), m.ID))
+	}
+
+	return sb.String(), nil
+}
+
+// unmute deletes the mute rule by its ID.
+func (b *Bot) unmute(ctx context.Context, args string) (string, error) {
+	id, err := uuid.Parse(strings.TrimSpace(args))
+	if err != nil {
+		return "", errUnmuteUsage
+	}
+
+	ok, err := b.archivist.Entities.Mutes.Delete(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to delete mute: %w", err)
+	}
+
+	if !ok {
+		return fmt.Sprintf("Mute %s not found", id), nil
+	}
+
+	return fmt.Sprintf("Unmuted %s", id), nil
+}
+
+// model shows the Compose model stats without arguments, overrides the model choice with the model name
+// or returns the automatic choice with "auto".
+func (b *Bot) model(args string) (string, error) {
+	if b.bandit == nil {
+		return "", errModelDisabled
+	}
+
+	switch model := strings.TrimSpace(args); model {
+	case "":
+	case "auto":
+		_ = b.bandit.Override("")
+	default:
+		if err := b.bandit.Override(model); err != nil {
+			return "", errors.Join(errModelUsage, err)
+		}
+	}
+
+	return b.bandit.String(), nil
+}
+
+// parseMute parses `/mute` command arguments: kind, value (can contain spaces) and duration (the last argument).
+func parseMute(args string, now time.Time) (*archivist.Mute, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 3 {
+		return nil, errMuteUsage
+	}
+
+	kind := strings.ToLower(fields[0])
+	if !slices.Contains(archivist.MuteKinds, kind) {
+		return nil, errMuteKind
+	}
+
+	d, err := parseDuration(fields[len(fields)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &archivist.Mute{
+		Kind:  kind,
+		Value: strings.Join(fields[1:len(fields)-1], " "),
+		Until: now.Add(d),
+	}, nil
+}
+
+// parseDuration parses positive duration in time.ParseDuration format with additional support of days (e.g. "2d").
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errDurationFormat
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return 0, errDurationFormat
+		}
+	}
+
+	if d <= 0 {
+		return 0, errDurationFormat
+	}
+
+	return d, nil
+}
+
+// isUsageError returns true if the error is caused by the wrong command arguments.
+func isUsageError(err error) bool {
+	return errors.Is(err, errMuteUsage) ||
+		errors.Is(err, errMuteKind) ||
+		errors.Is(err, errUnmuteUsage) ||
+		errors.Is(err, errDurationFormat) ||
+		errors.Is(err, errModelUsage) ||
+		errors.Is(err, errModelDisabled) ||
+		errors.Is(err, errScheduleUsage) ||
+		errors.Is(err, errScheduleDisabled) ||
+		errors.Is(err, errPauseUsage) ||
+		errors.Is(err, errResumeUsage) ||
+		errors.Is(err, errPauseDisabled) ||
+		errors.Is(err, errAskUsage) ||
+		errors.Is(err, errAskDisabled) ||
+		errors.Is(err, errApproveUsage) ||
+		errors.Is(err, errApproveDisabled) ||
+		errors.Is(err, errRetractUsage) ||
+		errors.Is(err, errRetractDisabled)
+}
@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseMute(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		args    string
+		want    *archivist.Mute
+		wantErr error
+	}{
+		{
+			name: "ticker for days",
+			args: "ticker GME 2d",
+			want: &archivist.Mute{Kind: archivist.MuteTicker, Value: "GME", Until: now.Add(48 * time.Hour)},
+		},
+		{
+			name: "keyword with spaces",
+			args: " Keyword  meme stock 90m",
+			want: &archivist.Mute{Kind: archivist.MuteKeyword, Value: "meme stock", Until: now.Add(90 * time.Minute)},
+		},
+		{
+			name:    "not enough arguments",
+			args:    "ticker GME",
+			wantErr: errMuteUsage,
+		},
+		{
+			name:    "unknown kind",
+			args:    "company GME 2d",
+			wantErr: errMuteKind,
+		},
+		{
+			name:    "invalid duration",
+			args:    "ticker GME forever",
+			wantErr: errDurationFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMute(tt.args, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseMute() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMute() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "hours", s: "6h", want: 6 * time.Hour},
+		{name: "days", s: "3d", want: 72 * time.Hour},
+		{name: "zero", s: "0d", wantErr: true},
+		{name: "negative", s: "-1h", wantErr: true},
+		{name: "invalid days", s: "xd", wantErr: true},
+		{name: "invalid", s: "week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDuration(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_model(t *testing.T) {
+	if _, err := (&Bot{}).model(""); !errors.Is(err, errModelDisabled) {
+		t.Errorf("model() error = %v, want %v", err, errModelDisabled)
+	}
+
+	bandit, err := composer.NewBandit([]composer.Arm{{Model: "gpt-4o-mini", Cost: 1}, {Model: "gpt-4o", Cost: 15}}, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := (&Bot{}).WithBandit(bandit)
+
+	tests := []struct {
+		name    string
+		args    string
+		want    string
+		wantErr error
+	}{
+		{name: "stats", args: "", want: "Compose model: auto (epsilon 0.10)"},
+		{name: "override", args: " gpt-4o ", want: "Compose model: gpt-4o (manual)"},
+		{name: "unknown model", args: "gpt-2", wantErr: errModelUsage},
+		{name: "auto", args: "auto", want: "Compose model: auto (epsilon 0.10)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.model(tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("model() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("model() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"strings"
+	"time"
+)
+
+const (
+	askTimeout    = time.Minute // timeout of the `/ask` command: search, ranking and answer
+	askNewsLimit  = 30          // number of the news found by the full-text search and ranked by relevance
+	askEventLimit = 10          // number of the events found by the full-text search and ranked by relevance
+	askContext    = 10          // number of the most relevant news and events the answer is based on
+)
+
+var (
+	errAskUsage    = errors.New("usage: /ask <question>, e.g. /ask when did we last post about TSMC capex?")
+	errAskDisabled = errors.New("archive questions are not configured")
+)
+
+// WithComposer enables the `/ask` command to answer the questions about the archive of the published news
+// and calendar events, e.g. "when did we last post about TSMC capex?".
+func (b *Bot) WithComposer(c *composer.Composer) *Bot {
+	b.composer = c
+	return b
+}
+
+// ask answers the question from the command arguments. The news and events are found by the full-text search,
+// ranked by the embeddings similarity to the question and the most relevant ones are passed to the composer,
+// which cites the links of the channel posts in the answer.
+func (b *Bot) ask(ctx context.Context, args string) (string, error) {
+	if b.composer == nil {
+		return "", errAskDisabled
+	}
+
+	question := strings.TrimSpace(args)
+	if question == "" {
+		return "", errAskUsage
+	}
+
+	news, err := b.archivist.Entities.News.Search(ctx, question, askNewsLimit)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to search news: %w", err)
+	}
+
+	events, err := b.archivist.Entities.Events.Search(ctx, question, askEventLimit)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to search events: %w", err)
+	}
+
+	headlines := archiveHeadlines(news, events)
+	if len(headlines) == 0 {
+		return "Nothing found in the archive", nil
+	}
+
+	// Full-text search order is good enough if the embeddings are not available
+	ranked, err := b.composer.Rank(ctx, question, headlines, askContext)
+	if err != nil {
+		b.logger.Warn("[admin] failed to rank the archive search results", "error", err)
+		ranked = headlines[:min(askContext, len(headlines))]
+	}
+
+	answer, err := b.composer.Answer(ctx, question, ranked)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to answer: %w", err)
+	}
+
+	return answer, nil
+}
+
+// archiveHeadlines returns the dated headlines of the news with the links to their channel posts and of the events
+// with their values, in the order of the search results.
+func archiveHeadlines(news []*archivist.News, events []*archivist.Event) []*composer.Headline {
+	headlines := make([]*composer.Headline, 0, len(news)+len(events))
+	for _, n := range news {
+		text := n.OriginalTitle
+		if n.ComposedText != "" {
+			text = n.ComposedText
+		}
+		headlines = append(headlines, &composer.Headline{
+			ID:   n.ID.String(),
+			Text: text,
+			Link: fmt.Sprintf("https://t.me/%s/%s", strings.TrimPrefix(n.ChannelID, "@"), n.PublicationID),
+			Date: n.PublishedAt.UTC().Format(time.DateTime),
+		})
+	}
+
+	for _, e := range events {
+		text := fmt.Sprintf("%s %s", e.Currency, e.Title)
+		if e.Actual != "" {
+			text += fmt.Sprintf(": actual %s, forecast %s, previous %s", e.Actual, e.Forecast, e.Previous)
+		}
+		headlines = append(headlines, &composer.Headline{
+			ID:   e.ID.String(),
+			Text: text,
+			Date: e.DateTime.UTC().Format(time.DateTime),
+		})
+	}
+
+	return headlines
+}
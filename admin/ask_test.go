@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBot_ask(t *testing.T) {
+	if _, err := (&Bot{}).ask(context.Background(), "when did we last post about TSMC capex?"); !errors.Is(err, errAskDisabled) {
+		t.Errorf("ask() error = %v, want %v", err, errAskDisabled)
+	}
+
+	b := (&Bot{}).WithComposer(composer.NewSandboxComposer())
+	if _, err := b.ask(context.Background(), "  "); !errors.Is(err, errAskUsage) {
+		t.Errorf("ask() error = %v, want %v", err, errAskUsage)
+	}
+}
+
+func Test_archiveHeadlines(t *testing.T) {
+	newsID, eventID := uuid.New(), uuid.New()
+	date := time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)
+	news := []*archivist.News{{
+		ID:            newsID,
+		ChannelID:     "@channel",
+		PublicationID: "42",
+		OriginalTitle: "TSMC raises capex",
+		ComposedText:  "TSMC raised its capex guidance to $30 billion.",
+		PublishedAt:   date,
+	}}
+	events := []*archivist.Event{{ID: eventID, Title: "CPI y/y", Currency: "USD", DateTime: date, Actual: "3.4%", Forecast: "3.5%", Previous: "3.5%"}}
+
+	want := []*composer.Headline{
+		{ID: newsID.String(), Text: "TSMC raised its capex guidance to $30 billion.", Link: "https://t.me/channel/42", Date: "2024-05-02 14:30:00"},
+		{ID: eventID.String(), Text: "USD CPI y/y: actual 3.4%, forecast 3.5%, previous 3.5%", Date: "2024-05-02 14:30:00"},
+	}
+	if got := archiveHeadlines(news, events); !reflect.DeepEqual(got, want) {
+		t.Errorf("archiveHeadlines() = %+v, want %+v", got, want)
+	}
+}
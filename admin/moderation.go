@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	flaggedPeriod = 7 * 24 * time.Hour // period of the news held for moderation listed by `/flagged`
+	flaggedLimit  = 5                  // number of the most recent news listed by `/flagged` (fits one message)
+)
+
+var (
+	errApproveUsage    = errors.New("usage: /approve <id>, see /flagged for the list of IDs")
+	errApproveDisabled = errors.New("approval is not configured, news are published only by the recovery (REPUBLISH_MAX_AGE)")
+)
+
+// WithApprovals enables the `/approve` command. Approved news are published by the recovery of their jobs,
+// so it must be enabled for the jobs (see jobs.Job.RepublishPending).
+func (b *Bot) WithApprovals() *Bot {
+	b.approvals = true
+	return b
+}
+
+// flagged lists the recent news held for moderation (e.g. with the unverified numbers) with their composed texts.
+func (b *Bot) flagged(ctx context.Context) (string, error) {
+	news, err := b.archivist.Entities.News.FindFlagged(ctx, time.Now().Add(-flaggedPeriod), flaggedLimit)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to find news held for moderation: %w", err)
+	}
+
+	if len(news) == 0 {
+		return "No news held for moderation", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Held for moderation:")
+	for _, n := range news {
+		sb.WriteString(fmt.Sprintf("\n\n%s (id: %s)\nOriginal: %s\nComposed: %s",
+			n.ModerationReason, n.ID, n.OriginalTitle, n.ComposedText))
+	}
+
+	return sb.String(), nil
+}
+
+// approve releases the news held for moderation by its ID, so it's published by the recovery of its job.
+func (b *Bot) approve(ctx context.Context, args string) (string, error) {
+	id, err := uuid.Parse(strings.TrimSpace(args))
+	if err != nil {
+		return "", errApproveUsage
+	}
+	if !b.approvals {
+		return "", errApproveDisabled
+	}
+
+	ok, err := b.archivist.Entities.News.Approve(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to approve news: %w", err)
+	}
+
+	if !ok {
+		return fmt.Sprintf("News %s is not held for moderation", id), nil
+	}
+
+	return fmt.Sprintf("Approved news %s, it will be published by the next recovery run of its job", id), nil
+}
@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestBot_approve(t *testing.T) {
+	for _, args := range []string{"", "42", "not-an-id"} {
+		if _, err := (&Bot{}).approve(context.Background(), args); !errors.Is(err, errApproveUsage) {
+			t.Errorf("approve(%q) error = %v, want %v", args, err, errApproveUsage)
+		}
+	}
+
+	if _, err := (&Bot{}).approve(context.Background(), uuid.NewString()); !errors.Is(err, errApproveDisabled) {
+		t.Errorf("approve() without the recovery error = %v, want %v", err, errApproveDisabled)
+	}
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"slices"
+	"strings"
+	"time"
+)
+
+// pauseAllArg is the `/pause` and `/resume` argument of the global pause.
+const pauseAllArg = "all"
+
+var (
+	errPauseUsage    = errors.New("usage: /pause <job|all> [reason], e.g. /pause market provider incident")
+	errResumeUsage   = errors.New("usage: /resume <job|all>, see /pauses for the paused jobs")
+	errPauseDisabled = errors.New("job pauses are not configured")
+)
+
+// WithPauses enables the `/pause`, `/resume` and `/pauses` commands to halt the runs of the jobs by their keys
+// (e.g. "market") or all jobs at once. Pauses are stored in the database and checked before each job run.
+func (b *Bot) WithPauses(jobs ...string) *Bot {
+	b.jobs = jobs
+	return b
+}
+
+// pause pauses the job (or all jobs) from the command arguments.
+func (b *Bot) pause(ctx context.Context, args, author string) (string, error) {
+	if len(b.jobs) == 0 {
+		return "", errPauseDisabled
+	}
+
+	job, reason, _ := strings.Cut(strings.TrimSpace(args), " ")
+	job, err := b.parsePauseJob(job, errPauseUsage)
+	if err != nil {
+		return "", err
+	}
+
+	p := &archivist.Pause{Job: job, Reason: strings.TrimSpace(reason), CreatedBy: author}
+	if err := b.archivist.Entities.Pauses.Save(ctx, p); err != nil {
+		return "", fmt.Errorf("[admin] failed to save pause: %w", err)
+	}
+
+	return fmt.Sprintf("Paused %s, use /resume %s to continue", pauseName(job), pauseArg(job)), nil
+}
+
+// resume deletes the pause of the job (or the global one) from the command arguments.
+func (b *Bot) resume(ctx context.Context, args string) (string, error) {
+	if len(b.jobs) == 0 {
+		return "", errPauseDisabled
+	}
+
+	job, err := b.parsePauseJob(strings.TrimSpace(args), errResumeUsage)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := b.archivist.Entities.Pauses.Delete(ctx, job)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to delete pause: %w", err)
+	}
+
+	if !ok {
+		return fmt.Sprintf("No pause of %s found", pauseName(job)), nil
+	}
+
+	return fmt.Sprintf("Resumed %s", pauseName(job)), nil
+}
+
+// pauses lists all paused jobs.
+func (b *Bot) pauses(ctx context.Context) (string, error) {
+	if len(b.jobs) == 0 {
+		return "", errPauseDisabled
+	}
+
+	pauses, err := b.archivist.Entities.Pauses.FindAll(ctx)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to find pauses: %w", err)
+	}
+
+	return formatPauses(pauses), nil
+}
+
+// parsePauseJob returns the job key or archivist.PauseAll for the "all" argument.
+// Unknown job keys are rejected, so the typo doesn't leave the job running.
+func (b *Bot) parsePauseJob(arg string, usage error) (string, error) {
+	arg = strings.ToLower(arg)
+	switch {
+	case arg == "":
+		return "", usage
+	case arg == pauseAllArg:
+		return archivist.PauseAll, nil
+	case slices.Contains(b.jobs, arg):
+		return arg, nil
+	default:
+		jobs := slices.Clone(b.jobs)
+		slices.Sort(jobs)
+		return "", errors.Join(usage, fmt.Errorf("unknown job %q, use one of: %s", arg, strings.Join(jobs, ", ")))
+	}
+}
+
+// formatPauses formats the paused jobs for the admin chat.
+func formatPauses(pauses []*archivist.Pause) string {
+	if len(pauses) == 0 {
+		return "No paused jobs"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Paused:")
+	for _, p := range pauses {
+		sb.WriteString(fmt.Sprintf("\n%s since %s", pauseName(p.Job), p.CreatedAt.UTC().Format(time.DateTime)))
+		if p.CreatedBy != "" {
+			sb.WriteString(" by @" + p.CreatedBy)
+		}
+		if p.Reason != "" {
+			sb.WriteString(": " + p.Reason)
+		}
+	}
+
+	return sb.String()
+}
+
+// pauseName returns the human-readable name of the paused job.
+func pauseName(job string) string {
+	if job == archivist.PauseAll {
+		return "all jobs"
+	}
+	return "job " + job
+}
+
+// pauseArg returns the command argument of the paused job.
+func pauseArg(job string) string {
+	if job == archivist.PauseAll {
+		return pauseAllArg
+	}
+	return job
+}
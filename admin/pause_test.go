@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"testing"
+	"time"
+)
+
+func TestBot_parsePauseJob(t *testing.T) {
+	b := &Bot{jobs: []string{"market", "broad", "calendar"}}
+
+	tests := []struct {
+		name    string
+		arg     string
+		want    string
+		wantErr error
+	}{
+		{name: "job", arg: "Market", want: "market"},
+		{name: "all", arg: "all", want: archivist.PauseAll},
+		{name: "empty", arg: "", wantErr: errPauseUsage},
+		{name: "unknown job", arg: "markt", wantErr: errPauseUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.parsePauseJob(tt.arg, errPauseUsage)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("parsePauseJob() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_formatPauses(t *testing.T) {
+	at := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	pauses := []*archivist.Pause{
+		{Job: archivist.PauseAll, CreatedBy: "admin", Reason: "bad feed", CreatedAt: at},
+		{Job: "market", CreatedAt: at.Add(time.Hour)},
+	}
+
+	want := "Paused:\nall jobs since 2024-01-10 12:00:00 by @admin: bad feed\njob market since 2024-01-10 13:00:00"
+	if got := formatPauses(pauses); got != want {
+		t.Errorf("formatPauses() = %q, want %q", got, want)
+	}
+	if got := formatPauses(nil); got != "No paused jobs" {
+		t.Errorf("formatPauses() = %q, want no paused jobs", got)
+	}
+}
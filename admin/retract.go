@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"strings"
+)
+
+// retractionMark is the prefix of the retraction notice of the struck through post.
+const retractionMark = "❌ Retracted: "
+
+var (
+	errRetractUsage    = errors.New("usage: /retract <id> [delete] <reason>, the post is struck through unless \"delete\" is given")
+	errRetractDisabled = errors.New("retraction is not configured")
+)
+
+// WithRetractions enables the `/retract` command for the news published to the channels of the publishers
+// (added to the previous ones).
+func (b *Bot) WithRetractions(publishers ...publisher.RetractPublisher) *Bot {
+	b.retractors = append(b.retractors, publishers...)
+	return b
+}
+
+// retract retracts the published news by its ID: deletes the post (with its chart replies and mirror) with "delete",
+// otherwise strikes its text through with the reason. The photo post whose struck through text doesn't fit
+// the caption is deleted instead. The news is marked retracted in the archive.
+func (b *Bot) retract(ctx context.Context, args string) (string, error) {
+	id, del, reason, err := parseRetract(args)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b.retractors) == 0 {
+		return "", errRetractDisabled
+	}
+
+	n, err := b.archivist.Entities.News.FindPublished(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to find news: %w", err)
+	}
+	if n == nil {
+		return fmt.Sprintf("News %s is not published or already retracted", id), nil
+	}
+
+	p := b.retractor(n.ChannelID)
+	if p == nil {
+		return fmt.Sprintf("News %s is published to %s, which is not managed by the bot", id, n.ChannelID), nil
+	}
+
+	var notes []string
+	if !del {
+		err = p.Edit(n.PublicationID, retraction(n.ComposedText, reason))
+		if errors.Is(err, publisher.ErrCaptionTooLong) {
+			del = true
+			notes = append(notes, "the struck through text doesn't fit the photo caption")
+		}
+	}
+	if del {
+		err = p.Delete(n.PublicationID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to retract news %s: %w", id, err)
+	}
+
+	// Charts sent as the replies to the long text would be left without it
+	if del && n.ReplyPubIDs != "" {
+		for _, replyID := range strings.Split(n.ReplyPubIDs, ",") {
+			if err := p.Delete(replyID); err != nil {
+				b.logger.Info("[admin] failed to delete chart reply", "id", id, "reply", replyID, "error", err)
+				notes = append(notes, fmt.Sprintf("failed to delete the chart reply %s: %s", replyID, err))
+			}
+		}
+	}
+
+	if _, err := b.archivist.Entities.News.Retract(ctx, id, reason); err != nil {
+		return "", fmt.Errorf("[admin] failed to mark news retracted: %w", err)
+	}
+
+	action := "Struck through"
+	if del {
+		action = "Deleted"
+	}
+	reply := fmt.Sprintf("%s news %s in %s", action, id, n.ChannelID)
+	if len(notes) > 0 {
+		reply += fmt.Sprintf(" (%s)", strings.Join(notes, "; "))
+	}
+
+	// The translated text of the mirror post isn't stored, so only the notice is left in place of it
+	if m := b.retractor(n.MirrorChannelID); n.MirrorPubID != "" && m != nil {
+		if del {
+			err = m.Delete(n.MirrorPubID)
+		} else {
+			err = m.Edit(n.MirrorPubID, retraction("", reason))
+		}
+		if err != nil {
+			b.logger.Info("[admin] failed to retract mirror post", "id", id, "error", err)
+			return reply + fmt.Sprintf(", but failed to retract the mirror post: %s", err), nil
+		}
+		reply += " and " + n.MirrorChannelID
+	}
+
+	return reply, nil
+}
+
+// retractor returns the publisher of the channel or nil if the channel is unknown.
+func (b *Bot) retractor(channelID string) publisher.RetractPublisher {
+	for _, p := range b.retractors {
+		if channelID != "" && p.Channel() == channelID {
+			return p
+		}
+	}
+	return nil
+}
+
+// parseRetract parses `/retract` command arguments: news ID, optional "delete" and the reason (can contain spaces).
+func parseRetract(args string) (id uuid.UUID, del bool, reason string, err error) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return uuid.Nil, false, "", errRetractUsage
+	}
+
+	id, err = uuid.Parse(fields[0])
+	if err != nil {
+		return uuid.Nil, false, "", errRetractUsage
+	}
+
+	fields = fields[1:]
+	if strings.EqualFold(fields[0], "delete") {
+		del = true
+		fields = fields[1:]
+	}
+
+	reason = strings.Join(fields, " ")
+	if reason == "" || len(reason) > archivist.RetractionReasonMaxLength {
+		return uuid.Nil, false, "", errRetractUsage
+	}
+
+	return id, del, reason, nil
+}
+
+// retraction returns the retraction notice with the composed text struck through (formatting is dropped).
+func retraction(text, reason string) publisher.Message {
+	msg := publisher.Message{Title: retractionMark + reason}
+	if text == "" {
+		return msg
+	}
+
+	plain := publisher.Markdown(text).PlainText()
+	msg.Body = []publisher.Segment{{
+		Text:     plain,
+		Entities: []publisher.Entity{{Type: publisher.EntityStrike, Offset: 0, Length: len(plain)}},
+	}}
+	return msg
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+)
+
+func Test_parseRetract(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name       string
+		args       string
+		wantDelete bool
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "strike through", args: id.String() + " wrong figures", wantReason: "wrong figures"},
+		{name: "delete", args: id.String() + " DELETE duplicate", wantDelete: true, wantReason: "duplicate"},
+		{name: "no arguments", args: "", wantErr: true},
+		{name: "no reason", args: id.String(), wantErr: true},
+		{name: "delete without reason", args: id.String() + " delete", wantErr: true},
+		{name: "invalid ID", args: "42 duplicate", wantErr: true},
+		{name: "reason too long", args: id.String() + " " + strings.Repeat("duplicate ", 13), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, gotDelete, gotReason, err := parseRetract(tt.args)
+			if tt.wantErr {
+				if !errors.Is(err, errRetractUsage) {
+					t.Errorf("parseRetract() error = %v, want %v", err, errRetractUsage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRetract() error = %v", err)
+			}
+			if gotID != id || gotDelete != tt.wantDelete || gotReason != tt.wantReason {
+				t.Errorf("parseRetract() = %s, %v, %q, want %s, %v, %q", gotID, gotDelete, gotReason, id, tt.wantDelete, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestBot_retract(t *testing.T) {
+	if _, err := (&Bot{}).retract(context.Background(), uuid.NewString()+" duplicate"); !errors.Is(err, errRetractDisabled) {
+		t.Errorf("retract() error = %v, want %v", err, errRetractDisabled)
+	}
+}
+
+func Test_retraction(t *testing.T) {
+	msg := retraction("*AAPL* is up 5%", "wrong figures")
+	if got, want := msg.HTML(), "❌ Retracted: wrong figures\n<s>AAPL is up 5%</s>"; got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+
+	if got, want := retraction("", "duplicate").Markdown(), "❌ Retracted: duplicate"; got != want {
+		t.Errorf("Markdown() of the notice = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPreviewPeriod = 6 * time.Hour
+	maxPreviewRuns       = 1000 // limit of the counted runs of the interval jobs in the preview period
+)
+
+var (
+	errScheduleUsage    = errors.New("usage: /schedule [<period>], e.g. /schedule 12h (6h by default)")
+	errScheduleDisabled = errors.New("schedule preview is not configured")
+)
+
+// scheduledRun is the upcoming run of the scheduled job.
+type scheduledRun struct {
+	name  string    // name of the job
+	next  time.Time // time of the next run
+	count int       // number of runs in the preview period
+}
+
+// WithSchedule enables the `/schedule` command to preview what goes out to the channel in the next hours:
+// upcoming runs of the scheduler jobs, calendar events and the messages waiting in the outbox of the channel.
+func (b *Bot) WithSchedule(s gocron.Scheduler, channelID string) *Bot {
+	b.scheduler = s
+	b.channelID = channelID
+	return b
+}
+
+// WithPublishQueue adds the requests waiting in the publish queue of the channel to the `/schedule` preview.
+func (b *Bot) WithPublishQueue(metrics *publisher.QueueMetrics) *Bot {
+	b.queue = metrics
+	return b
+}
+
+// schedule previews the job runs and calendar events in the period from the command arguments.
+func (b *Bot) schedule(ctx context.Context, args string) (string, error) {
+	if b.scheduler == nil {
+		return "", errScheduleDisabled
+	}
+
+	period := defaultPreviewPeriod
+	if args = strings.TrimSpace(args); args != "" {
+		d, err := parseDuration(args)
+		if err != nil {
+			return "", errors.Join(errScheduleUsage, err)
+		}
+		period = d
+	}
+
+	now := time.Now()
+	until := now.Add(period)
+
+	var runs []scheduledRun
+	for _, j := range b.scheduler.Jobs() {
+		if r, ok := upcomingRun(j, until); ok {
+			runs = append(runs, r)
+		}
+	}
+
+	events, err := b.archivist.Entities.Events.FindUpcoming(ctx, b.channelID, now, until)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to find upcoming events: %w", err)
+	}
+
+	outbox := pendingItems{name: "Outbox", unit: "messages"}
+	outbox.count, outbox.oldest, err = b.archivist.Entities.Outbox.Stats(ctx, b.channelID)
+	if err != nil {
+		return "", fmt.Errorf("[admin] failed to find outbox messages: %w", err)
+	}
+	pending := []pendingItems{outbox}
+	if b.queue != nil {
+		pending = append(pending, pendingItems{
+			name: "Publish queue", unit: "requests", count: b.queue.Depth(), oldest: b.queue.Oldest(),
+		})
+	}
+
+	return formatSchedule(period, runs, events) + formatPending(pending, now), nil
+}
+
+// pendingItems are the items waiting for publication, e.g. the messages queued to the outbox while Telegram was down.
+type pendingItems struct {
+	name   string    // name of the queue
+	unit   string    // name of the items in plural
+	count  int64     // number of the waiting items
+	oldest time.Time // time the oldest item was queued at
+}
+
+// formatPending formats the items waiting for publication for the admin chat.
+func formatPending(pending []pendingItems, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("\n\nPending publication:")
+	for _, p := range pending {
+		if p.count == 0 {
+			sb.WriteString(fmt.Sprintf("\n%s: empty", p.name))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n%s: %d %s", p.name, p.count, p.unit))
+		if !p.oldest.IsZero() {
+			sb.WriteString(fmt.Sprintf(", oldest %s ago", now.Sub(p.oldest).Round(time.Second)))
+		}
+	}
+
+	return sb.String()
+}
+
+// upcomingRun returns the next run of the job and the number of its runs until the given date.
+// Returns false if the job doesn't run until the date.
+func upcomingRun(j gocron.Job, until time.Time) (scheduledRun, bool) {
+	next, err := j.NextRuns(maxPreviewRuns)
+	if err != nil || len(next) == 0 || next[0].After(until) {
+		return scheduledRun{}, false
+	}
+
+	count := 0
+	for _, t := range next {
+		if t.After(until) {
+			break
+		}
+		count++
+	}
+
+	return scheduledRun{name: strings.TrimPrefix(j.Name(), "scheduler for "), next: next[0], count: count}, true
+}
+
+// formatSchedule formats the upcoming job runs (sorted by the next run) and calendar events for the admin chat.
+func formatSchedule(period time.Duration, runs []scheduledRun, events []*archivist.Event) string {
+	slices.SortStableFunc(runs, func(a, b scheduledRun) int {
+		return a.next.Compare(b.next)
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Scheduled in the next %s (UTC):", period))
+
+	if len(runs) == 0 {
+		sb.WriteString("\nNo job runs")
+	}
+	for _, r := range runs {
+		sb.WriteString(fmt.Sprintf("\n%s %s", r.next.UTC().Format(time.DateTime), r.name))
+		if r.count > 1 {
+			sb.WriteString(fmt.Sprintf(" (%d runs)", r.count))
+		}
+	}
+
+	sb.WriteString("\n\nCalendar events:")
+	if len(events) == 0 {
+		sb.WriteString("\nNo events")
+	}
+	for _, e := range events {
+		sb.WriteString(fmt.Sprintf("\n%s %s %s (%s)", e.DateTime.UTC().Format(time.DateTime), e.Currency, e.Title, e.Impact))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/samgozman/fin-thread/archivist"
+	"testing"
+	"time"
+)
+
+func TestBot_schedule(t *testing.T) {
+	if _, err := (&Bot{}).schedule(context.Background(), ""); !errors.Is(err, errScheduleDisabled) {
+		t.Errorf("schedule() error = %v, want %v", err, errScheduleDisabled)
+	}
+
+	s, err := gocron.NewScheduler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Shutdown() }()
+	b := (&Bot{}).WithSchedule(s, "@channel")
+	if _, err := b.schedule(context.Background(), "soon"); !errors.Is(err, errScheduleUsage) {
+		t.Errorf("schedule() error = %v, want %v", err, errScheduleUsage)
+	}
+}
+
+func Test_upcomingRun(t *testing.T) {
+	s, err := gocron.NewScheduler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Shutdown() }()
+
+	j, err := s.NewJob(gocron.DurationJob(time.Minute), gocron.NewTask(func() {}), gocron.WithName("scheduler for Market news"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	r, ok := upcomingRun(j, time.Now().Add(10*time.Minute+30*time.Second))
+	if !ok || r.name != "Market news" || r.count != 10 {
+		t.Errorf("upcomingRun() = %+v, %v, want 10 runs of Market news", r, ok)
+	}
+
+	if _, ok := upcomingRun(j, time.Now().Add(30*time.Second)); ok {
+		t.Errorf("upcomingRun() = true, want false for the period without runs")
+	}
+}
+
+func Test_formatPending(t *testing.T) {
+	now := time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC)
+	pending := []pendingItems{
+		{name: "Outbox", unit: "messages", count: 3, oldest: now.Add(-12 * time.Minute)},
+		{name: "Publish queue", unit: "requests"},
+	}
+
+	want := "\n\nPending publication:\nOutbox: 3 messages, oldest 12m0s ago\nPublish queue: empty"
+	if got := formatPending(pending, now); got != want {
+		t.Errorf("formatPending() = %q, want %q", got, want)
+	}
+}
+
+func Test_formatSchedule(t *testing.T) {
+	at := time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC)
+	runs := []scheduledRun{
+		{name: "Market news", next: at.Add(time.Minute), count: 360},
+		{name: "Before Market Open summary job", next: at.Add(time.Hour), count: 1},
+		{name: "Calendar updates", next: at.Add(30 * time.Second), count: 240},
+	}
+	events := []*archivist.Event{
+		{DateTime: at.Add(90 * time.Minute), Currency: "USD", Title: "Core CPI m/m", Impact: "high"},
+	}
+
+	want := `Scheduled in the next 6h0m0s (UTC):
+2024-01-02 13:00:30 Calendar updates (240 runs)
+2024-01-02 13:01:00 Market news (360 runs)
+2024-01-02 14:00:00 Before Market Open summary job
+
+Calendar events:
+2024-01-02 14:30:00 USD Core CPI m/m (high)`
+	if got := formatSchedule(6*time.Hour, runs, events); got != want {
+		t.Errorf("formatSchedule() = %q, want %q", got, want)
+	}
+
+	want = "Scheduled in the next 1h0m0s (UTC):\nNo job runs\n\nCalendar events:\nNo events"
+	if got := formatSchedule(time.Hour, nil, nil); got != want {
+		t.Errorf("formatSchedule() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/jobs"
+	"strings"
+	"time"
+)
+
+var errStatusDisabled = errors.New("jobs status is not configured")
+
+// statusReporter reports the status of the scheduled jobs (e.g. jobs.ScheduleMonitor).
+type statusReporter interface {
+	Status() []jobs.JobStatus
+}
+
+// WithStatus enables the `/status` command to show the next run and the result of the last run of the scheduled jobs.
+func (b *Bot) WithStatus(r statusReporter) *Bot {
+	b.status = r
+	return b
+}
+
+// jobsStatus returns the status of the scheduled jobs.
+func (b *Bot) jobsStatus() (string, error) {
+	if b.status == nil {
+		return "", errStatusDisabled
+	}
+	return formatStatus(b.status.Status()), nil
+}
+
+// formatStatus formats the jobs status (sorted by the next run) for the admin chat.
+func formatStatus(statuses []jobs.JobStatus) string {
+	var sb strings.Builder
+	sb.WriteString("Jobs status (UTC):")
+	if len(statuses) == 0 {
+		sb.WriteString("\nNo scheduled jobs")
+	}
+
+	for _, s := range statuses {
+		sb.WriteString(fmt.Sprintf("\n%s: next %s", s.Name, s.NextRun.UTC().Format(time.DateTime)))
+		if s.Overdue {
+			sb.WriteString(" (overdue)")
+		}
+		if s.Running {
+			sb.WriteString(", running")
+		}
+
+		if s.Result == jobs.RunPending {
+			sb.WriteString(", didn't run yet")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(", last %s at %s in %s", s.Result, s.LastRun.UTC().Format(time.DateTime), s.Duration.Round(time.Millisecond)))
+		if s.Error != "" {
+			sb.WriteString(": " + s.Error)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/jobs"
+	"testing"
+	"time"
+)
+
+func TestBot_jobsStatus(t *testing.T) {
+	if _, err := (&Bot{}).jobsStatus(); !errors.Is(err, errStatusDisabled) {
+		t.Errorf("jobsStatus() error = %v, want %v", err, errStatusDisabled)
+	}
+}
+
+func Test_formatStatus(t *testing.T) {
+	at := time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC)
+	statuses := []jobs.JobStatus{
+		{Name: "Market news", NextRun: at, Overdue: true, Running: true, LastRun: at.Add(-time.Minute), Duration: 1234567 * time.Microsecond, Result: jobs.RunOK},
+		{Name: "Calendar", NextRun: at.Add(time.Hour), LastRun: at.Add(-23 * time.Hour), Duration: 2 * time.Second, Result: jobs.RunFailed, Error: "fetch failed"},
+		{Name: "Recap", NextRun: at.Add(8 * time.Hour), Result: jobs.RunPending},
+	}
+
+	want := `Jobs status (UTC):
+Market news: next 2024-01-02 13:00:00 (overdue), running, last ok at 2024-01-02 12:59:00 in 1.235s
+Calendar: next 2024-01-02 14:00:00, last failed at 2024-01-01 14:00:00 in 2s: fetch failed
+Recap: next 2024-01-02 21:00:00, didn't run yet`
+	if got := formatStatus(statuses); got != want {
+		t.Errorf("formatStatus() = %q, want %q", got, want)
+	}
+
+	if got, want := formatStatus(nil), "Jobs status (UTC):\nNo scheduled jobs"; got != want {
+		t.Errorf("formatStatus() = %q, want %q", got, want)
+	}
+}
@@ -6,206 +6,813 @@ import (
 	"github.com/avast/retry-go"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-co-op/gocron/v2"
+	"github.com/samgozman/fin-thread/admin"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/alert"
+	"github.com/samgozman/fin-thread/internal/startup"
 	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/internal/web"
 	"github.com/samgozman/fin-thread/jobs"
 	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/narrator"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"github.com/samgozman/fin-thread/publisher"
 	"github.com/samgozman/fin-thread/scavenger"
+	"github.com/samgozman/fin-thread/scavenger/cache"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"io"
 	"log/slog"
+	"os"
+	"slices"
 	"time"
 )
 
 type App struct {
-	cnf *Config // App configuration
+	cnf     *Config               // App configuration
+	monitor *jobs.ScheduleMonitor // Monitor of the scheduled jobs run times
+	pauses  *jobs.PauseGuard      // Guard of the jobs paused in the database
+	once    *runOnce              // Single run of the job instead of scheduling them (see runJob)
 }
 
-func (a *App) start() {
-	telegramPublisher, err := publisher.NewTelegramPublisher(
-		a.cnf.env.TelegramChannelID,
-		a.cnf.env.TelegramBotToken,
-		a.cnf.env.ShouldPublish,
-	)
+// start starts the components with retries and schedules the jobs, then blocks forever.
+// With App.once it runs the requested job of the main channel once instead and returns its error.
+// It returns the *startup.Error if a required component failed to start. Optional components (data sources, cache,
+// sector, summary, mirror and tenant channels, admin chat) are skipped with Env.StartupDegraded,
+// otherwise they are required too.
+func (a *App) start() error {
+	a.setupAlerts()
+	orch := startup.NewOrchestrator(a.cnf.startup.attempts, a.cnf.startup.delay, a.cnf.env.StartupDegraded)
+
+	chatID := a.cnf.env.TelegramChannelID
+	if a.once != nil && a.once.channel != "" {
+		chatID = a.once.channel
+	}
+
+	var telegramPublisher *publisher.TelegramPublisher
+	err := orch.Required("publisher", func() (err error) {
+		telegramPublisher, err = a.newPublisher(chatID)
+		return err
+	})
 	if err != nil {
-		slog.Default().Error("[main] Error creating Telegram telegramPublisher:", err)
-		panic(err)
+		return err
 	}
 
-	archivistEntity, err := archivist.NewArchivist(a.cnf.env.PostgresDSN)
+	var archivistEntity *archivist.Archivist
+	rollback := func() error { return nil }
+	defer func() { _ = rollback() }()
+	err = orch.Required("archivist", func() (err error) {
+		if a.once != nil && a.once.dryRun {
+			archivistEntity, rollback, err = archivist.NewDryRunArchivist(a.cnf.env.PostgresDSN)
+			return err
+		}
+		archivistEntity, err = archivist.NewArchivist(a.cnf.env.PostgresDSN)
+		return err
+	})
 	if err != nil {
-		slog.Default().Error("[main] Error creating Archivist:", err)
-		panic(err)
+		return err
 	}
 
 	composerEntity := composer.NewComposer(a.cnf.env.OpenAiToken, a.cnf.env.TogetherAIToken, a.cnf.env.GoogleGeminiToken)
+	if a.cnf.env.Sandbox {
+		composerEntity = composer.NewSandboxComposer()
+	}
+	composerEntity = composerEntity.With(
+		composer.UseMaxComposedLength(a.cnf.composeMaxLength),
+		composer.UseGlossary(a.cnf.glossary),
+		composer.UseScrubbing(a.cnf.scrubProviders...),
+		composer.UseModelRoutes(a.cnf.modelRegistry, a.cnf.env.TelegramChannelID),
+	).WithBandit(a.cnf.composeBandit)
+
+	// Narrator of the audio brief (OpenAI API is not available in the sandbox mode)
+	var narratorEntity *narrator.Narrator
+	if a.cnf.env.SummaryVoice != "" && !a.cnf.env.Sandbox {
+		narratorEntity, err = narrator.NewNarrator(a.cnf.env.OpenAiToken, a.cnf.env.SummaryVoice)
+		if err != nil {
+			return &startup.Error{Component: "narrator", Err: err}
+		}
+	}
 
 	marketJournalist := journalist.NewJournalist("MarketNews", a.cnf.rssProviders.marketJournalists).
 		FlagByKeys(a.cnf.suspiciousKeywords).
+		TruncateDescriptions(a.cnf.descMaxLength).
+		IdentifyBy(a.cnf.newsIDStrategy).
 		Limit(2)
 
 	broadNews := journalist.NewJournalist("BroadNews", a.cnf.rssProviders.broadJournalists).
 		FlagByKeys(a.cnf.suspiciousKeywords).
+		TruncateDescriptions(a.cnf.descMaxLength).
+		IdentifyBy(a.cnf.newsIDStrategy).
 		Limit(1)
 
-	// get all stockMap and pass as a parameter to jobs
-	scv := scavenger.Scavenger{}
-	var stockMap *stocks.StockMap
-	err = retry.Do(func() error {
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		stockMap, err = scv.Screener.FetchFromNasdaq(ctx)
-		return fmt.Errorf("error fetching stockMap: %w", err)
-	}, retry.Attempts(2), retry.Delay(5*time.Second))
+	// Journalists are shared by the channels, each channel decides whether to publish the rating changes
+	if len(a.cnf.ratingChanges) > 0 {
+		marketJournalist.DetectRatingChanges()
+		broadNews.DetectRatingChanges()
+	}
+
+	scv, err := scavenger.NewScavenger(a.cnf.scavengers...)
 	if err != nil {
-		slog.Default().Error("[main] Error fetching stockMap:", err)
+		return &startup.Error{Component: "scavenger", Err: err}
+	}
 
+	var c cache.Cache = cache.NewMemory()
+	if a.cnf.env.CacheRedisURL != "" {
+		// The in-memory cache is used if Redis is skipped
+		_, err = orch.Optional("cache", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			r, err := cache.NewRedis(ctx, a.cnf.env.CacheRedisURL)
+			if err != nil {
+				return err
+			}
+			c = r
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	scv.WithCache(c, nil)
+	if i := scv.Insider(); i != nil && a.cnf.env.SECUserAgent != "" {
+		i.SetUserAgent(a.cnf.env.SECUserAgent)
+	}
+
+	// Jobs of the skipped sources are not scheduled (e.g. calendar runs without the stocks screener)
+	for _, source := range scv.Sources() {
+		ok, err := orch.Optional("scavenger/"+source.Name(), func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			return source.Init(ctx)
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			scv.Remove(source.Name())
+		}
+	}
+
+	// get all stockMap and pass as a parameter to jobs
+	var stockMap *stocks.StockMap
+	if screener := scv.Screener(); screener != nil {
+		err = retry.Do(func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			stockMap, err = screener.FetchFromNasdaq(ctx)
+			if err != nil {
+				return fmt.Errorf("error fetching stockMap: %w", err)
+			}
+			return nil
+		}, retry.Attempts(2), retry.Delay(5*time.Second))
+		if err != nil {
+			slog.Default().Error("[main] Error fetching stockMap:", "error", err)
+		}
+	}
+	if stockMap == nil {
 		// TODO: Find a reliable API source for this sorts of data
 		// try to fill the gaps with static data
-		stockMap = scv.Screener.FetchFromString(a.cnf.env.StockSymbols)
+		stockMap = new(stocks.Screener).FetchFromString(a.cnf.env.StockSymbols)
 		if stockMap == nil {
 			slog.Default().Error("[main] Error fetching stockMap from env")
 		}
 	}
 
-	marketJob := jobs.NewJob(composerEntity, telegramPublisher, archivistEntity, marketJournalist, stockMap).
+	// Listed tickers are refreshed by the weekly listings job (only if the screener is enabled)
+	var universe *stocks.Universe
+	if scv.Screener() != nil {
+		universe = stocks.NewUniverse(stockMap)
+	}
+
+	// The run redirected to another chat doesn't publish to the other production channels
+	sectorChannels, summaryChannels, mirrorChannelID := a.cnf.sectorChannels, a.cnf.summaryChannels, a.cnf.env.MirrorChannelID
+	slackWebhookURL := a.cnf.env.SlackWebhookURL
+	if a.once != nil && a.once.channel != "" {
+		sectorChannels, summaryChannels, mirrorChannelID, slackWebhookURL = nil, nil, "", ""
+	}
+
+	sectorPublishers := make(map[string]publisher.Publisher, len(sectorChannels))
+	for sector, chatID := range sectorChannels {
+		_, err = orch.Optional("sector channel "+sector, func() error {
+			p, err := a.newPublisher(chatID)
+			if err != nil {
+				return err
+			}
+			sectorPublishers[sector] = p
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	summaryPublishers := make([]publisher.Publisher, 0, len(summaryChannels))
+	for _, chatID := range summaryChannels {
+		_, err = orch.Optional("summary channel "+chatID, func() error {
+			p, err := a.newPublisher(chatID)
+			if err != nil {
+				return err
+			}
+			summaryPublishers = append(summaryPublishers, p)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var mirrorPublisher *publisher.TelegramPublisher
+	if mirrorChannelID != "" {
+		_, err = orch.Optional("mirror channel "+mirrorChannelID, func() (err error) {
+			mirrorPublisher, err = a.newPublisher(mirrorChannelID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Other targets of the market and broad news, published after the main channel
+	var targets []publisher.Publisher
+	if slackWebhookURL != "" {
+		targets = append(targets, publisher.NewSlackPublisher(slackWebhookURL, a.cnf.env.SlackChannel))
+	}
+
+	// Sentry hub for fatal errors
+	hub := sentry.CurrentHub().Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelFatal)
+	})
+	defer hub.Flush(2 * time.Second)
+	defer hub.Recover(nil)
+
+	s, err := gocron.NewScheduler()
+	if err != nil {
+		return &startup.Error{Component: "scheduler", Err: err}
+	}
+
+	// Jobs paused by the admin (globally or one by one) skip their runs until resumed
+	a.pauses = jobs.NewPauseGuard(archivistEntity.Entities.Pauses)
+
+	// Monitor of the scheduled vs actual run times, it also catches up the missed runs of a.cnf.catchUpJobs
+	a.monitor = jobs.NewScheduleMonitor(a.cnf.scheduleTolerance)
+	_, err = s.NewJob(
+		a.cnf.schedule("schedule-monitor"),
+		gocron.NewTask(a.monitor.Run()),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		gocron.WithName("scheduler for Schedule monitor"),
+	)
+	if err != nil {
+		return &startup.Error{Component: "scheduler", Err: fmt.Errorf("error scheduling job for Schedule monitor: %w", err)}
+	}
+
+	// News pipeline of the main channel, it also cross-posts to the sector, summary and mirror channels
+	shared := &pipeline{
+		composer:         composerEntity,
+		marketJournalist: marketJournalist,
+		broadJournalist:  broadNews,
+		scavenger:        scv,
+		stockMap:         stockMap,
+		universe:         universe,
+		narrator:         narratorEntity,
+	}
+	err = a.scheduleChannel(s, shared, &channel{
+		publisher:         telegramPublisher,
+		archivist:         archivistEntity,
+		sectorPublishers:  sectorPublishers,
+		summaryPublishers: summaryPublishers,
+		mirrorPublisher:   mirrorPublisher,
+		targets:           targets,
+		permalinkBaseURL:  a.cnf.env.WebBaseURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Clicks of the main channel news tracked by the web server shift the Compose traffic between the models
+	if a.cnf.composeBandit != nil && a.cnf.env.WebAddr != "" && a.cnf.env.LinkSecret != "" {
+		engagementJob := jobs.NewEngagementJob(archivistEntity, a.cnf.composeBandit, telegramPublisher.Channel())
+		err = a.scheduleJob(s, "Compose model engagement", "engagement", engagementJob.Run())
+		if err != nil {
+			return err
+		}
+	}
+
+	// `fin-thread run` doesn't need the tenant channels, admin bot and web server
+	if a.once != nil {
+		return a.once.run()
+	}
+
+	// Tenant channels run the same pipeline, but store news, events and summaries to their own database (or schema)
+	for chatID, dsn := range a.cnf.tenants {
+		var tenant *channel
+		ok, err := orch.Optional("tenant "+chatID, func() error {
+			tenantPublisher, err := a.newPublisher(chatID)
+			if err != nil {
+				return fmt.Errorf("error creating Telegram tenant publisher: %w", err)
+			}
+			tenantArchivist, err := archivist.NewArchivist(dsn)
+			if err != nil {
+				return fmt.Errorf("error creating tenant Archivist: %w", err)
+			}
+			tenant = &channel{publisher: tenantPublisher, archivist: tenantArchivist}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := a.scheduleChannel(s, shared, tenant); err != nil {
+			return err
+		}
+	}
+
+	// Watchdog job to alert admin about silent failures
+	var adminPublisher *publisher.TelegramPublisher
+	if a.cnf.env.AdminChatID != "" {
+		_, err = orch.Optional("admin chat", func() (err error) {
+			adminPublisher, err = a.newPublisher(a.cnf.env.AdminChatID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if adminPublisher != nil {
+		watchdogJob := jobs.NewWatchdogJob(adminPublisher, archivistEntity).
+			AlertOnSilence(a.cnf.watchdog.silencePeriod).
+			AlertOnFilterRate(a.cnf.watchdog.filterRateThreshold).
+			AlertOnProviderVolume(time.Hour, a.cnf.watchdog.volumeSpike)
+		err = a.scheduleJob(s, "Watchdog", "watchdog", watchdogJob.Run())
+		if err != nil {
+			return err
+		}
+
+		statsJob := jobs.NewStatsJob(adminPublisher, archivistEntity).
+			WithComposerMetrics(composerEntity.Metrics).
+			WithPublishQueueMetrics(telegramPublisher.Queue.Metrics())
+		err = a.scheduleJob(s, "Stats", "stats", statsJob.Run())
+		if err != nil {
+			return err
+		}
+
+		// Admin bot to manage runtime mute rules (Telegram API is not available in the sandbox mode)
+		if !a.cnf.env.Sandbox {
+			adminBot := admin.NewBot(adminPublisher.BotAPI, a.cnf.env.AdminChatID, archivistEntity).
+				WithBandit(a.cnf.composeBandit).
+				WithComposer(composerEntity).
+				WithSchedule(s, telegramPublisher.ChannelID).
+				WithPublishQueue(telegramPublisher.Queue.Metrics()).
+				WithStatus(a.monitor).
+				WithPauses(a.pausableJobs()...).
+				WithRetractions(telegramPublisher)
+			if mirrorPublisher != nil {
+				adminBot.WithRetractions(mirrorPublisher)
+			}
+			if a.cnf.republishMaxAge > 0 {
+				adminBot.WithApprovals()
+			}
+			go func() {
+				if err := adminBot.Run(); err != nil {
+					slog.Default().Error("[main] Error running admin bot:", "error", err)
+					utils.CaptureSentryException("adminBotError", hub, err)
+				}
+			}()
+			defer adminBot.Stop()
+		}
+	}
+
+	// Web server with the pages of the published news of the main channel and the click-tracking redirects
+	if a.cnf.env.WebAddr != "" {
+		webServer := web.NewServer(a.cnf.env.WebAddr, a.cnf.env.WebBaseURL, archivistEntity.Entities.News).
+			WithHealth(a.monitor)
+		if a.cnf.env.LinkSecret != "" {
+			webServer.WithRedirects([]byte(a.cnf.env.LinkSecret), archivistEntity.Entities.Clicks)
+		}
+		go func() {
+			if err := webServer.Run(); err != nil {
+				slog.Default().Error("[main] Error running web server:", "error", err)
+				utils.CaptureSentryException("webServerError", hub, err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = webServer.Shutdown(ctx)
+		}()
+	}
+
+	defer func(s gocron.Scheduler) {
+		if err := s.Shutdown(); err != nil {
+			slog.Default().Error("[main] Error shutting down scheduler:", "error", err)
+		}
+	}(s)
+	s.Start()
+
+	if err := orch.Skipped(); err != nil {
+		slog.Default().Warn("[main] Started fin-thread in the degraded mode", "error", err)
+		utils.CaptureSentryException("startupDegraded", hub, errlvl.Wrap(err, errlvl.ERROR))
+	}
+
+	slog.Default().Info("Started fin-thread successfully")
+	select {}
+}
+
+// pipeline holds the dependencies shared by the news pipelines of all channels.
+type pipeline struct {
+	composer         *composer.Composer
+	marketJournalist *journalist.Journalist
+	broadJournalist  *journalist.Journalist
+	scavenger        *scavenger.Scavenger
+	stockMap         *stocks.StockMap
+	universe         *stocks.Universe   // Listed tickers refreshed by the listings job (optional)
+	narrator         *narrator.Narrator // Narrator of the audio brief (optional)
+}
+
+// channel is the Telegram channel (tenant) with its own archivist the news pipeline jobs are scheduled for.
+type channel struct {
+	publisher         *publisher.TelegramPublisher
+	archivist         *archivist.Archivist
+	sectorPublishers  map[string]publisher.Publisher // Sector channels for the news cross-posting (optional)
+	summaryPublishers []publisher.Publisher          // Channels for the before market open summary (channel itself if empty)
+	mirrorPublisher   *publisher.TelegramPublisher   // Channel where the news are mirrored in Env.MirrorLanguage (optional)
+	targets           []publisher.Publisher          // Other targets of the market and broad news, e.g. Slack (optional)
+	permalinkBaseURL  string                         // Public base URL of the web server with the news pages (optional)
+}
+
+// scheduleChannel schedules the news, calendar, summary, recap, follow-up, listings, insider, recovery and outbox jobs
+// of the channel.
+func (a *App) scheduleChannel(s gocron.Scheduler, p *pipeline, ch *channel) error {
+	// Models routed for the channel (e.g. fine-tuned on its style) replace the ones of the main channel
+	channelComposer := p.composer.With(composer.UseModelRoutes(a.cnf.modelRegistry, ch.publisher.Channel()))
+
+	// The news are published to the other targets only after the channel itself (see publisher.MultiPublisher)
+	var newsPublisher publisher.Publisher = ch.publisher
+	if len(ch.targets) > 0 {
+		newsPublisher = publisher.NewMultiPublisher(ch.publisher, ch.targets...)
+	}
+
+	marketJob := jobs.NewJob(channelComposer.WithExamples(a.cnf.examples["market"]), newsPublisher, ch.archivist, p.marketJournalist, p.stockMap).
 		FetchUntil(time.Now().Add(-60 * time.Second)).
 		OmitSuspicious().
 		OmitIfAllKeysEmpty().
 		OmitUnlistedStocks().
 		RemoveClones().
 		ComposeText().
+		AttachCharts(p.scavenger.Quotes()).
+		SeparateETFs(a.cnf.etfs).
+		ListConstituents(3).
+		Watchlist(a.cnf.watchlist...).
+		ProviderTrust(a.cnf.providerTrust).
+		TagRules(a.cnf.tagRules...).
+		RouteSectors(ch.sectorPublishers).
+		Timeout(a.cnf.jobTimeouts["market"]).
 		SaveToDB()
 
-	broadJob := jobs.NewJob(composerEntity, telegramPublisher, archivistEntity, broadNews, stockMap).
+	broadJob := jobs.NewJob(channelComposer.WithExamples(a.cnf.examples["broad"]), newsPublisher, ch.archivist, p.broadJournalist, p.stockMap).
 		FetchUntil(time.Now().Add(-4 * time.Minute)).
 		OmitSuspicious().
 		OmitEmptyMeta(jobs.MetaTickers).
 		OmitUnlistedStocks().
+		OmitBelowMarketCap(a.cnf.broadMinMarketCap).
+		SeparateETFs(a.cnf.etfs).
 		RemoveClones().
 		ComposeText().
+		SelectBeforeCompose(5).
+		Watchlist(a.cnf.watchlist...).
+		ProviderTrust(a.cnf.providerTrust).
+		TagRules(a.cnf.tagRules...).
+		RouteSectors(ch.sectorPublishers).
+		Timeout(a.cnf.jobTimeouts["broad"]).
 		SaveToDB()
 
-	// Sentry hub for fatal errors
-	hub := sentry.CurrentHub().Clone()
-	hub.ConfigureScope(func(scope *sentry.Scope) {
-		scope.SetLevel(sentry.LevelFatal)
-	})
-	defer hub.Flush(2 * time.Second)
-	defer hub.Recover(nil)
+	if len(a.cnf.stockCountries) > 0 {
+		marketJob.DemoteForeignStocks(a.cnf.stockCountries...)
+		broadJob.OmitForeignStocks(a.cnf.stockCountries...)
+	}
 
-	s, err := gocron.NewScheduler()
-	if err != nil {
-		hub.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error creating scheduler",
-			Level:    sentry.LevelFatal,
-		}, nil)
-		utils.CaptureSentryException("createSchedulerError", hub, err)
-		panic(err)
+	if len(a.cnf.shadowFilter) > 0 {
+		marketJob.ShadowFilter(a.cnf.shadowFilter...)
+		broadJob.ShadowFilter(a.cnf.shadowFilter...)
 	}
 
-	_, err = s.NewJob(
-		gocron.DurationJob(60*time.Second),
-		gocron.NewTask(marketJob.Run()),
-		gocron.WithSingletonMode(gocron.LimitModeReschedule), // for often jobs
-		gocron.WithName("scheduler for Market news"),
-	)
+	// Fetch windows continue from the last successful run, the FetchUntil overlap is kept between the windows
+	marketJob.ResumeFromCheckpoint(60*time.Second, time.Hour)
+	broadJob.ResumeFromCheckpoint(4*time.Minute, time.Hour)
 
-	if err != nil {
-		hub.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Market news",
-			Level:    sentry.LevelFatal,
-		}, nil)
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+	if ch.mirrorPublisher != nil {
+		marketJob.MirrorTranslation(ch.mirrorPublisher, a.cnf.env.MirrorLanguage)
+		broadJob.MirrorTranslation(ch.mirrorPublisher, a.cnf.env.MirrorLanguage)
 	}
 
-	_, err = s.NewJob(
-		gocron.DurationJob(4*time.Minute),
-		gocron.NewTask(broadJob.Run()),
-		gocron.WithName("scheduler for Broad market news"),
-	)
-	if err != nil {
-		hub.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Broad news",
-			Level:    sentry.LevelFatal,
-		}, nil)
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
-	}
-
-	// Calendar job
-	calJob := jobs.NewCalendarJob(
-		scv.EconomicCalendar,
-		telegramPublisher,
-		archivistEntity,
-		"mql5-calendar",
-	)
+	if ch.permalinkBaseURL != "" {
+		marketJob.AppendPermalinks(ch.permalinkBaseURL)
+		broadJob.AppendPermalinks(ch.permalinkBaseURL)
+	}
 
-	_, err = s.NewJob(
-		gocron.CronJob("0 4 * * 1-5", false), // every weekday at 4:00 UTC
-		gocron.NewTask(calJob.RunDailyCalendarJob()),
-		gocron.WithName("scheduler for Calendar"),
+	marketJob.AddButtons(a.cnf.newsButtons["market"]...)
+	broadJob.AddButtons(a.cnf.newsButtons["broad"]...)
+
+	if w := p.scavenger.Wayback(); w != nil {
+		marketJob.ArchiveLinks(w)
+		broadJob.ArchiveLinks(w)
+	}
+
+	if p.universe != nil {
+		marketJob.UseUniverse(p.universe)
+		broadJob.UseUniverse(p.universe)
+	}
+
+	if include, ok := a.cnf.includeRatingChanges(ch.publisher.ChannelID); ok {
+		if include {
+			marketJob.IncludeRatingChanges()
+			broadJob.IncludeRatingChanges()
+		} else {
+			marketJob.OmitRatingChanges()
+			broadJob.OmitRatingChanges()
+		}
+	}
+
+	if d := a.cnf.linkDecorator(ch.publisher.ChannelID); d != nil {
+		marketJob.DecorateLinks(d)
+		broadJob.DecorateLinks(d)
+	}
+
+	if a.cnf.outboxMaxAge > 0 {
+		marketJob.QueueWhenOffline()
+		broadJob.QueueWhenOffline()
+	}
+
+	// Transient AI and Telegram errors are retried within the stage deadlines of the run
+	marketJob.RetryStages(a.cnf.stageAttempts, 2*time.Second)
+	broadJob.RetryStages(a.cnf.stageAttempts, 2*time.Second)
+
+	if a.cnf.republishMaxAge > 0 {
+		marketJob.RepublishPending(a.cnf.republishMaxAge)
+		broadJob.RepublishPending(a.cnf.republishMaxAge)
+	}
+
+	if a.cnf.storyGap > 0 {
+		marketJob.TrackStories(a.cnf.storyGap)
+		broadJob.TrackStories(a.cnf.storyGap)
+	}
+
+	if a.cnf.env.VerifyNumbers {
+		marketJob.VerifyNumbers()
+		broadJob.VerifyNumbers()
+	}
+
+	if profile, ok := a.cnf.complianceProfile(ch.publisher.ChannelID); ok {
+		marketJob.UseCompliance(profile)
+		broadJob.UseCompliance(profile)
+	}
+
+	if a.cnf.recentTexts > 0 {
+		marketJob.AvoidRepetition(a.cnf.recentTexts, 24*time.Hour)
+		broadJob.AvoidRepetition(a.cnf.recentTexts, 24*time.Hour)
+	}
+
+	if a.once != nil && !a.once.from.IsZero() {
+		marketJob.FetchWindow(a.once.from, a.once.to)
+		broadJob.FetchWindow(a.once.from, a.once.to)
+	}
+
+	for _, job := range []*jobs.Job{marketJob, broadJob} {
+		if err := job.Validate(); err != nil {
+			return &startup.Error{Component: "jobs", Err: err}
+		}
+	}
+
+	err := a.scheduleJob(s, "Market news", "market", marketJob.Run(),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule), // for often jobs
 	)
 	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Calendar",
-			Level:    sentry.LevelFatal,
-		})
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+		return err
 	}
 
-	_, err = s.NewJob(
-		gocron.DurationJob(90*time.Second),
-		gocron.NewTask(calJob.RunCalendarUpdatesJob()),
-		gocron.WithName("scheduler for Calendar updates"),
-	)
+	err = a.scheduleJob(s, "Broad market news", "broad", broadJob.Run())
 	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Calendar updates",
-			Level:    sentry.LevelFatal,
-		})
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+		return err
+	}
+
+	// Calendar jobs (only if the economic calendar scavenger is enabled)
+	if calendar := p.scavenger.EconomicCalendar(); calendar != nil {
+		calJob := jobs.NewCalendarJob(
+			calendar,
+			ch.publisher,
+			ch.archivist,
+			ecal.SourceName,
+		).OnlyCountries(a.cnf.calendarCountries...).
+			PublishPolls(a.cnf.calendarPolls).
+			WithTheme(a.cnf.theme)
+		if a.cnf.env.FXQuotes {
+			calJob.AppendFXPairs(a.cnf.fxThreshold, p.scavenger.Quotes())
+		} else {
+			calJob.AppendFXPairs(a.cnf.fxThreshold, nil)
+		}
+		if a.cnf.env.EventTitlesAI {
+			calJob.NormalizeTitles(a.cnf.eventTitles, channelComposer)
+		} else {
+			calJob.NormalizeTitles(a.cnf.eventTitles, nil)
+		}
+		if a.cnf.env.CalendarEditPlan {
+			calJob.EditPlan()
+		}
+
+		err = a.scheduleJob(s, "Calendar", "calendar", calJob.RunDailyCalendarJob())
+		if err != nil {
+			return err
+		}
+
+		err = a.scheduleJob(s, "Calendar updates", "calendar-updates", calJob.RunCalendarUpdatesJob())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Week ahead preview job (only if any of the calendar scavengers is enabled)
+	if p.scavenger.EconomicCalendar() != nil || p.scavenger.CorporateCalendar() != nil {
+		weekAheadJob := jobs.NewWeekAheadJob(p.scavenger.EconomicCalendar(), p.scavenger.CorporateCalendar(), ch.publisher).
+			OnlyCountries(a.cnf.calendarCountries...)
+		err = a.scheduleJob(s, "Week ahead", "week-ahead", weekAheadJob.Run())
+		if err != nil {
+			return err
+		}
 	}
 
 	// Before market open job
 	bmoJob := jobs.NewSummaryJob(
-		composerEntity,
-		telegramPublisher,
-		archivistEntity,
-	)
-	_, err = s.NewJob(
-		// TODO: Use holidays calendar to avoid unnecessary runs
-		gocron.CronJob("0 14 * * 1-5", false), // every weekday at 14:00 UTC (market opens at 14:30 UTC)
-		gocron.NewTask(bmoJob.Run(time.Now().Truncate(24*time.Hour))),
-		gocron.WithName("scheduler for Before Market Open summary job"),
+		channelComposer,
+		ch.publisher,
+		ch.archivist,
+	).PublishTo(ch.summaryPublishers...)
+	if p.narrator != nil {
+		bmoJob.WithVoice(p.narrator)
+	}
+	// TODO: Use holidays calendar to avoid unnecessary runs
+	err = a.scheduleJob(s, "Before Market Open summary job", "summary", bmoJob.Run(time.Now().Truncate(24*time.Hour)))
+	if err != nil {
+		return err
+	}
+
+	// Post-market recap job (index closes are skipped if quotes are disabled)
+	recapJob := jobs.NewRecapJob(
+		channelComposer,
+		ch.publisher,
+		ch.archivist,
+		p.scavenger.Quotes(),
 	)
+	err = a.scheduleJob(s, "Post-market recap job", "recap", recapJob.Run())
 	if err != nil {
-		sentry.AddBreadcrumb(&sentry.Breadcrumb{
-			Category: "scheduler",
-			Message:  "Error scheduling job for Before Market Open",
-			Level:    sentry.LevelFatal,
-		})
-		utils.CaptureSentryException("createScheduleJobError", hub, err)
-		panic(err)
+		return err
 	}
 
-	defer func(s gocron.Scheduler) {
-		err := s.Shutdown()
+	// Follow-up job to reply with the ticker reaction to the published news (only if quotes are enabled)
+	if quotes := p.scavenger.Quotes(); quotes != nil {
+		followUpJob := jobs.NewFollowUpJob(quotes, ch.publisher, ch.archivist)
+		err = a.scheduleJob(s, "Follow-up", "follow-up", followUpJob.Run())
 		if err != nil {
-			panic(err)
+			return err
 		}
-	}(s)
-	s.Start()
+	}
 
-	slog.Default().Info("Started fin-thread successfully")
-	select {}
+	// Weekly new listings and delistings (only if the screener is enabled)
+	if screener := p.scavenger.Screener(); screener != nil {
+		listingsJob := jobs.NewListingsJob(screener, ch.publisher, ch.archivist, p.universe)
+		err = a.scheduleJob(s, "Listings", "listings", listingsJob.Run())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Weekly insider trades of the watchlist (only if the SEC insider source is enabled)
+	if i := p.scavenger.Insider(); i != nil && len(a.cnf.watchlist) > 0 {
+		insiderJob := jobs.NewInsiderJob(i, ch.publisher, ch.archivist, a.cnf.watchlist).
+			MinValue(a.cnf.insiderMinValue)
+		err = a.scheduleJob(s, "Insider trades", "insider", insiderJob.Run())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Recovery of the composed news saved, but never published by the news jobs
+	if a.cnf.republishMaxAge > 0 {
+		err = a.scheduleJob(s, "Market news recovery", "recovery-market", marketJob.Recover())
+		if err != nil {
+			return err
+		}
+
+		err = a.scheduleJob(s, "Broad market news recovery", "recovery-broad", broadJob.Recover())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Replay of the news queued while Telegram was unreachable
+	if a.cnf.outboxMaxAge > 0 {
+		outboxJob := jobs.NewOutboxJob(ch.publisher, ch.archivist, a.cnf.outboxMaxAge)
+		err = a.scheduleJob(s, "Outbox", "outbox", outboxJob.Run())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduleJob schedules the job function by the schedule of the job key with the "scheduler for <name>" name.
+// Run times of the job are watched by App.monitor, runs of the paused jobs are skipped by App.pauses.
+// The error is returned as *startup.Error. With App.once the job is not scheduled, the requested one is kept to run.
+func (a *App) scheduleJob(s gocron.Scheduler, name, key string, fn jobs.JobFunc, options ...gocron.JobOption) error {
+	if a.once != nil {
+		a.once.capture(key, fn)
+		return nil
+	}
+
+	fn = a.pauses.Guard(key, fn)
+	fn, err := a.monitor.Watch(name, a.cnf.schedules[key], slices.Contains(a.cnf.catchUpJobs, key), fn)
+	if err != nil {
+		return &startup.Error{Component: "scheduler", Err: err}
+	}
+
+	options = append(options, gocron.WithName("scheduler for "+name))
+	if _, err := s.NewJob(a.cnf.schedule(key), gocron.NewTask(fn), options...); err != nil {
+		return &startup.Error{Component: "scheduler", Err: fmt.Errorf("error scheduling job for %s: %w", name, err)}
+	}
+	return nil
+}
+
+// pausableJobs returns the keys of the jobs scheduled by App.scheduleJob, which can be paused by the admin.
+func (a *App) pausableJobs() []string {
+	keys := make([]string, 0, len(a.cnf.schedules))
+	for key := range a.cnf.schedules {
+		if key != "schedule-monitor" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// setupAlerts sends the captured errors of the configured level to the admin chat and webhook, if alerts are enabled.
+func (a *App) setupAlerts() {
+	if a.cnf.alert.level == "" {
+		return
+	}
+
+	var sinks []alert.Sink
+	if a.cnf.env.AdminChatID != "" {
+		p, err := a.newPublisher(a.cnf.env.AdminChatID)
+		if err != nil {
+			slog.Default().Error("[main] Error creating Telegram alerts publisher:", "error", err)
+		} else {
+			sinks = append(sinks, alert.SinkFunc(func(_ context.Context, al alert.Alert) error {
+				_, err := p.Publish(publisher.Text(al.String()))
+				return err
+			}))
+		}
+	}
+	if a.cnf.env.AlertWebhookURL != "" {
+		sinks = append(sinks, &alert.Webhook{URL: a.cnf.env.AlertWebhookURL})
+	}
+	if len(sinks) == 0 {
+		slog.Default().Warn("[main] Alerts are enabled, but neither admin chat nor webhook is set")
+		return
+	}
+
+	utils.SetErrorNotifier(alert.NewNotifier(a.cnf.alert.level, a.cnf.alert.window, sinks...))
+}
+
+// newPublisher creates a new TelegramPublisher for the given chat.
+// In the sandbox mode (or the dry run of App.once) messages are written to the console or to the Env.SandboxOutput file instead.
+func (a *App) newPublisher(chatID string) (*publisher.TelegramPublisher, error) {
+	if !a.cnf.env.Sandbox && (a.once == nil || !a.once.dryRun) {
+		p, err := publisher.NewTelegramPublisher(chatID, a.cnf.env.TelegramBotToken, a.cnf.env.ShouldPublish)
+		if err != nil {
+			return nil, err
+		}
+		if a.cnf.env.VerifyPublish {
+			p.Verifier = publisher.NewVerifier()
+		}
+		p.ParseMode = a.cnf.parseMode(chatID)
+		p.Queue = publisher.NewQueue(a.cnf.publishInterval)
+		p.Footer = a.cnf.disclaimer(chatID)
+		return p, nil
+	}
+
+	out := io.Writer(os.Stdout)
+	if a.cnf.env.SandboxOutput != "" {
+		f, err := os.OpenFile(a.cnf.env.SandboxOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("error opening sandbox output: %w", err)
+		}
+		out = f
+	}
+
+	p := publisher.NewSandboxPublisher(chatID, out)
+	p.ParseMode = a.cnf.parseMode(chatID)
+	p.Footer = a.cnf.disclaimer(chatID)
+	return p, nil
 }
@@ -0,0 +1,70 @@
+package archivist
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type CheckpointsDB struct {
+	Conn *gorm.DB
+}
+
+func NewCheckpointsDB(db *gorm.DB) *CheckpointsDB {
+	return &CheckpointsDB{Conn: db}
+}
+
+// Checkpoint is the persisted progress of the job, e.g. the end of the fetch window of its last successful run,
+// so the next run (also after restart) continues from it.
+type Checkpoint struct {
+	Name      string    `gorm:"primaryKey;size:128;not null" json:"name"` // Name of the job
+	Time      time.Time `gorm:"not null" json:"time"`                     // Checkpoint time
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+func (c *Checkpoint) Validate() error {
+	if c.Name == "" {
+		return newError(errlvl.INFO, errCheckpointNameEmpty, nil)
+	}
+
+	if len(c.Name) > 128 {
+		return newError(errlvl.INFO, errCheckpointNameTooLong, nil)
+	}
+
+	return nil
+}
+
+// Get returns the checkpoint by its name or nil if it doesn't exist.
+func (db *CheckpointsDB) Get(ctx context.Context, name string) (*Checkpoint, error) {
+	var c Checkpoint
+	res := db.Conn.WithContext(ctx).Where("name = ?", name).Take(&c)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errCheckpointFind, res.Error)
+	}
+
+	return &c, nil
+}
+
+// Save creates or moves the checkpoint to the given time.
+func (db *CheckpointsDB) Save(ctx context.Context, name string, t time.Time) error {
+	c := &Checkpoint{Name: name, Time: t, UpdatedAt: time.Now()}
+	if err := c.Validate(); err != nil {
+		return newError(errlvl.INFO, errCheckpointValidation, err)
+	}
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"time", "updated_at"}),
+	}).Create(c)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errCheckpointSave, res.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package archivist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckpoint_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint Checkpoint
+		wantErr    error
+	}{
+		{name: "valid", checkpoint: Checkpoint{Name: "Run.MarketNews"}},
+		{name: "empty name", checkpoint: Checkpoint{}, wantErr: errCheckpointNameEmpty},
+		{name: "long name", checkpoint: Checkpoint{Name: strings.Repeat("a", 129)}, wantErr: errCheckpointNameTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.checkpoint.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
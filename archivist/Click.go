@@ -0,0 +1,102 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ClicksDB struct {
+	Conn *gorm.DB
+}
+
+func NewClicksDB(db *gorm.DB) *ClicksDB {
+	return &ClicksDB{Conn: db}
+}
+
+// Click is the click on the post link recorded by the click-tracking redirect of the web server,
+// so the engagement can be measured per post, channel and news provider.
+type Click struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	NewsID    uuid.UUID `gorm:"type:uuid;index;not null" json:"news_id"` // ID of the news (News.ID)
+	ChannelID string    `gorm:"size:64;index" json:"channel_id"`         // ID of the channel (chat ID in Telegram)
+	Provider  string    `gorm:"size:64;index" json:"provider"`           // Name of the news provider
+	URL       string    `gorm:"size:1024" json:"url"`                    // Target URL of the link
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (c *Click) Validate() error {
+	if c.NewsID == uuid.Nil {
+		return newError(errlvl.INFO, errClickNewsIDEmpty, nil)
+	}
+
+	if len(c.ChannelID) > 64 || len(c.Provider) > 64 || len(c.URL) > 1024 {
+		return newError(errlvl.INFO, errClickTooLong, nil)
+	}
+
+	return nil
+}
+
+// Create records the click.
+func (db *ClicksDB) Create(ctx context.Context, c *Click) error {
+	if err := c.Validate(); err != nil {
+		return newError(errlvl.INFO, errClickValidation, err)
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+
+	res := db.Conn.WithContext(ctx).Create(c)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errClickCreation, res.Error)
+	}
+
+	return nil
+}
+
+// CountByNews returns the number of clicks of each news by its ID. News without clicks are not in the map.
+func (db *ClicksDB) CountByNews(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		NewsID uuid.UUID
+		Clicks int64
+	}
+	res := db.Conn.WithContext(ctx).
+		Model(&Click{}).
+		Select("news_id, COUNT(*) AS clicks").
+		Where("news_id IN ?", ids).
+		Group("news_id").
+		Scan(&rows)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errClickFind, res.Error)
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, r := range rows {
+		counts[r.NewsID] = r.Clicks
+	}
+	return counts, nil
+}
+
+// FindClickedNews finds the IDs of the news of the channel which links were clicked at least minClicks times
+// since the provided date. Clicks of all channels, tenants included, are recorded in the main database.
+func (db *ClicksDB) FindClickedNews(ctx context.Context, channelID string, since time.Time, minClicks int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	res := db.Conn.WithContext(ctx).
+		Model(&Click{}).
+		Where("channel_id = ?", channelID).
+		Where("created_at >= ?", since).
+		Group("news_id").
+		Having("COUNT(*) >= ?", minClicks).
+		Pluck("news_id", &ids)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errClickFind, res.Error)
+	}
+
+	return ids, nil
+}
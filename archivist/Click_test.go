@@ -0,0 +1,28 @@
+package archivist
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+)
+
+func TestClick_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		click   Click
+		wantErr error
+	}{
+		{name: "valid", click: Click{NewsID: uuid.New(), ChannelID: "@channel", Provider: "Reuters", URL: "https://example.com"}},
+		{name: "empty news ID", click: Click{ChannelID: "@channel"}, wantErr: errClickNewsIDEmpty},
+		{name: "long URL", click: Click{NewsID: uuid.New(), URL: strings.Repeat("a", 1025)}, wantErr: errClickTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.click.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,7 +7,11 @@ import (
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"github.com/samgozman/fin-thread/scavenger/ecal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type EventsDB struct {
@@ -21,19 +25,27 @@ func NewEventsDB(db *gorm.DB) *EventsDB {
 }
 
 type Event struct {
-	ID           uuid.UUID                     `gorm:"primaryKey;type:uuid;not null;" json:"id"` // ID of the event (UUID)
-	ChannelID    string                        `gorm:"size:64" json:"channel_id"`                // ID of the channel (chat ID in Telegram)
-	ProviderName string                        `gorm:"size:64" json:"provider_name"`             // Name of the provider (e.g. "mql5")
-	Title        string                        `gorm:"size:256" json:"title"`                    // Event title
-	DateTime     time.Time                     `gorm:"not null" json:"date_time"`                // Event date and time
-	Country      ecal.EconomicCalendarCountry  `gorm:"size:32" json:"country"`                   // Country of the event
-	Currency     ecal.EconomicCalendarCurrency `gorm:"size:10" json:"currency"`                  // Currency impacted by the event
-	Impact       ecal.EconomicCalendarImpact   `gorm:"size:10" json:"impact"`                    // Impact of the event on the market
-	Actual       string                        `gorm:"size:64" json:"actual"`                    // Actual value of the event (if available)
-	Forecast     string                        `gorm:"size:64" json:"forecast"`                  // Forecasted value of the event (if available)
-	Previous     string                        `gorm:"size:64" json:"previous"`                  // Previous value of the event (if available)
-	CreatedAt    time.Time                     `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
-	UpdatedAt    time.Time                     `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	ID                uuid.UUID                      `gorm:"primaryKey;type:uuid;not null;" json:"id"`                                                     // ID of the event (UUID)
+	ChannelID         string                         `gorm:"size:64;uniqueIndex:idx_events_natural_key" json:"channel_id"`                                 // ID of the channel (chat ID in Telegram)
+	ProviderName      string                         `gorm:"size:64" json:"provider_name"`                                                                 // Name of the provider (e.g. "mql5")
+	ProviderID        string                         `gorm:"size:64;index" json:"provider_id"`                                                             // ID of the event in the provider (empty if unknown)
+	Title             string                         `gorm:"size:256;uniqueIndex:idx_events_natural_key" json:"title"`                                     // Event title
+	DateTime          time.Time                      `gorm:"not null;uniqueIndex:idx_events_natural_key;index:idx_events_pending_actual" json:"date_time"` // Event date and time
+	Country           ecal.EconomicCalendarCountry   `gorm:"size:32" json:"country"`                                                                       // Country of the event
+	Currency          ecal.EconomicCalendarCurrency  `gorm:"size:10;uniqueIndex:idx_events_natural_key" json:"currency"`                                   // Currency impacted by the event
+	Impact            ecal.EconomicCalendarImpact    `gorm:"size:10;index:idx_events_pending_actual" json:"impact"`                                        // Impact of the event on the market
+	Actual            string                         `gorm:"size:64;index:idx_events_pending_actual" json:"actual"`                                        // Actual value of the event (if available)
+	Forecast          string                         `gorm:"size:64" json:"forecast"`                                                                      // Forecasted value of the event (if available)
+	Previous          string                         `gorm:"size:64" json:"previous"`                                                                      // Previous value of the event (if available)
+	SeriesKey         string                         `gorm:"size:300;index" json:"series_key"`                                                             // Key of the recurring indicator series (see SeriesKey)
+	EventType         ecal.EconomicCalendarEventType `gorm:"size:16" json:"event_type"`                                                                    // Type of the event (e.g. indicator or speech)
+	AllDay            bool                           `gorm:"default:false" json:"all_day"`                                                                 // Event takes the whole day (DateTime has no meaningful time)
+	Tentative         bool                           `gorm:"default:false" json:"tentative"`                                                               // Event time is not announced yet (DateTime has no meaningful time)
+	PollID            string                         `gorm:"size:64" json:"poll_id"`                                                                       // ID of the forecast poll publication (message ID in Telegram)
+	PlanID            string                         `gorm:"size:64;index" json:"plan_id"`                                                                 // ID of the daily plan publication listing the event (message ID in Telegram)
+	PublishedActualAt *time.Time                     `json:"published_actual_at"`                                                                          // Time when the actual value was announced in the channel (nil if not yet)
+	CreatedAt         time.Time                      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt         time.Time                      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
 }
 
 func (e *Event) Validate() error {
@@ -49,6 +61,10 @@ func (e *Event) Validate() error {
 		return newError(errlvl.INFO, errTitleTooLong, nil)
 	}
 
+	if len(e.ProviderID) > 64 {
+		return newError(errlvl.INFO, errProviderIDTooLong, nil)
+	}
+
 	return nil
 }
 
@@ -57,6 +73,10 @@ func (e *Event) BeforeCreate(_ *gorm.DB) error {
 		e.ID = uuid.New()
 	}
 
+	if e.SeriesKey == "" {
+		e.SeriesKey = SeriesKey(e.Title, e.Country)
+	}
+
 	if err := e.Validate(); err != nil {
 		return newError(errlvl.INFO, errEventValidation, err)
 	}
@@ -80,12 +100,159 @@ func (e *Event) ToHeadline() *composer.Headline {
 	}
 }
 
-func (edb *EventsDB) Create(ctx context.Context, e []*Event) error {
-	res := edb.Conn.WithContext(ctx).Create(e)
+// eventsNaturalKey is the list of columns that identify the same event published to the same channel.
+var eventsNaturalKey = []clause.Column{{Name: "title"}, {Name: "date_time"}, {Name: "currency"}, {Name: "channel_id"}}
+
+// Create saves the events. Events that already exist (by title, date_time, currency and channel_id)
+// are updated instead, so the same event fetched by different calendar jobs is stored only once.
+// Actual value of the existing event is never overwritten, provider and plan IDs are kept if the new ones are unknown.
+// Event.ID of the provided events is set to the ID of the stored row.
+func (edb *EventsDB) Create(ctx context.Context, events []*Event) error {
+	e := distinctEvents(events)
+	if len(e) == 0 {
+		return nil
+	}
+
+	updates := clause.AssignmentColumns([]string{
+		"provider_name", "country", "impact", "forecast", "previous",
+		"event_type", "all_day", "tentative", "updated_at",
+	})
+	updates = append(updates, clause.Assignment{
+		Column: clause.Column{Name: "provider_id"},
+		Value:  gorm.Expr("COALESCE(NULLIF(excluded.provider_id, ''), events.provider_id)"),
+	}, clause.Assignment{
+		Column: clause.Column{Name: "plan_id"},
+		Value:  gorm.Expr("COALESCE(NULLIF(excluded.plan_id, ''), events.plan_id)"),
+	})
+
+	// Existing rows keep their IDs, so the IDs are returned to replace the generated ones
+	res := edb.Conn.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   eventsNaturalKey,
+			DoUpdates: updates,
+		}, clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Create(e)
 	if res.Error != nil {
 		return newError(errlvl.ERROR, errEventCreation, res.Error)
 	}
 
+	// Duplicates removed from the batch get the ID of the stored event too
+	ids := make(map[eventKey]uuid.UUID, len(e))
+	for _, v := range e {
+		ids[naturalKey(v)] = v.ID
+	}
+	for _, v := range events {
+		v.ID = ids[naturalKey(v)]
+	}
+
+	return nil
+}
+
+// BulkCreate saves the events with multi-row inserts of bulkBatchSize rows, e.g. for the backfill of the calendar history.
+// Unlike Create, events that already exist (by title, date_time, currency and channel_id) are skipped, not updated.
+// Returns the number of inserted events. The batches are inserted in one transaction, nothing is inserted
+// if any of the events is invalid.
+func (edb *EventsDB) BulkCreate(ctx context.Context, e []*Event) (int64, error) {
+	if len(e) == 0 {
+		return 0, nil
+	}
+
+	res := edb.Conn.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: eventsNaturalKey, DoNothing: true}).
+		CreateInBatches(e, bulkBatchSize)
+	if res.Error != nil {
+		return 0, newError(errlvl.ERROR, errEventCreation, res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
+
+// SetPollID sets Event.PollID of the stored event with the same natural key (title, date_time, currency and channel_id).
+func (edb *EventsDB) SetPollID(ctx context.Context, e *Event, pollID string) error {
+	res := edb.Conn.WithContext(ctx).
+		Model(&Event{}).
+		Where("title = ? AND date_time = ? AND currency = ? AND channel_id = ?", e.Title, e.DateTime, e.Currency, e.ChannelID).
+		Update("poll_id", pollID)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errEventUpdate, res.Error)
+	}
+
+	return nil
+}
+
+// FindPlan finds the events of the channel listed in the daily plan publication with the given ID (see Event.PlanID),
+// sorted by date in ascending order.
+func (edb *EventsDB) FindPlan(ctx context.Context, channelID, planID string) ([]*Event, error) {
+	var events []*Event
+	res := edb.Conn.WithContext(ctx).
+		Where("channel_id = ? AND plan_id = ?", channelID, planID).
+		Order("date_time ASC").
+		Find(&events)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errFindPlanEvents, res.Error)
+	}
+
+	return events, nil
+}
+
+// MarkActualPublished sets Event.PublishedActualAt of the event if it's not set yet.
+// Returns false if the actual value of the event was already announced (e.g. by the retried or concurrent run).
+func (edb *EventsDB) MarkActualPublished(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := edb.Conn.WithContext(ctx).
+		Model(&Event{}).
+		Where("id = ? AND published_actual_at IS NULL", id).
+		Update("published_actual_at", at)
+	if res.Error != nil {
+		return false, newError(errlvl.ERROR, errEventUpdate, res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// eventKey is the natural key of the event (see eventsNaturalKey).
+type eventKey struct {
+	title, currency, channelID string
+	dateTime                   time.Time
+}
+
+func naturalKey(e *Event) eventKey {
+	return eventKey{title: e.Title, currency: e.Currency, channelID: e.ChannelID, dateTime: e.DateTime.UTC()}
+}
+
+// distinctEvents removes events with the same natural key from the batch (the last one wins),
+// because a single upsert statement can't affect the same row twice.
+func distinctEvents(events []*Event) []*Event {
+	index := make(map[eventKey]int, len(events))
+	result := make([]*Event, 0, len(events))
+	for _, e := range events {
+		k := naturalKey(e)
+		if i, ok := index[k]; ok {
+			result[i] = e
+			continue
+		}
+		index[k] = len(result)
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// removeDuplicateEvents deletes already stored duplicates (keeping the first one)
+// so the unique index on the events natural key can be created. It's a one-off migration:
+// once the index exists, there are no duplicates to remove.
+func removeDuplicateEvents(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Event{}) || db.Migrator().HasIndex(&Event{}, "idx_events_natural_key") {
+		return nil
+	}
+
+	res := db.Exec(`DELETE FROM events a USING events b
+		WHERE a.title = b.title AND a.date_time = b.date_time
+		AND a.currency = b.currency AND a.channel_id = b.channel_id
+		AND a.ctid > b.ctid`)
+	if res.Error != nil {
+		return newError(errlvl.FATAL, errEventsDeduplication, res.Error)
+	}
+
 	return nil
 }
 
@@ -99,7 +266,8 @@ func (edb *EventsDB) Update(ctx context.Context, e *Event) error {
 }
 
 // FindRecentEventsWithoutValue finds events without Event.Actual value from the start of the day.
-// Also, it filters out events with Event.Impact = None and Event.Impact = Holiday (e.g. no impact events).
+// Also, it filters out events with Event.Impact = None and Event.Impact = Holiday (e.g. no impact events)
+// and events that never have values (e.g. speeches, see ecal.TypesWithoutValues).
 func (edb *EventsDB) FindRecentEventsWithoutValue(ctx context.Context) ([]*Event, error) {
 	var events []*Event
 	res := edb.
@@ -108,6 +276,7 @@ func (edb *EventsDB) FindRecentEventsWithoutValue(ctx context.Context) ([]*Event
 		Where("date_time >= ?", time.Now().UTC().Truncate(24*time.Hour)).
 		Where("impact NOT IN ?", []ecal.EconomicCalendarImpact{ecal.EconomicCalendarImpactNone, ecal.EconomicCalendarImpactHoliday}).
 		Where("actual = ?", "").
+		Where("event_type IS NULL OR event_type NOT IN ?", ecal.TypesWithoutValues()).
 		Find(&events)
 
 	if res.Error != nil {
@@ -117,6 +286,62 @@ func (edb *EventsDB) FindRecentEventsWithoutValue(ctx context.Context) ([]*Event
 	return events, nil
 }
 
+// FindSeries finds the last `limit` readings (events with Event.Actual value) of the recurring indicator
+// with the provided SeriesKey published to the channel until the provided date.
+// Readings are sorted by date in ascending order.
+func (edb *EventsDB) FindSeries(ctx context.Context, channelID, seriesKey string, until time.Time, limit int) ([]*Event, error) {
+	var events []*Event
+	res := edb.Conn.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Where("series_key = ?", seriesKey).
+		Where("date_time <= ?", until).
+		Where("actual != ?", "").
+		Order("date_time DESC").
+		Limit(limit).
+		Find(&events)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errFindEventSeries, res.Error)
+	}
+
+	slices.Reverse(events)
+
+	return events, nil
+}
+
+// SeriesKey returns the key of the recurring indicator series by its normalized title and country,
+// e.g. "united states:core cpi m m" for "Core CPI m/m".
+func SeriesKey(title string, country ecal.EconomicCalendarCountry) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.ToLower(country) + ":" + strings.Join(words, " ")
+}
+
+// backfillSeriesKeys sets Event.SeriesKey for the events created before the series were introduced.
+// Keys are never empty, so only the first run after the upgrade finds events to update (one statement per batch).
+func backfillSeriesKeys(db *gorm.DB) error {
+	var events []*Event
+	res := db.Model(&Event{}).
+		Select("id", "title", "country").
+		Where("series_key IS NULL OR series_key = ?", "").
+		FindInBatches(&events, 500, func(tx *gorm.DB, _ int) error {
+			values := make([]string, len(events))
+			args := make([]any, 0, 2*len(events))
+			for i, e := range events {
+				values[i] = "(?::uuid, ?)"
+				args = append(args, e.ID, SeriesKey(e.Title, e.Country))
+			}
+			return tx.Exec(`UPDATE events SET series_key = v.key
+				FROM (VALUES `+strings.Join(values, ", ")+`) AS v(id, key) WHERE events.id = v.id`, args...).Error
+		})
+	if res.Error != nil {
+		return newError(errlvl.FATAL, errFailedMigration, res.Error)
+	}
+
+	return nil
+}
+
 // FindAllUntilDate finds all events between time.Now until the provided date.
 func (edb *EventsDB) FindAllUntilDate(ctx context.Context, until time.Time) ([]*Event, error) {
 	var events []*Event
@@ -131,3 +356,41 @@ func (edb *EventsDB) FindAllUntilDate(ctx context.Context, until time.Time) ([]*
 
 	return events, nil
 }
+
+// Search finds up to the limit of the events matching any word of the query (Postgres full-text search
+// of the title), the best matches first.
+func (edb *EventsDB) Search(ctx context.Context, query string, limit int) ([]*Event, error) {
+	document := "to_tsvector('english', title)"
+
+	var events []*Event
+	res := edb.Conn.WithContext(ctx).
+		Where(document+" @@ "+anyWordQuery, query).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(" + document + ", " + anyWordQuery + ") DESC, date_time DESC",
+			Vars: []any{query},
+		}}).
+		Limit(limit).
+		Find(&events)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errEventsSearch, res.Error)
+	}
+
+	return events, nil
+}
+
+// FindUpcoming finds the events of the channel between the provided dates (without Event.Impact = None),
+// sorted by date in ascending order.
+func (edb *EventsDB) FindUpcoming(ctx context.Context, channelID string, from, until time.Time) ([]*Event, error) {
+	var events []*Event
+	res := edb.Conn.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Where("date_time BETWEEN ? AND ?", from, until).
+		Where("impact != ?", ecal.EconomicCalendarImpactNone).
+		Order("date_time ASC").
+		Find(&events)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errFindUpcomingEvents, res.Error)
+	}
+
+	return events, nil
+}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvent_BeforeCreate(t *testing.T) {
@@ -152,6 +153,16 @@ func TestEvent_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid event with long ProviderID",
+			fields: Event{
+				ChannelID:    "testChannel",
+				ProviderName: "testProvider",
+				ProviderID:   strings.Repeat("1", 65),
+				Title:        "testTitle",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -189,3 +200,59 @@ func TestNewEventsDB(t *testing.T) {
 		})
 	}
 }
+
+func Test_distinctEvents(t *testing.T) {
+	dt := time.Date(2023, time.April, 10, 12, 30, 0, 0, time.UTC)
+	cpi := &Event{ChannelID: "channel", Title: "CPI", DateTime: dt, Currency: "USD", Forecast: "2.9%"}
+	cpiUpdated := &Event{ChannelID: "channel", Title: "CPI", DateTime: dt.In(time.FixedZone("EST", -5*60*60)), Currency: "USD", Forecast: "3.0%"}
+	cpiOtherChannel := &Event{ChannelID: "other", Title: "CPI", DateTime: dt, Currency: "USD"}
+	ppi := &Event{ChannelID: "channel", Title: "PPI", DateTime: dt, Currency: "USD"}
+
+	tests := []struct {
+		name   string
+		events []*Event
+		want   []*Event
+	}{
+		{
+			name:   "no duplicates",
+			events: []*Event{cpi, cpiOtherChannel, ppi},
+			want:   []*Event{cpi, cpiOtherChannel, ppi},
+		},
+		{
+			name:   "the last duplicate wins and keeps the position",
+			events: []*Event{cpi, ppi, cpiUpdated},
+			want:   []*Event{cpiUpdated, ppi},
+		},
+		{
+			name:   "empty",
+			events: nil,
+			want:   []*Event{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := distinctEvents(tt.events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("distinctEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		title   string
+		country string
+		want    string
+	}{
+		{title: "Core CPI m/m", country: "United States", want: "united states:core cpi m m"},
+		{title: "  Core  CPI M/M ", country: "United States", want: "united states:core cpi m m"},
+		{title: "ZEW Economic Sentiment", country: "Germany", want: "germany:zew economic sentiment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := SeriesKey(tt.title, tt.country); got != tt.want {
+				t.Errorf("SeriesKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
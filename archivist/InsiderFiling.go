@@ -0,0 +1,95 @@
+package archivist
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type InsiderFilingsDB struct {
+	Conn *gorm.DB
+}
+
+func NewInsiderFilingsDB(db *gorm.DB) *InsiderFilingsDB {
+	return &InsiderFilingsDB{Conn: db}
+}
+
+// InsiderFiling is the SEC Form 4 filing published in the insider trading digest of the channel.
+// It's stored to avoid publishing the same filing twice (e.g. filed late or on the digest boundary).
+type InsiderFiling struct {
+	AccessionNumber string    `gorm:"primaryKey;size:32;not null" json:"accession_number"` // ID of the filing in the EDGAR
+	ChannelID       string    `gorm:"primaryKey;size:64;not null" json:"channel_id"`       // ID of the channel where the filing was published
+	Ticker          string    `gorm:"size:16" json:"ticker"`                               // Ticker of the company
+	PublishedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"published_at,omitempty"`
+}
+
+func (f *InsiderFiling) Validate() error {
+	if f.AccessionNumber == "" {
+		return newError(errlvl.INFO, errAccessionEmpty, nil)
+	}
+
+	if len(f.AccessionNumber) > 32 {
+		return newError(errlvl.INFO, errAccessionTooLong, nil)
+	}
+
+	if f.ChannelID == "" {
+		return newError(errlvl.INFO, errChannelIDEmpty, nil)
+	}
+
+	if len(f.ChannelID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	if len(f.Ticker) > 16 {
+		return newError(errlvl.INFO, errTickerTooLong, nil)
+	}
+
+	return nil
+}
+
+func (f *InsiderFiling) BeforeCreate(_ *gorm.DB) error {
+	if err := f.Validate(); err != nil {
+		return newError(errlvl.INFO, errInsiderValidation, err)
+	}
+
+	return nil
+}
+
+// Create saves the published filings, already saved ones are skipped.
+func (db *InsiderFilingsDB) Create(ctx context.Context, filings []*InsiderFiling) error {
+	if len(filings) == 0 {
+		return nil
+	}
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(filings)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errInsiderCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindPublished returns the accession numbers of the given filings that were already published to the channel.
+func (db *InsiderFilingsDB) FindPublished(ctx context.Context, channelID string, accessionNumbers []string) (map[string]bool, error) {
+	published := make(map[string]bool)
+	if len(accessionNumbers) == 0 {
+		return published, nil
+	}
+
+	var found []string
+	res := db.Conn.WithContext(ctx).
+		Model(&InsiderFiling{}).
+		Where("channel_id = ? AND accession_number IN ?", channelID, accessionNumbers).
+		Pluck("accession_number", &found)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errInsiderFind, res.Error)
+	}
+
+	for _, n := range found {
+		published[n] = true
+	}
+
+	return published, nil
+}
@@ -0,0 +1,30 @@
+package archivist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInsiderFiling_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filing  InsiderFiling
+		wantErr error
+	}{
+		{name: "valid", filing: InsiderFiling{AccessionNumber: "0000320193-24-000001", ChannelID: "-100123", Ticker: "AAPL"}},
+		{name: "empty accession number", filing: InsiderFiling{ChannelID: "-100123"}, wantErr: errAccessionEmpty},
+		{name: "long accession number", filing: InsiderFiling{AccessionNumber: strings.Repeat("1", 33), ChannelID: "-100123"}, wantErr: errAccessionTooLong},
+		{name: "empty channel", filing: InsiderFiling{AccessionNumber: "0000320193-24-000001"}, wantErr: errChannelIDEmpty},
+		{name: "long channel", filing: InsiderFiling{AccessionNumber: "0000320193-24-000001", ChannelID: strings.Repeat("1", 65)}, wantErr: errChannelIDTooLong},
+		{name: "long ticker", filing: InsiderFiling{AccessionNumber: "0000320193-24-000001", ChannelID: "-100123", Ticker: strings.Repeat("A", 17)}, wantErr: errTickerTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filing.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package archivist
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"time"
+)
+
+type ListingsDB struct {
+	Conn *gorm.DB
+}
+
+func NewListingsDB(db *gorm.DB) *ListingsDB {
+	return &ListingsDB{Conn: db}
+}
+
+// Listing is the ticker of the stock universe (e.g. from the Nasdaq screener). Consecutive snapshots
+// of the universe are compared with the stored listings to find new listings and delistings.
+type Listing struct {
+	Ticker     string     `gorm:"primaryKey;size:16;not null" json:"ticker"` // Ticker of the stock
+	Name       string     `gorm:"size:256" json:"name"`                      // Name of the company
+	ListedAt   time.Time  `gorm:"not null" json:"listed_at"`                 // Time of the first snapshot with the ticker
+	DelistedAt *time.Time `json:"delisted_at"`                               // Time of the first snapshot without the ticker (nil if listed)
+	UpdatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+}
+
+func (l *Listing) Validate() error {
+	if l.Ticker == "" {
+		return newError(errlvl.INFO, errTickerEmpty, nil)
+	}
+
+	if len(l.Ticker) > 16 {
+		return newError(errlvl.INFO, errTickerTooLong, nil)
+	}
+
+	if len(l.Name) > 256 {
+		return newError(errlvl.INFO, errNameTooLong, nil)
+	}
+
+	return nil
+}
+
+// ListingsDiff is the difference between the stored listings and the new snapshot of the universe.
+type ListingsDiff struct {
+	Listed   []*Listing // new (or relisted) tickers sorted by ticker
+	Delisted []*Listing // tickers missing in the snapshot sorted by ticker
+	Baseline bool       // if true, the snapshot is the first one and saved without changes
+}
+
+// Sync saves the snapshot of the universe (ticker -> company name) taken at the given time
+// and returns its difference from the stored listings. The first snapshot is saved as the baseline.
+func (db *ListingsDB) Sync(ctx context.Context, snapshot map[string]string, at time.Time) (*ListingsDiff, error) {
+	var diff *ListingsDiff
+	err := db.Conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored []*Listing
+		if err := tx.Find(&stored).Error; err != nil {
+			return newError(errlvl.ERROR, errListingsFind, err)
+		}
+
+		var changed []*Listing
+		diff, changed = diffListings(stored, snapshot, at)
+		for _, l := range changed {
+			if err := l.Validate(); err != nil {
+				return newError(errlvl.INFO, errListingValidation, err)
+			}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ticker"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "listed_at", "delisted_at", "updated_at"}),
+		}).CreateInBatches(changed, 500)
+		if res.Error != nil {
+			return newError(errlvl.ERROR, errListingsSave, res.Error)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// diffListings compares the stored listings with the snapshot (ticker -> company name).
+// Returns the difference and the listings to save.
+func diffListings(stored []*Listing, snapshot map[string]string, at time.Time) (diff *ListingsDiff, changed []*Listing) {
+	diff = &ListingsDiff{Baseline: len(stored) == 0}
+	now := time.Now()
+
+	known := make(map[string]*Listing, len(stored))
+	for _, l := range stored {
+		known[l.Ticker] = l
+		if _, ok := snapshot[l.Ticker]; ok || l.DelistedAt != nil {
+			continue
+		}
+		delisted := *l
+		delisted.DelistedAt = &at
+		delisted.UpdatedAt = now
+		diff.Delisted = append(diff.Delisted, &delisted)
+		changed = append(changed, &delisted)
+	}
+
+	for ticker, name := range snapshot {
+		if l, ok := known[ticker]; ok && l.DelistedAt == nil {
+			continue
+		}
+		listed := &Listing{Ticker: ticker, Name: name, ListedAt: at, UpdatedAt: now}
+		if !diff.Baseline {
+			diff.Listed = append(diff.Listed, listed)
+		}
+		changed = append(changed, listed)
+	}
+
+	sort.Slice(diff.Listed, func(i, j int) bool { return diff.Listed[i].Ticker < diff.Listed[j].Ticker })
+	sort.Slice(diff.Delisted, func(i, j int) bool { return diff.Delisted[i].Ticker < diff.Delisted[j].Ticker })
+
+	return diff, changed
+}
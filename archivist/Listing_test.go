@@ -0,0 +1,79 @@
+package archivist
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListing_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing Listing
+		wantErr error
+	}{
+		{name: "valid", listing: Listing{Ticker: "AAPL", Name: "Apple Inc."}},
+		{name: "empty ticker", listing: Listing{Name: "Apple Inc."}, wantErr: errTickerEmpty},
+		{name: "long ticker", listing: Listing{Ticker: strings.Repeat("A", 17)}, wantErr: errTickerTooLong},
+		{name: "long name", listing: Listing{Ticker: "AAPL", Name: strings.Repeat("a", 257)}, wantErr: errNameTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.listing.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_diffListings(t *testing.T) {
+	before := time.Date(2024, 3, 3, 18, 0, 0, 0, time.UTC)
+	at := before.AddDate(0, 0, 7)
+	tickers := func(listings []*Listing) []string {
+		var result []string
+		for _, l := range listings {
+			result = append(result, l.Ticker)
+		}
+		return result
+	}
+
+	t.Run("baseline", func(t *testing.T) {
+		diff, changed := diffListings(nil, map[string]string{"AAPL": "Apple Inc.", "MSFT": "Microsoft"}, at)
+		if !diff.Baseline || len(diff.Listed) != 0 || len(diff.Delisted) != 0 || len(changed) != 2 {
+			t.Errorf("diffListings() = %+v, %d changed, want baseline with 2 saved listings", diff, len(changed))
+		}
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		stored := []*Listing{
+			{Ticker: "AAPL", Name: "Apple Inc.", ListedAt: before},
+			{Ticker: "TWTR", Name: "Twitter", ListedAt: before},
+			{Ticker: "OLD", Name: "Old Corp", ListedAt: before, DelistedAt: &before},
+			{Ticker: "GONE", Name: "Gone Corp", ListedAt: before, DelistedAt: &before},
+		}
+		snapshot := map[string]string{"AAPL": "Apple Inc.", "RDDT": "Reddit", "OLD": "Old Corp", "ARM": "Arm Holdings"}
+
+		diff, changed := diffListings(stored, snapshot, at)
+		if diff.Baseline {
+			t.Errorf("diffListings() Baseline = true, want false")
+		}
+		if got, want := tickers(diff.Listed), []string{"ARM", "OLD", "RDDT"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("diffListings() Listed = %v, want %v", got, want)
+		}
+		if got, want := tickers(diff.Delisted), []string{"TWTR"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("diffListings() Delisted = %v, want %v", got, want)
+		}
+		if !diff.Delisted[0].DelistedAt.Equal(at) || !diff.Listed[1].ListedAt.Equal(at) || diff.Listed[1].DelistedAt != nil {
+			t.Errorf("diffListings() dates = %+v, %+v, want changed at %v", diff.Delisted[0], diff.Listed[1], at)
+		}
+		if len(changed) != 4 {
+			t.Errorf("diffListings() changed %d listings, want 4", len(changed))
+		}
+		if stored[1].DelistedAt != nil {
+			t.Errorf("diffListings() should not modify the stored listings")
+		}
+	})
+}
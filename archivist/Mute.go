@@ -0,0 +1,128 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+)
+
+type MutesDB struct {
+	Conn *gorm.DB
+}
+
+func NewMutesDB(db *gorm.DB) *MutesDB {
+	return &MutesDB{Conn: db}
+}
+
+// MuteKind is the kind of the value that is muted.
+type MuteKind = string
+
+const (
+	MuteTicker   MuteKind = "ticker"   // News with the ticker in the meta
+	MuteHashtag  MuteKind = "hashtag"  // News with the hashtag in the meta
+	MuteKeyword  MuteKind = "keyword"  // News with the keyword in the original or composed text
+	MuteProvider MuteKind = "provider" // News from the provider
+)
+
+// MuteKinds is the list of all supported mute kinds.
+var MuteKinds = []MuteKind{MuteTicker, MuteHashtag, MuteKeyword, MuteProvider}
+
+// Mute is a runtime rule that blocks publishing of the matching news until the provided date
+// (e.g. to stop meme-stock floods).
+type Mute struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;not null;" json:"id"` // ID of the mute (UUID)
+	Kind      MuteKind  `gorm:"size:16;not null" json:"kind"`             // Kind of the muted value (ticker, hashtag, keyword, provider)
+	Value     string    `gorm:"size:128;not null" json:"value"`           // Muted value (case-insensitive)
+	Until     time.Time `gorm:"not null;index" json:"until"`              // Mute is active until this date
+	CreatedBy string    `gorm:"size:64" json:"created_by"`                // Who created the mute (e.g. Telegram username)
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (m *Mute) Validate() error {
+	if !slices.Contains(MuteKinds, m.Kind) {
+		return newError(errlvl.INFO, errMuteKindUnknown, nil)
+	}
+
+	if m.Value == "" {
+		return newError(errlvl.INFO, errMuteValueEmpty, nil)
+	}
+
+	if len(m.Value) > 128 {
+		return newError(errlvl.INFO, errMuteValueTooLong, nil)
+	}
+
+	return nil
+}
+
+func (m *Mute) BeforeCreate(_ *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+
+	if err := m.Validate(); err != nil {
+		return newError(errlvl.INFO, errMuteValidation, err)
+	}
+
+	return nil
+}
+
+// Matches returns true if the news (with the decoded meta) is blocked by the mute.
+func (m *Mute) Matches(n *News, meta *composer.ComposedMeta) bool {
+	switch m.Kind {
+	case MuteTicker:
+		return meta != nil && containsFold(meta.Tickers, strings.TrimPrefix(m.Value, "$"))
+	case MuteHashtag:
+		return meta != nil && containsFold(meta.Hashtags, strings.TrimPrefix(m.Value, "#"))
+	case MuteKeyword:
+		text := strings.ToLower(n.OriginalTitle + "\n" + n.OriginalDesc + "\n" + n.ComposedText)
+		return strings.Contains(text, strings.ToLower(m.Value))
+	case MuteProvider:
+		return strings.EqualFold(n.ProviderName, m.Value)
+	default:
+		return false
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimPrefix(v, "#"), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *MutesDB) Create(ctx context.Context, m *Mute) error {
+	res := db.Conn.WithContext(ctx).Create(m)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errMuteCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindActive finds all mutes that are active at the provided date.
+func (db *MutesDB) FindActive(ctx context.Context, at time.Time) ([]*Mute, error) {
+	var m []*Mute
+	res := db.Conn.WithContext(ctx).Where("until > ?", at).Order("until ASC").Find(&m)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errMuteFind, res.Error)
+	}
+
+	return m, nil
+}
+
+// Delete deletes the mute by its ID. Returns false if the mute wasn't found.
+func (db *MutesDB) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	res := db.Conn.WithContext(ctx).Delete(&Mute{}, "id = ?", id)
+	if res.Error != nil {
+		return false, newError(errlvl.ERROR, errMuteDelete, res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,40 @@
+package archivist
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"testing"
+)
+
+func TestMute_Matches(t *testing.T) {
+	n := &News{
+		ProviderName:  "MemeWire",
+		OriginalTitle: "GME soars again",
+		ComposedText:  "GameStop shares soar on meme stock rally.",
+	}
+	meta := &composer.ComposedMeta{
+		Tickers:  []string{"GME"},
+		Hashtags: []string{"stocks"},
+	}
+
+	tests := []struct {
+		name string
+		mute Mute
+		want bool
+	}{
+		{name: "ticker", mute: Mute{Kind: MuteTicker, Value: "gme"}, want: true},
+		{name: "ticker with dollar", mute: Mute{Kind: MuteTicker, Value: "$GME"}, want: true},
+		{name: "other ticker", mute: Mute{Kind: MuteTicker, Value: "AMC"}, want: false},
+		{name: "hashtag", mute: Mute{Kind: MuteHashtag, Value: "#Stocks"}, want: true},
+		{name: "keyword", mute: Mute{Kind: MuteKeyword, Value: "Meme Stock"}, want: true},
+		{name: "other keyword", mute: Mute{Kind: MuteKeyword, Value: "crypto"}, want: false},
+		{name: "provider", mute: Mute{Kind: MuteProvider, Value: "memewire"}, want: true},
+		{name: "unknown kind", mute: Mute{Kind: "company", Value: "GME"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mute.Matches(n, meta); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
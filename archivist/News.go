@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type NewsDB struct {
@@ -22,26 +26,54 @@ func NewNewsDB(db *gorm.DB) *NewsDB {
 }
 
 type News struct {
-	ID            uuid.UUID      `gorm:"primaryKey;type:uuid;not null;" json:"id"`  // ID of the news (UUID)
-	Hash          string         `gorm:"size:32;uniqueIndex;not null;" json:"hash"` // MD5 Hash of the news (URL + title + description + date)
-	ChannelID     string         `gorm:"size:64" json:"channel_id"`                 // ID of the channel (chat ID in Telegram)
-	PublicationID string         `gorm:"size:64" json:"publication_id"`             // ID of the publication (message ID in Telegram)
-	ProviderName  string         `gorm:"size:64" json:"provider_name"`              // Name of the provider (e.g. "Reuters")
-	URL           string         `gorm:"size:512;uniqueIndex;not null;" json:"url"` // URL of the original news
-	OriginalTitle string         `gorm:"size:512" json:"original_title"`            // Original News title
-	OriginalDesc  string         `gorm:"size:1024" json:"original_desc"`            // Original News description
-	ComposedText  string         `gorm:"size:512" json:"composed_text"`             // Composed text
-	MetaData      datatypes.JSON `gorm:"" json:"meta_data"`                         // Meta data (tickers, markets, hashtags, etc.)
-	IsSuspicious  bool           `gorm:"default:false" json:"is_suspicious"`        // Is the news suspicious (contains keywords that should be checked by human before publishing)
-	IsFiltered    bool           `gorm:"default:false" json:"is_filtered"`          // Is the news filtered out by others service (e.g. Composer.Filter)
-	PublishedAt   time.Time      `gorm:"default:null" json:"published_at"`          // Composed News publication date
-	OriginalDate  time.Time      `gorm:"not null" json:"original_date"`             // Original News date
-	CreatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
-	UpdatedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
+	ID                uuid.UUID      `gorm:"primaryKey;type:uuid;not null;" json:"id"`                  // ID of the news (UUID)
+	Hash              string         `gorm:"size:32;uniqueIndex;not null;" json:"hash"`                 // Hash of the news (journalist.News.ID, see journalist.IDStrategy)
+	ChannelID         string         `gorm:"size:64;index:idx_news_channel_date" json:"channel_id"`     // ID of the channel (chat ID in Telegram)
+	PublicationID     string         `gorm:"size:64" json:"publication_id"`                             // ID of the publication (message ID in Telegram)
+	ReplyPubIDs       string         `gorm:"size:256" json:"reply_pub_ids"`                             // Comma-separated IDs of the charts sent as the replies to the long publication (optional)
+	MirrorChannelID   string         `gorm:"size:64" json:"mirror_channel_id"`                          // ID of the channel with the translated copy of the publication (optional)
+	MirrorPubID       string         `gorm:"size:64" json:"mirror_pub_id"`                              // ID of the translated publication in the mirror channel (optional)
+	ProviderName      string         `gorm:"size:64" json:"provider_name"`                              // Name of the provider (e.g. "Reuters")
+	JobName           string         `gorm:"size:64" json:"job_name"`                                   // Name of the job that saved the news (e.g. "Run.Market")
+	StoryID           uuid.UUID      `gorm:"type:uuid;index" json:"story_id"`                           // ID of the developing story of the news (optional)
+	StoryPart         int            `gorm:"default:0" json:"story_part"`                               // Number of the news in the story (0 if not in a story)
+	URL               string         `gorm:"size:512;uniqueIndex;not null;" json:"url"`                 // URL of the original news
+	GUID              string         `gorm:"size:512" json:"guid"`                                      // GUID of the original news item in the feed (optional)
+	ArchiveURL        string         `gorm:"size:512" json:"archive_url"`                               // URL of the original news snapshot in the web archive (optional)
+	OriginalTitle     string         `gorm:"size:512" json:"original_title"`                            // Original News title
+	OriginalDesc      string         `gorm:"size:1024" json:"original_desc"`                            // Original News description
+	ComposedText      string         `gorm:"size:4096" json:"composed_text"`                            // Composed text (up to ComposedTextMaxLength characters)
+	MetaData          datatypes.JSON `gorm:"" json:"meta_data"`                                         // Meta data (tickers, markets, hashtags, etc.)
+	IsSuspicious      bool           `gorm:"default:false" json:"is_suspicious"`                        // Is the news suspicious (contains keywords that should be checked by human before publishing)
+	IsFiltered        bool           `gorm:"default:false" json:"is_filtered"`                          // Is the news filtered out by others service (e.g. Composer.Filter)
+	FilteredReason    string         `gorm:"size:32" json:"filtered_reason"`                            // Reason code why the news was filtered out (e.g. "clickbait")
+	WouldFilter       bool           `gorm:"default:false" json:"would_filter"`                         // Would the news be filtered out by the shadow filter (recorded, but not enforced)
+	WouldFilterReason string         `gorm:"size:32" json:"would_filter_reason"`                        // Reason code of the shadow filter decision
+	ModerationReason  string         `gorm:"size:128" json:"moderation_reason"`                         // Why the news is held for moderation instead of publishing (optional)
+	ApprovedAt        time.Time      `gorm:"default:null" json:"approved_at"`                           // Date when the news held for moderation was approved by the admin
+	ComplianceProfile string         `gorm:"size:32" json:"compliance_profile"`                         // Name of the compliance profile applied to the publication (optional)
+	ComposeModel      string         `gorm:"size:64" json:"compose_model"`                              // OpenAI model that composed the text (optional)
+	PublishPending    bool           `gorm:"default:false" json:"publish_pending"`                      // Is the news waiting for the publication (see NewsDB.FindComposedUnpublished)
+	PublishedAt       time.Time      `gorm:"default:null;index" json:"published_at"`                    // Composed News publication date
+	FollowedUpAt      time.Time      `gorm:"default:null" json:"followed_up_at"`                        // Date when the ticker reaction to the publication was checked
+	RetractedAt       time.Time      `gorm:"default:null" json:"retracted_at"`                          // Date when the publication was retracted (deleted or struck through)
+	RetractionReason  string         `gorm:"size:128" json:"retraction_reason"`                         // Why the publication was retracted (e.g. "duplicate")
+	OriginalDate      time.Time      `gorm:"not null;index:idx_news_channel_date" json:"original_date"` // Original News date
+	CreatedAt         time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt         time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at,omitempty"`
 }
 
+// ComposedTextMaxLength is the maximum length of News.ComposedText in characters (column size).
+const ComposedTextMaxLength = 4096
+
+// OriginalDescMaxLength is the maximum length of News.OriginalDesc in characters (column size).
+const OriginalDescMaxLength = 1024
+
+// RetractionReasonMaxLength is the maximum length of News.RetractionReason in bytes (column size).
+const RetractionReasonMaxLength = 128
+
 func (n *News) Validate() error {
-	if len(n.ChannelID) > 64 {
+	if len(n.ChannelID) > 64 || len(n.MirrorChannelID) > 64 {
 		return newError(errlvl.INFO, errChannelIDTooLong, nil)
 	}
 
@@ -49,7 +81,7 @@ func (n *News) Validate() error {
 		return newError(errlvl.INFO, errHashTooLong, nil)
 	}
 
-	if len(n.PublicationID) > 64 {
+	if len(n.PublicationID) > 64 || len(n.MirrorPubID) > 64 {
 		return newError(errlvl.INFO, errPubIDTooLong, nil)
 	}
 
@@ -57,6 +89,10 @@ func (n *News) Validate() error {
 		return newError(errlvl.INFO, errProviderNameTooLong, nil)
 	}
 
+	if len(n.JobName) > 64 {
+		return newError(errlvl.INFO, errJobNameTooLong, nil)
+	}
+
 	if n.URL == "" {
 		return newError(errlvl.INFO, errURLEmpty, nil)
 	}
@@ -65,18 +101,46 @@ func (n *News) Validate() error {
 		return newError(errlvl.INFO, errURLTooLong, nil)
 	}
 
+	if len(n.GUID) > 512 {
+		return newError(errlvl.INFO, errGUIDTooLong, nil)
+	}
+
+	if len(n.ArchiveURL) > 512 {
+		return newError(errlvl.INFO, errArchiveURLTooLong, nil)
+	}
+
 	if len(n.OriginalTitle) > 512 {
 		return newError(errlvl.INFO, errOriginalTitleTooLong, nil)
 	}
 
-	if len(n.OriginalDesc) > 1024 {
+	if utf8.RuneCountInString(n.OriginalDesc) > OriginalDescMaxLength {
 		return newError(errlvl.INFO, errOriginalDescTooLong, nil)
 	}
 
-	if len(n.ComposedText) > 512 {
+	if utf8.RuneCountInString(n.ComposedText) > ComposedTextMaxLength {
 		return newError(errlvl.INFO, errComposedTextTooLong, nil)
 	}
 
+	if len(n.FilteredReason) > 32 || len(n.WouldFilterReason) > 32 {
+		return newError(errlvl.INFO, errFilteredReasonTooLong, nil)
+	}
+
+	if len(n.ModerationReason) > 128 {
+		return newError(errlvl.INFO, errModerationTooLong, nil)
+	}
+
+	if len(n.RetractionReason) > RetractionReasonMaxLength {
+		return newError(errlvl.INFO, errRetractionTooLong, nil)
+	}
+
+	if len(n.ComplianceProfile) > 32 {
+		return newError(errlvl.INFO, errComplianceTooLong, nil)
+	}
+
+	if len(n.ComposeModel) > 64 {
+		return newError(errlvl.INFO, errComposeModelTooLong, nil)
+	}
+
 	if n.OriginalDate.IsZero() {
 		return newError(errlvl.INFO, errOriginalDateEmpty, nil)
 	}
@@ -98,9 +162,7 @@ func (n *News) BeforeCreate(*gorm.DB) error {
 		n.GenerateHash()
 	}
 
-	if len(n.OriginalDesc) > 1024 {
-		n.OriginalDesc = n.OriginalDesc[:1024]
-	}
+	n.OriginalDesc = utils.Truncate(n.OriginalDesc, OriginalDescMaxLength)
 
 	err := n.Validate()
 	if err != nil {
@@ -127,6 +189,24 @@ func (db *NewsDB) Create(ctx context.Context, n []*News) error {
 	return nil
 }
 
+// BulkCreate saves the news with multi-row inserts of bulkBatchSize rows, e.g. for the backfill of thousands of news.
+// News that already exist (by hash or URL) are skipped. Returns the number of inserted news.
+// The batches are inserted in one transaction, nothing is inserted if any of the news is invalid.
+func (db *NewsDB) BulkCreate(ctx context.Context, n []*News) (int64, error) {
+	if len(n) == 0 {
+		return 0, nil
+	}
+
+	res := db.Conn.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(n, bulkBatchSize)
+	if res.Error != nil {
+		return 0, newError(errlvl.ERROR, errNewsCreation, res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
+
 func (db *NewsDB) Update(ctx context.Context, n *News) error {
 	res := db.Conn.WithContext(ctx).Where("hash = ?", n.Hash).Updates(n)
 	if res.Error != nil {
@@ -147,6 +227,43 @@ func (db *NewsDB) FindAllByHashes(ctx context.Context, hashes []string) ([]*News
 	return n, nil
 }
 
+// RecomputeHashes recomputes the hashes of all saved news with the hash function (e.g. after changing the news ID strategy),
+// so the news fetched again are still recognized as duplicates. News whose new hash is already taken by another news
+// keep the old hash. Returns the number of updated and conflicting news.
+func (db *NewsDB) RecomputeHashes(ctx context.Context, hash func(n *News) string) (updated, conflicts int, err error) {
+	// FindInBatches pages by the primary key, any other order would skip or repeat the news between the batches
+	var batch []*News
+	res := db.Conn.WithContext(ctx).FindInBatches(&batch, 500, func(_ *gorm.DB, _ int) error {
+		for _, n := range batch {
+			h := hash(n)
+			if h == "" || h == n.Hash {
+				continue
+			}
+
+			var taken int64
+			if err := db.Conn.WithContext(ctx).Model(&News{}).Where("hash = ?", h).Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				conflicts++
+				continue
+			}
+
+			err := db.Conn.WithContext(ctx).Model(&News{}).Where("id = ?", n.ID).UpdateColumn("hash", h).Error
+			if err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if res.Error != nil {
+		return updated, conflicts, newError(errlvl.ERROR, errNewsRecomputeHashes, res.Error)
+	}
+
+	return updated, conflicts, nil
+}
+
 // FindAllByUrls finds news by its URL.
 func (db *NewsDB) FindAllByUrls(ctx context.Context, urls []string) ([]*News, error) {
 	var n []*News
@@ -168,3 +285,382 @@ func (db *NewsDB) FindAllUntilDate(ctx context.Context, until time.Time) ([]*New
 
 	return n, nil
 }
+
+// NewsStats holds aggregated counters of the news processed since some date.
+type NewsStats struct {
+	Total       int64 // Total number of news saved to the database
+	Filtered    int64 // Number of news filtered out by others service (e.g. Composer.Filter)
+	Published   int64 // Number of published news
+	WouldFilter int64 // Number of news the shadow filter would filter out
+}
+
+// FilterRate returns the share of filtered news among all news (0..1).
+func (s *NewsStats) FilterRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+
+	return float64(s.Filtered) / float64(s.Total)
+}
+
+// WouldFilterRate returns the share of news the shadow filter would filter out among all news (0..1).
+func (s *NewsStats) WouldFilterRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+
+	return float64(s.WouldFilter) / float64(s.Total)
+}
+
+// CountSince counts all, filtered, published and shadow filtered news created since the provided date.
+func (db *NewsDB) CountSince(ctx context.Context, since time.Time) (*NewsStats, error) {
+	var stats NewsStats
+	res := db.Conn.WithContext(ctx).
+		Select(
+			"COUNT(*) AS total, "+
+				"COUNT(*) FILTER (WHERE is_filtered) AS filtered, "+
+				"COUNT(*) FILTER (WHERE published_at IS NOT NULL) AS published, "+
+				"COUNT(*) FILTER (WHERE would_filter) AS would_filter",
+		).
+		Where("created_at >= ?", since).
+		Scan(&stats)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsCount, res.Error)
+	}
+
+	return &stats, nil
+}
+
+// CountFilteredReasons counts filtered news created since the provided date grouped by News.FilteredReason.
+// News filtered without a reason are counted under the empty key.
+func (db *NewsDB) CountFilteredReasons(ctx context.Context, since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		FilteredReason string
+		Count          int64
+	}
+	res := db.Conn.WithContext(ctx).
+		Select("filtered_reason, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Where("is_filtered = ?", true).
+		Group("filtered_reason").
+		Scan(&rows)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsCount, res.Error)
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		result[r.FilteredReason] = r.Count
+	}
+
+	return result, nil
+}
+
+// CountByProvider counts news created in [from, to) grouped by News.ProviderName.
+func (db *NewsDB) CountByProvider(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	var rows []struct {
+		ProviderName string
+		Count        int64
+	}
+	res := db.Conn.WithContext(ctx).
+		Select("provider_name, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("provider_name").
+		Scan(&rows)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsCount, res.Error)
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		result[r.ProviderName] = r.Count
+	}
+
+	return result, nil
+}
+
+// CountMarkets counts published news since the provided date grouped by the market from News.MetaData.
+func (db *NewsDB) CountMarkets(ctx context.Context, since time.Time) (map[string]int64, error) {
+	return db.countMetaValues(ctx, since, "markets")
+}
+
+// CountSectors counts published news since the provided date grouped by the sector from News.MetaData.
+func (db *NewsDB) CountSectors(ctx context.Context, since time.Time) (map[string]int64, error) {
+	return db.countMetaValues(ctx, since, "sectors")
+}
+
+// countMetaValues counts published news since the provided date grouped by the values of the News.MetaData array key.
+func (db *NewsDB) countMetaValues(ctx context.Context, since time.Time, key string) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	res := db.Conn.WithContext(ctx).
+		Select("jsonb_array_elements_text(meta_data->?) AS value, COUNT(*) AS count", key).
+		Where("published_at >= ?", since).
+		Where("jsonb_typeof(meta_data->?) = 'array'", key).
+		Group("value").
+		Scan(&rows)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsCount, res.Error)
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		result[r.Value] = r.Count
+	}
+
+	return result, nil
+}
+
+// FindForFollowUp finds published news with tickers in MetaData that were not followed up yet
+// and were published between the provided dates.
+func (db *NewsDB) FindForFollowUp(ctx context.Context, from, to time.Time) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("published_at BETWEEN ? AND ?", from, to).
+		Where("publication_id != ?", "").
+		Where("followed_up_at IS NULL").
+		Where("retracted_at IS NULL").
+		Where("jsonb_typeof(meta_data->'tickers') = 'array'").
+		Where("jsonb_array_length(meta_data->'tickers') > 0").
+		Order("published_at ASC").
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindForFollowUp, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindComposedPublished finds the news of the channel published between the provided dates
+// with the text composed by the known model (see News.ComposeModel), except the retracted ones.
+func (db *NewsDB) FindComposedPublished(ctx context.Context, channelID string, from, to time.Time) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Where("published_at >= ? AND published_at < ?", from, to).
+		Where("publication_id != ?", "").
+		Where("compose_model != ?", "").
+		Where("retracted_at IS NULL").
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindComposedPublished, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindRecentTexts finds up to the limit of the news published to the channel since the provided date
+// with the composed text and any of the given tickers or hashtags in News.MetaData, the most recent first.
+func (db *NewsDB) FindRecentTexts(
+	ctx context.Context,
+	channelID string,
+	since time.Time,
+	tickers, hashtags []string,
+	limit int,
+) ([]*News, error) {
+	if len(tickers) == 0 && len(hashtags) == 0 {
+		return nil, nil
+	}
+
+	// Tickers and hashtags never contain commas, so they are passed as a single text parameter
+	topics := db.Conn.WithContext(ctx).
+		Where("jsonb_exists_any(meta_data->'tickers', string_to_array(?, ','))", strings.Join(tickers, ",")).
+		Or("jsonb_exists_any(meta_data->'hashtags', string_to_array(?, ','))", strings.Join(hashtags, ","))
+
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Where("published_at >= ?", since).
+		Where("publication_id != ?", "").
+		Where("retracted_at IS NULL").
+		Where("composed_text != ?", "").
+		Where(topics).
+		Order("published_at DESC").
+		Limit(limit).
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindRecentTexts, res.Error)
+	}
+
+	return n, nil
+}
+
+// Search finds up to the limit of the published news matching any word of the query (Postgres full-text search
+// of the original title and the composed text), the best matches first.
+func (db *NewsDB) Search(ctx context.Context, query string, limit int) ([]*News, error) {
+	document := "to_tsvector('english', original_title || ' ' || composed_text)"
+
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("publication_id != ?", "").
+		Where("retracted_at IS NULL").
+		Where(document+" @@ "+anyWordQuery, query).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(" + document + ", " + anyWordQuery + ") DESC, published_at DESC",
+			Vars: []any{query},
+		}}).
+		Limit(limit).
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsSearch, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindEngaging finds the news with the composed text published since the provided date among the clicked ones
+// (see ClicksDB.FindClickedNews), in order of publication. Nil clicked means all published news.
+func (db *NewsDB) FindEngaging(ctx context.Context, since time.Time, clicked []uuid.UUID) ([]*News, error) {
+	query := db.Conn.WithContext(ctx).
+		Where("published_at >= ?", since).
+		Where("publication_id != ?", "").
+		Where("retracted_at IS NULL").
+		Where("composed_text != ?", "")
+
+	if clicked != nil {
+		if len(clicked) == 0 {
+			return nil, nil
+		}
+		query = query.Where("id IN ?", clicked)
+	}
+
+	var n []*News
+	res := query.Order("published_at ASC").Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindEngaging, res.Error)
+	}
+
+	return n, nil
+}
+
+// anyWordQuery is the full-text search query matching any word of the text parameter (plainto_tsquery matches all).
+const anyWordQuery = "replace(plainto_tsquery('english', ?)::text, '&', '|')::tsquery"
+
+// MarkPending sets the PublishPending flag of the news with the given hashes.
+func (db *NewsDB) MarkPending(ctx context.Context, hashes []string, pending bool) error {
+	res := db.Conn.WithContext(ctx).
+		Where("hash IN ?", hashes).
+		Update("publish_pending", pending)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errNewsMarkPending, res.Error)
+	}
+
+	return nil
+}
+
+// FindComposedUnpublished finds the news with the composed text saved (or approved, see NewsDB.Approve)
+// pending publication in the last maxAge, but never published (e.g. the publication failed, the run timed out
+// or the process crashed before publishing), in order of creation.
+func (db *NewsDB) FindComposedUnpublished(ctx context.Context, maxAge time.Duration) ([]*News, error) {
+	since := time.Now().Add(-maxAge)
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("publish_pending = ?", true).
+		Where("publication_id = ?", "").
+		Where("composed_text != ?", "").
+		Where("created_at >= ? OR approved_at >= ?", since, since).
+		Order("created_at ASC").
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindUnpublished, res.Error)
+	}
+
+	return n, nil
+}
+
+// FindFlagged finds up to the limit of the unpublished news held for moderation (see News.ModerationReason)
+// created since the provided date, the most recent first.
+func (db *NewsDB) FindFlagged(ctx context.Context, since time.Time, limit int) ([]*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("moderation_reason != ?", "").
+		Where("publication_id = ?", "").
+		Where("created_at >= ?", since).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindFlagged, res.Error)
+	}
+
+	return n, nil
+}
+
+// Approve clears the moderation reason of the unpublished news held for moderation and marks it pending publication
+// with the approval date, so the news is published by the recovery of its job (see NewsDB.FindComposedUnpublished)
+// regardless of its age.
+// Returns false if there is no such news.
+func (db *NewsDB) Approve(ctx context.Context, id uuid.UUID) (bool, error) {
+	res := db.Conn.WithContext(ctx).
+		Where("id = ?", id).
+		Where("moderation_reason != ?", "").
+		Where("publication_id = ?", "").
+		Updates(map[string]any{"moderation_reason": "", "publish_pending": true, "approved_at": time.Now()})
+	if res.Error != nil {
+		return false, newError(errlvl.ERROR, errNewsApprove, res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// FindPublished finds the published news by its ID. Returns nil if there is no such news, it wasn't published
+// (e.g. filtered out) or it was retracted, so the unpublished news are never exposed.
+func (db *NewsDB) FindPublished(ctx context.Context, id uuid.UUID) (*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("id = ?", id).
+		Where("publication_id != ?", "").
+		Where("retracted_at IS NULL").
+		Limit(1).
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindPublished, res.Error)
+	}
+
+	if len(n) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return n[0], nil
+}
+
+// Retract marks the published news as retracted with the reason (after its publication was deleted or struck through),
+// so it's no longer served, searched, followed up or used as the context of the new posts.
+// Returns false if there is no such published news or it was already retracted.
+func (db *NewsDB) Retract(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	if len(reason) > RetractionReasonMaxLength {
+		return false, newError(errlvl.INFO, errRetractionTooLong, nil)
+	}
+
+	res := db.Conn.WithContext(ctx).
+		Where("id = ?", id).
+		Where("publication_id != ?", "").
+		Where("retracted_at IS NULL").
+		Updates(map[string]any{"retracted_at": time.Now(), "retraction_reason": reason})
+	if res.Error != nil {
+		return false, newError(errlvl.ERROR, errNewsRetract, res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// FindLastPublished finds the most recently published news. Returns nil if nothing was published yet.
+func (db *NewsDB) FindLastPublished(ctx context.Context) (*News, error) {
+	var n []*News
+	res := db.Conn.WithContext(ctx).
+		Where("published_at IS NOT NULL").
+		Order("published_at DESC").
+		Limit(1).
+		Find(&n)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errNewsFindLastPublished, res.Error)
+	}
+
+	if len(n) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return n[0], nil
+}
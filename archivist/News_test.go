@@ -7,6 +7,7 @@ import (
 	"github.com/samgozman/fin-thread/composer"
 	"gorm.io/gorm"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -142,6 +143,46 @@ func TestNews_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Test News Validate - Invalid News (JobName too long)",
+			fields: News{
+				ChannelID:     "testChannel",
+				ProviderName:  "testProvider",
+				JobName:       strings.Repeat("Run.Market", 7),
+				URL:           "https://test.com",
+				OriginalTitle: "Test Title",
+				OriginalDesc:  "Test Description",
+				OriginalDate:  time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test News Validate - Invalid News (MirrorPubID too long)",
+			fields: News{
+				ChannelID:       "testChannel",
+				MirrorChannelID: "testMirror",
+				MirrorPubID:     strings.Repeat("1", 65),
+				ProviderName:    "testProvider",
+				URL:             "https://test.com",
+				OriginalTitle:   "Test Title",
+				OriginalDesc:    "Test Description",
+				OriginalDate:    time.Now(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test News Validate - Invalid News (RetractionReason too long)",
+			fields: News{
+				ChannelID:        "testChannel",
+				ProviderName:     "testProvider",
+				URL:              "https://test.com",
+				OriginalTitle:    "Test Title",
+				OriginalDesc:     "Test Description",
+				RetractionReason: strings.Repeat("duplicate ", 13),
+				OriginalDate:     time.Now(),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,109 @@
+package archivist
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"time"
+	"unicode/utf8"
+)
+
+type OutboxDB struct {
+	Conn *gorm.DB
+}
+
+func NewOutboxDB(db *gorm.DB) *OutboxDB {
+	return &OutboxDB{Conn: db}
+}
+
+// OutboxMessage is the formatted message that wasn't published because Telegram was unreachable.
+// Messages are replayed in the order of creation and deleted after publishing.
+type OutboxMessage struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChannelID string    `gorm:"size:64;index;not null" json:"channel_id"` // ID of the channel (chat ID in Telegram)
+	NewsHash  string    `gorm:"size:32" json:"news_hash"`                 // Hash of the news updated after publishing (optional)
+	Message   string    `gorm:"size:4096;not null" json:"message"`        // Formatted message
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (m *OutboxMessage) Validate() error {
+	if m.ChannelID == "" {
+		return newError(errlvl.INFO, errChannelIDEmpty, nil)
+	}
+
+	if m.Message == "" {
+		return newError(errlvl.INFO, errOutboxMessageEmpty, nil)
+	}
+
+	if utf8.RuneCountInString(m.Message) > 4096 {
+		return newError(errlvl.INFO, errOutboxMessageTooLong, nil)
+	}
+
+	return nil
+}
+
+// Create queues the message.
+func (db *OutboxDB) Create(ctx context.Context, m *OutboxMessage) error {
+	if err := m.Validate(); err != nil {
+		return newError(errlvl.INFO, errOutboxValidation, err)
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+
+	res := db.Conn.WithContext(ctx).Create(m)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errOutboxCreation, res.Error)
+	}
+
+	return nil
+}
+
+// FindPending returns the queued messages of the channel in the order of creation.
+func (db *OutboxDB) FindPending(ctx context.Context, channelID string) ([]*OutboxMessage, error) {
+	var m []*OutboxMessage
+	res := db.Conn.WithContext(ctx).Where("channel_id = ?", channelID).Order("id ASC").Find(&m)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errOutboxFind, res.Error)
+	}
+
+	return m, nil
+}
+
+// Count returns the number of the queued messages of the channel.
+func (db *OutboxDB) Count(ctx context.Context, channelID string) (int64, error) {
+	count, _, err := db.Stats(ctx, channelID)
+	return count, err
+}
+
+// Stats returns the number of the queued messages of the channel and the creation time of the oldest one
+// (zero if the outbox is empty).
+func (db *OutboxDB) Stats(ctx context.Context, channelID string) (count int64, oldest time.Time, err error) {
+	var stats struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	res := db.Conn.WithContext(ctx).
+		Model(&OutboxMessage{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("channel_id = ?", channelID).
+		Scan(&stats)
+	if res.Error != nil {
+		return 0, time.Time{}, newError(errlvl.ERROR, errOutboxFind, res.Error)
+	}
+
+	if stats.Oldest != nil {
+		oldest = *stats.Oldest
+	}
+	return stats.Count, oldest, nil
+}
+
+// Delete deletes the message from the queue.
+func (db *OutboxDB) Delete(ctx context.Context, id uint) error {
+	res := db.Conn.WithContext(ctx).Delete(&OutboxMessage{}, id)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errOutboxDelete, res.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package archivist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOutboxMessage_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		message OutboxMessage
+		wantErr error
+	}{
+		{name: "valid", message: OutboxMessage{ChannelID: "@channel", NewsHash: "abc", Message: "Fed holds rates"}},
+		{name: "valid unicode", message: OutboxMessage{ChannelID: "@channel", Message: strings.Repeat("📈", 4096)}},
+		{name: "empty channel", message: OutboxMessage{Message: "Fed holds rates"}, wantErr: errChannelIDEmpty},
+		{name: "empty message", message: OutboxMessage{ChannelID: "@channel"}, wantErr: errOutboxMessageEmpty},
+		{name: "long message", message: OutboxMessage{ChannelID: "@channel", Message: strings.Repeat("a", 4097)}, wantErr: errOutboxMessageTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.message.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
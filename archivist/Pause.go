@@ -0,0 +1,104 @@
+package archivist
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// PauseAll is the Pause.Job value of the global pause of all jobs.
+const PauseAll = "*"
+
+type PausesDB struct {
+	Conn *gorm.DB
+}
+
+func NewPausesDB(db *gorm.DB) *PausesDB {
+	return &PausesDB{Conn: db}
+}
+
+// Pause halts the runs of the job (or all jobs with PauseAll) until it's deleted, e.g. during the incidents.
+// Paused jobs keep their checkpoints, so they continue from the same place after the pause.
+type Pause struct {
+	Job       string    `gorm:"primaryKey;size:64;not null" json:"job"` // Job key (e.g. "market") or PauseAll
+	Reason    string    `gorm:"size:256" json:"reason"`                 // Why the job is paused (optional)
+	CreatedBy string    `gorm:"size:64" json:"created_by"`              // Who paused the job (e.g. Telegram username)
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+}
+
+func (p *Pause) Validate() error {
+	if p.Job == "" {
+		return newError(errlvl.INFO, errPauseJobEmpty, nil)
+	}
+
+	if len(p.Job) > 64 {
+		return newError(errlvl.INFO, errPauseJobTooLong, nil)
+	}
+
+	if len(p.Reason) > 256 {
+		return newError(errlvl.INFO, errPauseReasonTooLong, nil)
+	}
+
+	return nil
+}
+
+// Save creates or replaces the pause of the job.
+func (db *PausesDB) Save(ctx context.Context, p *Pause) error {
+	if err := p.Validate(); err != nil {
+		return newError(errlvl.INFO, errPauseValidation, err)
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+
+	res := db.Conn.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "created_by", "created_at"}),
+	}).Create(p)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errPauseSave, res.Error)
+	}
+
+	return nil
+}
+
+// Find returns the pause that halts the job: the global one first, then the job one. Returns nil if the job isn't paused.
+func (db *PausesDB) Find(ctx context.Context, job string) (*Pause, error) {
+	var pauses []*Pause
+	res := db.Conn.WithContext(ctx).Where("job IN ?", []string{PauseAll, job}).Find(&pauses)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errPauseFind, res.Error)
+	}
+
+	var found *Pause
+	for _, p := range pauses {
+		if found == nil || p.Job == PauseAll {
+			found = p
+		}
+	}
+
+	return found, nil
+}
+
+// FindAll returns all pauses sorted by the job key.
+func (db *PausesDB) FindAll(ctx context.Context) ([]*Pause, error) {
+	var p []*Pause
+	res := db.Conn.WithContext(ctx).Order("job ASC").Find(&p)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errPauseFind, res.Error)
+	}
+
+	return p, nil
+}
+
+// Delete deletes the pause of the job. Returns false if the job wasn't paused.
+func (db *PausesDB) Delete(ctx context.Context, job string) (bool, error) {
+	res := db.Conn.WithContext(ctx).Delete(&Pause{}, "job = ?", job)
+	if res.Error != nil {
+		return false, newError(errlvl.ERROR, errPauseDelete, res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,29 @@
+package archivist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPause_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pause   Pause
+		wantErr error
+	}{
+		{name: "valid job", pause: Pause{Job: "market", Reason: "provider incident"}},
+		{name: "valid global", pause: Pause{Job: PauseAll}},
+		{name: "empty job", pause: Pause{Reason: "incident"}, wantErr: errPauseJobEmpty},
+		{name: "long job", pause: Pause{Job: strings.Repeat("a", 65)}, wantErr: errPauseJobTooLong},
+		{name: "long reason", pause: Pause{Job: "market", Reason: strings.Repeat("a", 257)}, wantErr: errPauseReasonTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pause.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type StoriesDB struct {
+	Conn *gorm.DB
+}
+
+func NewStoriesDB(db *gorm.DB) *StoriesDB {
+	return &StoriesDB{Conn: db}
+}
+
+// Story is the developing story: related news published to the channel over days
+// (e.g. the merger from the first rumor to the closed deal). News of the story are linked by News.StoryID.
+type Story struct {
+	ID        uuid.UUID                   `gorm:"primaryKey;type:uuid;not null;" json:"id"` // ID of the story (UUID)
+	ChannelID string                      `gorm:"size:64;index;not null" json:"channel_id"` // ID of the channel (chat ID in Telegram)
+	Title     string                      `gorm:"size:512" json:"title"`                    // Original title of the latest news of the story
+	Topics    datatypes.JSONSlice[string] `gorm:"" json:"topics"`                           // Tickers and hashtags of the story news
+	Parts     int                         `gorm:"not null;default:1" json:"parts"`          // Number of the news in the story
+	CreatedAt time.Time                   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at,omitempty"`
+	UpdatedAt time.Time                   `gorm:"default:CURRENT_TIMESTAMP;index" json:"updated_at,omitempty"` // Date of the latest news of the story
+}
+
+func (s *Story) Validate() error {
+	if s.ChannelID == "" {
+		return newError(errlvl.INFO, errChannelIDEmpty, nil)
+	}
+
+	if len(s.ChannelID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	if len(s.Title) > 512 {
+		return newError(errlvl.INFO, errTitleTooLong, nil)
+	}
+
+	return nil
+}
+
+func (s *Story) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	if err := s.Validate(); err != nil {
+		return newError(errlvl.INFO, errStoryValidation, err)
+	}
+
+	return nil
+}
+
+// Create saves the new story.
+func (db *StoriesDB) Create(ctx context.Context, s *Story) error {
+	res := db.Conn.WithContext(ctx).Create(s)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errStoryCreation, res.Error)
+	}
+
+	return nil
+}
+
+// Update saves the title and topics of the story. Parts are counted by AddPart.
+func (db *StoriesDB) Update(ctx context.Context, s *Story) error {
+	if err := s.Validate(); err != nil {
+		return newError(errlvl.INFO, errStoryValidation, err)
+	}
+
+	res := db.Conn.WithContext(ctx).Model(s).Updates(map[string]any{
+		"title":      s.Title,
+		"topics":     s.Topics,
+		"updated_at": time.Now(),
+	})
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errStoryUpdate, res.Error)
+	}
+
+	return nil
+}
+
+// AddPart increments the parts of the story and returns the number of the new part. The increment is atomic,
+// so the jobs publishing to the same channel concurrently never get the same part.
+func (db *StoriesDB) AddPart(ctx context.Context, id uuid.UUID) (int, error) {
+	s := Story{ID: id}
+	res := db.Conn.WithContext(ctx).Model(&s).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "parts"}}}).
+		UpdateColumns(map[string]any{"parts": gorm.Expr("parts + 1"), "updated_at": time.Now()})
+	if res.Error != nil {
+		return 0, newError(errlvl.ERROR, errStoryUpdate, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return 0, newError(errlvl.ERROR, errStoryUpdate, gorm.ErrRecordNotFound)
+	}
+
+	return s.Parts, nil
+}
+
+// RemovePart gives back the part of the story added by AddPart (e.g. its news failed to publish),
+// unless the later part is already added.
+func (db *StoriesDB) RemovePart(ctx context.Context, id uuid.UUID, part int) error {
+	res := db.Conn.WithContext(ctx).Model(&Story{}).
+		Where("id = ? AND parts = ?", id, part).
+		UpdateColumn("parts", gorm.Expr("parts - 1"))
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errStoryUpdate, res.Error)
+	}
+
+	return nil
+}
+
+// FindActive finds the stories of the channel with the news since the provided date, the most recent first.
+func (db *StoriesDB) FindActive(ctx context.Context, channelID string, since time.Time) ([]*Story, error) {
+	var s []*Story
+	res := db.Conn.WithContext(ctx).
+		Where("channel_id = ?", channelID).
+		Where("updated_at >= ?", since).
+		Order("updated_at DESC").
+		Find(&s)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errStoryFind, res.Error)
+	}
+
+	return s, nil
+}
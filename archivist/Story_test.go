@@ -0,0 +1,28 @@
+package archivist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStory_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		story   Story
+		wantErr error
+	}{
+		{name: "valid", story: Story{ChannelID: "@channel", Title: "Apple faces EU fine", Topics: []string{"AAPL"}, Parts: 1}},
+		{name: "empty channel", story: Story{Title: "Apple faces EU fine"}, wantErr: errChannelIDEmpty},
+		{name: "long channel", story: Story{ChannelID: strings.Repeat("a", 65)}, wantErr: errChannelIDTooLong},
+		{name: "long title", story: Story{ChannelID: "@channel", Title: strings.Repeat("a", 513)}, wantErr: errTitleTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.story.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package archivist
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+type SummariesDB struct {
+	Conn *gorm.DB
+}
+
+func NewSummariesDB(db *gorm.DB) *SummariesDB {
+	return &SummariesDB{Conn: db}
+}
+
+// SummaryKind is the kind of the published summary.
+type SummaryKind = string
+
+const (
+	SummaryBeforeOpen SummaryKind = "before_open" // Summary of the news and events before the market open
+	SummaryRecap      SummaryKind = "recap"       // Recap of the trading day after the market close
+)
+
+// SummaryKinds is the list of all supported summary kinds.
+var SummaryKinds = []SummaryKind{SummaryBeforeOpen, SummaryRecap}
+
+// Summary is the published summary with the provenance of its headlines: which News and Event IDs
+// were fed into it and which of them made it into the final text.
+type Summary struct {
+	ID            uuid.UUID                   `gorm:"primaryKey;type:uuid;not null;" json:"id"` // ID of the summary (UUID)
+	ChannelID     string                      `gorm:"size:64" json:"channel_id"`                // ID of the channel (chat ID in Telegram)
+	PublicationID string                      `gorm:"size:64" json:"publication_id"`            // ID of the publication (message ID in Telegram)
+	Kind          SummaryKind                 `gorm:"size:16;not null;index" json:"kind"`       // Kind of the summary (before open, recap)
+	Text          string                      `gorm:"size:4096" json:"text"`                    // Published text of the summary
+	InputIDs      datatypes.JSONSlice[string] `gorm:"" json:"input_ids"`                        // IDs of the News and Events fed into the summary
+	IncludedIDs   datatypes.JSONSlice[string] `gorm:"" json:"included_ids"`                     // IDs of the News and Events included in the final text
+	CreatedAt     time.Time                   `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at,omitempty"`
+}
+
+// NewSummary creates a new Summary of the kind with provenance of the headlines.
+// Only the summarised headlines with IDs from the input are counted as included (AI can return unknown IDs).
+func NewSummary(kind SummaryKind, input []*composer.Headline, summarised []*composer.SummarisedHeadline) *Summary {
+	s := &Summary{
+		Kind:        kind,
+		InputIDs:    make(datatypes.JSONSlice[string], 0, len(input)),
+		IncludedIDs: make(datatypes.JSONSlice[string], 0, len(summarised)),
+	}
+
+	for _, h := range input {
+		s.InputIDs = append(s.InputIDs, h.ID)
+	}
+	for _, h := range summarised {
+		if slices.Contains(s.InputIDs, h.ID) && !slices.Contains(s.IncludedIDs, h.ID) {
+			s.IncludedIDs = append(s.IncludedIDs, h.ID)
+		}
+	}
+
+	return s
+}
+
+func (s *Summary) Validate() error {
+	if !slices.Contains(SummaryKinds, s.Kind) {
+		return newError(errlvl.INFO, errSummaryKindUnknown, nil)
+	}
+
+	if len(s.ChannelID) > 64 {
+		return newError(errlvl.INFO, errChannelIDTooLong, nil)
+	}
+
+	if len(s.PublicationID) > 64 {
+		return newError(errlvl.INFO, errPubIDTooLong, nil)
+	}
+
+	if utf8.RuneCountInString(s.Text) > 4096 {
+		return newError(errlvl.INFO, errSummaryTextTooLong, nil)
+	}
+
+	return nil
+}
+
+func (s *Summary) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	if err := s.Validate(); err != nil {
+		return newError(errlvl.INFO, errSummaryValidation, err)
+	}
+
+	return nil
+}
+
+func (db *SummariesDB) Create(ctx context.Context, s *Summary) error {
+	res := db.Conn.WithContext(ctx).Create(s)
+	if res.Error != nil {
+		return newError(errlvl.ERROR, errSummaryCreation, res.Error)
+	}
+
+	return nil
+}
+
+// SummaryStats holds aggregated counters of the summaries published since some date.
+type SummaryStats struct {
+	Summaries int64 // Number of published summaries
+	Included  int64 // Number of unique News and Events included in the summaries (each counted once)
+}
+
+// CountSince counts summaries created since the provided date and unique headlines included in them,
+// so the same news included in both before open summary and recap is not counted twice.
+func (db *SummariesDB) CountSince(ctx context.Context, since time.Time) (*SummaryStats, error) {
+	var stats SummaryStats
+	res := db.Conn.WithContext(ctx).Model(&Summary{}).Where("created_at >= ?", since).Count(&stats.Summaries)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errSummaryCount, res.Error)
+	}
+
+	res = db.Conn.WithContext(ctx).
+		Table("summaries, jsonb_array_elements_text(summaries.included_ids) AS included(id)").
+		Where("summaries.created_at >= ?", since).
+		Select("COUNT(DISTINCT included.id)").
+		Scan(&stats.Included)
+	if res.Error != nil {
+		return nil, newError(errlvl.ERROR, errSummaryCount, res.Error)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,45 @@
+package archivist
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewSummary(t *testing.T) {
+	input := []*composer.Headline{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	summarised := []*composer.SummarisedHeadline{{ID: "3"}, {ID: "1"}, {ID: "42"}, {ID: "1"}}
+
+	s := NewSummary(SummaryRecap, input, summarised)
+	if s.Kind != SummaryRecap {
+		t.Errorf("NewSummary() kind = %s, want %s", s.Kind, SummaryRecap)
+	}
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual([]string(s.InputIDs), want) {
+		t.Errorf("NewSummary() InputIDs = %v, want %v", s.InputIDs, want)
+	}
+	if want := []string{"3", "1"}; !reflect.DeepEqual([]string(s.IncludedIDs), want) {
+		t.Errorf("NewSummary() IncludedIDs = %v, want %v", s.IncludedIDs, want)
+	}
+}
+
+func TestSummary_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary Summary
+		wantErr bool
+	}{
+		{name: "valid", summary: Summary{Kind: SummaryBeforeOpen, ChannelID: "@channel", Text: "text"}},
+		{name: "unknown kind", summary: Summary{Kind: "weekly"}, wantErr: true},
+		{name: "channel id too long", summary: Summary{Kind: SummaryRecap, ChannelID: strings.Repeat("a", 65)}, wantErr: true},
+		{name: "publication id too long", summary: Summary{Kind: SummaryRecap, PublicationID: strings.Repeat("1", 65)}, wantErr: true},
+		{name: "text too long", summary: Summary{Kind: SummaryRecap, Text: strings.Repeat("a", 4097)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.summary.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
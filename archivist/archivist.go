@@ -5,10 +5,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// bulkBatchSize is the number of rows inserted by a single statement of the BulkCreate methods
+// (Postgres allows up to 65535 parameters in the statement, the widest table has about 30 columns).
+const bulkBatchSize = 1000
+
 // entities is a struct that contains all the entities that Archivist is responsible for.
 type entities struct {
-	News   *NewsDB
-	Events *EventsDB
+	News        *NewsDB
+	Events      *EventsDB
+	Mutes       *MutesDB
+	Summaries   *SummariesDB
+	Checkpoints *CheckpointsDB
+	Listings    *ListingsDB
+	Insiders    *InsiderFilingsDB
+	Pauses      *PausesDB
+	Outbox      *OutboxDB
+	Clicks      *ClicksDB
+	Stories     *StoriesDB
 }
 
 // Archivist is responsible for storing and retrieving data from the database.
@@ -20,24 +33,83 @@ type Archivist struct {
 // NewArchivist creates a new Archivist with provided DSN to connect to database.
 //
 // DSN is a string in the format of: "user=gorm password=gorm dbname=gorm port=9920 sslmode=disable".
+// Add "search_path=name" to the DSN to keep the tables in a separate schema (created if not exists).
 func NewArchivist(dsn string) (*Archivist, error) {
 	conn, err := connectToPG(dsn)
 	if err != nil {
 		return nil, err
 	}
 
+	err = migrate(conn, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archivist{db: conn, Entities: newEntities(conn)}, nil
+}
+
+// NewDryRunArchivist creates a new Archivist as NewArchivist that makes all reads and writes (including the schema
+// migration) in a single transaction, and the function that rolls it back, so nothing is persisted
+// (e.g. for the debugging run of the job against the production database).
+// Queries share one connection and Postgres fails all queries after the first failed one.
+func NewDryRunArchivist(dsn string) (*Archivist, func() error, error) {
+	conn, err := connectToPG(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := conn.Begin()
+	if tx.Error != nil {
+		return nil, nil, newError(errlvl.ERROR, errFailedDryRun, tx.Error)
+	}
+	rollback := func() error {
+		return tx.Rollback().Error
+	}
+
+	err = migrate(tx, dsn)
+	if err != nil {
+		_ = rollback()
+		return nil, nil, err
+	}
+
+	return &Archivist{db: tx, Entities: newEntities(tx)}, rollback, nil
+}
+
+// migrate creates the schema of the DSN and migrates the tables to the current models.
+func migrate(conn *gorm.DB, dsn string) error {
+	err := createSchema(conn, dsn)
+	if err != nil {
+		return err
+	}
+
+	// Duplicated events have to be removed before the unique index is created
+	err = removeDuplicateEvents(conn)
+	if err != nil {
+		return err
+	}
+
 	// Migrate the schema automatically for now.
 	// TODO: Add migration tool later.
-	err = conn.AutoMigrate(&News{}, &Event{})
+	err = conn.AutoMigrate(&News{}, &Event{}, &Mute{}, &Summary{}, &Checkpoint{}, &Listing{}, &InsiderFiling{}, &Pause{}, &OutboxMessage{}, &Click{}, &Story{})
 	if err != nil {
-		return nil, newError(errlvl.FATAL, errFailedMigration, err)
+		return newError(errlvl.FATAL, errFailedMigration, err)
 	}
 
-	return &Archivist{
-		db: conn,
-		Entities: &entities{
-			News:   NewNewsDB(conn),
-			Events: NewEventsDB(conn),
-		},
-	}, nil
+	return backfillSeriesKeys(conn)
+}
+
+func newEntities(conn *gorm.DB) *entities {
+	return &entities{
+		News:        NewNewsDB(conn),
+		Events:      NewEventsDB(conn),
+		Mutes:       NewMutesDB(conn),
+		Summaries:   NewSummariesDB(conn),
+		Checkpoints: NewCheckpointsDB(conn),
+		Listings:    NewListingsDB(conn),
+		Insiders:    NewInsiderFilingsDB(conn),
+		Pauses:      NewPausesDB(conn),
+		Outbox:      NewOutboxDB(conn),
+		Clicks:      NewClicksDB(conn),
+		Stories:     NewStoriesDB(conn),
+	}
 }
@@ -3,10 +3,12 @@ package archivist
 import (
 	"fmt"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/jackc/pgx/v5"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log/slog"
+	"strings"
 	"time"
 )
 
@@ -19,7 +21,7 @@ func connectToPG(dsn string) (*gorm.DB, error) {
 	db, err := backoff.RetryWithData[*gorm.DB](func() (*gorm.DB, error) {
 		conn, err := gorm.Open(postgres.New(postgres.Config{
 			DSN: dsn,
-		}))
+		}), &gorm.Config{Logger: newQueryLogger(slog.Default())})
 		if err != nil {
 			slog.Info("[connectToPG] Postgres not yet ready...")
 			return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
@@ -33,3 +35,33 @@ func connectToPG(dsn string) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// dsnSchema returns the first schema of the DSN search_path (empty if not set).
+func dsnSchema(dsn string) (string, error) {
+	cnf, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse DSN: %w", err)
+	}
+
+	schema, _, _ := strings.Cut(cnf.RuntimeParams["search_path"], ",")
+	return strings.Trim(strings.TrimSpace(schema), `"`), nil
+}
+
+// createSchema creates the schema from the DSN search_path, so the tables of the tenant can be kept
+// in the separate schema of the shared database.
+func createSchema(db *gorm.DB, dsn string) error {
+	schema, err := dsnSchema(dsn)
+	if err != nil {
+		return newError(errlvl.FATAL, errFailedSchemaCreation, err)
+	}
+	if schema == "" || schema == "public" || schema == "$user" {
+		return nil
+	}
+
+	res := db.Exec("CREATE SCHEMA IF NOT EXISTS " + pgx.Identifier{schema}.Sanitize())
+	if res.Error != nil {
+		return newError(errlvl.FATAL, errFailedSchemaCreation, res.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package archivist
+
+import "testing"
+
+func Test_dsnSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     string
+		want    string
+		wantErr bool
+	}{
+		{name: "no search path", dsn: "host=localhost user=postgres dbname=finfeed", want: ""},
+		{name: "key-value", dsn: "host=localhost dbname=finfeed search_path=brand_b", want: "brand_b"},
+		{name: "multiple schemas", dsn: "host=localhost dbname=finfeed search_path='brand_b, public'", want: "brand_b"},
+		{name: "url", dsn: "postgres://postgres@localhost:5432/finfeed?search_path=brand_c", want: "brand_c"},
+		{name: "invalid", dsn: "postgres://postgres@localhost:port/finfeed", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dsnSchema(tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("dsnSchema() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("dsnSchema() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,30 +9,111 @@ import (
 type archivistError error
 
 var (
-	errChannelIDTooLong     archivistError = errors.New("channel_id is too long")
-	errHashTooLong          archivistError = errors.New("hash is too long")
-	errPubIDTooLong         archivistError = errors.New("publication_id is too long")
-	errProviderNameTooLong  archivistError = errors.New("provider_name is too long")
-	errURLTooLong           archivistError = errors.New("url is too long")
-	errOriginalTitleTooLong archivistError = errors.New("original_title is too long")
-	errOriginalDescTooLong  archivistError = errors.New("original_desc is too long")
-	errComposedTextTooLong  archivistError = errors.New("composed_text is too long")
-	errOriginalDateEmpty    archivistError = errors.New("original_date is empty")
-	errTitleTooLong         archivistError = errors.New("title is too long")
-	errURLEmpty             archivistError = errors.New("url is empty")
-	errEventValidation      archivistError = errors.New("event validation failed")
-	errEventCreation        archivistError = errors.New("event creation failed")
-	errEventUpdate          archivistError = errors.New("event update failed")
-	errFindRecentEvents     archivistError = errors.New("failed to find recent events")
-	errFindUntilEvents      archivistError = errors.New("failed to find events until the given date")
-	errNewsValidation       archivistError = errors.New("news validation failed")
-	errNewsCreation         archivistError = errors.New("news creation failed")
-	errNewsUpdate           archivistError = errors.New("news update failed")
-	errNewsFindAllByHash    archivistError = errors.New("failed to find news by hash")
-	errNewsFindAllByUrls    archivistError = errors.New("failed to find news by urls")
-	errNewsFindUntil        archivistError = errors.New("failed to find news until the given date")
-	errFailedMigration      archivistError = errors.New("failed to migrate schema")
-	errFailedConnection     archivistError = errors.New("failed to connect to database")
+	errChannelIDTooLong          archivistError = errors.New("channel_id is too long")
+	errHashTooLong               archivistError = errors.New("hash is too long")
+	errPubIDTooLong              archivistError = errors.New("publication_id is too long")
+	errProviderNameTooLong       archivistError = errors.New("provider_name is too long")
+	errJobNameTooLong            archivistError = errors.New("job_name is too long")
+	errProviderIDTooLong         archivistError = errors.New("provider_id is too long")
+	errURLTooLong                archivistError = errors.New("url is too long")
+	errGUIDTooLong               archivistError = errors.New("guid is too long")
+	errArchiveURLTooLong         archivistError = errors.New("archive_url is too long")
+	errOriginalTitleTooLong      archivistError = errors.New("original_title is too long")
+	errOriginalDescTooLong       archivistError = errors.New("original_desc is too long")
+	errComposedTextTooLong       archivistError = errors.New("composed_text is too long")
+	errFilteredReasonTooLong     archivistError = errors.New("filtered_reason is too long")
+	errModerationTooLong         archivistError = errors.New("moderation_reason is too long")
+	errComplianceTooLong         archivistError = errors.New("compliance_profile is too long")
+	errComposeModelTooLong       archivistError = errors.New("compose_model is too long")
+	errRetractionTooLong         archivistError = errors.New("retraction_reason is too long")
+	errOriginalDateEmpty         archivistError = errors.New("original_date is empty")
+	errTitleTooLong              archivistError = errors.New("title is too long")
+	errURLEmpty                  archivistError = errors.New("url is empty")
+	errEventValidation           archivistError = errors.New("event validation failed")
+	errEventCreation             archivistError = errors.New("event creation failed")
+	errEventUpdate               archivistError = errors.New("event update failed")
+	errEventsDeduplication       archivistError = errors.New("failed to remove duplicated events")
+	errFindRecentEvents          archivistError = errors.New("failed to find recent events")
+	errFindEventSeries           archivistError = errors.New("failed to find event series")
+	errFindPlanEvents            archivistError = errors.New("failed to find events of the daily plan")
+	errFindUntilEvents           archivistError = errors.New("failed to find events until the given date")
+	errEventsSearch              archivistError = errors.New("failed to search events")
+	errFindUpcomingEvents        archivistError = errors.New("failed to find upcoming events")
+	errNewsValidation            archivistError = errors.New("news validation failed")
+	errNewsCreation              archivistError = errors.New("news creation failed")
+	errNewsUpdate                archivistError = errors.New("news update failed")
+	errNewsFindAllByHash         archivistError = errors.New("failed to find news by hash")
+	errNewsFindAllByUrls         archivistError = errors.New("failed to find news by urls")
+	errNewsFindUntil             archivistError = errors.New("failed to find news until the given date")
+	errNewsCount                 archivistError = errors.New("failed to count news")
+	errNewsFindLastPublished     archivistError = errors.New("failed to find last published news")
+	errNewsFindPublished         archivistError = errors.New("failed to find published news")
+	errNewsFindForFollowUp       archivistError = errors.New("failed to find news for follow up")
+	errNewsFindComposedPublished archivistError = errors.New("failed to find published composed news")
+	errNewsRecomputeHashes       archivistError = errors.New("failed to recompute news hashes")
+	errNewsMarkPending           archivistError = errors.New("failed to mark news pending publication")
+	errNewsFindUnpublished       archivistError = errors.New("failed to find unpublished news")
+	errNewsFindRecentTexts       archivistError = errors.New("failed to find recently published texts")
+	errNewsSearch                archivistError = errors.New("failed to search news")
+	errNewsFindEngaging          archivistError = errors.New("failed to find engaging news")
+	errNewsFindFlagged           archivistError = errors.New("failed to find news held for moderation")
+	errNewsApprove               archivistError = errors.New("failed to approve news")
+	errNewsRetract               archivistError = errors.New("failed to retract news")
+	errMuteKindUnknown           archivistError = errors.New("mute kind is unknown")
+	errMuteValueEmpty            archivistError = errors.New("mute value is empty")
+	errMuteValueTooLong          archivistError = errors.New("mute value is too long")
+	errMuteValidation            archivistError = errors.New("mute validation failed")
+	errMuteCreation              archivistError = errors.New("mute creation failed")
+	errMuteFind                  archivistError = errors.New("failed to find mutes")
+	errMuteDelete                archivistError = errors.New("failed to delete mute")
+	errSummaryKindUnknown        archivistError = errors.New("summary kind is unknown")
+	errSummaryTextTooLong        archivistError = errors.New("summary text is too long")
+	errSummaryValidation         archivistError = errors.New("summary validation failed")
+	errSummaryCreation           archivistError = errors.New("summary creation failed")
+	errSummaryCount              archivistError = errors.New("failed to count summaries")
+	errCheckpointNameEmpty       archivistError = errors.New("checkpoint name is empty")
+	errCheckpointNameTooLong     archivistError = errors.New("checkpoint name is too long")
+	errCheckpointValidation      archivistError = errors.New("checkpoint validation failed")
+	errCheckpointFind            archivistError = errors.New("failed to find checkpoint")
+	errCheckpointSave            archivistError = errors.New("failed to save checkpoint")
+	errTickerEmpty               archivistError = errors.New("ticker is empty")
+	errTickerTooLong             archivistError = errors.New("ticker is too long")
+	errNameTooLong               archivistError = errors.New("name is too long")
+	errListingValidation         archivistError = errors.New("listing validation failed")
+	errListingsFind              archivistError = errors.New("failed to find listings")
+	errListingsSave              archivistError = errors.New("failed to save listings")
+	errAccessionEmpty            archivistError = errors.New("accession number is empty")
+	errAccessionTooLong          archivistError = errors.New("accession number is too long")
+	errChannelIDEmpty            archivistError = errors.New("channel_id is empty")
+	errInsiderValidation         archivistError = errors.New("insider filing validation failed")
+	errInsiderCreation           archivistError = errors.New("insider filings creation failed")
+	errInsiderFind               archivistError = errors.New("failed to find insider filings")
+	errPauseJobEmpty             archivistError = errors.New("pause job is empty")
+	errPauseJobTooLong           archivistError = errors.New("pause job is too long")
+	errPauseReasonTooLong        archivistError = errors.New("pause reason is too long")
+	errPauseValidation           archivistError = errors.New("pause validation failed")
+	errPauseSave                 archivistError = errors.New("failed to save pause")
+	errPauseFind                 archivistError = errors.New("failed to find pauses")
+	errPauseDelete               archivistError = errors.New("failed to delete pause")
+	errOutboxMessageEmpty        archivistError = errors.New("outbox message is empty")
+	errOutboxMessageTooLong      archivistError = errors.New("outbox message is too long")
+	errOutboxValidation          archivistError = errors.New("outbox message validation failed")
+	errOutboxCreation            archivistError = errors.New("outbox message creation failed")
+	errOutboxFind                archivistError = errors.New("failed to find outbox messages")
+	errOutboxDelete              archivistError = errors.New("failed to delete outbox message")
+	errClickNewsIDEmpty          archivistError = errors.New("click news_id is empty")
+	errClickTooLong              archivistError = errors.New("click field is too long")
+	errClickValidation           archivistError = errors.New("click validation failed")
+	errClickCreation             archivistError = errors.New("click creation failed")
+	errClickFind                 archivistError = errors.New("failed to find clicks")
+	errStoryValidation           archivistError = errors.New("story validation failed")
+	errStoryCreation             archivistError = errors.New("story creation failed")
+	errStoryUpdate               archivistError = errors.New("story update failed")
+	errStoryFind                 archivistError = errors.New("failed to find stories")
+	errFailedMigration           archivistError = errors.New("failed to migrate schema")
+	errFailedDryRun              archivistError = errors.New("failed to begin dry run transaction")
+	errFailedConnection          archivistError = errors.New("failed to connect to database")
+	errFailedSchemaCreation      archivistError = errors.New("failed to create schema")
 )
 
 // newError creates a wrapped error instance with the given errors.
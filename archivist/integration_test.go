@@ -0,0 +1,419 @@
+//go:build integration
+
+package archivist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Integration tests run the Archivist against the real Postgres (in Docker).
+// Run them with `make test-integration` (requires Docker).
+
+func TestIntegration_NewsDB_BulkCreate(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	now := time.Now().UTC()
+	news := make([]*News, 0, bulkBatchSize+500)
+	for i := range cap(news) {
+		news = append(news, &News{
+			Hash:          fmt.Sprintf("hash-%d", i),
+			URL:           fmt.Sprintf("https://example.com/news/%d", i),
+			OriginalTitle: fmt.Sprintf("News %d", i),
+			OriginalDate:  now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	if err := a.Entities.News.Create(ctx, []*News{{Hash: "hash-0", URL: "https://example.com/other", OriginalDate: now}}); err != nil {
+		t.Fatal(err)
+	}
+
+	inserted, err := a.Entities.News.BulkCreate(ctx, news)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted != int64(len(news)-1) {
+		t.Errorf("BulkCreate() inserted %d news, want %d without the existing hash", inserted, len(news)-1)
+	}
+
+	// Invalid news roll back the whole insert
+	invalid := []*News{
+		{Hash: "hash-new", URL: "https://example.com/new", OriginalDate: now},
+		{Hash: "hash-invalid", OriginalDate: now},
+	}
+	if _, err := a.Entities.News.BulkCreate(ctx, invalid); err == nil {
+		t.Error("BulkCreate() of the news without URL error = nil")
+	}
+	if found, err := a.Entities.News.FindAllByHashes(ctx, []string{"hash-new"}); err != nil || len(found) != 0 {
+		t.Errorf("FindAllByHashes() = %v, %v, want the valid news of the failed insert rolled back", found, err)
+	}
+}
+
+func TestIntegration_EventsDB_BulkCreate(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	date := time.Date(2024, 1, 2, 13, 30, 0, 0, time.UTC)
+	existing := &Event{ChannelID: "@test", Title: "CPI", DateTime: date, Currency: "USD", Actual: "3.1%"}
+	if err := a.Entities.Events.Create(ctx, []*Event{existing}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := []*Event{
+		{ChannelID: "@test", Title: "CPI", DateTime: date, Currency: "USD", Actual: "9.9%"},
+		{ChannelID: "@test", Title: "Nonfarm Payrolls", DateTime: date.Add(72 * time.Hour), Currency: "USD"},
+		{ChannelID: "@test", Title: "Nonfarm Payrolls", DateTime: date.Add(72 * time.Hour), Currency: "USD"},
+	}
+	inserted, err := a.Entities.Events.BulkCreate(ctx, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted != 1 {
+		t.Errorf("BulkCreate() inserted %d events, want 1", inserted)
+	}
+
+	var cpi Event
+	if err := a.Entities.Events.Conn.WithContext(ctx).Where("title = ?", "CPI").First(&cpi).Error; err != nil {
+		t.Fatal(err)
+	}
+	if cpi.Actual != "3.1%" {
+		t.Errorf("existing event actual = %q, want it kept", cpi.Actual)
+	}
+}
+
+func TestIntegration_EventsDB_Create(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	date := time.Date(2024, 1, 2, 13, 30, 0, 0, time.UTC)
+	existing := &Event{ChannelID: "@test", Title: "CPI", DateTime: date, Currency: "USD"}
+	if err := a.Entities.Events.Create(ctx, []*Event{existing}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := []*Event{
+		{ChannelID: "@test", Title: "Nonfarm Payrolls", DateTime: date.Add(72 * time.Hour), Currency: "USD"},
+		{ChannelID: "@test", Title: "CPI", DateTime: date, Currency: "USD", Forecast: "3.2%"},
+		{ChannelID: "@test", Title: "CPI", DateTime: date, Currency: "USD", Forecast: "3.3%"},
+	}
+	if err := a.Entities.Events.Create(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	if events[1].ID != existing.ID || events[2].ID != existing.ID {
+		t.Errorf("Create() IDs = %v, %v, want the stored event ID %v", events[1].ID, events[2].ID, existing.ID)
+	}
+
+	// Updates by the returned IDs reach the stored rows
+	events[0].Actual = "216K"
+	if err := a.Entities.Events.Update(ctx, events[0]); err != nil {
+		t.Fatal(err)
+	}
+	var nfp Event
+	if err := a.Entities.Events.Conn.WithContext(ctx).Where("title = ?", "Nonfarm Payrolls").First(&nfp).Error; err != nil {
+		t.Fatal(err)
+	}
+	if nfp.ID != events[0].ID || nfp.Actual != "216K" {
+		t.Errorf("stored event = %v %q, want %v %q", nfp.ID, nfp.Actual, events[0].ID, "216K")
+	}
+}
+
+func TestIntegration_backfillSeriesKeys(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	date := time.Date(2024, 1, 2, 13, 30, 0, 0, time.UTC)
+	events := []*Event{
+		{ChannelID: "@test", Title: "Core CPI m/m", DateTime: date, Currency: "USD", Country: "United States"},
+		{ChannelID: "@test", Title: "ECB Rate", DateTime: date, Currency: "EUR", Country: "European Union"},
+	}
+	if err := a.Entities.Events.Create(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.db.Model(&Event{}).Where("1 = 1").UpdateColumn("series_key", "").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backfillSeriesKeys(a.db); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		var stored Event
+		if err := a.db.First(&stored, "id = ?", e.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if want := SeriesKey(e.Title, e.Country); stored.SeriesKey != want {
+			t.Errorf("SeriesKey of %q = %q, want %q", e.Title, stored.SeriesKey, want)
+		}
+	}
+}
+
+func TestIntegration_NewsDB_Retract(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	now := time.Now().UTC()
+	published := &News{Hash: "published", URL: "https://example.com/published", ChannelID: "@test", PublicationID: "42", PublishedAt: now, OriginalDate: now}
+	unpublished := &News{Hash: "unpublished", URL: "https://example.com/unpublished", OriginalDate: now}
+	if err := a.Entities.News.Create(ctx, []*News{published, unpublished}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := a.Entities.News.Retract(ctx, unpublished.ID, "duplicate"); err != nil || ok {
+		t.Errorf("Retract() of the unpublished news = %v, %v, want false", ok, err)
+	}
+	if ok, err := a.Entities.News.Retract(ctx, published.ID, "duplicate"); err != nil || !ok {
+		t.Fatalf("Retract() = %v, %v, want true", ok, err)
+	}
+	if ok, err := a.Entities.News.Retract(ctx, published.ID, "wrong"); err != nil || ok {
+		t.Errorf("Retract() of the retracted news = %v, %v, want false", ok, err)
+	}
+
+	if n, err := a.Entities.News.FindPublished(ctx, published.ID); err != nil || n != nil {
+		t.Errorf("FindPublished() = %v, %v, want the retracted news hidden", n, err)
+	}
+	var n News
+	if err := a.Entities.News.Conn.WithContext(ctx).Where("id = ?", published.ID).First(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n.RetractedAt.IsZero() || n.RetractionReason != "duplicate" {
+		t.Errorf("retracted news = %v %q, want the date and the first reason", n.RetractedAt, n.RetractionReason)
+	}
+}
+
+func TestIntegration_NewsDB_Approve(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	now := time.Now().UTC()
+	flagged := &News{
+		Hash: "flagged", URL: "https://example.com/flagged", ComposedText: "Apple revenue up 80%",
+		ModerationReason: "unverified numbers: 80%", OriginalDate: now,
+	}
+	if err := a.Entities.News.Create(ctx, []*News{flagged}); err != nil {
+		t.Fatal(err)
+	}
+	err := a.Entities.News.Conn.WithContext(ctx).Model(&News{}).Where("id = ?", flagged.ID).
+		UpdateColumn("created_at", now.Add(-24*time.Hour)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := a.Entities.News.Approve(ctx, flagged.ID); err != nil || !ok {
+		t.Fatalf("Approve() = %v, %v, want true", ok, err)
+	}
+	pending, err := a.Entities.News.FindComposedUnpublished(ctx, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != flagged.ID {
+		t.Errorf("FindComposedUnpublished() = %v, want the news approved a day after its creation", pending)
+	}
+}
+
+func TestIntegration_NewsDB_RecomputeHashes(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	// More than one batch with the creation dates in the reverse order of the IDs
+	now := time.Now().UTC()
+	news := make([]*News, 0, 1200)
+	for i := range cap(news) {
+		news = append(news, &News{
+			Hash:          fmt.Sprintf("old-%d", i),
+			URL:           fmt.Sprintf("https://example.com/news/%d", i),
+			OriginalTitle: fmt.Sprintf("News %d", i),
+			OriginalDate:  now,
+		})
+	}
+	if _, err := a.Entities.News.BulkCreate(ctx, news); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range news {
+		err := a.Entities.News.Conn.WithContext(ctx).Model(&News{}).Where("id = ?", n.ID).
+			UpdateColumn("created_at", now.Add(-time.Duration(i)*time.Minute)).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, conflicts, err := a.Entities.News.RecomputeHashes(ctx, func(n *News) string {
+		return "new-" + n.URL
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != len(news) || conflicts != 0 {
+		t.Errorf("RecomputeHashes() = %d, %d, want %d updated without conflicts", updated, conflicts, len(news))
+	}
+
+	var stale int64
+	if err := a.Entities.News.Conn.WithContext(ctx).Model(&News{}).Where("hash LIKE 'old-%'").Count(&stale).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stale != 0 {
+		t.Errorf("%d news kept the old hash, want all rewritten", stale)
+	}
+}
+
+func TestIntegration_ClicksDB_FindClickedNews(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	now := time.Now().UTC()
+	popular, single, tenant := uuid.New(), uuid.New(), uuid.New()
+	for _, c := range []*Click{
+		{NewsID: popular, ChannelID: "@main"},
+		{NewsID: popular, ChannelID: "@main"},
+		{NewsID: single, ChannelID: "@main"},
+		{NewsID: tenant, ChannelID: "@tenant"},
+		{NewsID: tenant, ChannelID: "@tenant"},
+		{NewsID: single, ChannelID: "@main", CreatedAt: now.Add(-48 * time.Hour)},
+	} {
+		if err := a.Entities.Clicks.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := a.Entities.Clicks.FindClickedNews(ctx, "@main", now.Add(-time.Hour), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != popular {
+		t.Errorf("FindClickedNews() = %v, want only the news clicked twice in the channel since the date", ids)
+	}
+	if ids, err := a.Entities.Clicks.FindClickedNews(ctx, "@tenant", now.Add(-time.Hour), 2); err != nil || len(ids) != 1 {
+		t.Errorf("FindClickedNews() of the tenant = %v, %v, want its news", ids, err)
+	}
+
+	counts, err := a.Entities.Clicks.CountByNews(ctx, []uuid.UUID{popular, single, uuid.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || counts[popular] != 2 || counts[single] != 2 {
+		t.Errorf("CountByNews() = %v, want 2 clicks of each clicked news", counts)
+	}
+
+	// Nothing is found among the news without clicks
+	if n, err := a.Entities.News.FindEngaging(ctx, now.Add(-time.Hour), []uuid.UUID{}); err != nil || len(n) != 0 {
+		t.Errorf("FindEngaging() = %v, %v, want none", n, err)
+	}
+}
+
+func TestIntegration_StoriesDB_AddPart(t *testing.T) {
+	ctx := context.Background()
+	a := newTestArchivist(t)
+
+	story := &Story{ChannelID: "@main", Title: "Apple faces EU fine", Topics: []string{"AAPL"}, Parts: 1}
+	if err := a.Entities.Stories.Create(ctx, story); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent jobs of the channel get the distinct parts
+	parts := make([]int, 10)
+	errs := make([]error, len(parts))
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parts[i], errs[i] = a.Entities.Stories.AddPart(ctx, story.ID)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(parts)
+	if want := []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}; !slices.Equal(parts, want) {
+		t.Errorf("AddPart() = %v, want %v", parts, want)
+	}
+
+	// Only the latest part is given back
+	if err := a.Entities.Stories.RemovePart(ctx, story.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Entities.Stories.RemovePart(ctx, story.ID, 11); err != nil {
+		t.Fatal(err)
+	}
+	if part, err := a.Entities.Stories.AddPart(ctx, story.ID); err != nil || part != 11 {
+		t.Errorf("AddPart() after RemovePart() = %d, %v, want 11", part, err)
+	}
+}
+
+func TestIntegration_NewDryRunArchivist(t *testing.T) {
+	ctx := context.Background()
+	dsn := newTestDSN(t)
+	a, err := NewArchivist(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dry, rollback, err := NewDryRunArchivist(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	if err := dry.Entities.News.Create(ctx, []*News{{Hash: "dry", URL: "https://example.com/dry", OriginalDate: now}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := a.Entities.News.FindAllByHashes(ctx, []string{"dry"}); err != nil || len(found) != 0 {
+		t.Errorf("FindAllByHashes() = %v, %v, want the news of the dry run rolled back", found, err)
+	}
+}
+
+// newTestArchivist starts the Postgres container and creates the Archivist connected to it.
+func newTestArchivist(t *testing.T) *Archivist {
+	t.Helper()
+
+	a, err := NewArchivist(newTestDSN(t))
+	if err != nil {
+		t.Fatalf("error creating archivist: %v", err)
+	}
+
+	return a
+}
+
+// newTestDSN starts the Postgres container and returns its DSN.
+func newTestDSN(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	pg, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("finthread"),
+		postgres.WithUsername("finthread"),
+		postgres.WithPassword("finthread"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute),
+		),
+	)
+	if err != nil {
+		t.Fatalf("error starting postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pg.Terminate(ctx); err != nil {
+			t.Errorf("error terminating postgres: %v", err)
+		}
+	})
+
+	dsn, err := pg.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dsn
+}
@@ -0,0 +1,72 @@
+package archivist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log/slog"
+	"time"
+)
+
+const (
+	slowQueryThreshold = 500 * time.Millisecond // queries taking longer are logged as slow
+	loggedQueryLength  = 1000                   // max length of the logged SQL (bulk inserts are huge)
+)
+
+// queryLogger is the gorm logger that writes the slow and failed queries to slog instead of the standard output.
+// gorm.ErrRecordNotFound is not logged, it is the expected result of the lookups.
+type queryLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	silent        bool // set by gorm with logger.Silent for the internal queries
+}
+
+func newQueryLogger(l *slog.Logger) *queryLogger {
+	return &queryLogger{logger: l, slowThreshold: slowQueryThreshold}
+}
+
+func (l *queryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	c := *l
+	c.silent = level == logger.Silent
+	return &c
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, data ...any) {
+	if !l.silent {
+		l.logger.InfoContext(ctx, "[archivist] "+fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if !l.silent {
+		l.logger.WarnContext(ctx, "[archivist] "+fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, data ...any) {
+	if !l.silent {
+		l.logger.ErrorContext(ctx, "[archivist] "+fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs the query if it failed or took longer than the slow threshold.
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "[archivist] query failed",
+			"error", err, "elapsed", elapsed, "rows", rows, "sql", utils.Truncate(sql, loggedQueryLength))
+	case elapsed > l.slowThreshold:
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "[archivist] slow query",
+			"elapsed", elapsed, "rows", rows, "sql", utils.Truncate(sql, loggedQueryLength))
+	}
+}
@@ -0,0 +1,50 @@
+package archivist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryLogger_Trace(t *testing.T) {
+	sql := func() (string, int64) { return `SELECT * FROM "news" WHERE published_at >= '2024-01-02'`, 3 }
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		err     error
+		silent  bool
+		want    string
+	}{
+		{name: "fast query", elapsed: time.Millisecond},
+		{name: "slow query", elapsed: time.Second, want: "slow query"},
+		{name: "failed query", elapsed: time.Millisecond, err: errors.New("relation does not exist"), want: "query failed"},
+		{name: "record not found", elapsed: time.Millisecond, err: gorm.ErrRecordNotFound},
+		{name: "silent mode", elapsed: time.Second, silent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var l logger.Interface = newQueryLogger(slog.New(slog.NewTextHandler(&out, nil)))
+			if tt.silent {
+				l = l.LogMode(logger.Silent)
+			}
+
+			l.Trace(context.Background(), time.Now().Add(-tt.elapsed), sql, tt.err)
+
+			got := out.String()
+			if tt.want == "" && got != "" {
+				t.Errorf("Trace() logged %q, want nothing", got)
+			}
+			if tt.want != "" && (!strings.Contains(got, tt.want) || !strings.Contains(got, "published_at") || !strings.Contains(got, "rows=3")) {
+				t.Errorf("Trace() logged %q, want %q with the query", got, tt.want)
+			}
+		})
+	}
+}
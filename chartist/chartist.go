@@ -0,0 +1,100 @@
+// Package chartist renders price charts that can be attached to the published news.
+package chartist
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+	"time"
+)
+
+const (
+	chartWidth  = 800
+	chartHeight = 400
+)
+
+var (
+	colorUp            = drawing.ColorFromHex("16a34a") // price is above the previous close
+	colorDown          = drawing.ColorFromHex("dc2626") // price is below the previous close
+	colorPreviousClose = drawing.ColorFromHex("9ca3af")
+)
+
+// Intraday renders the intraday price chart of the ticker with the previous close line as a PNG image.
+func Intraday(data *quotes.Intraday) ([]byte, error) {
+	if data == nil || len(data.Points) < 2 {
+		return nil, errlvl.Wrap(fmt.Errorf("not enough prices to render the chart"), errlvl.WARN)
+	}
+
+	xValues := make([]time.Time, 0, len(data.Points))
+	yValues := make([]float64, 0, len(data.Points))
+	for _, p := range data.Points {
+		xValues = append(xValues, p.Time)
+		yValues = append(yValues, p.Price)
+	}
+
+	color := colorUp
+	if data.Change() < 0 {
+		color = colorDown
+	}
+
+	series := []chart.Series{
+		chart.TimeSeries{
+			Name:    data.Ticker,
+			XValues: xValues,
+			YValues: yValues,
+			Style: chart.Style{
+				StrokeColor: color,
+				StrokeWidth: 2,
+				FillColor:   color.WithAlpha(32),
+			},
+		},
+	}
+	if data.PreviousClose > 0 {
+		series = append(series, chart.TimeSeries{
+			Name:    "Previous close",
+			XValues: []time.Time{xValues[0], xValues[len(xValues)-1]},
+			YValues: []float64{data.PreviousClose, data.PreviousClose},
+			Style: chart.Style{
+				StrokeColor:     colorPreviousClose,
+				StrokeWidth:     1,
+				StrokeDashArray: []float64{5, 5},
+			},
+		})
+	}
+
+	graph := chart.Chart{
+		Title:  fmt.Sprintf("%s %s", data.Ticker, formatChange(data.Change())),
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 50, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeHourValueFormatter,
+		},
+		YAxis: chart.YAxis{
+			ValueFormatter: func(v interface{}) string {
+				return fmt.Sprintf("%.2f", v)
+			},
+		},
+		Series: series,
+	}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error rendering %s chart: %w", data.Ticker, err), errlvl.ERROR)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatChange formats the percentage change with the sign, e.g. "+4.30%".
+func formatChange(change float64) string {
+	if change > 0 {
+		return fmt.Sprintf("+%.2f%%", change)
+	}
+	return fmt.Sprintf("%.2f%%", change)
+}
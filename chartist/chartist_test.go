@@ -0,0 +1,55 @@
+package chartist
+
+import (
+	"bytes"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestIntraday(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 14, 30, 0, 0, time.UTC)
+	data := &quotes.Intraday{
+		Ticker:        "NVDA",
+		PreviousClose: 100,
+		Points: []quotes.Point{
+			{Time: start, Price: 101},
+			{Time: start.Add(5 * time.Minute), Price: 99.5},
+			{Time: start.Add(10 * time.Minute), Price: 104.3},
+		},
+	}
+
+	b, err := Intraday(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Intraday() returned invalid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != chartWidth || img.Bounds().Dy() != chartHeight {
+		t.Errorf("Intraday() image size = %v, want %dx%d", img.Bounds(), chartWidth, chartHeight)
+	}
+
+	if _, err := Intraday(&quotes.Intraday{Ticker: "NVDA", Points: data.Points[:1]}); err == nil {
+		t.Errorf("Intraday() should return error for a single price")
+	}
+}
+
+func Test_formatChange(t *testing.T) {
+	tests := []struct {
+		change float64
+		want   string
+	}{
+		{change: 4.3, want: "+4.30%"},
+		{change: -1.234, want: "-1.23%"},
+		{change: 0, want: "0.00%"},
+	}
+	for _, tt := range tests {
+		if got := formatChange(tt.change); got != tt.want {
+			t.Errorf("formatChange(%v) = %v, want %v", tt.change, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	// EmbeddingModel is the OpenAI model of the embeddings used to rank the headlines by relevance.
+	EmbeddingModel  = openai.SmallEmbedding3
+	answerMaxTokens = 512
+)
+
+// Rank returns up to the limit of the headlines most relevant to the query by the cosine similarity
+// of their embeddings, the most relevant first.
+func (c *Composer) Rank(ctx context.Context, query string, headlines []*Headline, limit int) ([]*Headline, error) {
+	if len(headlines) == 0 {
+		return nil, nil
+	}
+
+	input := make([]string, 0, len(headlines)+1)
+	input = append(input, query)
+	for _, h := range headlines {
+		input = append(input, h.Text)
+	}
+
+	resp, err := c.OpenAiClient.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{Input: input, Model: EmbeddingModel})
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, "Rank", "OpenAiClient.CreateEmbeddings")
+	}
+
+	if len(resp.Data) != len(input) {
+		return nil, newError(
+			fmt.Errorf("got %d embeddings for %d inputs", len(resp.Data), len(input)),
+			errlvl.WARN,
+			"Rank",
+			"OpenAiClient.CreateEmbeddings",
+		)
+	}
+
+	// Embeddings can be returned in any order, their index is the index of the input
+	vectors := make([][]float32, len(input))
+	for _, e := range resp.Data {
+		if e.Index < 0 || e.Index >= len(input) {
+			return nil, newError(fmt.Errorf("unknown embedding index %d", e.Index), errlvl.WARN, "Rank", "OpenAiClient.CreateEmbeddings")
+		}
+		vectors[e.Index] = e.Embedding
+	}
+
+	type scored struct {
+		headline *Headline
+		score    float64
+	}
+	ranked := make([]scored, 0, len(headlines))
+	for i, h := range headlines {
+		ranked = append(ranked, scored{headline: h, score: cosineSimilarity(vectors[0], vectors[i+1])})
+	}
+	slices.SortStableFunc(ranked, func(a, b scored) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	result := make([]*Headline, 0, min(limit, len(ranked)))
+	for _, r := range ranked[:min(limit, len(ranked))] {
+		result = append(result, r.headline)
+	}
+
+	return result, nil
+}
+
+// cosineSimilarity returns the cosine similarity of the vectors (0 for empty or zero vectors).
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Answer answers the question about the channel archive using only the given headlines (posts and events
+// with dates and links), citing the links of the used posts. Returns plain text.
+func (c *Composer) Answer(ctx context.Context, question string, headlines []*Headline, opts ...Option) (string, error) {
+	config := c.snapshot(opts)
+
+	if strings.TrimSpace(question) == "" {
+		return "", newError(errors.New("question can't be empty"), errlvl.ERROR, "Answer", "question")
+	}
+
+	jsonHeadlines, err := json.Marshal(headlines)
+	if err != nil {
+		return "", newError(err, errlvl.ERROR, "Answer", "json.Marshal")
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: config.AnswerPrompt(),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Question: %s\nPosts: %s", question, jsonHeadlines),
+			},
+		},
+		Temperature: 0.3,
+		MaxTokens:   answerMaxTokens,
+	}
+	config.route(TaskAnswer, &req)
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", newError(err, errlvl.WARN, "Answer", "OpenAiClient.CreateChatCompletion")
+	}
+
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", newError(errors.New("empty response"), errlvl.WARN, "Answer", "OpenAiClient.CreateChatCompletion")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
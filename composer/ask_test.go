@@ -0,0 +1,69 @@
+package composer
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestComposer_Rank(t *testing.T) {
+	c := NewSandboxComposer()
+	headlines := []*Headline{
+		{ID: "1", Text: "Fed holds rates steady"},
+		{ID: "2", Text: "TSMC raises capex to $30 billion"},
+		{ID: "3", Text: "Apple faces EU fine"},
+		{ID: "4", Text: "TSMC beats revenue estimates"},
+	}
+
+	got, err := c.Rank(context.Background(), "TSMC capex", headlines, 2)
+	if err != nil {
+		t.Fatalf("Rank() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0].ID != "2" || got[1].ID != "4" {
+		t.Errorf("Rank() = %v, want headlines 2 and 4", got)
+	}
+}
+
+func Test_cosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{name: "same direction", a: []float32{1, 2}, b: []float32{2, 4}, want: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 1}, want: 0},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 1}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("cosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposer_Answer(t *testing.T) {
+	client := &answersClient{answers: []string{" TSMC raised capex on May 2 (https://t.me/channel/42). "}}
+	c := &Composer{OpenAiClient: client, Config: defaultPromptConfig()}
+	headlines := []*Headline{{ID: "1", Text: "TSMC raises capex", Link: "https://t.me/channel/42", Date: "2024-05-02"}}
+
+	got, err := c.Answer(context.Background(), "when did we last post about TSMC capex?", headlines)
+	if err != nil {
+		t.Fatalf("Answer() error = %v", err)
+	}
+	if got != "TSMC raised capex on May 2 (https://t.me/channel/42)." {
+		t.Errorf("Answer() = %q, want the trimmed answer", got)
+	}
+
+	user := client.requests[0].Messages[1].Content
+	if !strings.Contains(user, "when did we last post about TSMC capex?") || !strings.Contains(user, `"date":"2024-05-02"`) {
+		t.Errorf("Answer() user message = %q, want the question and the dated posts", user)
+	}
+
+	if _, err := c.Answer(context.Background(), " ", headlines); err == nil {
+		t.Error("Answer() error = nil, want error for the empty question")
+	}
+}
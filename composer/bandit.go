@@ -0,0 +1,219 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Arm is one of the provider models the Bandit can choose for Compose.
+type Arm struct {
+	Provider string  // AI provider of the model (see Providers), ProviderOpenAI if empty
+	Model    string  // model name, unique across the arms
+	Cost     float64 // relative cost of one call (e.g. 1 for gpt-4o-mini and 15 for gpt-4o), must be positive
+}
+
+// Outcome is the quality signal of one Compose call made with the chosen model.
+type Outcome struct {
+	ParseFailed bool // the answer couldn't be parsed even after the repair request
+	Composed    int  // composed news with IDs from the input
+	Unknown     int  // composed news with IDs that were never in the input (hallucinations)
+}
+
+// armStats holds the collected quality signals of the Arm.
+type armStats struct {
+	Arm
+	calls         int
+	parseFailures int
+	composed      int
+	unknown       int
+	engagement    float64 // sum of the engagement scores
+	engagements   int     // number of the engagement scores
+}
+
+// quality returns the quality of the arm answers (0..1): share of parsed answers
+// multiplied by the share of known IDs and by the average engagement (if any).
+func (a *armStats) quality() float64 {
+	q := 1.0
+	if a.calls > 0 {
+		q *= 1 - float64(a.parseFailures)/float64(a.calls)
+	}
+	if a.composed+a.unknown > 0 {
+		q *= 1 - float64(a.unknown)/float64(a.composed+a.unknown)
+	}
+	if a.engagements > 0 {
+		// Engagement adjusts the quality, but never zeroes it out
+		q *= (1 + a.engagement/float64(a.engagements)) / 2
+	}
+
+	return q
+}
+
+// score returns the cost-adjusted quality of the arm.
+func (a *armStats) score() float64 {
+	return a.quality() / a.Cost
+}
+
+// Bandit is an epsilon-greedy multi-armed bandit that gradually shifts Compose traffic
+// to the model with the best cost-adjusted quality (parse failure rate, hallucinated IDs rate and engagement).
+// Every model is tried minCalls times first, then the best one is chosen with 1-epsilon probability
+// and a random one otherwise. The manual override (see Bandit.Override) disables the exploration.
+// Stats are kept in memory, so the bandit starts exploring again after restart. Bandit is safe for concurrent use.
+type Bandit struct {
+	mu       sync.Mutex
+	arms     []*armStats
+	epsilon  float64    // probability of choosing a random arm
+	minCalls int        // number of calls of each arm before exploitation
+	override string     // model to use regardless of the stats (empty for automatic choice)
+	rand     *rand.Rand // random source for the exploration
+}
+
+// NewBandit creates a new Bandit for the given arms with the exploration probability epsilon (0..1).
+func NewBandit(arms []Arm, epsilon float64) (*Bandit, error) {
+	if len(arms) == 0 {
+		return nil, errors.New("bandit needs at least one arm")
+	}
+	if epsilon < 0 || epsilon > 1 {
+		return nil, fmt.Errorf("bandit epsilon must be in range 0..1, got %v", epsilon)
+	}
+
+	b := &Bandit{
+		epsilon:  epsilon,
+		minCalls: 5,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+	for _, a := range arms {
+		if a.Model == "" || a.Cost <= 0 {
+			return nil, fmt.Errorf("bandit arm %q must have a name and positive cost", a.Model)
+		}
+		if a.Provider == "" {
+			a.Provider = ProviderOpenAI
+		}
+		if _, ok := ParseProvider(a.Provider); !ok {
+			return nil, fmt.Errorf("bandit arm %q has unknown provider %q", a.Model, a.Provider)
+		}
+		if b.arm(a.Model) != nil {
+			return nil, fmt.Errorf("bandit arm %q is duplicated", a.Model)
+		}
+		b.arms = append(b.arms, &armStats{Arm: a})
+	}
+
+	return b, nil
+}
+
+// Choose returns the provider model for the next Compose call.
+func (b *Bandit) Choose() Arm {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.override != "" {
+		return b.arm(b.override).Arm
+	}
+
+	for _, a := range b.arms {
+		if a.calls < b.minCalls {
+			return a.Arm
+		}
+	}
+
+	if b.rand.Float64() < b.epsilon {
+		return b.arms[b.rand.Intn(len(b.arms))].Arm
+	}
+
+	best := b.arms[0]
+	for _, a := range b.arms[1:] {
+		if a.score() > best.score() {
+			best = a
+		}
+	}
+
+	return best.Arm
+}
+
+// Override sets the model that will be always chosen. Empty model returns the automatic choice.
+// The model must be one of the arms.
+func (b *Bandit) Override(model string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if model != "" && b.arm(model) == nil {
+		return fmt.Errorf("unknown model %q, use one of: %s", model, strings.Join(b.models(), ", "))
+	}
+	b.override = model
+
+	return nil
+}
+
+// Record adds the outcome of the Compose call to the model stats. Unknown models are ignored.
+func (b *Bandit) Record(model string, o Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a := b.arm(model)
+	if a == nil {
+		return
+	}
+
+	a.calls++
+	if o.ParseFailed {
+		a.parseFailures++
+	}
+	a.composed += o.Composed
+	a.unknown += o.Unknown
+}
+
+// RecordEngagement adds the engagement score (0..1) of the news composed by the model, e.g. relative clicks
+// (see jobs.EngagementJob). Unknown models are ignored.
+func (b *Bandit) RecordEngagement(model string, score float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a := b.arm(model)
+	if a == nil {
+		return
+	}
+
+	a.engagement += min(max(score, 0), 1)
+	a.engagements++
+}
+
+// String returns the human-readable stats of the arms.
+func (b *Bandit) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var sb strings.Builder
+	if b.override != "" {
+		sb.WriteString(fmt.Sprintf("Compose model: %s (manual)", b.override))
+	} else {
+		sb.WriteString(fmt.Sprintf("Compose model: auto (epsilon %.2f)", b.epsilon))
+	}
+	for _, a := range b.arms {
+		sb.WriteString(fmt.Sprintf("\n- %s (%s): calls %d, parse failures %d, unknown IDs %d/%d, engaged posts %d, quality %.2f, score %.3f",
+			a.Model, a.Provider, a.calls, a.parseFailures, a.unknown, a.composed+a.unknown, a.engagements, a.quality(), a.score()))
+	}
+
+	return sb.String()
+}
+
+func (b *Bandit) arm(model string) *armStats {
+	for _, a := range b.arms {
+		if a.Model == model {
+			return a
+		}
+	}
+
+	return nil
+}
+
+func (b *Bandit) models() []string {
+	models := make([]string, len(b.arms))
+	for i, a := range b.arms {
+		models[i] = a.Model
+	}
+
+	return models
+}
@@ -0,0 +1,223 @@
+package composer
+
+import (
+	"context"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/samgozman/fin-thread/journalist"
+	"strings"
+	"testing"
+	"time"
+)
+
+type togetherAIAnswers struct {
+	answers  []string
+	requests []togetherAIRequest
+}
+
+func (a *togetherAIAnswers) CreateChatCompletion(_ context.Context, req togetherAIRequest) (*TogetherAIResponse, error) {
+	a.requests = append(a.requests, req)
+	resp := &TogetherAIResponse{}
+	if len(a.answers) > 0 {
+		resp.Choices = append(resp.Choices, struct {
+			Text string `json:"text"`
+		}{Text: a.answers[0]})
+		a.answers = a.answers[1:]
+	}
+	return resp, nil
+}
+
+type geminiAnswers struct {
+	answers  []string
+	requests []GoogleGeminiRequest
+}
+
+func (a *geminiAnswers) CreateChatCompletion(_ context.Context, req GoogleGeminiRequest) (*genai.GenerateContentResponse, error) {
+	a.requests = append(a.requests, req)
+	resp := &genai.GenerateContentResponse{}
+	if len(a.answers) > 0 {
+		resp.Candidates = []*genai.Candidate{{Content: &genai.Content{Parts: []genai.Part{genai.Text(a.answers[0])}}}}
+		a.answers = a.answers[1:]
+	}
+	return resp, nil
+}
+
+func TestNewBandit(t *testing.T) {
+	tests := []struct {
+		name    string
+		arms    []Arm
+		epsilon float64
+		wantErr bool
+	}{
+		{name: "valid", arms: []Arm{{Model: "gpt-4o-mini", Cost: 1}}, epsilon: 0.1},
+		{name: "no arms", epsilon: 0.1, wantErr: true},
+		{name: "zero cost", arms: []Arm{{Model: "gpt-4o-mini"}}, epsilon: 0.1, wantErr: true},
+		{name: "invalid epsilon", arms: []Arm{{Model: "gpt-4o-mini", Cost: 1}}, epsilon: 2, wantErr: true},
+		{name: "other provider", arms: []Arm{{Provider: ProviderGemini, Model: "gemini-pro", Cost: 1}}, epsilon: 0.1},
+		{name: "unknown provider", arms: []Arm{{Provider: "Mistral", Model: "mistral-large", Cost: 1}}, epsilon: 0.1, wantErr: true},
+		{name: "duplicated model", arms: []Arm{{Model: "gpt-4o", Cost: 1}, {Model: "gpt-4o", Cost: 2}}, epsilon: 0.1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBandit(tt.arms, tt.epsilon); (err != nil) != tt.wantErr {
+				t.Errorf("NewBandit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBandit_Choose(t *testing.T) {
+	b, err := NewBandit([]Arm{{Model: "cheap", Cost: 1}, {Model: "smart", Cost: 2}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every arm is explored first
+	if got := b.Choose().Model; got != "cheap" {
+		t.Errorf("Choose() = %s, want cheap", got)
+	}
+	for i := 0; i < b.minCalls; i++ {
+		b.Record("cheap", Outcome{Composed: 1, Unknown: 4})
+	}
+	if got := b.Choose().Model; got != "smart" {
+		t.Errorf("Choose() = %s, want smart to be explored", got)
+	}
+	for i := 0; i < b.minCalls; i++ {
+		b.Record("smart", Outcome{Composed: 5})
+	}
+
+	// cheap: quality 0.2 / cost 1, smart: quality 1 / cost 2
+	if got := b.Choose().Model; got != "smart" {
+		t.Errorf("Choose() = %s, want smart with the best score", got)
+	}
+
+	if err := b.Override("cheap"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Choose().Model; got != "cheap" {
+		t.Errorf("Choose() = %s, want overridden cheap", got)
+	}
+	if err := b.Override("unknown"); err == nil {
+		t.Errorf("Override() should fail for unknown model")
+	}
+	if err := b.Override(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Choose().Model; got != "smart" {
+		t.Errorf("Choose() = %s, want smart after override reset", got)
+	}
+}
+
+func TestArmStats_quality(t *testing.T) {
+	tests := []struct {
+		name string
+		arm  armStats
+		want float64
+	}{
+		{name: "no data", arm: armStats{}, want: 1},
+		{name: "parse failures", arm: armStats{calls: 4, parseFailures: 1, composed: 3}, want: 0.75},
+		{name: "unknown IDs", arm: armStats{calls: 2, composed: 9, unknown: 1}, want: 0.9},
+		{name: "engagement", arm: armStats{calls: 1, composed: 1, engagement: 1, engagements: 2}, want: 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arm.quality(); got != tt.want {
+				t.Errorf("quality() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposer_Compose_bandit(t *testing.T) {
+	bandit, err := NewBandit([]Arm{{Model: "gpt-4o", Cost: 1}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &answersClient{answers: []string{
+		`[{"id":"1","text":"Apple"},{"id":"42","text":"Fed"}]`,
+		`not a json`,
+		`still not a json`,
+	}}
+	c := (&Composer{OpenAiClient: client, Config: defaultPromptConfig()}).WithBandit(bandit)
+	news := journalist.NewsList{{ID: "1", Title: "Apple beats estimates", Date: time.Now()}}
+
+	if _, err := c.Compose(context.Background(), news); err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+	if client.requests[0].Model != "gpt-4o" {
+		t.Errorf("Compose() model = %s, want gpt-4o chosen by the bandit", client.requests[0].Model)
+	}
+
+	if _, err := c.Compose(context.Background(), news); err == nil {
+		t.Fatalf("Compose() should fail for unparsable answer")
+	}
+
+	want := "- gpt-4o (OpenAI): calls 2, parse failures 1, unknown IDs 1/2"
+	if got := bandit.String(); !strings.Contains(got, want) {
+		t.Errorf("Bandit.String() = %s, want to contain %s", got, want)
+	}
+}
+
+func TestComposer_Compose_banditProviders(t *testing.T) {
+	news := journalist.NewsList{{ID: "1", Title: "Apple beats estimates", Date: time.Now()}}
+
+	tests := []struct {
+		name     string
+		arm      Arm
+		requests func(openAI *answersClient, together *togetherAIAnswers, gemini *geminiAnswers) int
+	}{
+		{
+			name:     "OpenAI",
+			arm:      Arm{Model: "gpt-4o", Cost: 1},
+			requests: func(o *answersClient, _ *togetherAIAnswers, _ *geminiAnswers) int { return len(o.requests) },
+		},
+		{
+			name:     "TogetherAI",
+			arm:      Arm{Provider: ProviderTogetherAI, Model: "mistralai/Mixtral-8x7B-Instruct-v0.1", Cost: 1},
+			requests: func(_ *answersClient, t *togetherAIAnswers, _ *geminiAnswers) int { return len(t.requests) },
+		},
+		{
+			name:     "GoogleGemini",
+			arm:      Arm{Provider: ProviderGemini, Model: "gemini-pro", Cost: 1},
+			requests: func(_ *answersClient, _ *togetherAIAnswers, g *geminiAnswers) int { return len(g.requests) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bandit, err := NewBandit([]Arm{tt.arm}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			answer := `[{"id":"1","text":"Apple"}]`
+			openAI := &answersClient{answers: []string{answer, "not a json"}}
+			together := &togetherAIAnswers{answers: []string{answer, "not a json"}}
+			gemini := &geminiAnswers{answers: []string{answer, "not a json"}}
+			c := (&Composer{
+				OpenAiClient:       openAI,
+				TogetherAIClient:   together,
+				GoogleGeminiClient: gemini,
+				Config:             defaultPromptConfig(),
+			}).WithBandit(bandit)
+
+			composed, err := c.Compose(context.Background(), news)
+			if err != nil {
+				t.Fatalf("Compose() error = %v", err)
+			}
+			if len(composed) != 1 || composed[0].Model != tt.arm.Model {
+				t.Fatalf("Compose() = %v, want one news composed by %s", composed, tt.arm.Model)
+			}
+			if got := tt.requests(openAI, together, gemini); got != 1 {
+				t.Errorf("Compose() sent %d requests to %s, want 1", got, tt.name)
+			}
+
+			if _, err := c.Compose(context.Background(), news); err == nil {
+				t.Fatalf("Compose() should fail for unparsable answer")
+			}
+			want := "calls 2, parse failures 1"
+			if got := bandit.String(); !strings.Contains(got, want) {
+				t.Errorf("Bandit.String() = %s, want to contain %s", got, want)
+			}
+		})
+	}
+}
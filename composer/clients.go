@@ -11,11 +11,13 @@ import (
 	"google.golang.org/api/option"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // openAiClientInterface is an interface for OpenAI API client.
 type openAiClientInterface interface {
 	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (response openai.ChatCompletionResponse, err error)
+	CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (res openai.EmbeddingResponse, err error)
 }
 
 // togetherAIClientInterface is an interface for TogetherAI API client.
@@ -125,6 +127,7 @@ type GoogleGeminiClientInterface interface {
 
 // GoogleGeminiRequest is a struct that contains options for Google Gemini API requests.
 type GoogleGeminiRequest struct {
+	Model       string  `json:"model"` // gemini-pro if empty
 	Prompt      string  `json:"prompt"`
 	MaxTokens   int32   `json:"max_tokens"`
 	Temperature float32 `json:"temperature"`
@@ -159,7 +162,11 @@ func (g *GoogleGemini) CreateChatCompletion(ctx context.Context, req GoogleGemin
 		}
 	}(client)
 
-	model := client.GenerativeModel("gemini-pro")
+	name := req.Model
+	if name == "" {
+		name = "gemini-pro"
+	}
+	model := client.GenerativeModel(name)
 	model.SetTemperature(req.Temperature)
 	model.SetTopP(req.TopP)
 	model.SetTopK(req.TopK)
@@ -177,3 +184,19 @@ func (g *GoogleGemini) CreateChatCompletion(ctx context.Context, req GoogleGemin
 
 	return resp, nil
 }
+
+// geminiText returns the text of the first Google Gemini answer candidate (empty if there is none).
+func geminiText(resp *genai.GenerateContentResponse) string {
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			sb.WriteString(string(text))
+		}
+	}
+
+	return sb.String()
+}
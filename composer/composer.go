@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
@@ -18,26 +19,163 @@ import (
 
 // Composer is used to compose (rephrase) news and events, find some meta information about them,
 // filter out some unnecessary stuff, summarise them and so on.
+//
+// Composer is safe for concurrent use by multiple jobs: AI clients don't hold any per-call state,
+// Config is never modified after the Composer is created (With* methods return a copy)
+// and each call works with its own config snapshot with the per-call options applied.
+// Config must not be modified directly once the Composer is shared.
 type Composer struct {
 	OpenAiClient       openAiClientInterface
 	TogetherAIClient   togetherAIClientInterface
 	GoogleGeminiClient GoogleGeminiClientInterface
 	Config             *promptConfig
+	Metrics            *Metrics // AI answers quality counters (shared between copies, optional)
+	Bandit             *Bandit  // chooses the Compose model by its cost-adjusted quality (shared between copies, optional)
 }
 
 // NewComposer creates a new Composer instance with OpenAI and TogetherAI clients and default config.
 func NewComposer(oaiToken, tgrAiToken, geminiToken string) *Composer {
-	return &Composer{
-		OpenAiClient:       openai.NewClient(oaiToken),
-		TogetherAIClient:   NewTogetherAI(tgrAiToken),
-		GoogleGeminiClient: NewGoogleGemini(geminiToken),
-		Config:             defaultPromptConfig(),
+	c := &Composer{
+		OpenAiClient:     openai.NewClient(oaiToken),
+		TogetherAIClient: NewTogetherAI(tgrAiToken),
+		Config:           defaultPromptConfig(),
+		Metrics:          &Metrics{},
+	}
+
+	// Gemini token is optional
+	if geminiToken != "" {
+		c.GoogleGeminiClient = NewGoogleGemini(geminiToken)
 	}
+
+	return c
+}
+
+// With returns a copy of the Composer with the given options applied to its config.
+// The copy shares the same AI clients, the original Composer is not changed.
+func (c *Composer) With(opts ...Option) *Composer {
+	composer := *c
+	composer.Config = c.snapshot(opts)
+
+	return &composer
+}
+
+// WithMaxComposedLength returns a copy of the Composer with the target max length of the composed text in characters.
+// Longer texts will be truncated by the sentence boundary.
+func (c *Composer) WithMaxComposedLength(length int) *Composer {
+	return c.With(UseMaxComposedLength(length))
+}
+
+// WithExamples returns a copy of the Composer which uses the given few-shot examples set
+// for Compose and Filter prompts. It is used to select different examples for each job
+// while sharing the same AI clients. Nil set means no examples.
+func (c *Composer) WithExamples(set *ExampleSet) *Composer {
+	return c.With(UseExamples(set))
+}
+
+// WithGlossary returns a copy of the Composer with the channel glossary (preferred phrasings, banned words and tone)
+// which will be injected into Compose and Summarise prompts.
+func (c *Composer) WithGlossary(glossary *Glossary) *Composer {
+	return c.With(UseGlossary(glossary))
 }
 
+// WithBandit returns a copy of the Composer which chooses the Compose model with the given bandit
+// and reports the outcome of each ComposeWithModel call to it. Nil bandit means openai.GPT4oMini for Compose.
+func (c *Composer) WithBandit(bandit *Bandit) *Composer {
+	composer := *c
+	composer.Bandit = bandit
+
+	return &composer
+}
+
+// Ping sends one cheap completion request to each configured AI provider
+// and returns the result of each request by the provider name.
+func (c *Composer) Ping(ctx context.Context) map[string]error {
+	const prompt = "Answer with one word: ping"
+	result := make(map[string]error)
+
+	if c.OpenAiClient != nil {
+		_, err := c.OpenAiClient.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:     openai.GPT4oMini,
+			Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
+			MaxTokens: 1,
+		})
+		if err != nil {
+			err = newError(err, errlvl.ERROR, "Ping", "OpenAiClient.CreateChatCompletion")
+		}
+		result[ProviderOpenAI] = err
+	}
+
+	if c.TogetherAIClient != nil {
+		resp, err := c.TogetherAIClient.CreateChatCompletion(ctx, togetherAIRequest{
+			Model:     "mistralai/Mixtral-8x7B-Instruct-v0.1",
+			Prompt:    fmt.Sprintf("[INST]%s[/INST]", prompt),
+			MaxTokens: 1,
+		})
+		if err == nil && len(resp.Choices) == 0 {
+			err = errors.New("empty response")
+		}
+		if err != nil {
+			err = newError(err, errlvl.ERROR, "Ping", "TogetherAIClient.CreateChatCompletion")
+		}
+		result[ProviderTogetherAI] = err
+	}
+
+	if c.GoogleGeminiClient != nil {
+		_, err := c.GoogleGeminiClient.CreateChatCompletion(ctx, GoogleGeminiRequest{
+			Prompt:    prompt,
+			MaxTokens: 1,
+		})
+		if err != nil {
+			err = newError(err, errlvl.ERROR, "Ping", "GoogleGeminiClient.CreateChatCompletion")
+		}
+		result[ProviderGemini] = err
+	}
+
+	return result
+}
+
+// Models used for the two-stage compose (Composer.Select, then Composer.ComposeWithModel).
+const (
+	SelectModel  = openai.GPT4oMini // cheap model used to select news from the whole batch
+	ComposeModel = openai.GPT4o     // stronger model used to compose only the selected news
+)
+
 // Compose creates a new AI-composed news from the given news list.
 // It will also find some meta information about the news and events (markets, tickers, hashtags).
-func (c *Composer) Compose(ctx context.Context, news journalist.NewsList) ([]*ComposedNews, error) {
+// The provider model is chosen by the Composer.Bandit if it is set and no model is routed for TaskCompose (see UseModelRoutes).
+func (c *Composer) Compose(ctx context.Context, news journalist.NewsList, opts ...Option) ([]*ComposedNews, error) {
+	arm := Arm{Provider: ProviderOpenAI, Model: openai.GPT4oMini}
+	if c.Bandit != nil && c.snapshot(opts).Models[TaskCompose] == nil {
+		arm = c.Bandit.Choose()
+	}
+
+	return c.compose(ctx, news, arm.Provider, arm.Model, opts...)
+}
+
+// ComposeWithModel is the same as Compose, but uses the given OpenAI model.
+// The model routed for TaskCompose (see UseModelRoutes) takes precedence over the given one.
+func (c *Composer) ComposeWithModel(
+	ctx context.Context,
+	news journalist.NewsList,
+	model string,
+	opts ...Option,
+) ([]*ComposedNews, error) {
+	return c.compose(ctx, news, ProviderOpenAI, model, opts...)
+}
+
+// compose composes the news with the model of the given provider (see Providers).
+// The routed models are served by ProviderOpenAI, so the routed model replaces both of them.
+func (c *Composer) compose(
+	ctx context.Context,
+	news journalist.NewsList,
+	provider, model string,
+	opts ...Option,
+) ([]*ComposedNews, error) {
+	config := c.snapshot(opts)
+	if config.Models[TaskCompose] != nil {
+		provider = ProviderOpenAI
+	}
+
 	// RemoveDuplicates out news that are not from today
 	var todayNews journalist.NewsList = lo.Filter(news, func(n *journalist.News, _ int) bool {
 		return n.Date.Day() == time.Now().Day()
@@ -47,36 +185,105 @@ func (c *Composer) Compose(ctx context.Context, news journalist.NewsList) ([]*Co
 		return nil, nil
 	}
 
-	// Convert news to JSON
-	preFilteredNews := todayNews.RemoveFlagged()
-	jsonNews, err := preFilteredNews.ToContentJSON()
+	// Convert news to JSON payloads that fit the model context window
+	builder := &promptBuilder{
+		provider:  provider,
+		model:     model,
+		maxTokens: 2048,
+		system:    config.composeSystemPrompt(),
+		examples:  config.Examples.compose(),
+		scrub:     config.scrubs(provider),
+	}
+	builder.use(config.Models[TaskCompose])
+	model = builder.model
+	input := todayNews.RemoveFlagged()
+	composed, err := c.composeAll(ctx, builder, input)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Compose", "NewsList.ToContentJSON")
-	}
-
-	// Compose news
-	resp, err := c.OpenAiClient.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4oMini,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: c.Config.ComposePrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: jsonNews,
-				},
-			},
-			Temperature:      1,
-			MaxTokens:        2048,
-			TopP:             1,
-			FrequencyPenalty: 0,
-			PresencePenalty:  0,
-			Stop:             []string{"#"}, // Stop on hashtags in text
-		},
-	)
+		if c.Bandit != nil && isParseError(err) {
+			c.Bandit.Record(model, Outcome{ParseFailed: true})
+		}
+		return nil, err
+	}
+
+	// AI can return IDs that were never in the input, which will not match any news later
+	fullComposedNews, missing, unknown, duplicates := matchIDs(input, composed)
+	c.Metrics.recordAnswer(unknown, duplicates)
+	if c.Bandit != nil {
+		c.Bandit.Record(model, Outcome{Composed: len(fullComposedNews), Unknown: unknown})
+	}
+
+	// Retry once only for the news whose IDs were probably mangled
+	if unknown > 0 && len(missing) > 0 {
+		retried, err := c.composeAll(ctx, builder, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		retriedNews, stillMissing, unknown, duplicates := matchIDs(missing, retried)
+		c.Metrics.recordAnswer(unknown, duplicates)
+		c.Metrics.recordRetry(len(stillMissing))
+		fullComposedNews = append(fullComposedNews, retriedNews...)
+	} else {
+		c.Metrics.recordMissing(len(missing))
+	}
+
+	for _, n := range fullComposedNews {
+		// Fix unicode symbols in tickers
+		for i, t := range n.Tickers {
+			n.Tickers[i] = utils.ReplaceUnicodeSymbols(t)
+		}
+
+		// Markets are free-form in AI answer (SPY vs S&P500 vs ES)
+		n.Markets = NormalizeMarkets(n.Markets)
+		n.Hashtags = marketsHashtags(n.Markets, n.Hashtags)
+
+		// AI doesn't always respect the target length
+		n.Text = truncateText(n.Text, config.ComposeMaxLength)
+		n.Model = model
+	}
+
+	return fullComposedNews, nil
+}
+
+// composeAll composes the news in batches that fit the model context window.
+func (c *Composer) composeAll(ctx context.Context, builder *promptBuilder, news journalist.NewsList) ([]*ComposedNews, error) {
+	payloads, err := builder.payloads(news)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Compose", "promptBuilder.payloads")
+	}
+
+	var result []*ComposedNews
+	for _, jsonNews := range payloads {
+		composed, err := c.composeBatch(ctx, builder, jsonNews)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, composed...)
+	}
+
+	return result, nil
+}
+
+// composeBatch composes one JSON payload of news prepared by the promptBuilder.
+func (c *Composer) composeBatch(ctx context.Context, builder *promptBuilder, jsonNews string) ([]*ComposedNews, error) {
+	switch builder.provider {
+	case ProviderTogetherAI:
+		return c.composeBatchTogetherAI(ctx, builder, jsonNews)
+	case ProviderGemini:
+		return c.composeBatchGemini(ctx, builder, jsonNews)
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:            builder.model,
+		Messages:         chatMessages(builder.system, builder.examples, jsonNews),
+		Temperature:      builder.temperatureOr(1),
+		MaxTokens:        builder.maxTokens,
+		TopP:             1,
+		FrequencyPenalty: 0,
+		PresencePenalty:  0,
+		Stop:             []string{"#"}, // Stop on hashtags in text
+	}
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return nil, newError(err, errlvl.WARN, "Compose", "OpenAiClient.CreateChatCompletion")
 	}
@@ -85,25 +292,135 @@ func (c *Composer) Compose(ctx context.Context, news journalist.NewsList) ([]*Co
 		return nil, newError(errors.New("empty response"), errlvl.WARN, "Compose", "OpenAiClient.CreateChatCompletion")
 	}
 
-	matches, err := aiJSONStringFixer(resp.Choices[0].Message.Content)
+	var composed []*ComposedNews
+	if err := c.unmarshalAnswer(ctx, "Compose", req, resp.Choices[0].Message.Content, &composed); err != nil {
+		return nil, err
+	}
+
+	return composed, nil
+}
+
+// composeBatchTogetherAI is the same as composeBatch, but for the ProviderTogetherAI models.
+// The answer is not repaired, because the repair request is only supported by OpenAI.
+func (c *Composer) composeBatchTogetherAI(ctx context.Context, builder *promptBuilder, jsonNews string) ([]*ComposedNews, error) {
+	if c.TogetherAIClient == nil {
+		return nil, newError(errors.New("client is not configured"), errlvl.ERROR, "Compose", "TogetherAIClient")
+	}
+
+	resp, err := c.TogetherAIClient.CreateChatCompletion(ctx, togetherAIRequest{
+		Model:             builder.model,
+		Prompt:            instPrompt(builder.system, builder.examples, jsonNews),
+		MaxTokens:         builder.maxTokens,
+		Temperature:       float64(builder.temperatureOr(1)),
+		TopP:              1,
+		RepetitionPenalty: 1,
+		Stop:              []string{"</s>", "[INST]"},
+	})
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Compose", "aiJSONStringFixer")
+		return nil, newError(err, errlvl.WARN, "Compose", "TogetherAIClient.CreateChatCompletion")
 	}
 
-	var fullComposedNews []*ComposedNews
-	err = json.Unmarshal([]byte(matches), &fullComposedNews)
+	if len(resp.Choices) == 0 {
+		return nil, newError(errors.New("empty response"), errlvl.WARN, "Compose", "TogetherAIClient.CreateChatCompletion")
+	}
+
+	var composed []*ComposedNews
+	if err := parseAnswer(resp.Choices[0].Text, &composed); err != nil {
+		return nil, newError(err, errlvl.ERROR, "Compose", "parseAnswer").WithValue(resp.Choices[0].Text)
+	}
+
+	return composed, nil
+}
+
+// composeBatchGemini is the same as composeBatch, but for the ProviderGemini models.
+// The answer is not repaired, because the repair request is only supported by OpenAI.
+func (c *Composer) composeBatchGemini(ctx context.Context, builder *promptBuilder, jsonNews string) ([]*ComposedNews, error) {
+	if c.GoogleGeminiClient == nil {
+		return nil, newError(errors.New("client is not configured"), errlvl.ERROR, "Compose", "GoogleGeminiClient")
+	}
+
+	resp, err := c.GoogleGeminiClient.CreateChatCompletion(ctx, GoogleGeminiRequest{
+		Model:       builder.model,
+		Prompt:      textPrompt(builder.system, builder.examples, jsonNews),
+		MaxTokens:   int32(builder.maxTokens), //nolint:gosec
+		Temperature: builder.temperatureOr(1),
+		TopP:        1,
+	})
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Compose", "json.Unmarshal").WithValue(matches)
+		return nil, newError(err, errlvl.WARN, "Compose", "GoogleGeminiClient.CreateChatCompletion")
 	}
 
-	for _, n := range fullComposedNews {
-		// Fix unicode symbols in tickers
-		for i, t := range n.Tickers {
-			n.Tickers[i] = utils.ReplaceUnicodeSymbols(t)
+	answer := geminiText(resp)
+	if answer == "" {
+		return nil, newError(errors.New("empty response"), errlvl.WARN, "Compose", "GoogleGeminiClient.CreateChatCompletion")
+	}
+
+	var composed []*ComposedNews
+	if err := parseAnswer(answer, &composed); err != nil {
+		return nil, newError(err, errlvl.ERROR, "Compose", "parseAnswer").WithValue(answer)
+	}
+
+	return composed, nil
+}
+
+// Select is the first (cheap) stage of the two-stage compose: it ranks the whole news batch
+// and keeps only up to `limit` most important news. The news list is returned with IsFiltered flag
+// set to true for news that were not selected, so only the selected subset will be composed.
+func (c *Composer) Select(ctx context.Context, news journalist.NewsList, limit int, opts ...Option) (journalist.NewsList, error) {
+	config := c.snapshot(opts)
+
+	if len(news) == 0 {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		return nil, errors.New("limit must be greater than 0")
+	}
+
+	preFilteredNews := news.RemoveFlagged()
+	if len(preFilteredNews) == 0 {
+		return news, nil
+	}
+
+	builder := &promptBuilder{
+		model:     SelectModel,
+		maxTokens: 1024,
+		system:    config.SelectPrompt(limit),
+		scrub:     config.scrubs(ProviderOpenAI),
+	}
+	builder.use(config.Models[TaskSelect])
+	payloads, err := builder.payloads(preFilteredNews)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "Select", "promptBuilder.payloads")
+	}
+
+	// If the batch was split, each part is ranked separately and the earlier parts win
+	var selected []*filterDecision
+	for _, jsonNews := range payloads {
+		s, err := c.decideBatch(ctx, "Select", builder, 0.2, jsonNews)
+		if err != nil {
+			return nil, err
 		}
+		selected = append(selected, s...)
 	}
 
-	return fullComposedNews, nil
+	// Keep only the first `limit` selected news in case AI returned more
+	selectedMap := make(map[string]bool, limit)
+	for _, d := range selected {
+		if len(selectedMap) == limit {
+			break
+		}
+		selectedMap[d.ID] = true
+	}
+
+	for _, n := range preFilteredNews {
+		if !selectedMap[n.ID] {
+			n.IsFiltered = true
+			n.FilteredReason = string(FilterReasonNotSelected)
+		}
+	}
+
+	return news, nil
 }
 
 // Summarise create a short AI summary for the Headline array of any kind.
@@ -113,7 +430,38 @@ func (c *Composer) Compose(ctx context.Context, news journalist.NewsList) ([]*Co
 //
 // `maxTokens` is used to limit summary size in tokens. It is the hard limit for AI and also used
 // for dynamically decide how many sentences AI should produce.
-func (c *Composer) Summarise(ctx context.Context, headlines []*Headline, headlinesLimit, maxTokens int) ([]*SummarisedHeadline, error) {
+func (c *Composer) Summarise(
+	ctx context.Context,
+	headlines []*Headline,
+	headlinesLimit, maxTokens int,
+	opts ...Option,
+) ([]*SummarisedHeadline, error) {
+	config := c.snapshot(opts)
+	return c.summarise(ctx, "Summarise", config, config.summariseSystemPrompt, headlines, headlinesLimit, maxTokens)
+}
+
+// Recap creates a short AI recap of the trading day for the Headline array (e.g. published news after the market close).
+// It works the same way as Summarise, but uses the RecapPrompt variant written in the past tense.
+func (c *Composer) Recap(
+	ctx context.Context,
+	headlines []*Headline,
+	headlinesLimit, maxTokens int,
+	opts ...Option,
+) ([]*SummarisedHeadline, error) {
+	config := c.snapshot(opts)
+	return c.summarise(ctx, "Recap", config, config.recapSystemPrompt, headlines, headlinesLimit, maxTokens)
+}
+
+// summarise sends the headlines to OpenAI with the system prompt built for headlinesLimit.
+// fnName is used as the error source of the public method.
+func (c *Composer) summarise(
+	ctx context.Context,
+	fnName string,
+	config *promptConfig,
+	systemPrompt summarisePromptFunc,
+	headlines []*Headline,
+	headlinesLimit, maxTokens int,
+) ([]*SummarisedHeadline, error) {
 	if len(headlines) == 0 {
 		return nil, nil
 	}
@@ -128,110 +476,184 @@ func (c *Composer) Summarise(ctx context.Context, headlines []*Headline, headlin
 
 	jsonHeadlines, err := json.Marshal(headlines)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Summarise", "json.Marshal headlines").WithValue(fmt.Sprintf("%+v", headlines))
-	}
-
-	resp, err := c.OpenAiClient.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4oMini,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: c.Config.SummarisePrompt(headlinesLimit),
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: string(jsonHeadlines),
-				},
+		return nil, newError(err, errlvl.ERROR, fnName, "json.Marshal headlines").WithValue(fmt.Sprintf("%+v", headlines))
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt(headlinesLimit),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: string(jsonHeadlines),
 			},
-			Temperature:      1,
-			MaxTokens:        maxTokens,
-			TopP:             0.7,
-			FrequencyPenalty: 0,
-			PresencePenalty:  0,
 		},
-	)
+		Temperature:      1,
+		MaxTokens:        maxTokens,
+		TopP:             0.7,
+		FrequencyPenalty: 0,
+		PresencePenalty:  0,
+	}
+	config.route(TaskSummarise, &req)
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return nil, newError(err, errlvl.WARN, "Summarise", "OpenAiClient.CreateChatCompletion")
+		return nil, newError(err, errlvl.WARN, fnName, "OpenAiClient.CreateChatCompletion")
 	}
 
 	if len(resp.Choices) == 0 {
-		return nil, newError(errors.New("empty response"), errlvl.WARN, "Summarise", "OpenAiClient.CreateChatCompletion")
+		return nil, newError(errors.New("empty response"), errlvl.WARN, fnName, "OpenAiClient.CreateChatCompletion")
 	}
 
-	matches, err := aiJSONStringFixer(resp.Choices[0].Message.Content)
-	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Summarise", "aiJSONStringFixer")
+	var h []*SummarisedHeadline
+	if err := c.unmarshalAnswer(ctx, fnName, req, resp.Choices[0].Message.Content, &h); err != nil {
+		return nil, err
 	}
 
-	var h []*SummarisedHeadline
-	err = json.Unmarshal([]byte(matches), &h)
+	return h, nil
+}
+
+// Translate translates the formatted news post into the given language (e.g. "Spanish") for the mirror channel.
+// Markdown formatting, links, hashtags and tickers of the post are kept unchanged.
+func (c *Composer) Translate(ctx context.Context, text, language string, opts ...Option) (string, error) {
+	config := c.snapshot(opts)
+
+	if text == "" {
+		return "", nil
+	}
+
+	if language == "" {
+		return "", newError(errors.New("language can't be empty"), errlvl.ERROR, "Translate", "language")
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: config.TranslatePrompt(language),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: text,
+			},
+		},
+		Temperature: 0.3,
+		MaxTokens:   translateMaxTokens,
+	}
+	config.route(TaskTranslate, &req)
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Summarise", "json.Unmarshal").WithValue(resp.Choices[0].Message.Content)
+		return "", newError(err, errlvl.WARN, "Translate", "OpenAiClient.CreateChatCompletion")
 	}
 
-	return h, nil
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", newError(errors.New("empty response"), errlvl.WARN, "Translate", "OpenAiClient.CreateChatCompletion")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
 }
 
-// Filter removes unnecessary news from the given news list using TogetherAI API
-// and returns the same news list with IsFiltered flag set to true for filtered out news.
-func (c *Composer) Filter(ctx context.Context, news journalist.NewsList) (journalist.NewsList, error) {
-	if len(news) == 0 {
+// NormalizeEventTitles translates the economic event titles (e.g. from the German source) to the standard English names.
+// Returns the map of the original title to its name, titles missing in the AI answer are not included.
+func (c *Composer) NormalizeEventTitles(ctx context.Context, titles []string, opts ...Option) (map[string]string, error) {
+	config := c.snapshot(opts)
+
+	if len(titles) == 0 {
 		return nil, nil
 	}
 
-	preFilteredNews := news.RemoveFlagged()
-	jsonNews, err := preFilteredNews.ToContentJSON()
+	jsonTitles, err := json.Marshal(titles)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Filter", "ToContentJSON").WithValue(fmt.Sprintf("%+v", news))
-	}
-
-	resp, err := c.OpenAiClient.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4oMini,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: c.Config.FilterPrompt(),
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: jsonNews,
-				},
+		return nil, newError(err, errlvl.ERROR, "NormalizeEventTitles", "json.Marshal titles")
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4oMini,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: config.EventTitlesPrompt(),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: string(jsonTitles),
 			},
-			Temperature:      0.7,
-			MaxTokens:        2048,
-			TopP:             0.7,
-			FrequencyPenalty: 0,
-			PresencePenalty:  0,
 		},
-	)
-
+		Temperature: 0,
+		MaxTokens:   2048,
+	}
+	config.route(TaskEventTitles, &req)
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return nil, newError(err, errlvl.WARN, "Filter", "OpenAiClient.CreateChatCompletion")
+		return nil, newError(err, errlvl.WARN, "NormalizeEventTitles", "OpenAiClient.CreateChatCompletion")
 	}
 
 	if len(resp.Choices) == 0 {
-		return nil, newError(errors.New("empty response"), errlvl.WARN, "Filter", "OpenAiClient.CreateChatCompletion")
+		return nil, newError(errors.New("empty response"), errlvl.WARN, "NormalizeEventTitles", "OpenAiClient.CreateChatCompletion")
 	}
 
-	matches, err := aiJSONStringFixer(resp.Choices[0].Message.Content)
-	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Filter", "aiJSONStringFixer")
+	var names []struct {
+		Title string `json:"title"`
+		Name  string `json:"name"`
+	}
+	if err := c.unmarshalAnswer(ctx, "NormalizeEventTitles", req, resp.Choices[0].Message.Content, &names); err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]bool, len(titles))
+	for _, t := range titles {
+		requested[t] = true
+	}
+
+	result := make(map[string]string, len(names))
+	for _, n := range names {
+		if requested[n.Title] && strings.TrimSpace(n.Name) != "" {
+			result[n.Title] = strings.TrimSpace(n.Name)
+		}
 	}
 
-	var chosenByAi journalist.NewsList
-	err = json.Unmarshal([]byte(matches), &chosenByAi)
+	return result, nil
+}
+
+// Filter removes unnecessary news from the given news list using TogetherAI API
+// and returns the same news list with IsFiltered flag set to true for filtered out news.
+func (c *Composer) Filter(ctx context.Context, news journalist.NewsList, opts ...Option) (journalist.NewsList, error) {
+	config := c.snapshot(opts)
+
+	if len(news) == 0 {
+		return nil, nil
+	}
+
+	preFilteredNews := news.RemoveFlagged()
+	builder := &promptBuilder{
+		model:     config.FilterModel,
+		maxTokens: 2048,
+		system:    config.FilterPrompt(),
+		examples:  config.Examples.filter(),
+		scrub:     config.scrubs(ProviderOpenAI),
+	}
+	builder.use(config.Models[TaskFilter])
+	payloads, err := builder.payloads(preFilteredNews)
 	if err != nil {
-		return nil, newError(err, errlvl.ERROR, "Filter", "json.Unmarshal").WithValue(resp.Choices[0].Message.Content)
+		return nil, newError(err, errlvl.ERROR, "Filter", "promptBuilder.payloads")
 	}
 
-	// Create a map of chosenByAi news IDs to quickly find them
-	chosenMap := make(map[string]*journalist.News)
-	for _, n := range chosenByAi {
-		chosenMap[n.ID] = n
+	var decisions []*filterDecision
+	for _, jsonNews := range payloads {
+		d, err := c.decideBatch(ctx, "Filter", builder, 0.7, jsonNews)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d...)
+	}
+
+	// Create a map of AI decisions by news IDs to quickly find them
+	decisionsMap := make(map[string]*filterDecision)
+	for _, d := range decisions {
+		decisionsMap[d.ID] = d
 	}
 
 	preFilteredMap := make(map[string]*journalist.News)
@@ -239,25 +661,102 @@ func (c *Composer) Filter(ctx context.Context, news journalist.NewsList) (journa
 		preFilteredMap[n.ID] = n
 	}
 
-	// Add IsFiltered flag to the original news list if it is NOT chosen by AI (filtered out)
+	// Add IsFiltered flag and the reason to the original news list if it is NOT chosen by AI (filtered out)
 	for _, n := range news {
-		_, isChosen := chosenMap[n.ID]
-		_, isPreFiltered := preFilteredMap[n.ID]
-
 		// Mark news as filtered only if it wasn't removed by pre-filtering before
-		if !isChosen && isPreFiltered {
+		if _, isPreFiltered := preFilteredMap[n.ID]; !isPreFiltered {
+			continue
+		}
+
+		d, ok := decisionsMap[n.ID]
+		switch {
+		case !ok:
+			// News omitted from the answer are considered removed without explanation
+			n.IsFiltered = true
+			n.FilteredReason = string(FilterReasonLowValue)
+		case d.Reason != "":
 			n.IsFiltered = true
+			n.FilteredReason = string(parseFilterReason(d.Reason))
 		}
 	}
 
 	return news, nil
 }
 
+// decideBatch sends one JSON payload of news prepared by the promptBuilder and parses AI decisions about them.
+// It is used by Select and Filter which have the same answer format.
+func (c *Composer) decideBatch(
+	ctx context.Context,
+	fnName string,
+	builder *promptBuilder,
+	temperature float32,
+	jsonNews string,
+) ([]*filterDecision, error) {
+	req := openai.ChatCompletionRequest{
+		Model:            builder.model,
+		Messages:         chatMessages(builder.system, builder.examples, jsonNews),
+		Temperature:      builder.temperatureOr(temperature),
+		MaxTokens:        builder.maxTokens,
+		TopP:             0.7,
+		FrequencyPenalty: 0,
+		PresencePenalty:  0,
+	}
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, newError(err, errlvl.WARN, fnName, "OpenAiClient.CreateChatCompletion")
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, newError(errors.New("empty response"), errlvl.WARN, fnName, "OpenAiClient.CreateChatCompletion")
+	}
+
+	var decisions []*filterDecision
+	if err := c.unmarshalAnswer(ctx, fnName, req, resp.Choices[0].Message.Content, &decisions); err != nil {
+		return nil, err
+	}
+
+	return decisions, nil
+}
+
+// FilterReason is the reason code why the news was removed by Composer.Filter.
+type FilterReason string
+
+const (
+	FilterReasonClickbait     FilterReason = "clickbait"
+	FilterReasonAdvertisement FilterReason = "advertisement"
+	FilterReasonNonFinancial  FilterReason = "non-financial"
+	FilterReasonDuplicate     FilterReason = "duplicate"
+	FilterReasonLowValue      FilterReason = "low-value"
+	FilterReasonNotSelected   FilterReason = "not-selected" // news was not selected by Composer.Select
+)
+
+// filterDecision is the AI decision about a single news in Composer.Filter.
+type filterDecision struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"` // empty if the news should be kept
+}
+
+// parseFilterReason maps the free-form AI reason to the known FilterReason (FilterReasonLowValue by default).
+func parseFilterReason(reason string) FilterReason {
+	r := FilterReason(strings.ToLower(strings.TrimSpace(reason)))
+	switch r {
+	case FilterReasonClickbait, FilterReasonAdvertisement, FilterReasonNonFinancial, FilterReasonDuplicate, FilterReasonLowValue:
+		return r
+	case "advertising", "ad", "ads":
+		return FilterReasonAdvertisement
+	case "non financial", "nonfinancial":
+		return FilterReasonNonFinancial
+	default:
+		return FilterReasonLowValue
+	}
+}
+
 // Headline is the base data structure for the data to summarise.
 type Headline struct {
 	ID   string `json:"id"`
 	Text string `json:"text"`
 	Link string `json:"link"`
+	Date string `json:"date,omitempty"` // date of the news or event, set only for the archive questions (see Composer.Answer)
 }
 
 // SummarisedHeadline is the base data structure of summarised news or events.
@@ -274,12 +773,14 @@ type ComposedNews struct {
 	ID       string   `json:"id"`
 	Text     string   `json:"text"`
 	Tickers  []string `json:"tickers"`  // tickers mentioned or/and related to the news
-	Markets  []string `json:"markets"`  // US/EU/Asia stocks, bonds, commodities, housing, etc.
+	Markets  []string `json:"markets"`  // US/EU/Asia stocks, bonds, commodities, housing, etc. (canonical IDs, see NormalizeMarkets)
 	Hashtags []string `json:"hashtags"` // hashtags related to the news (#inflation, #fed, #buybacks, etc.)
+	Model    string   `json:"-"`        // OpenAI model that composed the news (not a part of the AI answer)
 }
 
 type ComposedMeta struct {
 	Tickers  []string `json:"tickers"`
 	Markets  []string `json:"markets"`
 	Hashtags []string `json:"hashtags"`
+	Sectors  []string `json:"sectors,omitempty"` // sectors of the Tickers (enriched from the stocks data, not composed)
 }
@@ -23,6 +23,11 @@ func (m *MockOpenAiClient) CreateChatCompletion(ctx context.Context, req openai.
 	return args.Get(0).(openai.ChatCompletionResponse), args.Error(1) //nolint:wrapcheck
 }
 
+func (m *MockOpenAiClient) CreateEmbeddings(ctx context.Context, conv openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	args := m.Called(ctx, conv)
+	return args.Get(0).(openai.EmbeddingResponse), args.Error(1) //nolint:wrapcheck
+}
+
 func TestComposer_Compose(t *testing.T) {
 	news := journalist.NewsList{
 		{
@@ -64,6 +69,7 @@ func TestComposer_Compose(t *testing.T) {
 		name                 string
 		args                 args
 		expectedFilteredNews journalist.NewsList
+		answer               []*ComposedNews // AI answer, defaults to want
 		want                 []*ComposedNews
 		wantErr              bool
 	}{
@@ -74,19 +80,13 @@ func TestComposer_Compose(t *testing.T) {
 			},
 			expectedFilteredNews: journalist.NewsList{news[0], news[1], news[2]},
 			want: []*ComposedNews{
-				{
-					ID:       "1",
-					Text:     "Ray Dalio warns about the soaring U.S. government debt reaching a critical inflection point, potentially leading to larger problems.",
-					Tickers:  []string{"AAPL"},
-					Markets:  []string{},
-					Hashtags: []string{"debt"},
-				},
 				{
 					ID:       "2",
 					Text:     "The market anticipates aggressive rate cuts by the Fed, despite the cautious approach of central bank officials. Investors may face disappointment.",
 					Tickers:  []string{},
 					Markets:  []string{},
 					Hashtags: []string{"interestrates"},
+					Model:    openai.GPT4oMini,
 				},
 				{
 					ID:       "3",
@@ -94,10 +94,29 @@ func TestComposer_Compose(t *testing.T) {
 					Tickers:  []string{},
 					Markets:  []string{},
 					Hashtags: []string{},
+					Model:    openai.GPT4oMini,
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "Should drop composed news with IDs that were not in the input",
+			args: args{
+				news: news,
+			},
+			expectedFilteredNews: journalist.NewsList{news[0], news[1], news[2]},
+			answer: []*ComposedNews{
+				{ID: "1", Text: "Suspicious news should not be composed", Tickers: []string{}, Markets: []string{}, Hashtags: []string{}},
+				{ID: "2", Text: "Fed", Tickers: []string{}, Markets: []string{}, Hashtags: []string{}},
+				{ID: "2", Text: "Fed duplicate", Tickers: []string{}, Markets: []string{}, Hashtags: []string{}},
+				{ID: "3", Text: "Prices", Tickers: []string{}, Markets: []string{}, Hashtags: []string{}},
+			},
+			want: []*ComposedNews{
+				{ID: "2", Text: "Fed", Tickers: []string{}, Markets: []string{}, Hashtags: []string{}, Model: openai.GPT4oMini},
+				{ID: "3", Text: "Prices", Tickers: []string{}, Markets: []string{}, Hashtags: []string{}, Model: openai.GPT4oMini},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Should pass and return empty array correctly",
 			args: args{
@@ -128,7 +147,11 @@ func TestComposer_Compose(t *testing.T) {
 			jsonNews, _ := tt.expectedFilteredNews.RemoveFlagged().ToContentJSON()
 
 			// Break the JSON to test the fix for OpenAI frequent bug (with extra closing bracket and some other stuff)
-			wantNewsJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+			answer := tt.answer
+			if answer == nil {
+				answer = tt.want
+			}
+			wantNewsJSON, _ := json.MarshalIndent(answer, "", "  ")
 			wantNewsJSON = []byte(fmt.Sprintf("```{%s}```", wantNewsJSON))
 
 			mockClient.On("CreateChatCompletion", mock.Anything, openai.ChatCompletionRequest{
@@ -136,7 +159,7 @@ func TestComposer_Compose(t *testing.T) {
 				Messages: []openai.ChatCompletionMessage{
 					{
 						Role:    openai.ChatMessageRoleSystem,
-						Content: defConf.ComposePrompt,
+						Content: defConf.composeSystemPrompt(),
 					},
 					{
 						Role:    openai.ChatMessageRoleUser,
@@ -300,6 +323,77 @@ func TestComposer_Summarise(t *testing.T) {
 	}
 }
 
+func TestComposer_Recap(t *testing.T) {
+	client := &answersClient{answers: []string{
+		`[{"id":"1","summary":"Nvidia rallied after earnings","verb":"rallied","link":"https://t.me/fin_thread/1"}]`,
+	}}
+	c := &Composer{OpenAiClient: client, Config: defaultPromptConfig()}
+	headlines := []*Headline{{ID: "1", Text: "Nvidia rallies after earnings", Link: "https://t.me/fin_thread/1"}}
+
+	got, err := c.Recap(context.Background(), headlines, 10, 512)
+	if err != nil {
+		t.Fatalf("Recap() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Verb != "rallied" {
+		t.Errorf("Recap() = %v, want one recap headline", got)
+	}
+
+	if system := client.requests[0].Messages[0].Content; system != c.Config.RecapPrompt(10) {
+		t.Errorf("Recap() system prompt = %s, want RecapPrompt", system)
+	}
+
+	if _, err := c.Recap(context.Background(), headlines, 10, 0); err == nil {
+		t.Errorf("Recap() should fail for zero maxTokens")
+	}
+}
+
+func TestComposer_Translate(t *testing.T) {
+	client := &answersClient{answers: []string{"  Nvidia sube tras los resultados #AI $NVDA\n", "  "}}
+	c := &Composer{OpenAiClient: client, Config: defaultPromptConfig()}
+
+	got, err := c.Translate(context.Background(), "Nvidia rallies after earnings #AI $NVDA", "Spanish")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if want := "Nvidia sube tras los resultados #AI $NVDA"; got != want {
+		t.Errorf("Translate() = %q, want %q", got, want)
+	}
+	if system := client.requests[0].Messages[0].Content; system != c.Config.TranslatePrompt("Spanish") {
+		t.Errorf("Translate() system prompt = %s, want TranslatePrompt", system)
+	}
+
+	if _, err := c.Translate(context.Background(), "Nvidia rallies", "Spanish"); err == nil {
+		t.Errorf("Translate() should fail for the empty answer")
+	}
+
+	if _, err := c.Translate(context.Background(), "Nvidia rallies", ""); err == nil {
+		t.Errorf("Translate() should fail for the empty language")
+	}
+
+	if got, err := c.Translate(context.Background(), "", "Spanish"); got != "" || err != nil {
+		t.Errorf("Translate() = %q, %v, want empty text without error", got, err)
+	}
+}
+
+func TestComposer_NormalizeEventTitles(t *testing.T) {
+	client := &answersClient{answers: []string{
+		`[{"title":"Verbraucherpreisindex (Jahr)","name":"CPI y/y"},{"title":"unknown","name":"Unknown"},{"title":"GDP q/q","name":" "}]`,
+	}}
+	c := &Composer{OpenAiClient: client, Config: defaultPromptConfig()}
+
+	got, err := c.NormalizeEventTitles(context.Background(), []string{"Verbraucherpreisindex (Jahr)", "GDP q/q"})
+	if err != nil {
+		t.Fatalf("NormalizeEventTitles() error = %v", err)
+	}
+	if want := map[string]string{"Verbraucherpreisindex (Jahr)": "CPI y/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeEventTitles() = %v, want %v", got, want)
+	}
+
+	if _, err := c.NormalizeEventTitles(context.Background(), []string{"GDP q/q"}); err == nil {
+		t.Errorf("NormalizeEventTitles() should fail on the client error")
+	}
+}
+
 func TestComposer_Filter(t *testing.T) {
 	type args struct {
 		news journalist.NewsList
@@ -439,3 +533,98 @@ func TestComposer_Filter(t *testing.T) {
 		})
 	}
 }
+
+func TestComposer_Select(t *testing.T) {
+	newNews := func() journalist.NewsList {
+		return journalist.NewsList{
+			{ID: "1", Title: "Fed holds rates steady", Date: time.Now().UTC()},
+			{ID: "2", Title: "Top 10 gadgets for summer", Date: time.Now().UTC()},
+			{ID: "3", Title: "CPI rises 0.4% in March", Date: time.Now().UTC()},
+			{ID: "4", Title: "Already flagged", Date: time.Now().UTC(), IsFiltered: true, FilteredReason: "clickbait"},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		limit        int
+		answer       string
+		wantFiltered map[string]string
+		wantErr      bool
+	}{
+		{
+			name:   "Should flag news that were not selected",
+			limit:  2,
+			answer: `[{"ID":"3"},{"ID":"1"}]`,
+			wantFiltered: map[string]string{
+				"2": string(FilterReasonNotSelected),
+				"4": "clickbait",
+			},
+		},
+		{
+			name:   "Should keep only the first limit news",
+			limit:  1,
+			answer: `[{"ID":"3"},{"ID":"1"}]`,
+			wantFiltered: map[string]string{
+				"1": string(FilterReasonNotSelected),
+				"2": string(FilterReasonNotSelected),
+				"4": "clickbait",
+			},
+		},
+		{
+			name:    "Should return error on invalid limit",
+			limit:   0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(MockOpenAiClient)
+			mockClient.On("CreateChatCompletion", mock.Anything, mock.Anything).Return(openai.ChatCompletionResponse{
+				Choices: []openai.ChatCompletionChoice{
+					{Message: openai.ChatCompletionMessage{Content: tt.answer}},
+				},
+			}, nil)
+
+			c := &Composer{
+				OpenAiClient: mockClient,
+				Config:       defaultPromptConfig(),
+			}
+			got, err := c.Select(context.Background(), newNews(), tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Select() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for _, n := range got {
+				reason, wantFiltered := tt.wantFiltered[n.ID]
+				if n.IsFiltered != wantFiltered || n.FilteredReason != reason {
+					t.Errorf("Select() news %s filtered = %v (%q), want %v (%q)", n.ID, n.IsFiltered, n.FilteredReason, wantFiltered, reason)
+				}
+			}
+		})
+	}
+}
+
+func Test_parseFilterReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   FilterReason
+	}{
+		{reason: "clickbait", want: FilterReasonClickbait},
+		{reason: " Advertisement ", want: FilterReasonAdvertisement},
+		{reason: "ads", want: FilterReasonAdvertisement},
+		{reason: "Non Financial", want: FilterReasonNonFinancial},
+		{reason: "duplicate", want: FilterReasonDuplicate},
+		{reason: "boring", want: FilterReasonLowValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			if got := parseFilterReason(tt.reason); got != tt.want {
+				t.Errorf("parseFilterReason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
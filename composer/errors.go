@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"strings"
 )
 
 var (
 	errEmptyRegexMatch = errors.New("empty regex match")
+	errNoJSONArray     = errors.New("no JSON array found")
 )
 
 // Error is an error that occurs during news composing process.
@@ -50,3 +52,9 @@ func newError(err error, level errlvl.Lvl, name, source string) *Error {
 		source: source,
 	}
 }
+
+// isParseError returns true if the error is caused by the AI answer that couldn't be parsed (even after the repair).
+func isParseError(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && strings.HasPrefix(e.source, "parseAnswer")
+}
@@ -0,0 +1,127 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+	"os"
+	"strings"
+)
+
+// Example is a curated few-shot example: the input news and the ideal AI answer for them.
+// Both are stored as raw JSON in the same format as the real prompt payload and answer.
+type Example struct {
+	Input  json.RawMessage `json:"input"`
+	Output json.RawMessage `json:"output"`
+}
+
+// ExampleSet holds few-shot examples for each prompt that supports them.
+type ExampleSet struct {
+	Compose []*Example `json:"compose"`
+	Filter  []*Example `json:"filter"`
+}
+
+// LoadExampleSets reads named few-shot example sets from the JSON file in the following format:
+//
+//	{"market": {"compose": [{"input": [...], "output": [...]}], "filter": [...]}}
+//
+// The set name is used to select examples for the job (see Composer.WithExamples).
+func LoadExampleSets(path string) (map[string]*ExampleSet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "LoadExampleSets", "os.ReadFile").WithValue(path)
+	}
+
+	var sets map[string]*ExampleSet
+	if err := json.Unmarshal(b, &sets); err != nil {
+		return nil, newError(err, errlvl.ERROR, "LoadExampleSets", "json.Unmarshal").WithValue(path)
+	}
+
+	for name, set := range sets {
+		if set == nil {
+			continue
+		}
+		for _, examples := range [][]*Example{set.Compose, set.Filter} {
+			for i, e := range examples {
+				if e == nil || len(e.Input) == 0 || len(e.Output) == 0 {
+					return nil, newError(
+						errors.New("input and output are required"),
+						errlvl.ERROR,
+						"LoadExampleSets",
+						"validation",
+					).WithValue(fmt.Sprintf("%s[%d]", name, i))
+				}
+			}
+		}
+	}
+
+	return sets, nil
+}
+
+func (s *ExampleSet) compose() []*Example {
+	if s == nil {
+		return nil
+	}
+	return s.Compose
+}
+
+func (s *ExampleSet) filter() []*Example {
+	if s == nil {
+		return nil
+	}
+	return s.Filter
+}
+
+// instPrompt builds the completion prompt in the [INST] format of the instruction-tuned models (e.g. Mixtral)
+// with the same parts as chatMessages.
+func instPrompt(system string, examples []*Example, user string) string {
+	var sb strings.Builder
+	sb.WriteString("<s>[INST] " + system)
+	for _, e := range examples {
+		sb.WriteString("\n\n" + string(e.Input) + " [/INST] " + string(e.Output) + "</s>[INST] ")
+	}
+	sb.WriteString("\n\n" + user + " [/INST]")
+
+	return sb.String()
+}
+
+// textPrompt builds the single text prompt with the same parts as chatMessages for the providers without chat roles.
+func textPrompt(system string, examples []*Example, user string) string {
+	var sb strings.Builder
+	sb.WriteString(system)
+	for _, e := range examples {
+		sb.WriteString("\n\nInput:\n" + string(e.Input) + "\nOutput:\n" + string(e.Output))
+	}
+	sb.WriteString("\n\nInput:\n" + user + "\nOutput:\n")
+
+	return sb.String()
+}
+
+// chatMessages builds the chat messages: system prompt, few-shot examples as user/assistant pairs and the user input.
+func chatMessages(system string, examples []*Example, user string) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(examples)*2+2)
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: system,
+	})
+
+	for _, e := range examples {
+		messages = append(messages,
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: string(e.Input),
+			},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: string(e.Output),
+			},
+		)
+	}
+
+	return append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: user,
+	})
+}
@@ -0,0 +1,88 @@
+package composer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestLoadExampleSets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]*ExampleSet
+		wantErr bool
+	}{
+		{
+			name:    "Should load named sets",
+			content: `{"market":{"compose":[{"input":[{"id":"1"}],"output":[{"id":"1","text":"t"}]}]}}`,
+			want: map[string]*ExampleSet{
+				"market": {
+					Compose: []*Example{
+						{Input: json.RawMessage(`[{"id":"1"}]`), Output: json.RawMessage(`[{"id":"1","text":"t"}]`)},
+					},
+				},
+			},
+		},
+		{
+			name:    "Should return error for example without output",
+			content: `{"market":{"filter":[{"input":[{"id":"1"}]}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error for invalid JSON",
+			content: `{"market":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "examples.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadExampleSets(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadExampleSets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadExampleSets() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_chatMessages(t *testing.T) {
+	examples := []*Example{
+		{Input: json.RawMessage(`["in"]`), Output: json.RawMessage(`["out"]`)},
+	}
+
+	want := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "system"},
+		{Role: openai.ChatMessageRoleUser, Content: `["in"]`},
+		{Role: openai.ChatMessageRoleAssistant, Content: `["out"]`},
+		{Role: openai.ChatMessageRoleUser, Content: "user"},
+	}
+	if got := chatMessages("system", examples, "user"); !reflect.DeepEqual(got, want) {
+		t.Errorf("chatMessages() = %v, want %v", got, want)
+	}
+}
+
+func TestComposer_WithExamples(t *testing.T) {
+	c := &Composer{Config: defaultPromptConfig()}
+	set := &ExampleSet{Filter: []*Example{{Input: json.RawMessage(`[]`), Output: json.RawMessage(`[]`)}}}
+
+	got := c.WithExamples(set)
+	if got.Config.Examples != set {
+		t.Errorf("WithExamples() examples = %v, want %v", got.Config.Examples, set)
+	}
+	if c.Config.Examples != nil {
+		t.Errorf("WithExamples() should not change the original composer")
+	}
+}
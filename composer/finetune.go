@@ -0,0 +1,47 @@
+package composer
+
+import (
+	"encoding/json"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+)
+
+// FineTuneExample is the training example in the OpenAI chat fine-tuning format (one JSONL line):
+// the Compose system prompt, the news payload and the composed answer.
+type FineTuneExample struct {
+	Messages []openai.ChatCompletionMessage `json:"messages"`
+}
+
+// FineTuneExample returns the training example of the Compose call that produced the composed news
+// from the original news, so the fine-tuned model learns the style of the published posts.
+// The system prompt and the payload are the same as in Compose with the given options.
+func (c *Composer) FineTuneExample(news *journalist.News, composed *ComposedNews, opts ...Option) (*FineTuneExample, error) {
+	config := c.snapshot(opts)
+
+	input := *news
+	if config.scrubs(ProviderOpenAI) {
+		input.Title = ScrubText(input.Title)
+		input.Description = ScrubText(input.Description)
+	}
+
+	payload, err := journalist.NewsList{&input}.ToContentJSON()
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "FineTuneExample", "NewsList.ToContentJSON")
+	}
+
+	answer := *composed
+	answer.ID = news.ID
+	jsonAnswer, err := json.Marshal([]*ComposedNews{&answer})
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "FineTuneExample", "json.Marshal composed")
+	}
+
+	messages := chatMessages(config.composeSystemPrompt(), nil, payload)
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: string(jsonAnswer),
+	})
+
+	return &FineTuneExample{Messages: messages}, nil
+}
@@ -0,0 +1,73 @@
+package composer
+
+import (
+	"encoding/json"
+	"github.com/samgozman/fin-thread/journalist"
+	"strings"
+	"testing"
+)
+
+func TestComposer_FineTuneExample(t *testing.T) {
+	c := &Composer{Config: defaultPromptConfig()}
+	news := &journalist.News{
+		ID:          "hash1",
+		Title:       "Apple beats estimates",
+		Description: "Contact ir@apple.com for details",
+	}
+	composed := &ComposedNews{
+		ID:       "another-id",
+		Text:     "Apple beats estimates",
+		Tickers:  []string{"AAPL"},
+		Markets:  []string{},
+		Hashtags: []string{"earnings"},
+	}
+
+	got, err := c.FineTuneExample(news, composed, UseMaxComposedLength(200), UseScrubbing(ProviderOpenAI))
+	if err != nil {
+		t.Fatalf("FineTuneExample() error = %v", err)
+	}
+
+	line, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var example struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(line, &example); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(example.Messages) != 3 {
+		t.Fatalf("FineTuneExample() messages = %d, want 3", len(example.Messages))
+	}
+
+	roles := []string{"system", "user", "assistant"}
+	for i, m := range example.Messages {
+		if m.Role != roles[i] {
+			t.Errorf("FineTuneExample() message %d role = %s, want %s", i, m.Role, roles[i])
+		}
+	}
+
+	if !strings.Contains(example.Messages[0].Content, "shorter than 200 characters") {
+		t.Errorf("FineTuneExample() system prompt = %q, want the compose prompt with the options", example.Messages[0].Content)
+	}
+
+	wantUser := `[{"id":"hash1","title":"Apple beats estimates","description":"Contact [email] for details"}]`
+	if example.Messages[1].Content != wantUser {
+		t.Errorf("FineTuneExample() user = %s, want %s", example.Messages[1].Content, wantUser)
+	}
+
+	wantAnswer := `[{"id":"hash1","text":"Apple beats estimates","tickers":["AAPL"],"markets":[],"hashtags":["earnings"]}]`
+	if example.Messages[2].Content != wantAnswer {
+		t.Errorf("FineTuneExample() assistant = %s, want %s", example.Messages[2].Content, wantAnswer)
+	}
+
+	if composed.ID != "another-id" || news.Description != "Contact ir@apple.com for details" {
+		t.Errorf("FineTuneExample() modified the original news")
+	}
+}
@@ -0,0 +1,95 @@
+package composer
+
+import (
+	"strings"
+)
+
+// Market is a canonical market (index) identifier used in ComposedNews.Markets.
+type Market struct {
+	ID      string   // canonical index identifier, e.g. "SPX"
+	Hashtag string   // hashtag related to the market (without #)
+	Aliases []string // known aliases used by AI (tickers of ETFs, futures, names), upper case
+}
+
+// markets is the normalization table for the free-form AI "markets" output.
+var markets = []*Market{
+	{ID: "SPX", Hashtag: "sp500", Aliases: []string{"SPY", "VOO", "IVV", "ES", "S&P500", "S&P 500", "S&P", "SP500", "GSPC", "^GSPC", "US500"}},
+	{ID: "NDX", Hashtag: "nasdaq", Aliases: []string{"QQQ", "NQ", "NASDAQ", "NASDAQ100", "NASDAQ 100", "NASDAQ-100", "IXIC", "^IXIC", "^NDX", "US100", "COMP"}},
+	{ID: "DJI", Hashtag: "dowjones", Aliases: []string{"DIA", "YM", "DOW", "DOW JONES", "DJIA", "^DJI", "US30"}},
+	{ID: "RUT", Hashtag: "russell2000", Aliases: []string{"IWM", "RTY", "RUSSELL", "RUSSELL 2000", "RUSSELL2000", "^RUT"}},
+	{ID: "VIX", Hashtag: "volatility", Aliases: []string{"^VIX", "VXX", "UVXY", "CBOE VIX"}},
+	{ID: "DAX", Hashtag: "germany", Aliases: []string{"^GDAXI", "GDAXI", "DAX40", "DAX 40", "DE40", "EWG"}},
+	{ID: "FTSE", Hashtag: "uk", Aliases: []string{"^FTSE", "FTSE100", "FTSE 100", "UK100", "EWU"}},
+	{ID: "STOXX50", Hashtag: "europe", Aliases: []string{"^STOXX50E", "STOXX50E", "EURO STOXX 50", "EUROSTOXX50", "SX5E", "FEZ", "EU50"}},
+	{ID: "N225", Hashtag: "japan", Aliases: []string{"^N225", "NIKKEI", "NIKKEI 225", "NIKKEI225", "JP225", "EWJ"}},
+	{ID: "HSI", Hashtag: "hongkong", Aliases: []string{"^HSI", "HANG SENG", "HANGSENG", "HK50"}},
+	{ID: "SSEC", Hashtag: "china", Aliases: []string{"000001.SS", "SHANGHAI", "SHANGHAI COMPOSITE", "FXI", "MCHI"}},
+	{ID: "TNX", Hashtag: "bonds", Aliases: []string{"^TNX", "US10Y", "10Y", "TLT", "IEF", "ZN", "TREASURIES", "BONDS"}},
+	{ID: "GOLD", Hashtag: "gold", Aliases: []string{"GLD", "GC", "XAUUSD", "XAU", "IAU"}},
+	{ID: "OIL", Hashtag: "oil", Aliases: []string{"USO", "CL", "WTI", "BRENT", "BZ", "CRUDE", "CRUDE OIL"}},
+	{ID: "DXY", Hashtag: "dollar", Aliases: []string{"UUP", "USDX", "DX", "US DOLLAR INDEX", "DOLLAR INDEX"}},
+}
+
+// marketsByAlias maps canonical IDs and all aliases to the Market.
+var marketsByAlias = func() map[string]*Market {
+	m := make(map[string]*Market)
+	for _, market := range markets {
+		m[market.ID] = market
+		for _, a := range market.Aliases {
+			m[a] = market
+		}
+	}
+	return m
+}()
+
+// FindMarket returns the known Market by its canonical ID or alias (case-insensitive).
+func FindMarket(alias string) (*Market, bool) {
+	m, ok := marketsByAlias[strings.ToUpper(strings.TrimSpace(alias))]
+	return m, ok
+}
+
+// NormalizeMarkets maps markets aliases to canonical identifiers and removes duplicates.
+// Unknown markets are kept in upper case.
+func NormalizeMarkets(list []string) []string {
+	result := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, alias := range list {
+		id := strings.ToUpper(strings.TrimSpace(alias))
+		if id == "" {
+			continue
+		}
+		if m, ok := marketsByAlias[id]; ok {
+			id = m.ID
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+
+	return result
+}
+
+// marketsHashtags adds hashtags of the known markets to the hashtags list (without duplicates).
+func marketsHashtags(marketIDs, hashtags []string) []string {
+	for _, id := range marketIDs {
+		m, ok := marketsByAlias[id]
+		if !ok {
+			continue
+		}
+
+		found := false
+		for _, h := range hashtags {
+			if strings.EqualFold(h, m.Hashtag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			hashtags = append(hashtags, m.Hashtag)
+		}
+	}
+
+	return hashtags
+}
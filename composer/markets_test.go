@@ -0,0 +1,50 @@
+package composer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeMarkets(t *testing.T) {
+	tests := []struct {
+		name string
+		list []string
+		want []string
+	}{
+		{
+			name: "Should map aliases to canonical IDs",
+			list: []string{"SPY", "qqq", " Russell 2000 ", "^DJI"},
+			want: []string{"SPX", "NDX", "RUT", "DJI"},
+		},
+		{
+			name: "Should remove duplicates",
+			list: []string{"SPY", "S&P500", "ES", "SPX"},
+			want: []string{"SPX"},
+		},
+		{
+			name: "Should keep unknown markets in upper case",
+			list: []string{"housing", "", "GLD"},
+			want: []string{"HOUSING", "GOLD"},
+		},
+		{
+			name: "Should return empty list for nil",
+			list: nil,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeMarkets(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeMarkets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_marketsHashtags(t *testing.T) {
+	got := marketsHashtags([]string{"SPX", "HOUSING", "NDX"}, []string{"fed", "Nasdaq"})
+	want := []string{"fed", "Nasdaq", "sp500"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marketsHashtags() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package composer
+
+import (
+	"sync/atomic"
+
+	"github.com/samgozman/fin-thread/journalist"
+)
+
+// Metrics holds the counters of the AI answers quality. It is shared between the Composer copies
+// and is safe for concurrent use. All methods are nil-safe.
+type Metrics struct {
+	unknownIDs   atomic.Int64 // composed news with IDs that were not in the input batch
+	duplicateIDs atomic.Int64 // composed news with IDs that were already composed
+	missingIDs   atomic.Int64 // input news that were not composed even after the retry
+	retries      atomic.Int64 // retry requests for the news with unknown IDs
+}
+
+// Reset returns the current counters by name and sets them to zero.
+func (m *Metrics) Reset() map[string]int64 {
+	if m == nil {
+		return nil
+	}
+
+	return map[string]int64{
+		"unknown IDs":   m.unknownIDs.Swap(0),
+		"duplicate IDs": m.duplicateIDs.Swap(0),
+		"missing IDs":   m.missingIDs.Swap(0),
+		"retries":       m.retries.Swap(0),
+	}
+}
+
+// recordAnswer counts unknown and duplicated IDs of the AI answer.
+func (m *Metrics) recordAnswer(unknown, duplicates int) {
+	if m == nil {
+		return
+	}
+	m.unknownIDs.Add(int64(unknown))
+	m.duplicateIDs.Add(int64(duplicates))
+}
+
+// recordRetry counts one retry request and the news that were still missing after it.
+func (m *Metrics) recordRetry(missing int) {
+	if m == nil {
+		return
+	}
+	m.retries.Add(1)
+	m.missingIDs.Add(int64(missing))
+}
+
+// recordMissing counts the input news that were not composed.
+func (m *Metrics) recordMissing(missing int) {
+	if m == nil {
+		return
+	}
+	m.missingIDs.Add(int64(missing))
+}
+
+// matchIDs keeps only composed news which IDs map to the input news (first one wins for duplicates).
+// It returns the kept news, input news that were not composed and the number of unknown and duplicated IDs.
+func matchIDs(
+	input journalist.NewsList,
+	composed []*ComposedNews,
+) (matched []*ComposedNews, missing journalist.NewsList, unknown, duplicates int) {
+	ids := make(map[string]bool, len(input))
+	for _, n := range input {
+		ids[n.ID] = false
+	}
+
+	for _, n := range composed {
+		seen, ok := ids[n.ID]
+		switch {
+		case !ok:
+			unknown++
+		case seen:
+			duplicates++
+		default:
+			ids[n.ID] = true
+			matched = append(matched, n)
+		}
+	}
+
+	for _, n := range input {
+		if !ids[n.ID] {
+			missing = append(missing, n)
+		}
+	}
+
+	return matched, missing, unknown, duplicates
+}
@@ -0,0 +1,109 @@
+package composer
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/journalist"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_matchIDs(t *testing.T) {
+	input := journalist.NewsList{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	tests := []struct {
+		name           string
+		composed       []*ComposedNews
+		wantMatched    []string
+		wantMissing    []string
+		wantUnknown    int
+		wantDuplicates int
+	}{
+		{
+			name:        "all IDs match",
+			composed:    []*ComposedNews{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+			wantMatched: []string{"1", "2", "3"},
+		},
+		{
+			name:        "unknown IDs are dropped",
+			composed:    []*ComposedNews{{ID: "1"}, {ID: "42"}, {ID: "3"}},
+			wantMatched: []string{"1", "3"},
+			wantMissing: []string{"2"},
+			wantUnknown: 1,
+		},
+		{
+			name:           "duplicates are dropped",
+			composed:       []*ComposedNews{{ID: "1"}, {ID: "1"}, {ID: "2"}},
+			wantMatched:    []string{"1", "2"},
+			wantMissing:    []string{"3"},
+			wantDuplicates: 1,
+		},
+		{
+			name:        "empty answer",
+			wantMissing: []string{"1", "2", "3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, missing, unknown, duplicates := matchIDs(input, tt.composed)
+
+			var matchedIDs, missingIDs []string
+			for _, n := range matched {
+				matchedIDs = append(matchedIDs, n.ID)
+			}
+			for _, n := range missing {
+				missingIDs = append(missingIDs, n.ID)
+			}
+
+			if !reflect.DeepEqual(matchedIDs, tt.wantMatched) {
+				t.Errorf("matchIDs() matched = %v, want %v", matchedIDs, tt.wantMatched)
+			}
+			if !reflect.DeepEqual(missingIDs, tt.wantMissing) {
+				t.Errorf("matchIDs() missing = %v, want %v", missingIDs, tt.wantMissing)
+			}
+			if unknown != tt.wantUnknown || duplicates != tt.wantDuplicates {
+				t.Errorf("matchIDs() unknown = %d, duplicates = %d, want %d, %d",
+					unknown, duplicates, tt.wantUnknown, tt.wantDuplicates)
+			}
+		})
+	}
+}
+
+func TestComposer_ComposeRetriesUnknownIDs(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Apple beats estimates", Date: time.Now()},
+		{ID: "2", Title: "Fed holds rates", Date: time.Now()},
+	}
+
+	client := &answersClient{answers: []string{
+		`[{"id":"1","text":"Apple"},{"id":"2x","text":"Fed"}]`,
+		`[{"id":"2","text":"Fed"}]`,
+	}}
+	c := &Composer{OpenAiClient: client, Config: defaultPromptConfig(), Metrics: &Metrics{}}
+
+	got, err := c.Compose(context.Background(), news)
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0].ID != "1" || got[1].ID != "2" {
+		t.Errorf("Compose() = %v, want news with IDs 1 and 2", got)
+	}
+
+	if len(client.requests) != 2 {
+		t.Fatalf("Compose() sent %d requests, want 2", len(client.requests))
+	}
+	retry := client.requests[1].Messages[len(client.requests[1].Messages)-1].Content
+	if strings.Contains(retry, "Apple") || !strings.Contains(retry, "Fed holds rates") {
+		t.Errorf("Compose() retry payload = %s, want only the missing news", retry)
+	}
+
+	want := map[string]int64{"unknown IDs": 1, "duplicate IDs": 0, "missing IDs": 0, "retries": 1}
+	if got := c.Metrics.Reset(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metrics.Reset() = %v, want %v", got, want)
+	}
+	if got := c.Metrics.Reset(); got["unknown IDs"] != 0 || got["retries"] != 0 {
+		t.Errorf("Metrics.Reset() after reset = %v, want zero counters", got)
+	}
+}
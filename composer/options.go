@@ -0,0 +1,97 @@
+package composer
+
+import "slices"
+
+// Option changes the prompt config of a single Composer call (e.g. Composer.Compose(ctx, news, UseExamples(set)))
+// or of the Composer copy (see Composer.With). Options never change the config of the original Composer.
+//
+// Available options:
+//   - UseMaxComposedLength: target max length of the composed text in characters (Compose);
+//   - UseGlossary: channel glossary (Compose and Summarise);
+//   - UseExamples: few-shot examples set (Compose and Filter);
+//   - UseFilterPrompt: system prompt (Filter);
+//   - UseFilterModel: OpenAI model (Filter);
+//   - UseScrubbing: AI providers that receive the scrubbed news (Compose, Select and Filter);
+//   - UseRecentTexts: recently published texts whose phrasing is not repeated (Compose);
+//   - UseModelRoutes: models routed for the tasks of the channel (all OpenAI calls, see Tasks).
+type Option func(config *promptConfig)
+
+// UseMaxComposedLength sets the target max length of the composed text in characters.
+// Longer texts will be truncated by the sentence boundary. Zero means no limit.
+func UseMaxComposedLength(length int) Option {
+	return func(config *promptConfig) {
+		config.ComposeMaxLength = length
+	}
+}
+
+// UseGlossary sets the channel glossary (preferred phrasings, banned words and tone)
+// which will be injected into Compose and Summarise prompts. The glossary must not be modified after that.
+func UseGlossary(glossary *Glossary) Option {
+	return func(config *promptConfig) {
+		config.Glossary = glossary
+	}
+}
+
+// UseExamples sets the few-shot examples set for Compose and Filter prompts. Nil set means no examples.
+// The set must not be modified after that.
+func UseExamples(set *ExampleSet) Option {
+	return func(config *promptConfig) {
+		config.Examples = set
+	}
+}
+
+// UseFilterPrompt replaces the Filter system prompt, e.g. to trial a new prompt in the shadow mode.
+// The prompt must keep the Filter answer format: [{"ID":"","Reason":""}].
+func UseFilterPrompt(prompt string) Option {
+	return func(config *promptConfig) {
+		config.FilterPrompt = func() string { return prompt }
+	}
+}
+
+// UseFilterModel sets the OpenAI model used by Filter (openai.GPT4oMini by default).
+func UseFilterModel(model string) Option {
+	return func(config *promptConfig) {
+		config.FilterModel = model
+	}
+}
+
+// UseScrubbing sets the AI providers (see Providers) that receive the news titles and descriptions
+// without emails, phone numbers and link tracking parameters (see ScrubText). No providers means no scrubbing.
+func UseScrubbing(providers ...string) Option {
+	return func(config *promptConfig) {
+		config.ScrubProviders = providers
+	}
+}
+
+// UseRecentTexts sets the texts recently published on the same topics (tickers, hashtags) as the composed news.
+// The composer is asked not to repeat their phrasing. No texts means no recency context.
+func UseRecentTexts(texts ...string) Option {
+	return func(config *promptConfig) {
+		config.RecentTexts = texts
+	}
+}
+
+// UseModelRoutes sets the models routed for the tasks of the channel by the registry (see ModelRegistry.Routes),
+// e.g. the model fine-tuned on the channel style for TaskCompose. Tasks without the routed model use the defaults.
+// Nil registry means no routes.
+func UseModelRoutes(registry *ModelRegistry, channelID string) Option {
+	return func(config *promptConfig) {
+		config.Models = registry.Routes(channelID)
+	}
+}
+
+// scrubs returns true if the news sent to the provider must be scrubbed.
+func (p *promptConfig) scrubs(provider string) bool {
+	return slices.Contains(p.ScrubProviders, provider)
+}
+
+// snapshot returns the copy of the Composer config with the given options applied.
+// Every call uses its own snapshot, so the config can't change in the middle of the call.
+func (c *Composer) snapshot(opts []Option) *promptConfig {
+	config := *c.Config
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &config
+}
@@ -0,0 +1,68 @@
+package composer
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/journalist"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestComposer_With(t *testing.T) {
+	c := &Composer{Config: defaultPromptConfig()}
+	glossary := &Glossary{Tone: "neutral"}
+
+	got := c.With(UseMaxComposedLength(100), UseGlossary(glossary))
+	if got.Config.ComposeMaxLength != 100 || got.Config.Glossary != glossary {
+		t.Errorf("With() config = %+v, want max length 100 and glossary %v", got.Config, glossary)
+	}
+	if c.Config.ComposeMaxLength != defaultComposeLimit || c.Config.Glossary != nil {
+		t.Errorf("With() should not change the original composer")
+	}
+}
+
+func TestUseFilterPrompt(t *testing.T) {
+	c := &Composer{Config: defaultPromptConfig()}
+
+	got := c.snapshot([]Option{UseFilterPrompt("new prompt"), UseFilterModel("gpt-4o")})
+	if got.FilterPrompt() != "new prompt" || got.FilterModel != "gpt-4o" {
+		t.Errorf("snapshot() filter prompt = %q, model = %q, want new prompt and gpt-4o", got.FilterPrompt(), got.FilterModel)
+	}
+	if c.Config.FilterPrompt() == "new prompt" || c.Config.FilterModel == "gpt-4o" {
+		t.Errorf("snapshot() should not change the original composer")
+	}
+}
+
+func TestComposer_Compose_concurrentOptions(t *testing.T) {
+	c := NewSandboxComposer()
+	news := journalist.NewsList{
+		{ID: "1", Title: "Stocks rally as inflation cools down sharply", Date: time.Now()},
+	}
+
+	var wg sync.WaitGroup
+	for i := 10; i <= 40; i++ {
+		wg.Add(1)
+		go func(limit int) {
+			defer wg.Done()
+
+			composed, err := c.Compose(context.Background(), news, UseMaxComposedLength(limit))
+			if err != nil {
+				t.Errorf("Compose() error = %v", err)
+				return
+			}
+			want := truncateText(news[0].Title, limit)
+			if len(composed) != 1 || composed[0].Text != want {
+				t.Errorf("Compose() with limit %d = %v, want text %q", limit, composed, want)
+			}
+
+			// Copies can be created while other calls are running
+			_ = c.With(UseExamples(&ExampleSet{}), UseGlossary(&Glossary{Tone: fmt.Sprint(limit)}))
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Config.ComposeMaxLength != defaultComposeLimit {
+		t.Errorf("Compose() options should not change the composer config, got %d", c.Config.ComposeMaxLength)
+	}
+}
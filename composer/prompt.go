@@ -1,25 +1,53 @@
 package composer
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/sashabaranov/go-openai"
+	"sort"
+	"strings"
+)
 
 type promptConfig struct {
 	ComposePrompt        string
+	ComposeMaxLength     int         // target max length of the composed text in characters (0 means no limit)
+	Glossary             *Glossary   // channel glossary injected into Compose and Summarise prompts (optional)
+	Examples             *ExampleSet // few-shot examples for Compose and Filter prompts (optional)
+	SelectPrompt         selectPromptFunc
 	SummarisePrompt      summarisePromptFunc
+	RecapPrompt          summarisePromptFunc // post-market recap variant of SummarisePrompt
 	FilterPrompt         func() string
+	FilterModel          string // OpenAI model used by Filter
 	FilterPromptInstruct filterPromptFunc
+	TranslatePrompt      translatePromptFunc
+	EventTitlesPrompt    func() string
+	AnswerPrompt         func() string
+	ScrubProviders       []string // AI providers that receive the news without personal data (see ScrubText)
+	RecentTexts          []string // texts recently published on the same topics, their phrasing is not repeated (optional)
+	Models               routes   // models routed for the tasks by the ModelRegistry (optional)
 }
 
 const (
 	maxWordsPerSentence = 10
+	defaultComposeLimit = 512
+	translateMaxTokens  = 2048 // limit of the translated post, enough for the longest Telegram message
+	selectPromptHeader  = "You will be given a JSON array of financial news to rank."
+	answerPromptHeader  = "You will be given a question about the archive of the financial news Telegram channel."
+	filterReasonsList   = "clickbait, advertisement, non-financial, duplicate or low-value"
 )
 
+// Hashtags is the list of hashtags the composer can choose from for the news.
+var Hashtags = []string{
+	"inflation", "interestrates", "crisis", "unemployment", "bankruptcy", "dividends", "IPO",
+	"debt", "war", "buybacks", "fed", "AI", "crypto", "bitcoin",
+}
+
 func defaultPromptConfig() *promptConfig {
 	return &promptConfig{
 		ComposePrompt: `You need to fill some (or none) tickers, markets and hashtags arrays for each news.
 		If news are mentioning some companies and stocks you need to find appropriate stocks 'tickers' (ONLY STOCKS, ignore ETFs and crypto). 
 		If news are about some market events you need to fill 'markets' with some index tickers (like SPY, QQQ, or RUT etc.) based on the context.
 		News context can be also related to some popular topics, we call it 'hashtags'.
-		You only need to choose appropriate hashtag (0-3) only from this list: inflation, interestrates, crisis, unemployment, bankruptcy, dividends, IPO, debt, war, buybacks, fed, AI, crypto, bitcoin.
+		You only need to choose appropriate hashtag (0-3) only from this list: ` + strings.Join(Hashtags, ", ") + `.
 		It is OK if you don't find some tickers, markets or hashtags. It's also possible that you will find none.
 		Next you need to create an informative, original 'text' based on the title and description.
 		You need to write a 'text' that would be easy to read and understand, 1-2 sentences long.
@@ -27,6 +55,18 @@ func defaultPromptConfig() *promptConfig {
 		----------------------------------------
 		ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
 `,
+		ComposeMaxLength: defaultComposeLimit,
+		SelectPrompt: func(limit int) string {
+			return fmt.Sprintf(selectPromptHeader+`
+				You need to choose up to %v most important financial, economical and stock market news from the batch.
+				Skip blank, purposeless, clickbait, advertising, non-financial or duplicated news.
+				Sort the chosen news by importance, the most important first.
+				Always answer in the following JSON format: [{\"ID\":\"\"}] or [].
+				----------------------------------------
+				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.`,
+				limit,
+			)
+		},
 		SummarisePrompt: func(headlinesLimit int) string {
 			return fmt.Sprintf(`You will receive a JSON array of news with IDs.
 				You need to create a short (%v words max) summary for the %v most important financial, 
@@ -35,6 +75,20 @@ func defaultPromptConfig() *promptConfig {
 				Always answer in the following JSON format: [{summary:"", verb:"", id:"", link:""}]
 				----------------------------------------
 				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
+`,
+				maxWordsPerSentence,
+				headlinesLimit,
+			)
+		},
+		RecapPrompt: func(headlinesLimit int) string {
+			return fmt.Sprintf(`You will receive a JSON array of today's published news with IDs.
+				The US stock market is closed now, you need to recap the trading day.
+				Create a short (%v words max) summary in the past tense for the %v most important financial,
+				economical, stock market news that moved the markets today.
+				Find the main verb in the string and put it into the result JSON.
+				Always answer in the following JSON format: [{summary:"", verb:"", id:"", link:""}]
+				----------------------------------------
+				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
 `,
 				maxWordsPerSentence,
 				headlinesLimit,
@@ -42,24 +96,133 @@ func defaultPromptConfig() *promptConfig {
 		},
 		FilterPrompt: func() string {
 			return `You will be given a JSON array of financial news.
-				You need to remove from array blank, purposeless, clickbait, advertising or non-financial news.
+				You need to find blank, purposeless, clickbait, advertising or non-financial news that should be removed.
 				Most important news right know is inflation, interest rates, war, elections, crisis, unemployment index etc.
-				Always answer in the following JSON format: [{\"ID\":\"\",\"Title\":\"\",\"Description\":\"\"}] or [].
+				For each news set the 'Reason' why it should be removed: ` + filterReasonsList + `.
+				Leave 'Reason' empty for the news that should be kept.
+				Always answer in the following JSON format: [{\"ID\":\"\",\"Reason\":\"\"}] or [].
 				----------------------------------------
 				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.`
 		},
+		FilterModel: openai.GPT4oMini,
 		FilterPromptInstruct: func(newsJson string) string {
 			return fmt.Sprintf(`[INST]You will be given a JSON array of financial news.
-				You need to remove from array blank, purposeless, clickbait, advertising or non-financial news.
+				You need to find blank, purposeless, clickbait, advertising or non-financial news that should be removed.
 				Most important news right know is inflation, interest rates, war, elections, crisis, unemployment index etc.
-				Always answer in the following JSON format: [{\"ID\":\"\",\"Title\":\"\",\"Description\":\"\"}] or [].
+				For each news set the 'Reason' why it should be removed: %s.
+				Leave 'Reason' empty for the news that should be kept.
+				Always answer in the following JSON format: [{\"ID\":\"\",\"Reason\":\"\"}] or [].
 				----------------------------------------
 				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.
-				Input:\n%s[/INST]`, newsJson)
+				Input:\n%s[/INST]`, filterReasonsList, newsJson)
+		},
+		EventTitlesPrompt: func() string {
+			return `You will be given a JSON array of economic calendar event titles.
+				Some of them can be in other languages or use non-standard names.
+				For each title find the standard English 'name' used by the economic calendars
+				(e.g. "Verbraucherpreisindex (Jahr)" -> "CPI y/y"). Keep the standard English titles unchanged.
+				Always answer in the following JSON format: [{\"title\":\"\",\"name\":\"\"}].
+				----------------------------------------
+				ONLY JSON IS ALLOWED as an answer. No explanation or other text is allowed.`
+		},
+		AnswerPrompt: func() string {
+			return answerPromptHeader + `
+				The question is followed by a JSON array of the channel posts and economic calendar events relevant to it
+				with their IDs, dates (UTC) and links.
+				Answer the question briefly (up to 5 sentences) using ONLY the given posts and events.
+				Cite each used post by its link in parentheses right after the fact, e.g. "TSMC raised capex (https://t.me/...)".
+				If the posts don't answer the question, say that nothing was found in the archive.
+				Answer with the plain text only. No Markdown is allowed.`
 		},
+		TranslatePrompt: func(language string) string {
+			return fmt.Sprintf(`You will be given a financial news post for the Telegram channel.
+				You need to translate it into %s.
+				Keep Markdown formatting, links, hashtags, tickers (like $AAPL), emojis and numbers unchanged.
+				Answer with the translated post only. No explanation or other text is allowed.`,
+				language,
+			)
+		},
+	}
+}
+
+// composeSystemPrompt returns ComposePrompt with the target length instruction (if limit is set),
+// the channel glossary (if set) and the recently published texts (if set).
+func (p *promptConfig) composeSystemPrompt() string {
+	prompt := p.ComposePrompt
+	if p.ComposeMaxLength > 0 {
+		prompt = fmt.Sprintf("%s\nEach 'text' MUST be shorter than %d characters.", prompt, p.ComposeMaxLength)
+	}
+
+	return prompt + p.Glossary.instructions() + recentTextsInstructions(p.RecentTexts)
+}
+
+// recentTextsInstructions returns the recently published texts as an additional prompt instructions block,
+// so the new texts don't repeat the same phrasing (e.g. "X rises on Y") many times a day. Empty for no texts.
+func recentTextsInstructions(texts []string) string {
+	if len(texts) == 0 {
+		return ""
 	}
+
+	var sb strings.Builder
+	sb.WriteString("\n----------------------------------------\n")
+	sb.WriteString("These texts were recently published on the same topics. ")
+	sb.WriteString("Don't repeat their phrasing and sentence structure, the new 'text' must read differently:")
+	for _, t := range texts {
+		sb.WriteString("\n- " + strings.ReplaceAll(t, "\n", " "))
+	}
+
+	return sb.String()
+}
+
+// summariseSystemPrompt returns SummarisePrompt with the channel glossary (if set).
+func (p *promptConfig) summariseSystemPrompt(headlinesLimit int) string {
+	return p.SummarisePrompt(headlinesLimit) + p.Glossary.instructions()
+}
+
+// recapSystemPrompt returns RecapPrompt with the channel glossary (if set).
+func (p *promptConfig) recapSystemPrompt(headlinesLimit int) string {
+	return p.RecapPrompt(headlinesLimit) + p.Glossary.instructions()
+}
+
+// Glossary holds the channel terminology and style guidelines for the composed texts.
+type Glossary struct {
+	Tone      string            `json:"tone"`      // tone guidelines, e.g. "neutral, no emotions"
+	Preferred map[string]string `json:"preferred"` // preferred phrasings: phrase to avoid -> phrase to use instead
+	Banned    []string          `json:"banned"`    // words that should never be used
+}
+
+// instructions returns the glossary as an additional prompt instructions block (empty for nil glossary).
+func (g *Glossary) instructions() string {
+	if g == nil || (g.Tone == "" && len(g.Preferred) == 0 && len(g.Banned) == 0) {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n----------------------------------------\nFollow the channel guidelines for the text:")
+	if g.Tone != "" {
+		sb.WriteString(fmt.Sprintf("\n- Tone: %s.", strings.TrimSuffix(g.Tone, ".")))
+	}
+
+	phrases := make([]string, 0, len(g.Preferred))
+	for k := range g.Preferred {
+		phrases = append(phrases, k)
+	}
+	sort.Strings(phrases)
+	for _, k := range phrases {
+		sb.WriteString(fmt.Sprintf("\n- Write '%s' instead of '%s'.", g.Preferred[k], k))
+	}
+
+	if len(g.Banned) > 0 {
+		sb.WriteString(fmt.Sprintf("\n- Never use these words: %s.", strings.Join(g.Banned, ", ")))
+	}
+
+	return sb.String()
 }
 
 type summarisePromptFunc = func(headlinesLimit int) string
 
+type selectPromptFunc = func(limit int) string
+
 type filterPromptFunc = func(newsJson string) string
+
+type translatePromptFunc = func(language string) string
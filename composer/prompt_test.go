@@ -0,0 +1,73 @@
+package composer
+
+import "testing"
+
+func TestGlossary_instructions(t *testing.T) {
+	tests := []struct {
+		name     string
+		glossary *Glossary
+		want     string
+	}{
+		{
+			name:     "Should return empty string for nil glossary",
+			glossary: nil,
+			want:     "",
+		},
+		{
+			name:     "Should return empty string for empty glossary",
+			glossary: &Glossary{},
+			want:     "",
+		},
+		{
+			name: "Should return all guidelines",
+			glossary: &Glossary{
+				Tone: "neutral, no emotions.",
+				Preferred: map[string]string{
+					"rate hike": "rate increase",
+					"plunge":    "fall",
+				},
+				Banned: []string{"skyrocket", "moon"},
+			},
+			want: "\n----------------------------------------\nFollow the channel guidelines for the text:" +
+				"\n- Tone: neutral, no emotions." +
+				"\n- Write 'fall' instead of 'plunge'." +
+				"\n- Write 'rate increase' instead of 'rate hike'." +
+				"\n- Never use these words: skyrocket, moon.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.glossary.instructions(); got != tt.want {
+				t.Errorf("instructions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_recentTextsInstructions(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  string
+	}{
+		{
+			name: "Should return empty string for no texts",
+			want: "",
+		},
+		{
+			name:  "Should list the texts in one line each",
+			texts: []string{"Apple rises on strong iPhone sales.", "Apple rises on\nbuyback news."},
+			want: "\n----------------------------------------\nThese texts were recently published on the same topics. " +
+				"Don't repeat their phrasing and sentence structure, the new 'text' must read differently:" +
+				"\n- Apple rises on strong iPhone sales." +
+				"\n- Apple rises on buyback news.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recentTextsInstructions(tt.texts); got != tt.want {
+				t.Errorf("recentTextsInstructions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Tasks of the Composer the models can be routed for by the ModelRegistry.
+const (
+	TaskCompose     = "compose"      // Compose and ComposeWithModel
+	TaskSelect      = "select"       // Select
+	TaskFilter      = "filter"       // Filter
+	TaskSummarise   = "summarise"    // Summarise and Recap
+	TaskTranslate   = "translate"    // Translate
+	TaskEventTitles = "event-titles" // NormalizeEventTitles
+	TaskAnswer      = "answer"       // Answer
+)
+
+// Tasks is the list of the tasks the models can be routed for.
+var Tasks = []string{TaskCompose, TaskSelect, TaskFilter, TaskSummarise, TaskTranslate, TaskEventTitles, TaskAnswer}
+
+// RegisteredModel is the model of the ModelRegistry: the provider, model ID (e.g. the fine-tuned one)
+// and the request parameters replacing the defaults of the task.
+type RegisteredModel struct {
+	Provider    string   `json:"provider"`              // AI provider (see Providers), all tasks are served by ProviderOpenAI
+	Model       string   `json:"model"`                 // Model ID, e.g. "ft:gpt-4o-mini-2024-07-18:org::abc123"
+	Temperature *float32 `json:"temperature,omitempty"` // Sampling temperature (the task default if not set)
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // Completion tokens limit (the task default if 0)
+}
+
+// ModelRegistry is the named models and their routing to the tasks for all channels and per channel.
+// It is read from the JSON file in the following format:
+//
+//	{
+//	  "models": {"channel-style": {"provider": "OpenAI", "model": "ft:gpt-4o-mini-2024-07-18:org::abc123", "temperature": 0.8}},
+//	  "tasks": {"compose": "channel-style"},
+//	  "channels": {"@tenant": {"compose": "tenant-style"}}
+//	}
+type ModelRegistry struct {
+	Models   map[string]*RegisteredModel  `json:"models"`   // Model name -> model
+	Tasks    map[string]string            `json:"tasks"`    // Task -> model name of all channels
+	Channels map[string]map[string]string `json:"channels"` // Channel ID -> task -> model name, overrides Tasks
+}
+
+// LoadModelRegistry reads the ModelRegistry from the JSON file and validates it.
+func LoadModelRegistry(path string) (*ModelRegistry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newError(err, errlvl.ERROR, "LoadModelRegistry", "os.ReadFile").WithValue(path)
+	}
+
+	var r ModelRegistry
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, newError(err, errlvl.ERROR, "LoadModelRegistry", "json.Unmarshal").WithValue(path)
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, newError(err, errlvl.ERROR, "LoadModelRegistry", "validation").WithValue(path)
+	}
+
+	return &r, nil
+}
+
+// Validate returns an error if the model is not served by the provider of the tasks, has no model ID
+// or the parameters are out of the API range.
+func (m *RegisteredModel) Validate() error {
+	if m == nil || strings.TrimSpace(m.Model) == "" {
+		return errors.New("model ID is required")
+	}
+
+	// All tasks are sent to the OpenAI client (fine-tuned models are served by the same API)
+	if provider, ok := ParseProvider(m.Provider); !ok || provider != ProviderOpenAI {
+		return fmt.Errorf("unsupported provider %q, expected %s", m.Provider, ProviderOpenAI)
+	}
+
+	if m.Temperature != nil && (*m.Temperature < 0 || *m.Temperature > 2) {
+		return fmt.Errorf("temperature %.2f is out of range [0, 2]", *m.Temperature)
+	}
+
+	if m.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens %d is negative", m.MaxTokens)
+	}
+
+	return nil
+}
+
+// Validate returns an error if any model is invalid or any route refers to the unknown task or model.
+func (r *ModelRegistry) Validate() error {
+	for name, m := range r.Models {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("model %q: %w", name, err)
+		}
+	}
+
+	if err := r.validateRoutes(r.Tasks); err != nil {
+		return fmt.Errorf("tasks: %w", err)
+	}
+
+	for channelID, tasks := range r.Channels {
+		if err := r.validateRoutes(tasks); err != nil {
+			return fmt.Errorf("channel %s: %w", channelID, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ModelRegistry) validateRoutes(tasks map[string]string) error {
+	for task, name := range tasks {
+		if !slices.Contains(Tasks, task) {
+			return fmt.Errorf("unknown task %q, expected one of %v", task, Tasks)
+		}
+		if _, ok := r.Models[name]; !ok {
+			return fmt.Errorf("task %q: unknown model %q", task, name)
+		}
+	}
+
+	return nil
+}
+
+// routes is the map of the task -> model routed for it.
+type routes map[string]*RegisteredModel
+
+// Routes returns the models routed for the tasks of the channel: the channel routes over the common ones.
+// Returns nil for the nil registry.
+func (r *ModelRegistry) Routes(channelID string) map[string]*RegisteredModel {
+	if r == nil {
+		return nil
+	}
+
+	models := make(map[string]*RegisteredModel, len(Tasks))
+	for task, name := range r.Tasks {
+		models[task] = r.Models[name]
+	}
+	for task, name := range r.Channels[channelID] {
+		models[task] = r.Models[name]
+	}
+
+	return models
+}
+
+// Override returns the copy of the registry that routes the tasks of all channels to the OpenAI model
+// (e.g. to try another model in a single run), other routes are kept. Works with the nil registry.
+func (r *ModelRegistry) Override(model string, tasks ...string) (*ModelRegistry, error) {
+	m := &RegisteredModel{Provider: ProviderOpenAI, Model: model}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	o := &ModelRegistry{
+		Models:   map[string]*RegisteredModel{},
+		Tasks:    map[string]string{},
+		Channels: map[string]map[string]string{},
+	}
+	if r != nil {
+		maps.Copy(o.Models, r.Models)
+		maps.Copy(o.Tasks, r.Tasks)
+		for channelID, routes := range r.Channels {
+			o.Channels[channelID] = maps.Clone(routes)
+		}
+	}
+
+	name := "override:" + model
+	o.Models[name] = m
+	for _, task := range tasks {
+		o.Tasks[task] = name
+		for _, routes := range o.Channels {
+			delete(routes, task)
+		}
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// route applies the model routed for the task (see UseModelRoutes) to the request: the model ID
+// and the set parameters. The request is not changed if no model is routed for the task.
+func (p *promptConfig) route(task string, req *openai.ChatCompletionRequest) {
+	m := p.Models[task]
+	if m == nil {
+		return
+	}
+
+	req.Model = m.Model
+	if m.Temperature != nil {
+		req.Temperature = *m.Temperature
+	}
+	if m.MaxTokens > 0 {
+		req.MaxTokens = m.MaxTokens
+	}
+}
+
+// baseModel returns the base model of the OpenAI fine-tuned model ID ("ft:<base>:<org>::<id>"),
+// so the tokens and the context window are counted as for the base model. Other IDs are returned as is.
+func baseModel(model string) string {
+	if rest, ok := strings.CutPrefix(model, "ft:"); ok {
+		base, _, _ := strings.Cut(rest, ":")
+		return base
+	}
+
+	return model
+}
@@ -0,0 +1,189 @@
+package composer
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/journalist"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadModelRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "Should load the registry",
+			content: `{"models":{"style":{"provider":"openai","model":"ft:gpt-4o-mini-2024-07-18:org::abc","temperature":0.8}},
+				"tasks":{"compose":"style"},"channels":{"@tenant":{"summarise":"style"}}}`,
+		},
+		{
+			name:    "Should return error for unknown task",
+			content: `{"models":{"style":{"provider":"OpenAI","model":"gpt-4o"}},"tasks":{"write":"style"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error for unknown model",
+			content: `{"models":{},"channels":{"@tenant":{"compose":"style"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error for unsupported provider",
+			content: `{"models":{"style":{"provider":"TogetherAI","model":"mixtral"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error for empty model ID",
+			content: `{"models":{"style":{"provider":"OpenAI"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Should return error for temperature out of range",
+			content: `{"models":{"style":{"provider":"OpenAI","model":"gpt-4o","temperature":3}}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "models.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadModelRegistry(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadModelRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModelRegistry_Routes(t *testing.T) {
+	common := &RegisteredModel{Provider: ProviderOpenAI, Model: "ft:gpt-4o-mini-2024-07-18:org::common"}
+	tenant := &RegisteredModel{Provider: ProviderOpenAI, Model: "ft:gpt-4o-mini-2024-07-18:org::tenant"}
+	r := &ModelRegistry{
+		Models:   map[string]*RegisteredModel{"common": common, "tenant": tenant},
+		Tasks:    map[string]string{TaskCompose: "common", TaskFilter: "common"},
+		Channels: map[string]map[string]string{"@tenant": {TaskCompose: "tenant"}},
+	}
+
+	main := r.Routes("@main")
+	if main[TaskCompose] != common || main[TaskFilter] != common || main[TaskSummarise] != nil {
+		t.Errorf("Routes() of the main channel = %v, want the common routes", main)
+	}
+
+	tenantRoutes := r.Routes("@tenant")
+	if tenantRoutes[TaskCompose] != tenant || tenantRoutes[TaskFilter] != common {
+		t.Errorf("Routes() of the tenant = %v, want the tenant compose model over the common one", tenantRoutes)
+	}
+
+	if got := (*ModelRegistry)(nil).Routes("@main"); got != nil {
+		t.Errorf("Routes() of nil registry = %v, want nil", got)
+	}
+}
+
+func TestComposer_UseModelRoutes(t *testing.T) {
+	temperature := float32(0.5)
+	r := &ModelRegistry{
+		Models: map[string]*RegisteredModel{
+			"style": {Provider: ProviderOpenAI, Model: "ft:gpt-4o-mini-2024-07-18:org::abc", Temperature: &temperature},
+		},
+		Tasks: map[string]string{TaskCompose: "style", TaskSummarise: "style"},
+	}
+	bandit, err := NewBandit([]Arm{{Model: "gpt-4o", Cost: 1}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &answersClient{answers: []string{
+		`[{"id":"1","text":"Apple"}]`,
+		`[{"id":"1","summary":"Apple beats estimates","verb":"beats","link":""}]`,
+		`Apple übertrifft die Erwartungen`,
+	}}
+	c := (&Composer{OpenAiClient: client, Config: defaultPromptConfig()}).WithBandit(bandit).With(UseModelRoutes(r, "@main"))
+	news := journalist.NewsList{{ID: "1", Title: "Apple beats estimates", Date: time.Now()}}
+
+	if _, err := c.Compose(context.Background(), news); err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+	if _, err := c.Summarise(context.Background(), []*Headline{{ID: "1", Text: "Apple beats estimates"}}, 1, 512); err != nil {
+		t.Fatalf("Summarise() error = %v", err)
+	}
+	if _, err := c.Translate(context.Background(), "Apple beats estimates", "German"); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+
+	for i, want := range []string{"ft:gpt-4o-mini-2024-07-18:org::abc", "ft:gpt-4o-mini-2024-07-18:org::abc", "gpt-4o-mini"} {
+		if got := client.requests[i].Model; got != want {
+			t.Errorf("request %d model = %s, want %s", i, got, want)
+		}
+	}
+	if client.requests[0].Temperature != temperature {
+		t.Errorf("Compose() temperature = %v, want the routed %v", client.requests[0].Temperature, temperature)
+	}
+	if client.requests[1].MaxTokens != 512 {
+		t.Errorf("Summarise() max tokens = %d, want the task default 512", client.requests[1].MaxTokens)
+	}
+}
+
+func Test_baseModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{model: "ft:gpt-4o-mini-2024-07-18:org::abc123", want: "gpt-4o-mini-2024-07-18"},
+		{model: "ft:gpt-4o-mini-2024-07-18:org:custom-suffix:abc123", want: "gpt-4o-mini-2024-07-18"},
+		{model: "gpt-4o", want: "gpt-4o"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := baseModel(tt.model); got != tt.want {
+				t.Errorf("baseModel() = %v, want %v", got, tt.want)
+			}
+			if contextWindow(tt.model) != contextWindow(tt.want) {
+				t.Errorf("contextWindow() of %s differs from the base model", tt.model)
+			}
+		})
+	}
+}
+
+func TestModelRegistry_Override(t *testing.T) {
+	common := &RegisteredModel{Provider: ProviderOpenAI, Model: "ft:gpt-4o-mini-2024-07-18:org::common"}
+	r := &ModelRegistry{
+		Models:   map[string]*RegisteredModel{"common": common},
+		Tasks:    map[string]string{TaskCompose: "common", TaskFilter: "common"},
+		Channels: map[string]map[string]string{"@tenant": {TaskCompose: "common"}},
+	}
+
+	o, err := r.Override("gpt-4o", TaskCompose, TaskSelect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, channelID := range []string{"@main", "@tenant"} {
+		routes := o.Routes(channelID)
+		if routes[TaskCompose].Model != "gpt-4o" || routes[TaskSelect].Model != "gpt-4o" || routes[TaskFilter] != common {
+			t.Errorf("Routes(%q) of the override = %v, want gpt-4o for compose and select", channelID, routes)
+		}
+	}
+	if r.Tasks[TaskCompose] != "common" || r.Channels["@tenant"][TaskCompose] != "common" || len(r.Models) != 1 {
+		t.Errorf("Override() changed the original registry: %+v", r)
+	}
+
+	o, err = (*ModelRegistry)(nil).Override("gpt-4o", TaskSummarise)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if routes := o.Routes("@main"); len(routes) != 1 || routes[TaskSummarise].Model != "gpt-4o" {
+		t.Errorf("Routes() of the nil registry override = %v, want only summarise", routes)
+	}
+
+	if _, err := r.Override("gpt-4o", "unknown"); err == nil {
+		t.Error("Override() of the unknown task error = nil")
+	}
+	if _, err := r.Override(" ", TaskCompose); err == nil {
+		t.Error("Override() with the empty model error = nil")
+	}
+}
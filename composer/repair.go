@@ -0,0 +1,58 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+)
+
+// repairPrompt is the follow-up message asking the model to correct its invalid JSON answer.
+const repairPrompt = `Your previous answer can't be parsed as JSON: %s.
+Answer again with the corrected JSON in the same format. ONLY JSON IS ALLOWED as an answer.`
+
+// unmarshalAnswer fixes (see aiJSONStringFixer) and unmarshals the AI answer into v.
+// If the answer is still invalid, it sends one follow-up message to the model with the parse error
+// and its invalid answer asking to correct it, before giving up. The original request is not modified.
+func (c *Composer) unmarshalAnswer(ctx context.Context, fnName string, req openai.ChatCompletionRequest, answer string, v any) error {
+	parseErr := parseAnswer(answer, v)
+	if parseErr == nil {
+		return nil
+	}
+
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages)+2)
+	messages = append(messages, req.Messages...)
+	messages = append(messages,
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf(repairPrompt, parseErr)},
+	)
+	req.Messages = messages
+
+	resp, err := c.OpenAiClient.CreateChatCompletion(ctx, req)
+	if err != nil || len(resp.Choices) == 0 {
+		return newError(parseErr, errlvl.ERROR, fnName, "parseAnswer").WithValue(answer)
+	}
+
+	if err := parseAnswer(resp.Choices[0].Message.Content, v); err != nil {
+		return newError(errors.Join(parseErr, err), errlvl.ERROR, fnName, "parseAnswer (repaired)").
+			WithValue(resp.Choices[0].Message.Content)
+	}
+
+	return nil
+}
+
+// parseAnswer fixes the most common AI JSON bugs and unmarshals the answer into v.
+func parseAnswer(answer string, v any) error {
+	matches, err := aiJSONStringFixer(answer)
+	if err != nil {
+		return errNoJSONArray
+	}
+
+	if err := json.Unmarshal([]byte(matches), v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	return nil
+}
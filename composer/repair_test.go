@@ -0,0 +1,101 @@
+package composer
+
+import (
+	"context"
+	"errors"
+	"github.com/sashabaranov/go-openai"
+	"strings"
+	"testing"
+)
+
+// answersClient answers with the given answers one by one and records the requests.
+type answersClient struct {
+	answers  []string
+	requests []openai.ChatCompletionRequest
+}
+
+func (a *answersClient) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	a.requests = append(a.requests, req)
+	if len(a.answers) == 0 {
+		return openai.ChatCompletionResponse{}, errors.New("no more answers")
+	}
+
+	answer := a.answers[0]
+	a.answers = a.answers[1:]
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: answer}}},
+	}, nil
+}
+
+func (a *answersClient) CreateEmbeddings(context.Context, openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return openai.EmbeddingResponse{}, errors.New("embeddings are not supported")
+}
+
+func TestComposer_unmarshalAnswer(t *testing.T) {
+	req := openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "payload"}},
+	}
+
+	tests := []struct {
+		name         string
+		answer       string
+		repaired     []string
+		wantID       string
+		wantErr      bool
+		wantRequests int
+	}{
+		{
+			name:         "valid answer",
+			answer:       `[{"ID":"1"}]`,
+			wantID:       "1",
+			wantRequests: 0,
+		},
+		{
+			name:         "repaired answer",
+			answer:       `[{"ID":"1",}]`,
+			repaired:     []string{`[{"ID":"2"}]`},
+			wantID:       "2",
+			wantRequests: 1,
+		},
+		{
+			name:         "still invalid after repair",
+			answer:       `[{"ID":"1",}]`,
+			repaired:     []string{`no json`},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "repair request failed",
+			answer:       `no json`,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &answersClient{answers: tt.repaired}
+			c := &Composer{OpenAiClient: client, Config: defaultPromptConfig()}
+
+			var got []*filterDecision
+			err := c.unmarshalAnswer(context.Background(), "Test", req, tt.answer, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unmarshalAnswer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(client.requests) != tt.wantRequests {
+				t.Fatalf("unmarshalAnswer() requests = %d, want %d", len(client.requests), tt.wantRequests)
+			}
+			if !tt.wantErr && (len(got) != 1 || got[0].ID != tt.wantID) {
+				t.Errorf("unmarshalAnswer() = %v, want ID %s", got, tt.wantID)
+			}
+			if tt.wantRequests > 0 {
+				msgs := client.requests[0].Messages
+				if len(msgs) != 3 || msgs[1].Content != tt.answer || !strings.Contains(msgs[2].Content, "can't be parsed") {
+					t.Errorf("unmarshalAnswer() repair messages = %v", msgs)
+				}
+				if len(req.Messages) != 1 {
+					t.Errorf("unmarshalAnswer() should not modify the original request")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sashabaranov/go-openai"
+	"hash/fnv"
+	"strings"
+)
+
+// sandboxOpenAiClient is a fake OpenAI client for the sandbox mode.
+// It answers with deterministic responses based on the request payload, so the whole pipeline
+// can be run locally without any AI provider credentials:
+//   - Filter keeps all news;
+//   - Select keeps all news;
+//   - Compose uses the original title as the composed text without any meta;
+//   - Summarise uses the headline text as the summary;
+//   - Answer lists the given posts;
+//   - embeddings are the hashed bags of words, so Rank prefers the headlines with the query words.
+type sandboxOpenAiClient struct {
+	config *promptConfig
+}
+
+// NewSandboxComposer creates a new Composer instance that doesn't call any external AI providers.
+func NewSandboxComposer() *Composer {
+	config := defaultPromptConfig()
+	return &Composer{
+		OpenAiClient: &sandboxOpenAiClient{config: config},
+		Config:       config,
+		Metrics:      &Metrics{},
+	}
+}
+
+func (s *sandboxOpenAiClient) CreateChatCompletion(
+	_ context.Context,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	var system, user string
+	for _, m := range req.Messages {
+		switch m.Role {
+		case openai.ChatMessageRoleSystem:
+			system = m.Content
+		case openai.ChatMessageRoleUser:
+			user = m.Content
+		}
+	}
+
+	var content any
+	switch {
+	case system == "":
+		content = "pong"
+	case strings.HasPrefix(system, selectPromptHeader):
+		content = json.RawMessage(user)
+	// Prompts can be extended with the per-call options (length, glossary), so only the base prompts are compared
+	case strings.HasPrefix(system, s.config.ComposePrompt):
+		var news []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal([]byte(user), &news); err != nil {
+			return openai.ChatCompletionResponse{}, errors.Join(errors.New("sandbox: invalid compose payload"), err)
+		}
+		composed := make([]*ComposedNews, 0, len(news))
+		for _, n := range news {
+			composed = append(composed, &ComposedNews{ID: n.ID, Text: n.Title, Tickers: []string{}, Markets: []string{}, Hashtags: []string{}})
+		}
+		content = composed
+	case strings.HasPrefix(system, s.config.FilterPrompt()):
+		content = json.RawMessage(user)
+	case strings.HasPrefix(system, answerPromptHeader):
+		var headlines []*Headline
+		_, posts, _ := strings.Cut(user, "\nPosts: ")
+		if err := json.Unmarshal([]byte(posts), &headlines); err != nil {
+			return openai.ChatCompletionResponse{}, errors.Join(errors.New("sandbox: invalid answer payload"), err)
+		}
+		lines := []string{fmt.Sprintf("Sandbox answer based on %d posts:", len(headlines))}
+		for _, h := range headlines {
+			lines = append(lines, fmt.Sprintf("%s %s (%s)", h.Date, h.Text, h.Link))
+		}
+		content = strings.Join(lines, "\n")
+	default:
+		var headlines []*Headline
+		if err := json.Unmarshal([]byte(user), &headlines); err != nil {
+			return openai.ChatCompletionResponse{}, errors.Join(errors.New("sandbox: invalid summarise payload"), err)
+		}
+		summarised := make([]*SummarisedHeadline, 0, len(headlines))
+		for _, h := range headlines {
+			summarised = append(summarised, &SummarisedHeadline{ID: h.ID, Summary: h.Text, Link: h.Link})
+		}
+		content = summarised
+	}
+
+	text, ok := content.(string)
+	if !ok {
+		b, err := json.Marshal(content)
+		if err != nil {
+			return openai.ChatCompletionResponse{}, errors.Join(errors.New("sandbox: failed to marshal response"), err)
+		}
+		text = string(b)
+	}
+
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: text}},
+		},
+	}, nil
+}
+
+// sandboxEmbeddingSize is the size of the sandbox embeddings.
+const sandboxEmbeddingSize = 64
+
+func (s *sandboxOpenAiClient) CreateEmbeddings(
+	_ context.Context,
+	conv openai.EmbeddingRequestConverter,
+) (openai.EmbeddingResponse, error) {
+	input, ok := conv.Convert().Input.([]string)
+	if !ok {
+		return openai.EmbeddingResponse{}, errors.New("sandbox: only string embeddings are supported")
+	}
+
+	resp := openai.EmbeddingResponse{Data: make([]openai.Embedding, 0, len(input))}
+	for i, text := range input {
+		vector := make([]float32, sandboxEmbeddingSize)
+		for _, w := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(strings.Trim(w, ".,:;!?\"'()")))
+			vector[h.Sum32()%sandboxEmbeddingSize]++
+		}
+		resp.Data = append(resp.Data, openai.Embedding{Index: i, Embedding: vector})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,47 @@
+package composer
+
+import (
+	"context"
+	"github.com/samgozman/fin-thread/journalist"
+	"testing"
+	"time"
+)
+
+func TestNewSandboxComposer(t *testing.T) {
+	c := NewSandboxComposer()
+	news := journalist.NewsList{
+		{ID: "1", Title: "First title", Description: "First description", Date: time.Now()},
+		{ID: "2", Title: "Second title", Description: "Second description", Date: time.Now()},
+	}
+
+	filtered, err := c.Filter(context.Background(), news)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	for _, n := range filtered {
+		if n.IsFiltered {
+			t.Errorf("Filter() news %s is filtered, but sandbox should keep all news", n.ID)
+		}
+	}
+
+	composed, err := c.Compose(context.Background(), news)
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+	if len(composed) != len(news) {
+		t.Fatalf("Compose() len = %v, want %v", len(composed), len(news))
+	}
+	for i, n := range composed {
+		if n.ID != news[i].ID || n.Text != news[i].Title {
+			t.Errorf("Compose() = %+v, want text %q for id %s", n, news[i].Title, news[i].ID)
+		}
+	}
+
+	summarised, err := c.Summarise(context.Background(), []*Headline{{ID: "1", Text: "Some text", Link: "https://t.me/c/1"}}, 1, 256)
+	if err != nil {
+		t.Fatalf("Summarise() error = %v", err)
+	}
+	if len(summarised) != 1 || summarised[0].Summary != "Some text" || summarised[0].Link != "https://t.me/c/1" {
+		t.Errorf("Summarise() = %+v", summarised)
+	}
+}
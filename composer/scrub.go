@@ -0,0 +1,86 @@
+package composer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AI providers by the name used in Composer.Ping and UseScrubbing.
+const (
+	ProviderOpenAI     = "OpenAI"
+	ProviderTogetherAI = "TogetherAI"
+	ProviderGemini     = "GoogleGemini"
+)
+
+// Providers is the list of the supported AI providers.
+var Providers = []string{ProviderOpenAI, ProviderTogetherAI, ProviderGemini}
+
+// Placeholders of the scrubbed personal data, so AI still understands that something was there.
+const (
+	scrubbedEmail = "[email]"
+	scrubbedPhone = "[phone]"
+)
+
+var (
+	emailRegexp = regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)*\.[a-zA-Z]{2,}`)
+	phoneRegexp = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]\d{4}\b`)
+	linkRegexp  = regexp.MustCompile(`https?://[^\s"'<>()]+`)
+)
+
+// trackingParams are the query parameters of the links added by the newsletters and ad networks.
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"yclid":  true,
+	"igshid": true,
+	"mc_cid": true,
+	"mc_eid": true,
+	"_hsenc": true,
+	"_hsmi":  true,
+}
+
+// ScrubText removes the personal data and tracking junk from the news text before it is sent to the AI provider:
+// emails and phone numbers are replaced with placeholders, tracking parameters (utm_*, fbclid, etc.) are removed
+// from the links.
+func ScrubText(text string) string {
+	text = emailRegexp.ReplaceAllString(text, scrubbedEmail)
+	text = phoneRegexp.ReplaceAllString(text, scrubbedPhone)
+	return linkRegexp.ReplaceAllStringFunc(text, removeTrackingParams)
+}
+
+// removeTrackingParams removes the tracking query parameters from the link keeping the order of the others.
+func removeTrackingParams(link string) string {
+	base, query, ok := strings.Cut(link, "?")
+	if !ok {
+		return link
+	}
+
+	query, fragment, hasFragment := strings.Cut(query, "#")
+	var params []string
+	for _, p := range strings.Split(query, "&") {
+		key, _, _ := strings.Cut(p, "=")
+		if key == "" || strings.HasPrefix(strings.ToLower(key), "utm_") || trackingParams[strings.ToLower(key)] {
+			continue
+		}
+		params = append(params, p)
+	}
+
+	if len(params) > 0 {
+		base += "?" + strings.Join(params, "&")
+	}
+	if hasFragment {
+		base += "#" + fragment
+	}
+
+	return base
+}
+
+// ParseProvider returns the AI provider by its case-insensitive name.
+func ParseProvider(name string) (string, bool) {
+	for _, p := range Providers {
+		if strings.EqualFold(p, strings.TrimSpace(name)) {
+			return p, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,64 @@
+package composer
+
+import "testing"
+
+func TestScrubText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "email",
+			text: "For more information contact john.doe+ir@example.co.uk today.",
+			want: "For more information contact [email] today.",
+		},
+		{
+			name: "phone numbers",
+			text: "Call (212) 555-1234 or +1 646.555.9876.",
+			want: "Call [phone] or [phone].",
+		},
+		{
+			name: "tracking parameters",
+			text: "Read more: https://example.com/news?id=42&utm_source=rss&utm_medium=feed&fbclid=abc#top",
+			want: "Read more: https://example.com/news?id=42#top",
+		},
+		{
+			name: "only tracking parameters",
+			text: "https://example.com/news?utm_campaign=daily",
+			want: "https://example.com/news",
+		},
+		{
+			name: "financial numbers are kept",
+			text: "Revenue rose 12.5% to $1,234,567 on 2024-05-01, EPS 1.23 vs 1.10 expected.",
+			want: "Revenue rose 12.5% to $1,234,567 on 2024-05-01, EPS 1.23 vs 1.10 expected.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScrubText(tt.text); got != tt.want {
+				t.Errorf("ScrubText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "openai", want: ProviderOpenAI, wantOk: true},
+		{name: " GoogleGemini ", want: ProviderGemini, wantOk: true},
+		{name: "mistral", want: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseProvider(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ParseProvider() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
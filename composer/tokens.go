@@ -0,0 +1,200 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/sashabaranov/go-openai"
+	"sync"
+	"unicode/utf8"
+)
+
+// contextWindows holds the context window sizes (in tokens) of the models used by the Composer.
+var contextWindows = map[string]int{
+	openai.GPT4oMini:                       128_000,
+	openai.GPT4oMini20240718:               128_000,
+	openai.GPT4o:                           128_000,
+	openai.GPT4o20240513:                   128_000,
+	"mistralai/Mixtral-8x7B-Instruct-v0.1": 32_768,
+}
+
+const (
+	defaultContextWindow   = 8_192         // context window of the unknown models
+	defaultEncoding        = "cl100k_base" // encoding of the models unknown to tiktoken
+	messageTokensOverhead  = 4             // tokens added by the chat format to each message
+	trimmedDescriptionSize = 256           // max description length in characters if the payload doesn't fit the context window
+)
+
+var (
+	errPromptTooLarge = errors.New("system prompt and completion don't fit the model context window")
+	errNewsTooLarge   = errors.New("single news doesn't fit the model context window")
+)
+
+func init() {
+	// Use embedded BPE files instead of downloading them in runtime
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// encoders caches tiktoken encoders by the model name (loading BPE ranks is expensive).
+var encoders = struct {
+	sync.Mutex
+	byModel map[string]*tiktoken.Tiktoken
+}{byModel: make(map[string]*tiktoken.Tiktoken)}
+
+// CountTokens returns the number of tokens in the text for the given model (tiktoken-compatible).
+// Models unknown to tiktoken (e.g. Mixtral) are counted with cl100k_base encoding which is close enough for the limits.
+func CountTokens(model, text string) int {
+	encoders.Lock()
+	enc, ok := encoders.byModel[model]
+	if !ok {
+		var err error
+		enc, err = tiktoken.EncodingForModel(baseModel(model))
+		if err != nil {
+			enc, err = tiktoken.GetEncoding(defaultEncoding)
+		}
+		if err != nil {
+			enc = nil
+		}
+		encoders.byModel[model] = enc
+	}
+	encoders.Unlock()
+
+	if enc == nil {
+		// Rough estimation: ~4 characters per token for English
+		return utf8.RuneCountInString(text)/4 + 1
+	}
+
+	return len(enc.EncodeOrdinary(text))
+}
+
+// contextWindow returns the context window size of the model in tokens.
+func contextWindow(model string) int {
+	if w, ok := contextWindows[baseModel(model)]; ok {
+		return w
+	}
+	return defaultContextWindow
+}
+
+// promptBuilder measures the system prompt, few-shot examples and the news payload against the model context window
+// and prepares the payloads that fit into it, so the overflows are handled before the API call.
+type promptBuilder struct {
+	provider    string     // AI provider of the model (see Providers), ProviderOpenAI if empty
+	model       string     // model name to count tokens and find the context window
+	maxTokens   int        // tokens reserved for the completion
+	temperature *float32   // sampling temperature of the model routed for the task (the task default if nil)
+	system      string     // system prompt
+	examples    []*Example // few-shot examples sent with each payload
+	scrub       bool       // if true, personal data and tracking junk are removed from the news (see ScrubText)
+}
+
+// use replaces the model, completion limit and temperature of the builder with the model routed
+// for the task (see UseModelRoutes). The builder is not changed if no model is routed.
+func (b *promptBuilder) use(m *RegisteredModel) *promptBuilder {
+	if m == nil {
+		return b
+	}
+
+	b.model = m.Model
+	if m.MaxTokens > 0 {
+		b.maxTokens = m.MaxTokens
+	}
+	b.temperature = m.Temperature
+	return b
+}
+
+// temperatureOr returns the temperature of the routed model or the default one of the task.
+func (b *promptBuilder) temperatureOr(temperature float32) float32 {
+	if b.temperature != nil {
+		return *b.temperature
+	}
+	return temperature
+}
+
+// budget returns the number of tokens available for the news payload.
+func (b *promptBuilder) budget() int {
+	used := b.maxTokens + CountTokens(b.model, b.system) + 2*messageTokensOverhead
+	for _, e := range b.examples {
+		used += CountTokens(b.model, string(e.Input)) + CountTokens(b.model, string(e.Output)) + 2*messageTokensOverhead
+	}
+
+	return contextWindow(b.model) - used
+}
+
+// payloads returns JSON payloads (see journalist.NewsList.ToContentJSON) of the news that fit into the context window.
+// If all news don't fit into one payload, descriptions are trimmed first and then the news are split into batches.
+// The original news are never modified.
+func (b *promptBuilder) payloads(news journalist.NewsList) ([]string, error) {
+	budget := b.budget()
+	if budget <= 0 {
+		return nil, fmt.Errorf("%w: %s, %d tokens over", errPromptTooLarge, b.model, -budget)
+	}
+
+	if b.scrub {
+		scrubbed := make(journalist.NewsList, len(news))
+		for i, n := range news {
+			s := *n
+			s.Title = ScrubText(s.Title)
+			s.Description = ScrubText(s.Description)
+			scrubbed[i] = &s
+		}
+		news = scrubbed
+	}
+
+	payload, err := news.ToContentJSON()
+	if err != nil {
+		return nil, err
+	}
+	if CountTokens(b.model, payload) <= budget {
+		return []string{payload}, nil
+	}
+
+	trimmed := make(journalist.NewsList, len(news))
+	for i, n := range news {
+		t := *n
+		t.Description = truncateText(t.Description, trimmedDescriptionSize)
+		trimmed[i] = &t
+	}
+
+	payload, err = trimmed.ToContentJSON()
+	if err != nil {
+		return nil, err
+	}
+	if CountTokens(b.model, payload) <= budget {
+		return []string{payload}, nil
+	}
+
+	// Split into batches by the tokens of each news (JSON array overhead is negligible)
+	var batches []journalist.NewsList
+	var batch journalist.NewsList
+	batchTokens := 0
+	for _, n := range trimmed {
+		item, err := journalist.NewsList{n}.ToContentJSON()
+		if err != nil {
+			return nil, err
+		}
+		tokens := CountTokens(b.model, item)
+		if tokens > budget {
+			return nil, fmt.Errorf("%w: news %s", errNewsTooLarge, n.ID)
+		}
+		if batchTokens+tokens > budget {
+			batches = append(batches, batch)
+			batch, batchTokens = nil, 0
+		}
+		batch = append(batch, n)
+		batchTokens += tokens
+	}
+	batches = append(batches, batch)
+
+	result := make([]string, 0, len(batches))
+	for _, bn := range batches {
+		p, err := bn.ToContentJSON()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package composer
+
+import (
+	"errors"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/sashabaranov/go-openai"
+	"strings"
+	"testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		text  string
+		want  int
+	}{
+		{name: "gpt-4o", model: openai.GPT4o, text: "hello world", want: 2},
+		{name: "unknown model uses cl100k_base", model: "mistralai/Mixtral-8x7B-Instruct-v0.1", text: "hello world", want: 2},
+		{name: "empty", model: openai.GPT4oMini, text: "", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountTokens(tt.model, tt.text); got != tt.want {
+				t.Errorf("CountTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_promptBuilder_payloads(t *testing.T) {
+	const model = "test-model"
+	contextWindows[model] = 400
+	t.Cleanup(func() { delete(contextWindows, model) })
+
+	long := strings.Repeat("Inflation is cooling down. ", 40)
+	short := journalist.NewsList{
+		{ID: "1", Title: "Fed holds rates", Description: "The Fed kept rates unchanged."},
+		{ID: "2", Title: "Oil jumps", Description: "Oil prices rose 3%."},
+	}
+
+	t.Run("fits as is", func(t *testing.T) {
+		b := &promptBuilder{model: model, maxTokens: 100, system: "system"}
+		got, err := b.payloads(short)
+		if err != nil {
+			t.Fatalf("payloads() error = %v", err)
+		}
+		want, _ := short.ToContentJSON()
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("payloads() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("trims descriptions", func(t *testing.T) {
+		news := journalist.NewsList{{ID: "1", Title: "CPI report", Description: long}}
+		b := &promptBuilder{model: model, maxTokens: 250, system: "system"}
+		got, err := b.payloads(news)
+		if err != nil {
+			t.Fatalf("payloads() error = %v", err)
+		}
+		if len(got) != 1 || strings.Contains(got[0], long) {
+			t.Errorf("payloads() = %v, want one payload with trimmed description", got)
+		}
+		if news[0].Description != long {
+			t.Errorf("payloads() should not modify the original news")
+		}
+	})
+
+	t.Run("splits into batches", func(t *testing.T) {
+		news := make(journalist.NewsList, 0, 6)
+		for _, id := range []string{"1", "2", "3", "4", "5", "6"} {
+			news = append(news, &journalist.News{ID: id, Title: "CPI report " + id, Description: long})
+		}
+		b := &promptBuilder{model: model, maxTokens: 100, system: "system"}
+		got, err := b.payloads(news)
+		if err != nil {
+			t.Fatalf("payloads() error = %v", err)
+		}
+		if len(got) < 2 {
+			t.Fatalf("payloads() len = %v, want at least 2 batches", len(got))
+		}
+		for i, p := range got {
+			if CountTokens(model, p) > b.budget() {
+				t.Errorf("payloads()[%d] doesn't fit the budget", i)
+			}
+		}
+		if !strings.Contains(got[0], `"id":"1"`) || !strings.Contains(got[len(got)-1], `"id":"6"`) {
+			t.Errorf("payloads() should keep the news order")
+		}
+	})
+
+	t.Run("scrubs news", func(t *testing.T) {
+		news := journalist.NewsList{{ID: "1", Title: "Fed holds rates", Description: "Contact press@fed.gov for details."}}
+		b := &promptBuilder{model: model, maxTokens: 100, system: "system", scrub: true}
+		got, err := b.payloads(news)
+		if err != nil {
+			t.Fatalf("payloads() error = %v", err)
+		}
+		if len(got) != 1 || strings.Contains(got[0], "press@fed.gov") || !strings.Contains(got[0], scrubbedEmail) {
+			t.Errorf("payloads() = %v, want scrubbed email", got)
+		}
+		if news[0].Description != "Contact press@fed.gov for details." {
+			t.Errorf("payloads() modified the original news")
+		}
+	})
+
+	t.Run("prompt too large", func(t *testing.T) {
+		b := &promptBuilder{model: model, maxTokens: 400, system: "system"}
+		if _, err := b.payloads(short); !errors.Is(err, errPromptTooLarge) {
+			t.Errorf("payloads() error = %v, want %v", err, errPromptTooLarge)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package composer
 
 import (
+	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 // aiJSONStringFixer will fix the most weird OpenAI & Mistral bugs with a broken JSON array.
@@ -29,3 +31,23 @@ func aiJSONStringFixer(str string) (string, error) {
 
 	return matches, nil
 }
+
+// truncateText shortens the text to maxLength characters (runes) by the last complete sentence.
+// If there is no complete sentence within the limit, the text is cut by the last word and "…" is added.
+// Text is returned as is if maxLength is 0 or the text is short enough.
+func truncateText(text string, maxLength int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return string(runes)
+	}
+
+	// Find the last sentence end within the limit which is followed by a space
+	for i := maxLength - 1; i > 0; i-- {
+		if (runes[i] == '.' || runes[i] == '!' || runes[i] == '?') && unicode.IsSpace(runes[i+1]) {
+			return string(runes[:i+1])
+		}
+	}
+
+	// No sentence boundary found, cut by the last word
+	return utils.Truncate(string(runes), maxLength)
+}
@@ -58,3 +58,56 @@ func Test_aiJSONStringFixer(t *testing.T) {
 		})
 	}
 }
+
+func Test_truncateText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "Should return short text as is",
+			text:      "Fed holds rates steady.",
+			maxLength: 100,
+			want:      "Fed holds rates steady.",
+		},
+		{
+			name:      "Should not truncate without limit",
+			text:      "Fed holds rates steady. Stocks rally.",
+			maxLength: 0,
+			want:      "Fed holds rates steady. Stocks rally.",
+		},
+		{
+			name:      "Should cut by the last complete sentence",
+			text:      "Fed holds rates steady. Stocks rally! Bonds fall sharply after the decision.",
+			maxLength: 50,
+			want:      "Fed holds rates steady. Stocks rally!",
+		},
+		{
+			name:      "Should not cut inside numbers",
+			text:      "CPI rose 0.4% in March. Core inflation is still high.",
+			maxLength: 30,
+			want:      "CPI rose 0.4% in March.",
+		},
+		{
+			name:      "Should cut by the last word if there is no sentence boundary",
+			text:      "Fed holds rates steady, signals cuts later this year",
+			maxLength: 25,
+			want:      "Fed holds rates steady…",
+		},
+		{
+			name:      "Should count unicode characters",
+			text:      "Индекс вырос. Рынок падает",
+			maxLength: 20,
+			want:      "Индекс вырос.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateText(tt.text, tt.maxLength); got != tt.want {
+				t.Errorf("truncateText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,24 +3,120 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-co-op/gocron/v2"
 	"github.com/go-playground/validator/v10"
+	"github.com/robfig/cron/v3"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/alert"
+	"github.com/samgozman/fin-thread/internal/links"
+	"github.com/samgozman/fin-thread/jobs"
 	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/narrator"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Env is a structure that holds all the environment variables that are used in the app.
 type Env struct {
 	TelegramChannelID string `mapstructure:"TELEGRAM_CHANNEL_ID" validate:"required"`
-	TelegramBotToken  string `mapstructure:"TELEGRAM_BOT_TOKEN" validate:"required"`
-	OpenAiToken       string `mapstructure:"OPENAI_TOKEN" validate:"required"`
-	TogetherAIToken   string `mapstructure:"TOGETHER_AI_TOKEN" validate:"required"`
+	TelegramBotToken  string `mapstructure:"TELEGRAM_BOT_TOKEN" validate:"required_unless=Sandbox true"`
+	OpenAiToken       string `mapstructure:"OPENAI_TOKEN" validate:"required_unless=Sandbox true"`
+	TogetherAIToken   string `mapstructure:"TOGETHER_AI_TOKEN" validate:"required_unless=Sandbox true"`
 	GoogleGeminiToken string `mapstructure:"GOOGLE_GEMINI_TOKEN"`
 	PostgresDSN       string `mapstructure:"POSTGRES_DSN" validate:"required"`
-	SentryDSN         string `mapstructure:"SENTRY_DSN" validate:"required"`
-	StockSymbols      string `mapstructure:"STOCK_SYMBOLS" validate:"required"`
-	MarketJournalists string `mapstructure:"MARKET_JOURNALISTS" validate:"required,json"`
-	BroadJournalists  string `mapstructure:"BROAD_JOURNALISTS" validate:"required,json"`
+	SentryDSN         string `mapstructure:"SENTRY_DSN" validate:"required_unless=Sandbox true"`
+	SentryEnvironment string `mapstructure:"SENTRY_ENVIRONMENT"`
+	SentryTracesRate  string `mapstructure:"SENTRY_TRACES_SAMPLE_RATE" validate:"omitempty,number"`
+	SentryProfileRate string `mapstructure:"SENTRY_PROFILES_SAMPLE_RATE" validate:"omitempty,number"`
+	StockSymbols      string `mapstructure:"STOCK_SYMBOLS" validate:"required_unless=Sandbox true"`
+	MarketJournalists string `mapstructure:"MARKET_JOURNALISTS" validate:"required_unless=Sandbox true,omitempty,json"`
+	BroadJournalists  string `mapstructure:"BROAD_JOURNALISTS" validate:"required_unless=Sandbox true,omitempty,json"`
 	ServerName        string `mapstructure:"SERVER_NAME"`
 	ShouldPublish     bool   `mapstructure:"SHOULD_PUBLISH" validate:"boolean"`
+	AdminChatID       string `mapstructure:"TELEGRAM_ADMIN_CHAT_ID"`
+	WatchdogSilence   string `mapstructure:"WATCHDOG_SILENCE_PERIOD"`
+	WatchdogFilter    string `mapstructure:"WATCHDOG_FILTER_RATE"`
+	WatchdogSpike     string `mapstructure:"WATCHDOG_VOLUME_SPIKE"`
+	AlertLevel        string `mapstructure:"ALERT_LEVEL"`
+	AlertWebhookURL   string `mapstructure:"ALERT_WEBHOOK_URL" validate:"omitempty,url"`
+	AlertWindow       string `mapstructure:"ALERT_DEDUP_WINDOW"`
+	StartupRetries    string `mapstructure:"STARTUP_RETRIES" validate:"omitempty,number"`
+	StartupDelay      string `mapstructure:"STARTUP_RETRY_DELAY"`
+	StartupDegraded   bool   `mapstructure:"STARTUP_DEGRADED" validate:"boolean"`
+	ScheduleTolerance string `mapstructure:"SCHEDULE_TOLERANCE"`
+	ScheduleCatchUp   string `mapstructure:"SCHEDULE_CATCH_UP"`
+	Sandbox           bool   `mapstructure:"SANDBOX" validate:"boolean"`
+	SandboxFixtures   string `mapstructure:"SANDBOX_FIXTURES" validate:"required_if=Sandbox true"`
+	SandboxSpeed      string `mapstructure:"SANDBOX_SPEED"`
+	SandboxOutput     string `mapstructure:"SANDBOX_OUTPUT"`
+	ComposeMaxLength  string `mapstructure:"COMPOSE_MAX_LENGTH" validate:"omitempty,number"`
+	DescMaxLength     string `mapstructure:"DESCRIPTION_MAX_LENGTH" validate:"omitempty,number"`
+	PromptGlossary    string `mapstructure:"PROMPT_GLOSSARY" validate:"omitempty,json"`
+	PromptExamples    string `mapstructure:"PROMPT_EXAMPLES_FILE" validate:"omitempty,file"`
+	RecentTexts       string `mapstructure:"RECENT_TEXTS" validate:"omitempty,number"`
+	Scavengers        string `mapstructure:"SCAVENGERS"`
+	CacheRedisURL     string `mapstructure:"CACHE_REDIS_URL" validate:"omitempty,url"`
+	CalendarCountries string `mapstructure:"CALENDAR_COUNTRIES"`
+	CalendarPolls     string `mapstructure:"CALENDAR_POLLS" validate:"omitempty,number"`
+	CalendarEditPlan  bool   `mapstructure:"CALENDAR_EDIT_PLAN" validate:"boolean"`
+	Watchlist         string `mapstructure:"WATCHLIST"`
+	SectorChannels    string `mapstructure:"SECTOR_CHANNELS" validate:"omitempty,json"`
+	SummaryChannels   string `mapstructure:"SUMMARY_CHANNELS"`
+	MirrorChannelID   string `mapstructure:"MIRROR_CHANNEL_ID"`
+	MirrorLanguage    string `mapstructure:"MIRROR_LANGUAGE" validate:"required_with=MirrorChannelID"`
+	SlackWebhookURL   string `mapstructure:"SLACK_WEBHOOK_URL" validate:"omitempty,url"`
+	SlackChannel      string `mapstructure:"SLACK_CHANNEL" validate:"required_with=SlackWebhookURL"`
+	SummaryVoice      string `mapstructure:"SUMMARY_VOICE"`
+	WebAddr           string `mapstructure:"WEB_ADDR"`
+	WebBaseURL        string `mapstructure:"WEB_BASE_URL" validate:"required_with=WebAddr,omitempty,url"`
+	BroadMinMarketCap string `mapstructure:"BROAD_MIN_MARKET_CAP" validate:"omitempty,number"`
+	StockCountries    string `mapstructure:"STOCK_COUNTRIES"`
+	ShadowPrompt      string `mapstructure:"SHADOW_FILTER_PROMPT_FILE" validate:"omitempty,file"`
+	ShadowModel       string `mapstructure:"SHADOW_FILTER_MODEL"`
+	ComposeModels     string `mapstructure:"COMPOSE_MODELS"`
+	ComposeModel      string `mapstructure:"COMPOSE_MODEL_OVERRIDE"`
+	ModelRegistry     string `mapstructure:"MODEL_REGISTRY_FILE" validate:"omitempty,file"`
+	ETFHoldings       string `mapstructure:"ETF_HOLDINGS_FILE" validate:"omitempty,file"`
+	Schedules         string `mapstructure:"SCHEDULES" validate:"omitempty,json"`
+	JobTimeouts       string `mapstructure:"JOB_TIMEOUTS" validate:"omitempty,json"`
+	NewsButtons       string `mapstructure:"NEWS_BUTTONS" validate:"omitempty,json"`
+	StageAttempts     string `mapstructure:"STAGE_ATTEMPTS" validate:"omitempty,number"`
+	Tenants           string `mapstructure:"TENANTS" validate:"omitempty,json"`
+	ProviderTrust     string `mapstructure:"PROVIDER_TRUST" validate:"omitempty,json"`
+	TagRules          string `mapstructure:"TAG_RULES" validate:"omitempty,json"`
+	NewsIDStrategy    string `mapstructure:"NEWS_ID_STRATEGY"`
+	Theme             string `mapstructure:"THEME" validate:"omitempty,oneof=default plain"`
+	ThemeIcons        string `mapstructure:"THEME_ICONS" validate:"omitempty,json"`
+	EventTitles       string `mapstructure:"EVENT_TITLES" validate:"omitempty,json"`
+	EventTitlesAI     bool   `mapstructure:"EVENT_TITLES_AI" validate:"boolean"`
+	FXThreshold       string `mapstructure:"FX_THRESHOLD" validate:"omitempty,number"`
+	FXQuotes          bool   `mapstructure:"FX_QUOTES" validate:"boolean"`
+	SECUserAgent      string `mapstructure:"SEC_USER_AGENT"`
+	InsiderMinValue   string `mapstructure:"INSIDER_MIN_VALUE" validate:"omitempty,number"`
+	RatingChanges     string `mapstructure:"RATING_CHANGES" validate:"omitempty,json"`
+	OutboxMaxAge      string `mapstructure:"OUTBOX_MAX_AGE"`
+	PublishInterval   string `mapstructure:"PUBLISH_INTERVAL"`
+	RepublishMaxAge   string `mapstructure:"REPUBLISH_MAX_AGE"`
+	StoryGap          string `mapstructure:"STORY_GAP"`
+	VerifyPublish     bool   `mapstructure:"VERIFY_PUBLISH" validate:"boolean"`
+	LinkUTM           string `mapstructure:"LINK_UTM" validate:"omitempty,json"`
+	LinkSecret        string `mapstructure:"LINK_SECRET"`
+	LinkShortener     string `mapstructure:"LINK_SHORTENER" validate:"omitempty,url"`
+	AIScrub           string `mapstructure:"AI_SCRUB"`
+	VerifyNumbers     bool   `mapstructure:"VERIFY_NUMBERS" validate:"boolean"`
+	Compliance        string `mapstructure:"COMPLIANCE_PROFILES" validate:"omitempty,json"`
+	ParseModes        string `mapstructure:"PARSE_MODES" validate:"omitempty,json"`
 }
 
 type Config struct {
@@ -30,6 +126,65 @@ type Config struct {
 		marketJournalists []journalist.NewsProvider // Market news journalists
 		broadJournalists  []journalist.NewsProvider // Broad news journalists
 	}
+	composeMaxLength  int                             // Target max length of the composed text in characters
+	descMaxLength     int                             // Max length of the original news description in characters
+	glossary          *composer.Glossary              // Channel glossary for the Compose and Summarise prompts (optional)
+	examples          map[string]*composer.ExampleSet // Few-shot examples sets by the job name: "market" or "broad" (optional)
+	recentTexts       int                             // Texts published in the last day on the same topics passed to the composer (0 disables)
+	scavengers        []string                        // Names of the enabled scavenger sources (all if empty)
+	calendarCountries []ecal.EconomicCalendarCountry  // Countries included in the calendar posts (all if empty)
+	calendarPolls     int                             // Max number of the daily forecast polls for high-impact events (0 disables)
+	watchlist         []string                        // Tickers whose news bypass the stricter filters (optional)
+	sectorChannels    map[string]string               // Sector name -> Telegram channel ID for the sector news cross-posting (optional)
+	summaryChannels   []string                        // Telegram channel IDs where the before market open summary is published (news channel if empty)
+	broadMinMarketCap float64                         // Omit broad news whose tickers all have market cap (USD) below this value (0 disables)
+	stockCountries    []string                        // Countries of the domestic stocks, news with foreign stocks only are omitted or demoted (optional)
+	shadowFilter      []composer.Option               // Prompt and model of the shadow AI filter, which decisions are recorded but not enforced (disabled if empty)
+	scrubProviders    []string                        // AI providers that receive the news without emails, phones and link tracking (optional)
+	composeBandit     *composer.Bandit                // Chooses the Compose model between the configured ones (optional, gpt-4o-mini if nil)
+	modelRegistry     *composer.ModelRegistry         // Models (e.g. fine-tuned) routed for the composer tasks per channel (optional)
+	etfs              *stocks.ETFMap                  // ETFs with their largest holdings, separated from the stocks of the news
+	schedules         map[string]string               // Job name -> Go duration (interval jobs) or cron expression in UTC
+	jobTimeouts       map[string]time.Duration        // News job name (market, broad) -> timeout of its run
+	newsButtons       map[string][]jobs.Button        // News job name (market, broad) -> buttons under its posts (optional)
+	stageAttempts     uint                            // Attempts of the compose and publish stages of the news jobs on the transient errors
+	scheduleTolerance time.Duration                   // Allowed delay of the job run, later runs and missed runs are reported
+	catchUpJobs       []string                        // Jobs (by schedule name) whose missed run is executed once by the schedule monitor
+	tenants           map[string]string               // Telegram channel ID -> Postgres DSN of the additional channels with their own database (optional)
+	providerTrust     map[string]float64              // News provider name ("*" for unknown ones) -> trust weight that modulates filtering (optional)
+	ratingChanges     map[string]bool                 // Telegram channel ID ("*" for others) -> publish analyst rating changes or omit them (optional)
+	tagRules          []jobs.TagRule                  // Deterministic tagging rules applied to the composed news (optional)
+	newsIDStrategy    journalist.IDStrategy           // Strategy of the news ID (hash) generation used to find duplicated news
+	theme             *jobs.Theme                     // Icons of the calendar posts
+	eventTitles       map[string]string               // Source economic event title -> standard English name (optional)
+	fxThreshold       float64                         // Min deviation of the actual value from the forecast to append the FX pairs (0 disables)
+	insiderMinValue   float64                         // Insider filings with the trades value (USD) below this value are skipped
+	outboxMaxAge      time.Duration                   // News queued while Telegram is unreachable are dropped after this age (0 disables the queue)
+	publishInterval   time.Duration                   // Min interval between the Telegram requests of each chat publisher (0 disables the spacing)
+	republishMaxAge   time.Duration                   // Saved news that failed to publish are republished up to this age (0 disables)
+	storyGap          time.Duration                   // Developing stories without news for this period end (0 disables the stories)
+	linkUTM           map[string]map[string]string    // Telegram channel ID ("*" for others) -> UTM parameters of the ticker links (optional)
+	compliance        map[string]jobs.Compliance      // Telegram channel ID ("*" for others) -> compliance profile of the channel jurisdiction (optional)
+	parseModes        map[string]string               // Telegram chat ID ("*" for others) -> parse mode of its messages (Markdown by default)
+	sentry            struct {
+		environment        string  // Environment of the Sentry events (e.g. "production" or "sandbox")
+		release            string  // Release of the Sentry events (from the build info)
+		tracesSampleRate   float64 // Share of the sampled transactions (0..1)
+		profilesSampleRate float64 // Share of the profiled sampled transactions (0..1)
+	}
+	watchdog struct {
+		silencePeriod       time.Duration // Alert admin if no news were published for this period during market hours
+		filterRateThreshold float64       // Alert admin if the share of filtered news is above this threshold (0..1)
+		volumeSpike         float64       // Alert admin if the hourly provider volume is above this multiple of its average (0 disables)
+	}
+	startup struct {
+		attempts uint          // Attempts to start each component (Telegram, database, data sources)
+		delay    time.Duration // Delay between the start attempts
+	}
+	alert struct {
+		level  sentry.Level  // Minimal level of the errors sent to the admin chat and webhook (disabled if empty)
+		window time.Duration // Alerts of the same error are sent once per this window
+	}
 }
 
 // NewConfig creates a new Config object with the given Env and default values from DefaultConfig.
@@ -37,26 +192,532 @@ func NewConfig(env *Env) (*Config, error) {
 	c := DefaultConfig()
 	c.env = env
 
-	// unmarshal rss providers and validate them
-	marketJournalists, err := unmarshalRssProviders(env.MarketJournalists)
-	if err != nil {
-		return nil, fmt.Errorf("marketJournalists: %w", err)
+	if env.Sandbox {
+		if err := c.loadSandboxProviders(); err != nil {
+			return nil, fmt.Errorf("sandbox: %w", err)
+		}
+	} else {
+		// unmarshal rss providers and validate them
+		marketJournalists, err := unmarshalRssProviders(env.MarketJournalists)
+		if err != nil {
+			return nil, fmt.Errorf("marketJournalists: %w", err)
+		}
+
+		broadJournalists, err := unmarshalRssProviders(env.BroadJournalists)
+		if err != nil {
+			return nil, fmt.Errorf("broadJournalists: %w", err)
+		}
+
+		c.rssProviders.marketJournalists = marketJournalists
+		c.rssProviders.broadJournalists = broadJournalists
 	}
 
-	broadJournalists, err := unmarshalRssProviders(env.BroadJournalists)
-	if err != nil {
-		return nil, fmt.Errorf("broadJournalists: %w", err)
+	if env.ComposeMaxLength != "" {
+		l, err := strconv.Atoi(env.ComposeMaxLength)
+		if err != nil {
+			return nil, fmt.Errorf("compose max length: %w", err)
+		}
+		if l <= 0 || l > archivist.ComposedTextMaxLength {
+			return nil, fmt.Errorf("compose max length must be in range 1..%d", archivist.ComposedTextMaxLength)
+		}
+		c.composeMaxLength = l
+	}
+
+	if env.DescMaxLength != "" {
+		l, err := strconv.Atoi(env.DescMaxLength)
+		if err != nil {
+			return nil, fmt.Errorf("description max length: %w", err)
+		}
+		if l <= 0 || l > archivist.OriginalDescMaxLength {
+			return nil, fmt.Errorf("description max length must be in range 1..%d", archivist.OriginalDescMaxLength)
+		}
+		c.descMaxLength = l
+	}
+
+	if env.PromptGlossary != "" {
+		var g composer.Glossary
+		if err := json.Unmarshal([]byte(env.PromptGlossary), &g); err != nil {
+			return nil, fmt.Errorf("prompt glossary: %w", err)
+		}
+		c.glossary = &g
+	}
+
+	if env.RecentTexts != "" {
+		n, err := strconv.Atoi(env.RecentTexts)
+		if err != nil {
+			return nil, fmt.Errorf("recent texts: %w", err)
+		}
+		c.recentTexts = n
+	}
+
+	if env.PromptExamples != "" {
+		sets, err := composer.LoadExampleSets(env.PromptExamples)
+		if err != nil {
+			return nil, fmt.Errorf("prompt examples: %w", err)
+		}
+		c.examples = sets
+	}
+
+	if env.Scavengers != "" {
+		for _, name := range strings.Split(env.Scavengers, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				c.scavengers = append(c.scavengers, name)
+			}
+		}
+	}
+
+	if env.CalendarCountries != "" {
+		countries, err := ecal.ParseCountries(strings.Split(env.CalendarCountries, ","))
+		if err != nil {
+			return nil, fmt.Errorf("calendar countries: %w", err)
+		}
+		c.calendarCountries = countries
+	}
+
+	if env.CalendarPolls != "" {
+		n, err := strconv.Atoi(env.CalendarPolls)
+		if err != nil {
+			return nil, fmt.Errorf("calendar polls: %w", err)
+		}
+		c.calendarPolls = n
+	}
+
+	if env.FXThreshold != "" {
+		th, err := strconv.ParseFloat(env.FXThreshold, 64)
+		if err != nil {
+			return nil, fmt.Errorf("fx threshold: %w", err)
+		}
+		c.fxThreshold = th
+	}
+
+	if env.InsiderMinValue != "" {
+		v, err := strconv.ParseFloat(env.InsiderMinValue, 64)
+		if err != nil {
+			return nil, fmt.Errorf("insider min value: %w", err)
+		}
+		c.insiderMinValue = v
+	}
+
+	if env.OutboxMaxAge != "" {
+		d, err := time.ParseDuration(env.OutboxMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("outbox max age: %w", err)
+		}
+		c.outboxMaxAge = d
+	}
+
+	if env.PublishInterval != "" {
+		d, err := time.ParseDuration(env.PublishInterval)
+		if err != nil {
+			return nil, fmt.Errorf("publish interval: %w", err)
+		}
+		c.publishInterval = d
+	}
+
+	if env.RepublishMaxAge != "" {
+		d, err := time.ParseDuration(env.RepublishMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("republish max age: %w", err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("republish max age must not be negative, got %s", env.RepublishMaxAge)
+		}
+		c.republishMaxAge = d
+	}
+
+	if env.StoryGap != "" {
+		d, err := time.ParseDuration(env.StoryGap)
+		if err != nil {
+			return nil, fmt.Errorf("story gap: %w", err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("story gap must not be negative, got %s", env.StoryGap)
+		}
+		c.storyGap = d
+	}
+
+	if env.Watchlist != "" {
+		for _, t := range strings.Split(env.Watchlist, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				c.watchlist = append(c.watchlist, strings.ToUpper(t))
+			}
+		}
+	}
+
+	if env.SummaryChannels != "" {
+		for _, ch := range strings.Split(env.SummaryChannels, ",") {
+			if ch = strings.TrimSpace(ch); ch != "" {
+				c.summaryChannels = append(c.summaryChannels, ch)
+			}
+		}
+	}
+
+	if env.SummaryVoice != "" {
+		if _, err := narrator.ParseVoice(env.SummaryVoice); err != nil {
+			return nil, fmt.Errorf("summary voice: %w", err)
+		}
+	}
+
+	if env.SectorChannels != "" {
+		if err := json.Unmarshal([]byte(env.SectorChannels), &c.sectorChannels); err != nil {
+			return nil, fmt.Errorf("sector channels: %w", err)
+		}
+	}
+
+	if env.BroadMinMarketCap != "" {
+		m, err := strconv.ParseFloat(env.BroadMinMarketCap, 64)
+		if err != nil {
+			return nil, fmt.Errorf("broad min market cap: %w", err)
+		}
+		c.broadMinMarketCap = m
+	}
+
+	if env.StockCountries != "" {
+		for _, country := range strings.Split(env.StockCountries, ",") {
+			if country = strings.TrimSpace(country); country != "" {
+				c.stockCountries = append(c.stockCountries, country)
+			}
+		}
+	}
+
+	if env.ShadowPrompt != "" {
+		prompt, err := os.ReadFile(env.ShadowPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("shadow filter prompt: %w", err)
+		}
+		c.shadowFilter = append(c.shadowFilter, composer.UseFilterPrompt(string(prompt)))
+	}
+
+	if env.ShadowModel != "" {
+		c.shadowFilter = append(c.shadowFilter, composer.UseFilterModel(env.ShadowModel))
+	}
+
+	if env.ComposeModels != "" {
+		arms, err := parseArms(env.ComposeModels)
+		if err != nil {
+			return nil, fmt.Errorf("compose models: %w", err)
+		}
+		for _, a := range arms {
+			if a.Provider == composer.ProviderGemini && env.GoogleGeminiToken == "" {
+				return nil, fmt.Errorf("compose models: %s needs GOOGLE_GEMINI_TOKEN", a.Model)
+			}
+		}
+		bandit, err := composer.NewBandit(arms, 0.1)
+		if err != nil {
+			return nil, fmt.Errorf("compose models: %w", err)
+		}
+		if err := bandit.Override(env.ComposeModel); err != nil {
+			return nil, fmt.Errorf("compose model override: %w", err)
+		}
+		c.composeBandit = bandit
+	}
+
+	if env.Schedules != "" {
+		var schedules map[string]string
+		if err := json.Unmarshal([]byte(env.Schedules), &schedules); err != nil {
+			return nil, fmt.Errorf("schedules: %w", err)
+		}
+		for job, schedule := range schedules {
+			if _, ok := c.schedules[job]; !ok {
+				return nil, fmt.Errorf("schedules: unknown job %q", job)
+			}
+			if _, err := parseSchedule(schedule); err != nil {
+				return nil, fmt.Errorf("schedules: job %q: %w", job, err)
+			}
+			c.schedules[job] = schedule
+		}
+	}
+
+	if env.JobTimeouts != "" {
+		var timeouts map[string]string
+		if err := json.Unmarshal([]byte(env.JobTimeouts), &timeouts); err != nil {
+			return nil, fmt.Errorf("job timeouts: %w", err)
+		}
+		for job, timeout := range timeouts {
+			if _, ok := c.jobTimeouts[job]; !ok {
+				return nil, fmt.Errorf("job timeouts: unknown job %q, expected market or broad", job)
+			}
+			d, err := time.ParseDuration(timeout)
+			if err != nil {
+				return nil, fmt.Errorf("job timeouts: job %q: %w", job, err)
+			}
+			c.jobTimeouts[job] = d
+		}
+	}
+
+	if env.NewsButtons != "" {
+		var buttons map[string][]jobs.Button
+		if err := json.Unmarshal([]byte(env.NewsButtons), &buttons); err != nil {
+			return nil, fmt.Errorf("news buttons: %w", err)
+		}
+		for job, kinds := range buttons {
+			if job != "market" && job != "broad" {
+				return nil, fmt.Errorf("news buttons: unknown job %q, expected market or broad", job)
+			}
+			for _, kind := range kinds {
+				if !slices.Contains(jobs.Buttons, kind) {
+					return nil, fmt.Errorf("news buttons: job %q: unknown button %q", job, kind)
+				}
+			}
+		}
+		c.newsButtons = buttons
+	}
+
+	if env.StageAttempts != "" {
+		n, err := strconv.ParseUint(env.StageAttempts, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("stage attempts: %w", err)
+		}
+		c.stageAttempts = uint(n)
+	}
+
+	if env.ScheduleTolerance != "" {
+		d, err := time.ParseDuration(env.ScheduleTolerance)
+		if err != nil {
+			return nil, fmt.Errorf("schedule tolerance: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("schedule tolerance must be positive, got %s", env.ScheduleTolerance)
+		}
+		c.scheduleTolerance = d
+	}
+
+	if env.ScheduleCatchUp != "" {
+		c.catchUpJobs = nil
+		for _, job := range strings.Split(env.ScheduleCatchUp, ",") {
+			if job = strings.TrimSpace(job); job == "" {
+				continue
+			}
+			if _, ok := c.schedules[job]; !ok {
+				return nil, fmt.Errorf("schedule catch up: unknown job %q", job)
+			}
+			c.catchUpJobs = append(c.catchUpJobs, job)
+		}
+	}
+
+	if env.ProviderTrust != "" {
+		if err := json.Unmarshal([]byte(env.ProviderTrust), &c.providerTrust); err != nil {
+			return nil, fmt.Errorf("provider trust: %w", err)
+		}
+		for name, w := range c.providerTrust {
+			if w < 0 {
+				return nil, fmt.Errorf("provider trust: %s weight must not be negative, got %v", name, w)
+			}
+		}
+	}
+
+	if env.AIScrub != "" {
+		for _, name := range strings.Split(env.AIScrub, ",") {
+			provider, ok := composer.ParseProvider(name)
+			if !ok {
+				return nil, fmt.Errorf("AI scrub: unknown provider %q, expected one of %v", name, composer.Providers)
+			}
+			c.scrubProviders = append(c.scrubProviders, provider)
+		}
+	}
+
+	if env.LinkUTM != "" {
+		if err := json.Unmarshal([]byte(env.LinkUTM), &c.linkUTM); err != nil {
+			return nil, fmt.Errorf("link UTM: %w", err)
+		}
+	}
+
+	if env.LinkSecret != "" && env.WebAddr == "" {
+		return nil, fmt.Errorf("link secret: click-tracking redirects require WEB_ADDR")
+	}
+
+	if env.LinkShortener != "" && !strings.Contains(env.LinkShortener, "{url}") {
+		return nil, fmt.Errorf("link shortener: URL must contain the {url} placeholder")
+	}
+
+	if env.Compliance != "" {
+		if err := json.Unmarshal([]byte(env.Compliance), &c.compliance); err != nil {
+			return nil, fmt.Errorf("compliance profiles: %w", err)
+		}
+		for channelID, p := range c.compliance {
+			if err := p.Validate(); err != nil {
+				return nil, fmt.Errorf("compliance profiles: channel %s: %w", channelID, err)
+			}
+		}
+	}
+
+	if env.ParseModes != "" {
+		if err := json.Unmarshal([]byte(env.ParseModes), &c.parseModes); err != nil {
+			return nil, fmt.Errorf("parse modes: %w", err)
+		}
+		for chatID, mode := range c.parseModes {
+			if !slices.Contains(publisher.ParseModes, mode) {
+				return nil, fmt.Errorf("parse modes: chat %s: unknown mode %q, use one of: %s", chatID, mode, strings.Join(publisher.ParseModes, ", "))
+			}
+		}
+	}
+
+	if env.RatingChanges != "" {
+		if err := json.Unmarshal([]byte(env.RatingChanges), &c.ratingChanges); err != nil {
+			return nil, fmt.Errorf("rating changes: %w", err)
+		}
+	}
+
+	if env.TagRules != "" {
+		if err := json.Unmarshal([]byte(env.TagRules), &c.tagRules); err != nil {
+			return nil, fmt.Errorf("tag rules: %w", err)
+		}
+		for _, r := range c.tagRules {
+			if err := r.Validate(); err != nil {
+				return nil, fmt.Errorf("tag rules: %w", err)
+			}
+		}
+	}
+
+	if env.NewsIDStrategy != "" {
+		s := journalist.IDStrategy(strings.ToLower(strings.TrimSpace(env.NewsIDStrategy)))
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+		c.newsIDStrategy = s
+	}
+
+	if env.Theme == "plain" {
+		c.theme = jobs.PlainTheme()
+	}
+	if env.ThemeIcons != "" {
+		// Icons missing in the JSON keep the values of the base theme
+		if err := json.Unmarshal([]byte(env.ThemeIcons), c.theme); err != nil {
+			return nil, fmt.Errorf("theme icons: %w", err)
+		}
+	}
+
+	if env.EventTitles != "" {
+		if err := json.Unmarshal([]byte(env.EventTitles), &c.eventTitles); err != nil {
+			return nil, fmt.Errorf("event titles: %w", err)
+		}
+	}
+
+	if env.Tenants != "" {
+		if err := json.Unmarshal([]byte(env.Tenants), &c.tenants); err != nil {
+			return nil, fmt.Errorf("tenants: %w", err)
+		}
+		// Tenants share the news pipeline, so the same database would make them skip each other's news as duplicates
+		dsns := map[string]string{env.PostgresDSN: env.TelegramChannelID}
+		for chatID, dsn := range c.tenants {
+			if chatID == env.TelegramChannelID {
+				return nil, fmt.Errorf("tenants: channel %s is the main channel", chatID)
+			}
+			if dsn == "" {
+				return nil, fmt.Errorf("tenants: channel %s has no DSN", chatID)
+			}
+			if other, ok := dsns[dsn]; ok {
+				return nil, fmt.Errorf("tenants: channel %s has the same DSN as %s", chatID, other)
+			}
+			dsns[dsn] = chatID
+		}
 	}
 
-	c.rssProviders.marketJournalists = marketJournalists
-	c.rssProviders.broadJournalists = broadJournalists
+	if env.ModelRegistry != "" {
+		registry, err := composer.LoadModelRegistry(env.ModelRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("model registry: %w", err)
+		}
+		for chatID := range registry.Channels {
+			if _, ok := c.tenants[chatID]; !ok && chatID != env.TelegramChannelID {
+				return nil, fmt.Errorf("model registry: channel %s is neither the main channel nor a tenant", chatID)
+			}
+		}
+		c.modelRegistry = registry
+	}
+
+	if env.ETFHoldings != "" {
+		etfs, err := stocks.LoadETFs(env.ETFHoldings)
+		if err != nil {
+			return nil, fmt.Errorf("etf holdings: %w", err)
+		}
+		c.etfs = etfs
+	}
+
+	if env.SentryEnvironment != "" {
+		c.sentry.environment = env.SentryEnvironment
+	} else if env.Sandbox {
+		c.sentry.environment = "sandbox"
+	}
+
+	if env.SentryTracesRate != "" {
+		r, err := parseSampleRate(env.SentryTracesRate)
+		if err != nil {
+			return nil, fmt.Errorf("sentry traces sample rate: %w", err)
+		}
+		c.sentry.tracesSampleRate = r
+	}
+
+	if env.SentryProfileRate != "" {
+		r, err := parseSampleRate(env.SentryProfileRate)
+		if err != nil {
+			return nil, fmt.Errorf("sentry profiles sample rate: %w", err)
+		}
+		c.sentry.profilesSampleRate = r
+	}
+
+	if env.WatchdogSilence != "" {
+		d, err := time.ParseDuration(env.WatchdogSilence)
+		if err != nil {
+			return nil, fmt.Errorf("watchdog silence period: %w", err)
+		}
+		c.watchdog.silencePeriod = d
+	}
+
+	if env.WatchdogFilter != "" {
+		r, err := strconv.ParseFloat(env.WatchdogFilter, 64)
+		if err != nil {
+			return nil, fmt.Errorf("watchdog filter rate: %w", err)
+		}
+		c.watchdog.filterRateThreshold = r
+	}
+
+	if env.WatchdogSpike != "" {
+		r, err := strconv.ParseFloat(env.WatchdogSpike, 64)
+		if err != nil {
+			return nil, fmt.Errorf("watchdog volume spike: %w", err)
+		}
+		c.watchdog.volumeSpike = r
+	}
+
+	if env.AlertLevel != "" {
+		l, err := alert.ParseLevel(env.AlertLevel)
+		if err != nil {
+			return nil, fmt.Errorf("alert level: %w", err)
+		}
+		c.alert.level = l
+	}
+
+	if env.StartupRetries != "" {
+		n, err := strconv.ParseUint(env.StartupRetries, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("startup retries: %w", err)
+		}
+		c.startup.attempts = uint(n) + 1
+	}
+
+	if env.StartupDelay != "" {
+		d, err := time.ParseDuration(env.StartupDelay)
+		if err != nil {
+			return nil, fmt.Errorf("startup retry delay: %w", err)
+		}
+		c.startup.delay = d
+	}
+
+	if env.AlertWindow != "" {
+		d, err := time.ParseDuration(env.AlertWindow)
+		if err != nil {
+			return nil, fmt.Errorf("alert deduplication window: %w", err)
+		}
+		c.alert.window = d
+	}
 
 	return c, nil
 }
 
 // DefaultConfig creates a new Config object with default values.
 func DefaultConfig() *Config {
-	return &Config{
+	c := &Config{
 		env: &Env{},
 		suspiciousKeywords: []string{
 			"sign up",
@@ -130,6 +791,219 @@ func DefaultConfig() *Config {
 			"woke",
 		},
 	}
+	c.composeMaxLength = 512
+	c.descMaxLength = archivist.OriginalDescMaxLength
+	c.newsIDStrategy = journalist.IDByContent
+	c.theme = jobs.DefaultTheme()
+	c.broadMinMarketCap = 300_000_000 // micro caps
+	c.calendarPolls = 2
+	c.insiderMinValue = 100_000
+	c.outboxMaxAge = 30 * time.Minute
+	c.publishInterval = time.Second // Telegram allows about one message per second in the same chat
+	c.republishMaxAge = 30 * time.Minute
+	c.storyGap = 72 * time.Hour
+	c.etfs = stocks.DefaultETFs()
+	c.schedules = map[string]string{
+		"market":           "60s",
+		"broad":            "4m",
+		"calendar":         "0 4 * * 1-5", // every weekday at 4:00 UTC
+		"calendar-updates": "90s",
+		"week-ahead":       "0 18 * * 0",         // every Sunday at 18:00 UTC
+		"summary":          "0 14 * * 1-5",       // every weekday at 14:00 UTC (market opens at 14:30 UTC)
+		"recap":            "15 21 * * 1-5",      // every weekday at 21:15 UTC (market closes at 21:00 UTC)
+		"follow-up":        "*/30 14-21 * * 1-5", // every 30 minutes during the US market hours
+		"listings":         "0 12 * * 6",         // every Saturday at 12:00 UTC
+		"insider":          "0 14 * * 6",         // every Saturday at 14:00 UTC
+		"outbox":           "1m",
+		"recovery-market":  "3m",
+		"recovery-broad":   "3m",
+		"engagement":       "1h",
+		"watchdog":         "10m",
+		"stats":            "0 22 * * 1-5", // every weekday at 22:00 UTC (after the market close)
+		"schedule-monitor": "1m",
+	}
+	c.jobTimeouts = map[string]time.Duration{
+		"market": 45 * time.Second,
+		"broad":  90 * time.Second, // selects and composes with two models
+	}
+	c.stageAttempts = 3
+	c.recentTexts = 5
+	c.scheduleTolerance = 2 * time.Minute
+	c.catchUpJobs = []string{"calendar"}
+	c.watchdog.silencePeriod = 2 * time.Hour
+	c.watchdog.filterRateThreshold = 0.9
+	c.watchdog.volumeSpike = 4
+	c.alert.window = time.Hour
+	c.startup.attempts = 3
+	c.startup.delay = 10 * time.Second
+	c.sentry.environment = "production"
+	c.sentry.release = buildRelease()
+	c.sentry.tracesSampleRate = 1.0   // There are not many transactions, so we can afford to send all of them
+	c.sentry.profilesSampleRate = 1.0 // Same here
+
+	return c
+}
+
+// sentryOptions returns the Sentry client options from the config.
+func (c *Config) sentryOptions() sentry.ClientOptions {
+	return sentry.ClientOptions{
+		Dsn:                c.env.SentryDSN,
+		EnableTracing:      true,
+		TracesSampleRate:   c.sentry.tracesSampleRate,
+		ProfilesSampleRate: c.sentry.profilesSampleRate,
+		ServerName:         c.env.ServerName,
+		Environment:        c.sentry.environment,
+		Release:            c.sentry.release,
+	}
+}
+
+// linkDecorator returns the decorator of the ticker links of the channel: UTM parameters of the channel
+// ("*" value by default), click-tracking redirect with LINK_SECRET and the shortener.
+// Returns nil if links are not configured, so they get the default UTM parameters.
+func (c *Config) linkDecorator(channelID string) *links.Decorator {
+	if c.linkUTM == nil && c.env.LinkSecret == "" && c.env.LinkShortener == "" {
+		return nil
+	}
+
+	d := links.Default()
+	utm, ok := c.linkUTM[channelID]
+	if !ok {
+		utm, ok = c.linkUTM["*"]
+	}
+	if ok {
+		d.UTM = make(url.Values, len(utm))
+		for k, v := range utm {
+			d.UTM.Set(k, v)
+		}
+	}
+
+	if c.env.LinkSecret != "" {
+		d.RedirectURL = c.env.WebBaseURL
+		d.Secret = []byte(c.env.LinkSecret)
+	}
+
+	if c.env.LinkShortener != "" {
+		d.Shortener = links.NewHTTPShortener(c.env.LinkShortener)
+	}
+
+	return d
+}
+
+// includeRatingChanges returns true if the analyst rating changes are published to the channel ("*" value by default).
+// Returns ok false if rating changes are not configured for the channel, so they are handled as regular news.
+func (c *Config) includeRatingChanges(channelID string) (include, ok bool) {
+	if include, ok = c.ratingChanges[channelID]; ok {
+		return include, true
+	}
+	include, ok = c.ratingChanges["*"]
+	return include, ok
+}
+
+// complianceProfile returns the compliance profile of the channel ("*" value by default).
+// Returns ok false if no profile is configured for the channel.
+func (c *Config) complianceProfile(channelID string) (p jobs.Compliance, ok bool) {
+	if p, ok = c.compliance[channelID]; ok {
+		return p, true
+	}
+	p, ok = c.compliance["*"]
+	return p, ok
+}
+
+// disclaimer returns the disclaimer footer of the compliance profile of the chat messages.
+// Returns nil if the profile has no disclaimer or the chat is the admin one.
+func (c *Config) disclaimer(chatID string) []publisher.Segment {
+	p, ok := c.complianceProfile(chatID)
+	if !ok || chatID == c.env.AdminChatID {
+		return nil
+	}
+	return p.Footer()
+}
+
+// parseMode returns the Telegram parse mode of the chat messages ("*" value by default, Markdown if not configured).
+func (c *Config) parseMode(chatID string) string {
+	if mode, ok := c.parseModes[chatID]; ok {
+		return mode
+	}
+	if mode, ok := c.parseModes["*"]; ok {
+		return mode
+	}
+	return publisher.ModeMarkdown
+}
+
+// schedule returns the scheduler job definition of the job by its name.
+// Schedules are validated in NewConfig, so it panics only on the unknown job name.
+func (c *Config) schedule(job string) gocron.JobDefinition {
+	d, err := parseSchedule(c.schedules[job])
+	if err != nil {
+		panic(fmt.Errorf("job %q: %w", job, err))
+	}
+
+	return d
+}
+
+// parseSchedule parses the job schedule: Go duration (e.g. "90s") for the interval jobs
+// or standard cron expression (e.g. "0 14 * * 1-5").
+func parseSchedule(s string) (gocron.JobDefinition, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("interval must be positive, got %s", s)
+		}
+		return gocron.DurationJob(d), nil
+	}
+
+	if _, err := cron.ParseStandard(s); err != nil {
+		return nil, fmt.Errorf("schedule %q is neither duration nor cron expression: %w", s, err)
+	}
+
+	return gocron.CronJob(s, false), nil
+}
+
+// parseSampleRate parses the Sentry sample rate, which must be in range 0..1.
+func parseSampleRate(s string) (float64, error) {
+	r, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if r < 0 || r > 1 {
+		return 0, fmt.Errorf("sample rate must be in range 0..1, got %v", r)
+	}
+
+	return r, nil
+}
+
+// buildRelease returns the release name from the build info: module version for the tagged builds
+// or VCS revision for the local ones (empty if the build info is not available).
+func buildRelease() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return "fin-thread@" + v
+	}
+
+	var revision string
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if revision == "" {
+		return ""
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+
+	return "fin-thread@" + revision
 }
 
 type rssProvider struct {
@@ -158,3 +1032,77 @@ func unmarshalRssProviders(str string) ([]journalist.NewsProvider, error) {
 
 	return result, nil
 }
+
+// loadSandboxProviders replaces RSS providers with journalist.FixtureProvider for each recorded feed
+// found in the `market` and `broad` subdirectories of Env.SandboxFixtures.
+func (c *Config) loadSandboxProviders() error {
+	speed := 60.0
+	if c.env.SandboxSpeed != "" {
+		s, err := strconv.ParseFloat(c.env.SandboxSpeed, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing speed: %w", err)
+		}
+		speed = s
+	}
+
+	load := func(dir string) ([]journalist.NewsProvider, error) {
+		files, err := filepath.Glob(filepath.Join(c.env.SandboxFixtures, dir, "*.xml"))
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s fixtures: %w", dir, err)
+		}
+
+		providers := make([]journalist.NewsProvider, 0, len(files))
+		for _, f := range files {
+			name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+			providers = append(providers, journalist.NewFixtureProvider(name, f, speed))
+		}
+
+		return providers, nil
+	}
+
+	market, err := load("market")
+	if err != nil {
+		return err
+	}
+	broad, err := load("broad")
+	if err != nil {
+		return err
+	}
+
+	c.rssProviders.marketJournalists = market
+	c.rssProviders.broadJournalists = broad
+
+	return nil
+}
+
+// parseArms parses comma separated list of the models with relative costs, e.g. "gpt-4o-mini:1,gpt-4o:15".
+// The models of the other providers (see composer.Providers) are prefixed with the provider name,
+// e.g. "GoogleGemini/gemini-pro:2" or "TogetherAI/mistralai/Mixtral-8x7B-Instruct-v0.1:1", OpenAI is the default.
+func parseArms(s string) ([]composer.Arm, error) {
+	var arms []composer.Arm
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		i := strings.LastIndex(item, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("model %q has no cost, use model:cost format", item)
+		}
+		model, cost := strings.TrimSpace(item[:i]), item[i+1:]
+		c, err := strconv.ParseFloat(strings.TrimSpace(cost), 64)
+		if err != nil {
+			return nil, fmt.Errorf("model %q cost: %w", model, err)
+		}
+
+		provider := composer.ProviderOpenAI
+		if name, rest, ok := strings.Cut(model, "/"); ok {
+			if p, ok := composer.ParseProvider(name); ok {
+				provider, model = p, strings.TrimSpace(rest)
+			}
+		}
+		arms = append(arms, composer.Arm{Provider: provider, Model: model, Cost: c})
+	}
+
+	return arms, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// defaultConfigFile is the embedded configuration with sane defaults (e.g. journalists RSS providers),
+// so the minimal deployment only needs the channel, tokens and database DSN.
+//
+//go:embed defaults.yaml
+var defaultConfigFile []byte
+
+// envLookup resolves the environment variables from the sources in order of precedence:
+// process environment, SECRETS_FILE, CONFIG_FILE and the embedded defaults.
+type envLookup struct {
+	sources []map[string]string
+}
+
+// newEnvLookup creates a new envLookup with the optional config (YAML) and secrets (KEY=VALUE lines) files.
+func newEnvLookup(configFile, secretsFile string) (*envLookup, error) {
+	l := &envLookup{}
+
+	if secretsFile != "" {
+		data, err := os.ReadFile(secretsFile)
+		if err != nil {
+			return nil, fmt.Errorf("secrets file: %w", err)
+		}
+		values, err := parseSecrets(data)
+		if err != nil {
+			return nil, fmt.Errorf("secrets file: %w", err)
+		}
+		l.sources = append(l.sources, values)
+	}
+
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		values, err := parseConfigFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		l.sources = append(l.sources, values)
+	}
+
+	values, err := parseConfigFile(defaultConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("default config: %w", err)
+	}
+	l.sources = append(l.sources, values)
+
+	return l, nil
+}
+
+// Get returns the value of the environment variable from the first source that has it (empty string if none).
+func (l *envLookup) Get(key string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	for _, s := range l.sources {
+		if v, ok := s[key]; ok {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// parseConfigFile parses the YAML config where keys are the environment variable names.
+// Scalars are used as is, lists and maps (e.g. MARKET_JOURNALISTS or SCHEDULES) are converted to JSON strings.
+func parseConfigFile(data []byte) (map[string]string, error) {
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing yaml: %w", err)
+	}
+
+	values := make(map[string]string, len(doc))
+	for key, node := range doc {
+		if node.Kind == yaml.ScalarNode {
+			values[key] = node.Value
+			continue
+		}
+
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, fmt.Errorf("error decoding %s: %w", key, err)
+		}
+		j, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("error converting %s to json: %w", key, err)
+		}
+		values[key] = string(j)
+	}
+
+	return values, nil
+}
+
+// parseSecrets parses the secrets file in the .env format: KEY=VALUE lines, empty lines and # comments are skipped.
+func parseSecrets(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading secrets: %w", err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger"
+	"io"
+	"time"
+)
+
+// doctorCheck is a single diagnostic check of the `fin-thread doctor` command.
+type doctorCheck struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// runDoctor verifies each configured dependency and prints a pass/fail report to w.
+// Returns false if any of the checks failed.
+func runDoctor(cnf *Config, w io.Writer) bool {
+	checks := []doctorCheck{
+		{
+			name: "Postgres connect & migrate",
+			fn: func(_ context.Context) error {
+				_, err := archivist.NewArchivist(cnf.env.PostgresDSN)
+				return err
+			},
+		},
+		{
+			name: fmt.Sprintf("Telegram channel %s", cnf.env.TelegramChannelID),
+			fn: func(_ context.Context) error {
+				return checkTelegram(cnf.env.TelegramChannelID, cnf.env.TelegramBotToken)
+			},
+		},
+	}
+
+	if cnf.env.AdminChatID != "" {
+		checks = append(checks, doctorCheck{
+			name: fmt.Sprintf("Telegram admin chat %s", cnf.env.AdminChatID),
+			fn: func(_ context.Context) error {
+				return checkTelegram(cnf.env.AdminChatID, cnf.env.TelegramBotToken)
+			},
+		})
+	}
+
+	for _, chatID := range cnf.summaryChannels {
+		checks = append(checks, doctorCheck{
+			name: fmt.Sprintf("Telegram summary channel %s", chatID),
+			fn: func(_ context.Context) error {
+				return checkTelegram(chatID, cnf.env.TelegramBotToken)
+			},
+		})
+	}
+
+	for chatID, dsn := range cnf.tenants {
+		checks = append(checks,
+			doctorCheck{
+				name: fmt.Sprintf("Postgres connect & migrate for tenant %s", chatID),
+				fn: func(_ context.Context) error {
+					_, err := archivist.NewArchivist(dsn)
+					return err
+				},
+			},
+			doctorCheck{
+				name: fmt.Sprintf("Telegram tenant channel %s", chatID),
+				fn: func(_ context.Context) error {
+					return checkTelegram(chatID, cnf.env.TelegramBotToken)
+				},
+			},
+		)
+	}
+
+	// One cheap completion per AI provider
+	c := composer.NewComposer(cnf.env.OpenAiToken, cnf.env.TogetherAIToken, cnf.env.GoogleGeminiToken)
+	var pings map[string]error
+	providers := []string{"OpenAI", "TogetherAI"}
+	if cnf.env.GoogleGeminiToken != "" {
+		providers = append(providers, "GoogleGemini")
+	}
+	for _, p := range providers {
+		checks = append(checks, doctorCheck{
+			name: fmt.Sprintf("AI provider %s", p),
+			fn: func(ctx context.Context) error {
+				if pings == nil {
+					pings = c.Ping(ctx)
+				}
+				return pings[p]
+			},
+		})
+	}
+
+	// Fetch of each RSS provider
+	providersList := append(cnf.rssProviders.marketJournalists, cnf.rssProviders.broadJournalists...) //nolint:gocritic
+	for i, p := range providersList {
+		name := fmt.Sprintf("#%d", i)
+		if rss, ok := p.(*journalist.RssProvider); ok {
+			name = rss.Name
+		}
+		checks = append(checks, doctorCheck{
+			name: fmt.Sprintf("RSS provider %s", name),
+			fn: func(ctx context.Context) error {
+				_, err := p.Fetch(ctx, time.Now().Add(-24*time.Hour))
+				return err
+			},
+		})
+	}
+
+	// Health check of each enabled scavenger source
+	scv, err := scavenger.NewScavenger(cnf.scavengers...)
+	if err != nil {
+		checks = append(checks, doctorCheck{
+			name: "Scavenger sources",
+			fn: func(_ context.Context) error {
+				return err
+			},
+		})
+	} else {
+		for _, source := range scv.Sources() {
+			checks = append(checks, doctorCheck{
+				name: fmt.Sprintf("Scavenger %s", source.Name()),
+				fn:   source.HealthCheck,
+			})
+		}
+	}
+
+	failed := 0
+	for _, check := range checks {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		err := check.fn(ctx)
+		cancel()
+
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", check.name, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "[PASS] %s\n", check.name)
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%d checks, %d passed, %d failed\n", len(checks), len(checks)-failed, failed)
+
+	return failed == 0
+}
+
+// checkTelegram verifies that the bot token is valid and the bot can post to the given chat.
+func checkTelegram(chatID, token string) error {
+	p, err := publisher.NewTelegramPublisher(chatID, token, true)
+	if err != nil {
+		return err
+	}
+
+	return p.CheckPermissions()
+}
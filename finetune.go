@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"io"
+	"os"
+	"time"
+)
+
+// runExportFineTune writes the training data of the published news to the JSONL file in the OpenAI chat fine-tuning
+// format (see composer.Composer.FineTuneExample), so a cheaper model can be fine-tuned on the channel style.
+// Only the news which links were clicked at least -min-clicks times are exported (clicks of the tenants are
+// recorded in the main database too). Prints the report to w, the file isn't created if there is nothing to export.
+// Returns false if the arguments are invalid or the export failed.
+//
+// Arguments:
+//   - -out: path of the JSONL file (stdout by default);
+//   - -since: period of the published news, e.g. 720h (30 days by default);
+//   - -min-clicks: minimal number of the link clicks of the news (1 by default, 0 to export all published news);
+//   - -channel: ID of the main channel or a tenant (the main channel by default).
+func runExportFineTune(cnf *Config, args []string, w io.Writer) bool {
+	fs := flag.NewFlagSet("export-finetune", flag.ContinueOnError)
+	fs.SetOutput(w)
+	out := fs.String("out", "", "path of the JSONL file (stdout if empty)")
+	since := fs.Duration("since", 30*24*time.Hour, "period of the published news")
+	minClicks := fs.Int("min-clicks", 1, "minimal number of the link clicks of the news")
+	channel := fs.String("channel", cnf.env.TelegramChannelID, "ID of the main channel or a tenant")
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+
+	dsn := cnf.env.PostgresDSN
+	if *channel != cnf.env.TelegramChannelID {
+		var ok bool
+		if dsn, ok = cnf.tenants[*channel]; !ok {
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: unknown channel\n", *channel)
+			return false
+		}
+	}
+
+	mainArchivist, err := archivist.NewArchivist(cnf.env.PostgresDSN)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", *channel, err)
+		return false
+	}
+	a := mainArchivist
+	if dsn != cnf.env.PostgresDSN {
+		if a, err = archivist.NewArchivist(dsn); err != nil {
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", *channel, err)
+			return false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	from := time.Now().Add(-*since)
+
+	var clicked []uuid.UUID
+	if *minClicks > 0 {
+		clicked, err = mainArchivist.Entities.Clicks.FindClickedNews(ctx, *channel, from, *minClicks)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", *channel, err)
+			return false
+		}
+		if len(clicked) == 0 {
+			_, _ = fmt.Fprintf(w, "[DONE] %s: no clicks recorded, nothing to export\n", *channel)
+			return true
+		}
+	}
+
+	news, err := a.Entities.News.FindEngaging(ctx, from, clicked)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", *channel, err)
+		return false
+	}
+	if len(news) == 0 {
+		_, _ = fmt.Fprintf(w, "[DONE] %s: no published news, nothing to export\n", *channel)
+		return true
+	}
+
+	dst := w
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", *channel, err)
+			return false
+		}
+		defer f.Close()
+		dst = f
+	}
+
+	c := composer.NewComposer(cnf.env.OpenAiToken, cnf.env.TogetherAIToken, cnf.env.GoogleGeminiToken).With(
+		composer.UseMaxComposedLength(cnf.composeMaxLength),
+		composer.UseGlossary(cnf.glossary),
+		composer.UseScrubbing(cnf.scrubProviders...),
+	)
+
+	exported, skipped, err := writeFineTuneExamples(dst, c, news)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "[FAIL] %s: %s (%d exported before the error)\n", *channel, err, exported)
+		return false
+	}
+	if *out != "" {
+		_, _ = fmt.Fprintf(w, "[DONE] %s: %d news exported to %s, %d skipped without meta\n", *channel, exported, *out, skipped)
+	}
+
+	return true
+}
+
+// writeFineTuneExamples writes the training examples of the news to w, one JSON per line.
+// News without the composed meta can't be turned into the composer answer, so they are skipped.
+func writeFineTuneExamples(w io.Writer, c *composer.Composer, news []*archivist.News) (exported, skipped int, err error) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for _, n := range news {
+		var meta composer.ComposedMeta
+		if len(n.MetaData) == 0 || json.Unmarshal(n.MetaData, &meta) != nil {
+			skipped++
+			continue
+		}
+
+		example, err := c.FineTuneExample(
+			&journalist.News{ID: n.Hash, Title: n.OriginalTitle, Description: n.OriginalDesc},
+			&composer.ComposedNews{
+				Text:     n.ComposedText,
+				Tickers:  emptyIfNil(meta.Tickers),
+				Markets:  emptyIfNil(meta.Markets),
+				Hashtags: emptyIfNil(meta.Hashtags),
+			},
+		)
+		if err != nil {
+			return exported, skipped, err
+		}
+
+		if err := enc.Encode(example); err != nil {
+			return exported, skipped, err
+		}
+		exported++
+	}
+
+	return exported, skipped, nil
+}
+
+// emptyIfNil returns the empty slice instead of nil, so the answer has the same `[]` as the composer answers.
+func emptyIfNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
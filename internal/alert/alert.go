@@ -0,0 +1,176 @@
+// Package alert notifies the admin about high-severity errors through the secondary channels
+// (Telegram admin chat, webhook), so fatal failures don't end up only in the container logs and Sentry.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const sendTimeout = 10 * time.Second // timeout of sending the alert to all sinks
+
+// levels holds the severity rank of the Sentry levels.
+var levels = map[sentry.Level]int{
+	sentry.LevelDebug:   1,
+	sentry.LevelInfo:    2,
+	sentry.LevelWarning: 3,
+	sentry.LevelError:   4,
+	sentry.LevelFatal:   5,
+}
+
+// ParseLevel parses the minimal alert level: "error" or "fatal".
+func ParseLevel(s string) (sentry.Level, error) {
+	switch l := sentry.Level(strings.ToLower(strings.TrimSpace(s))); l {
+	case sentry.LevelError, sentry.LevelFatal:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown alert level %q, expected error or fatal", s)
+	}
+}
+
+// Alert is the notification about the captured error.
+type Alert struct {
+	Name  string       `json:"name"`  // name of the exception, e.g. "createScheduleJobError"
+	Level sentry.Level `json:"level"` // severity of the error
+	Error string       `json:"error"` // error message
+	Time  time.Time    `json:"time"`  // time of the error
+}
+
+// String formats the alert as the text message.
+func (a Alert) String() string {
+	return fmt.Sprintf("🚨 [%s] %s\n%s", strings.ToUpper(string(a.Level)), a.Name, a.Error)
+}
+
+// Sink sends the alert to the secondary channel.
+type Sink interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+// SinkFunc is the function adapter of the Sink.
+type SinkFunc func(ctx context.Context, a Alert) error
+
+// Send calls f(ctx, a).
+func (f SinkFunc) Send(ctx context.Context, a Alert) error {
+	return f(ctx, a)
+}
+
+// Webhook sends alerts as JSON (Alert fields and the formatted "text") with the POST request to the URL.
+type Webhook struct {
+	URL string
+}
+
+// Send posts the alert to the webhook URL.
+func (w *Webhook) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(struct {
+		Alert
+		Text string `json:"text"`
+	}{a, a.String()})
+	if err != nil {
+		return fmt.Errorf("error marshalling alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %w", err)
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected webhook response status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// Notifier sends alerts about the errors with the level from the minimal one to the sinks.
+// Alerts of the same name and level are sent once per the deduplication window.
+type Notifier struct {
+	minLevel sentry.Level
+	window   time.Duration
+	sinks    []Sink
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time // time of the last sent alert by the name and level
+	wg       sync.WaitGroup       // pending asynchronous alerts
+}
+
+// NewNotifier creates a new Notifier with the minimal level, deduplication window and sinks.
+func NewNotifier(minLevel sentry.Level, window time.Duration, sinks ...Sink) *Notifier {
+	return &Notifier{
+		minLevel: minLevel,
+		window:   window,
+		sinks:    sinks,
+		logger:   slog.Default(),
+		now:      time.Now,
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// Notify sends the alert about the error if its level is high enough and the same alert wasn't sent
+// during the deduplication window. Fatal alerts are sent synchronously because the process is likely about to exit,
+// others are sent in the background.
+func (n *Notifier) Notify(name string, level sentry.Level, err error) {
+	if err == nil || len(n.sinks) == 0 || levels[level] < levels[n.minLevel] {
+		return
+	}
+
+	now := n.now()
+	key := name + "/" + string(level)
+	n.mu.Lock()
+	if last, ok := n.lastSent[key]; ok && now.Sub(last) < n.window {
+		n.mu.Unlock()
+		return
+	}
+	n.lastSent[key] = now
+	n.mu.Unlock()
+
+	a := Alert{Name: name, Level: level, Error: err.Error(), Time: now}
+	if level == sentry.LevelFatal {
+		n.send(a)
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.send(a)
+	}()
+}
+
+// Wait waits for the alerts sent in the background.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// send sends the alert to all sinks, errors are logged since there is no other place to report them.
+func (n *Notifier) send(a Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, s := range n.sinks {
+		if err := s.Send(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		n.logger.Error("[alert] Error sending alert", "name", a.Name, "error", errors.Join(errs...))
+	}
+}
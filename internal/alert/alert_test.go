@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/getsentry/sentry-go"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (s *recordingSink) Send(_ context.Context, a Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, a)
+	return nil
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	type notification struct {
+		name  string
+		level sentry.Level
+		after time.Duration // time since the start
+	}
+	tests := []struct {
+		name     string
+		minLevel sentry.Level
+		notify   []notification
+		want     []string // names of the sent alerts
+	}{
+		{
+			name:     "skip errors below the level",
+			minLevel: sentry.LevelFatal,
+			notify: []notification{
+				{name: "jobError", level: sentry.LevelError},
+				{name: "schedulerError", level: sentry.LevelFatal},
+			},
+			want: []string{"schedulerError"},
+		},
+		{
+			name:     "deduplicate during the window",
+			minLevel: sentry.LevelError,
+			notify: []notification{
+				{name: "jobError", level: sentry.LevelError},
+				{name: "jobError", level: sentry.LevelError, after: 30 * time.Minute},
+				{name: "otherError", level: sentry.LevelError, after: 30 * time.Minute},
+				{name: "jobError", level: sentry.LevelError, after: 61 * time.Minute},
+			},
+			want: []string{"jobError", "otherError", "jobError"},
+		},
+		{
+			name:     "warnings are never sent",
+			minLevel: sentry.LevelError,
+			notify:   []notification{{name: "warn", level: sentry.LevelWarning}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			n := NewNotifier(tt.minLevel, time.Hour, sink)
+			start := time.Now()
+			for _, nt := range tt.notify {
+				n.now = func() time.Time { return start.Add(nt.after) }
+				n.Notify(nt.name, nt.level, errors.New("failed"))
+				n.Wait()
+			}
+
+			var got []string
+			for _, a := range sink.alerts {
+				got = append(got, a.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("sent %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("sent %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	a := Alert{Name: "createSchedulerError", Level: sentry.LevelFatal, Error: "scheduler is down", Time: time.Now()}
+	if err := (&Webhook{URL: srv.URL}).Send(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	if got["name"] != a.Name || got["level"] != "fatal" || got["text"] != a.String() {
+		t.Errorf("Send() body = %v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := (&Webhook{URL: failing.URL}).Send(context.Background(), a); err == nil {
+		t.Error("Send() error = nil, want error on 500 status")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel(" Fatal "); err != nil || l != sentry.LevelFatal {
+		t.Errorf("ParseLevel() = %v, %v", l, err)
+	}
+	if _, err := ParseLevel("warning"); err == nil {
+		t.Error("ParseLevel() error = nil, want error")
+	}
+}
@@ -0,0 +1,149 @@
+// Package links decorates the outgoing links of the posts in one place: per-channel UTM parameters,
+// the signed click-tracking redirect of the web server and the optional link shortener.
+package links
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const shortenTimeout = 5 * time.Second // timeout of the single shortener request
+
+// Post is the post the link is decorated for, it's added to the click-tracking redirect.
+type Post struct {
+	NewsID   string // ID of the news (archivist.News.ID)
+	Channel  string // ID of the channel
+	Provider string // name of the news provider
+}
+
+// Decorator decorates the links of the channel posts. Zero Decorator returns the links as is.
+type Decorator struct {
+	UTM         url.Values // UTM parameters appended to the links (optional)
+	RedirectURL string     // public base URL of the web server with the click-tracking redirect (optional)
+	Secret      []byte     // key of the redirect link signature, required with RedirectURL
+	Shortener   Shortener  // shortener of the decorated links (optional)
+}
+
+// Default returns the Decorator with the default UTM parameters only.
+func Default() *Decorator {
+	return &Decorator{UTM: url.Values{"utm_source": {"finthread"}}}
+}
+
+// Decorate appends the UTM parameters to the link, wraps it into the signed click-tracking redirect of the post
+// and shortens the result. The shortener errors are logged and the unshortened link is returned.
+func (d *Decorator) Decorate(ctx context.Context, link string, p Post) string {
+	link = appendParams(link, d.UTM)
+
+	if d.RedirectURL != "" && len(d.Secret) > 0 && p.NewsID != "" {
+		link = Redirect(d.RedirectURL, d.Secret, link, p)
+	}
+
+	if d.Shortener != nil {
+		short, err := d.Shortener.Shorten(ctx, link)
+		if err != nil {
+			slog.Default().Warn("[links] Error shortening link", "link", link, "error", err)
+			return link
+		}
+		link = short
+	}
+
+	return link
+}
+
+// appendParams appends the parameters to the link query keeping the existing ones.
+func appendParams(link string, params url.Values) string {
+	if len(params) == 0 {
+		return link
+	}
+
+	sep := "?"
+	if strings.Contains(link, "?") {
+		sep = "&"
+	}
+	return link + sep + params.Encode()
+}
+
+// Redirect returns the signed click-tracking redirect link to the target, e.g.
+// "https://example.com/r?c=%40channel&n=<news id>&p=Reuters&s=<signature>&u=<target>".
+func Redirect(baseURL string, secret []byte, target string, p Post) string {
+	q := url.Values{
+		"u": {target},
+		"n": {p.NewsID},
+		"c": {p.Channel},
+		"p": {p.Provider},
+		"s": {Sign(secret, target, p)},
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/r?" + q.Encode()
+}
+
+// Sign returns the signature of the redirect link, so the redirect can't be used to send users to other sites
+// and the recorded clicks can't be forged.
+func Sign(secret []byte, target string, p Post) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s\n%s", target, p.NewsID, p.Channel, p.Provider)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Verify returns true if the signature of the redirect link is valid.
+func Verify(secret []byte, target string, p Post, signature string) bool {
+	return len(secret) > 0 && hmac.Equal([]byte(Sign(secret, target, p)), []byte(signature))
+}
+
+// Shortener shortens the links.
+type Shortener interface {
+	Shorten(ctx context.Context, link string) (string, error)
+}
+
+// HTTPShortener shortens the links with the GET request to the shortener API that returns the short link
+// as the plain text, e.g. "https://is.gd/create.php?format=simple&url={url}".
+type HTTPShortener struct {
+	Endpoint string // API URL with the {url} placeholder of the escaped link
+	Client   *http.Client
+}
+
+// NewHTTPShortener creates a new HTTPShortener with the API URL template.
+func NewHTTPShortener(endpoint string) *HTTPShortener {
+	return &HTTPShortener{
+		Endpoint: endpoint,
+		Client:   &http.Client{Timeout: shortenTimeout},
+	}
+}
+
+// Shorten returns the short link.
+func (s *HTTPShortener) Shorten(ctx context.Context, link string) (string, error) {
+	endpoint := strings.ReplaceAll(s.Endpoint, "{url}", url.QueryEscape(link))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating shortener request: %w", err)
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending shortener request: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("error reading shortener response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected shortener response status %d", res.StatusCode)
+	}
+
+	short := strings.TrimSpace(string(body))
+	if u, err := url.Parse(short); err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("unexpected shortener response %q", short)
+	}
+
+	return short, nil
+}
@@ -0,0 +1,117 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type fakeShortener struct {
+	err error
+}
+
+func (f fakeShortener) Shorten(_ context.Context, link string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "https://sho.rt/" + url.QueryEscape(link)[:8], nil
+}
+
+func TestDecorator_Decorate(t *testing.T) {
+	post := Post{NewsID: "0b5d0a4e-4d3a-4c5e-9a53-3a2c1f6c2b11", Channel: "@fin_thread", Provider: "Reuters"}
+	utm := url.Values{"utm_source": {"finthread"}, "utm_medium": {"telegram"}}
+
+	tests := []struct {
+		name      string
+		decorator *Decorator
+		link      string
+		want      string
+	}{
+		{
+			name:      "zero decorator",
+			decorator: &Decorator{},
+			link:      "https://example.com/AAPL",
+			want:      "https://example.com/AAPL",
+		},
+		{
+			name:      "default",
+			decorator: Default(),
+			link:      "https://example.com/AAPL",
+			want:      "https://example.com/AAPL?utm_source=finthread",
+		},
+		{
+			name:      "UTM with existing query",
+			decorator: &Decorator{UTM: utm},
+			link:      "https://example.com/AAPL?lang=en",
+			want:      "https://example.com/AAPL?lang=en&utm_medium=telegram&utm_source=finthread",
+		},
+		{
+			name:      "redirect",
+			decorator: &Decorator{UTM: utm, RedirectURL: "https://fin.example.com/", Secret: []byte("secret")},
+			link:      "https://example.com/AAPL",
+			want: Redirect("https://fin.example.com", []byte("secret"),
+				"https://example.com/AAPL?utm_medium=telegram&utm_source=finthread", post),
+		},
+		{
+			name:      "shortener",
+			decorator: &Decorator{Shortener: fakeShortener{}},
+			link:      "https://example.com/AAPL",
+			want:      "https://sho.rt/https%3A",
+		},
+		{
+			name:      "shortener error",
+			decorator: &Decorator{UTM: utm, Shortener: fakeShortener{err: errors.New("rate limited")}},
+			link:      "https://example.com/AAPL",
+			want:      "https://example.com/AAPL?utm_medium=telegram&utm_source=finthread",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.decorator.Decorate(context.Background(), tt.link, post); got != tt.want {
+				t.Errorf("Decorate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	post := Post{NewsID: "1", Channel: "@fin_thread", Provider: "Reuters"}
+	signature := Sign(secret, "https://example.com", post)
+
+	if !Verify(secret, "https://example.com", post, signature) {
+		t.Error("Verify() = false for the valid signature")
+	}
+	if Verify(secret, "https://evil.example.com", post, signature) {
+		t.Error("Verify() = true for the other target")
+	}
+	if Verify(secret, "https://example.com", Post{NewsID: "1", Channel: "@fin_thread", Provider: "Bloomberg"}, signature) {
+		t.Error("Verify() = true for the other provider")
+	}
+	if Verify(nil, "https://example.com", post, Sign(nil, "https://example.com", post)) {
+		t.Error("Verify() = true without the secret")
+	}
+}
+
+func TestHTTPShortener_Shorten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("url") != "https://example.com/AAPL?utm_source=finthread" {
+			http.Error(w, "bad url", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("https://is.gd/abc123\n"))
+	}))
+	defer server.Close()
+
+	s := NewHTTPShortener(server.URL + "/create.php?format=simple&url={url}")
+	got, err := s.Shorten(context.Background(), "https://example.com/AAPL?utm_source=finthread")
+	if err != nil {
+		t.Fatalf("Shorten() error = %v", err)
+	}
+	if got != "https://is.gd/abc123" {
+		t.Errorf("Shorten() = %v, want https://is.gd/abc123", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Package startup initializes the application components with retries, so a briefly unavailable dependency
+// (Telegram, database, data source) doesn't abort the start, and optionally skips the failed optional components
+// to run the application in the degraded mode (e.g. calendar without stocks).
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"github.com/avast/retry-go"
+	"log/slog"
+	"time"
+)
+
+// Error is the error of the component that failed to start.
+type Error struct {
+	Component string // name of the component, e.g. "archivist" or "scavenger/yahoo-quotes"
+	Optional  bool   // if true, the application can run without the component in the degraded mode
+	Err       error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("error starting %s: %v", e.Component, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Orchestrator starts the components with retries and keeps track of the skipped optional components.
+type Orchestrator struct {
+	attempts uint          // attempts to start each component
+	delay    time.Duration // delay between the attempts
+	degraded bool          // if true, optional components that failed to start are skipped instead of failing the start
+	logger   *slog.Logger
+	skipped  []*Error
+}
+
+// NewOrchestrator creates a new Orchestrator. If degraded is true, failed optional components are skipped.
+func NewOrchestrator(attempts uint, delay time.Duration, degraded bool) *Orchestrator {
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	return &Orchestrator{
+		attempts: attempts,
+		delay:    delay,
+		degraded: degraded,
+		logger:   slog.Default(),
+	}
+}
+
+// Required starts the component with retries and returns the Error if all attempts failed.
+func (o *Orchestrator) Required(component string, start func() error) error {
+	if err := o.retry(component, start); err != nil {
+		return &Error{Component: component, Err: err}
+	}
+	return nil
+}
+
+// Optional starts the component with retries. If all attempts failed, the component is skipped in the degraded mode
+// (ok is false, err is nil), otherwise the Error is returned.
+func (o *Orchestrator) Optional(component string, start func() error) (ok bool, err error) {
+	if err := o.retry(component, start); err != nil {
+		e := &Error{Component: component, Optional: true, Err: err}
+		if !o.degraded {
+			return false, e
+		}
+
+		o.logger.Warn("[startup] Skipping component", "component", component, "error", err)
+		o.skipped = append(o.skipped, e)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Skipped returns the joined errors of the optional components skipped in the degraded mode (nil if none).
+func (o *Orchestrator) Skipped() error {
+	errs := make([]error, 0, len(o.skipped))
+	for _, e := range o.skipped {
+		errs = append(errs, e)
+	}
+	return errors.Join(errs...)
+}
+
+func (o *Orchestrator) retry(component string, start func() error) error {
+	return retry.Do(
+		start,
+		retry.Attempts(o.attempts),
+		retry.Delay(o.delay),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			o.logger.Warn("[startup] Retrying component", "component", component, "attempt", n+1, "error", err)
+		}),
+	)
+}
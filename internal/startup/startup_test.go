@@ -0,0 +1,83 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+)
+
+// failing returns the start function that fails the first n calls.
+func failing(n int) (start func() error, calls *int) {
+	calls = new(int)
+	return func() error {
+		*calls++
+		if *calls <= n {
+			return errors.New("unavailable")
+		}
+		return nil
+	}, calls
+}
+
+func TestOrchestrator_Required(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "started", failures: 0, wantCalls: 1},
+		{name: "started after retry", failures: 2, wantCalls: 3},
+		{name: "failed", failures: 5, wantCalls: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOrchestrator(3, 0, true)
+			start, calls := failing(tt.failures)
+
+			err := o.Required("archivist", start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Required() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("Required() calls = %d, want %d", *calls, tt.wantCalls)
+			}
+
+			var e *Error
+			if tt.wantErr && (!errors.As(err, &e) || e.Component != "archivist" || e.Optional) {
+				t.Errorf("Required() error = %#v, want required *Error of archivist", err)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_Optional(t *testing.T) {
+	tests := []struct {
+		name        string
+		degraded    bool
+		failures    int
+		wantOK      bool
+		wantErr     bool
+		wantSkipped bool
+	}{
+		{name: "started", failures: 1, wantOK: true},
+		{name: "skipped in degraded mode", degraded: true, failures: 5, wantSkipped: true},
+		{name: "failed without degraded mode", failures: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOrchestrator(2, 0, tt.degraded)
+			start, _ := failing(tt.failures)
+
+			ok, err := o.Optional("scavenger/stocks-screener", start)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Errorf("Optional() = %v, %v, want %v, wantErr %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+
+			var e *Error
+			if skipped := o.Skipped(); (skipped != nil) != tt.wantSkipped {
+				t.Errorf("Skipped() = %v, wantSkipped %v", skipped, tt.wantSkipped)
+			} else if tt.wantSkipped && (!errors.As(skipped, &e) || !e.Optional) {
+				t.Errorf("Skipped() = %#v, want optional *Error", skipped)
+			}
+		})
+	}
+}
@@ -4,8 +4,26 @@ import (
 	"errors"
 	"github.com/getsentry/sentry-go"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"sync/atomic"
 )
 
+// ErrorNotifier is notified about the captured exceptions in addition to Sentry (e.g. alert.Notifier).
+type ErrorNotifier interface {
+	Notify(name string, level sentry.Level, err error)
+}
+
+// errorNotifier is the optional ErrorNotifier set with SetErrorNotifier.
+var errorNotifier atomic.Pointer[ErrorNotifier]
+
+// SetErrorNotifier sets the notifier of the captured exceptions (nil disables notifications).
+func SetErrorNotifier(n ErrorNotifier) {
+	if n == nil {
+		errorNotifier.Store(nil)
+		return
+	}
+	errorNotifier.Store(&n)
+}
+
 type sentryHub interface {
 	CaptureException(exception error) *sentry.EventID
 	WithScope(callback func(scope *sentry.Scope))
@@ -26,6 +44,10 @@ func CaptureSentryException(name string, hub sentryHub, err error) {
 		})
 		hub.CaptureException(err)
 	})
+
+	if n := errorNotifier.Load(); n != nil {
+		(*n).Notify(name, errType, err)
+	}
 }
 
 // errorsLevelMatcher is a helper function that returns the Sentry level for the given error.
@@ -54,6 +54,30 @@ func TestCaptureSentryException(t *testing.T) {
 	}
 }
 
+type notifierFunc func(name string, level sentry.Level, err error)
+
+func (f notifierFunc) Notify(name string, level sentry.Level, err error) { f(name, level, err) }
+
+func TestCaptureSentryException_notifier(t *testing.T) {
+	var gotName string
+	var gotLevel sentry.Level
+	SetErrorNotifier(notifierFunc(func(name string, level sentry.Level, _ error) {
+		gotName, gotLevel = name, level
+	}))
+	defer SetErrorNotifier(nil)
+
+	err := errlvl.Wrap(errors.New("scheduler is down"), errlvl.FATAL)
+	hub := new(MockHub)
+	hub.On("WithScope", mock.Anything)
+	hub.On("CaptureException", err).Return(new(sentry.EventID))
+
+	CaptureSentryException("createSchedulerError", hub, err)
+
+	if gotName != "createSchedulerError" || gotLevel != sentry.LevelFatal {
+		t.Errorf("Notify() got name = %q, level = %q", gotName, gotLevel)
+	}
+}
+
 type customError struct {
 	// severity level of the error
 	level errlvl.Lvl
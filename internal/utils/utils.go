@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // ParseDate parses a date string into a time.Time object in UTC.
@@ -100,3 +101,23 @@ func ReplaceUnicodeSymbols(s string) string {
 
 	return decoded
 }
+
+// Truncate shortens the string to maxLength characters (runes) by the last word and adds "…" (within the limit).
+// Invalid UTF-8 sequences are removed, so the result is always safe to store and send.
+// String is returned as is if maxLength is 0 or it is short enough.
+func Truncate(s string, maxLength int) string {
+	runes := []rune(strings.ToValidUTF8(s, ""))
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return string(runes)
+	}
+
+	// Leave space for the ellipsis
+	cut := runes[:maxLength-1]
+	for i := len(cut) - 1; i > 0; i-- {
+		if unicode.IsSpace(cut[i]) {
+			return strings.TrimRightFunc(string(cut[:i]), unicode.IsPunct) + "…"
+		}
+	}
+
+	return string(cut) + "…"
+}
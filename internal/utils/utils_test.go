@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func Test_ParseDate(t *testing.T) {
@@ -178,3 +179,30 @@ func Test_ReplaceUnicodeSymbols(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		maxLength int
+		want      string
+	}{
+		{name: "short text", s: "Stocks rally", maxLength: 20, want: "Stocks rally"},
+		{name: "no limit", s: "Stocks rally", maxLength: 0, want: "Stocks rally"},
+		{name: "cut by the last word", s: "Stocks rally, bonds fall", maxLength: 16, want: "Stocks rally…"},
+		{name: "multi-byte runes", s: "Акции растут на фоне отчётов", maxLength: 15, want: "Акции растут…"},
+		{name: "no spaces", s: "Наибольшийрост", maxLength: 5, want: "Наиб…"},
+		{name: "invalid utf-8", s: "Stocks\xff rally", maxLength: 20, want: "Stocks rally"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.s, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("Truncate() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Truncate() = %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Package web serves the public pages of the published news (permalinks), so the posts can be shared
+// outside Telegram with the full composed text, meta and the original source, the signed click-tracking
+// redirects of the post links (see links.Redirect) and the health of the scheduled jobs.
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/links"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second  // timeout of reading the request headers
+	requestTimeout    = 10 * time.Second // timeout of the page rendering (including the database query)
+)
+
+// newsFinder finds the published news by ID (e.g. archivist.NewsDB).
+type newsFinder interface {
+	FindPublished(ctx context.Context, id uuid.UUID) (*archivist.News, error)
+}
+
+// clickRecorder records the clicks of the redirect links (e.g. archivist.ClicksDB).
+type clickRecorder interface {
+	Create(ctx context.Context, c *archivist.Click) error
+}
+
+// healthReporter reports the health of the app with its JSON-encodable details (e.g. jobs.ScheduleMonitor).
+type healthReporter interface {
+	Health() (ok bool, details any)
+}
+
+// Permalink returns the URL of the news page on the server with the public base URL, e.g. "https://example.com/news/<id>".
+func Permalink(baseURL string, id uuid.UUID) string {
+	return fmt.Sprintf("%s/news/%s", strings.TrimSuffix(baseURL, "/"), id)
+}
+
+// Server serves the HTML pages of the published news.
+type Server struct {
+	server  *http.Server
+	news    newsFinder
+	baseURL string        // public base URL of the server used in the page meta
+	secret  []byte        // key of the redirect links signature (redirects are disabled if empty)
+	clicks  clickRecorder // recorder of the redirect clicks (optional)
+	health  healthReporter
+	logger  *slog.Logger
+}
+
+// NewServer creates a new Server that will listen on the address (e.g. ":8080") with the public base URL.
+func NewServer(addr, baseURL string, news newsFinder) *Server {
+	s := &Server{
+		news:    news,
+		baseURL: baseURL,
+		logger:  slog.Default(),
+	}
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	return s
+}
+
+// WithRedirects enables the click-tracking redirects of the links signed with the secret (see links.Redirect).
+// Clicks are recorded by the recorder if it's not nil.
+func (s *Server) WithRedirects(secret []byte, clicks clickRecorder) *Server {
+	s.secret = secret
+	s.clicks = clicks
+	return s
+}
+
+// WithHealth reports the details of the reporter (e.g. the scheduled jobs status) on the health endpoint.
+func (s *Server) WithHealth(h healthReporter) *Server {
+	s.health = h
+	return s
+}
+
+// Handler returns the HTTP handler of the server routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /news/{id}", s.newsPage)
+	mux.HandleFunc("GET /r", s.redirect)
+	mux.HandleFunc("GET /health", s.healthCheck)
+	return http.TimeoutHandler(mux, requestTimeout, "timeout")
+}
+
+// Run starts the server and blocks until it is shut down.
+func (s *Server) Run() error {
+	err := s.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("[web] server error: %w", err)
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// newsPage renders the page of the published news by its ID.
+func (s *Server) newsPage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	n, err := s.news.FindPublished(r.Context(), id)
+	if err != nil {
+		s.logger.Error("[web] Error finding news", "id", id, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if n == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := newsTemplate.Execute(w, newNewsView(n, Permalink(s.baseURL, n.ID))); err != nil {
+		s.logger.Error("[web] Error rendering news page", "id", id, "error", err)
+	}
+}
+
+// redirect records the click of the signed link and redirects to its target.
+// Links with the invalid signature are not found, so the server can't be used as an open redirect.
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	target := q.Get("u")
+	p := links.Post{NewsID: q.Get("n"), Channel: q.Get("c"), Provider: q.Get("p")}
+	if !links.Verify(s.secret, target, p, q.Get("s")) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if id, err := uuid.Parse(p.NewsID); err == nil && s.clicks != nil {
+		click := &archivist.Click{NewsID: id, ChannelID: p.Channel, Provider: p.Provider, URL: target}
+		if err := s.clicks.Create(r.Context(), click); err != nil {
+			s.logger.Error("[web] Error recording click", "news", p.NewsID, "error", err)
+		}
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// healthCheck responds with the JSON health status: `{"status":"ok","details":...}` or 503 with the "degraded"
+// status if the reporter is unhealthy (e.g. some scheduled jobs are overdue).
+func (s *Server) healthCheck(w http.ResponseWriter, _ *http.Request) {
+	status := struct {
+		Status  string `json:"status"`
+		Details any    `json:"details,omitempty"`
+	}{Status: "ok"}
+	code := http.StatusOK
+
+	if s.health != nil {
+		var ok bool
+		ok, status.Details = s.health.Health()
+		if !ok {
+			status.Status = "degraded"
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.Error("[web] Error encoding health status", "error", err)
+	}
+}
+
+// newsView is the data of the news page template.
+type newsView struct {
+	Title       string
+	Text        string
+	Permalink   string
+	Provider    string
+	URL         string
+	ArchiveURL  string
+	TelegramURL string
+	PublishedAt string
+	Meta        composer.ComposedMeta
+}
+
+// newNewsView creates the page view of the news, the composed text falls back to the original description.
+func newNewsView(n *archivist.News, permalink string) *newsView {
+	v := &newsView{
+		Title:       n.OriginalTitle,
+		Text:        n.ComposedText,
+		Permalink:   permalink,
+		Provider:    n.ProviderName,
+		URL:         n.URL,
+		ArchiveURL:  n.ArchiveURL,
+		TelegramURL: fmt.Sprintf("https://t.me/%s/%s", strings.TrimPrefix(n.ChannelID, "@"), n.PublicationID),
+		PublishedAt: n.PublishedAt.UTC().Format("2006-01-02 15:04 UTC"),
+	}
+	if v.Text == "" {
+		v.Text = n.OriginalDesc
+	}
+	if n.MetaData != nil {
+		_ = json.Unmarshal(n.MetaData, &v.Meta)
+	}
+
+	return v
+}
+
+var newsTemplate = template.Must(template.New("news").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Text}}">
+<meta property="og:type" content="article">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Text}}">
+<meta property="og:url" content="{{.Permalink}}">
+<link rel="canonical" href="{{.Permalink}}">
+</head>
+<body>
+<article>
+<h1>{{.Title}}</h1>
+<p>{{.Text}}</p>
+{{- with .Meta.Tickers}}
+<p>Tickers: {{range $i, $t := .}}{{if $i}}, {{end}}${{$t}}{{end}}</p>
+{{- end}}
+{{- with .Meta.Markets}}
+<p>Markets: {{range $i, $m := .}}{{if $i}}, {{end}}{{$m}}{{end}}</p>
+{{- end}}
+{{- with .Meta.Hashtags}}
+<p>{{range $i, $h := .}}{{if $i}} {{end}}#{{$h}}{{end}}</p>
+{{- end}}
+<p>Published {{.PublishedAt}} in <a href="{{.TelegramURL}}">Telegram</a></p>
+<p>Source: <a href="{{.URL}}" rel="nofollow noopener">{{if .Provider}}{{.Provider}}{{else}}{{.URL}}{{end}}</a>
+{{- with .ArchiveURL}} (<a href="{{.}}" rel="nofollow noopener">archived</a>){{end}}</p>
+</article>
+</body>
+</html>
+`))
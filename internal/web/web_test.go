@@ -0,0 +1,182 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/links"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeNewsFinder map[uuid.UUID]*archivist.News
+
+func (f fakeNewsFinder) FindPublished(_ context.Context, id uuid.UUID) (*archivist.News, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("connection refused")
+	}
+	return f[id], nil
+}
+
+func TestPermalink(t *testing.T) {
+	id := uuid.MustParse("0b5d0a4e-4d3a-4c5e-9a53-3a2c1f6c2b11")
+	want := "https://example.com/news/0b5d0a4e-4d3a-4c5e-9a53-3a2c1f6c2b11"
+	for _, base := range []string{"https://example.com", "https://example.com/"} {
+		if got := Permalink(base, id); got != want {
+			t.Errorf("Permalink(%q) = %s, want %s", base, got, want)
+		}
+	}
+}
+
+func TestServer_newsPage(t *testing.T) {
+	id := uuid.New()
+	news := fakeNewsFinder{id: {
+		ID:            id,
+		ChannelID:     "@fin_thread",
+		PublicationID: "42",
+		ProviderName:  "Reuters",
+		URL:           "https://example.com/apple",
+		OriginalTitle: "Apple beats <earnings> estimates",
+		ComposedText:  "Apple reported record revenue.",
+		MetaData:      []byte(`{"tickers":["AAPL"],"markets":[],"hashtags":["earnings"]}`),
+		PublishedAt:   time.Date(2024, 1, 2, 13, 30, 0, 0, time.UTC),
+	}}
+	s := httptest.NewServer(NewServer(":0", "https://example.com", news).Handler())
+	defer s.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "published news",
+			path:     "/news/" + id.String(),
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"<title>Apple beats &lt;earnings&gt; estimates</title>",
+				`<meta property="og:url" content="https://example.com/news/` + id.String() + `">`,
+				"Apple reported record revenue.",
+				"Tickers: $AAPL",
+				"#earnings",
+				`<a href="https://t.me/fin_thread/42">Telegram</a>`,
+				`<a href="https://example.com/apple" rel="nofollow noopener">Reuters</a>`,
+				"2024-01-02 13:30 UTC",
+			},
+		},
+		{name: "unknown news", path: "/news/" + uuid.NewString(), wantCode: http.StatusNotFound},
+		{name: "invalid id", path: "/news/123", wantCode: http.StatusNotFound},
+		{name: "database error", path: "/news/" + uuid.Nil.String(), wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Get(s.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+
+			if res.StatusCode != tt.wantCode {
+				t.Fatalf("GET %s status = %d, want %d", tt.path, res.StatusCode, tt.wantCode)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("GET %s body doesn't contain %q:\n%s", tt.path, want, body)
+				}
+			}
+		})
+	}
+}
+
+type fakeClickRecorder []*archivist.Click
+
+func (f *fakeClickRecorder) Create(_ context.Context, c *archivist.Click) error {
+	*f = append(*f, c)
+	return nil
+}
+
+func TestServer_redirect(t *testing.T) {
+	secret := []byte("secret")
+	clicks := &fakeClickRecorder{}
+	s := httptest.NewServer(NewServer(":0", "https://example.com", fakeNewsFinder{}).WithRedirects(secret, clicks).Handler())
+	defer s.Close()
+
+	id := uuid.New()
+	post := links.Post{NewsID: id.String(), Channel: "@fin_thread", Provider: "Reuters"}
+	target := "https://short-fork.extr.app/en/AAPL?utm_source=finthread"
+	signed := links.Redirect(s.URL, secret, target, post)
+	forged := links.Redirect(s.URL, []byte("other"), target, post)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	res, err := client.Get(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != target {
+		t.Errorf("signed redirect = %d %s, want %d %s", res.StatusCode, res.Header.Get("Location"), http.StatusFound, target)
+	}
+	if len(*clicks) != 1 || (*clicks)[0].NewsID != id || (*clicks)[0].Provider != "Reuters" || (*clicks)[0].ChannelID != "@fin_thread" {
+		t.Errorf("recorded clicks = %+v, want one click of the news", *clicks)
+	}
+
+	res, err = client.Get(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("forged redirect status = %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+	if len(*clicks) != 1 {
+		t.Errorf("recorded %d clicks, want forged click to be ignored", len(*clicks))
+	}
+}
+
+type fakeHealthReporter bool
+
+func (f fakeHealthReporter) Health() (bool, any) {
+	return bool(f), []string{"Market news"}
+}
+
+func TestServer_healthCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		health     healthReporter
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without reporter", wantStatus: http.StatusOK, wantBody: `{"status":"ok"}`},
+		{name: "healthy", health: fakeHealthReporter(true), wantStatus: http.StatusOK, wantBody: `{"status":"ok","details":["Market news"]}`},
+		{name: "degraded", health: fakeHealthReporter(false), wantStatus: http.StatusServiceUnavailable, wantBody: `{"status":"degraded","details":["Market news"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer(":0", "https://example.com", fakeNewsFinder{})
+			if tt.health != nil {
+				srv.WithHealth(tt.health)
+			}
+			s := httptest.NewServer(srv.Handler())
+			defer s.Close()
+
+			res, err := http.Get(s.URL + "/health")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, _ := io.ReadAll(res.Body)
+
+			if res.StatusCode != tt.wantStatus || strings.TrimSpace(string(body)) != tt.wantBody {
+				t.Errorf("GET /health = %d %s, want %d %s", res.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
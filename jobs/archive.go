@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	archiveQueueSize = 100             // published news waiting for the snapshot, new ones are dropped if the queue is full
+	archiveTimeout   = 2 * time.Minute // timeout of the snapshot capture and update of the single news
+)
+
+// snapshotter captures the snapshot of the page in the web archive and returns its URL (e.g. wayback.Wayback).
+type snapshotter interface {
+	Snapshot(ctx context.Context, link string) (string, error)
+}
+
+// linkArchiver saves the web archive snapshots of the published news links in the background,
+// because the capture is too slow for the job timeout.
+type linkArchiver struct {
+	snapshotter snapshotter
+	update      func(ctx context.Context, n *archivist.News) error // saves the news with the archive URL
+	hub         *sentry.Hub
+	logger      *slog.Logger
+	queue       chan *archivist.News
+	once        sync.Once
+}
+
+// newLinkArchiver creates a new linkArchiver. The worker is started on the first enqueue.
+func newLinkArchiver(s snapshotter, update func(ctx context.Context, n *archivist.News) error, logger *slog.Logger) *linkArchiver {
+	return &linkArchiver{
+		snapshotter: s,
+		update:      update,
+		hub:         sentry.CurrentHub().Clone(),
+		logger:      logger,
+		queue:       make(chan *archivist.News, archiveQueueSize),
+	}
+}
+
+// enqueue adds the news without the archive URL to the queue. News are skipped if the queue is full.
+func (a *linkArchiver) enqueue(news []*archivist.News) {
+	a.once.Do(func() {
+		go a.work()
+	})
+
+	for _, n := range news {
+		if n.URL == "" || n.ArchiveURL != "" {
+			continue
+		}
+		select {
+		case a.queue <- n:
+		default:
+			a.logger.Warn("[linkArchiver] queue is full, skipping news", "hash", n.Hash)
+		}
+	}
+}
+
+// work captures the snapshots of the queued news one by one and saves their archive URL.
+func (a *linkArchiver) work() {
+	for n := range a.queue {
+		if err := a.archive(n); err != nil {
+			a.logger.Warn("[linkArchiver]", "hash", n.Hash, "error", err)
+			utils.CaptureSentryException("jobArchiveLinkError", a.hub, err)
+		}
+	}
+}
+
+// archive captures the snapshot of the news link and saves it as the news archive URL.
+func (a *linkArchiver) archive(n *archivist.News) error {
+	ctx, cancel := context.WithTimeout(context.Background(), archiveTimeout)
+	defer cancel()
+
+	snapshot, err := a.snapshotter.Snapshot(ctx, n.URL)
+	if err != nil {
+		return fmt.Errorf("[linkArchiver.Snapshot]: %w", err)
+	}
+	if len(snapshot) > 512 {
+		return fmt.Errorf("[linkArchiver.Snapshot]: snapshot URL of %s is too long", n.URL)
+	}
+
+	n.ArchiveURL = snapshot
+	if err := a.update(ctx, n); err != nil {
+		return fmt.Errorf("[linkArchiver.News.Update]: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSnapshotter struct {
+	err error
+}
+
+func (s *fakeSnapshotter) Snapshot(_ context.Context, link string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return "https://web.archive.org/web/20240102030405/" + link, nil
+}
+
+func TestLinkArchiver(t *testing.T) {
+	tests := []struct {
+		name    string
+		news    []*archivist.News
+		err     error
+		want    map[string]string // hash -> archive URL of the updated news
+		wantErr bool
+	}{
+		{
+			name: "archive published news",
+			news: []*archivist.News{
+				{Hash: "1", URL: "https://example.com/1"},
+				{Hash: "2", URL: "https://example.com/2"},
+			},
+			want: map[string]string{
+				"1": "https://web.archive.org/web/20240102030405/https://example.com/1",
+				"2": "https://web.archive.org/web/20240102030405/https://example.com/2",
+			},
+		},
+		{
+			name: "skip already archived news",
+			news: []*archivist.News{
+				{Hash: "1", URL: "https://example.com/1", ArchiveURL: "https://web.archive.org/web/1/https://example.com/1"},
+				{Hash: "2", URL: "https://example.com/2"},
+			},
+			want: map[string]string{
+				"2": "https://web.archive.org/web/20240102030405/https://example.com/2",
+			},
+		},
+		{
+			name:    "snapshot error",
+			news:    []*archivist.News{{Hash: "1", URL: "https://example.com/1"}},
+			err:     errors.New("rate limited"),
+			want:    map[string]string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			got := make(map[string]string)
+			done := make(chan struct{}, len(tt.news))
+			update := func(_ context.Context, n *archivist.News) error {
+				mu.Lock()
+				got[n.Hash] = n.ArchiveURL
+				mu.Unlock()
+				done <- struct{}{}
+				return nil
+			}
+
+			a := newLinkArchiver(&fakeSnapshotter{err: tt.err}, update, slog.Default())
+			if tt.wantErr {
+				if err := a.archive(tt.news[0]); err == nil {
+					t.Errorf("archive() error = nil, wantErr")
+				}
+				return
+			}
+
+			a.enqueue(tt.news)
+			for range tt.want {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatal("timeout waiting for the archived news")
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(got) != len(tt.want) {
+				t.Fatalf("updated %v, want %v", got, tt.want)
+			}
+			for hash, url := range tt.want {
+				if got[hash] != url {
+					t.Errorf("news %s archive URL = %q, want %q", hash, got[hash], url)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"testing"
+	"time"
+)
+
+// Benchmarks of the news pipeline with the fake external services. Run them with `make bench`
+// and compare the results with benchstat before and after the changes of the compose and publish path.
+
+// syntheticNews generates n RSS items (newest first) with unique titles and links.
+// Every 10th item is a webinar advertisement which is removed by the fake AI filter.
+func syntheticNews(n int, now time.Time) []rssItem {
+	items := make([]rssItem, n)
+	for i := range items {
+		title := fmt.Sprintf("Company %d reports quarterly earnings above estimates", i)
+		if i%10 == 0 {
+			title = fmt.Sprintf("Join our webinar on trading #%d", i)
+		}
+		items[i] = rssItem{
+			Title:       title,
+			Description: fmt.Sprintf("Revenue of company %d grew %d%% year over year, guidance was raised.", i, i%50),
+			Link:        fmt.Sprintf("https://example.com/news/%d", i),
+			Date:        now.Add(-time.Duration(i) * time.Second),
+		}
+	}
+	return items
+}
+
+func BenchmarkJob_Run(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("news=%d", size), func(b *testing.B) {
+			tg := newFakeTelegram(b)
+			ai := newFakeOpenAI(b)
+			now := time.Now().UTC()
+			feed := newFakeRSS(b, syntheticNews(size, now))
+
+			c := composer.NewComposer("test", "test", "")
+			c.OpenAiClient = ai.client()
+			j := journalist.NewJournalist("Bench", []journalist.NewsProvider{journalist.NewRssProvider("Bench feed", feed.URL)})
+			job := NewJob(c, tg.publisher(b, "@bench_channel"), nil, j, nil).
+				FetchUntil(now.Add(-time.Hour)).
+				OmitSuspicious().
+				OmitIfAllKeysEmpty().
+				ComposeText()
+			run := job.Run()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				run()
+			}
+			b.StopTimer()
+
+			published := len(tg.sent())
+			b.ReportMetric(float64(published)/float64(b.N), "published/op")
+			b.ReportMetric(float64(b.Elapsed().Microseconds())/float64(published), "µs/published")
+		})
+	}
+}
+
+func BenchmarkJob_prepublishFilter(b *testing.B) {
+	meta, _ := json.Marshal(composer.ComposedMeta{Tickers: []string{"AAPL"}, Markets: []string{"SPX"}})
+	news := make([]*archivist.News, 1000)
+	for i := range news {
+		news[i] = &archivist.News{
+			ID:            uuid.New(),
+			ProviderName:  "Bench feed",
+			OriginalTitle: fmt.Sprintf("Company %d reports quarterly earnings", i),
+			ComposedText:  fmt.Sprintf("Company %d reports quarterly earnings.", i),
+			MetaData:      meta,
+		}
+	}
+	mutes := []*archivist.Mute{{Kind: archivist.MuteKeyword, Value: "webinar", Until: time.Now().Add(time.Hour)}}
+
+	job := NewJob(nil, nil, nil, &journalist.Journalist{Name: "Bench"}, nil).
+		ComposeText().
+		OmitSuspicious().
+		OmitIfAllKeysEmpty().
+		Watchlist("MSFT").
+		ProviderTrust(map[string]float64{"Bench feed": 1.5})
+	tx := sentry.StartTransaction(context.Background(), "bench")
+	hub := sentry.CurrentHub().Clone()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := job.prepublishFilter(tx, hub, news, mutes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
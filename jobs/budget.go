@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Estimates of the news job stages after composing. They are reserved from the end of the job timeout,
+// so the slow AI stages time out earlier and the composed (already paid) news are still saved and published.
+const (
+	saveEstimate    = 2 * time.Second // saving the composed news and looking up the active mutes
+	publishEstimate = 6 * time.Second // publishing the batch to Telegram
+	updateEstimate  = 2 * time.Second // saving the publication IDs of the published news
+
+	// lateStagesEstimate is the time reserved for all stages after composing.
+	lateStagesEstimate = saveEstimate + publishEstimate + updateEstimate
+)
+
+// withReserve returns the context that expires the reserve earlier than ctx, so the later stages
+// have at least the reserve left. If ctx has no deadline, the returned context is only canceled with ctx.
+func withReserve(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
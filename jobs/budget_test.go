@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_withReserve(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	withDeadline, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		reserve      time.Duration
+		wantDeadline time.Time
+		wantOK       bool
+	}{
+		{name: "with deadline", ctx: withDeadline, reserve: 10 * time.Second, wantDeadline: deadline.Add(-10 * time.Second), wantOK: true},
+		{name: "without deadline", ctx: context.Background(), reserve: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := withReserve(tt.ctx, tt.reserve)
+			defer cancel()
+
+			got, ok := ctx.Deadline()
+			if ok != tt.wantOK || !got.Equal(tt.wantDeadline) {
+				t.Errorf("withReserve() deadline = %v, %v, want %v, %v", got, ok, tt.wantDeadline, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("reserve longer than the time left", func(t *testing.T) {
+		ctx, cancel := withReserve(withDeadline, 2*time.Minute)
+		defer cancel()
+
+		if ctx.Err() == nil {
+			t.Error("withReserve() context is not expired")
+		}
+		if withDeadline.Err() != nil {
+			t.Error("withReserve() expired the parent context")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"encoding/json"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
+	"slices"
+)
+
+// Button is the kind of the button under the published news (see Job.AddButtons).
+type Button string
+
+const (
+	ButtonSource Button = "source" // original news article
+	ButtonChart  Button = "chart"  // price chart of the first ticker
+	ButtonTicker Button = "ticker" // ticker page of the first ticker ("More on $AAPL")
+)
+
+// Buttons are all kinds of the news buttons.
+var Buttons = []Button{ButtonSource, ButtonChart, ButtonTicker}
+
+// chartURL is the base URL of the ticker price charts linked by ButtonChart.
+const chartURL = "https://www.tradingview.com/chart/?symbol="
+
+// AddButtons adds the buttons of the given kinds under the published news in the given order, e.g. the link
+// to the original article and the chart of the first ticker. Telegram publishes them as the inline keyboard,
+// other publishers list them as links. Buttons without the data (e.g. the chart of the news without tickers)
+// are skipped. Note: ButtonChart and ButtonTicker require ComposeText to be set.
+func (job *Job) AddButtons(kinds ...Button) *Job {
+	job.options.buttons = append(job.options.buttons, kinds...)
+	return job
+}
+
+// formatButtons returns the buttons of the news with the ticker links decorated by the given function
+// (see Job.AddButtons). Returns nil if no buttons are set.
+func (job *Job) formatButtons(n *archivist.News, decorate func(link string) string) []publisher.Link {
+	if len(job.options.buttons) == 0 {
+		return nil
+	}
+
+	var ticker string
+	var meta composer.ComposedMeta
+	if n.MetaData != nil && json.Unmarshal(n.MetaData, &meta) == nil && len(meta.Tickers) > 0 {
+		ticker = meta.Tickers[0]
+	}
+
+	buttons := make([]publisher.Link, 0, len(job.options.buttons))
+	for _, kind := range job.options.buttons {
+		switch {
+		case kind == ButtonSource && n.URL != "":
+			buttons = append(buttons, publisher.Link{Title: "📰 Source", URL: n.URL})
+		case kind == ButtonChart && ticker != "":
+			buttons = append(buttons, publisher.Link{Title: "📈 Chart", URL: chartURL + ticker})
+		case kind == ButtonTicker && ticker != "":
+			buttons = append(buttons, publisher.Link{Title: "More on $" + ticker, URL: decorate(tickerURL + ticker)})
+		}
+	}
+
+	return buttons
+}
+
+// unknownButtons returns the kinds of the Job.AddButtons that are not in Buttons.
+func unknownButtons(kinds []Button) []Button {
+	var unknown []Button
+	for _, kind := range kinds {
+		if !slices.Contains(Buttons, kind) {
+			unknown = append(unknown, kind)
+		}
+	}
+	return unknown
+}
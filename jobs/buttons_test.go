@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/publisher"
+	"gorm.io/datatypes"
+	"reflect"
+	"testing"
+)
+
+func TestJob_formatButtons(t *testing.T) {
+	decorate := func(link string) string { return link + "?utm_source=finthread" }
+	withTickers := &archivist.News{URL: "https://example.com/news", MetaData: datatypes.JSON(`{"tickers":["AAPL","MSFT"]}`)}
+
+	tests := []struct {
+		name    string
+		buttons []Button
+		news    *archivist.News
+		want    []publisher.Link
+	}{
+		{name: "no buttons", news: withTickers, want: nil},
+		{
+			name:    "all buttons in order",
+			buttons: []Button{ButtonTicker, ButtonSource, ButtonChart},
+			news:    withTickers,
+			want: []publisher.Link{
+				{Title: "More on $AAPL", URL: tickerURL + "AAPL?utm_source=finthread"},
+				{Title: "📰 Source", URL: "https://example.com/news"},
+				{Title: "📈 Chart", URL: chartURL + "AAPL"},
+			},
+		},
+		{
+			name:    "ticker buttons skipped without tickers",
+			buttons: []Button{ButtonSource, ButtonChart, ButtonTicker},
+			news:    &archivist.News{URL: "https://example.com/news", MetaData: datatypes.JSON(`{"tickers":[]}`)},
+			want:    []publisher.Link{{Title: "📰 Source", URL: "https://example.com/news"}},
+		},
+		{
+			name:    "no meta",
+			buttons: []Button{ButtonChart},
+			news:    &archivist.News{URL: "https://example.com/news"},
+			want:    []publisher.Link{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).AddButtons(tt.buttons...)
+			if got := job.formatButtons(tt.news, decorate); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("formatButtons() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,31 +2,51 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/avast/retry-go"
 	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/publisher"
 	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
 	"log/slog"
 	"math"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 )
 
+// updatesWindowMargin is the margin of the updates fetch window around the pending events,
+// the actual values are usually released right at the event time.
+const updatesWindowMargin = 2 * time.Hour
+
+// minTitleSimilarity is the minimal similarity of the titles of the same event without the provider ID,
+// e.g. "Core CPI m/m" and "Core CPI m/m (Nov)" (0.75), but not "CPI m/m" and "Core CPI m/m" (0.67).
+const minTitleSimilarity = 0.7
+
 // CalendarJob is the struct that will fetch calendar events and publish them to the channel.
 type CalendarJob struct {
-	calendarScavenger *ecal.EconomicCalendar       // calendar scavenger that will fetch calendar events
-	publisher         *publisher.TelegramPublisher // publisher that will publish news to the channel
-	archivist         *archivist.Archivist         // archivist that will save news to the database
-	logger            *slog.Logger                 // special logger for the job
-	providerName      string                       // name of the job provider
+	calendarScavenger *ecal.EconomicCalendar         // calendar scavenger that will fetch calendar events
+	publisher         publisher.Publisher            // publisher that will publish news to the channel
+	archivist         *archivist.Archivist           // archivist that will save news to the database
+	logger            *slog.Logger                   // special logger for the job
+	providerName      string                         // name of the job provider
+	countries         []ecal.EconomicCalendarCountry // countries to include in the channel (all if empty)
+	pollsLimit        int                            // if > 0, will publish up to N forecast polls for high-impact events
+	theme             *Theme                         // icons of the calendar posts
+	titles            *eventTitles                   // if set, will normalize the fetched event titles
+	fxThreshold       float64                        // if > 0, will append FX pairs of the events deviated from the forecast by more than it
+	fxQuotes          *quotes.Quotes                 // quotes source of the appended FX pairs (optional)
+	editPlan          bool                           // if true, will edit the daily plan with the actual values instead of posting the updates
 }
 
 func NewCalendarJob(
 	calendarScavenger *ecal.EconomicCalendar,
-	publisher *publisher.TelegramPublisher,
+	publisher publisher.Publisher,
 	archivist *archivist.Archivist,
 	providerName string,
 ) *CalendarJob {
@@ -36,96 +56,138 @@ func NewCalendarJob(
 		archivist:         archivist,
 		logger:            slog.Default(),
 		providerName:      providerName,
+		theme:             DefaultTheme(),
 	}
 }
 
-// RunDailyCalendarJob creates events plan for the upcoming day and publishes them to the channel.
-// It should be run every business day.
-func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
-	return func() {
-		_ = retry.Do(func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
-			defer cancel()
-			j.logger.Info("[calendar] Running daily plan")
-
-			tx := sentry.StartTransaction(ctx, "RunDailyCalendarJob")
-			tx.Op = "job-calendar"
-
-			// Sentry performance monitoring
-			hub := sentry.GetHubFromContext(ctx)
-			if hub == nil {
-				hub = sentry.CurrentHub().Clone()
-				ctx = sentry.SetHubOnContext(ctx, hub)
-			}
+// OnlyCountries sets the countries which events will be published to the channel (all if empty).
+func (j *CalendarJob) OnlyCountries(countries ...ecal.EconomicCalendarCountry) *CalendarJob {
+	j.countries = countries
+	return j
+}
 
-			defer tx.Finish()
-			defer hub.Flush(2 * time.Second)
-			defer hub.Recover(nil)
+// PublishPolls sets the max number of forecast polls (e.g. "Will CPI come in above 3.2%?") published
+// after the daily plan for the high-impact events with forecast. Polls are resolved by the updates job.
+func (j *CalendarJob) PublishPolls(limit int) *CalendarJob {
+	j.pollsLimit = limit
+	return j
+}
 
-			// Create events plan for the current day
-			from := time.Now().Truncate(24 * time.Hour)
-			to := from.Add(23 * time.Hour).Add(59 * time.Minute).Add(59 * time.Second)
-			span := tx.StartChild("EconomicCalendar.Fetch")
-			events, err := j.calendarScavenger.Fetch(ctx, from, to)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("[job-calendar] Error fetching events: %w", err)
-				j.logger.Error(e.Error())
-				utils.CaptureSentryException("calendarJobFetchError", hub, e)
-				return e
-			}
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  fmt.Sprintf("EconomicCalendar.Fetch returned %d events", len(events)),
-				Level:    sentry.LevelInfo,
-			}, nil)
-			if len(events) == 0 {
-				return nil
-			}
+// WithTheme sets the icons of the calendar posts (DefaultTheme if nil).
+func (j *CalendarJob) WithTheme(t *Theme) *CalendarJob {
+	if t != nil {
+		j.theme = t
+	}
+	return j
+}
 
-			// Format events to the text
-			m := formatDailyEvents(events)
+// NormalizeTitles maps the fetched event titles (e.g. from the German source) to the standard English names
+// with the table (case-insensitive source title -> name) and optional AI fallback for the titles missing in it.
+func (j *CalendarJob) NormalizeTitles(table map[string]string, ai titleNormalizer) *CalendarJob {
+	if len(table) > 0 || ai != nil {
+		j.titles = newEventTitles(table, ai, j.logger)
+	}
+	return j
+}
 
-			// Publish events to the channel
-			span = tx.StartChild("TelegramPublisher.Publish")
-			_, err = j.publisher.Publish(m)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("[job-calendar] Error publishing events: %w", err)
-				j.logger.Error(e.Error())
-				utils.CaptureSentryException("calendarJobPublishError", hub, e)
-				// Note: Unrecoverable error, because Telegram API often hangs up, but somehow publishes the message
-				return retry.Unrecoverable(e) //nolint:wrapcheck
-			}
+// AppendFXPairs appends the hashtags of the FX pairs of the impacted currency (e.g. #eurusd, #usdjpy for USD)
+// to the updates of the events with the actual value deviated from the forecast by more than the threshold
+// (e.g. 0.25 for 25%). If q is set, the current pair quotes are added too.
+func (j *CalendarJob) AppendFXPairs(threshold float64, q *quotes.Quotes) *CalendarJob {
+	j.fxThreshold = threshold
+	j.fxQuotes = q
+	return j
+}
 
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  "Calendar published successfully",
-				Level:    sentry.LevelInfo,
-			}, nil)
+// EditPlan makes the updates job edit the daily plan message ("Economic calendar for today") in place with
+// the actual values of the events instead of posting them as separate updates. The actual values of the events
+// whose plan can't be edited (e.g. the publisher can't edit messages) are still posted as updates.
+func (j *CalendarJob) EditPlan() *CalendarJob {
+	j.editPlan = true
+	return j
+}
 
-			mappedEvents := make([]*archivist.Event, 0, len(events))
-			for _, e := range events {
-				mappedEvents = append(mappedEvents, mapEventToDB(e, j.publisher.ChannelID, j.providerName))
-			}
+// RunDailyCalendarJob creates events plan for the upcoming day and publishes them to the channel.
+// It should be run every business day.
+func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
+	return func() error {
+		return retry.Do(func() error {
+			return runInstrumented("calendar", defaultJobTimeout, func(ctx context.Context, r *JobRun) error {
+				tx, hub := r.Tx, r.Hub
+				r.SetChannel(j.publisher.Channel())
+				j.logger.Info("[calendar] Running daily plan")
+
+				// Create events plan for the current day
+				from := time.Now().Truncate(24 * time.Hour)
+				to := from.Add(23 * time.Hour).Add(59 * time.Minute).Add(59 * time.Second)
+				span := tx.StartChild("EconomicCalendar.Fetch")
+				events, err := j.calendarScavenger.Fetch(ctx, from, to)
+				span.Finish()
+				if err != nil {
+					e := fmt.Errorf("[job-calendar] Error fetching events: %w", err)
+					j.logger.Error(e.Error())
+					utils.CaptureSentryException("calendarJobFetchError", hub, e)
+					return e
+				}
+				events = events.FilterByCountries(j.countries)
+				j.normalizeTitles(ctx, hub, events)
+				hub.AddBreadcrumb(&sentry.Breadcrumb{
+					Category: "successful",
+					Message:  fmt.Sprintf("EconomicCalendar.Fetch returned %d events", len(events)),
+					Level:    sentry.LevelInfo,
+				}, nil)
+				if len(events) == 0 {
+					return nil
+				}
 
-			span = tx.StartChild("Archivist.CreateEvents")
-			err = j.archivist.Entities.Events.Create(ctx, mappedEvents)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("[job-calendar] Error saving events: %w", err)
-				j.logger.Error(e.Error())
-				utils.CaptureSentryException("calendarJobSaveError", hub, e)
-				return retry.Unrecoverable(e) //nolint:wrapcheck
-			}
+				// Format events to the text
+				m := formatDailyEvents(events, j.theme, false)
+
+				// Publish events to the channel
+				span = tx.StartChild("TelegramPublisher.Publish")
+				planID, err := j.publisher.Publish(publisher.Markdown(m))
+				span.Finish()
+				if err != nil {
+					e := fmt.Errorf("[job-calendar] Error publishing events: %w", err)
+					j.logger.Error(e.Error())
+					utils.CaptureSentryException("calendarJobPublishError", hub, e)
+					// Note: Unrecoverable error, because Telegram API often hangs up, but somehow publishes the message
+					return retry.Unrecoverable(e) //nolint:wrapcheck
+				}
 
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  fmt.Sprintf("Events.Create saved %d events", len(mappedEvents)),
-				Level:    sentry.LevelInfo,
-			}, nil)
+				hub.AddBreadcrumb(&sentry.Breadcrumb{
+					Category: "successful",
+					Message:  "Calendar published successfully",
+					Level:    sentry.LevelInfo,
+				}, nil)
+
+				mappedEvents := make([]*archivist.Event, 0, len(events))
+				for _, e := range events {
+					event := mapEventToDB(e, j.publisher.Channel(), j.providerName)
+					event.PlanID = planID
+					mappedEvents = append(mappedEvents, event)
+				}
+
+				span = tx.StartChild("Archivist.CreateEvents")
+				err = j.archivist.Entities.Events.Create(ctx, mappedEvents)
+				span.Finish()
+				if err != nil {
+					e := fmt.Errorf("[job-calendar] Error saving events: %w", err)
+					j.logger.Error(e.Error())
+					utils.CaptureSentryException("calendarJobSaveError", hub, e)
+					return retry.Unrecoverable(e) //nolint:wrapcheck
+				}
 
-			return nil
+				hub.AddBreadcrumb(&sentry.Breadcrumb{
+					Category: "successful",
+					Message:  fmt.Sprintf("Events.Create saved %d events", len(mappedEvents)),
+					Level:    sentry.LevelInfo,
+				}, nil)
+
+				j.publishPolls(ctx, tx, hub, events)
+
+				return nil
+			})
 		},
 			retry.Attempts(5),
 			retry.Delay(10*time.Minute),
@@ -135,32 +197,16 @@ func (j *CalendarJob) RunDailyCalendarJob() JobFunc {
 
 // RunCalendarUpdatesJob fetches "Actual" values for today's events and publishes updates to the channel.
 func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
-	return func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
-		defer cancel()
-
-		tx := sentry.StartTransaction(ctx, "RunCalendarUpdatesJob")
-		tx.Op = "job-calendar-updates"
-
-		// Sentry performance monitoring
-		hub := sentry.GetHubFromContext(ctx)
-		if hub == nil {
-			hub = sentry.CurrentHub().Clone()
-			ctx = sentry.SetHubOnContext(ctx, hub)
-		}
-
-		defer tx.Finish()
-		defer hub.Flush(2 * time.Second)
-		defer hub.Recover(nil)
+	return WithInstrumentation("calendar-updates", func(ctx context.Context, r *JobRun) {
+		tx, hub := r.Tx, r.Hub
+		r.SetChannel(j.publisher.Channel())
 
 		// Fetch eventsDB for today from the database
 		span := tx.StartChild("Archivist.FindRecentEventsWithoutValue")
 		eventsDB, err := j.archivist.Entities.Events.FindRecentEventsWithoutValue(ctx)
 		span.Finish()
 		if err != nil {
-			e := fmt.Errorf("[job-calendar-updates] Error fetching eventsDB: %w", err)
-			j.logger.Error(e.Error())
-			utils.CaptureSentryException("calendarUpdatesJobFindRecentError", hub, e)
+			_ = r.Fail("calendarUpdatesJobFindRecentError", "Error fetching eventsDB", err)
 			return
 		}
 		hub.AddBreadcrumb(&sentry.Breadcrumb{
@@ -172,18 +218,20 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 			return
 		}
 
-		// Fetch eventsDB for today from the calendar
+		// Fetch events around the pending ones from the calendar
+		from, to, ok := updatesWindow(eventsDB, time.Now())
+		if !ok {
+			return
+		}
 		span = tx.StartChild("EconomicCalendar.Fetch")
-		from := time.Now().Truncate(24 * time.Hour)
-		to := from.Add(23 * time.Hour).Add(59 * time.Minute).Add(59 * time.Second)
 		calendarEvents, err := j.calendarScavenger.Fetch(ctx, from, to)
 		span.Finish()
 		if err != nil {
-			e := fmt.Errorf("[job-calendar-updates] Error fetching events from provider: %w", err)
-			j.logger.Error(e.Error())
-			utils.CaptureSentryException("calendarUpdatesJobFetchError", hub, e)
+			_ = r.Fail("calendarUpdatesJobFetchError", "Error fetching events from provider", err)
 			return
 		}
+		calendarEvents = calendarEvents.FilterByCountries(j.countries)
+		j.normalizeTitles(ctx, hub, calendarEvents)
 		hub.AddBreadcrumb(&sentry.Breadcrumb{
 			Category: "successful",
 			Message:  fmt.Sprintf("EconomicCalendar.Fetch returned %d eventsDB", len(calendarEvents)),
@@ -204,27 +252,29 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 		// Update eventsDB with actual values
 		var updatedEventsDB []*archivist.Event
 		for _, e := range eventsDB {
-			for _, ce := range calendarEvents {
-				if e.Country != ce.Country || e.Currency != ce.Currency || e.Title != ce.Title || ce.Actual == "" {
-					continue
-				}
-				ev := &archivist.Event{
-					ID:           e.ID,
-					ChannelID:    e.ChannelID,
-					ProviderName: e.ProviderName,
-					DateTime:     e.DateTime,
-					Country:      e.Country,
-					Currency:     e.Currency,
-					Impact:       e.Impact,
-					Title:        e.Title,
-					Forecast:     ce.Forecast,
-					Previous:     ce.Previous,
-					Actual:       ce.Actual,
-					UpdatedAt:    time.Now(),
-				}
-
-				updatedEventsDB = append(updatedEventsDB, ev)
+			ce := matchEvent(e, calendarEvents)
+			if ce == nil || ce.Actual == "" {
+				continue
+			}
+			ev := &archivist.Event{
+				ID:           e.ID,
+				ChannelID:    e.ChannelID,
+				ProviderName: e.ProviderName,
+				ProviderID:   e.ProviderID,
+				DateTime:     e.DateTime,
+				Country:      e.Country,
+				Currency:     e.Currency,
+				Impact:       e.Impact,
+				Title:        e.Title,
+				Forecast:     ce.Forecast,
+				Previous:     ce.Previous,
+				Actual:       ce.Actual,
+				PollID:       e.PollID,
+				PlanID:       e.PlanID,
+				UpdatedAt:    time.Now(),
 			}
+
+			updatedEventsDB = append(updatedEventsDB, ev)
 		}
 
 		// TODO: add update many method to archivist with transaction
@@ -233,9 +283,7 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 			err = j.archivist.Entities.Events.Update(ctx, event)
 			span.Finish()
 			if err != nil {
-				e := fmt.Errorf("[job-calendar-updates] Error updating event: %w", err)
-				j.logger.Error(e.Error())
-				utils.CaptureSentryException("calendarUpdatesJobUpdateEventError", hub, e)
+				_ = r.Fail("calendarUpdatesJobUpdateEventError", "Error updating event", err)
 				return
 			}
 		}
@@ -246,26 +294,76 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 			Level:    sentry.LevelInfo,
 		}, nil)
 
+		// Skip the actual values already announced (e.g. by the retried or concurrent run)
+		announced := make([]*archivist.Event, 0, len(updatedEventsDB))
+		for _, event := range updatedEventsDB {
+			span = tx.StartChild("Archivist.MarkActualPublished")
+			ok, err := j.archivist.Entities.Events.MarkActualPublished(ctx, event.ID, time.Now())
+			span.Finish()
+			if err != nil {
+				_ = r.Fail("calendarUpdatesJobMarkPublishedError", "Error marking event actual as published", err)
+				return
+			}
+			if ok {
+				announced = append(announced, event)
+			}
+		}
+		updatedEventsDB = announced
+
+		// Edit the daily plans in place, only the actual values of the events whose plan wasn't edited are posted
+		posted := updatedEventsDB
+		if j.editPlan {
+			posted = j.editPlans(ctx, tx, hub, updatedEventsDB)
+		}
+
+		// Render the last readings of each indicator (not critical, skip the series on errors)
+		series := make(map[uuid.UUID]string, len(posted))
+		for _, e := range posted {
+			span = tx.StartChild("Archivist.FindSeries")
+			readings, err := j.archivist.Entities.Events.FindSeries(
+				ctx,
+				e.ChannelID,
+				archivist.SeriesKey(e.Title, e.Country),
+				e.DateTime,
+				seriesLength,
+			)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("[job-calendar-updates] Error fetching event series: %w", err)
+				j.logger.Warn(e.Error())
+				utils.CaptureSentryException("calendarUpdatesJobFindSeriesError", hub, e)
+				continue
+			}
+			series[e.ID] = formatSeries(readings)
+		}
+
 		// Group events by country
 		eventsByCountry := make(map[ecal.EconomicCalendarCountry][]*archivist.Event)
-		for _, e := range updatedEventsDB {
+		for _, e := range posted {
 			eventsByCountry[e.Country] = append(eventsByCountry[e.Country], e)
 		}
 
 		// Publish eventsDB to the channel
 		for country, events := range eventsByCountry {
-			m := formatEventsUpdate(country, events)
+			m := formatEventsUpdate(country, events, series, j.theme)
 			if m == "" {
 				continue
 			}
+			if j.fxThreshold > 0 {
+				if pairs := surprisePairs(events, j.fxThreshold); len(pairs) > 0 {
+					var fxQuotes map[string]*quotes.Intraday
+					if j.fxQuotes != nil {
+						fxQuotes = j.fetchFXQuotes(ctx, hub, pairs)
+					}
+					m += "\n" + formatFXPairs(pairs, fxQuotes, j.theme)
+				}
+			}
 
 			span = tx.StartChild("TelegramPublisher.Publish")
-			_, err := j.publisher.Publish(m)
+			_, err := j.publisher.Publish(publisher.Markdown(m))
 			span.Finish()
 			if err != nil {
-				e := fmt.Errorf("[job-calendar-updates] Error publishing event: %w", err)
-				j.logger.Error(e.Error())
-				utils.CaptureSentryException("calendarUpdatesJobPublishError", hub, e)
+				_ = r.Fail("calendarUpdatesJobPublishError", "Error publishing event", err)
 				return
 			}
 		}
@@ -275,41 +373,239 @@ func (j *CalendarJob) RunCalendarUpdatesJob() JobFunc {
 			Message:  fmt.Sprintf("TelegramPublisher.Publish published %d events", len(eventsByCountry)),
 			Level:    sentry.LevelInfo,
 		}, nil)
+
+		j.resolvePolls(tx, hub, updatedEventsDB)
+	})
+}
+
+// editPlans edits the daily plan messages of the events with their actual values. Returns the events whose plan
+// can't be edited (e.g. the plan was published before its ID was saved or the edit failed), so they are posted as updates.
+func (j *CalendarJob) editPlans(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, events []*archivist.Event) []*archivist.Event {
+	editor, ok := j.publisher.(publisher.EditPublisher)
+	if !ok {
+		return events
+	}
+
+	var rest []*archivist.Event
+	plans := make(map[string][]*archivist.Event)
+	for _, e := range events {
+		if e.PlanID == "" {
+			rest = append(rest, e)
+			continue
+		}
+		plans[e.PlanID] = append(plans[e.PlanID], e)
+	}
+
+	var edited int
+	for planID, updated := range plans {
+		span := tx.StartChild("Archivist.FindPlan")
+		plan, err := j.archivist.Entities.Events.FindPlan(ctx, updated[0].ChannelID, planID)
+		span.Finish()
+		if err == nil && len(plan) == 0 {
+			err = errors.New("events of the plan are not found")
+		}
+		if err == nil {
+			span = tx.StartChild("TelegramPublisher.Edit")
+			err = editor.Edit(planID, publisher.Markdown(formatDailyEvents(mapEventsFromDB(plan), j.theme, true)))
+			span.Finish()
+		}
+		if err != nil {
+			e := fmt.Errorf("[job-calendar-updates] Error editing daily plan %s: %w", planID, err)
+			j.logger.Warn(e.Error())
+			utils.CaptureSentryException("calendarUpdatesJobEditPlanError", hub, e)
+			rest = append(rest, updated...)
+			continue
+		}
+		edited++
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("TelegramPublisher.Edit edited %d daily plans", edited),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	return rest
+}
+
+// publishPolls publishes forecast polls for the high-impact events and saves their IDs for the resolution.
+// Polls are not critical, so errors are only reported. Skipped if the publisher can't publish polls.
+func (j *CalendarJob) publishPolls(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, events ecal.EconomicCalendarEvents) {
+	polls, ok := j.publisher.(publisher.PollPublisher)
+	if !ok || j.pollsLimit <= 0 {
+		return
+	}
+
+	var published int
+	for _, e := range pollEvents(events, j.pollsLimit) {
+		question, options := formatPoll(e, j.theme)
+
+		span := tx.StartChild("TelegramPublisher.PublishPoll")
+		pollID, err := polls.PublishPoll(question, options)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-calendar] Error publishing poll: %w", err)
+			j.logger.Warn(e.Error())
+			utils.CaptureSentryException("calendarJobPublishPollError", hub, e)
+			continue
+		}
+		published++
+		if pollID == "" {
+			continue
+		}
+
+		span = tx.StartChild("Archivist.SetPollID")
+		err = j.archivist.Entities.Events.SetPollID(ctx, mapEventToDB(e, j.publisher.Channel(), j.providerName), pollID)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[job-calendar] Error saving poll ID: %w", err)
+			j.logger.Warn(e.Error())
+			utils.CaptureSentryException("calendarJobSavePollError", hub, e)
+		}
+	}
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf("TelegramPublisher.PublishPoll published %d polls", published),
+		Level:    sentry.LevelInfo,
+	}, nil)
+}
+
+// resolvePolls closes the forecast polls of the events with actual values and replies to them with the result.
+// Polls are not critical, so errors are only reported.
+func (j *CalendarJob) resolvePolls(tx *sentry.Span, hub *sentry.Hub, events []*archivist.Event) {
+	polls, ok := j.publisher.(publisher.PollPublisher)
+	if !ok {
+		return
+	}
+
+	for _, e := range events {
+		if e.PollID == "" {
+			continue
+		}
+
+		span := tx.StartChild("TelegramPublisher.StopPoll")
+		err := polls.StopPoll(e.PollID)
+		span.Finish()
+		if err != nil {
+			// The poll can be already closed, the resolution is still useful
+			err := fmt.Errorf("[job-calendar-updates] Error stopping poll: %w", err)
+			j.logger.Warn(err.Error())
+			utils.CaptureSentryException("calendarUpdatesJobStopPollError", hub, err)
+		}
+
+		span = tx.StartChild("TelegramPublisher.PublishReply")
+		_, err = polls.PublishReply(publisher.Markdown(formatPollResolution(e, j.theme)), e.PollID)
+		span.Finish()
+		if err != nil {
+			err := fmt.Errorf("[job-calendar-updates] Error publishing poll resolution: %w", err)
+			j.logger.Warn(err.Error())
+			utils.CaptureSentryException("calendarUpdatesJobResolvePollError", hub, err)
+		}
+	}
+}
+
+// pollEvents returns up to limit high-impact indicator events with forecast and the exact time.
+func pollEvents(events ecal.EconomicCalendarEvents, limit int) ecal.EconomicCalendarEvents {
+	var result ecal.EconomicCalendarEvents
+	for _, e := range events {
+		if len(result) >= limit {
+			break
+		}
+		if e.Impact != ecal.EconomicCalendarImpactHigh || e.Forecast == "" || e.AllDay || e.Tentative ||
+			slices.Contains(ecal.TypesWithoutValues(), e.EventType) {
+			continue
+		}
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// normalizeTitles normalizes the event titles if the normalization is enabled.
+func (j *CalendarJob) normalizeTitles(ctx context.Context, hub *sentry.Hub, events ecal.EconomicCalendarEvents) {
+	if j.titles != nil {
+		j.titles.normalize(ctx, hub, events)
+	}
+}
+
+// formatPoll returns the forecast poll question and answer options for the event.
+func formatPoll(e *ecal.EconomicCalendarEvent, theme *Theme) (question string, options []string) {
+	question = withIcons(fmt.Sprintf("Will %s come in above %s?", e.Title, e.Forecast), theme.country(e.Country))
+	return question, []string{"Above " + e.Forecast, "In line", "Below " + e.Forecast}
+}
+
+// formatPollResolution formats the actual value of the event compared to the forecast for the poll follow-up.
+func formatPollResolution(e *archivist.Event, theme *Theme) string {
+	actual, forecast := signedValue(e.Actual), signedValue(e.Forecast)
+
+	var result string
+	switch {
+	case actual > forecast:
+		result = "above"
+	case actual < forecast:
+		result = "below"
+	default:
+		result = "in line with"
+	}
+
+	return withIcons(fmt.Sprintf("%s came in at *%s*, %s the %s forecast", e.Title, e.Actual, result, e.Forecast), theme.Poll)
+}
+
+// signedValue converts the event value (e.g. "-0.5%") to float keeping its sign.
+func signedValue(v string) float64 {
+	f := utils.StrValueToFloat(v)
+	if strings.HasPrefix(strings.TrimSpace(v), "-") {
+		return -f
 	}
+
+	return f
 }
 
 // formatDailyEvents formats events to the text for publishing to the telegram channel.
-func formatDailyEvents(events ecal.EconomicCalendarEvents) string {
+// Days with holidays only get the compact "markets closed" message instead of the plan (see formatHolidays).
+// The actual values of the events are printed only if withActual is true (the plan edited by the updates job).
+func formatDailyEvents(events ecal.EconomicCalendarEvents, theme *Theme, withActual bool) string {
 	// Handle empty events case
 	if len(events) == 0 {
 		return ""
 	}
 
+	if holidaysOnly(events) {
+		return formatHolidays(events, theme)
+	}
+
 	var m strings.Builder
 
 	// Build header
-	m.WriteString("📅 Economic calendar for today\n\n")
+	m.WriteString(withIcons("Economic calendar for today", theme.CalendarHeader) + "\n\n")
+
+	// Events without the exact time are printed in separate sections instead of a bogus "00:00"
+	var allDay, tentative ecal.EconomicCalendarEvents
 
 	// Iterate through events
 	for _, e := range events {
-		// Add event
-		country := ecal.GetCountryEmoji(e.Country)
-
-		// Print holiday events without time
-		if e.Impact == ecal.EconomicCalendarImpactHoliday {
-			m.WriteString(fmt.Sprintf("%s %s\n", country, e.Title))
-		} else {
-			m.WriteString(fmt.Sprintf("%s %s %s", country, e.DateTime.Format("15:04"), e.Title))
+		switch {
+		case e.AllDay:
+			allDay = append(allDay, e)
+		case e.Tentative:
+			tentative = append(tentative, e)
+		default:
+			writeDailyEvent(&m, e, true, withActual, theme)
+		}
+	}
 
-			// Print forecast and previous values if they are not empty
-			if e.Forecast != "" {
-				m.WriteString(fmt.Sprintf(", forecast: %s", e.Forecast))
-			}
-			if e.Previous != "" {
-				m.WriteString(fmt.Sprintf(", last: %s", e.Previous))
-			}
+	if len(allDay) > 0 {
+		m.WriteString("\nAll day:\n")
+		for _, e := range allDay {
+			writeDailyEvent(&m, e, false, withActual, theme)
+		}
+	}
 
-			m.WriteString("\n")
+	if len(tentative) > 0 {
+		m.WriteString("\nTime to be announced:\n")
+		for _, e := range tentative {
+			writeDailyEvent(&m, e, false, withActual, theme)
 		}
 	}
 
@@ -319,7 +615,73 @@ func formatDailyEvents(events ecal.EconomicCalendarEvents) string {
 	return m.String()
 }
 
-func formatEventsUpdate(country ecal.EconomicCalendarCountry, events []*archivist.Event) string {
+// holidaysOnly returns true if all events are holidays.
+func holidaysOnly(events ecal.EconomicCalendarEvents) bool {
+	for _, e := range events {
+		if e.Impact != ecal.EconomicCalendarImpactHoliday {
+			return false
+		}
+	}
+	return len(events) > 0
+}
+
+// formatHolidays formats the holidays of the day as the compact "markets closed" message with the holiday countries.
+func formatHolidays(events ecal.EconomicCalendarEvents, theme *Theme) string {
+	var m strings.Builder
+	m.WriteString(withIcons("Markets closed today:", theme.CalendarHeader) + "\n")
+	for _, e := range events {
+		m.WriteString(withIcons(e.Title, theme.country(e.Country)) + "\n")
+	}
+	m.WriteString("#calendar #holiday")
+
+	return m.String()
+}
+
+// writeDailyEvent writes a single event line of the daily plan (with the event time if withTime is true
+// and the actual value if withActual is true).
+func writeDailyEvent(m *strings.Builder, e *ecal.EconomicCalendarEvent, withTime, withActual bool, theme *Theme) {
+	country := theme.country(e.Country)
+
+	// Print holiday events without time
+	if e.Impact == ecal.EconomicCalendarImpactHoliday {
+		m.WriteString(withIcons(e.Title, country) + "\n")
+		return
+	}
+
+	title := e.Title
+	if e.EventType == ecal.EconomicCalendarTypeSpeech {
+		title = withIcons(title, theme.Speech)
+	}
+
+	if withTime {
+		m.WriteString(withIcons(title, country, e.DateTime.Format("15:04")))
+	} else {
+		m.WriteString(withIcons(title, country))
+	}
+
+	// Print the actual value of the plan edited by the updates job
+	if withActual && e.Actual != "" {
+		m.WriteString(fmt.Sprintf(": *%s*", e.Actual))
+	}
+
+	// Print forecast and previous values if they are not empty
+	if e.Forecast != "" {
+		m.WriteString(fmt.Sprintf(", forecast: %s", e.Forecast))
+	}
+	if e.Previous != "" {
+		m.WriteString(fmt.Sprintf(", last: %s", e.Previous))
+	}
+
+	m.WriteString("\n")
+}
+
+// formatEventsUpdate formats updated events of the country with optional series sparklines (by event ID).
+func formatEventsUpdate(
+	country ecal.EconomicCalendarCountry,
+	events []*archivist.Event,
+	series map[uuid.UUID]string,
+	theme *Theme,
+) string {
 	// Handle nil event case
 	if len(events) == 0 {
 		return ""
@@ -329,9 +691,7 @@ func formatEventsUpdate(country ecal.EconomicCalendarCountry, events []*archivis
 	var m strings.Builder
 
 	// Add country emoji and hashtag
-	countryEmoji := ecal.GetCountryEmoji(country)
-	countryHashtag := ecal.GetCountryHashtag(country)
-	m.WriteString(fmt.Sprintf("%s #%s\n", countryEmoji, countryHashtag))
+	m.WriteString(countryHeader(country, theme) + "\n")
 
 	// Iterate through events
 	for i, event := range events {
@@ -341,13 +701,31 @@ func formatEventsUpdate(country ecal.EconomicCalendarCountry, events []*archivis
 		}
 
 		// Add event
-		m.WriteString(formatEvent(event))
+		m.WriteString(formatEvent(event, theme))
+
+		// Add the last readings of the indicator
+		if s := series[event.ID]; s != "" {
+			m.WriteString("\n" + withIcons(s, theme.Series))
+		}
 	}
 
 	return m.String()
 }
 
-func formatEvent(event *archivist.Event) string {
+// countryHeader returns the country icon and hashtag. Countries without the hashtag (e.g. unknown country codes)
+// are named instead, unless the theme already shows the country names.
+func countryHeader(country ecal.EconomicCalendarCountry, theme *Theme) string {
+	icon := theme.country(country)
+	if tag := ecal.GetCountryHashtag(country); tag != "" {
+		return withIcons("#"+tag, icon)
+	}
+	if icon == string(country) {
+		return icon
+	}
+	return withIcons(string(country), icon)
+}
+
+func formatEvent(event *archivist.Event, theme *Theme) string {
 	var ev strings.Builder
 
 	actualNumber := utils.StrValueToFloat(event.Actual)
@@ -358,9 +736,9 @@ func formatEvent(event *archivist.Event) string {
 	if (event.Previous != "" && actualNumber != previousNumber) ||
 		(event.Forecast != "" && actualNumber != forecastNumber) {
 		if event.Impact == ecal.EconomicCalendarImpactHigh {
-			ev.WriteString("🔥 ")
+			ev.WriteString(withIcons("", theme.HighImpact))
 		} else {
-			ev.WriteString("⚠️ ")
+			ev.WriteString(withIcons("", theme.Surprise))
 		}
 	}
 
@@ -391,6 +769,97 @@ func formatEvent(event *archivist.Event) string {
 	return ev.String()
 }
 
+// updatesWindow returns the fetch window of the updates job: today's pending events (without actual value)
+// with the updatesWindowMargin, rounded to the hours to reuse the cached responses between the runs.
+// Returns false if there are no pending events today.
+func updatesWindow(pending []*archivist.Event, now time.Time) (from, to time.Time, ok bool) {
+	dayStart := now.UTC().Truncate(24 * time.Hour)
+	dayEnd := dayStart.Add(24*time.Hour - time.Second)
+
+	for _, e := range pending {
+		dt := e.DateTime.UTC()
+		if dt.Before(dayStart) || dt.After(dayEnd) {
+			continue
+		}
+		if !ok || dt.Before(from) {
+			from = dt
+		}
+		if !ok || dt.After(to) {
+			to = dt
+		}
+		ok = true
+	}
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	from = from.Add(-updatesWindowMargin).Truncate(time.Hour)
+	if from.Before(dayStart) {
+		from = dayStart
+	}
+	to = to.Add(updatesWindowMargin).Truncate(time.Hour).Add(time.Hour)
+	if to.After(dayEnd) {
+		to = dayEnd
+	}
+
+	return from, to, true
+}
+
+// matchEvent finds the fetched calendar event of the stored one by the provider ID. Events without the ID
+// (e.g. stored before the IDs were introduced or fetched from the HTML fallback) are matched by the country,
+// currency and the most similar title, so small title tweaks of the provider don't break the updates.
+// Returns nil if no event matches.
+func matchEvent(e *archivist.Event, events ecal.EconomicCalendarEvents) *ecal.EconomicCalendarEvent {
+	if e.ProviderID != "" {
+		for _, ce := range events {
+			if ce.ID == e.ProviderID {
+				return ce
+			}
+		}
+	}
+
+	var best *ecal.EconomicCalendarEvent
+	var bestScore float64
+	for _, ce := range events {
+		if e.Country != ce.Country || e.Currency != ce.Currency {
+			continue
+		}
+		if score := titleSimilarity(e.Title, ce.Title); score >= minTitleSimilarity && score > bestScore {
+			best, bestScore = ce, score
+		}
+	}
+
+	return best
+}
+
+// titleSimilarity returns the share of the common words of the titles (Jaccard index, 0..1),
+// titles are compared case-insensitive without punctuation.
+func titleSimilarity(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		set := make(map[string]bool)
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			set[w] = true
+		}
+		return set
+	}
+
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for w := range wa {
+		if wb[w] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(wa)+len(wb)-common)
+}
+
 // mapEventToDB maps calendar event to the database event instance.
 // One crucial thing is that we use actual date if event time is available.
 // There is no need to store 2 event dates in the database.
@@ -405,6 +874,7 @@ func mapEventToDB(e *ecal.EconomicCalendarEvent, channelID, providerName string)
 	return &archivist.Event{
 		ChannelID:    channelID,
 		ProviderName: providerName,
+		ProviderID:   e.ID,
 		DateTime:     dt,
 		Country:      e.Country,
 		Currency:     e.Currency,
@@ -412,5 +882,30 @@ func mapEventToDB(e *ecal.EconomicCalendarEvent, channelID, providerName string)
 		Title:        e.Title,
 		Forecast:     e.Forecast,
 		Previous:     e.Previous,
+		EventType:    e.EventType,
+		AllDay:       e.AllDay,
+		Tentative:    e.Tentative,
+	}
+}
+
+// mapEventsFromDB maps the database events to the calendar events, e.g. to render the daily plan again.
+func mapEventsFromDB(events []*archivist.Event) ecal.EconomicCalendarEvents {
+	mapped := make(ecal.EconomicCalendarEvents, 0, len(events))
+	for _, e := range events {
+		mapped = append(mapped, &ecal.EconomicCalendarEvent{
+			ID:        e.ProviderID,
+			DateTime:  e.DateTime,
+			Country:   e.Country,
+			Currency:  e.Currency,
+			Impact:    e.Impact,
+			Title:     e.Title,
+			Actual:    e.Actual,
+			Forecast:  e.Forecast,
+			Previous:  e.Previous,
+			EventType: e.EventType,
+			AllDay:    e.AllDay,
+			Tentative: e.Tentative,
+		})
 	}
+	return mapped
 }
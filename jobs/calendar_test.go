@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/scavenger/ecal"
 	"reflect"
@@ -93,6 +94,91 @@ func Test_formatDailyEvents(t *testing.T) {
 				"*Time is in UTC*\n" +
 				"#calendar #economy",
 		},
+		{
+			name: "case all day, tentative and speech events",
+			args: args{
+				events: ecal.EconomicCalendarEvents{
+					{
+						DateTime: time.Date(2023, time.April, 10, 0, 0, 0, 0, time.UTC),
+						Country:  ecal.EconomicCalendarJapan,
+						Currency: ecal.EconomicCalendarJPY,
+						Impact:   ecal.EconomicCalendarImpactHigh,
+						Title:    "BoJ Policy Statement",
+						AllDay:   true,
+					},
+					{
+						DateTime:  time.Date(2023, time.April, 10, 0, 0, 0, 0, time.UTC),
+						Country:   ecal.EconomicCalendarUnitedStates,
+						Currency:  ecal.EconomicCalendarUSD,
+						Impact:    ecal.EconomicCalendarImpactHigh,
+						Title:     "Treasury Refunding Announcement",
+						Previous:  "$102b",
+						Tentative: true,
+					},
+					{
+						DateTime: time.Date(2023, time.April, 10, 0, 0, 0, 0, time.UTC),
+						Country:  ecal.EconomicCalendarUnitedKingdom,
+						Currency: ecal.EconomicCalendarGBP,
+						Impact:   ecal.EconomicCalendarImpactHoliday,
+						Title:    "Easter Monday",
+						AllDay:   true,
+					},
+					{
+						DateTime: time.Date(2023, time.April, 10, 12, 30, 0, 0, time.UTC),
+						Country:  ecal.EconomicCalendarUnitedStates,
+						Currency: ecal.EconomicCalendarUSD,
+						Impact:   ecal.EconomicCalendarImpactHigh,
+						Title:    "CPI Announcement",
+						Forecast: "2.9%",
+					},
+					{
+						DateTime:  time.Date(2023, time.April, 10, 14, 0, 0, 0, time.UTC),
+						Country:   ecal.EconomicCalendarUnitedStates,
+						Currency:  ecal.EconomicCalendarUSD,
+						Impact:    ecal.EconomicCalendarImpactHigh,
+						Title:     "Fed Chair Powell Speech",
+						EventType: ecal.EconomicCalendarTypeSpeech,
+					},
+				},
+			},
+			want: "📅 Economic calendar for today\n\n" +
+				"🇺🇸 12:30 CPI Announcement, forecast: 2.9%\n" +
+				"🇺🇸 14:00 🎙️ Fed Chair Powell Speech\n" +
+				"\nAll day:\n" +
+				"🇯🇵 BoJ Policy Statement\n" +
+				"🇬🇧 Easter Monday\n" +
+				"\nTime to be announced:\n" +
+				"🇺🇸 Treasury Refunding Announcement, last: $102b\n" +
+				"*Time is in UTC*\n" +
+				"#calendar #economy",
+		},
+		{
+			name: "case holidays only",
+			args: args{
+				events: ecal.EconomicCalendarEvents{
+					{
+						DateTime: time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC),
+						Country:  ecal.EconomicCalendarUnitedStates,
+						Currency: ecal.EconomicCalendarUSD,
+						Impact:   ecal.EconomicCalendarImpactHoliday,
+						Title:    "Christmas Day",
+						AllDay:   true,
+					},
+					{
+						DateTime: time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC),
+						Country:  ecal.EconomicCalendarUnitedKingdom,
+						Currency: ecal.EconomicCalendarGBP,
+						Impact:   ecal.EconomicCalendarImpactHoliday,
+						Title:    "Christmas Day",
+						AllDay:   true,
+					},
+				},
+			},
+			want: "📅 Markets closed today:\n" +
+				"🇺🇸 Christmas Day\n" +
+				"🇬🇧 Christmas Day\n" +
+				"#calendar #holiday",
+		},
 		{
 			name: "case none events",
 			args: args{
@@ -103,7 +189,7 @@ func Test_formatDailyEvents(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatDailyEvents(tt.args.events)
+			got := formatDailyEvents(tt.args.events, DefaultTheme(), false)
 			if got != tt.want {
 				t.Errorf("formatDailyEvents() = %v, want %v", got, tt.want)
 			}
@@ -174,6 +260,29 @@ func Test_mapEventToDB(t *testing.T) {
 				Previous:     "2.8%",
 			},
 		},
+		{
+			name: "case 3 - tentative event",
+			args: args{
+				e: &ecal.EconomicCalendarEvent{
+					DateTime:  time.Date(2023, time.April, 10, 0, 0, 0, 0, time.UTC),
+					Currency:  ecal.EconomicCalendarUSD,
+					Impact:    ecal.EconomicCalendarImpactHigh,
+					Title:     "Treasury Refunding Announcement",
+					Tentative: true,
+				},
+				channelID:    "channel-id",
+				providerName: "provider-name",
+			},
+			want: &archivist.Event{
+				ChannelID:    "channel-id",
+				ProviderName: "provider-name",
+				DateTime:     time.Date(2023, time.April, 10, 0, 0, 0, 0, time.UTC),
+				Currency:     ecal.EconomicCalendarUSD,
+				Impact:       ecal.EconomicCalendarImpactHigh,
+				Title:        "Treasury Refunding Announcement",
+				Tentative:    true,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -184,10 +293,168 @@ func Test_mapEventToDB(t *testing.T) {
 	}
 }
 
+func Test_formatDailyEvents_editedPlan(t *testing.T) {
+	plan := []*archivist.Event{
+		{
+			DateTime: time.Date(2023, time.April, 10, 12, 30, 0, 0, time.UTC),
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Currency: ecal.EconomicCalendarUSD,
+			Impact:   ecal.EconomicCalendarImpactHigh,
+			Title:    "Core CPI m/m",
+			Actual:   "0.3%",
+			Forecast: "0.2%",
+			Previous: "0.3%",
+			PlanID:   "42",
+		},
+		{
+			DateTime: time.Date(2023, time.April, 10, 14, 0, 0, 0, time.UTC),
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Currency: ecal.EconomicCalendarUSD,
+			Impact:   ecal.EconomicCalendarImpactMedium,
+			Title:    "Crude Oil Inventories",
+			Forecast: "-1.2M",
+			PlanID:   "42",
+		},
+	}
+
+	want := "📅 Economic calendar for today\n\n" +
+		"🇺🇸 12:30 Core CPI m/m: *0.3%*, forecast: 0.2%, last: 0.3%\n" +
+		"🇺🇸 14:00 Crude Oil Inventories, forecast: -1.2M\n" +
+		"*Time is in UTC*\n" +
+		"#calendar #economy"
+	if got := formatDailyEvents(mapEventsFromDB(plan), DefaultTheme(), true); got != want {
+		t.Errorf("formatDailyEvents() = %q, want %q", got, want)
+	}
+
+	// The plan published after the release of the event doesn't print its actual value
+	want = "📅 Economic calendar for today\n\n" +
+		"🇺🇸 12:30 Core CPI m/m, forecast: 0.2%, last: 0.3%\n" +
+		"🇺🇸 14:00 Crude Oil Inventories, forecast: -1.2M\n" +
+		"*Time is in UTC*\n" +
+		"#calendar #economy"
+	if got := formatDailyEvents(mapEventsFromDB(plan), DefaultTheme(), false); got != want {
+		t.Errorf("formatDailyEvents() without actual values = %q, want %q", got, want)
+	}
+}
+
+func Test_updatesWindow(t *testing.T) {
+	now := time.Date(2024, 3, 12, 13, 30, 0, 0, time.UTC)
+	day := now.Truncate(24 * time.Hour)
+	at := func(h, m int) *archivist.Event {
+		return &archivist.Event{DateTime: day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)}
+	}
+
+	tests := []struct {
+		name     string
+		pending  []*archivist.Event
+		wantFrom time.Time
+		wantTo   time.Time
+		wantOk   bool
+	}{
+		{
+			name:     "around the pending events",
+			pending:  []*archivist.Event{at(14, 30), at(12, 15)},
+			wantFrom: day.Add(10 * time.Hour),
+			wantTo:   day.Add(17 * time.Hour),
+			wantOk:   true,
+		},
+		{
+			name:     "clamped to the day",
+			pending:  []*archivist.Event{at(0, 30), at(23, 0)},
+			wantFrom: day,
+			wantTo:   day.Add(24*time.Hour - time.Second),
+			wantOk:   true,
+		},
+		{
+			name:     "tomorrow events are skipped",
+			pending:  []*archivist.Event{at(9, 0), at(33, 0)},
+			wantFrom: day.Add(7 * time.Hour),
+			wantTo:   day.Add(12 * time.Hour),
+			wantOk:   true,
+		},
+		{name: "no events today", pending: []*archivist.Event{at(33, 0)}, wantOk: false},
+		{name: "no events", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := updatesWindow(tt.pending, now)
+			if ok != tt.wantOk || !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("updatesWindow() = %v, %v, %v, want %v, %v, %v", from, to, ok, tt.wantFrom, tt.wantTo, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_matchEvent(t *testing.T) {
+	cpi := &ecal.EconomicCalendarEvent{ID: "1", Country: ecal.EconomicCalendarUnitedStates, Currency: ecal.EconomicCalendarUSD, Title: "CPI m/m (Nov)"}
+	coreCPI := &ecal.EconomicCalendarEvent{ID: "2", Country: ecal.EconomicCalendarUnitedStates, Currency: ecal.EconomicCalendarUSD, Title: "Core CPI m/m"}
+	euCPI := &ecal.EconomicCalendarEvent{ID: "3", Country: ecal.EconomicCalendarEuropeanUnion, Currency: ecal.EconomicCalendarEUR, Title: "Core CPI y/y"}
+	events := ecal.EconomicCalendarEvents{cpi, coreCPI, euCPI}
+
+	tests := []struct {
+		name  string
+		event *archivist.Event
+		want  *ecal.EconomicCalendarEvent
+	}{
+		{
+			name:  "by provider ID despite the renamed title",
+			event: &archivist.Event{ProviderID: "2", Country: ecal.EconomicCalendarUnitedStates, Currency: ecal.EconomicCalendarUSD, Title: "Core Inflation"},
+			want:  coreCPI,
+		},
+		{
+			name:  "exact title without ID",
+			event: &archivist.Event{Country: ecal.EconomicCalendarUnitedStates, Currency: ecal.EconomicCalendarUSD, Title: "Core CPI m/m"},
+			want:  coreCPI,
+		},
+		{
+			name:  "similar title without ID",
+			event: &archivist.Event{Country: ecal.EconomicCalendarEuropeanUnion, Currency: ecal.EconomicCalendarEUR, Title: "Core CPI y/y (Nov)"},
+			want:  euCPI,
+		},
+		{
+			name:  "unknown ID falls back to title",
+			event: &archivist.Event{ProviderID: "42", Country: ecal.EconomicCalendarUnitedStates, Currency: ecal.EconomicCalendarUSD, Title: "CPI m/m (Nov)"},
+			want:  cpi,
+		},
+		{
+			name:  "different indicator",
+			event: &archivist.Event{Country: ecal.EconomicCalendarUnitedStates, Currency: ecal.EconomicCalendarUSD, Title: "Retail Sales m/m"},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchEvent(tt.event, events); got != tt.want {
+				t.Errorf("matchEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_titleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "Core CPI m/m", b: "core cpi M/M", want: 1},
+		{a: "Core CPI m/m", b: "Core CPI m/m (Nov)", want: 0.75},
+		{a: "CPI y/y", b: "CPI m/m", want: 1.0 / 3},
+		{a: "", b: "CPI m/m", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			if got := titleSimilarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("titleSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_formatEventsUpdate(t *testing.T) {
 	type args struct {
 		country ecal.EconomicCalendarCountry
 		events  []*archivist.Event
+		series  map[uuid.UUID]string
 	}
 	tests := []struct {
 		name string
@@ -354,12 +621,126 @@ func Test_formatEventsUpdate(t *testing.T) {
 			},
 			want: "🇩🇪 #germany\n🔥 Current Account n.s.a.: *€\u200b30.8b* (+54.00%), forecast: €\u200b21.7b, last: €\u200b20.0b",
 		},
+		{
+			name: "case 9 - with series",
+			args: args{
+				country: ecal.EconomicCalendarUnitedStates,
+				events: []*archivist.Event{
+					{
+						ID:       uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+						DateTime: time.Date(2023, time.April, 10, 12, 0, 0, 0, time.UTC),
+						Country:  ecal.EconomicCalendarUnitedStates,
+						Currency: ecal.EconomicCalendarUSD,
+						Impact:   ecal.EconomicCalendarImpactHigh,
+						Title:    "CPI Announcement",
+						Actual:   "2.9%",
+						Forecast: "2.9%",
+					},
+				},
+				series: map[uuid.UUID]string{
+					uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"): "▁▄█ (3 readings)",
+				},
+			},
+			want: "🇺🇸 #usa\nCPI Announcement: *2.9%*, forecast: 2.9%\n📊 ▁▄█ (3 readings)",
+		},
+		{
+			name: "case 10 - unknown country without hashtag",
+			args: args{
+				country: "Country #123",
+				events: []*archivist.Event{
+					{
+						DateTime: time.Date(2023, time.April, 10, 12, 0, 0, 0, time.UTC),
+						Country:  "Country #123",
+						Currency: ecal.EconomicCalendarUSD,
+						Impact:   ecal.EconomicCalendarImpactMedium,
+						Title:    "Trade Balance",
+						Actual:   "1.2B",
+					},
+				},
+			},
+			want: "🏳️ Country #123\nTrade Balance: *1.2B*",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := formatEventsUpdate(tt.args.country, tt.args.events); got != tt.want {
+			if got := formatEventsUpdate(tt.args.country, tt.args.events, tt.args.series, DefaultTheme()); got != tt.want {
 				t.Errorf("formatEventsUpdate() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_pollEvents(t *testing.T) {
+	cpi := &ecal.EconomicCalendarEvent{Title: "CPI y/y", Impact: ecal.EconomicCalendarImpactHigh, Forecast: "3.2%"}
+	nfp := &ecal.EconomicCalendarEvent{Title: "Nonfarm Payrolls", Impact: ecal.EconomicCalendarImpactHigh, Forecast: "180K"}
+	events := ecal.EconomicCalendarEvents{
+		{Title: "Retail Sales", Impact: ecal.EconomicCalendarImpactMedium, Forecast: "0.3%"},
+		{Title: "GDP q/q", Impact: ecal.EconomicCalendarImpactHigh},
+		{Title: "Fed Chair Speech", Impact: ecal.EconomicCalendarImpactHigh, Forecast: "1", EventType: ecal.EconomicCalendarTypeSpeech},
+		{Title: "PPI m/m", Impact: ecal.EconomicCalendarImpactHigh, Forecast: "0.1%", Tentative: true},
+		cpi,
+		nfp,
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  ecal.EconomicCalendarEvents
+	}{
+		{name: "all polls", limit: 3, want: ecal.EconomicCalendarEvents{cpi, nfp}},
+		{name: "limited", limit: 1, want: ecal.EconomicCalendarEvents{cpi}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pollEvents(events, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pollEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatPoll(t *testing.T) {
+	question, options := formatPoll(&ecal.EconomicCalendarEvent{
+		Country:  ecal.EconomicCalendarUnitedStates,
+		Title:    "CPI y/y",
+		Forecast: "3.2%",
+	}, DefaultTheme())
+
+	if want := "🇺🇸 Will CPI y/y come in above 3.2%?"; question != want {
+		t.Errorf("formatPoll() question = %q, want %q", question, want)
+	}
+	if want := []string{"Above 3.2%", "In line", "Below 3.2%"}; !reflect.DeepEqual(options, want) {
+		t.Errorf("formatPoll() options = %v, want %v", options, want)
+	}
+}
+
+func Test_formatPollResolution(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *archivist.Event
+		want  string
+	}{
+		{
+			name:  "above",
+			event: &archivist.Event{Title: "CPI y/y", Actual: "3.4%", Forecast: "3.2%"},
+			want:  "🗳 CPI y/y came in at *3.4%*, above the 3.2% forecast",
+		},
+		{
+			name:  "in line",
+			event: &archivist.Event{Title: "CPI y/y", Actual: "3.2%", Forecast: "3.2%"},
+			want:  "🗳 CPI y/y came in at *3.2%*, in line with the 3.2% forecast",
+		},
+		{
+			name:  "negative below",
+			event: &archivist.Event{Title: "PPI m/m", Actual: "-0.5%", Forecast: "0.1%"},
+			want:  "🗳 PPI m/m came in at *-0.5%*, below the 0.1% forecast",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatPollResolution(tt.event, DefaultTheme()); got != tt.want {
+				t.Errorf("formatPollResolution() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
+	"slices"
+	"strings"
+)
+
+// complianceReason is the filter reason of the news with the categories prohibited by the Compliance profile.
+const complianceReason = "compliance"
+
+// Compliance is the profile of the legal requirements of the channel jurisdiction (e.g. "eu-mifid")
+// applied to the published news (see Job.UseCompliance).
+type Compliance struct {
+	Name        string   `json:"name"`        // Name of the profile recorded as News.ComplianceProfile for the audit
+	Disclaimer  string   `json:"disclaimer"`  // Footer appended to every message of the channel, Markdown (optional)
+	Prohibited  []string `json:"prohibited"`  // Hashtags, markets or sectors of the composed meta that are not published, e.g. "crypto"
+	Attribution bool     `json:"attribution"` // If true, the provider of the news is credited, e.g. "Source: Reuters"
+}
+
+// enabled returns true if any requirement of the profile is set.
+func (p *Compliance) enabled() bool {
+	return p.Name != "" || p.Disclaimer != "" || len(p.Prohibited) > 0 || p.Attribution
+}
+
+// Validate returns an error if the profile has no name (or it doesn't fit the News.ComplianceProfile column)
+// or has empty prohibited categories.
+func (p *Compliance) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Name) > 32 {
+		return fmt.Errorf("name %q is longer than 32 characters", p.Name)
+	}
+	if slices.ContainsFunc(p.Prohibited, func(c string) bool { return strings.TrimSpace(c) == "" }) {
+		return errors.New("prohibited categories must not be empty")
+	}
+
+	return nil
+}
+
+// prohibits returns true if any hashtag, market or sector of the meta is the prohibited category (case-insensitive).
+func (p *Compliance) prohibits(meta *composer.ComposedMeta) bool {
+	for _, categories := range [][]string{meta.Hashtags, meta.Markets, meta.Sectors} {
+		for _, c := range categories {
+			if slices.ContainsFunc(p.Prohibited, func(prohibited string) bool { return strings.EqualFold(prohibited, c) }) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// format returns the source attribution line of the news required by the profile. Returns nil if it's not required.
+func (p *Compliance) format(n *archivist.News) []publisher.Segment {
+	if !p.Attribution || n.ProviderName == "" {
+		return nil
+	}
+
+	return []publisher.Segment{{Text: "Source: " + n.ProviderName}}
+}
+
+// Footer returns the disclaimer paragraphs of the profile. The disclaimer is appended to every message
+// of the channel (news, calendar, summaries, etc.) by the publisher (see publisher.TelegramPublisher.Footer).
+func (p *Compliance) Footer() []publisher.Segment {
+	if p.Disclaimer == "" {
+		return nil
+	}
+
+	return publisher.Markdown(p.Disclaimer).Body
+}
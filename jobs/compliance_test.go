@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCompliance_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Compliance
+		wantErr bool
+	}{
+		{name: "valid profile", profile: Compliance{Name: "eu-mifid", Disclaimer: "Not investment advice.", Prohibited: []string{"crypto"}}},
+		{name: "without name", profile: Compliance{Disclaimer: "Not investment advice."}, wantErr: true},
+		{name: "too long name", profile: Compliance{Name: strings.Repeat("a", 33)}, wantErr: true},
+		{name: "empty prohibited category", profile: Compliance{Name: "us", Prohibited: []string{" "}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.profile.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompliance_prohibits(t *testing.T) {
+	p := Compliance{Name: "uk-fca", Prohibited: []string{"Crypto", "Energy"}}
+	tests := []struct {
+		name string
+		meta composer.ComposedMeta
+		want bool
+	}{
+		{name: "prohibited hashtag", meta: composer.ComposedMeta{Hashtags: []string{"crypto"}}, want: true},
+		{name: "prohibited sector", meta: composer.ComposedMeta{Tickers: []string{"XOM"}, Sectors: []string{"Energy"}}, want: true},
+		{name: "allowed categories", meta: composer.ComposedMeta{Markets: []string{"SPY"}, Hashtags: []string{"earnings"}}},
+		{name: "empty meta", meta: composer.ComposedMeta{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.prohibits(&tt.meta); got != tt.want {
+				t.Errorf("prohibits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_newDBNews_compliance(t *testing.T) {
+	job := &Job{publisher: &mockPublisher{}, options: &jobOptions{
+		compliance:      Compliance{Name: "uk-fca", Prohibited: []string{"crypto"}},
+		republishMaxAge: 1,
+	}}
+	news := journalist.NewsList{{ID: "1", Title: "Apple beats estimates"}, {ID: "2", Title: "Bitcoin hits record"}}
+	composed := []*composer.ComposedNews{
+		{ID: "1", Text: "Apple beats estimates", Tickers: []string{"AAPL"}},
+		{ID: "2", Text: "Bitcoin hits record", Hashtags: []string{"crypto"}},
+	}
+
+	got, err := job.newDBNews(news, composed)
+	if err != nil {
+		t.Fatalf("newDBNews() error = %v", err)
+	}
+
+	for _, n := range got {
+		if n.ComplianceProfile != "uk-fca" {
+			t.Errorf("newDBNews() compliance profile = %q, want uk-fca", n.ComplianceProfile)
+		}
+	}
+	if got[0].IsFiltered || !got[0].PublishPending {
+		t.Errorf("newDBNews() allowed news filtered = %v, pending %v, want published", got[0].IsFiltered, got[0].PublishPending)
+	}
+	if !got[1].IsFiltered || got[1].FilteredReason != complianceReason || got[1].PublishPending {
+		t.Errorf("newDBNews() prohibited news filtered = %v (%q), pending %v, want filtered",
+			got[1].IsFiltered, got[1].FilteredReason, got[1].PublishPending)
+	}
+}
+
+func TestJob_publish_compliance(t *testing.T) {
+	p := &mockPublisher{}
+	job := &Job{name: "test", publisher: p, logger: slog.Default(), options: &jobOptions{
+		compliance: Compliance{Name: "eu-mifid", Disclaimer: "_Not investment advice._", Attribution: true},
+	}}
+	news := []*archivist.News{{Hash: "1", ProviderName: "Reuters", OriginalTitle: "Fed holds rates", OriginalDesc: "Markets are flat"}}
+
+	ctx := context.Background()
+	r := &JobRun{Tx: sentry.StartTransaction(ctx, "test"), Hub: sentry.CurrentHub().Clone(), name: "test", logger: slog.Default()}
+	if _, err := job.publish(ctx, r, "published", news); err != nil {
+		t.Fatalf("publish() error = %v", err)
+	}
+
+	want := "Fed holds rates\nMarkets are flat\nSource: Reuters"
+	if p.messages[0] != want {
+		t.Errorf("publish() message = %q, want %q", p.messages[0], want)
+	}
+}
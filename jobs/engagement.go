@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EngagementJob feeds the clicks of the published news to the composer.Bandit, so it shifts the Compose traffic
+// to the model whose posts are clicked more. The news are scored after the delay since the publication
+// (the time to collect the clicks) relatively to the most clicked news of the same period.
+type EngagementJob struct {
+	archivist *archivist.Archivist // archivist of the channel with the news and the clicks
+	bandit    *composer.Bandit     // bandit that chooses the Compose model
+	channelID string               // channel of the news
+	delay     time.Duration        // time to collect the clicks after the publication
+	logger    *slog.Logger         // special logger for the job
+
+	mu   sync.Mutex // guards from
+	from time.Time  // start of the next publication period to score (zero before the first run)
+}
+
+// NewEngagementJob creates a new EngagementJob of the channel which scores the news 1 hour after the publication.
+func NewEngagementJob(archivist *archivist.Archivist, bandit *composer.Bandit, channelID string) *EngagementJob {
+	return &EngagementJob{
+		archivist: archivist,
+		bandit:    bandit,
+		channelID: channelID,
+		delay:     time.Hour,
+		logger:    slog.Default(),
+	}
+}
+
+// Run return job function that will be executed by the scheduler. Each run scores the news published since
+// the end of the period of the previous run (the delay before the first run).
+func (j *EngagementJob) Run() JobFunc {
+	return WithInstrumentation("engagement", func(ctx context.Context, r *JobRun) {
+		tx := r.Tx
+		r.SetChannel(j.channelID)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		to := time.Now().UTC().Add(-j.delay)
+		from := j.from
+		if from.IsZero() {
+			from = to.Add(-j.delay)
+		}
+
+		span := tx.StartChild("News.FindComposedPublished")
+		news, err := j.archivist.Entities.News.FindComposedPublished(ctx, j.channelID, from, to)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("engagementJobFindError", "Error finding news", err)
+			return
+		}
+
+		ids := make([]uuid.UUID, len(news))
+		for i, n := range news {
+			ids[i] = n.ID
+		}
+		span = tx.StartChild("Clicks.CountByNews")
+		clicks, err := j.archivist.Entities.Clicks.CountByNews(ctx, ids)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("engagementJobClicksError", "Error counting clicks", err)
+			return
+		}
+
+		scores := engagementScores(news, clicks)
+		for _, s := range scores {
+			j.bandit.RecordEngagement(s.model, s.score)
+		}
+		j.from = to
+
+		r.Success("Recorded the engagement of %d news published since %s", len(scores), from.Format(time.RFC3339))
+	})
+}
+
+// engagementScore is the engagement of the news composed by the model.
+type engagementScore struct {
+	model string
+	score float64
+}
+
+// engagementScores returns the engagement scores (0..1) of the news: their clicks relative to the most clicked
+// of them. Returns nil if none of the news were clicked (e.g. the clicks are not tracked).
+func engagementScores(news []*archivist.News, clicks map[uuid.UUID]int64) []engagementScore {
+	var most int64
+	for _, n := range news {
+		most = max(most, clicks[n.ID])
+	}
+	if most == 0 {
+		return nil
+	}
+
+	scores := make([]engagementScore, 0, len(news))
+	for _, n := range news {
+		scores = append(scores, engagementScore{model: n.ComposeModel, score: float64(clicks[n.ID]) / float64(most)})
+	}
+	return scores
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"reflect"
+	"testing"
+)
+
+func Test_engagementScores(t *testing.T) {
+	news := []*archivist.News{
+		{ID: uuid.New(), ComposeModel: "gpt-4o-mini"},
+		{ID: uuid.New(), ComposeModel: "gpt-4o"},
+		{ID: uuid.New(), ComposeModel: "gpt-4o-mini"},
+	}
+
+	got := engagementScores(news, map[uuid.UUID]int64{news[0].ID: 2, news[1].ID: 8})
+	want := []engagementScore{{model: "gpt-4o-mini", score: 0.25}, {model: "gpt-4o", score: 1}, {model: "gpt-4o-mini", score: 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("engagementScores() = %v, want %v", got, want)
+	}
+
+	if got := engagementScores(news, nil); got != nil {
+		t.Errorf("engagementScores() without clicks = %v, want nil", got)
+	}
+}
@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/sashabaranov/go-openai"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fakes of the external services (Telegram Bot API, OpenAI and RSS feeds) for the integration tests and benchmarks.
+
+// telegramMessage is the message sent to the fake Telegram Bot API.
+type telegramMessage struct {
+	chatID    string
+	text      string
+	messageID string // ID of the edited message
+}
+
+// fakeTelegram is the Telegram Bot API server that records the sent and edited messages.
+type fakeTelegram struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	messages []telegramMessage
+	edits    []telegramMessage
+	offline  bool // if true, connections of the sendMessage requests are dropped
+}
+
+func newFakeTelegram(t testing.TB) *fakeTelegram {
+	t.Helper()
+
+	tg := &fakeTelegram{}
+	tg.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/sendMessage") && tg.isOffline():
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				_ = conn.Close()
+			}
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			tg.mu.Lock()
+			tg.messages = append(tg.messages, telegramMessage{chatID: r.FormValue("chat_id"), text: r.FormValue("text")})
+			id := len(tg.messages)
+			tg.mu.Unlock()
+			_, _ = fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d,"date":0,"chat":{"id":1}}}`, id)
+		case strings.HasSuffix(r.URL.Path, "/editMessageText"):
+			tg.mu.Lock()
+			tg.edits = append(tg.edits, telegramMessage{
+				chatID:    r.FormValue("chat_id"),
+				text:      r.FormValue("text"),
+				messageID: r.FormValue("message_id"),
+			})
+			tg.mu.Unlock()
+			_, _ = fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%s,"date":0,"chat":{"id":1}}}`, r.FormValue("message_id"))
+		default:
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+		}
+	}))
+	t.Cleanup(tg.server.Close)
+
+	return tg
+}
+
+// publisher creates the TelegramPublisher which sends all Bot API requests to the fake server.
+func (tg *fakeTelegram) publisher(t testing.TB, channelID string) *publisher.TelegramPublisher {
+	t.Helper()
+
+	target, _ := url.Parse(tg.server.URL)
+	client := &http.Client{Transport: rewriteTransport{target: target}}
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", client)
+	if err != nil {
+		t.Fatalf("error creating bot: %v", err)
+	}
+
+	return &publisher.TelegramPublisher{ChannelID: channelID, BotAPI: bot, ShouldPublish: true}
+}
+
+// setOffline makes the server unreachable for the sendMessage requests (network errors).
+func (tg *fakeTelegram) setOffline(offline bool) {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	tg.offline = offline
+}
+
+func (tg *fakeTelegram) isOffline() bool {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return tg.offline
+}
+
+// sent returns the copy of the sent messages.
+func (tg *fakeTelegram) sent() []telegramMessage {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return append([]telegramMessage(nil), tg.messages...)
+}
+
+// edited returns the copy of the edited messages.
+func (tg *fakeTelegram) edited() []telegramMessage {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return append([]telegramMessage(nil), tg.edits...)
+}
+
+// rewriteTransport sends all requests to the target server (Telegram API endpoint can't be changed in tgbotapi).
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = rt.target.Scheme
+	r.URL.Host = rt.target.Host
+	r.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// fakeOpenAI is the OpenAI chat completions server. Compose requests (with the "#" stop sequence) get the title
+// as the composed text with AAPL ticker, Filter requests remove news mentioning webinars as advertisement,
+// Translate requests get the post with the "[translated]" prefix.
+type fakeOpenAI struct {
+	server *httptest.Server
+}
+
+func newFakeOpenAI(t testing.TB) *fakeOpenAI {
+	t.Helper()
+
+	ai := &fakeOpenAI{}
+	ai.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var news []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		}
+		payload := req.Messages[len(req.Messages)-1].Content
+		_ = json.Unmarshal([]byte(payload), &news)
+
+		var answer any
+		if strings.Contains(req.Messages[0].Content, "translate it into") {
+			answer = "[translated] " + payload
+		} else if len(req.Stop) > 0 {
+			composed := make([]*composer.ComposedNews, 0, len(news))
+			for _, n := range news {
+				composed = append(composed, &composer.ComposedNews{ID: n.ID, Text: n.Title + ".", Tickers: []string{"AAPL"}})
+			}
+			answer = composed
+		} else {
+			decisions := make([]map[string]string, 0, len(news))
+			for _, n := range news {
+				reason := ""
+				if strings.Contains(strings.ToLower(n.Title), "webinar") {
+					reason = string(composer.FilterReasonAdvertisement)
+				}
+				decisions = append(decisions, map[string]string{"id": n.ID, "reason": reason})
+			}
+			answer = decisions
+		}
+
+		content, _ := json.Marshal(answer)
+		if s, ok := answer.(string); ok {
+			content = []byte(s)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Model: req.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: string(content)},
+				FinishReason: openai.FinishReasonStop,
+			}},
+		})
+	}))
+	t.Cleanup(ai.server.Close)
+
+	return ai
+}
+
+func (ai *fakeOpenAI) client() *openai.Client {
+	config := openai.DefaultConfig("test-token")
+	config.BaseURL = ai.server.URL + "/v1"
+	return openai.NewClientWithConfig(config)
+}
+
+// rssItem is the item of the fake RSS feed.
+type rssItem struct {
+	Title       string
+	Description string
+	Link        string
+	Date        time.Time
+}
+
+// newFakeRSS starts the server with the RSS feed of the items (newest first).
+func newFakeRSS(t testing.TB, items []rssItem) *httptest.Server {
+	t.Helper()
+
+	var feed strings.Builder
+	feed.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Test</title>`)
+	for _, i := range items {
+		_, _ = fmt.Fprintf(&feed, "<item><title>%s</title><description>%s</description><link>%s</link><pubDate>%s</pubDate></item>",
+			i.Title, i.Description, i.Link, i.Date.Format(time.RFC1123Z))
+	}
+	feed.WriteString(`</channel></rss>`)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(feed.String()))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// FollowUpJob checks how the tickers moved a few hours after the ticker-tagged news were published
+// and replies to the publications with the reaction, e.g. "NVDA +4.3% since this post".
+type FollowUpJob struct {
+	quotes    *quotes.Quotes           // quotes source to get the ticker prices
+	publisher publisher.ReplyPublisher // publisher that will reply to the published news
+	archivist *archivist.Archivist     // archivist that will be used to find published news
+	logger    *slog.Logger             // special logger for the job
+	options   *followUpOptions         // job options
+}
+
+// followUpOptions holds options needed for the FollowUpJob execution.
+type followUpOptions struct {
+	after   time.Duration // check the reaction after this period since the publication
+	maxAge  time.Duration // skip publications older than this period (e.g. published before the weekend)
+	minMove float64       // reply only if the ticker moved at least this percentage (absolute value)
+}
+
+// NewFollowUpJob creates a new FollowUpJob instance with default options:
+// check the reaction 3 hours after the publication and reply only to moves of 2% or more.
+func NewFollowUpJob(
+	quotes *quotes.Quotes,
+	publisher publisher.ReplyPublisher,
+	archivist *archivist.Archivist,
+) *FollowUpJob {
+	return &FollowUpJob{
+		quotes:    quotes,
+		publisher: publisher,
+		archivist: archivist,
+		logger:    slog.Default(),
+		options: &followUpOptions{
+			after:   3 * time.Hour,
+			maxAge:  12 * time.Hour,
+			minMove: 2,
+		},
+	}
+}
+
+// After sets the period since the publication after which the ticker reaction is checked.
+func (j *FollowUpJob) After(d time.Duration) *FollowUpJob {
+	j.options.after = d
+	return j
+}
+
+// MinMove sets the minimal ticker move (in percents) to reply to the publication.
+func (j *FollowUpJob) MinMove(percent float64) *FollowUpJob {
+	j.options.minMove = percent
+	return j
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *FollowUpJob) Run() JobFunc {
+	return WithInstrumentation("follow-up", func(ctx context.Context, r *JobRun) {
+		tx, hub := r.Tx, r.Hub
+		r.SetChannel(j.publisher.Channel())
+
+		now := time.Now().UTC()
+
+		span := tx.StartChild("News.FindForFollowUp")
+		news, err := j.archivist.Entities.News.FindForFollowUp(ctx, now.Add(-j.options.maxAge), now.Add(-j.options.after))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("followUpJobFindError", "Error finding news", err)
+			return
+		}
+
+		replies := 0
+		for _, n := range news {
+			ticker := firstTicker(n)
+			if ticker == "" {
+				continue
+			}
+
+			span = tx.StartChild("Quotes.FetchIntraday")
+			data, err := j.quotes.FetchIntraday(ctx, ticker)
+			span.Finish()
+			if err != nil {
+				e := fmt.Errorf("[job-follow-up] Error fetching %s quotes: %w", ticker, err)
+				j.logger.Warn(e.Error())
+				utils.CaptureSentryException("followUpJobFetchQuotesError", hub, e)
+				continue
+			}
+
+			if change, ok := changeSince(data, n.PublishedAt); ok && math.Abs(change) >= j.options.minMove {
+				span = tx.StartChild("TelegramPublisher.PublishReply")
+				_, err = j.publisher.PublishReply(publisher.Markdown(formatFollowUp(ticker, change)), n.PublicationID)
+				span.Finish()
+				if err != nil {
+					_ = r.Fail("followUpJobPublishError", "Error publishing reply", err)
+					return
+				}
+				replies++
+			}
+
+			// Mark the news as followed up even without a reply, so it won't be checked again
+			n.FollowedUpAt = now
+			span = tx.StartChild("News.Update")
+			err = j.archivist.Entities.News.Update(ctx, n)
+			span.Finish()
+			if err != nil {
+				_ = r.Fail("followUpJobUpdateError", "Error updating news", err)
+				return
+			}
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("FollowUpJob checked %d news and published %d replies", len(news), replies),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	})
+}
+
+// firstTicker returns the first ticker from the news meta (empty if there are no tickers).
+func firstTicker(n *archivist.News) string {
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil || len(meta.Tickers) == 0 {
+		return ""
+	}
+	return meta.Tickers[0]
+}
+
+// changeSince returns the percentage change of the last price from the price at the publication time.
+// If the news was published before the trading session, the previous close is used as the reference price.
+func changeSince(data *quotes.Intraday, publishedAt time.Time) (float64, bool) {
+	if len(data.Points) == 0 {
+		return 0, false
+	}
+
+	reference, ok := data.PriceAt(publishedAt)
+	if !ok {
+		reference = data.PreviousClose
+	}
+	if reference == 0 {
+		return 0, false
+	}
+
+	return (data.Last()/reference - 1) * 100, true
+}
+
+// formatFollowUp formats the ticker reaction reply.
+func formatFollowUp(ticker string, change float64) string {
+	emoji := "📈"
+	if change < 0 {
+		emoji = "📉"
+	}
+	return fmt.Sprintf("%s %s %+.1f%% since this post", emoji, ticker, change)
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"testing"
+	"time"
+)
+
+func Test_changeSince(t *testing.T) {
+	open := time.Date(2024, time.March, 13, 14, 30, 0, 0, time.UTC)
+	data := &quotes.Intraday{
+		Ticker:        "NVDA",
+		PreviousClose: 90,
+		Points: []quotes.Point{
+			{Time: open, Price: 95},
+			{Time: open.Add(time.Hour), Price: 100},
+			{Time: open.Add(2 * time.Hour), Price: 104.3},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		data        *quotes.Intraday
+		publishedAt time.Time
+		want        float64
+		wantOk      bool
+	}{
+		{
+			name:        "published during the session",
+			data:        data,
+			publishedAt: open.Add(70 * time.Minute),
+			want:        4.3,
+			wantOk:      true,
+		},
+		{
+			name:        "published before the session",
+			data:        data,
+			publishedAt: open.Add(-time.Hour),
+			want:        15.89,
+			wantOk:      true,
+		},
+		{
+			name:        "no prices",
+			data:        &quotes.Intraday{Ticker: "NVDA", PreviousClose: 90},
+			publishedAt: open,
+			wantOk:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := changeSince(tt.data, tt.publishedAt)
+			if ok != tt.wantOk {
+				t.Fatalf("changeSince() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (got < tt.want-0.01 || got > tt.want+0.01) {
+				t.Errorf("changeSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatFollowUp(t *testing.T) {
+	if got := formatFollowUp("NVDA", 4.3); got != "📈 NVDA +4.3% since this post" {
+		t.Errorf("formatFollowUp() = %v", got)
+	}
+	if got := formatFollowUp("TSLA", -2.04); got != "📉 TSLA -2.0% since this post" {
+		t.Errorf("formatFollowUp() = %v", got)
+	}
+}
+
+func Test_firstTicker(t *testing.T) {
+	tests := []struct {
+		name string
+		meta string
+		want string
+	}{
+		{name: "with tickers", meta: `{"tickers":["NVDA","AMD"]}`, want: "NVDA"},
+		{name: "without tickers", meta: `{"tickers":[]}`, want: ""},
+		{name: "invalid meta", meta: `nope`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstTicker(&archivist.News{MetaData: []byte(tt.meta)}); got != tt.want {
+				t.Errorf("firstTicker() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
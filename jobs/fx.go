@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"math"
+	"slices"
+	"strings"
+)
+
+// fxPairs holds the major FX pairs of the currencies impacted by the events.
+var fxPairs = map[ecal.EconomicCalendarCurrency][]string{
+	ecal.EconomicCalendarUSD: {"EURUSD", "USDJPY"},
+	ecal.EconomicCalendarEUR: {"EURUSD"},
+	ecal.EconomicCalendarGBP: {"GBPUSD"},
+	ecal.EconomicCalendarJPY: {"USDJPY"},
+	ecal.EconomicCalendarCHF: {"USDCHF"},
+	ecal.EconomicCalendarCNY: {"USDCNY"},
+	ecal.EconomicCalendarAUD: {"AUDUSD"},
+	ecal.EconomicCalendarNZD: {"NZDUSD"},
+	ecal.EconomicCalendarINR: {"USDINR"},
+}
+
+// surpriseDeviation returns the relative deviation of the actual value from the forecast, e.g. 0.5 for 0.3% vs 0.2%.
+// Returns false if the event has no forecast or actual value.
+func surpriseDeviation(e *archivist.Event) (float64, bool) {
+	if e.Actual == "" || e.Forecast == "" {
+		return 0, false
+	}
+
+	actual := utils.StrValueToFloat(e.Actual)
+	forecast := utils.StrValueToFloat(e.Forecast)
+	if forecast == 0 {
+		if actual == 0 {
+			return 0, true
+		}
+		return math.Inf(1), true
+	}
+
+	return math.Abs(actual-forecast) / math.Abs(forecast), true
+}
+
+// surprisePairs returns the distinct FX pairs of the events with the deviation from the forecast
+// above the threshold (e.g. 0.25 for 25%).
+func surprisePairs(events []*archivist.Event, threshold float64) []string {
+	var pairs []string
+	for _, e := range events {
+		if d, ok := surpriseDeviation(e); !ok || d < threshold {
+			continue
+		}
+		for _, p := range fxPairs[e.Currency] {
+			if !slices.Contains(pairs, p) {
+				pairs = append(pairs, p)
+			}
+		}
+	}
+	return pairs
+}
+
+// fetchFXQuotes fetches the quotes of the FX pairs. Pairs with errors are skipped, since quotes are optional.
+func (j *CalendarJob) fetchFXQuotes(ctx context.Context, hub *sentry.Hub, pairs []string) map[string]*quotes.Intraday {
+	result := make(map[string]*quotes.Intraday, len(pairs))
+	for _, p := range pairs {
+		data, err := j.fxQuotes.FetchFX(ctx, p)
+		if err != nil {
+			e := fmt.Errorf("[job-calendar-updates] Error fetching %s quotes: %w", p, err)
+			j.logger.Warn(e.Error())
+			utils.CaptureSentryException("calendarUpdatesJobFXQuotesError", hub, e)
+			continue
+		}
+		result[p] = data
+	}
+	return result
+}
+
+// formatFXPairs formats the FX pairs hashtags with their last quotes (if available), e.g. "💱 #eurusd 1.0845 (+0.12%)".
+func formatFXPairs(pairs []string, fxQuotes map[string]*quotes.Intraday, theme *Theme) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	items := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		item := "#" + strings.ToLower(p)
+		if data, ok := fxQuotes[p]; ok && data.Last() != 0 {
+			precision := 4
+			if data.Last() >= 20 {
+				precision = 2 // e.g. USDJPY
+			}
+			item += fmt.Sprintf(" %.*f (%+.2f%%)", precision, data.Last(), data.Change())
+		}
+		items = append(items, item)
+	}
+
+	return withIcons(strings.Join(items, ", "), theme.FX)
+}
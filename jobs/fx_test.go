@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"reflect"
+	"testing"
+)
+
+func Test_surprisePairs(t *testing.T) {
+	usd := &archivist.Event{Currency: ecal.EconomicCalendarUSD, Actual: "0.4%", Forecast: "0.2%"}
+	eur := &archivist.Event{Currency: ecal.EconomicCalendarEUR, Actual: "2.9%", Forecast: "3.0%"}
+	eurStrong := &archivist.Event{Currency: ecal.EconomicCalendarEUR, Actual: "1.5%", Forecast: "1.0%"}
+	zero := &archivist.Event{Currency: ecal.EconomicCalendarGBP, Actual: "0.1%", Forecast: "0.0%"}
+	noForecast := &archivist.Event{Currency: ecal.EconomicCalendarJPY, Actual: "1.0%"}
+
+	tests := []struct {
+		name   string
+		events []*archivist.Event
+		want   []string
+	}{
+		{name: "strong deviation", events: []*archivist.Event{usd}, want: []string{"EURUSD", "USDJPY"}},
+		{name: "small deviation", events: []*archivist.Event{eur}, want: nil},
+		{name: "distinct pairs", events: []*archivist.Event{usd, eurStrong, eur}, want: []string{"EURUSD", "USDJPY"}},
+		{name: "zero forecast", events: []*archivist.Event{zero}, want: []string{"GBPUSD"}},
+		{name: "no forecast", events: []*archivist.Event{noForecast}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := surprisePairs(tt.events, 0.25); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("surprisePairs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatFXPairs(t *testing.T) {
+	fxQuotes := map[string]*quotes.Intraday{
+		"EURUSD": {PreviousClose: 1.08, Points: []quotes.Point{{Price: 1.0854}}},
+		"USDJPY": {PreviousClose: 150, Points: []quotes.Point{{Price: 149.55}}},
+	}
+
+	tests := []struct {
+		name   string
+		pairs  []string
+		quotes map[string]*quotes.Intraday
+		theme  *Theme
+		want   string
+	}{
+		{name: "no pairs", want: ""},
+		{name: "hashtags only", pairs: []string{"EURUSD", "USDJPY"}, theme: DefaultTheme(), want: "💱 #eurusd, #usdjpy"},
+		{
+			name:   "with quotes",
+			pairs:  []string{"EURUSD", "USDJPY", "GBPUSD"},
+			quotes: fxQuotes,
+			theme:  PlainTheme(),
+			want:   "FX: #eurusd 1.0854 (+0.50%), #usdjpy 149.55 (-0.30%), #gbpusd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatFXPairs(tt.pairs, tt.quotes, tt.theme); got != tt.want {
+				t.Errorf("formatFXPairs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
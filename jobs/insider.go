@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/insider"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	insiderPeriod = 7 * 24 * time.Hour // period of the filings in the digest
+	insiderLimit  = 20                 // max number of the filings in the digest
+)
+
+// InsiderJob publishes the weekly digest of the insider purchases and sales (SEC Form 4 filings)
+// of the watchlist tickers. Published filings are stored, so the late filings are not repeated. It should be run weekly.
+type InsiderJob struct {
+	insider   *insider.Insider     // source of the Form 4 filings
+	publisher publisher.Publisher  // publisher that will publish the digest to the channel
+	archivist *archivist.Archivist // archivist that will store the published filings
+	tickers   []string             // tickers of the companies (watchlist)
+	minValue  float64              // filings with the trades value (USD) below this value are skipped
+	logger    *slog.Logger         // special logger for the job
+}
+
+// NewInsiderJob creates a new InsiderJob instance for the tickers.
+func NewInsiderJob(
+	insider *insider.Insider,
+	publisher publisher.Publisher,
+	archivist *archivist.Archivist,
+	tickers []string,
+) *InsiderJob {
+	return &InsiderJob{
+		insider:   insider,
+		publisher: publisher,
+		archivist: archivist,
+		tickers:   tickers,
+		logger:    slog.Default(),
+	}
+}
+
+// MinValue sets the minimal value (USD) of the purchases and sales of the filing to be included in the digest.
+func (j *InsiderJob) MinValue(usd float64) *InsiderJob {
+	j.minValue = usd
+	return j
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *InsiderJob) Run() JobFunc {
+	return WithInstrumentationTimeout("insider", 5*time.Minute, func(ctx context.Context, r *JobRun) {
+		tx := r.Tx
+		r.SetChannel(j.publisher.Channel())
+
+		to := time.Now().UTC()
+		from := to.Add(-insiderPeriod)
+
+		// Digest is published even if some tickers failed (e.g. unknown to the EDGAR)
+		var filings []*insider.Filing
+		span := tx.StartChild("Insider.FetchFilings")
+		for _, ticker := range j.tickers {
+			f, err := j.insider.FetchFilings(ctx, ticker, from, to)
+			if err != nil {
+				r.Warn("insiderJobFetchError", fmt.Sprintf("Error fetching filings of %s", ticker), err)
+				continue
+			}
+			filings = append(filings, f...)
+		}
+		span.Finish()
+		r.Stage("fetched", len(filings), nil)
+
+		filings = filterInsiderFilings(filings, j.minValue)
+
+		accessionNumbers := make([]string, 0, len(filings))
+		for _, f := range filings {
+			accessionNumbers = append(accessionNumbers, f.AccessionNumber)
+		}
+
+		span = tx.StartChild("Archivist.Insiders.FindPublished")
+		published, err := j.archivist.Entities.Insiders.FindPublished(ctx, j.publisher.Channel(), accessionNumbers)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("insiderJobFindError", "Error finding published filings", err)
+			return
+		}
+
+		var fresh []*insider.Filing
+		for _, f := range filings {
+			if !published[f.AccessionNumber] {
+				fresh = append(fresh, f)
+			}
+		}
+		r.Stage("fresh", len(fresh), nil)
+
+		if len(fresh) == 0 {
+			r.Success("No new insider trades")
+			return
+		}
+
+		span = tx.StartChild("TelegramPublisher.Publish")
+		_, err = j.publisher.Publish(publisher.Markdown(formatInsiderDigest(fresh, insiderLimit)))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("insiderJobPublishError", "Error publishing insider digest", err)
+			return
+		}
+
+		saved := make([]*archivist.InsiderFiling, 0, len(fresh))
+		for _, f := range fresh {
+			saved = append(saved, &archivist.InsiderFiling{
+				AccessionNumber: f.AccessionNumber,
+				ChannelID:       j.publisher.Channel(),
+				Ticker:          f.Ticker,
+			})
+		}
+
+		span = tx.StartChild("Archivist.Insiders.Create")
+		err = j.archivist.Entities.Insiders.Create(ctx, saved)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("insiderJobSaveError", "Error saving published filings", err)
+			return
+		}
+
+		r.Success("Insider digest published with %d filings", len(fresh))
+	})
+}
+
+// filterInsiderFilings returns the filings with the total value of the purchases and sales at least minValue.
+func filterInsiderFilings(filings []*insider.Filing, minValue float64) []*insider.Filing {
+	var result []*insider.Filing
+	for _, f := range filings {
+		_, bought := f.Total(insider.Purchase)
+		_, sold := f.Total(insider.Sale)
+		if bought+sold >= minValue {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// formatInsiderDigest formats up to limit filings grouped by the ticker in the order of the first filing.
+func formatInsiderDigest(filings []*insider.Filing, limit int) string {
+	var m strings.Builder
+	m.WriteString("🕵️ *Insider trades of the week*\n")
+
+	var order []string
+	byTicker := make(map[string][]*insider.Filing)
+	for _, f := range filings {
+		if _, ok := byTicker[f.Ticker]; !ok {
+			order = append(order, f.Ticker)
+		}
+		byTicker[f.Ticker] = append(byTicker[f.Ticker], f)
+	}
+
+	n := 0
+	for _, ticker := range order {
+		if n == limit {
+			break
+		}
+		m.WriteString(fmt.Sprintf("\n$%s\n", ticker))
+		for _, f := range byTicker[ticker] {
+			if n == limit {
+				break
+			}
+			m.WriteString(formatInsiderFiling(f) + "\n")
+			n++
+		}
+	}
+	if len(filings) > limit {
+		m.WriteString(fmt.Sprintf("\nand %d more filings\n", len(filings)-limit))
+	}
+
+	m.WriteString("#insiders")
+	return m.String()
+}
+
+// formatInsiderFiling formats the purchases and sales of the filing, e.g. "🔴 John Doe (CEO) sold 1,000 shares for $185.5K".
+func formatInsiderFiling(f *insider.Filing) string {
+	name := f.Insider
+	if f.Title != "" {
+		name = fmt.Sprintf("%s (%s)", f.Insider, f.Title)
+	}
+
+	var trades []string
+	if shares, value := f.Total(insider.Purchase); shares > 0 {
+		trades = append(trades, fmt.Sprintf("bought %s shares for $%s", formatShares(shares), formatUSD(value)))
+	}
+	if shares, value := f.Total(insider.Sale); shares > 0 {
+		trades = append(trades, fmt.Sprintf("sold %s shares for $%s", formatShares(shares), formatUSD(value)))
+	}
+
+	emoji := "🔴"
+	if _, bought := f.Total(insider.Purchase); bought > 0 {
+		emoji = "🟢"
+	}
+
+	return fmt.Sprintf("%s %s %s", emoji, name, strings.Join(trades, " and "))
+}
+
+// formatShares formats the number of shares with the thousands separators, e.g. 12,345.
+func formatShares(shares float64) string {
+	s := fmt.Sprintf("%.0f", shares)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// formatUSD formats the value with the K, M or B suffix, e.g. 1.2M.
+func formatUSD(value float64) string {
+	switch {
+	case value >= 1e9:
+		return fmt.Sprintf("%.1fB", value/1e9)
+	case value >= 1e6:
+		return fmt.Sprintf("%.1fM", value/1e6)
+	case value >= 1e3:
+		return fmt.Sprintf("%.1fK", value/1e3)
+	default:
+		return fmt.Sprintf("%.0f", value)
+	}
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/scavenger/insider"
+	"testing"
+)
+
+func Test_filterInsiderFilings(t *testing.T) {
+	filings := []*insider.Filing{
+		{AccessionNumber: "1", Trades: []insider.Trade{{Code: insider.Sale, Shares: 1000, Price: 100}}},
+		{AccessionNumber: "2", Trades: []insider.Trade{{Code: insider.Purchase, Shares: 10, Price: 100}}},
+		{AccessionNumber: "3", Trades: []insider.Trade{
+			{Code: insider.Purchase, Shares: 300, Price: 100},
+			{Code: insider.Sale, Shares: 200, Price: 100},
+		}},
+	}
+
+	got := filterInsiderFilings(filings, 50000)
+	if len(got) != 2 || got[0].AccessionNumber != "1" || got[1].AccessionNumber != "3" {
+		t.Errorf("filterInsiderFilings() returned %d filings, want 1 and 3", len(got))
+	}
+}
+
+func Test_formatInsiderDigest(t *testing.T) {
+	filings := []*insider.Filing{
+		{Ticker: "AAPL", Insider: "Cook Timothy D", Title: "CEO", Trades: []insider.Trade{
+			{Code: insider.Sale, Shares: 500, Price: 185.5},
+			{Code: insider.Sale, Shares: 100, Price: 186},
+		}},
+		{Ticker: "NVDA", Insider: "Huang Jen Hsun", Trades: []insider.Trade{{Code: insider.Purchase, Shares: 12000, Price: 500}}},
+		{Ticker: "AAPL", Insider: "Levinson Arthur D", Title: "Director", Trades: []insider.Trade{
+			{Code: insider.Purchase, Shares: 1000, Price: 180},
+			{Code: insider.Sale, Shares: 10, Price: 190},
+		}},
+	}
+
+	want := "🕵️ *Insider trades of the week*\n" +
+		"\n$AAPL\n" +
+		"🔴 Cook Timothy D (CEO) sold 600 shares for $111.3K\n" +
+		"🟢 Levinson Arthur D (Director) bought 1,000 shares for $180.0K and sold 10 shares for $1.9K\n" +
+		"\n$NVDA\n" +
+		"🟢 Huang Jen Hsun bought 12,000 shares for $6.0M\n" +
+		"#insiders"
+	if got := formatInsiderDigest(filings, 20); got != want {
+		t.Errorf("formatInsiderDigest() = %q, want %q", got, want)
+	}
+
+	wantLimited := "🕵️ *Insider trades of the week*\n" +
+		"\n$AAPL\n" +
+		"🔴 Cook Timothy D (CEO) sold 600 shares for $111.3K\n" +
+		"\nand 2 more filings\n" +
+		"#insiders"
+	if got := formatInsiderDigest(filings, 1); got != wantLimited {
+		t.Errorf("formatInsiderDigest() = %q, want %q", got, wantLimited)
+	}
+}
+
+func Test_formatShares(t *testing.T) {
+	tests := map[float64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567"}
+	for shares, want := range tests {
+		if got := formatShares(shares); got != want {
+			t.Errorf("formatShares(%v) = %q, want %q", shares, got, want)
+		}
+	}
+}
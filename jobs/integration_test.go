@@ -0,0 +1,558 @@
+//go:build integration
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Integration tests run the jobs against the real Postgres (in Docker) and fake Telegram, OpenAI, RSS and calendar servers.
+// Run them with `make test-integration` (requires Docker).
+
+func TestIntegration_JobRun(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+	ai := newFakeOpenAI(t)
+
+	now := time.Now().UTC()
+	feed := newFakeRSS(t, []rssItem{
+		{Title: "Apple beats earnings estimates", Description: "AAPL reported record revenue.", Link: "https://example.com/apple", Date: now.Add(-time.Minute)},
+		{Title: "Join our webinar on trading", Description: "Sign up today.", Link: "https://example.com/webinar", Date: now.Add(-2 * time.Minute)},
+	})
+
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+	j := journalist.NewJournalist("Test", []journalist.NewsProvider{journalist.NewRssProvider("Test feed", feed.URL)})
+
+	job := NewJob(c, tg.publisher(t, "@test_channel"), arch, j, nil).
+		FetchUntil(now.Add(-time.Hour)).
+		OmitSuspicious().
+		OmitIfAllKeysEmpty().
+		ComposeText().
+		RemoveClones().
+		SaveToDB()
+	if err := job.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	job.Run()()
+
+	var news []*archivist.News
+	if err := arch.Entities.News.Conn.WithContext(ctx).Order("original_title").Find(&news).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(news) != 2 {
+		t.Fatalf("saved %d news, want 2", len(news))
+	}
+
+	apple, webinar := news[0], news[1]
+	if apple.PublicationID == "" || apple.PublishedAt.IsZero() || apple.ComposedText != "Apple beats earnings estimates." {
+		t.Errorf("published news = %+v", apple)
+	}
+	if !webinar.IsFiltered || webinar.FilteredReason != string(composer.FilterReasonAdvertisement) || webinar.PublicationID != "" {
+		t.Errorf("filtered news = %+v", webinar)
+	}
+
+	messages := tg.sent()
+	if len(messages) != 1 {
+		t.Fatalf("sent %d messages, want 1: %v", len(messages), messages)
+	}
+	if messages[0].chatID != "@test_channel" || !strings.Contains(messages[0].text, "Apple beats earnings estimates.") {
+		t.Errorf("sent message = %+v", messages[0])
+	}
+
+	// The same news are not published twice
+	job.Run()()
+
+	if messages := tg.sent(); len(messages) != 1 {
+		t.Errorf("sent %d messages after the second run, want 1", len(messages))
+	}
+	var count int64
+	if err := arch.Entities.News.Conn.WithContext(ctx).Model(&archivist.News{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("saved %d news after the second run, want 2", count)
+	}
+}
+
+func TestIntegration_JobRunCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+	ai := newFakeOpenAI(t)
+
+	// The news is older than FetchUntil, but newer than the checkpoint of the previous run
+	now := time.Now().UTC()
+	feed := newFakeRSS(t, []rssItem{
+		{Title: "Apple beats earnings estimates", Description: "AAPL reported record revenue.", Link: "https://example.com/apple", Date: now.Add(-10 * time.Minute)},
+	})
+	if err := arch.Entities.Checkpoints.Save(ctx, "Run.Test", now.Add(-15*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+	j := journalist.NewJournalist("Test", []journalist.NewsProvider{journalist.NewRssProvider("Test feed", feed.URL)})
+
+	job := NewJob(c, tg.publisher(t, "@test_channel"), arch, j, nil).
+		FetchUntil(now.Add(-time.Minute)).
+		ResumeFromCheckpoint(time.Minute, time.Hour).
+		ComposeText().
+		RemoveClones().
+		SaveToDB()
+	if err := job.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	job.Run()()
+
+	if messages := tg.sent(); len(messages) != 1 {
+		t.Fatalf("sent %d messages, want 1: %v", len(messages), messages)
+	}
+	checkpoint, err := arch.Entities.Checkpoints.Get(ctx, "Run.Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint == nil || checkpoint.Time.Before(now) {
+		t.Errorf("checkpoint = %+v, want moved to the end of the run window", checkpoint)
+	}
+}
+
+func TestIntegration_CalendarJob(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `[{
+			"ID": 1,
+			"EventType": 1,
+			"EventName": "Core CPI m/m",
+			"Importance": "high",
+			"CurrencyCode": "USD",
+			"Country": 840,
+			"ForecastValue": "0.2%%",
+			"PreviousValue": "0.3%%",
+			"ReleaseDate": %d,
+			"FullDate": %q
+		}]`, today.Add(12*time.Hour).UnixMilli(), today.Add(12*time.Hour).Format("2006-01-02T15:04:05"))
+	}))
+	t.Cleanup(calendar.Close)
+
+	cal := &ecal.EconomicCalendar{}
+	cal.SetURLs(calendar.URL, calendar.URL)
+
+	job := NewCalendarJob(cal, tg.publisher(t, "@test_channel"), arch, ecal.SourceName)
+	job.RunDailyCalendarJob()()
+
+	var events []*archivist.Event
+	if err := arch.Entities.Events.Conn.WithContext(ctx).Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Title != "Core CPI m/m" || events[0].ChannelID != "@test_channel" {
+		t.Errorf("saved events = %+v, want Core CPI m/m", events)
+	}
+
+	messages := tg.sent()
+	if len(messages) != 1 || !strings.Contains(messages[0].text, "Core CPI m/m") {
+		t.Errorf("sent messages = %+v, want the daily calendar", messages)
+	}
+}
+
+func TestIntegration_CalendarUpdatesJob_announcesActualOnce(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `[{
+			"ID": 1,
+			"EventType": 1,
+			"EventName": "Core CPI m/m",
+			"Importance": "high",
+			"CurrencyCode": "USD",
+			"Country": 840,
+			"ActualValue": "0.3%%",
+			"ForecastValue": "0.2%%",
+			"PreviousValue": "0.3%%",
+			"ReleaseDate": %d,
+			"FullDate": %q
+		}]`, today.Add(12*time.Hour).UnixMilli(), today.Add(12*time.Hour).Format("2006-01-02T15:04:05"))
+	}))
+	t.Cleanup(calendar.Close)
+
+	event := &archivist.Event{
+		ChannelID:    "@test_channel",
+		ProviderName: ecal.SourceName,
+		ProviderID:   "1",
+		Title:        "Core CPI m/m",
+		DateTime:     today.Add(12 * time.Hour),
+		Country:      ecal.EconomicCalendarUnitedStates,
+		Currency:     ecal.EconomicCalendarUSD,
+		Impact:       ecal.EconomicCalendarImpactHigh,
+	}
+	if err := arch.Entities.Events.Create(ctx, []*archivist.Event{event}); err != nil {
+		t.Fatal(err)
+	}
+
+	cal := &ecal.EconomicCalendar{}
+	cal.SetURLs(calendar.URL, calendar.URL)
+	job := NewCalendarJob(cal, tg.publisher(t, "@test_channel"), arch, ecal.SourceName)
+	job.RunCalendarUpdatesJob()()
+
+	// The second run still sees the event without the actual value, e.g. it was started before the first one saved it
+	err := arch.Entities.Events.Conn.WithContext(ctx).
+		Model(&archivist.Event{}).
+		Where("id = ?", event.ID).
+		Update("actual", "").
+		Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.RunCalendarUpdatesJob()()
+
+	if messages := tg.sent(); len(messages) != 1 || !strings.Contains(messages[0].text, "Core CPI m/m") {
+		t.Errorf("sent messages = %+v, want the single actual value announcement", messages)
+	}
+}
+
+func TestIntegration_CalendarUpdatesJob_editsPlan(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	actual := ""
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `[{
+			"ID": 1,
+			"EventType": 1,
+			"EventName": "Core CPI m/m",
+			"Importance": "high",
+			"CurrencyCode": "USD",
+			"Country": 840,
+			"ActualValue": %q,
+			"ForecastValue": "0.2%%",
+			"PreviousValue": "0.3%%",
+			"ReleaseDate": %d,
+			"FullDate": %q
+		}]`, actual, today.Add(12*time.Hour).UnixMilli(), today.Add(12*time.Hour).Format("2006-01-02T15:04:05"))
+	}))
+	t.Cleanup(calendar.Close)
+
+	cal := &ecal.EconomicCalendar{}
+	cal.SetURLs(calendar.URL, calendar.URL)
+	job := NewCalendarJob(cal, tg.publisher(t, "@test_channel"), arch, ecal.SourceName).EditPlan()
+	if err := job.RunDailyCalendarJob()(); err != nil {
+		t.Fatal(err)
+	}
+
+	actual = "0.3%"
+	if err := job.RunCalendarUpdatesJob()(); err != nil {
+		t.Fatal(err)
+	}
+
+	if messages := tg.sent(); len(messages) != 1 {
+		t.Errorf("sent messages = %+v, want the daily plan only", messages)
+	}
+	edits := tg.edited()
+	if len(edits) != 1 || edits[0].messageID != "1" || !strings.Contains(edits[0].text, "Core CPI m/m: *0.3%*") {
+		t.Errorf("edited messages = %+v, want the daily plan with the actual value", edits)
+	}
+
+	events, err := arch.Entities.Events.FindPlan(ctx, "@test_channel", "1")
+	if err != nil || len(events) != 1 || events[0].Actual != "0.3%" {
+		t.Errorf("FindPlan() = %+v, %v, want the event with the actual value", events, err)
+	}
+}
+
+func TestIntegration_RecomputeHashes(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+
+	date := time.Now().Add(-time.Hour)
+	news := []*archivist.News{
+		{OriginalTitle: "Fed holds rates", URL: "https://a.com/fed?utm_source=rss", OriginalDate: date},
+		{OriginalTitle: "Fed holds rates steady", URL: "https://a.com/fed", OriginalDate: date},
+		{OriginalTitle: "Apple beats earnings", URL: "https://a.com/apple", OriginalDate: date},
+	}
+	if err := arch.Entities.News.Create(ctx, news); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, conflicts, err := arch.Entities.News.RecomputeHashes(ctx, func(n *archivist.News) string {
+		return journalist.IDByURL.NewsID(n.OriginalTitle, n.OriginalDesc, n.URL, n.GUID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both Fed news have the same normalized link, so the second one keeps the old hash
+	if updated != 2 || conflicts != 1 {
+		t.Errorf("RecomputeHashes() = %d updated, %d conflicts, want 2 and 1", updated, conflicts)
+	}
+
+	found, err := arch.Entities.News.FindAllByHashes(ctx, []string{journalist.IDByURL.NewsID("", "", "https://a.com/apple", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].OriginalTitle != "Apple beats earnings" {
+		t.Errorf("FindAllByHashes() = %+v, want the news with the recomputed hash", found)
+	}
+}
+
+func TestIntegration_PauseGuard(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	guard := NewPauseGuard(arch.Entities.Pauses)
+
+	runs := map[string]int{}
+	run := func(job string) {
+		_ = guard.Guard(job, func() error { runs[job]++; return nil })()
+	}
+
+	if err := arch.Entities.Pauses.Save(ctx, &archivist.Pause{Job: "market", Reason: "bad feed"}); err != nil {
+		t.Fatal(err)
+	}
+	run("market")
+	run("broad")
+	if runs["market"] != 0 || runs["broad"] != 1 {
+		t.Errorf("runs = %v, want only broad job run", runs)
+	}
+
+	if err := arch.Entities.Pauses.Save(ctx, &archivist.Pause{Job: archivist.PauseAll}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := arch.Entities.Pauses.Delete(ctx, "market"); err != nil || !ok {
+		t.Fatalf("Delete() = %v, %v, want deleted", ok, err)
+	}
+	run("market")
+	run("broad")
+	if runs["market"] != 0 || runs["broad"] != 1 {
+		t.Errorf("runs = %v, want no runs with the global pause", runs)
+	}
+
+	if ok, err := arch.Entities.Pauses.Delete(ctx, archivist.PauseAll); err != nil || !ok {
+		t.Fatalf("Delete() = %v, %v, want deleted", ok, err)
+	}
+	run("market")
+	if runs["market"] != 1 {
+		t.Errorf("runs = %v, want market job run after resume", runs)
+	}
+}
+
+func TestIntegration_OutboxJob(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+	ai := newFakeOpenAI(t)
+
+	now := time.Now().UTC()
+	feed := newFakeRSS(t, []rssItem{
+		{Title: "Apple beats earnings estimates", Description: "AAPL reported record revenue.", Link: "https://example.com/apple", Date: now.Add(-time.Minute)},
+	})
+
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+	j := journalist.NewJournalist("Test", []journalist.NewsProvider{journalist.NewRssProvider("Test feed", feed.URL)})
+
+	p := tg.publisher(t, "@test_channel")
+	job := NewJob(c, p, arch, j, nil).
+		FetchUntil(now.Add(-time.Hour)).
+		ComposeText().
+		RemoveClones().
+		SaveToDB().
+		QueueWhenOffline()
+	if err := job.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tg.setOffline(true)
+	job.Run()()
+
+	queued, err := arch.Entities.Outbox.FindPending(ctx, "@test_channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || !strings.Contains(queued[0].Message, "Apple beats earnings estimates.") {
+		t.Fatalf("queued messages = %+v, want the apple news", queued)
+	}
+
+	// News of the next run are queued behind the ones not replayed yet, even if Telegram is back
+	tg.setOffline(false)
+	next := newFakeRSS(t, []rssItem{
+		{Title: "Microsoft raises dividend", Description: "MSFT raised the dividend by 10%.", Link: "https://example.com/msft", Date: now.Add(-time.Minute)},
+	})
+	nextJob := NewJob(c, p, arch, journalist.NewJournalist("Test", []journalist.NewsProvider{journalist.NewRssProvider("Next feed", next.URL)}), nil).
+		FetchUntil(now.Add(-time.Hour)).
+		ComposeText().
+		RemoveClones().
+		SaveToDB().
+		QueueWhenOffline()
+	nextJob.Run()()
+	if messages := tg.sent(); len(messages) != 0 {
+		t.Fatalf("sent %d messages with the non-empty outbox, want 0", len(messages))
+	}
+	tg.setOffline(true)
+
+	// Stale message is dropped rather than posted late
+	stale := &archivist.OutboxMessage{ChannelID: "@test_channel", Message: "Stale news", CreatedAt: now.Add(-2 * time.Hour)}
+	if err := arch.Entities.Outbox.Create(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	outbox := NewOutboxJob(p, arch, time.Hour)
+	outbox.Run()()
+	if pending, _ := arch.Entities.Outbox.FindPending(ctx, "@test_channel"); len(pending) != 3 {
+		t.Fatalf("pending %d messages while offline, want 3", len(pending))
+	}
+
+	tg.setOffline(false)
+	outbox.Run()()
+
+	messages := tg.sent()
+	if len(messages) != 2 || !strings.Contains(messages[0].text, "Apple beats earnings estimates.") ||
+		!strings.Contains(messages[1].text, "Microsoft raises dividend.") {
+		t.Fatalf("sent messages = %+v, want the apple and microsoft news in order", messages)
+	}
+	if pending, _ := arch.Entities.Outbox.FindPending(ctx, "@test_channel"); len(pending) != 0 {
+		t.Errorf("pending %d messages after the replay, want 0", len(pending))
+	}
+
+	var apple archivist.News
+	if err := arch.Entities.News.Conn.WithContext(ctx).Where("hash = ?", queued[0].NewsHash).First(&apple).Error; err != nil {
+		t.Fatal(err)
+	}
+	if apple.PublicationID == "" || apple.PublishedAt.IsZero() {
+		t.Errorf("replayed news = %+v, want publication ID", apple)
+	}
+}
+
+func TestIntegration_JobRecover(t *testing.T) {
+	ctx := context.Background()
+	arch := newTestArchivist(t)
+	tg := newFakeTelegram(t)
+	ai := newFakeOpenAI(t)
+
+	now := time.Now().UTC()
+	feed := newFakeRSS(t, []rssItem{
+		{Title: "Apple beats earnings estimates", Description: "AAPL reported record revenue.", Link: "https://example.com/apple", Date: now.Add(-time.Minute)},
+	})
+
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+	j := journalist.NewJournalist("Test", []journalist.NewsProvider{journalist.NewRssProvider("Test feed", feed.URL)})
+
+	job := NewJob(c, tg.publisher(t, "@test_channel"), arch, j, nil).
+		FetchUntil(now.Add(-time.Hour)).
+		ComposeText().
+		RemoveClones().
+		SaveToDB().
+		RepublishPending(time.Hour)
+	if err := job.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tg.setOffline(true)
+	job.Run()()
+
+	pending, err := arch.Entities.News.FindComposedUnpublished(ctx, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ComposedText != "Apple beats earnings estimates." || pending[0].JobName != job.name {
+		t.Fatalf("pending news = %+v, want the composed apple news", pending)
+	}
+
+	// The feed item is a duplicate now, so the next run doesn't compose it again
+	tg.setOffline(false)
+	job.Run()()
+	if messages := tg.sent(); len(messages) != 0 {
+		t.Fatalf("sent %d messages by the run, want 0", len(messages))
+	}
+
+	// News of the other jobs are not recovered
+	other := NewJob(c, tg.publisher(t, "@test_channel"), arch, journalist.NewJournalist("Other", nil), nil).
+		ComposeText().
+		SaveToDB().
+		RepublishPending(time.Hour)
+	other.Recover()()
+	if messages := tg.sent(); len(messages) != 0 {
+		t.Fatalf("sent %d messages by the other job recovery, want 0", len(messages))
+	}
+
+	// The recovery publishes unmuted if the mutes can't be read, same as the run
+	if err := arch.Entities.News.Conn.Migrator().DropTable(&archivist.Mute{}); err != nil {
+		t.Fatal(err)
+	}
+	job.Recover()()
+
+	messages := tg.sent()
+	if len(messages) != 1 || !strings.Contains(messages[0].text, "Apple beats earnings estimates.") {
+		t.Fatalf("sent messages = %+v, want only the apple news", messages)
+	}
+	if pending, _ := arch.Entities.News.FindComposedUnpublished(ctx, time.Hour); len(pending) != 0 {
+		t.Errorf("pending %d news after the recovery, want 0", len(pending))
+	}
+
+	job.Recover()()
+	if messages := tg.sent(); len(messages) != 1 {
+		t.Errorf("sent %d messages after the next recovery, want 1", len(messages))
+	}
+}
+
+// newTestArchivist starts the Postgres container and creates the Archivist connected to it.
+func newTestArchivist(t *testing.T) *archivist.Archivist {
+	t.Helper()
+	ctx := context.Background()
+
+	pg, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("finthread"),
+		postgres.WithUsername("finthread"),
+		postgres.WithPassword("finthread"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute),
+		),
+	)
+	if err != nil {
+		t.Fatalf("error starting postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pg.Terminate(ctx); err != nil {
+			t.Errorf("error terminating postgres: %v", err)
+		}
+	})
+
+	dsn, err := pg.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := archivist.NewArchivist(dsn)
+	if err != nil {
+		t.Fatalf("error creating archivist: %v", err)
+	}
+
+	return a
+}
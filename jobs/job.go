@@ -1,51 +1,95 @@
 package jobs
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/chartist"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/links"
 	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/internal/web"
 	"github.com/samgozman/fin-thread/journalist"
 	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Job will be executed by the scheduler and will fetch, compose, publish and save news to the database.
 type Job struct {
-	name       string                       // name of the job
-	composer   *composer.Composer           // composer that will compose text for the article using OpenAI
-	publisher  *publisher.TelegramPublisher // publisher that will publish news to the channel
-	archivist  *archivist.Archivist         // archivist that will save news to the database
-	journalist *journalist.Journalist       // journalist that will fetch news
-	stocks     *stocks.StockMap             // stocks that will be used to filter news and compose meta (optional). TODO: use more fields from Stock struct
-	logger     *slog.Logger                 // special logger for the job
-	options    *jobOptions                  // job options
+	name       string                 // name of the job
+	composer   *composer.Composer     // composer that will compose text for the article using OpenAI
+	publisher  publisher.Publisher    // publisher that will publish news to the channel
+	archivist  *archivist.Archivist   // archivist that will save news to the database
+	journalist *journalist.Journalist // journalist that will fetch news
+	stocks     *stocks.StockMap       // stocks that will be used to filter news and enrich meta with sectors (optional)
+	universe   *stocks.Universe       // listed tickers refreshed by the ListingsJob, overrides Job.stocks for OmitUnlistedStocks (optional)
+	logger     *slog.Logger           // special logger for the job
+	options    *jobOptions            // job options
+	running    sync.Mutex             // held by the run and the recovery, so they never publish the same news
 }
 
 // jobOptions holds job options needed for the job execution.
 type jobOptions struct {
-	until              time.Time       // fetch articles until this date
-	omitSuspicious     bool            // if true, will not publish suspicious articles
-	omitEmptyMetaKeys  *omitKeyOptions // holds keys that will omit news if empty. Note: requires shouldComposeText to be true
-	omitIfAllKeysEmpty bool            // if true, will omit articles with empty meta for all keys. Note: requires shouldComposeText to be set
-	omitUnlistedStocks bool            // if true, will omit articles with stocks unlisted in the Job.stocks
-	shouldComposeText  bool            // if true, will compose text for the article using OpenAI. If false, will use original title and description
-	shouldSaveToDB     bool            // if true, will save all news to the database
-	shouldRemoveClones bool            // if true, will remove duplicated news found in the DB. Note: requires shouldSaveToDB to be true
+	until              time.Time         // fetch articles until this date
+	omitSuspicious     bool              // if true, will not publish suspicious articles
+	omitEmptyMetaKeys  *omitKeyOptions   // holds keys that will omit news if empty. Note: requires shouldComposeText to be true
+	unknownMetaKeys    []metaKey         // keys passed to OmitEmptyMeta that don't exist in composer.ComposedMeta (reported by Validate)
+	omitIfAllKeysEmpty bool              // if true, will omit articles with empty meta for all keys. Note: requires shouldComposeText to be set
+	omitUnlistedStocks bool              // if true, will omit articles with stocks unlisted in the Job.stocks
+	shouldComposeText  bool              // if true, will compose text for the article using OpenAI. If false, will use original title and description
+	shouldSaveToDB     bool              // if true, will save all news to the database
+	shouldRemoveClones bool              // if true, will remove duplicated news found in the DB. Note: requires shouldSaveToDB to be true
+	selectLimit        int               // if > 0, will select up to N news before composing them with a stronger model. Note: requires shouldComposeText to be true
+	watchlist          watchlist         // news with these tickers bypass the AI filter and empty meta omission and get a distinctive format
+	chartsQuotes       *quotes.Quotes    // if set, will attach the intraday chart of the first ticker to the published news. Note: requires shouldComposeText to be true
+	foreignStocks      *countryFilter    // if set, will omit or demote articles whose tickers are all listed outside the allowed countries
+	minMarketCap       float64           // if > 0, will omit articles whose tickers all have market cap (USD) below this value in the Job.stocks
+	etfs               *stocks.ETFMap    // if set, will move ETF tickers from the composed tickers to markets. Note: requires shouldComposeText to be true
+	constituents       int               // if > 0, will list up to N largest constituents of the market in the market news. Note: requires etfs to be set
+	sectorRoutes       sectorRoutes      // publishers of the sector channels where news of the sector tickers are cross-posted
+	mirror             *mirrorChannel    // if set, will publish the translated copy of the published news to the paired channel
+	shadowFilter       bool              // if true, will record the decision of the additional AI filter on news without enforcing it
+	shadowFilterOpts   []composer.Option // options (prompt, model) of the shadow AI filter
+	tagRules           tagRules          // deterministic tagging rules evaluated before the AI stages, important ones skip the AI filter
+	trust              *providerTrust    // if set, trust weights of the providers modulate the AI filter, empty meta omission and publishing order
+	archiver           *linkArchiver     // if set, will save the web archive snapshots of the published news links. Note: requires shouldSaveToDB to be true
+	permalinkBaseURL   string            // if set, will append the link to the news page on the web server. Note: requires shouldSaveToDB to be true
+	windowEnd          time.Time         // if set, will fetch only news published until this date since the until date, ignoring the checkpoint
+	checkpoint         bool              // if true, will fetch news since the persisted end of the last successful run window. Note: requires shouldSaveToDB to be true
+	checkpointOverlap  time.Duration     // overlap of the fetch window with the previous one
+	checkpointLookback time.Duration     // if > 0, the fetch window never starts earlier than this duration ago
+	includeRatings     bool              // if true, analyst rating changes skip the AI filter and are published in the structured format
+	omitRatings        bool              // if true, analyst rating changes are omitted
+	queueWhenOffline   bool              // if true, news not published because of the network errors are queued to the outbox. Note: requires shouldSaveToDB to be true
+	linkDecorator      *links.Decorator  // decorates the ticker links of the published news (links.Default if nil)
+	republishMaxAge    time.Duration     // if > 0, news saved pending publication, but not published, are republished up to this age. Note: requires shouldSaveToDB to be true
+	stageAttempts      uint              // if > 1, transient failures of the compose and publish stages are retried up to this number of attempts
+	stageRetryDelay    time.Duration     // delay between the attempts of the stage
+	timeout            time.Duration     // timeout of the run (defaultJobTimeout if 0), the end of it is reserved for the stages after composing
+	recentTexts        int               // if > 0, up to N texts recently published on the same topics are passed to the composer. Note: requires shouldSaveToDB to be true
+	recentPeriod       time.Duration     // period in which the recently published texts are looked up
+	storyGap           time.Duration     // if > 0, news are linked to the developing stories active in this period. Note: requires shouldSaveToDB to be true
+	verifyNumbers      bool              // if true, news with the composed figures missing in the original are held for moderation. Note: requires shouldSaveToDB to be true
+	compliance         Compliance        // if set, the disclaimer, attribution and prohibited categories of the channel jurisdiction are applied
+	buttons            []Button          // kinds of the buttons under the published news in their order
 }
 
 // NewJob creates a new Job instance.
 func NewJob(
 	composer *composer.Composer,
-	publisher *publisher.TelegramPublisher,
+	publisher publisher.Publisher,
 	archivist *archivist.Archivist,
 	journalist *journalist.Journalist,
 	stocks *stocks.StockMap,
@@ -75,6 +119,7 @@ func (job *Job) OmitSuspicious() *Job {
 }
 
 // OmitEmptyMeta will omit news with empty meta for the given key from composer.ComposedMeta.
+// Unknown keys are reported by Validate.
 // Note: requires ComposeText to be set.
 func (job *Job) OmitEmptyMeta(key metaKey) *Job {
 	if job.options.omitEmptyMetaKeys == nil {
@@ -88,7 +133,7 @@ func (job *Job) OmitEmptyMeta(key metaKey) *Job {
 	case MetaHashtags:
 		job.options.omitEmptyMetaKeys.emptyHashtags = true
 	default:
-		panic(fmt.Errorf("unknown meta key: %s", key))
+		job.options.unknownMetaKeys = append(job.options.unknownMetaKeys, key)
 	}
 	return job
 }
@@ -109,6 +154,14 @@ func (job *Job) ComposeText() *Job {
 	return job
 }
 
+// SelectBeforeCompose enables the two-stage compose: first a cheap AI call selects up to `limit` most important
+// news from the whole batch, then only the selected news are composed with a stronger model.
+// Note: requires ComposeText to be set.
+func (job *Job) SelectBeforeCompose(limit int) *Job {
+	job.options.selectLimit = limit
+	return job
+}
+
 // RemoveClones sets the flag that will remove duplicated news found in the DB.
 func (job *Job) RemoveClones() *Job {
 	job.options.shouldRemoveClones = true
@@ -127,65 +180,456 @@ func (job *Job) OmitUnlistedStocks() *Job {
 	return job
 }
 
-// Run return job function that will be executed by the scheduler.
-func (job *Job) Run() JobFunc {
-	return func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
-		defer cancel()
+// UseUniverse sets the listed tickers checked by OmitUnlistedStocks instead of the Job.stocks,
+// so the new listings and delistings found by the ListingsJob are applied without restart.
+func (job *Job) UseUniverse(u *stocks.Universe) *Job {
+	job.universe = u
+	return job
+}
 
-		tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s", job.name))
-		tx.Op = "job"
+// OmitForeignStocks sets the flag that will omit articles whose tickers are all from countries
+// other than the provided ones (e.g. "United States"), based on the Stock.Country data. Tickers with unknown country are kept.
+// Note: requires Job.stocks with country data and ComposeText to be set.
+func (job *Job) OmitForeignStocks(countries ...string) *Job {
+	job.options.foreignStocks = &countryFilter{countries: countries}
+	return job
+}
 
-		// Sentry performance monitoring
-		hub := sentry.GetHubFromContext(ctx)
-		if hub == nil {
-			hub = sentry.CurrentHub().Clone()
-			ctx = sentry.SetHubOnContext(ctx, hub)
-		}
+// DemoteForeignStocks works like OmitForeignStocks, but publishes such articles after all others instead of omitting them.
+func (job *Job) DemoteForeignStocks(countries ...string) *Job {
+	job.options.foreignStocks = &countryFilter{countries: countries, demote: true}
+	return job
+}
 
-		defer tx.Finish()
-		defer hub.Flush(2 * time.Second)
-		defer hub.Recover(nil)
+// OmitBelowMarketCap sets the flag that will omit articles whose tickers all have market cap below minUSD
+// (micro caps are disproportionately pump-and-dump noise). Tickers with unknown market cap are kept.
+// Note: requires Job.stocks with market cap data and ComposeText to be set.
+func (job *Job) OmitBelowMarketCap(minUSD float64) *Job {
+	job.options.minMarketCap = minUSD
+	return job
+}
 
-		news, err := job.getLatestNews(ctx, tx, hub)
-		if len(news) == 0 || err != nil {
-			return
-		}
+// SeparateETFs sets the ETFs data that will be used to move ETF tickers (which AI is told to ignore, but sometimes doesn't)
+// from the composed tickers to markets, so ticker validation options will only check stocks.
+// Note: requires ComposeText to be set.
+func (job *Job) SeparateETFs(etfs *stocks.ETFMap) *Job {
+	job.options.etfs = etfs
+	return job
+}
 
-		news, err = job.removeDuplicates(ctx, tx, hub, news)
-		if err != nil || len(news) == 0 {
-			return
-		}
+// ListConstituents sets the number of the largest constituents of the market (e.g. AAPL, MSFT for SPX)
+// that will be listed in the published news about the market without tickers.
+// Note: requires SeparateETFs to be set.
+func (job *Job) ListConstituents(n int) *Job {
+	job.options.constituents = n
+	return job
+}
 
-		news, err = job.filterByComposer(ctx, tx, hub, news)
-		if err != nil || len(news) == 0 {
-			return
-		}
+// ShadowFilter sets the flag that will run an additional AI filter with the given options (e.g. composer.UseFilterPrompt)
+// and record its decision on the saved news (News.WouldFilter) without enforcing it. It is used to trial a new filter
+// prompt or model against production traffic without changing what's published.
+// Note: requires SaveToDB to be set.
+func (job *Job) ShadowFilter(opts ...composer.Option) *Job {
+	job.options.shadowFilter = true
+	job.options.shadowFilterOpts = opts
+	return job
+}
 
-		composedNews, err := job.composeNews(ctx, tx, hub, news)
-		if err != nil || len(composedNews) == 0 {
-			return
-		}
+// Watchlist sets the tickers whose news bypass the stricter filters (AI filter, empty meta omission)
+// and are published with a distinctive format. News of other tickers keep the current strict path.
+func (job *Job) Watchlist(tickers ...string) *Job {
+	job.options.watchlist = newWatchlist(tickers)
+	return job
+}
 
-		dbNews, err := job.saveNews(ctx, tx, hub, news, composedNews)
-		if err != nil || len(dbNews) == 0 {
-			return
-		}
+// TagRules sets the deterministic tagging rules. Rules are matched against the original news before the AI stages:
+// news matching the important rules skip the AI filter, and the tags of the matching rules are always added
+// to the composed meta.
+// Invalid rules are skipped, use TagRule.Validate to check them beforehand.
+// Note: requires ComposeText to be set.
+func (job *Job) TagRules(rules ...TagRule) *Job {
+	job.options.tagRules = newTagRules(rules)
+	return job
+}
 
-		filteredNews, err := job.prepublishFilter(tx, hub, dbNews)
-		if err != nil || len(filteredNews) == 0 {
-			return
+// ProviderTrust sets the trust weights of the news providers by name, "*" key sets the weight of unknown providers (1 by default).
+// News of the trusted providers (weight >= 2) bypass the AI filter and empty meta omission,
+// news of the low-trust providers (weight < 1) are published only with tickers and providers with zero weight are never published.
+// Published news are ordered by the provider weight.
+func (job *Job) ProviderTrust(weights map[string]float64) *Job {
+	job.options.trust = newProviderTrust(weights)
+	return job
+}
+
+// AttachCharts sets the quotes source that will be used to attach intraday price charts of the first ticker
+// to the high-importance news: the watchlist news and the news matching the important tagging rules.
+// Note: requires ComposeText to be set.
+func (job *Job) AttachCharts(q *quotes.Quotes) *Job {
+	job.options.chartsQuotes = q
+	return job
+}
+
+// RouteSectors sets the publishers of the sector channels (by the sector name, case-insensitive).
+// Published news with tickers of the sector will be cross-posted to the sector channel.
+// Note: requires Job.stocks with sectors data and ComposeText to be set.
+func (job *Job) RouteSectors(routes map[string]publisher.Publisher) *Job {
+	job.options.sectorRoutes = newSectorRoutes(routes)
+	return job
+}
+
+// MirrorTranslation sets the publisher of the paired channel where the published news are mirrored
+// in the given language (e.g. "Spanish"). The translation is made by the composer from the formatted post.
+// Publication IDs of the translated posts are stored with the news if Job.SaveToDB is set.
+func (job *Job) MirrorTranslation(p publisher.Publisher, language string) *Job {
+	if p != nil {
+		job.options.mirror = &mirrorChannel{publisher: p, language: language}
+	}
+	return job
+}
+
+// AppendPermalinks appends the link to the news page on the web server with the public base URL
+// (see web.Server) to the published news, so the post can be shared outside Telegram. Note: requires SaveToDB to be set.
+func (job *Job) AppendPermalinks(baseURL string) *Job {
+	job.options.permalinkBaseURL = baseURL
+	return job
+}
+
+// DecorateLinks sets the decorator of the ticker links of the published news: UTM parameters of the channel,
+// signed click-tracking redirect of the web server and the link shortener. Links get the default UTM parameters without it.
+func (job *Job) DecorateLinks(d *links.Decorator) *Job {
+	job.options.linkDecorator = d
+	return job
+}
+
+// ResumeFromCheckpoint makes the job fetch news published since the end of the fetch window of its last successful run
+// (persisted, so it survives restarts) minus the overlap, instead of the fixed FetchUntil date. The overlap covers
+// feeds that add items with earlier dates, overlapping news are removed by RemoveClones. The window never starts
+// earlier than maxLookback ago (e.g. after a long downtime). FetchUntil date is used until the first checkpoint is saved.
+// Note: requires SaveToDB and RemoveClones to be set.
+func (job *Job) ResumeFromCheckpoint(overlap, maxLookback time.Duration) *Job {
+	job.options.checkpoint = true
+	job.options.checkpointOverlap = overlap
+	job.options.checkpointLookback = maxLookback
+	return job
+}
+
+// FetchWindow fixes the fetch window of the job to the news published from the given date until the other one,
+// e.g. to replay the past window while debugging. It replaces the FetchUntil date and the checkpoint of
+// ResumeFromCheckpoint, which is neither read nor saved.
+func (job *Job) FetchWindow(from, to time.Time) *Job {
+	job.options.until = from
+	job.options.windowEnd = to
+	return job
+}
+
+// ArchiveLinks sets the web archive (e.g. wayback.Wayback) that will capture snapshots of the published news links
+// in the background and save them as the news archive URL, so there is a fallback when the original link rots.
+// Note: requires SaveToDB to be set.
+func (job *Job) ArchiveLinks(s snapshotter) *Job {
+	job.options.archiver = newLinkArchiver(s, func(ctx context.Context, n *archivist.News) error {
+		return job.archivist.Entities.News.Update(ctx, n)
+	}, job.logger)
+	return job
+}
+
+// IncludeRatingChanges sets the flag that publishes analyst rating changes (see journalist.Journalist.DetectRatingChanges)
+// in the structured format: firm, ticker, from → to rating and price target. They skip the AI filter.
+func (job *Job) IncludeRatingChanges() *Job {
+	job.options.includeRatings = true
+	return job
+}
+
+// OmitRatingChanges sets the flag that omits analyst rating changes (see journalist.Journalist.DetectRatingChanges).
+func (job *Job) OmitRatingChanges() *Job {
+	job.options.omitRatings = true
+	return job
+}
+
+// QueueWhenOffline sets the flag that queues the formatted news to the database outbox if Telegram is unreachable
+// (network errors), instead of failing the run. Queued news are replayed in order by the OutboxJob.
+// Charts are not queued, the replayed news are published as text.
+func (job *Job) QueueWhenOffline() *Job {
+	job.options.queueWhenOffline = true
+	return job
+}
+
+// RepublishPending sets the max age of the news saved with the composed text, but not published (e.g. Telegram
+// error, the run timed out or the process crashed before publishing). They are published by the Job.Recover job
+// instead of being fetched and composed again. Older news are left unpublished rather than posted late.
+// Note: requires SaveToDB and ComposeText to be set.
+func (job *Job) RepublishPending(maxAge time.Duration) *Job {
+	job.options.republishMaxAge = maxAge
+	return job
+}
+
+// RetryStages sets the number of attempts of the compose and publish stages with the delay between them.
+// Only transient failures (network errors, rate limits, server errors) are retried within the stage deadline,
+// so a single failed request doesn't abort the whole run. The news are republished only if they are known
+// to be unpublished (see publishRetryable).
+func (job *Job) RetryStages(attempts uint, delay time.Duration) *Job {
+	job.options.stageAttempts = attempts
+	job.options.stageRetryDelay = delay
+	return job
+}
+
+// AvoidRepetition passes up to the limit of the texts published to the channel in the period on the tickers
+// and hashtags mentioned in the news to the composer, so it doesn't repeat the same phrasing (e.g. "X rises on Y")
+// many times a day.
+// Note: requires SaveToDB and ComposeText to be set.
+func (job *Job) AvoidRepetition(limit int, period time.Duration) *Job {
+	job.options.recentTexts = limit
+	job.options.recentPeriod = period
+	return job
+}
+
+// TrackStories links the related news published over days (common ticker or hashtag and the similar title)
+// to the developing stories. The continuing news are labeled with the story part, e.g. "Developing: part 3".
+// The story ends if it has no news for the gap period.
+// Note: requires SaveToDB and ComposeText to be set.
+func (job *Job) TrackStories(gap time.Duration) *Job {
+	job.options.storyGap = gap
+	return job
+}
+
+// VerifyNumbers checks that the numeric figures of the composed text (prices, percentages, amounts) appear
+// in the original title and description, as is or rounded. News with invented or altered figures are saved
+// with News.ModerationReason instead of publishing and can be approved by the admin (see admin `/approve`).
+// Note: requires SaveToDB and ComposeText to be set.
+func (job *Job) VerifyNumbers() *Job {
+	job.options.verifyNumbers = true
+	return job
+}
+
+// UseCompliance applies the compliance profile of the channel jurisdiction to the published news: the source
+// attribution is appended, news with the prohibited categories are filtered out and the profile name is recorded
+// as News.ComplianceProfile. The disclaimer is appended by the publisher of the channel (see Compliance.Footer).
+// Note: prohibited categories require ComposeText to be set.
+func (job *Job) UseCompliance(p Compliance) *Job {
+	job.options.compliance = p
+	return job
+}
+
+// Timeout sets the timeout of the run (25s by default). The stages before saving the news (fetching, AI filter
+// and composing) must finish lateStagesEstimate (10s) before the timeout, so the composed news are still saved
+// and published when the AI latency spikes.
+func (job *Job) Timeout(d time.Duration) *Job {
+	job.options.timeout = d
+	return job
+}
+
+// Validate checks that the job options are consistent, e.g. options that work on the composed meta
+// require ComposeText to be set. It should be called before scheduling the job, since inconsistent options
+// are silently ignored at runtime.
+func (job *Job) Validate() error {
+	var errs []error
+	requires := func(set bool, option, requirement string) {
+		if set {
+			errs = append(errs, fmt.Errorf("%s requires %s to be set", option, requirement))
 		}
+	}
 
-		publishedNews, err := job.publish(tx, hub, filteredNews)
-		if err != nil || len(publishedNews) == 0 {
-			return
+	o := job.options
+	for _, key := range o.unknownMetaKeys {
+		errs = append(errs, fmt.Errorf("OmitEmptyMeta: unknown meta key %q", key))
+	}
+	if o.selectLimit < 0 {
+		errs = append(errs, fmt.Errorf("SelectBeforeCompose: limit must be positive, got %d", o.selectLimit))
+	}
+	if o.constituents < 0 {
+		errs = append(errs, fmt.Errorf("ListConstituents: number must be positive, got %d", o.constituents))
+	}
+	if o.minMarketCap < 0 {
+		errs = append(errs, fmt.Errorf("OmitBelowMarketCap: market cap must be positive, got %v", o.minMarketCap))
+	}
+	if !o.windowEnd.IsZero() && !o.windowEnd.After(o.until) {
+		errs = append(errs, fmt.Errorf("FetchWindow: end %s must be after start %s",
+			o.windowEnd.Format(time.RFC3339), o.until.Format(time.RFC3339)))
+	}
+
+	requires(o.shouldRemoveClones && !o.shouldSaveToDB, "RemoveClones", "SaveToDB")
+	requires(o.shadowFilter && !o.shouldSaveToDB, "ShadowFilter", "SaveToDB")
+	requires(o.archiver != nil && !o.shouldSaveToDB, "ArchiveLinks", "SaveToDB")
+	requires(o.permalinkBaseURL != "" && !o.shouldSaveToDB, "AppendPermalinks", "SaveToDB")
+	requires(o.checkpoint && !o.shouldSaveToDB, "ResumeFromCheckpoint", "SaveToDB")
+	requires(o.checkpoint && !o.shouldRemoveClones, "ResumeFromCheckpoint", "RemoveClones")
+	requires(o.queueWhenOffline && !o.shouldSaveToDB, "QueueWhenOffline", "SaveToDB")
+	requires(o.republishMaxAge > 0 && !o.shouldSaveToDB, "RepublishPending", "SaveToDB")
+	if o.republishMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("RepublishPending: max age must be positive, got %s", o.republishMaxAge))
+	}
+	if o.checkpointOverlap < 0 || o.checkpointLookback < 0 {
+		errs = append(errs, fmt.Errorf("ResumeFromCheckpoint: durations must be positive, got %s and %s",
+			o.checkpointOverlap, o.checkpointLookback))
+	}
+	if o.timeout != 0 && o.timeout <= lateStagesEstimate {
+		errs = append(errs, fmt.Errorf("Timeout: must be longer than %s reserved for the stages after composing, got %s",
+			lateStagesEstimate, o.timeout))
+	}
+	requires(o.recentTexts > 0 && !o.shouldSaveToDB, "AvoidRepetition", "SaveToDB")
+	if o.recentTexts < 0 || o.recentPeriod < 0 {
+		errs = append(errs, fmt.Errorf("AvoidRepetition: limit and period must be positive, got %d and %s",
+			o.recentTexts, o.recentPeriod))
+	}
+	requires(o.storyGap > 0 && !o.shouldSaveToDB, "TrackStories", "SaveToDB")
+	if o.storyGap < 0 {
+		errs = append(errs, fmt.Errorf("TrackStories: gap must be positive, got %s", o.storyGap))
+	}
+	requires(o.verifyNumbers && !o.shouldSaveToDB, "VerifyNumbers", "SaveToDB")
+	if o.compliance.enabled() {
+		if err := o.compliance.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("UseCompliance: %w", err))
 		}
+	}
+	requires(o.constituents > 0 && o.etfs == nil, "ListConstituents", "SeparateETFs")
+	for _, kind := range unknownButtons(o.buttons) {
+		errs = append(errs, fmt.Errorf("AddButtons: unknown button %q", kind))
+	}
+	if o.includeRatings && o.omitRatings {
+		errs = append(errs, errors.New("IncludeRatingChanges and OmitRatingChanges are mutually exclusive"))
+	}
 
-		err = job.updateNews(ctx, tx, hub, publishedNews)
+	if !o.shouldComposeText {
+		requires(o.omitEmptyMetaKeys != nil || len(o.unknownMetaKeys) > 0, "OmitEmptyMeta", "ComposeText")
+		requires(o.omitIfAllKeysEmpty, "OmitIfAllKeysEmpty", "ComposeText")
+		requires(o.selectLimit > 0, "SelectBeforeCompose", "ComposeText")
+		requires(o.foreignStocks != nil, "OmitForeignStocks", "ComposeText")
+		requires(o.minMarketCap > 0, "OmitBelowMarketCap", "ComposeText")
+		requires(o.etfs != nil, "SeparateETFs", "ComposeText")
+		requires(len(o.tagRules) > 0, "TagRules", "ComposeText")
+		requires(o.chartsQuotes != nil, "AttachCharts", "ComposeText")
+		requires(len(o.sectorRoutes) > 0, "RouteSectors", "ComposeText")
+		requires(o.republishMaxAge > 0, "RepublishPending", "ComposeText")
+		requires(o.recentTexts > 0, "AvoidRepetition", "ComposeText")
+		requires(o.storyGap > 0, "TrackStories", "ComposeText")
+		requires(o.verifyNumbers, "VerifyNumbers", "ComposeText")
+		requires(len(o.compliance.Prohibited) > 0, "UseCompliance prohibited categories", "ComposeText")
+		requires(slices.Contains(o.buttons, ButtonChart) || slices.Contains(o.buttons, ButtonTicker),
+			"AddButtons chart and ticker buttons", "ComposeText")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid options of %s: %w", job.name, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Run return job function that will be executed by the scheduler.
+// Stages disabled by the job options pass the news through, so the run stops only if there is nothing left to publish.
+// With ResumeFromCheckpoint the end of the fetch window is saved as the checkpoint if no stage failed.
+func (job *Job) Run() JobFunc {
+	timeout := cmp.Or(job.options.timeout, defaultJobTimeout)
+	return WithInstrumentationTimeout(job.name, timeout, func(ctx context.Context, r *JobRun) {
+		r.SetChannel(job.publisher.Channel())
+		job.running.Lock()
+		defer job.running.Unlock()
+
+		from, to, err := job.fetchWindow(ctx, r.Tx, r.Hub)
 		if err != nil {
+			r.Stage("checkpoint", 0, err)
 			return
 		}
+
+		job.runStages(ctx, r, from)
+
+		if job.options.checkpoint && job.options.windowEnd.IsZero() && !r.Failed() {
+			job.saveCheckpoint(ctx, r.Tx, r.Hub, to)
+		}
+	})
+}
+
+// runStages fetches the news published since the given date and runs them through the job stages.
+// Each stage gets the sub-deadline that reserves the estimated time of the stages after it (see lateStagesEstimate),
+// so the slow fetching or composing fails on its own deadline instead of leaving no time to save and publish.
+func (job *Job) runStages(ctx context.Context, r *JobRun, from time.Time) {
+	tx, hub := r.Tx, r.Hub
+
+	composeCtx, cancel := withReserve(ctx, lateStagesEstimate)
+	defer cancel()
+	saveCtx, cancel := withReserve(ctx, publishEstimate+updateEstimate)
+	defer cancel()
+	publishCtx, cancel := withReserve(ctx, updateEstimate)
+	defer cancel()
+
+	news, err := job.getLatestNews(composeCtx, tx, hub, from)
+	r.Stage("fetched", len(news), err)
+	if len(news) == 0 || err != nil {
+		return
+	}
+
+	news, err = job.removeDuplicates(composeCtx, tx, hub, news)
+	r.Stage("unique", len(news), err)
+	if err != nil || len(news) == 0 {
+		return
+	}
+
+	// Rules are evaluated before the AI stages: important news skip the filter and the tags are added to the composed meta
+	tagged := job.options.tagRules.match(news)
+	if len(job.options.tagRules) > 0 {
+		r.Stage("tagged", len(tagged), nil)
+	}
+
+	wouldFilter, err := job.shadowFilterByComposer(composeCtx, tx, hub, news)
+	if job.options.shadowFilter {
+		r.Stage("shadowFiltered", wouldFilter, err)
+	}
+
+	news, err = job.filterByComposer(composeCtx, tx, hub, news, tagged)
+	r.Stage("kept", len(news.RemoveFlagged()), err)
+	if err != nil || len(news) == 0 {
+		return
+	}
+
+	composedNews, err := job.composeNews(composeCtx, r, news)
+	tagged.apply(composedNews)
+	if job.options.shouldComposeText {
+		r.Stage("composed", len(composedNews), err)
+	}
+	if err != nil || (job.options.shouldComposeText && len(composedNews) == 0) {
+		return
+	}
+
+	dbNews, err := job.saveNews(saveCtx, tx, hub, news, composedNews)
+	r.Stage("saved", len(dbNews), err)
+	if err != nil || len(dbNews) == 0 {
+		return
+	}
+	job.reportModeration(r, dbNews)
+
+	// The news are already saved, so they are published unmuted rather than left pending until the recovery
+	mutes, err := job.findMutes(saveCtx, tx, hub)
+	if err != nil {
+		r.Stage("mutes", 0, err)
+	}
+
+	filteredNews, err := job.prepublishFilter(tx, hub, dbNews, mutes)
+	r.Stage("prepublished", len(filteredNews), err)
+	if err != nil || len(filteredNews) == 0 {
+		return
+	}
+
+	// News saved pending publication, but skipped by the prepublish filter, are not recovered
+	skipped := lo.Filter(lo.Without(dbNews, filteredNews...), func(n *archivist.News, _ int) bool {
+		return n.PublishPending
+	})
+	if err := job.markPending(saveCtx, tx, hub, skipped, false); err != nil {
+		r.Stage("skipped", 0, err)
+		return
+	}
+
+	job.assignStories(saveCtx, tx, hub, filteredNews)
+
+	// News published before the error are still updated, so they are not republished
+	publishedNews, err := job.publish(publishCtx, r, "published", filteredNews)
+	r.Stage("published", len(publishedNews), err)
+	if len(publishedNews) == 0 {
+		return
+	}
+
+	err = job.updateNews(ctx, tx, hub, publishedNews)
+	r.Stage("updated", len(publishedNews), err)
+	if err == nil && job.options.archiver != nil && job.options.shouldSaveToDB {
+		job.options.archiver.enqueue(publishedNews)
 	}
 }
 
@@ -194,9 +638,32 @@ func (job *Job) filterByComposer(
 	tx *sentry.Span,
 	hub *sentry.Hub,
 	news journalist.NewsList,
+	tagged taggedNews,
 ) (journalist.NewsList, error) {
+	// News matching the important tagging rules don't need the AI decision.
+	// Filter marks the given news in place, so they are kept in the original list.
+	toFilter := news
+	if len(tagged) > 0 {
+		toFilter = lo.Filter(news, func(n *journalist.News, _ int) bool {
+			return !tagged.important(n)
+		})
+	}
+
+	// Analyst rating changes are published or omitted by the job options without the AI decision
+	if job.options.includeRatings || job.options.omitRatings {
+		for _, n := range news {
+			if n.RatingChange != nil && job.options.omitRatings {
+				n.IsFiltered = true
+				n.FilteredReason = ratingChangeReason
+			}
+		}
+		toFilter = lo.Filter(toFilter, func(n *journalist.News, _ int) bool {
+			return n.RatingChange == nil
+		})
+	}
+
 	span := tx.StartChild("filterByComposer.Filter")
-	news, err := job.composer.Filter(ctx, news)
+	_, err := job.composer.Filter(ctx, toFilter)
 	span.Finish()
 	if err != nil {
 		e := fmt.Errorf("[%s][Filter]: %w", job.name, err)
@@ -204,18 +671,132 @@ func (job *Job) filterByComposer(
 		utils.CaptureSentryException("jobComposerFilterError", hub, e)
 		return nil, e
 	}
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("filter returned %d news", len(news)),
-		Level:    sentry.LevelInfo,
-	}, nil)
+
+	// Restore watchlist and trusted providers news filtered out by AI
+	if len(job.options.watchlist) > 0 || job.options.trust != nil {
+		for _, n := range news {
+			if !n.IsFiltered || n.FilteredReason == ratingChangeReason {
+				continue
+			}
+			if job.options.watchlist.mentionedIn(n.Title+"\n"+n.Description) || job.options.trust.trusted(n.ProviderName) {
+				n.IsFiltered = false
+				n.FilteredReason = ""
+			}
+		}
+	}
 
 	return news, nil
 }
 
-func (job *Job) getLatestNews(ctx context.Context, tx *sentry.Span, hub *sentry.Hub) (journalist.NewsList, error) {
+// shadowFilterByComposer runs the shadow AI filter on the copy of news and records its decisions
+// in News.WouldFilter. Returns the number of news it would filter out.
+// Errors are only reported, the shadow filter must never affect the job.
+func (job *Job) shadowFilterByComposer(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	news journalist.NewsList,
+) (int, error) {
+	if !job.options.shadowFilter {
+		return 0, nil
+	}
+
+	shadowNews := make(journalist.NewsList, len(news))
+	for i, n := range news {
+		c := *n
+		shadowNews[i] = &c
+	}
+
+	span := tx.StartChild("shadowFilterByComposer.Filter")
+	shadowNews, err := job.composer.Filter(ctx, shadowNews, job.options.shadowFilterOpts...)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][ShadowFilter]: %w", job.name, err)
+		job.logger.Info(e.Error())
+		utils.CaptureSentryException("jobComposerShadowFilterError", hub, e)
+		return 0, e
+	}
+
+	var count int
+	for i, n := range news {
+		// News flagged before the filter were not decided by the shadow filter
+		if shadowNews[i].IsFiltered && !n.IsFiltered {
+			n.WouldFilter = true
+			n.WouldFilterReason = shadowNews[i].FilteredReason
+			count++
+		}
+	}
+
+	return count, nil
+
+}
+
+// fetchWindow returns the window [from, to] of the news publication dates to fetch. With ResumeFromCheckpoint
+// it starts from the persisted checkpoint (see fetchWindowStart), otherwise from the FetchUntil date.
+// The window fixed by FetchWindow is returned as is.
+func (job *Job) fetchWindow(ctx context.Context, tx *sentry.Span, hub *sentry.Hub) (from, to time.Time, err error) {
+	if !job.options.windowEnd.IsZero() {
+		return job.options.until, job.options.windowEnd, nil
+	}
+
+	to = time.Now()
+	if !job.options.checkpoint || job.archivist == nil {
+		return job.options.until, to, nil
+	}
+
+	span := tx.StartChild("fetchWindow.Checkpoints.Get")
+	checkpoint, err := job.archivist.Entities.Checkpoints.Get(ctx, job.name)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][fetchWindow.Checkpoints.Get]: %w", job.name, err)
+		job.logger.Error(e.Error())
+		utils.CaptureSentryException("jobGetCheckpointError", hub, e)
+		return time.Time{}, time.Time{}, e
+	}
+
+	var last time.Time
+	if checkpoint != nil {
+		last = checkpoint.Time
+	}
+
+	return fetchWindowStart(last, job.options.until, to, job.options.checkpointOverlap, job.options.checkpointLookback), to, nil
+}
+
+// fetchWindowStart returns the start of the fetch window: the end of the last successful run window (checkpoint)
+// minus the overlap, but not earlier than maxLookback before now and not later than the overlap before now
+// (e.g. if the checkpoint is in the future because of the clock skew). Without checkpoint it's the until date.
+func fetchWindowStart(checkpoint, until, now time.Time, overlap, maxLookback time.Duration) time.Time {
+	if checkpoint.IsZero() {
+		return until
+	}
+
+	from := checkpoint.Add(-overlap)
+	if latest := now.Add(-overlap); from.After(latest) {
+		from = latest
+	}
+	if earliest := now.Add(-maxLookback); maxLookback > 0 && from.Before(earliest) {
+		from = earliest
+	}
+
+	return from
+}
+
+// saveCheckpoint saves the end of the fetch window of the successful run, errors are only reported,
+// because the next run will fetch the same window again.
+func (job *Job) saveCheckpoint(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, to time.Time) {
+	span := tx.StartChild("saveCheckpoint.Checkpoints.Save")
+	err := job.archivist.Entities.Checkpoints.Save(ctx, job.name, to)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][saveCheckpoint.Checkpoints.Save]: %w", job.name, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobSaveCheckpointError", hub, e)
+	}
+}
+
+func (job *Job) getLatestNews(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, from time.Time) (journalist.NewsList, error) {
 	span := tx.StartChild("getLatestNews.GetLatestNews")
-	news, err := job.journalist.GetLatestNews(ctx, job.options.until)
+	news, err := job.journalist.GetLatestNews(ctx, from)
 	span.Finish()
 	if err != nil {
 		e := fmt.Errorf("[%s][getLatestNews.GetLatestNews]: %w", job.name, err)
@@ -224,19 +805,21 @@ func (job *Job) getLatestNews(ctx context.Context, tx *sentry.Span, hub *sentry.
 		return nil, e
 	}
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("GetLatestNews returned %d news", len(news)),
-		Level:    sentry.LevelInfo,
-	}, nil)
+	// News published after the end of the window fixed by FetchWindow are left for the scheduled runs
+	if end := job.options.windowEnd; !end.IsZero() {
+		news = lo.Filter(news, func(n *journalist.News, _ int) bool {
+			return !n.Date.After(end)
+		})
+	}
 
 	return news, nil
 }
 
 // removeDuplicates removes duplicated news in place found in the DB.
+// Returns the news as is if RemoveClones or SaveToDB are not set.
 func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) (journalist.NewsList, error) {
 	if !job.options.shouldRemoveClones || !job.options.shouldSaveToDB {
-		return nil, nil
+		return news, nil
 	}
 
 	hashes := make([]string, len(news))
@@ -294,40 +877,94 @@ func (job *Job) removeDuplicates(ctx context.Context, tx *sentry.Span, hub *sent
 		result = append(result, n)
 	}
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("removeDuplicates returned %d news", len(news)),
-		Level:    sentry.LevelInfo,
-	}, nil)
-
 	return result, nil
 }
 
 // composeNews composes text for the article using OpenAI and finds meta.
-func (job *Job) composeNews(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news journalist.NewsList) ([]*composer.ComposedNews, error) {
+// Returns no composed news if ComposeText is not set, the original title and description are published instead.
+// Transient AI errors are retried as the "composed" stage (see Job.RetryStages).
+func (job *Job) composeNews(ctx context.Context, r *JobRun, news journalist.NewsList) ([]*composer.ComposedNews, error) {
 	if !job.options.shouldComposeText {
 		return nil, nil
 	}
 
-	// TODO: Split openai jobs - 1: remove unnecessary news, 2: compose text
-	span := tx.StartChild("composeNews.Compose")
-	composedNews, err := job.composer.Compose(ctx, news)
-	span.Finish()
+	if job.options.selectLimit > 0 {
+		return job.selectAndComposeNews(ctx, r, news)
+	}
+
+	tx, hub := r.Tx, r.Hub
+	opts := job.recentTexts(ctx, tx, hub, news)
+	var composedNews []*composer.ComposedNews
+	err := job.retryStage(ctx, r, "composed", transient, func() (err error) {
+		span := tx.StartChild("composeNews.Compose")
+		composedNews, err = job.composer.Compose(ctx, news, opts...)
+		span.Finish()
+		return err
+	})
 	if err != nil {
 		e := fmt.Errorf("[%s][composeNews.Compose]: %w", job.name, err)
 		utils.CaptureSentryException("jobComposeNewsError", hub, e)
 		return nil, e
 	}
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("composeNews returned %d news", len(composedNews)),
-		Level:    sentry.LevelInfo,
-	}, nil)
+	return composedNews, nil
+}
+
+// selectAndComposeNews selects the most important news from the batch using a cheap model
+// and composes only the selected subset with a stronger model. Watchlist news are composed without the selection.
+func (job *Job) selectAndComposeNews(
+	ctx context.Context,
+	r *JobRun,
+	news journalist.NewsList,
+) ([]*composer.ComposedNews, error) {
+	tx, hub := r.Tx, r.Hub
+
+	// Watchlist news are always composed, as they skip the AI filter.
+	// Select marks the given news in place, so they are kept in the original list.
+	toSelect := news
+	if len(job.options.watchlist) > 0 {
+		toSelect = lo.Filter(news, func(n *journalist.News, _ int) bool {
+			return !job.options.watchlist.mentionedIn(n.Title + "\n" + n.Description)
+		})
+	}
+
+	err := job.retryStage(ctx, r, "composed", transient, func() (err error) {
+		span := tx.StartChild("selectAndComposeNews.Select")
+		_, err = job.composer.Select(ctx, toSelect, job.options.selectLimit)
+		span.Finish()
+		return err
+	})
+	if err != nil {
+		e := fmt.Errorf("[%s][selectAndComposeNews.Select]: %w", job.name, err)
+		utils.CaptureSentryException("jobSelectNewsError", hub, e)
+		return nil, e
+	}
+
+	selected := news.RemoveFlagged()
+
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	opts := job.recentTexts(ctx, tx, hub, selected)
+	var composedNews []*composer.ComposedNews
+	err = job.retryStage(ctx, r, "composed", transient, func() (err error) {
+		span := tx.StartChild("selectAndComposeNews.ComposeWithModel")
+		composedNews, err = job.composer.ComposeWithModel(ctx, selected, composer.ComposeModel, opts...)
+		span.Finish()
+		return err
+	})
+	if err != nil {
+		e := fmt.Errorf("[%s][selectAndComposeNews.ComposeWithModel]: %w", job.name, err)
+		utils.CaptureSentryException("jobComposeNewsError", hub, e)
+		return nil, e
+	}
 
 	return composedNews, nil
 }
 
+// saveNews saves the news with the composed text and meta to the database.
+// Returns the news entities without saving them if SaveToDB is not set.
 func (job *Job) saveNews(
 	ctx context.Context,
 	tx *sentry.Span,
@@ -335,10 +972,29 @@ func (job *Job) saveNews(
 	news journalist.NewsList,
 	composedNews []*composer.ComposedNews,
 ) ([]*archivist.News, error) {
+	dbNews, err := job.newDBNews(news, composedNews)
+	if err != nil {
+		return nil, err
+	}
+
 	if !job.options.shouldSaveToDB {
-		return nil, nil
+		return dbNews, nil
+	}
+
+	span := tx.StartChild("saveNews.News.Create")
+	err = job.archivist.Entities.News.Create(ctx, dbNews)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][saveNews.News.Create]: %w", job.name, err)
+		utils.CaptureSentryException("jobSaveNewsError", hub, e)
+		return nil, e
 	}
 
+	return dbNews, nil
+}
+
+// newDBNews creates the news entities from the original news with the composed text and meta (if composed).
+func (job *Job) newDBNews(news journalist.NewsList, composedNews []*composer.ComposedNews) ([]*archivist.News, error) {
 	if len(news) < len(composedNews) {
 		return nil, errors.New("[Job.saveNews]: Composed news count is more than original news count")
 	}
@@ -352,58 +1008,142 @@ func (job *Job) saveNews(
 	dbNews := make([]*archivist.News, len(news))
 	for i, n := range news {
 		dbNews[i] = &archivist.News{
-			Hash:          n.ID,
-			ChannelID:     job.publisher.ChannelID,
-			ProviderName:  n.ProviderName,
-			OriginalTitle: n.Title,
-			OriginalDesc:  n.Description,
-			OriginalDate:  n.Date,
-			URL:           n.Link,
-			IsSuspicious:  n.IsSuspicious,
-			IsFiltered:    n.IsFiltered,
+			Hash:              n.ID,
+			ChannelID:         job.publisher.Channel(),
+			ProviderName:      n.ProviderName,
+			JobName:           job.name,
+			OriginalTitle:     n.Title,
+			OriginalDesc:      n.Description,
+			OriginalDate:      n.Date,
+			URL:               n.Link,
+			GUID:              n.GUID,
+			IsSuspicious:      n.IsSuspicious,
+			IsFiltered:        n.IsFiltered,
+			FilteredReason:    n.FilteredReason,
+			WouldFilter:       n.WouldFilter,
+			WouldFilterReason: n.WouldFilterReason,
+		}
+		if job.options.compliance.enabled() {
+			dbNews[i].ComplianceProfile = job.options.compliance.Name
+		}
+
+		// GUID is optional, so too long ones are not saved instead of failing the whole batch
+		if len(n.GUID) > 512 {
+			dbNews[i].GUID = ""
 		}
 
 		// Save composed text and meta if found in the map
 		if val, ok := composedNewsMap[n.ID]; ok {
-			meta, err := json.Marshal(composer.ComposedMeta{
-				Tickers:  val.Tickers,
-				Markets:  val.Markets,
+			tickers, markets := val.Tickers, val.Markets
+			if job.options.etfs != nil {
+				var etfs []string
+				tickers, etfs = job.options.etfs.SplitTickers(val.Tickers)
+				markets = composer.NormalizeMarkets(append(slices.Clone(val.Markets), etfs...))
+			}
+
+			composedMeta := composer.ComposedMeta{
+				Tickers:  tickers,
+				Markets:  markets,
 				Hashtags: val.Hashtags,
-			})
+				Sectors:  job.stocks.Sectors(tickers),
+			}
+			meta, err := json.Marshal(composedMeta)
 			if err != nil {
 				return nil, fmt.Errorf("[Job.saveNews][json.Marshal] meta: %w", err)
 			}
 
 			dbNews[i].ComposedText = val.Text
+			dbNews[i].ComposeModel = val.Model
 			dbNews[i].MetaData = meta
+			if !n.IsFiltered && job.options.compliance.prohibits(&composedMeta) {
+				dbNews[i].IsFiltered = true
+				dbNews[i].FilteredReason = complianceReason
+			}
+			if job.options.verifyNumbers {
+				dbNews[i].ModerationReason = moderationReason(unverifiedNumbers(val.Text, n.Title, n.Description))
+			}
+			// Composed news are pending publication until published or skipped, so they are recovered after a failure
+			dbNews[i].PublishPending = job.options.republishMaxAge > 0 && !dbNews[i].IsFiltered && dbNews[i].ModerationReason == ""
 		}
 	}
 
-	span := tx.StartChild("saveNews.News.Create")
-	err := job.archivist.Entities.News.Create(ctx, dbNews)
+	return dbNews, nil
+}
+
+// listed returns true if the ticker is listed in the Job.universe (or in the Job.stocks if the universe is not set).
+// All tickers are listed if neither is set.
+func (job *Job) listed(ticker string) bool {
+	if job.universe != nil {
+		return job.universe.Listed(ticker)
+	}
+	if job.stocks == nil {
+		return true
+	}
+	_, ok := (*job.stocks)[ticker]
+	return ok
+}
+
+// foreignOnly returns true if all tickers have known country outside the countryFilter.countries.
+func (job *Job) foreignOnly(tickers []string) bool {
+	if job.stocks == nil || len(tickers) == 0 {
+		return false
+	}
+
+	for _, t := range tickers {
+		c := (*job.stocks)[t].Country
+		if c == "" || job.options.foreignStocks.allows(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// belowMarketCap returns true if all tickers have known market cap below jobOptions.minMarketCap.
+func (job *Job) belowMarketCap(tickers []string) bool {
+	if job.stocks == nil || len(tickers) == 0 {
+		return false
+	}
+
+	for _, t := range tickers {
+		c, ok := (*job.stocks)[t].MarketCapUSD()
+		if !ok || c >= job.options.minMarketCap {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findMutes finds mute rules that are active right now. Returns no mutes if the job has no archivist.
+func (job *Job) findMutes(ctx context.Context, tx *sentry.Span, hub *sentry.Hub) ([]*archivist.Mute, error) {
+	if job.archivist == nil {
+		return nil, nil
+	}
+
+	span := tx.StartChild("findMutes.Mutes.FindActive")
+	mutes, err := job.archivist.Entities.Mutes.FindActive(ctx, time.Now())
 	span.Finish()
 	if err != nil {
-		e := fmt.Errorf("[%s][saveNews.News.Create]: %w", job.name, err)
-		utils.CaptureSentryException("jobSaveNewsError", hub, e)
+		e := fmt.Errorf("[%s][findMutes.Mutes.FindActive]: %w", job.name, err)
+		job.logger.Error(e.Error())
+		utils.CaptureSentryException("jobFindMutesError", hub, e)
 		return nil, e
 	}
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("saveNews returned %d news", len(dbNews)),
-		Level:    sentry.LevelInfo,
-	}, nil)
-
-	return dbNews, nil
+	return mutes, nil
 }
 
 // prepublishFilter final filter before publishing which will use all options and gathered info from previous steps.
+// News matching any of the active mutes are skipped.
 func (job *Job) prepublishFilter(
 	tx *sentry.Span,
 	hub *sentry.Hub,
 	news []*archivist.News,
+	mutes []*archivist.Mute,
 ) ([]*archivist.News, error) {
 	filteredNews := make([]*archivist.News, 0, len(news))
+	demotedNews := make([]*archivist.News, 0)
 	span := tx.StartChild("prepublishFilter")
 
 NewsRange:
@@ -418,17 +1158,44 @@ NewsRange:
 			continue
 		}
 
+		// News held for moderation are published only after the approval
+		if n.ModerationReason != "" {
+			continue
+		}
+
+		// News without composed meta are published only if ComposeText is not set (with the original text)
+		if n.MetaData == nil && job.options.shouldComposeText {
+			continue
+		}
+
 		// TODO: Change Unmarshal with find method among ComposedNews
 		var meta composer.ComposedMeta
-		err := json.Unmarshal(n.MetaData, &meta)
-		if err != nil {
-			e := fmt.Errorf("[Job.publish][json.Unmarshal] meta: %w. Value: %v", err, n.MetaData)
-			utils.CaptureSentryException("jobPrepublishFilterError", hub, e)
-			return nil, e
+		if n.MetaData != nil {
+			if err := json.Unmarshal(n.MetaData, &meta); err != nil {
+				e := fmt.Errorf("[Job.publish][json.Unmarshal] meta: %w. Value: %v", err, n.MetaData)
+				utils.CaptureSentryException("jobPrepublishFilterError", hub, e)
+				return nil, e
+			}
+		}
+
+		// Skip muted news
+		for _, m := range mutes {
+			if m.Matches(n, &meta) {
+				continue NewsRange
+			}
+		}
+
+		// Watchlist news bypass empty meta omission
+		watched := job.isWatched(n, &meta)
+
+		// Trusted providers news bypass empty meta omission too, low-trust ones require tickers
+		trusted := job.options.trust.trusted(n.ProviderName)
+		if !watched && !job.options.trust.accepts(n.ProviderName, &meta) {
+			continue
 		}
 
 		// Skip news with empty meta if needed
-		if job.options.omitEmptyMetaKeys != nil {
+		if job.options.omitEmptyMetaKeys != nil && !watched && !trusted {
 			if job.options.omitEmptyMetaKeys.emptyTickers && len(meta.Tickers) == 0 {
 				continue
 			}
@@ -441,80 +1208,374 @@ NewsRange:
 		}
 
 		// Skip news with unlisted stocks if needed
-		if job.options.omitUnlistedStocks && job.stocks != nil && len(meta.Tickers) > 0 {
+		if job.options.omitUnlistedStocks && len(meta.Tickers) > 0 {
 			for _, t := range meta.Tickers {
-				if _, ok := (*job.stocks)[t]; !ok {
+				if !job.listed(t) {
 					continue NewsRange
 				}
 			}
 		}
 
+		// Skip news with micro caps only if needed
+		if job.options.minMarketCap > 0 && !watched && job.belowMarketCap(meta.Tickers) {
+			continue
+		}
+
 		// Omit if all keys are empty and omitIfAllKeysEmpty is set
-		if job.options.omitIfAllKeysEmpty &&
+		if job.options.omitIfAllKeysEmpty && !watched && !trusted &&
 			len(meta.Tickers) == 0 &&
 			len(meta.Markets) == 0 &&
 			len(meta.Hashtags) == 0 {
 			continue
 		}
 
+		// Skip or demote news with foreign stocks only if needed
+		if job.options.foreignStocks != nil && !watched && job.foreignOnly(meta.Tickers) {
+			if job.options.foreignStocks.demote {
+				demotedNews = append(demotedNews, n)
+			}
+			continue
+		}
+
 		filteredNews = append(filteredNews, n)
 	}
 
-	span.Finish()
+	// News of the more trusted providers are published first
+	if job.options.trust != nil {
+		slices.SortStableFunc(filteredNews, func(a, b *archivist.News) int {
+			return cmp.Compare(job.options.trust.weight(b.ProviderName), job.options.trust.weight(a.ProviderName))
+		})
+	}
+	filteredNews = append(filteredNews, demotedNews...)
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("prepublishFilter returned %d news", len(filteredNews)),
-		Level:    sentry.LevelInfo,
-	}, nil)
+	span.Finish()
 
 	return filteredNews, nil
 }
 
 // publish publishes the news to the channel and updates dbNews with PublicationID and PublishedAt fields.
+// On error it returns the news published before it along with the error.
+// Transient publication errors are retried as the given stage (see Job.RetryStages).
 func (job *Job) publish(
-	tx *sentry.Span,
-	hub *sentry.Hub,
+	ctx context.Context,
+	r *JobRun,
+	stage string,
 	news []*archivist.News,
 ) ([]*archivist.News, error) {
+	tx, hub := r.Tx, r.Hub
 	updatedNews := make([]*archivist.News, 0, len(news))
+	offline := job.hasQueued(ctx, tx, hub) // once Telegram is unreachable, the rest of the news are queued without trying
 
 	for _, n := range news {
-		// Format news
-		var formattedText string
-		if job.options.shouldComposeText {
-			formattedText = formatNewsWithComposedMeta(*n)
-		} else {
-			formattedText = n.OriginalTitle + "\n" + n.OriginalDesc
+		job.addStoryPart(ctx, tx, hub, n)
+		msg := job.newsMessage(ctx, n)
+
+		if offline {
+			if err := job.enqueue(ctx, tx, hub, n, msg.Markdown()); err != nil {
+				return updatedNews, err
+			}
+			continue
 		}
 
-		span := tx.StartChild("publish.Publish")
-		span.SetTag("news_hash", n.Hash)
-		id, err := job.publisher.Publish(formattedText)
-		span.Finish()
+		var id string
+		var replyIDs []string
+		text := msg // the chart is not cross-posted and mirrored
+		if chart := job.renderChart(ctx, tx, n, msg); chart != nil {
+			msg.Media = []publisher.Media{{Name: "chart.png", Data: chart}}
+		}
+		retryable := publishRetryable(len(msg.Media) == 0 && verifies(job.publisher))
+		err := job.retryStage(ctx, r, stage, retryable, func() (err error) {
+			span := tx.StartChild("publish.Publish")
+			span.SetTag("news_hash", n.Hash)
+			if p, ok := job.publisher.(publisher.AttachmentPublisher); ok {
+				id, replyIDs, err = p.PublishWithReplies(msg)
+			} else {
+				id, err = job.publisher.Publish(msg)
+			}
+			span.Finish()
+			return err
+		})
+
+		// The news reached the primary channel of the publisher.MultiPublisher, so it's not published again
+		if publisher.IsPartial(err) {
+			e := fmt.Errorf("[%s][publish] news %s: %w", job.name, n.Hash, err)
+			job.logger.Warn(e.Error())
+			utils.CaptureSentryException("jobPublishPartialError", hub, e)
+			err = nil
+		}
+
+		if err != nil && job.options.queueWhenOffline && isNetworkError(err) {
+			job.logger.Warn(fmt.Sprintf("[%s] Telegram is unreachable, queueing news", job.name), "error", err)
+			if err := job.enqueue(ctx, tx, hub, n, text.Markdown()); err != nil {
+				return updatedNews, err
+			}
+			offline = true
+			continue
+		}
 
 		if err != nil {
+			job.removeStoryPart(ctx, tx, hub, n)
 			e := fmt.Errorf("[Job.publish][publisher.Publish]: %w", err)
 			utils.CaptureSentryException("jobPublishError", hub, e)
-			return nil, e
+			return updatedNews, e
 		}
 
 		// Save publication data to the entity
 		n.PublicationID = id
+		n.ReplyPubIDs = strings.Join(replyIDs, ",")
 		n.PublishedAt = time.Now()
 
+		job.crossPostToSectors(tx, hub, n, text)
+		job.mirrorTranslation(ctx, tx, hub, n, text.Markdown())
+
 		updatedNews = append(updatedNews, n)
 	}
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  fmt.Sprintf("publishNews returned %d news", len(updatedNews)),
-		Level:    sentry.LevelInfo,
-	}, nil)
-
 	return updatedNews, nil
 }
 
+// newsMessage formats the news as the message: the composed text (the original one if ComposeText is not set
+// or the structured analyst rating change) with the lines and links of the job options.
+func (job *Job) newsMessage(ctx context.Context, n *archivist.News) publisher.Message {
+	var msg publisher.Message
+	decorate := job.decorateLinks(ctx, n)
+	if rc := job.ratingChange(n); rc != nil {
+		msg = formatRatingChange(*n, rc, decorate)
+	} else if job.options.shouldComposeText {
+		msg = formatNewsWithComposedMeta(*n, decorate)
+	} else {
+		msg = publisher.Message{Title: n.OriginalTitle, Body: []publisher.Segment{{Text: n.OriginalDesc}}}
+	}
+	if job.isWatched(n, nil) {
+		msg.Importance = publisher.ImportanceHigh
+	}
+	msg.Body = append(msg.Body, job.formatConstituents(n)...)
+	msg.Body = append(msg.Body, formatStoryPart(n)...)
+	msg.Links = append(msg.Links, job.formatPermalink(n)...)
+	msg.Buttons = append(msg.Buttons, job.formatButtons(n, decorate)...)
+	msg.Footer = append(msg.Footer, job.options.compliance.format(n)...)
+
+	return msg
+}
+
+// formatConstituents returns the line with the largest constituents of the first news market
+// if the news has no tickers and Job.ListConstituents is set. Returns nil otherwise.
+func (job *Job) formatConstituents(n *archivist.News) []publisher.Segment {
+	if job.options.constituents <= 0 || job.options.etfs == nil || n.MetaData == nil {
+		return nil
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil || len(meta.Tickers) > 0 {
+		return nil
+	}
+
+	for _, m := range meta.Markets {
+		if top := job.options.etfs.TopHoldings(m, job.options.constituents); len(top) > 0 {
+			return []publisher.Segment{{Text: fmt.Sprintf("🏛 %s heavyweights: %s", m, strings.Join(top, ", "))}}
+		}
+	}
+
+	return nil
+}
+
+// formatPermalink returns the link to the news page if Job.AppendPermalinks is set.
+// Returns nil otherwise or if the news wasn't saved yet.
+func (job *Job) formatPermalink(n *archivist.News) []publisher.Link {
+	if job.options.permalinkBaseURL == "" || n.ID == uuid.Nil {
+		return nil
+	}
+
+	return []publisher.Link{{Title: "🔗 Permalink", URL: web.Permalink(job.options.permalinkBaseURL, n.ID)}}
+}
+
+// crossPostToSectors publishes the formatted news to the sector channels of its tickers (if routed).
+// Errors are only logged because the news is already published to the main channel.
+func (job *Job) crossPostToSectors(tx *sentry.Span, hub *sentry.Hub, n *archivist.News, msg publisher.Message) {
+	if len(job.options.sectorRoutes) == 0 || n.MetaData == nil {
+		return
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil {
+		return
+	}
+
+	for _, p := range job.options.sectorRoutes.publishers(meta.Sectors) {
+		span := tx.StartChild("publish.crossPostToSectors")
+		span.SetTag("channel_id", p.Channel())
+		_, err := p.Publish(msg)
+		span.Finish()
+		if err != nil {
+			e := fmt.Errorf("[%s][crossPostToSectors] channel %s: %w", job.name, p.Channel(), err)
+			job.logger.Warn(e.Error())
+			utils.CaptureSentryException("jobCrossPostError", hub, e)
+		}
+	}
+}
+
+// isWatched returns true if the news tickers (from meta if provided) or the original text mention the watchlist tickers.
+func (job *Job) isWatched(n *archivist.News, meta *composer.ComposedMeta) bool {
+	if len(job.options.watchlist) == 0 {
+		return false
+	}
+
+	if meta == nil && n.MetaData != nil {
+		meta = &composer.ComposedMeta{}
+		if err := json.Unmarshal(n.MetaData, meta); err != nil {
+			meta = nil
+		}
+	}
+	if meta != nil && job.options.watchlist.contains(meta.Tickers) {
+		return true
+	}
+
+	return job.options.watchlist.mentionedIn(n.OriginalTitle + "\n" + n.OriginalDesc)
+}
+
+// renderChart renders the intraday chart of the first news ticker if Job.AttachCharts is set and the news
+// is important (see Job.important). Returns nil if the chart can't be rendered, so the news will be published
+// without it. Such misses are only logged, since the news is still published.
+func (job *Job) renderChart(ctx context.Context, tx *sentry.Span, n *archivist.News, msg publisher.Message) []byte {
+	if job.options.chartsQuotes == nil || n.MetaData == nil || !job.important(n, msg) {
+		return nil
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil || len(meta.Tickers) == 0 {
+		return nil
+	}
+
+	span := tx.StartChild("renderChart")
+	span.SetTag("ticker", meta.Tickers[0])
+	defer span.Finish()
+
+	data, err := job.options.chartsQuotes.FetchIntraday(ctx, meta.Tickers[0])
+	if err != nil {
+		job.logger.Warn(fmt.Sprintf("[%s][renderChart] Error fetching quotes: %v", job.name, err))
+		return nil
+	}
+
+	chart, err := chartist.Intraday(data)
+	if err != nil {
+		job.logger.Warn(fmt.Sprintf("[%s][renderChart] Error rendering chart: %v", job.name, err))
+		return nil
+	}
+
+	return chart
+}
+
+// important returns true if the message of the news is marked as important (e.g. the watchlist news)
+// or the original news matches the important tagging rules.
+func (job *Job) important(n *archivist.News, msg publisher.Message) bool {
+	return msg.Importance == publisher.ImportanceHigh ||
+		job.options.tagRules.important(n.OriginalTitle+"\n"+n.OriginalDesc)
+}
+
+// markPending sets the PublishPending flag of the news, e.g. clears it for the news that are not going
+// to be published by the job (see Job.RepublishPending). Does nothing if RepublishPending is not set.
+func (job *Job) markPending(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news []*archivist.News, pending bool) error {
+	if job.options.republishMaxAge <= 0 || len(news) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, len(news))
+	for i, n := range news {
+		hashes[i] = n.Hash
+	}
+
+	span := tx.StartChild("markPending.News.MarkPending")
+	err := job.archivist.Entities.News.MarkPending(ctx, hashes, pending)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][markPending.News.MarkPending]: %w", job.name, err)
+		utils.CaptureSentryException("jobMarkPendingError", hub, e)
+		return e
+	}
+
+	return nil
+}
+
+// Recover return job function that publishes the news saved by the job pending publication, but never published
+// (see Job.RepublishPending), e.g. after the publication failed or the process crashed between saving and publishing.
+// It should be scheduled every few minutes. The recovery is skipped while the run of the job is in progress.
+func (job *Job) Recover() JobFunc {
+	timeout := cmp.Or(job.options.timeout, defaultJobTimeout)
+	return WithInstrumentationTimeout(job.name+".Recover", timeout, func(ctx context.Context, r *JobRun) {
+		r.SetChannel(job.publisher.Channel())
+		if !job.running.TryLock() {
+			r.Success("Skipping recovery, the run of %s is in progress", job.name)
+			return
+		}
+		defer job.running.Unlock()
+
+		publishCtx, cancel := withReserve(ctx, updateEstimate)
+		defer cancel()
+
+		job.republishPending(publishCtx, ctx, r)
+	})
+}
+
+// republishPending publishes the unpublished news saved by the job in the last RepublishPending max age.
+// They pass the prepublish filter again with the current mutes (none if they can't be found),
+// the news it skips now are no longer pending.
+// Publication data is updated with updateCtx, the rest uses ctx.
+func (job *Job) republishPending(ctx, updateCtx context.Context, r *JobRun) {
+	tx, hub := r.Tx, r.Hub
+
+	span := tx.StartChild("republishPending.News.FindComposedUnpublished")
+	unpublished, err := job.archivist.Entities.News.FindComposedUnpublished(ctx, job.options.republishMaxAge)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][republishPending.News.FindComposedUnpublished]: %w", job.name, err)
+		utils.CaptureSentryException("jobFindUnpublishedError", hub, e)
+		r.Stage("pending", 0, e)
+		return
+	}
+
+	// News of the other jobs are recovered by their own recovery, since they may be in the middle of their run
+	pending := lo.Filter(unpublished, func(n *archivist.News, _ int) bool {
+		return n.JobName == job.name && n.ChannelID == job.publisher.Channel()
+	})
+	r.Stage("pending", len(pending), nil)
+	if len(pending) == 0 {
+		return
+	}
+
+	// Same as the run, pending news are published unmuted rather than held until the mutes can be read
+	mutes, err := job.findMutes(ctx, tx, hub)
+	if err != nil {
+		r.Stage("mutes", 0, err)
+	}
+
+	news, err := job.prepublishFilter(tx, hub, pending, mutes)
+	if err != nil {
+		r.Stage("republished", 0, err)
+		return
+	}
+
+	skipped := lo.Without(pending, news...)
+	if err := job.markPending(ctx, tx, hub, skipped, false); err != nil {
+		r.Stage("republished", 0, err)
+		return
+	}
+
+	published, err := job.publish(ctx, r, "republished", news)
+	r.Stage("republished", len(published), err)
+	if len(published) == 0 {
+		return
+	}
+
+	err = job.updateNews(updateCtx, tx, hub, published)
+	if err != nil {
+		r.Stage("updated", len(published), err)
+		return
+	}
+	if job.options.archiver != nil {
+		job.options.archiver.enqueue(published)
+	}
+}
+
 // updateNews updates news in the database.
 func (job *Job) updateNews(
 	ctx context.Context,
@@ -539,38 +1600,93 @@ func (job *Job) updateNews(
 		}
 	}
 
-	hub.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "successful",
-		Message:  "updateNews finished",
-		Level:    sentry.LevelInfo,
-	}, nil)
-
 	return nil
 }
 
-func formatNewsWithComposedMeta(n archivist.News) string {
+// decorateLinks returns the function that decorates the links of the news post (see Job.DecorateLinks).
+func (job *Job) decorateLinks(ctx context.Context, n *archivist.News) func(link string) string {
+	d := job.options.linkDecorator
+	if d == nil {
+		d = links.Default()
+	}
+
+	post := links.Post{Channel: job.publisher.Channel(), Provider: n.ProviderName}
+	if n.ID != uuid.Nil {
+		post.NewsID = n.ID.String()
+	}
+
+	return func(link string) string {
+		return d.Decorate(ctx, link, post)
+	}
+}
+
+// tickerURL is the base URL of the ticker pages linked in the posts.
+const tickerURL = "https://short-fork.extr.app/en/"
+
+// formatNewsWithComposedMeta formats the composed text with the ticker links decorated by the given function
+// and sector hashtags.
+func formatNewsWithComposedMeta(n archivist.News, decorate func(link string) string) publisher.Message {
+	text := publisher.Segment{Text: n.ComposedText}
+	msg := publisher.Message{Body: []publisher.Segment{text}}
 	if n.MetaData == nil {
-		return n.ComposedText
+		return msg
 	}
 
 	var meta composer.ComposedMeta
 	err := json.Unmarshal(n.MetaData, &meta)
 	if err != nil {
-		return n.ComposedText
+		return msg
 	}
 
-	result := n.ComposedText
+	// The first mention of each ticker is linked
 	for _, t := range meta.Tickers {
-		result = strings.Replace(result, t, fmt.Sprintf("[%s](https://short-fork.extr.app/en/%s?utm_source=finthread)", t, t), 1)
+		if i := firstUnlinked(text, t); i >= 0 {
+			text.Entities = append(text.Entities, publisher.Entity{
+				Type:   publisher.EntityLink,
+				Offset: i,
+				Length: len(t),
+				URL:    decorate(tickerURL + t),
+			})
+		}
 	}
+	msg.Body[0] = text
 
 	// TODO: Decide what to do with markets and hashtags
 
-	return result
+	for _, s := range meta.Sectors {
+		msg.Tags = append(msg.Tags, strings.TrimPrefix(sectorHashtag(s), "#"))
+	}
+
+	return msg
+}
+
+// firstUnlinked returns the offset of the first mention of the ticker in the segment text out of its links.
+// Returns -1 if there is no such mention.
+func firstUnlinked(s publisher.Segment, ticker string) int {
+	if ticker == "" {
+		return -1
+	}
+
+	for from := 0; ; {
+		i := strings.Index(s.Text[from:], ticker)
+		if i < 0 {
+			return -1
+		}
+		i += from
+
+		linked := slices.ContainsFunc(s.Entities, func(e publisher.Entity) bool {
+			return i < e.Offset+e.Length && e.Offset < i+len(ticker)
+		})
+		if !linked {
+			return i
+		}
+		from = i + len(ticker)
+	}
 }
 
 // JobFunc is a type for job function that will be executed by the scheduler.
-type JobFunc func()
+// It returns the error of the failed run, which is already logged and captured by the job.
+type JobFunc func() error
 
 // metaKey is a type for meta keys based on the keys from composer.ComposedMeta struct.
 type metaKey string
@@ -582,6 +1698,22 @@ const (
 	MetaHashtags metaKey = "Hashtags"
 )
 
+// countryFilter holds the countries of the stocks that are considered domestic for the job.
+type countryFilter struct {
+	countries []string // allowed countries of the stocks (case-insensitive), e.g. "United States"
+	demote    bool     // if true, will publish news with foreign stocks only after others instead of omitting them
+}
+
+// allows returns true if the country is one of the allowed countries.
+func (f *countryFilter) allows(country string) bool {
+	for _, c := range f.countries {
+		if strings.EqualFold(strings.TrimSpace(c), strings.TrimSpace(country)) {
+			return true
+		}
+	}
+	return false
+}
+
 // omitKeyOptions holds keys that will omit news if empty. Note: requires jobOptions.shouldComposeText to be true.
 type omitKeyOptions struct {
 	emptyTickers  bool // if true, will omit articles with empty tickers meta from composer.ComposedMeta
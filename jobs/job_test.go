@@ -3,15 +3,29 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
 	"github.com/samgozman/fin-thread/archivist"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/links"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/publisher"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"log/slog"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
+// defaultLinks decorates the links with the default UTM parameters.
+func defaultLinks(link string) string {
+	return links.Default().Decorate(context.Background(), link, links.Post{})
+}
+
 func Test_formatNewsWithComposedMeta(t *testing.T) {
 	type args struct {
 		n archivist.News
@@ -22,6 +36,10 @@ func Test_formatNewsWithComposedMeta(t *testing.T) {
 	d2, _ := json.Marshal(composer.ComposedMeta{
 		Tickers: []string{"AAPL", "MSFT"},
 	})
+	d3, _ := json.Marshal(composer.ComposedMeta{
+		Tickers: []string{"AAPL", "JNJ"},
+		Sectors: []string{"Technology", "Health Care"},
+	})
 	tests := []struct {
 		name string
 		args args
@@ -60,10 +78,21 @@ func Test_formatNewsWithComposedMeta(t *testing.T) {
 			},
 			want: "Some [AAPL](https://short-fork.extr.app/en/AAPL?utm_source=finthread) news about with [MSFT](https://short-fork.extr.app/en/MSFT?utm_source=finthread) stock.",
 		},
+		{
+			name: "with sectors",
+			args: args{
+				n: archivist.News{
+					ID:           uuid.New(),
+					ComposedText: "AAPL and JNJ are up.",
+					MetaData:     d3,
+				},
+			},
+			want: "[AAPL](https://short-fork.extr.app/en/AAPL?utm_source=finthread) and [JNJ](https://short-fork.extr.app/en/JNJ?utm_source=finthread) are up.\n#Technology #HealthCare",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := formatNewsWithComposedMeta(tt.args.n); got != tt.want {
+			if got := formatNewsWithComposedMeta(tt.args.n, defaultLinks).Markdown(); got != tt.want {
 				t.Errorf("formatNewsWithComposedMeta() = %v, want %v", got, tt.want)
 			}
 		})
@@ -76,7 +105,8 @@ func TestJob_prepublishFilter(t *testing.T) {
 		options *jobOptions
 	}
 	type args struct {
-		news []*archivist.News
+		news  []*archivist.News
+		mutes []*archivist.Mute
 	}
 
 	d1, _ := json.Marshal(composer.ComposedMeta{
@@ -108,6 +138,34 @@ func TestJob_prepublishFilter(t *testing.T) {
 			want:    []*archivist.News{},
 			wantErr: false,
 		},
+		{
+			name: "Omit news held for moderation",
+			fields: fields{
+				options: &jobOptions{},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:               uuid.New(),
+						ComposedText:     "AAPL rises 12%.",
+						MetaData:         d1,
+						ModerationReason: "unverified numbers: 12",
+					},
+					{
+						ID:           okID,
+						ComposedText: "Some other AAPL news.",
+						MetaData:     d1,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           okID,
+					ComposedText: "Some other AAPL news.",
+					MetaData:     d1,
+				},
+			},
+		},
 		{
 			name: "Omit suspicious news",
 			fields: fields{
@@ -214,6 +272,113 @@ func TestJob_prepublishFilter(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Omit foreign stocks",
+			fields: fields{
+				stocks: &stocks.StockMap{
+					"AAPL": stocks.Stock{Country: "United States"},
+					"PLTR": stocks.Stock{Country: "Cayman Islands"},
+				},
+				options: &jobOptions{
+					foreignStocks: &countryFilter{countries: []string{"united states"}},
+				},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:           uuid.New(),
+						ComposedText: "Some PLTR news.",
+						MetaData:     d2,
+					},
+					{
+						ID:           okID,
+						ComposedText: "Some AAPL news.",
+						MetaData:     d1,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           okID,
+					ComposedText: "Some AAPL news.",
+					MetaData:     d1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Demote foreign stocks",
+			fields: fields{
+				stocks: &stocks.StockMap{
+					"AAPL": stocks.Stock{Country: "United States"},
+					"PLTR": stocks.Stock{Country: "Cayman Islands"},
+				},
+				options: &jobOptions{
+					foreignStocks: &countryFilter{countries: []string{"United States"}, demote: true},
+				},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:           okID,
+						ComposedText: "Some PLTR news.",
+						MetaData:     d2,
+					},
+					{
+						ID:           okID,
+						ComposedText: "Some AAPL news.",
+						MetaData:     d1,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           okID,
+					ComposedText: "Some AAPL news.",
+					MetaData:     d1,
+				},
+				{
+					ID:           okID,
+					ComposedText: "Some PLTR news.",
+					MetaData:     d2,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Omit below market cap",
+			fields: fields{
+				stocks: &stocks.StockMap{
+					"AAPL": stocks.Stock{MarketCap: "3001298596040.00"},
+					"PLTR": stocks.Stock{MarketCap: "120000000.00"},
+				},
+				options: &jobOptions{
+					minMarketCap: 300_000_000,
+				},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:           okID,
+						ComposedText: "Some AAPL news.",
+						MetaData:     d1,
+					},
+					{
+						ID:           uuid.New(),
+						ComposedText: "Some PLTR news.",
+						MetaData:     d2,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           okID,
+					ComposedText: "Some AAPL news.",
+					MetaData:     d1,
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Omit if all keys are empty",
 			fields: fields{
@@ -267,6 +432,45 @@ func TestJob_prepublishFilter(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "Omit not composed news",
+			fields: fields{
+				stocks:  nil,
+				options: &jobOptions{shouldComposeText: true},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:            okID,
+						OriginalTitle: "Some AAPL news without composed meta",
+					},
+				},
+			},
+			want:    []*archivist.News{},
+			wantErr: false,
+		},
+		{
+			name: "Keep news without meta if not composing",
+			fields: fields{
+				stocks:  nil,
+				options: &jobOptions{},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:            okID,
+						OriginalTitle: "Some AAPL news without composed meta",
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:            okID,
+					OriginalTitle: "Some AAPL news without composed meta",
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Omit filtered news",
 			fields: fields{
@@ -299,6 +503,106 @@ func TestJob_prepublishFilter(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Omit muted news",
+			fields: fields{
+				stocks:  nil,
+				options: &jobOptions{},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:           uuid.New(),
+						ComposedText: "Some PLTR news.",
+						MetaData:     d2,
+					},
+					{
+						ID:           uuid.New(),
+						ProviderName: "MemeWire",
+						ComposedText: "Some AAPL news.",
+						MetaData:     d1,
+					},
+					{
+						ID:           okID,
+						ProviderName: "Reuters",
+						ComposedText: "Some other AAPL news.",
+						MetaData:     d1,
+					},
+				},
+				mutes: []*archivist.Mute{
+					{Kind: archivist.MuteTicker, Value: "pltr"},
+					{Kind: archivist.MuteProvider, Value: "memewire"},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:           okID,
+					ProviderName: "Reuters",
+					ComposedText: "Some other AAPL news.",
+					MetaData:     d1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Watchlist news bypass empty meta omission",
+			fields: fields{
+				stocks: nil,
+				options: &jobOptions{
+					omitEmptyMetaKeys:  &omitKeyOptions{emptyTickers: true},
+					omitIfAllKeysEmpty: true,
+					watchlist:          newWatchlist([]string{"NVDA"}),
+				},
+			},
+			args: args{
+				news: []*archivist.News{
+					{
+						ID:            okID,
+						OriginalTitle: "NVDA unveils new chips",
+						ComposedText:  "Nvidia unveils new chips.",
+						MetaData:      emptyMeta,
+					},
+					{
+						ID:            uuid.New(),
+						OriginalTitle: "PLTR wins a contract",
+						ComposedText:  "Palantir wins a contract.",
+						MetaData:      emptyMeta,
+					},
+				},
+			},
+			want: []*archivist.News{
+				{
+					ID:            okID,
+					OriginalTitle: "NVDA unveils new chips",
+					ComposedText:  "Nvidia unveils new chips.",
+					MetaData:      emptyMeta,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Provider trust modulates filtering and order",
+			fields: fields{
+				stocks: nil,
+				options: &jobOptions{
+					omitIfAllKeysEmpty: true,
+					trust:              newProviderTrust(map[string]float64{"Reuters": 2, "Blog": 0.5, "Spam": 0}),
+				},
+			},
+			args: args{
+				news: []*archivist.News{
+					{ID: okID, ProviderName: "Blog", ComposedText: "Some AAPL news.", MetaData: d1},
+					{ID: okID, ProviderName: "Blog", ComposedText: "Some news.", MetaData: emptyMeta},
+					{ID: okID, ProviderName: "Spam", ComposedText: "Some AAPL news.", MetaData: d1},
+					{ID: okID, ProviderName: "Reuters", ComposedText: "Some news.", MetaData: emptyMeta},
+				},
+			},
+			want: []*archivist.News{
+				{ID: okID, ProviderName: "Reuters", ComposedText: "Some news.", MetaData: emptyMeta},
+				{ID: okID, ProviderName: "Blog", ComposedText: "Some AAPL news.", MetaData: d1},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -309,7 +613,7 @@ func TestJob_prepublishFilter(t *testing.T) {
 			tx := sentry.StartTransaction(context.Background(), "test")
 			hub := sentry.CurrentHub().Clone()
 
-			got, err := job.prepublishFilter(tx, hub, tt.args.news)
+			got, err := job.prepublishFilter(tx, hub, tt.args.news, tt.args.mutes)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("prepublishFilter() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -320,3 +624,500 @@ func TestJob_prepublishFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestJob_formatPermalink(t *testing.T) {
+	id := uuid.MustParse("0b5d0a4e-4d3a-4c5e-9a53-3a2c1f6c2b11")
+
+	tests := []struct {
+		name    string
+		options *jobOptions
+		news    *archivist.News
+		want    string
+	}{
+		{
+			name:    "saved news",
+			options: &jobOptions{permalinkBaseURL: "https://example.com/"},
+			news:    &archivist.News{ID: id},
+			want:    "[🔗 Permalink](https://example.com/news/0b5d0a4e-4d3a-4c5e-9a53-3a2c1f6c2b11)",
+		},
+		{
+			name:    "unsaved news",
+			options: &jobOptions{permalinkBaseURL: "https://example.com"},
+			news:    &archivist.News{},
+			want:    "",
+		},
+		{
+			name:    "disabled",
+			options: &jobOptions{},
+			news:    &archivist.News{ID: id},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{options: tt.options}
+			if got := (publisher.Message{Links: job.formatPermalink(tt.news)}).Markdown(); got != tt.want {
+				t.Errorf("formatPermalink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_formatConstituents(t *testing.T) {
+	market, _ := json.Marshal(composer.ComposedMeta{Markets: []string{"RUT", "SPX"}})
+	withTickers, _ := json.Marshal(composer.ComposedMeta{Tickers: []string{"AAPL"}, Markets: []string{"SPX"}})
+
+	tests := []struct {
+		name    string
+		options *jobOptions
+		news    *archivist.News
+		want    string
+	}{
+		{
+			name:    "first market with known holdings",
+			options: &jobOptions{etfs: stocks.DefaultETFs(), constituents: 3},
+			news:    &archivist.News{MetaData: market},
+			want:    "🏛 SPX heavyweights: MSFT, AAPL, NVDA",
+		},
+		{
+			name:    "news with tickers",
+			options: &jobOptions{etfs: stocks.DefaultETFs(), constituents: 3},
+			news:    &archivist.News{MetaData: withTickers},
+			want:    "",
+		},
+		{
+			name:    "disabled",
+			options: &jobOptions{etfs: stocks.DefaultETFs()},
+			news:    &archivist.News{MetaData: market},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{options: tt.options}
+			if got := (publisher.Message{Body: job.formatConstituents(tt.news)}).Markdown(); got != tt.want {
+				t.Errorf("formatConstituents() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     *Job
+		wantErr string
+	}{
+		{
+			name: "valid options",
+			job: NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).
+				OmitSuspicious().
+				OmitEmptyMeta(MetaTickers).
+				ComposeText().
+				SelectBeforeCompose(5).
+				SeparateETFs(stocks.DefaultETFs()).
+				ListConstituents(3).
+				RemoveClones().
+				SaveToDB(),
+		},
+		{
+			name:    "remove clones without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).RemoveClones(),
+			wantErr: "RemoveClones requires SaveToDB to be set",
+		},
+		{
+			name:    "resume from checkpoint without removing clones",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).ResumeFromCheckpoint(time.Minute, time.Hour).SaveToDB(),
+			wantErr: "ResumeFromCheckpoint requires RemoveClones to be set",
+		},
+		{
+			name:    "archive links without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).ArchiveLinks(&fakeSnapshotter{}),
+			wantErr: "ArchiveLinks requires SaveToDB to be set",
+		},
+		{
+			name:    "unknown button",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).AddButtons(ButtonSource, "share"),
+			wantErr: `AddButtons: unknown button "share"`,
+		},
+		{
+			name:    "chart button without composing",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).AddButtons(ButtonSource, ButtonChart),
+			wantErr: "AddButtons chart and ticker buttons requires ComposeText to be set",
+		},
+		{
+			name:    "permalinks without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).AppendPermalinks("https://example.com"),
+			wantErr: "AppendPermalinks requires SaveToDB to be set",
+		},
+		{
+			name:    "queue when offline without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).QueueWhenOffline(),
+			wantErr: "QueueWhenOffline requires SaveToDB to be set",
+		},
+		{
+			name:    "republish pending without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).RepublishPending(time.Hour),
+			wantErr: "RepublishPending requires SaveToDB to be set",
+		},
+		{
+			name:    "avoid repetition without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).ComposeText().AvoidRepetition(5, 24*time.Hour),
+			wantErr: "AvoidRepetition requires SaveToDB to be set",
+		},
+		{
+			name:    "track stories without saving",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).ComposeText().TrackStories(72 * time.Hour),
+			wantErr: "TrackStories requires SaveToDB to be set",
+		},
+		{
+			name:    "timeout shorter than the reserve",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).Timeout(5 * time.Second),
+			wantErr: "Timeout: must be longer than 10s reserved for the stages after composing, got 5s",
+		},
+		{
+			name:    "omit empty meta without composing",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).OmitEmptyMeta(MetaMarkets),
+			wantErr: "OmitEmptyMeta requires ComposeText to be set",
+		},
+		{
+			name:    "unknown meta key",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).ComposeText().OmitEmptyMeta("Sectors"),
+			wantErr: `OmitEmptyMeta: unknown meta key "Sectors"`,
+		},
+		{
+			name: "constituents without ETFs",
+			job: NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).
+				ComposeText().
+				ListConstituents(3),
+			wantErr: "ListConstituents requires SeparateETFs to be set",
+		},
+		{
+			name:    "negative select limit",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).ComposeText().SelectBeforeCompose(-1),
+			wantErr: "SelectBeforeCompose: limit must be positive, got -1",
+		},
+		{
+			name: "fetch window ends before it starts",
+			job: NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).
+				FetchWindow(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 14, 0, 0, 0, time.UTC)),
+			wantErr: "FetchWindow: end 2024-01-02T14:00:00Z must be after start 2024-01-02T15:00:00Z",
+		},
+		{
+			name:    "include and omit rating changes",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).IncludeRatingChanges().OmitRatingChanges(),
+			wantErr: "IncludeRatingChanges and OmitRatingChanges are mutually exclusive",
+		},
+		{
+			name: "prohibited categories without composing",
+			job: NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).
+				UseCompliance(Compliance{Name: "uk-fca", Prohibited: []string{"crypto"}}),
+			wantErr: "UseCompliance prohibited categories requires ComposeText to be set",
+		},
+		{
+			name:    "compliance profile without name",
+			job:     NewJob(nil, nil, nil, &journalist.Journalist{Name: "test"}, nil).UseCompliance(Compliance{Disclaimer: "Not advice"}),
+			wantErr: "UseCompliance: name is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.job.Validate()
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want to contain %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJob_disabledStagesPassThrough(t *testing.T) {
+	news := journalist.NewsList{
+		{ID: "1", Title: "Apple beats earnings", Description: "AAPL is up", Link: "https://a.com/1", ProviderName: "test"},
+		{ID: "2", Title: "Fed holds rates", Description: "Markets are flat", Link: "https://a.com/2", ProviderName: "test"},
+	}
+
+	for _, removeClones := range []bool{false, true} {
+		for _, saveToDB := range []bool{false, true} {
+			for _, composeText := range []bool{false, true} {
+				name := fmt.Sprintf("removeClones=%v,saveToDB=%v,composeText=%v", removeClones, saveToDB, composeText)
+				t.Run(name, func(t *testing.T) {
+					job := &Job{
+						name:      "test",
+						publisher: &publisher.TelegramPublisher{ChannelID: "@test"},
+						options: &jobOptions{
+							shouldRemoveClones: removeClones,
+							shouldSaveToDB:     saveToDB,
+							shouldComposeText:  composeText,
+						},
+					}
+					ctx := context.Background()
+					tx := sentry.StartTransaction(ctx, "test")
+					hub := sentry.CurrentHub().Clone()
+
+					// Enabled stages need the database and OpenAI, only the disabled ones are checked
+					if !removeClones || !saveToDB {
+						got, err := job.removeDuplicates(ctx, tx, hub, news)
+						if err != nil || !reflect.DeepEqual(got, news) {
+							t.Errorf("removeDuplicates() = %v, %v, want the news as is", got, err)
+						}
+					}
+
+					if !composeText {
+						got, err := job.composeNews(ctx, &JobRun{Tx: tx, Hub: hub, name: "test", logger: slog.Default()}, news)
+						if err != nil || got != nil {
+							t.Errorf("composeNews() = %v, %v, want no composed news", got, err)
+						}
+					}
+
+					if !saveToDB {
+						got, err := job.saveNews(ctx, tx, hub, news, nil)
+						if err != nil || len(got) != len(news) {
+							t.Fatalf("saveNews() = %v, %v, want %d news entities", got, err, len(news))
+						}
+						for i, n := range got {
+							if n.Hash != news[i].ID || n.OriginalTitle != news[i].Title || n.ChannelID != "@test" {
+								t.Errorf("saveNews() news[%d] = %+v, want entity of %+v", i, n, news[i])
+							}
+						}
+
+						mutes, err := job.findMutes(ctx, tx, hub)
+						if err != nil || mutes != nil {
+							t.Errorf("findMutes() = %v, %v, want no mutes without archivist", mutes, err)
+						}
+
+						wantPublished := len(news)
+						if composeText {
+							wantPublished = 0 // not composed news have no text to publish
+						}
+						published, err := job.prepublishFilter(tx, hub, got, mutes)
+						if err != nil || len(published) != wantPublished {
+							t.Errorf("prepublishFilter() = %d news, %v, want %d", len(published), err, wantPublished)
+						}
+
+						if err := job.updateNews(ctx, tx, hub, got); err != nil {
+							t.Errorf("updateNews() error = %v", err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func Test_fetchWindowStart(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	until := now.Add(-time.Minute) // FetchUntil date set on start
+
+	tests := []struct {
+		name       string
+		checkpoint time.Time
+		want       time.Time
+	}{
+		{
+			name: "first run without checkpoint",
+			want: until,
+		},
+		{
+			name:       "regular run starts at the previous window end minus overlap",
+			checkpoint: now.Add(-time.Minute),
+			want:       now.Add(-2 * time.Minute),
+		},
+		{
+			name:       "slow previous run doesn't leave a gap",
+			checkpoint: now.Add(-5 * time.Minute),
+			want:       now.Add(-6 * time.Minute),
+		},
+		{
+			name:       "restart resumes from the checkpoint before the restart",
+			checkpoint: now.Add(-30 * time.Minute),
+			want:       now.Add(-31 * time.Minute),
+		},
+		{
+			name:       "long downtime is limited by lookback",
+			checkpoint: now.Add(-48 * time.Hour),
+			want:       now.Add(-time.Hour),
+		},
+		{
+			name:       "checkpoint in the future",
+			checkpoint: now.Add(time.Hour),
+			want:       now.Add(-time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fetchWindowStart(tt.checkpoint, until, now, time.Minute, time.Hour); !got.Equal(tt.want) {
+				t.Errorf("fetchWindowStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_Run_fetchWindow(t *testing.T) {
+	tg := newFakeTelegram(t)
+	ai := newFakeOpenAI(t)
+	now := time.Now().UTC()
+	feed := newFakeRSS(t, []rssItem{
+		{Title: "After the window", Description: "NVDA news.", Link: "https://example.com/after", Date: now.Add(-10 * time.Minute)},
+		{Title: "Within the window", Description: "MSFT news.", Link: "https://example.com/within", Date: now.Add(-90 * time.Minute)},
+		{Title: "Before the window", Description: "AAPL news.", Link: "https://example.com/before", Date: now.Add(-3 * time.Hour)},
+	})
+
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+	j := journalist.NewJournalist("Test", []journalist.NewsProvider{journalist.NewRssProvider("Test feed", feed.URL)})
+	job := NewJob(c, tg.publisher(t, "@test_channel"), nil, j, nil).
+		FetchUntil(now.Add(-time.Minute)).
+		FetchWindow(now.Add(-2*time.Hour), now.Add(-time.Hour)).
+		ComposeText()
+	if err := job.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := job.Run()(); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := tg.sent()
+	if len(messages) != 1 || !strings.Contains(messages[0].text, "Within the window") {
+		t.Errorf("sent messages = %+v, want only the news within the window", messages)
+	}
+}
+
+// mockPublisher is the publisher.Publisher that records the published messages and fails from the given one.
+type mockPublisher struct {
+	messages []string
+	failFrom int // number of the first failed message, 0 to never fail
+}
+
+func (p *mockPublisher) Publish(msg publisher.Message) (string, error) {
+	if p.failFrom > 0 && len(p.messages)+1 >= p.failFrom {
+		return "", errors.New("bad request")
+	}
+	p.messages = append(p.messages, msg.Markdown())
+	return strconv.Itoa(len(p.messages)), nil
+}
+
+func (p *mockPublisher) Channel() string {
+	return "@mock"
+}
+
+func TestJob_publish(t *testing.T) {
+	tests := []struct {
+		name          string
+		failFrom      int
+		wantPublished []string // publication IDs of the published news
+		wantErr       bool
+	}{
+		{name: "all published", wantPublished: []string{"1", "2", "3"}},
+		{name: "failed in the middle", failFrom: 2, wantPublished: []string{"1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mockPublisher{failFrom: tt.failFrom}
+			job := &Job{name: "test", publisher: p, options: &jobOptions{}, logger: slog.Default()}
+			news := []*archivist.News{
+				{Hash: "1", OriginalTitle: "Apple beats earnings", OriginalDesc: "AAPL is up"},
+				{Hash: "2", OriginalTitle: "Fed holds rates", OriginalDesc: "Markets are flat"},
+				{Hash: "3", OriginalTitle: "Oil drops", OriginalDesc: "Brent is down"},
+			}
+
+			ctx := context.Background()
+			r := &JobRun{Tx: sentry.StartTransaction(ctx, "test"), Hub: sentry.CurrentHub().Clone(), name: "test", logger: slog.Default()}
+			got, err := job.publish(ctx, r, "published", news)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var ids []string
+			for _, n := range got {
+				ids = append(ids, n.PublicationID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantPublished) {
+				t.Errorf("publish() published = %v, want %v", ids, tt.wantPublished)
+			}
+			if p.messages[0] != "Apple beats earnings\nAAPL is up" {
+				t.Errorf("publish() message = %q, want the original title and description", p.messages[0])
+			}
+		})
+	}
+}
+
+func TestJob_publish_partialMultiPublisher(t *testing.T) {
+	primary := &mockPublisher{}
+	other := &mockPublisher{failFrom: 1}
+	job := &Job{
+		name:      "test",
+		publisher: publisher.NewMultiPublisher(primary, other),
+		options:   &jobOptions{stageAttempts: 3},
+		logger:    slog.Default(),
+	}
+	news := []*archivist.News{{Hash: "1", OriginalTitle: "Fed holds rates", OriginalDesc: "Markets are flat"}}
+
+	ctx := context.Background()
+	r := &JobRun{Tx: sentry.StartTransaction(ctx, "test"), Hub: sentry.CurrentHub().Clone(), name: "test", logger: slog.Default()}
+	got, err := job.publish(ctx, r, "published", news)
+	if err != nil {
+		t.Fatalf("publish() error = %v, want the news published to the primary target", err)
+	}
+	if len(got) != 1 || got[0].PublicationID != "1" || len(primary.messages) != 1 {
+		t.Errorf("publish() = %v with %d primary messages, want the news published once", got, len(primary.messages))
+	}
+}
+
+func TestJob_important(t *testing.T) {
+	job := &Job{options: &jobOptions{tagRules: newTagRules([]TagRule{
+		{Pattern: "recession", Important: true},
+		{Pattern: "fed", Hashtags: []string{"fed"}},
+	})}}
+	tests := []struct {
+		name string
+		news *archivist.News
+		msg  publisher.Message
+		want bool
+	}{
+		{name: "watched", news: &archivist.News{OriginalTitle: "Apple beats earnings"}, msg: publisher.Message{Importance: publisher.ImportanceHigh}, want: true},
+		{name: "important rule", news: &archivist.News{OriginalTitle: "Economy", OriginalDesc: "Recession fears grow"}, want: true},
+		{name: "not important rule", news: &archivist.News{OriginalTitle: "Fed holds rates"}, want: false},
+		{name: "regular", news: &archivist.News{OriginalTitle: "Oil drops"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := job.important(tt.news, tt.msg); got != tt.want {
+				t.Errorf("important() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_selectAndComposeNews_watchlist(t *testing.T) {
+	ai := newFakeOpenAI(t)
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+
+	job := &Job{name: "test", composer: c, options: &jobOptions{selectLimit: 1, watchlist: newWatchlist([]string{"NVDA"})}, logger: slog.Default()}
+	news := journalist.NewsList{
+		{ID: "1", Title: "Fed holds rates", Description: "Markets are flat", Date: time.Now()},
+		{ID: "2", Title: "NVDA beats earnings", Description: "Shares are up", Date: time.Now()},
+	}
+
+	ctx := context.Background()
+	r := &JobRun{Tx: sentry.StartTransaction(ctx, "test"), Hub: sentry.CurrentHub().Clone(), name: "test", logger: slog.Default()}
+	composed, err := job.selectAndComposeNews(ctx, r, news)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, 0, len(composed))
+	for _, n := range composed {
+		ids = append(ids, n.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"1", "2"}) {
+		t.Errorf("selectAndComposeNews() composed %v, want the selected and the watchlist news", ids)
+	}
+	if news[1].IsFiltered {
+		t.Errorf("watchlist news filtered as %q, want it kept", news[1].FilteredReason)
+	}
+}
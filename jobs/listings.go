@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	listingsLimit     = 30  // max number of the tickers in each section of the listings post
+	maxUniverseShrink = 0.1 // snapshots smaller than the universe by more than this share are considered incomplete
+)
+
+// ListingsJob compares the snapshot of the stock universe (Nasdaq screener) with the previous one and publishes
+// the new listings and delistings. The universe used by the jobs with OmitUnlistedStocks is replaced too,
+// so news of the new listings are not omitted anymore. It should be run weekly.
+type ListingsJob struct {
+	screener  *stocks.Screener     // screener that will fetch the stock universe
+	publisher publisher.Publisher  // publisher that will publish the listings to the channel
+	archivist *archivist.Archivist // archivist that will store the listings
+	universe  *stocks.Universe     // universe of the news jobs (optional)
+	logger    *slog.Logger         // special logger for the job
+}
+
+// NewListingsJob creates a new ListingsJob instance. The universe is optional.
+func NewListingsJob(
+	screener *stocks.Screener,
+	publisher publisher.Publisher,
+	archivist *archivist.Archivist,
+	universe *stocks.Universe,
+) *ListingsJob {
+	return &ListingsJob{
+		screener:  screener,
+		publisher: publisher,
+		archivist: archivist,
+		universe:  universe,
+		logger:    slog.Default(),
+	}
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *ListingsJob) Run() JobFunc {
+	return WithInstrumentationTimeout("listings", 60*time.Second, func(ctx context.Context, r *JobRun) {
+		tx := r.Tx
+		r.SetChannel(j.publisher.Channel())
+
+		span := tx.StartChild("Screener.FetchFromNasdaq")
+		stockMap, err := j.screener.FetchFromNasdaq(ctx)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("listingsJobFetchError", "Error fetching stocks", err)
+			return
+		}
+
+		// Partial screener response would be announced as mass delistings
+		if j.universe != nil && float64(len(*stockMap)) < float64(j.universe.Len())*(1-maxUniverseShrink) {
+			r.Error("listingsJobIncompleteSnapshot", "Skipping incomplete snapshot", fmt.Errorf(
+				"snapshot has %d tickers, universe has %d", len(*stockMap), j.universe.Len(),
+			))
+			return
+		}
+
+		snapshot := make(map[string]string, len(*stockMap))
+		for ticker, s := range *stockMap {
+			snapshot[ticker] = s.Name
+		}
+
+		span = tx.StartChild("Archivist.Listings.Sync")
+		diff, err := j.archivist.Entities.Listings.Sync(ctx, snapshot, time.Now())
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("listingsJobSyncError", "Error saving listings", err)
+			return
+		}
+		r.Stage("listed", len(diff.Listed), nil)
+		r.Stage("delisted", len(diff.Delisted), nil)
+
+		if j.universe != nil {
+			j.universe.Replace(stockMap)
+		}
+
+		if diff.Baseline {
+			r.Success("Stock universe baseline saved with %d tickers", len(snapshot))
+			return
+		}
+
+		m := formatListings(diff, listingsLimit)
+		if m == "" {
+			r.Success("No new listings or delistings")
+			return
+		}
+
+		span = tx.StartChild("TelegramPublisher.Publish")
+		_, err = j.publisher.Publish(publisher.Markdown(m))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("listingsJobPublishError", "Error publishing listings", err)
+			return
+		}
+
+		r.Success("Listings published with %d new listings and %d delistings", len(diff.Listed), len(diff.Delisted))
+	})
+}
+
+// formatListings formats the new listings and delistings with up to limit tickers in each section.
+// Empty sections are skipped, empty string is returned if both are empty.
+func formatListings(diff *archivist.ListingsDiff, limit int) string {
+	var sections []string
+	if s := formatListingsSection("🆕 *New listings*", diff.Listed, limit); s != "" {
+		sections = append(sections, s)
+	}
+	if s := formatListingsSection("📤 *Delistings*", diff.Delisted, limit); s != "" {
+		sections = append(sections, s)
+	}
+
+	if len(sections) == 0 {
+		return ""
+	}
+
+	return strings.Join(sections, "\n") + "\n#listings"
+}
+
+func formatListingsSection(header string, listings []*archivist.Listing, limit int) string {
+	if len(listings) == 0 {
+		return ""
+	}
+
+	var m strings.Builder
+	m.WriteString(header + "\n")
+	for i, l := range listings {
+		if i == limit {
+			m.WriteString(fmt.Sprintf("and %d more\n", len(listings)-limit))
+			break
+		}
+		m.WriteString(l.Ticker)
+		if l.Name != "" {
+			m.WriteString(" – " + l.Name)
+		}
+		m.WriteString("\n")
+	}
+
+	return m.String()
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"testing"
+)
+
+func Test_formatListings(t *testing.T) {
+	listed := []*archivist.Listing{{Ticker: "ARM", Name: "Arm Holdings"}, {Ticker: "RDDT", Name: "Reddit"}, {Ticker: "XYZ"}}
+	delisted := []*archivist.Listing{{Ticker: "TWTR", Name: "Twitter"}}
+
+	tests := []struct {
+		name string
+		diff *archivist.ListingsDiff
+		want string
+	}{
+		{name: "no changes", diff: &archivist.ListingsDiff{}, want: ""},
+		{
+			name: "both sections",
+			diff: &archivist.ListingsDiff{Listed: listed[:2], Delisted: delisted},
+			want: "🆕 *New listings*\nARM – Arm Holdings\nRDDT – Reddit\n\n📤 *Delistings*\nTWTR – Twitter\n\n#listings",
+		},
+		{
+			name: "limited",
+			diff: &archivist.ListingsDiff{Listed: listed},
+			want: "🆕 *New listings*\nARM – Arm Holdings\nRDDT – Reddit\nand 1 more\n\n#listings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatListings(tt.diff, 2); got != tt.want {
+				t.Errorf("formatListings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/publisher"
+	"time"
+)
+
+const mirrorTimeout = 30 * time.Second // timeout of the translation of the single post
+
+// mirrorChannel is the paired channel where the published news are mirrored in another language.
+type mirrorChannel struct {
+	publisher publisher.Publisher // publisher of the mirror channel
+	language  string              // language of the mirror channel, e.g. "Spanish"
+}
+
+// mirrorTranslation translates the formatted news and publishes it to the mirror channel (if set),
+// then links the translated publication to the news. Errors are only logged because the news is already
+// published to the main channel.
+func (job *Job) mirrorTranslation(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, n *archivist.News, formattedText string) {
+	m := job.options.mirror
+	if m == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mirrorTimeout)
+	defer cancel()
+
+	span := tx.StartChild("publish.mirrorTranslation")
+	span.SetTag("channel_id", m.publisher.Channel())
+	defer span.Finish()
+
+	translated, err := job.composer.Translate(ctx, formattedText, m.language)
+	if err != nil {
+		e := fmt.Errorf("[%s][mirrorTranslation][composer.Translate] %s: %w", job.name, m.language, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobMirrorTranslateError", hub, e)
+		return
+	}
+
+	id, err := m.publisher.Publish(publisher.Markdown(translated))
+	if err != nil {
+		e := fmt.Errorf("[%s][mirrorTranslation] channel %s: %w", job.name, m.publisher.Channel(), err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobMirrorPublishError", hub, e)
+		return
+	}
+
+	n.MirrorChannelID = m.publisher.Channel()
+	n.MirrorPubID = id
+}
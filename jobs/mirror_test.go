@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"log/slog"
+	"testing"
+)
+
+func TestJob_mirrorTranslation(t *testing.T) {
+	tg := newFakeTelegram(t)
+	ai := newFakeOpenAI(t)
+
+	c := composer.NewComposer("test", "test", "")
+	c.OpenAiClient = ai.client()
+
+	news := []*archivist.News{
+		{Hash: "1", OriginalTitle: "Apple beats earnings estimates", OriginalDesc: "AAPL reported record revenue."},
+	}
+
+	tx := sentry.StartTransaction(context.Background(), "test")
+	defer tx.Finish()
+	r := &JobRun{Tx: tx, Hub: sentry.CurrentHub(), name: "test", logger: slog.Default()}
+
+	t.Run("mirrored", func(t *testing.T) {
+		job := NewJob(c, tg.publisher(t, "@test_channel"), nil, journalist.NewJournalist("Test", nil), nil).
+			MirrorTranslation(tg.publisher(t, "@test_channel_es"), "Spanish")
+
+		published, err := job.publish(context.Background(), r, "published", news)
+		if err != nil {
+			t.Fatalf("publish() error = %v", err)
+		}
+
+		sent := tg.sent()
+		if len(sent) != 2 {
+			t.Fatalf("sent %d messages, want 2", len(sent))
+		}
+		if want := "[translated] " + sent[0].text; sent[1].chatID != "@test_channel_es" || sent[1].text != want {
+			t.Errorf("mirrored message = %+v, want %q to @test_channel_es", sent[1], want)
+		}
+
+		n := published[0]
+		if n.PublicationID != "1" || n.MirrorChannelID != "@test_channel_es" || n.MirrorPubID != "2" {
+			t.Errorf("publish() news = %+v, want linked publication IDs 1 and 2", n)
+		}
+	})
+
+	t.Run("translation error", func(t *testing.T) {
+		broken := composer.NewComposer("test", "test", "")
+		// Telegram fake doesn't serve the chat completions, so the translation fails
+		broken.OpenAiClient = (&fakeOpenAI{server: tg.server}).client()
+		job := NewJob(broken, tg.publisher(t, "@test_channel"), nil, journalist.NewJournalist("Test", nil), nil).
+			MirrorTranslation(tg.publisher(t, "@test_channel_es"), "Spanish")
+		before := len(tg.sent())
+
+		n := &archivist.News{Hash: "2", OriginalTitle: "Nvidia rallies", OriginalDesc: "NVDA is up."}
+		published, err := job.publish(context.Background(), r, "published", []*archivist.News{n})
+		if err != nil {
+			t.Fatalf("publish() error = %v, mirror errors must not fail the publication", err)
+		}
+		if sent := tg.sent(); len(sent)-before != 1 || published[0].MirrorPubID != "" {
+			t.Errorf("publish() sent %d messages with mirror ID %q, want only the main channel one", len(sent)-before, published[0].MirrorPubID)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// numberRegex matches the numeric figures: integers and decimals with optional thousands separators
+	numberRegex = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+	// thousandsRegex matches the numbers with the comma thousands separators, e.g. "1,234,567.89"
+	thousandsRegex = regexp.MustCompile(`^\d{1,3}(?:,\d{3})+(?:\.\d+)?$`)
+	// indexNamesRegex matches the market index names with numbers, e.g. "S&P 500", which are not figures
+	indexNamesRegex = regexp.MustCompile(`(?i)\b(?:S&P|Nasdaq|FTSE|Russell|Stoxx|Nikkei|DAX|CAC|Dow Jones)\s?\d+`)
+)
+
+// numberSuffixes are the letters allowed right after the figure, e.g. "$3B", "5bn", "10x".
+var numberSuffixes = []string{"k", "m", "b", "bn", "t", "x"}
+
+// figure is the numeric figure of the text.
+type figure struct {
+	text     string  // figure as written in the text, e.g. "1,234.5"
+	value    float64 // value of the figure
+	decimals int     // number of the decimal places
+}
+
+// extractFigures returns the numeric figures of the text. If words is false, digits that are part of the words
+// (e.g. "Q2", "G7", "A320") are skipped, otherwise they are extracted too. The index names (e.g. "S&P 500")
+// are never extracted.
+func extractFigures(text string, words bool) []figure {
+	text = indexNamesRegex.ReplaceAllString(text, " ")
+
+	var figures []figure
+	for _, loc := range numberRegex.FindAllStringIndex(text, -1) {
+		s := strings.TrimRight(text[loc[0]:loc[1]], ".,")
+		if !words && partOfWord(text, loc[0], loc[0]+len(s)) {
+			continue
+		}
+
+		if f, ok := parseFigure(s); ok {
+			figures = append(figures, f)
+		}
+	}
+
+	return figures
+}
+
+// partOfWord returns true if the number at text[start:end] is surrounded by the letters (except the allowed suffixes).
+func partOfWord(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); unicode.IsLetter(r) {
+		return true
+	}
+
+	rest := text[end:]
+	i := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) })
+	if i < 0 {
+		i = len(rest)
+	}
+	if i == 0 {
+		return false
+	}
+
+	return !slices.Contains(numberSuffixes, strings.ToLower(rest[:i]))
+}
+
+// parseFigure parses the number with the comma thousands separators or the comma decimal separator.
+func parseFigure(s string) (figure, bool) {
+	normalized := s
+	if thousandsRegex.MatchString(s) {
+		normalized = strings.ReplaceAll(s, ",", "")
+	} else if strings.Count(s, ",") == 1 && !strings.Contains(s, ".") {
+		normalized = strings.Replace(s, ",", ".", 1)
+	}
+
+	value, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return figure{}, false
+	}
+
+	decimals := 0
+	if _, frac, ok := strings.Cut(normalized, "."); ok {
+		decimals = len(frac)
+	}
+
+	return figure{text: s, value: value, decimals: decimals}, true
+}
+
+// matches returns true if the figure is the original one or the original one rounded to its decimal places,
+// e.g. "12.5" matches "12.47".
+func (f figure) matches(original figure) bool {
+	if f.decimals > original.decimals {
+		return false
+	}
+
+	scale := math.Pow10(f.decimals)
+	return math.Round(original.value*scale) == math.Round(f.value*scale)
+}
+
+// unverifiedNumbers returns the figures of the composed text that don't appear in the original title
+// and description of the news (as is or rounded). Empty if all figures are verified.
+func unverifiedNumbers(composedText, title, description string) []string {
+	originals := extractFigures(title+"\n"+description, true)
+
+	var unverified []string
+	for _, f := range extractFigures(composedText, false) {
+		if !slices.ContainsFunc(originals, f.matches) {
+			unverified = append(unverified, f.text)
+		}
+	}
+
+	return unverified
+}
+
+// moderationReason returns the News.ModerationReason of the unverified figures (up to its column size).
+// Empty if there are no unverified figures.
+func moderationReason(unverified []string) string {
+	if len(unverified) == 0 {
+		return ""
+	}
+
+	reason := "unverified numbers: " + strings.Join(unverified, ", ")
+	if len(reason) > 128 {
+		reason = reason[:125] + "..."
+	}
+
+	return reason
+}
+
+// reportModeration records the number of the news held for moderation as the run stage (see Job.VerifyNumbers)
+// and logs them, so the admin can review and approve them.
+func (job *Job) reportModeration(r *JobRun, news []*archivist.News) {
+	if !job.options.verifyNumbers {
+		return
+	}
+
+	flagged := 0
+	for _, n := range news {
+		if n.ModerationReason != "" {
+			flagged++
+			job.logger.Warn(fmt.Sprintf("[%s] news held for moderation", job.name),
+				"id", n.ID, "title", n.OriginalTitle, "reason", n.ModerationReason)
+		}
+	}
+	r.Stage("moderation", flagged, nil)
+}
@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_unverifiedNumbers(t *testing.T) {
+	tests := []struct {
+		name        string
+		composed    string
+		title       string
+		description string
+		want        []string
+	}{
+		{
+			name:        "same figures",
+			composed:    "Apple revenue rose 12.5% to $1,234 million, EPS $1.53.",
+			title:       "Apple revenue up 12.5%",
+			description: "Revenue was $1,234 million and EPS was $1.53.",
+		},
+		{
+			name:     "rounded figures",
+			composed: "Nvidia shares jumped 8.1% after revenue of $26B.",
+			title:    "Nvidia jumps 8.07% on $26.04B revenue",
+		},
+		{
+			name:     "thousands separator",
+			composed: "Tesla deliveries fell 8.5% to 386,810 cars.",
+			title:    "Tesla deliveries fell 8.5% to 386,810 vehicles",
+		},
+		{
+			name:     "invented and altered figures",
+			composed: "Tesla deliveries fell 10% to 368,810 cars, the stock lost 3%.",
+			title:    "Tesla deliveries fell 8.5% to 386,810 vehicles",
+			want:     []string{"10", "368,810", "3"},
+		},
+		{
+			name:     "more precise than the original",
+			composed: "The Fed cut rates to 5.25%.",
+			title:    "The Fed cut rates to 5.3%",
+			want:     []string{"5.25"},
+		},
+		{
+			name:     "words and index names are not figures",
+			composed: "S&P 500 and Nasdaq 100 futures rose as Q2 GDP and the G7 summit came into focus.",
+			title:    "Futures rise ahead of GDP data",
+		},
+		{
+			name:     "figures with suffixes",
+			composed: "The deal is valued at $3bn, or 10x sales.",
+			title:    "Company agrees to the $3bn deal",
+			want:     []string{"10"},
+		},
+		{
+			name:     "comma decimal separator",
+			composed: "Inflation eased to 2,4%.",
+			title:    "Inflation eased to 2.4%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unverifiedNumbers(tt.composed, tt.title, tt.description); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unverifiedNumbers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_moderationReason(t *testing.T) {
+	if got := moderationReason(nil); got != "" {
+		t.Errorf("moderationReason() = %q, want empty", got)
+	}
+	if got := moderationReason([]string{"9", "3"}); got != "unverified numbers: 9, 3" {
+		t.Errorf("moderationReason() = %q, want the listed numbers", got)
+	}
+	if got := moderationReason(strings.Split(strings.Repeat("123456,", 30), ",")); len(got) != 128 {
+		t.Errorf("moderationReason() length = %d, want 128", len(got))
+	}
+}
+
+func TestJob_newDBNews_verifyNumbers(t *testing.T) {
+	job := &Job{publisher: &mockPublisher{}, options: &jobOptions{verifyNumbers: true, republishMaxAge: 1}}
+	news := journalist.NewsList{
+		{ID: "1", Title: "Apple revenue up 12%"},
+		{ID: "2", Title: "Tesla deliveries fell 8.5%"},
+	}
+	composed := []*composer.ComposedNews{
+		{ID: "1", Text: "Apple revenue rose 12%."},
+		{ID: "2", Text: "Tesla deliveries fell 10%."},
+	}
+
+	got, err := job.newDBNews(news, composed)
+	if err != nil {
+		t.Fatalf("newDBNews() error = %v", err)
+	}
+
+	if got[0].ModerationReason != "" || !got[0].PublishPending {
+		t.Errorf("newDBNews() verified news = %q, pending %v, want no moderation", got[0].ModerationReason, got[0].PublishPending)
+	}
+	if got[1].ModerationReason != "unverified numbers: 10" || got[1].PublishPending {
+		t.Errorf("newDBNews() altered news = %q, pending %v, want held for moderation", got[1].ModerationReason, got[1].PublishPending)
+	}
+}
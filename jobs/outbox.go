@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// isNetworkError returns true if the error is caused by the network (Telegram is unreachable, timeout, DNS),
+// not by the Telegram API response (e.g. bad markdown), so the message can be published later.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// enqueue saves the formatted news to the outbox of the channel (see Job.QueueWhenOffline).
+// The queued news is no longer pending publication, so it's replayed only by the OutboxJob.
+func (job *Job) enqueue(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, n *archivist.News, formattedText string) error {
+	span := tx.StartChild("publish.Outbox.Create")
+	span.SetTag("news_hash", n.Hash)
+	err := job.archivist.Entities.Outbox.Create(ctx, &archivist.OutboxMessage{
+		ChannelID: job.publisher.Channel(),
+		NewsHash:  n.Hash,
+		Message:   formattedText,
+	})
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][enqueue.Outbox.Create]: %w", job.name, err)
+		utils.CaptureSentryException("jobEnqueueError", hub, e)
+		return e
+	}
+
+	return job.markPending(ctx, tx, hub, []*archivist.News{n}, false)
+}
+
+// hasQueued returns true if the outbox of the channel has the messages not replayed yet (see Job.QueueWhenOffline),
+// so the news are queued behind them to keep the order of publication. Errors are reported and treated as empty outbox.
+func (job *Job) hasQueued(ctx context.Context, tx *sentry.Span, hub *sentry.Hub) bool {
+	if !job.options.queueWhenOffline {
+		return false
+	}
+
+	span := tx.StartChild("publish.Outbox.Count")
+	count, err := job.archivist.Entities.Outbox.Count(ctx, job.publisher.Channel())
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][hasQueued.Outbox.Count]: %w", job.name, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobOutboxCountError", hub, e)
+		return false
+	}
+
+	return count > 0
+}
+
+// OutboxJob replays the messages queued while Telegram was unreachable (see Job.QueueWhenOffline)
+// in the order they were queued. Messages older than the max age are dropped rather than posted late.
+type OutboxJob struct {
+	publisher publisher.Publisher  // publisher of the channel whose messages are replayed
+	archivist *archivist.Archivist // archivist with the outbox and the news of the channel
+	maxAge    time.Duration        // queued messages older than this are dropped
+	logger    *slog.Logger         // special logger for the job
+	now       func() time.Time
+}
+
+// NewOutboxJob creates a new OutboxJob instance.
+func NewOutboxJob(publisher publisher.Publisher, archivist *archivist.Archivist, maxAge time.Duration) *OutboxJob {
+	return &OutboxJob{
+		publisher: publisher,
+		archivist: archivist,
+		maxAge:    maxAge,
+		logger:    slog.Default(),
+		now:       time.Now,
+	}
+}
+
+// Run return job function that will be executed by the scheduler.
+// Replay stops on the first network error, the rest of the messages wait for the next run.
+func (j *OutboxJob) Run() JobFunc {
+	return WithInstrumentation("outbox", func(ctx context.Context, r *JobRun) {
+		tx := r.Tx
+		r.SetChannel(j.publisher.Channel())
+
+		span := tx.StartChild("Archivist.Outbox.FindPending")
+		messages, err := j.archivist.Entities.Outbox.FindPending(ctx, j.publisher.Channel())
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("outboxJobFindError", "Error finding queued messages", err)
+			return
+		}
+		r.Stage("queued", len(messages), nil)
+
+		var published, dropped int
+		for _, m := range messages {
+			if age := j.now().Sub(m.CreatedAt); age > j.maxAge {
+				j.logger.Info(fmt.Sprintf("[job-outbox] Dropping message %d queued %s ago", m.ID, age.Round(time.Second)))
+				dropped++
+			} else {
+				span := tx.StartChild("Publisher.Publish")
+				id, err := j.publisher.Publish(publisher.Markdown(m.Message))
+				span.Finish()
+				if err != nil && isNetworkError(err) {
+					r.Warn("outboxJobOffline", "Telegram is still unreachable", err)
+					break
+				}
+				if err != nil {
+					// Message is dropped, otherwise it would block the queue forever
+					r.Error("outboxJobPublishError", fmt.Sprintf("Error publishing message %d", m.ID), err)
+					dropped++
+				} else {
+					published++
+					j.updateNews(ctx, r, m, id)
+				}
+			}
+
+			if err := j.archivist.Entities.Outbox.Delete(ctx, m.ID); err != nil {
+				// Stop to not publish the same message twice on the next run
+				_ = r.Fail("outboxJobDeleteError", fmt.Sprintf("Error deleting message %d", m.ID), err)
+				break
+			}
+		}
+		r.Stage("published", published, nil)
+		r.Stage("dropped", dropped, nil)
+
+		if published > 0 || dropped > 0 {
+			r.Success("Replayed %d queued messages, dropped %d", published, dropped)
+		}
+	})
+}
+
+// updateNews saves the publication ID of the replayed message to its news. Errors are only reported
+// because the message is already published.
+func (j *OutboxJob) updateNews(ctx context.Context, r *JobRun, m *archivist.OutboxMessage, pubID string) {
+	if m.NewsHash == "" {
+		return
+	}
+
+	err := j.archivist.Entities.News.Update(ctx, &archivist.News{
+		Hash:          m.NewsHash,
+		PublicationID: pubID,
+		PublishedAt:   j.now(),
+	})
+	if err != nil {
+		r.Warn("outboxJobUpdateNewsError", fmt.Sprintf("Error updating news %s of message %d", m.NewsHash, m.ID), err)
+	}
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func Test_isNetworkError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "request error",
+			err:  fmt.Errorf("failed to send message to Telegram: %w", &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: dialErr}),
+			want: true,
+		},
+		{
+			name: "dial error",
+			err:  dialErr,
+			want: true,
+		},
+		{
+			name: "API error",
+			err:  fmt.Errorf("failed to send message to Telegram: %w", errors.New("Bad Request: can't parse entities")),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNetworkError(tt.err); got != tt.want {
+				t.Errorf("isNetworkError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"log/slog"
+	"time"
+)
+
+// pauseCheckTimeout is the timeout of the pause lookup before each run.
+const pauseCheckTimeout = 5 * time.Second
+
+// ErrPaused is returned by the job function of the paused job instead of running it.
+var ErrPaused = errors.New("job is paused")
+
+// pauseFinder finds the pause that halts the job (e.g. archivist.PausesDB).
+type pauseFinder interface {
+	Find(ctx context.Context, job string) (*archivist.Pause, error)
+}
+
+// PauseGuard skips the runs of the jobs paused in the database (see archivist.Pause), so the operator can halt
+// publishing during the incidents without stopping the process. Skipped runs don't move the job checkpoints.
+type PauseGuard struct {
+	pauses pauseFinder
+	hub    *sentry.Hub
+	logger *slog.Logger
+}
+
+// NewPauseGuard creates a new PauseGuard with the pauses storage.
+func NewPauseGuard(pauses pauseFinder) *PauseGuard {
+	return &PauseGuard{
+		pauses: pauses,
+		hub:    sentry.CurrentHub().Clone(),
+		logger: slog.Default(),
+	}
+}
+
+// Guard returns the job function that checks the global and the job pause before each run of fn.
+// If the pause can't be checked, the job runs, so the database outage doesn't halt the channel.
+// Skipped runs return ErrPaused.
+func (g *PauseGuard) Guard(job string, fn JobFunc) JobFunc {
+	return func() error {
+		if g.paused(job) {
+			return ErrPaused
+		}
+		return fn()
+	}
+}
+
+// paused returns true if the job or all jobs are paused.
+func (g *PauseGuard) paused(job string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), pauseCheckTimeout)
+	defer cancel()
+
+	p, err := g.pauses.Find(ctx, job)
+	if err != nil {
+		e := fmt.Errorf("[pause-guard] Error checking pause of %s: %w", job, err)
+		g.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobPauseCheckError", g.hub, e)
+		return false
+	}
+	if p == nil {
+		return false
+	}
+
+	g.logger.Info(fmt.Sprintf("[pause-guard] Skipping paused run of %s", job), "pause", p.Job, "reason", p.Reason)
+	return true
+}
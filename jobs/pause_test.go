@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/archivist"
+	"testing"
+)
+
+type fakePauses map[string]*archivist.Pause
+
+func (f fakePauses) Find(_ context.Context, job string) (*archivist.Pause, error) {
+	if p, ok := f[archivist.PauseAll]; ok {
+		return p, nil
+	}
+	if job == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	return f[job], nil
+}
+
+func TestPauseGuard_Guard(t *testing.T) {
+	tests := []struct {
+		name    string
+		pauses  fakePauses
+		job     string
+		wantRun bool
+	}{
+		{name: "not paused", pauses: fakePauses{"broad": {Job: "broad"}}, job: "market", wantRun: true},
+		{name: "job paused", pauses: fakePauses{"market": {Job: "market"}}, job: "market"},
+		{name: "all paused", pauses: fakePauses{archivist.PauseAll: {Job: archivist.PauseAll}}, job: "market"},
+		{name: "check failed", pauses: fakePauses{}, job: "broken", wantRun: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			err := NewPauseGuard(tt.pauses).Guard(tt.job, func() error { ran = true; return nil })()
+			if ran != tt.wantRun {
+				t.Errorf("Guard() ran = %v, want %v", ran, tt.wantRun)
+			}
+			if errors.Is(err, ErrPaused) == tt.wantRun {
+				t.Errorf("Guard() error = %v, want ErrPaused = %v", err, !tt.wantRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"encoding/json"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/publisher"
+)
+
+// ratingChangeReason is the filter reason of the analyst rating changes omitted by Job.OmitRatingChanges.
+const ratingChangeReason = "rating-change"
+
+// ratingChange returns the analyst rating change of the saved news (parsed from the original title)
+// if the job publishes them in the structured format. Returns nil otherwise.
+func (job *Job) ratingChange(n *archivist.News) *journalist.RatingChange {
+	if !job.options.includeRatings {
+		return nil
+	}
+	return journalist.ParseRatingChange(n.OriginalTitle)
+}
+
+// formatRatingChange formats the news as the analyst rating change, e.g.
+// "📊 Goldman Sachs upgrades AAPL: Neutral → Buy, PT $220 (from $200)" with the ticker link and sector hashtags
+// from the composed meta. The first composed ticker is used if the title has no ticker.
+func formatRatingChange(n archivist.News, r *journalist.RatingChange, decorate func(link string) string) publisher.Message {
+	rating := *r
+	if rating.Ticker == "" && n.MetaData != nil {
+		var meta composer.ComposedMeta
+		if err := json.Unmarshal(n.MetaData, &meta); err == nil && len(meta.Tickers) > 0 {
+			rating.Ticker = meta.Tickers[0]
+		}
+	}
+
+	n.ComposedText = "📊 " + rating.String()
+	msg := formatNewsWithComposedMeta(n, decorate)
+	msg.Tags = append(msg.Tags, "ratings")
+	return msg
+}
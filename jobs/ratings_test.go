@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"encoding/json"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"testing"
+)
+
+func TestJob_ratingChange(t *testing.T) {
+	n := &archivist.News{OriginalTitle: "Morgan Stanley downgrades Tesla to Equal-Weight"}
+
+	if r := (&Job{options: &jobOptions{}}).ratingChange(n); r != nil {
+		t.Errorf("ratingChange() = %+v, want nil without IncludeRatingChanges", r)
+	}
+
+	job := &Job{options: &jobOptions{includeRatings: true}}
+	if r := job.ratingChange(n); r == nil || r.Firm != "Morgan Stanley" || r.To != "Equal-Weight" {
+		t.Errorf("ratingChange() = %+v, want parsed rating change", r)
+	}
+	if r := job.ratingChange(&archivist.News{OriginalTitle: "Tesla delivers record number of cars"}); r != nil {
+		t.Errorf("ratingChange() = %+v, want nil for regular news", r)
+	}
+}
+
+func Test_formatRatingChange(t *testing.T) {
+	meta, _ := json.Marshal(composer.ComposedMeta{Tickers: []string{"TSLA"}, Sectors: []string{"Consumer Discretionary"}})
+	n := archivist.News{ComposedText: "Morgan Stanley cut Tesla to Equal-Weight.", MetaData: meta}
+	r := &journalist.RatingChange{
+		Firm: "Morgan Stanley", Action: journalist.RatingDowngrade, Company: "Tesla",
+		From: "Overweight", To: "Equal-Weight", PriceTarget: "310", PriorTarget: "345",
+	}
+
+	want := "📊 Morgan Stanley downgrades [TSLA](https://short-fork.extr.app/en/TSLA?utm_source=finthread): " +
+		"Overweight → Equal-Weight, PT $310 (from $345)\n" + sectorHashtag("Consumer Discretionary") + " #ratings"
+	if got := formatRatingChange(n, r, defaultLinks).Markdown(); got != want {
+		t.Errorf("formatRatingChange() = %q, want %q", got, want)
+	}
+	if r.Ticker != "" {
+		t.Errorf("formatRatingChange() should not modify the rating change")
+	}
+}
@@ -0,0 +1,238 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RecapJob publishes the post-market recap of the trading day: index closing levels,
+// surprises of the economic releases and the AI summary of the day's published news.
+// Unlike SummaryJob (pre-open), it should be run after the US market close.
+type RecapJob struct {
+	composer  *composer.Composer   // composer that will summarise the day's news using OpenAI
+	publisher publisher.Publisher  // publisher that will publish the recap to the channel
+	archivist *archivist.Archivist // archivist to find the day's published news and economic releases
+	quotes    *quotes.Quotes       // quotes source to get the indices closing levels (optional)
+	logger    *slog.Logger         // special logger for the job
+	indices   []recapIndex         // indices to include in the market close section
+}
+
+// recapIndex is the index ticker in the quotes source with its human-readable name.
+type recapIndex struct {
+	ticker string
+	name   string
+}
+
+// NewRecapJob creates a new RecapJob instance for S&P 500, Nasdaq and Dow Jones indices.
+func NewRecapJob(
+	composer *composer.Composer,
+	publisher publisher.Publisher,
+	archivist *archivist.Archivist,
+	quotes *quotes.Quotes,
+) *RecapJob {
+	return &RecapJob{
+		composer:  composer,
+		publisher: publisher,
+		archivist: archivist,
+		quotes:    quotes,
+		logger:    slog.Default(),
+		indices: []recapIndex{
+			{ticker: "^GSPC", name: "S&P 500"},
+			{ticker: "^IXIC", name: "Nasdaq"},
+			{ticker: "^DJI", name: "Dow Jones"},
+		},
+	}
+}
+
+// indexClose is the closing level of the index and its daily change in percents.
+type indexClose struct {
+	name   string
+	last   float64
+	change float64
+}
+
+// recap holds the data of all sections of the trading day recap.
+type recap struct {
+	day        time.Time
+	closes     []*indexClose
+	releases   []*archivist.Event
+	summarised []*composer.SummarisedHeadline
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *RecapJob) Run() JobFunc {
+	return WithInstrumentationTimeout("recap", 60*time.Second, func(ctx context.Context, r *JobRun) {
+		tx := r.Tx
+		r.SetChannel(j.publisher.Channel())
+
+		day := time.Now().UTC().Truncate(24 * time.Hour)
+		rec := &recap{day: day}
+
+		// Each section is optional, so the recap is published even if some of them failed
+		if j.quotes != nil {
+			span := tx.StartChild("Quotes.FetchIntraday")
+			for _, index := range j.indices {
+				data, err := j.quotes.FetchIntraday(ctx, index.ticker)
+				if err != nil {
+					r.Warn("recapJobFetchQuotesError", fmt.Sprintf("Error fetching %s quotes", index.ticker), err)
+					continue
+				}
+				if len(data.Points) == 0 {
+					continue
+				}
+				rec.closes = append(rec.closes, &indexClose{name: index.name, last: data.Last(), change: data.Change()})
+			}
+			span.Finish()
+		}
+
+		span := tx.StartChild("Events.FindAllUntilDate")
+		events, err := j.archivist.Entities.Events.FindAllUntilDate(ctx, day)
+		span.Finish()
+		if err != nil {
+			r.Error("recapJobEventsFindAllError", "Error fetching events from the database", err)
+		}
+		rec.releases = recapReleases(events)
+
+		span = tx.StartChild("News.FindAllUntilDate")
+		news, err := j.archivist.Entities.News.FindAllUntilDate(ctx, day)
+		span.Finish()
+		if err != nil {
+			r.Error("recapJobNewsFindAllError", "Error fetching news from the database", err)
+		}
+
+		published := lo.Filter(news, func(n *archivist.News, _ int) bool {
+			return n.PublicationID != ""
+		})
+		headlines := storyHeadlines(published)
+
+		if len(headlines) > 0 {
+			span = tx.StartChild("Composer.Recap")
+			summarised, err := j.composer.Recap(ctx, headlines, 10, 2048)
+			span.Finish()
+			if err != nil {
+				r.Error("recapJobComposerRecapError", "Error composing recap", err)
+			}
+			rec.summarised = summarised
+		}
+
+		m := formatRecap(rec)
+		if m == "" {
+			r.Success("Nothing to publish in the recap")
+			return
+		}
+
+		span = tx.StartChild("TelegramPublisher.Publish")
+		pubID, err := j.publisher.Publish(publisher.Markdown(m))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("recapJobPublishError", "Error publishing recap", err)
+			return
+		}
+
+		// Economic releases are listed in the recap as is, so they are always included
+		summary := archivist.NewSummary(archivist.SummaryRecap, headlines, rec.summarised)
+		for _, e := range rec.releases {
+			summary.InputIDs = append(summary.InputIDs, e.ID.String())
+			summary.IncludedIDs = append(summary.IncludedIDs, e.ID.String())
+		}
+		summary.ChannelID = j.publisher.Channel()
+		summary.PublicationID = pubID
+		summary.Text = m
+
+		span = tx.StartChild("Summaries.Create")
+		err = j.archivist.Entities.Summaries.Create(ctx, summary)
+		span.Finish()
+		if err != nil {
+			r.Error("recapJobSaveError", "Error saving recap", err)
+		}
+
+		r.Success("Recap published with %d indices, %d releases and %d headlines", len(rec.closes), len(rec.releases), len(rec.summarised))
+	})
+}
+
+// recapReleases returns economic releases with the actual value sorted by time.
+// The same event saved for different channels is included only once.
+func recapReleases(events []*archivist.Event) []*archivist.Event {
+	releases := lo.Filter(events, func(e *archivist.Event, _ int) bool {
+		return e.Actual != ""
+	})
+	releases = lo.UniqBy(releases, func(e *archivist.Event) string {
+		return fmt.Sprintf("%s|%s|%s", e.Title, e.Currency, e.DateTime.Format(time.RFC3339))
+	})
+	slices.SortStableFunc(releases, func(a, b *archivist.Event) int {
+		return a.DateTime.Compare(b.DateTime)
+	})
+
+	return releases
+}
+
+// formatSurprise formats the actual value of the release compared to the forecast (if any).
+func formatSurprise(e *archivist.Event) string {
+	if e.Forecast == "" {
+		return fmt.Sprintf("*%s*", e.Actual)
+	}
+
+	actual, forecast := signedValue(e.Actual), signedValue(e.Forecast)
+	switch {
+	case actual > forecast:
+		return fmt.Sprintf("*%s* vs %s forecast, above", e.Actual, e.Forecast)
+	case actual < forecast:
+		return fmt.Sprintf("*%s* vs %s forecast, below", e.Actual, e.Forecast)
+	default:
+		return fmt.Sprintf("*%s*, in line with the forecast", e.Actual)
+	}
+}
+
+// formatRecap formats the trading day recap with the market close, economic releases and news sections.
+// Empty sections are skipped, empty string is returned if all sections are empty.
+func formatRecap(r *recap) string {
+	var sections []string
+
+	var closes strings.Builder
+	for _, c := range r.closes {
+		emoji := "📈"
+		if c.change < 0 {
+			emoji = "📉"
+		}
+		closes.WriteString(fmt.Sprintf("%s %s: %.2f (%+.2f%%)\n", emoji, c.name, c.last, c.change))
+	}
+	if closes.Len() > 0 {
+		sections = append(sections, "🔔 *Market close*\n"+closes.String())
+	}
+
+	var releases strings.Builder
+	for _, e := range r.releases {
+		releases.WriteString(fmt.Sprintf("%s %s: %s\n", ecal.GetCountryEmoji(e.Country), e.Title, formatSurprise(e)))
+	}
+	if releases.Len() > 0 {
+		sections = append(sections, "📊 *Economic releases*\n"+releases.String())
+	}
+
+	var headlines strings.Builder
+	for _, h := range r.summarised {
+		m := fmt.Sprintf("- %s\n", h.Summary)
+		if h.Link != "" && h.Verb != "" {
+			m = strings.Replace(m, h.Verb, fmt.Sprintf("[%s](%s)", h.Verb, h.Link), 1)
+		}
+		headlines.WriteString(m)
+	}
+	if headlines.Len() > 0 {
+		sections = append(sections, "📰 *What happened today*\n"+headlines.String())
+	}
+
+	if len(sections) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("🌆 Trading day recap: %s\n\n%s\n#recap", r.day.Format("Jan 2"), strings.Join(sections, "\n"))
+}
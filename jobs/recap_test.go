@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"testing"
+	"time"
+)
+
+func Test_recapReleases(t *testing.T) {
+	at := time.Date(2024, 1, 15, 13, 30, 0, 0, time.UTC)
+	events := []*archivist.Event{
+		{ChannelID: "1", Title: "Retail Sales", Currency: ecal.EconomicCalendarUSD, DateTime: at.Add(time.Hour), Actual: "0.6%"},
+		{ChannelID: "1", Title: "CPI", Currency: ecal.EconomicCalendarUSD, DateTime: at, Actual: "3.4%"},
+		{ChannelID: "2", Title: "CPI", Currency: ecal.EconomicCalendarUSD, DateTime: at, Actual: "3.4%"},
+		{ChannelID: "1", Title: "PPI", Currency: ecal.EconomicCalendarUSD, DateTime: at},
+	}
+
+	got := recapReleases(events)
+	if len(got) != 2 || got[0].Title != "CPI" || got[1].Title != "Retail Sales" {
+		t.Errorf("recapReleases() = %v, want unique CPI and Retail Sales sorted by time", got)
+	}
+}
+
+func Test_formatSurprise(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *archivist.Event
+		want  string
+	}{
+		{name: "above", event: &archivist.Event{Actual: "3.4%", Forecast: "3.2%"}, want: "*3.4%* vs 3.2% forecast, above"},
+		{name: "below negative", event: &archivist.Event{Actual: "-0.2%", Forecast: "0.1%"}, want: "*-0.2%* vs 0.1% forecast, below"},
+		{name: "in line", event: &archivist.Event{Actual: "5.25%", Forecast: "5.25%"}, want: "*5.25%*, in line with the forecast"},
+		{name: "no forecast", event: &archivist.Event{Actual: "210K"}, want: "*210K*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSurprise(tt.event); got != tt.want {
+				t.Errorf("formatSurprise() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatRecap(t *testing.T) {
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		recap *recap
+		want  string
+	}{
+		{
+			name:  "empty",
+			recap: &recap{day: day},
+			want:  "",
+		},
+		{
+			name: "all sections",
+			recap: &recap{
+				day: day,
+				closes: []*indexClose{
+					{name: "S&P 500", last: 4783.83, change: 0.52},
+					{name: "Nasdaq", last: 14972.76, change: -0.31},
+				},
+				releases: []*archivist.Event{
+					{Title: "CPI", Country: ecal.EconomicCalendarUnitedStates, Actual: "3.4%", Forecast: "3.2%"},
+				},
+				summarised: []*composer.SummarisedHeadline{
+					{ID: "1", Summary: "Nvidia rallied after earnings", Verb: "rallied", Link: "https://t.me/fin_thread/1"},
+				},
+			},
+			want: "🌆 Trading day recap: Jan 15\n\n" +
+				"🔔 *Market close*\n📈 S&P 500: 4783.83 (+0.52%)\n📉 Nasdaq: 14972.76 (-0.31%)\n\n" +
+				"📊 *Economic releases*\n🇺🇸 CPI: *3.4%* vs 3.2% forecast, above\n\n" +
+				"📰 *What happened today*\n- Nvidia [rallied](https://t.me/fin_thread/1) after earnings\n" +
+				"\n#recap",
+		},
+		{
+			name: "only releases",
+			recap: &recap{
+				day:      day,
+				releases: []*archivist.Event{{Title: "CPI", Country: ecal.EconomicCalendarUnitedStates, Actual: "3.4%"}},
+			},
+			want: "🌆 Trading day recap: Jan 15\n\n📊 *Economic releases*\n🇺🇸 CPI: *3.4%*\n\n#recap",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRecap(tt.recap); got != tt.want {
+				t.Errorf("formatRecap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
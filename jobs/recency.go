@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/journalist"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// recentTexts returns the composer option with the texts recently published to the channel on the topics
+// mentioned in the news (see Job.AvoidRepetition), so the composer doesn't repeat their phrasing.
+// Returns no options if nothing was published on the topics. The recency context is not critical,
+// so errors are only reported and the news are composed without it.
+func (job *Job) recentTexts(
+	ctx context.Context,
+	tx *sentry.Span,
+	hub *sentry.Hub,
+	news journalist.NewsList,
+) []composer.Option {
+	if job.options.recentTexts <= 0 || job.archivist == nil {
+		return nil
+	}
+
+	tickers, hashtags := job.mentionedTopics(news)
+	if len(tickers) == 0 && len(hashtags) == 0 {
+		return nil
+	}
+
+	span := tx.StartChild("recentTexts.News.FindRecentTexts")
+	recent, err := job.archivist.Entities.News.FindRecentTexts(
+		ctx,
+		job.publisher.Channel(),
+		time.Now().Add(-job.options.recentPeriod),
+		tickers,
+		hashtags,
+		job.options.recentTexts,
+	)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][recentTexts.News.FindRecentTexts]: %w", job.name, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobRecentTextsError", hub, e)
+		return nil
+	}
+
+	if len(recent) == 0 {
+		return nil
+	}
+
+	texts := make([]string, 0, len(recent))
+	for _, n := range recent {
+		texts = append(texts, n.ComposedText)
+	}
+
+	return []composer.Option{composer.UseRecentTexts(texts...)}
+}
+
+// mentionedTopics returns the tickers and hashtags (see composer.Hashtags) mentioned in the original titles
+// and descriptions of the news. Tickers are the cashtags (e.g. $AAPL) and the upper-case words listed
+// in the Job.universe or Job.stocks (if set), so common abbreviations (e.g. "CEO") are not taken for tickers.
+func (job *Job) mentionedTopics(news journalist.NewsList) (tickers, hashtags []string) {
+	checkListed := job.universe != nil || job.stocks != nil
+
+	for _, n := range news {
+		words := strings.FieldsFunc(n.Title+"\n"+n.Description, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '$' && r != '.'
+		})
+		for _, w := range words {
+			w = strings.Trim(w, ".")
+			cashtag := strings.HasPrefix(w, "$")
+			w = strings.TrimLeft(w, "$")
+			if w == "" {
+				continue
+			}
+
+			if i := slices.IndexFunc(composer.Hashtags, func(h string) bool { return strings.EqualFold(h, w) }); i >= 0 {
+				hashtags = append(hashtags, composer.Hashtags[i])
+				continue
+			}
+
+			if isTickerWord(w) && (cashtag || (checkListed && job.listed(w))) {
+				tickers = append(tickers, w)
+			}
+		}
+	}
+
+	slices.Sort(tickers)
+	slices.Sort(hashtags)
+	return slices.Compact(tickers), slices.Compact(hashtags)
+}
+
+// isTickerWord returns true if the word looks like a ticker: 1-5 upper-case letters with the optional
+// share class suffix (e.g. "BRK.B").
+func isTickerWord(w string) bool {
+	symbol, class, found := strings.Cut(w, ".")
+	if len(symbol) == 0 || len(symbol) > 5 || (found && len(class) != 1) {
+		return false
+	}
+
+	for _, r := range symbol + class {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/journalist"
+	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"reflect"
+	"testing"
+)
+
+func TestJob_mentionedTopics(t *testing.T) {
+	news := journalist.NewsList{
+		{Title: "Apple (AAPL) rises on strong iPhone sales", Description: "CEO says AI features boost demand."},
+		{Title: "$TSLA and BRK.B lead the gains", Description: "The Fed holds rates, inflation cools."},
+	}
+
+	tests := []struct {
+		name         string
+		stocks       *stocks.StockMap
+		wantTickers  []string
+		wantHashtags []string
+	}{
+		{
+			name:         "listed tickers and cashtags",
+			stocks:       &stocks.StockMap{"AAPL": {}, "BRK.B": {}},
+			wantTickers:  []string{"AAPL", "BRK.B", "TSLA"},
+			wantHashtags: []string{"AI", "fed", "inflation"},
+		},
+		{
+			name:         "only cashtags without stocks",
+			wantTickers:  []string{"TSLA"},
+			wantHashtags: []string{"AI", "fed", "inflation"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{stocks: tt.stocks, options: &jobOptions{}}
+			tickers, hashtags := job.mentionedTopics(news)
+			if !reflect.DeepEqual(tickers, tt.wantTickers) || !reflect.DeepEqual(hashtags, tt.wantHashtags) {
+				t.Errorf("mentionedTopics() = %v, %v, want %v, %v", tickers, hashtags, tt.wantTickers, tt.wantHashtags)
+			}
+		})
+	}
+}
+
+func Test_isTickerWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "AAPL", want: true},
+		{word: "BRK.B", want: true},
+		{word: "F", want: true},
+		{word: "GOOGLE"},
+		{word: "Apple"},
+		{word: "BRK.BB"},
+		{word: "S&P"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := isTickerWord(tt.word); got != tt.want {
+				t.Errorf("isTickerWord(%q) = %v, want %v", tt.word, got, tt.want)
+			}
+		})
+	}
+}
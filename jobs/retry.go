@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/sashabaranov/go-openai"
+	"net/http"
+	"time"
+)
+
+// transient returns true if the error is likely to pass on retry: network errors, rate limits and server errors
+// of the AI provider API and Telegram flood control. Expired or canceled context is never transient.
+func transient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if isNetworkError(err) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+
+	var tgErr tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.RetryAfter > 0
+}
+
+// retryableStatus returns true for the rate limit and server error HTTP statuses.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryStage runs fn up to the stage attempts (see Job.RetryStages) while it fails with the retryable error
+// and ctx is not done. Returns the last error. Each retry is recorded in the run report of the stage.
+func (job *Job) retryStage(ctx context.Context, r *JobRun, stage string, retryable func(error) bool, fn func() error) error {
+	for attempt := uint(1); ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= job.options.stageAttempts || !retryable(err) {
+			return err
+		}
+
+		r.Retry(stage, attempt, err)
+		select {
+		case <-time.After(retryDelay(err, job.options.stageRetryDelay)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// retryDelay returns the delay before the next attempt: the given one or the longer wait requested
+// by Telegram flood control.
+func retryDelay(err error, delay time.Duration) time.Duration {
+	var tgErr tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		return max(delay, time.Duration(tgErr.RetryAfter)*time.Second)
+	}
+	return delay
+}
+
+// verifies returns true if the publisher verifies the ambiguous publication errors (see publisher.Verifier).
+// The publisher.MultiPublisher is retried only after the failure of its primary target, which verifies them.
+func verifies(p publisher.Publisher) bool {
+	if m, ok := p.(*publisher.MultiPublisher); ok {
+		p = m.Primary()
+	}
+	tp, ok := p.(*publisher.TelegramPublisher)
+	return ok && tp.Verifier != nil
+}
+
+// publishRetryable returns the retry condition of the publication. The news hash is its idempotency key:
+// the news is published again only while it's known to be unpublished, i.e. the error is not ambiguous
+// or the publisher verified that the message didn't reach the channel (verified is true for the text messages
+// of the publisher with publisher.Verifier). The message that reached the primary channel of the
+// publisher.MultiPublisher is never published again.
+func publishRetryable(verified bool) func(err error) bool {
+	return func(err error) bool {
+		return !publisher.IsPartial(err) && transient(err) && (verified || !publisher.IsAmbiguous(err))
+	}
+}
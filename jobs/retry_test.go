@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/sashabaranov/go-openai"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_transient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network error", err: fmt.Errorf("compose: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), want: true},
+		{name: "rate limit", err: fmt.Errorf("compose: %w", &openai.APIError{HTTPStatusCode: 429}), want: true},
+		{name: "server error", err: &openai.RequestError{HTTPStatusCode: 502, Err: errors.New("bad gateway")}, want: true},
+		{name: "bad request", err: &openai.APIError{HTTPStatusCode: 400}},
+		{name: "flood control", err: errors.Join(errors.New("failed to send"), tgbotapi.Error{ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}), want: true},
+		{name: "telegram api error", err: tgbotapi.Error{Message: "Bad Request: can't parse entities"}},
+		{name: "deadline exceeded", err: fmt.Errorf("compose: %w", context.DeadlineExceeded)},
+		{name: "other error", err: errors.New("invalid JSON")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transient(tt.err); got != tt.want {
+				t.Errorf("transient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_publishRetryable(t *testing.T) {
+	ambiguous := &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}
+	refused := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		verified bool
+		err      error
+		want     bool
+	}{
+		{name: "refused connection", err: refused, want: true},
+		{name: "ambiguous error", err: ambiguous},
+		{name: "verified ambiguous error", verified: true, err: ambiguous, want: true},
+		{name: "not transient", verified: true, err: errors.New("bad markdown")},
+		{name: "published to primary target", verified: true, err: &publisher.MultiError{
+			Errors: []publisher.TargetError{{Target: 1, Channel: "discord", Err: refused}},
+		}},
+		{name: "not published to primary target", err: &publisher.MultiError{
+			Errors: []publisher.TargetError{{Target: 0, Channel: "@main", Err: refused}},
+		}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publishRetryable(tt.verified)(tt.err); got != tt.want {
+				t.Errorf("publishRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_retryStage(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+	retryable := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		name        string
+		attempts    uint
+		errs        []error // errors of the consecutive calls, nil after the end
+		wantCalls   int
+		wantRetries int
+		wantErr     error
+	}{
+		{name: "succeeded", attempts: 3, wantCalls: 1},
+		{name: "retries disabled", attempts: 0, errs: []error{errTransient}, wantCalls: 1, wantErr: errTransient},
+		{name: "succeeded after retry", attempts: 3, errs: []error{errTransient, errTransient}, wantCalls: 3, wantRetries: 2},
+		{name: "attempts exhausted", attempts: 2, errs: []error{errTransient, errTransient, errTransient}, wantCalls: 2, wantRetries: 1, wantErr: errTransient},
+		{name: "not retryable", attempts: 3, errs: []error{errTransient, errPermanent}, wantCalls: 2, wantRetries: 1, wantErr: errPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{name: "test", options: &jobOptions{stageAttempts: tt.attempts, stageRetryDelay: time.Millisecond}}
+
+			var calls int
+			var run *JobRun
+			_ = runInstrumented("test", time.Second, func(ctx context.Context, r *JobRun) error {
+				run = r
+				err := job.retryStage(ctx, r, "composed", retryable, func() error {
+					calls++
+					if calls <= len(tt.errs) {
+						return tt.errs[calls-1]
+					}
+					return nil
+				})
+				if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+					t.Errorf("retryStage() error = %v, want %v", err, tt.wantErr)
+				}
+				r.Stage("composed", 1, err)
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("retryStage() calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if got := run.Stages()[0].Retries; got != tt.wantRetries {
+				t.Errorf("retryStage() retries = %d, want %d", got, tt.wantRetries)
+			}
+		})
+	}
+
+	t.Run("context done", func(t *testing.T) {
+		job := &Job{name: "test", options: &jobOptions{stageAttempts: 3, stageRetryDelay: time.Minute}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_ = runInstrumented("test", time.Second, func(_ context.Context, r *JobRun) error {
+			err := job.retryStage(ctx, r, "published", retryable, func() error { return errTransient })
+			if !errors.Is(err, errTransient) {
+				t.Errorf("retryStage() error = %v, want the last error", err)
+			}
+			return nil
+		})
+	})
+}
+
+func Test_retryDelay(t *testing.T) {
+	flood := fmt.Errorf("publish: %w", tgbotapi.Error{ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5}})
+	if got := retryDelay(flood, time.Second); got != 5*time.Second {
+		t.Errorf("retryDelay() = %s, want the flood control wait 5s", got)
+	}
+	if got := retryDelay(errors.New("timeout"), time.Second); got != time.Second {
+		t.Errorf("retryDelay() = %s, want 1s", got)
+	}
+}
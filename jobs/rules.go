@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TagRule is the deterministic tagging rule: news whose original title or description match the Pattern
+// always get the rule tickers, markets and hashtags, even if the AI compose missed them.
+type TagRule struct {
+	Pattern   string   `json:"pattern"`   // case-insensitive regular expression, e.g. `\bfed\b|powell`
+	Tickers   []string `json:"tickers"`   // tickers to add to the composed meta
+	Markets   []string `json:"markets"`   // markets (category) to add to the composed meta, aliases are normalized
+	Hashtags  []string `json:"hashtags"`  // hashtags to add to the composed meta, with or without "#"
+	Important bool     `json:"important"` // if true, matching news skip the AI filter
+}
+
+// Validate checks that the rule pattern is a valid regular expression and the rule adds at least one tag.
+func (r TagRule) Validate() error {
+	if r.Pattern == "" {
+		return errors.New("rule pattern is empty")
+	}
+	if _, err := regexp.Compile("(?i)" + r.Pattern); err != nil {
+		return fmt.Errorf("rule %q: %w", r.Pattern, err)
+	}
+	if len(r.Tickers) == 0 && len(r.Markets) == 0 && len(r.Hashtags) == 0 && !r.Important {
+		return fmt.Errorf("rule %q has no tags", r.Pattern)
+	}
+
+	return nil
+}
+
+// tagRule is the TagRule with the compiled pattern and normalized tags.
+type tagRule struct {
+	re        *regexp.Regexp
+	tickers   []string
+	markets   []string
+	hashtags  []string
+	important bool
+}
+
+// tagRules is the list of compiled TagRule evaluated in order.
+type tagRules []*tagRule
+
+// newTagRules compiles the rules and normalizes their tags. Invalid rules are skipped (see TagRule.Validate).
+// Returns nil if there are no valid rules.
+func newTagRules(rules []TagRule) tagRules {
+	var compiled tagRules
+	for _, r := range rules {
+		if r.Validate() != nil {
+			continue
+		}
+		re := regexp.MustCompile("(?i)" + r.Pattern)
+
+		c := &tagRule{re: re, markets: composer.NormalizeMarkets(r.Markets), important: r.Important}
+		for _, t := range r.Tickers {
+			if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+				c.tickers = append(c.tickers, t)
+			}
+		}
+		for _, h := range r.Hashtags {
+			if h = strings.TrimPrefix(strings.TrimSpace(h), "#"); h != "" {
+				c.hashtags = append(c.hashtags, h)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+
+	return compiled
+}
+
+// important returns true if the text (original title and description of the news) matches
+// at least one important rule.
+func (rules tagRules) important(text string) bool {
+	for _, r := range rules {
+		if r.important && r.re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleTags are the merged tags of the rules matching the news.
+type ruleTags struct {
+	tickers   []string
+	markets   []string
+	hashtags  []string
+	important bool
+}
+
+// taggedNews is the map of the news ID to the tags of the rules matching the news.
+type taggedNews map[string]*ruleTags
+
+// match evaluates the rules against the original news, so the AI filter and compose stages get the results.
+// Returns only the news matching at least one rule.
+func (rules tagRules) match(news journalist.NewsList) taggedNews {
+	tagged := make(taggedNews)
+	for _, n := range news {
+		text := n.Title + "\n" + n.Description
+		for _, r := range rules {
+			if !r.re.MatchString(text) {
+				continue
+			}
+			tags, ok := tagged[n.ID]
+			if !ok {
+				tags = &ruleTags{}
+				tagged[n.ID] = tags
+			}
+			tags.tickers = appendMissing(tags.tickers, r.tickers...)
+			tags.markets = appendMissing(tags.markets, r.markets...)
+			tags.hashtags = appendMissing(tags.hashtags, r.hashtags...)
+			tags.important = tags.important || r.important
+		}
+	}
+
+	return tagged
+}
+
+// important returns true if the news matches at least one important rule.
+func (tagged taggedNews) important(n *journalist.News) bool {
+	tags, ok := tagged[n.ID]
+	return ok && tags.important
+}
+
+// apply adds the tags of the matching rules to the composed news (without duplicates).
+func (tagged taggedNews) apply(composedNews []*composer.ComposedNews) {
+	for _, c := range composedNews {
+		tags, ok := tagged[c.ID]
+		if !ok {
+			continue
+		}
+		c.Tickers = appendMissing(c.Tickers, tags.tickers...)
+		c.Markets = composer.NormalizeMarkets(append(c.Markets, tags.markets...))
+		c.Hashtags = appendMissing(c.Hashtags, tags.hashtags...)
+	}
+}
+
+// appendMissing appends the values missing in the list (case-insensitive).
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, v) }) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/journalist"
+	"reflect"
+	"testing"
+)
+
+func TestTagRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    TagRule
+		wantErr bool
+	}{
+		{name: "valid", rule: TagRule{Pattern: `\bfed\b`, Hashtags: []string{"fed"}}, wantErr: false},
+		{name: "important only", rule: TagRule{Pattern: "recession", Important: true}, wantErr: false},
+		{name: "empty pattern", rule: TagRule{Hashtags: []string{"fed"}}, wantErr: true},
+		{name: "invalid pattern", rule: TagRule{Pattern: "fed(", Hashtags: []string{"fed"}}, wantErr: true},
+		{name: "no tags", rule: TagRule{Pattern: "fed"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_tagRules_match(t *testing.T) {
+	rules := newTagRules([]TagRule{
+		{Pattern: `\bfed\b|powell`, Hashtags: []string{"#fed"}, Markets: []string{"SPY"}},
+		{Pattern: "nvidia", Tickers: []string{"nvda"}, Important: true},
+		{Pattern: "(", Tickers: []string{"INVALID"}},
+	})
+	if len(rules) != 2 {
+		t.Fatalf("newTagRules() should skip invalid rules, got %d rules", len(rules))
+	}
+
+	news := journalist.NewsList{
+		{ID: "1", Title: "Powell says rates will stay high", Description: "Nvidia falls"},
+		{ID: "2", Title: "Oil prices rise", Description: "Supply concerns"},
+	}
+	composed := []*composer.ComposedNews{
+		{ID: "1", Tickers: []string{"NVDA"}, Markets: []string{"SPX"}, Hashtags: []string{"interestrates"}},
+		{ID: "2", Hashtags: []string{}},
+		{ID: "3"},
+	}
+	tagged := rules.match(news)
+	if len(tagged) != 1 {
+		t.Fatalf("match() = %d news, want only the matching one", len(tagged))
+	}
+	tagged.apply(composed)
+
+	want := []*composer.ComposedNews{
+		{ID: "1", Tickers: []string{"NVDA"}, Markets: []string{"SPX"}, Hashtags: []string{"interestrates", "fed"}},
+		{ID: "2", Hashtags: []string{}},
+		{ID: "3"},
+	}
+	if !reflect.DeepEqual(composed, want) {
+		t.Errorf("apply() = %+v, want %+v", composed[0], want[0])
+	}
+
+	if !tagged.important(news[0]) || tagged.important(news[1]) {
+		t.Errorf("important() should match only the news with the important rule pattern")
+	}
+}
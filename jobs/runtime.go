@@ -0,0 +1,237 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"log/slog"
+	"time"
+)
+
+// defaultJobTimeout is the timeout of the instrumented job run.
+const defaultJobTimeout = 25 * time.Second
+
+// JobRun holds the Sentry instrumentation of a single job run.
+type JobRun struct {
+	Tx      *sentry.Span // transaction of the run, use Tx.StartChild for the spans
+	Hub     *sentry.Hub  // hub of the run (cloned from the current hub)
+	name    string       // job name used in the log messages and transaction operation
+	logger  *slog.Logger
+	stages  []StageResult  // results of the run stages in order of execution
+	retries map[string]int // number of retries by the stage name
+	err     error          // error that stopped the run (see Fail)
+}
+
+// StageResult is the typed result of a single stage of the job run (e.g. how many news the filter returned).
+type StageResult struct {
+	Stage   string // name of the stage
+	Count   int    // number of items returned by the stage
+	Err     error  // error that stopped the stage (nil if succeeded), Count holds the items processed before it
+	Retries int    // number of retries of the transient failures during the stage
+}
+
+// SetChannel tags the transaction and all events of the run with the channel the job publishes to.
+func (r *JobRun) SetChannel(channelID string) {
+	r.Tx.SetTag("channel", channelID)
+	r.Hub.Scope().SetTag("channel", channelID)
+}
+
+// Stage records the result of the job stage. Results are collected during the run and emitted once
+// at its end as a structured log and Sentry transaction context (see runInstrumented).
+// They are also set as the hub scope context, so the errors captured during the run include the counts.
+func (r *JobRun) Stage(stage string, count int, err error) {
+	r.stages = append(r.stages, StageResult{Stage: stage, Count: count, Err: err, Retries: r.retries[stage]})
+	r.Hub.Scope().SetContext("stages", r.stagesContext())
+}
+
+// Retry logs the transient failure of the stage attempt before retrying it and counts the retry
+// in the result of the stage (the stage must be recorded with Stage after its retries).
+func (r *JobRun) Retry(stage string, attempt uint, err error) {
+	if r.retries == nil {
+		r.retries = make(map[string]int)
+	}
+	r.retries[stage]++
+
+	r.logger.Warn(fmt.Sprintf("[job-%s] Retrying %s after attempt %d: %v", r.name, stage, attempt, err))
+	r.Hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "retry",
+		Message:  fmt.Sprintf("Retrying %s after attempt %d: %v", stage, attempt, err),
+		Level:    sentry.LevelWarning,
+	}, nil)
+}
+
+// Failed returns true if any of the recorded stages failed.
+func (r *JobRun) Failed() bool {
+	for _, s := range r.stages {
+		if s.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// result returns the error that stopped the run or the error of the first failed stage, nil if the run succeeded.
+func (r *JobRun) result() error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, s := range r.stages {
+		if s.Err != nil {
+			return fmt.Errorf("[job-%s] stage %s failed: %w", r.name, s.Stage, s.Err)
+		}
+	}
+	return nil
+}
+
+// Stages returns the results of the run stages in order of execution.
+func (r *JobRun) Stages() []StageResult {
+	return r.stages
+}
+
+// stagesContext returns the stage results as Sentry context: stage name -> count (or error, with the count
+// of the partially succeeded stage), and "<stage>Retries" -> number of retries of the retried stages.
+func (r *JobRun) stagesContext() sentry.Context {
+	c := make(sentry.Context, len(r.stages))
+	for _, s := range r.stages {
+		if s.Retries > 0 {
+			c[s.Stage+"Retries"] = s.Retries
+		}
+		switch {
+		case s.Err != nil && s.Count > 0:
+			c[s.Stage] = fmt.Sprintf("%d, then %s", s.Count, s.Err)
+		case s.Err != nil:
+			c[s.Stage] = s.Err.Error()
+		default:
+			c[s.Stage] = s.Count
+		}
+	}
+	return c
+}
+
+// emitStages logs the stage results in one structured record and adds them to the transaction (if any).
+func (r *JobRun) emitStages() {
+	if len(r.stages) == 0 {
+		return
+	}
+
+	attrs := make([]any, 0, len(r.stages))
+	for _, s := range r.stages {
+		if s.Retries > 0 {
+			attrs = append(attrs, slog.Int(s.Stage+"Retries", s.Retries))
+		}
+		switch {
+		case s.Err != nil && s.Count > 0:
+			attrs = append(attrs, slog.String(s.Stage, fmt.Sprintf("%d, then error", s.Count)))
+		case s.Err != nil:
+			attrs = append(attrs, slog.String(s.Stage, "error"))
+		default:
+			attrs = append(attrs, slog.Int(s.Stage, s.Count))
+		}
+	}
+	r.logger.Info(fmt.Sprintf("[job-%s] Stages", r.name), attrs...)
+	r.Tx.SetContext("stages", r.stagesContext())
+}
+
+// Fail logs and captures the error that stops the run. Returns the error with the job name prefix.
+func (r *JobRun) Fail(exception, msg string, err error) error {
+	e := fmt.Errorf("[job-%s] %s: %w", r.name, msg, err)
+	r.logger.Error(e.Error())
+	utils.CaptureSentryException(exception, r.Hub, e)
+	if r.err == nil {
+		r.err = e
+	}
+	return e
+}
+
+// Error logs and captures the error that doesn't stop the run, but makes its result incomplete.
+func (r *JobRun) Error(exception, msg string, err error) {
+	e := fmt.Errorf("[job-%s] %s: %w", r.name, msg, err)
+	r.logger.Error(e.Error())
+	utils.CaptureSentryException(exception, r.Hub, e)
+}
+
+// Warn logs and captures the error that doesn't stop the run (e.g. one of the optional sources failed).
+func (r *JobRun) Warn(exception, msg string, err error) {
+	e := fmt.Errorf("[job-%s] %s: %w", r.name, msg, err)
+	r.logger.Warn(e.Error())
+	utils.CaptureSentryException(exception, r.Hub, e)
+}
+
+// Success adds the successful step breadcrumb.
+func (r *JobRun) Success(format string, args ...any) {
+	r.Hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "successful",
+		Message:  fmt.Sprintf(format, args...),
+		Level:    sentry.LevelInfo,
+	}, nil)
+}
+
+// WithInstrumentation returns the job function that runs fn with the default timeout (25s)
+// in its own Sentry transaction (see runInstrumented).
+func WithInstrumentation(name string, fn func(ctx context.Context, r *JobRun)) JobFunc {
+	return WithInstrumentationTimeout(name, defaultJobTimeout, fn)
+}
+
+// WithInstrumentationTimeout is WithInstrumentation with the custom timeout of the run.
+// The job function returns the error of the run stopped by JobRun.Fail or of its first failed stage.
+func WithInstrumentationTimeout(name string, timeout time.Duration, fn func(ctx context.Context, r *JobRun)) JobFunc {
+	return func() error {
+		return runInstrumented(name, timeout, func(ctx context.Context, r *JobRun) error {
+			fn(ctx, r)
+			return r.result()
+		})
+	}
+}
+
+// runInstrumented runs fn in the Sentry transaction "Job.<name>" with "job-<name>" operation.
+// It clones the hub for the run, recovers and reports panics, flushes the hub and marks the transaction with the run status.
+// The error returned by fn is expected to be already captured (see JobRun.Fail) and is returned as is.
+func runInstrumented(name string, timeout time.Duration, fn func(ctx context.Context, r *JobRun) error) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	tx := sentry.StartTransaction(ctx, fmt.Sprintf("Job.%s", name))
+	tx.Op = fmt.Sprintf("job-%s", name)
+
+	// Sentry performance monitoring
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+
+	defer tx.Finish()
+	defer hub.Flush(2 * time.Second)
+	// Note: hub.Recover must get the recovered value, `defer hub.Recover(nil)` doesn't recover anything
+	defer func() {
+		if p := recover(); p != nil {
+			hub.Recover(p)
+			slog.Default().Error(fmt.Sprintf("[job-%s] Recovered from panic", name), "panic", p)
+			tx.Status = sentry.SpanStatusInternalError
+			err = fmt.Errorf("[job-%s] panic: %v", name, p)
+		}
+	}()
+
+	// All events of the run are tagged with the job name
+	tx.SetTag("job", name)
+	hub.Scope().SetTag("job", name)
+
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "job",
+		Message:  fmt.Sprintf("Job %s started", name),
+		Level:    sentry.LevelInfo,
+	}, nil)
+
+	r := &JobRun{Tx: tx, Hub: hub, name: name, logger: slog.Default()}
+	defer r.emitStages()
+
+	err = fn(ctx, r)
+	if err != nil {
+		tx.Status = sentry.SpanStatusInternalError
+	} else {
+		tx.Status = sentry.SpanStatusOK
+	}
+
+	return err
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/getsentry/sentry-go"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_runInstrumented(t *testing.T) {
+	t.Run("run with hub on context", func(t *testing.T) {
+		err := runInstrumented("test", time.Second, func(ctx context.Context, r *JobRun) error {
+			if sentry.GetHubFromContext(ctx) != r.Hub {
+				t.Errorf("runInstrumented() context should hold the run hub")
+			}
+			if r.Tx.Op != "job-test" {
+				t.Errorf("runInstrumented() transaction op = %s, want job-test", r.Tx.Op)
+			}
+			r.SetChannel("@channel")
+			if r.Tx.Tags["job"] != "test" || r.Tx.Tags["channel"] != "@channel" {
+				t.Errorf("runInstrumented() transaction tags = %v, want job and channel", r.Tx.Tags)
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("runInstrumented() context should have the timeout")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("runInstrumented() error = %v", err)
+		}
+	})
+
+	t.Run("failed run", func(t *testing.T) {
+		want := errors.New("boom")
+		var tx *sentry.Span
+		err := runInstrumented("test", time.Second, func(_ context.Context, r *JobRun) error {
+			tx = r.Tx
+			return r.Fail("testJobError", "Error doing something", want)
+		})
+		if !errors.Is(err, want) || !strings.HasPrefix(err.Error(), "[job-test] Error doing something") {
+			t.Errorf("runInstrumented() error = %v, want wrapped %v", err, want)
+		}
+		if tx.Status != sentry.SpanStatusInternalError {
+			t.Errorf("runInstrumented() transaction status = %v, want internal error", tx.Status)
+		}
+	})
+
+	t.Run("errors don't stop the run", func(t *testing.T) {
+		err := runInstrumented("test", time.Second, func(_ context.Context, r *JobRun) error {
+			r.Error("testJobError", "Error doing something", errors.New("boom"))
+			r.Warn("testJobWarning", "Error doing something optional", errors.New("boom"))
+			return nil
+		})
+		if err != nil {
+			t.Errorf("runInstrumented() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("stages are collected", func(t *testing.T) {
+		var run *JobRun
+		_ = runInstrumented("test", time.Second, func(_ context.Context, r *JobRun) error {
+			run = r
+			r.Stage("fetched", 10, nil)
+			if r.Failed() {
+				t.Errorf("Failed() = true before the failed stage")
+			}
+			r.Stage("composed", 0, errors.New("timeout"))
+			return nil
+		})
+		if !run.Failed() {
+			t.Errorf("Failed() = false, want true")
+		}
+
+		want := []StageResult{{Stage: "fetched", Count: 10}, {Stage: "composed", Err: errors.New("timeout")}}
+		if got := run.Stages(); len(got) != 2 || got[0] != want[0] || got[1].Stage != "composed" || got[1].Err == nil {
+			t.Errorf("Stages() = %v, want %v", got, want)
+		}
+
+		c := run.stagesContext()
+		if c["fetched"] != 10 || c["composed"] != "timeout" {
+			t.Errorf("stagesContext() = %v, want counts and errors by stage", c)
+		}
+	})
+
+	t.Run("retries and partial successes are reported", func(t *testing.T) {
+		var run *JobRun
+		_ = runInstrumented("test", time.Second, func(_ context.Context, r *JobRun) error {
+			run = r
+			r.Retry("published", 1, errors.New("connection reset"))
+			r.Stage("published", 2, errors.New("bad markdown"))
+			return nil
+		})
+
+		if got := run.Stages()[0].Retries; got != 1 {
+			t.Errorf("Stages() retries = %d, want 1", got)
+		}
+		c := run.stagesContext()
+		if c["published"] != "2, then bad markdown" || c["publishedRetries"] != 1 {
+			t.Errorf("stagesContext() = %v, want partial count and retries", c)
+		}
+	})
+
+	t.Run("panic is recovered", func(t *testing.T) {
+		err := runInstrumented("test", time.Second, func(_ context.Context, _ *JobRun) error {
+			panic("unexpected")
+		})
+		if err == nil || !strings.Contains(err.Error(), "unexpected") {
+			t.Errorf("runInstrumented() error = %v, want recovered panic", err)
+		}
+	})
+}
+
+func TestWithInstrumentation_result(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(ctx context.Context, r *JobRun)
+		wantErr string
+	}{
+		{name: "succeeded", fn: func(_ context.Context, r *JobRun) { r.Stage("fetched", 1, nil) }},
+		{
+			name:    "failed",
+			fn:      func(_ context.Context, r *JobRun) { _ = r.Fail("testJobError", "Error fetching", errors.New("boom")) },
+			wantErr: "[job-test] Error fetching: boom",
+		},
+		{
+			name:    "stage failed",
+			fn:      func(_ context.Context, r *JobRun) { r.Stage("published", 2, errors.New("bad markdown")) },
+			wantErr: "[job-test] stage published failed: bad markdown",
+		},
+		{
+			name: "incomplete result",
+			fn: func(_ context.Context, r *JobRun) {
+				r.Error("testJobError", "Error doing something", errors.New("boom"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WithInstrumentation("test", tt.fn)()
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("WithInstrumentation() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
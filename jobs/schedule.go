@@ -0,0 +1,271 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/robfig/cron/v3"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxCountedRuns limits the number of the scheduled runs counted between two times (e.g. for the 1s interval jobs).
+const maxCountedRuns = 10000
+
+// jobSchedule returns the scheduled run times of the job.
+type jobSchedule interface {
+	// Next returns the first scheduled run time after t.
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule is the schedule of the interval (duration) jobs.
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// parseJobSchedule parses the job schedule: Go duration (e.g. "90s") or standard cron expression (e.g. "0 4 * * 1-5").
+func parseJobSchedule(spec string) (jobSchedule, error) {
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("interval must be positive, got %s", spec)
+		}
+		return intervalSchedule(d), nil
+	}
+
+	s, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %q is neither duration nor cron expression: %w", spec, err)
+	}
+
+	return s, nil
+}
+
+// countRuns returns the number of the scheduled runs in [from, to).
+func countRuns(s jobSchedule, from, to time.Time) int {
+	n := 0
+	for t := from; t.Before(to) && n < maxCountedRuns; t = s.Next(t) {
+		n++
+	}
+	return n
+}
+
+// RunResult is the result of the job run.
+type RunResult string
+
+const (
+	RunPending RunResult = "pending" // the job didn't run yet
+	RunOK      RunResult = "ok"
+	RunFailed  RunResult = "failed"
+	RunPaused  RunResult = "paused" // the run was skipped, because the job is paused (see PauseGuard)
+)
+
+// JobStatus is the status of the scheduled job: its next expected run and the result of the last one.
+type JobStatus struct {
+	Name     string        `json:"name"`
+	Schedule string        `json:"schedule"`        // Go duration or cron expression
+	NextRun  time.Time     `json:"next_run"`        // next expected run time
+	Overdue  bool          `json:"overdue"`         // the expected run didn't start within the tolerance
+	Running  bool          `json:"running"`         // the job is running now
+	LastRun  time.Time     `json:"last_run"`        // start time of the last run (zero if it didn't run yet)
+	Duration time.Duration `json:"duration_ns"`     // duration of the last run
+	Result   RunResult     `json:"result"`          // result of the last run
+	Error    string        `json:"error,omitempty"` // error of the last failed run
+}
+
+// watchedJob is the job watched by the ScheduleMonitor.
+type watchedJob struct {
+	name     string
+	spec     string // schedule of the job
+	schedule jobSchedule
+	fn       JobFunc   // original job function
+	catchUp  bool      // if true, the missed run is executed once by the monitor
+	expected time.Time // next expected run time
+	reported bool      // if true, the missed expected run is already reported by the monitor
+	caughtUp bool      // if true, the missed run was executed by the monitor and the late scheduled run is skipped
+	running  bool      // if true, the job is running now
+	lastRun  time.Time // start time of the last run
+	duration time.Duration
+	result   RunResult
+	err      error // error of the last run
+}
+
+// ScheduleMonitor compares the scheduled and actual run times of the jobs. It reports the runs started late
+// (drift above the tolerance) and the missed runs (e.g. the process slept, was paused or stuck in GC), and optionally
+// executes the missed run of the jobs that must not be skipped (e.g. the daily calendar) once.
+type ScheduleMonitor struct {
+	tolerance time.Duration // allowed delay of the run
+	jobs      []*watchedJob
+	mu        sync.Mutex
+	hub       *sentry.Hub
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// NewScheduleMonitor creates a new ScheduleMonitor with the allowed delay of the runs.
+func NewScheduleMonitor(tolerance time.Duration) *ScheduleMonitor {
+	return &ScheduleMonitor{
+		tolerance: tolerance,
+		hub:       sentry.CurrentHub().Clone(),
+		logger:    slog.Default(),
+		now:       time.Now,
+	}
+}
+
+// Watch returns the job function that records the actual run times of the job with the given schedule
+// (Go duration or cron expression, the same as the scheduler one). If catchUp is true, the missed run
+// is executed by the monitor (see ScheduleMonitor.Run) and the late scheduled run of the same slot is skipped.
+func (m *ScheduleMonitor) Watch(name, spec string, catchUp bool, fn JobFunc) (JobFunc, error) {
+	s, err := parseJobSchedule(spec)
+	if err != nil {
+		return nil, fmt.Errorf("job %q: %w", name, err)
+	}
+
+	j := &watchedJob{name: name, spec: spec, schedule: s, fn: fn, catchUp: catchUp, expected: s.Next(m.now()), result: RunPending}
+	m.mu.Lock()
+	m.jobs = append(m.jobs, j)
+	m.mu.Unlock()
+
+	return func() error {
+		if !m.started(j) {
+			return nil
+		}
+		return m.run(j)
+	}, nil
+}
+
+// run executes the job function and records the start time, duration and result of the run.
+// Runs skipped by the PauseGuard are recorded as paused and don't return the error.
+func (m *ScheduleMonitor) run(j *watchedJob) error {
+	start := m.now()
+	m.mu.Lock()
+	j.running = true
+	m.mu.Unlock()
+
+	err := j.fn()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j.running = false
+	j.lastRun, j.duration, j.err = start, m.now().Sub(start), err
+	switch {
+	case errors.Is(err, ErrPaused):
+		j.result, j.err = RunPaused, nil
+		return nil
+	case err != nil:
+		j.result = RunFailed
+	default:
+		j.result = RunOK
+	}
+	return err
+}
+
+// Status returns the status of the watched jobs sorted by the next run time.
+func (m *ScheduleMonitor) Status() []JobStatus {
+	now := m.now()
+
+	m.mu.Lock()
+	statuses := make([]JobStatus, len(m.jobs))
+	for i, j := range m.jobs {
+		statuses[i] = JobStatus{
+			Name:     j.name,
+			Schedule: j.spec,
+			NextRun:  j.expected,
+			Overdue:  !j.running && now.Sub(j.expected) > m.tolerance,
+			Running:  j.running,
+			LastRun:  j.lastRun,
+			Duration: j.duration,
+			Result:   j.result,
+		}
+		if j.err != nil {
+			statuses[i].Error = j.err.Error()
+		}
+	}
+	m.mu.Unlock()
+
+	slices.SortStableFunc(statuses, func(a, b JobStatus) int {
+		return a.NextRun.Compare(b.NextRun)
+	})
+	return statuses
+}
+
+// Health returns false if any of the watched jobs is overdue (e.g. the scheduler is stuck) with the jobs status.
+func (m *ScheduleMonitor) Health() (ok bool, details any) {
+	statuses := m.Status()
+	return !slices.ContainsFunc(statuses, func(s JobStatus) bool { return s.Overdue }), statuses
+}
+
+// started records the run of the job and reports its drift. Returns false if the run should be skipped,
+// because the monitor already executed the missed run.
+func (m *ScheduleMonitor) started(j *watchedJob) bool {
+	now := m.now()
+
+	m.mu.Lock()
+	expected, caughtUp := j.expected, j.caughtUp
+	if caughtUp && now.Before(expected.Add(-m.tolerance)) {
+		j.caughtUp = false
+		m.mu.Unlock()
+		m.logger.Info(fmt.Sprintf("[schedule-monitor] Skipping late run of %s, the missed run was already executed", j.name))
+		return false
+	}
+	j.expected = j.schedule.Next(now)
+	j.reported = false
+	j.caughtUp = false
+	m.mu.Unlock()
+
+	if drift := now.Sub(expected); drift > m.tolerance {
+		// The current run covers the expected one
+		missed := countRuns(j.schedule, expected, now) - 1
+		e := fmt.Errorf("[schedule-monitor] %s started %s late (scheduled at %s), missed %d runs",
+			j.name, drift.Round(time.Second), expected.Format(time.RFC3339), missed)
+		m.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobScheduleDrift", m.hub, errlvl.Wrap(e, errlvl.WARN))
+	}
+
+	return true
+}
+
+// Run return job function that reports the jobs that didn't start within the tolerance after the expected run
+// and executes the missed runs of the catch-up jobs. It should be scheduled more often than the tolerance.
+func (m *ScheduleMonitor) Run() JobFunc {
+	return WithInstrumentation("schedule-monitor", func(_ context.Context, r *JobRun) {
+		now := m.now()
+
+		var missedJobs int
+		var catchUps []*watchedJob
+		m.mu.Lock()
+		for _, j := range m.jobs {
+			if j.reported || now.Sub(j.expected) <= m.tolerance {
+				continue
+			}
+
+			missed := countRuns(j.schedule, j.expected, now)
+			r.Warn("jobMissedRun", "Missed scheduled run", errlvl.Wrap(fmt.Errorf(
+				"%s didn't start at %s, missed %d runs", j.name, j.expected.Format(time.RFC3339), missed,
+			), errlvl.ERROR))
+			j.reported = true
+			missedJobs++
+
+			if j.catchUp {
+				j.expected = j.schedule.Next(now)
+				j.reported = false
+				j.caughtUp = true
+				catchUps = append(catchUps, j)
+			}
+		}
+		m.mu.Unlock()
+		r.Stage("missed", missedJobs, nil)
+		r.Stage("caughtUp", len(catchUps), nil)
+
+		for _, j := range catchUps {
+			r.Success("Catching up the missed run of %s", j.name)
+			_ = m.run(j)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountRuns(t *testing.T) {
+	daily, err := parseJobSchedule("0 4 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval, err := parseJobSchedule("90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule jobSchedule
+		to       time.Time
+		want     int
+	}{
+		{name: "empty range", schedule: daily, to: from, want: 0},
+		{name: "daily same day", schedule: daily, to: from.Add(time.Hour), want: 1},
+		{name: "daily three days", schedule: daily, to: from.Add(49 * time.Hour), want: 3},
+		{name: "interval", schedule: interval, to: from.Add(5 * time.Minute), want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countRuns(tt.schedule, from, tt.to); got != tt.want {
+				t.Errorf("countRuns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseJobSchedule("every day"); err == nil {
+		t.Error("parseJobSchedule() error = nil, want error")
+	}
+}
+
+func TestScheduleMonitor(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		catchUp  bool
+		steps    []time.Duration // since start: positive - scheduled run, negative - monitor check at -step
+		wantRuns int
+		wantNext time.Time // expected next run after all steps
+	}{
+		{
+			name:     "on time",
+			steps:    []time.Duration{time.Hour, -(time.Hour + 10*time.Minute), 25 * time.Hour},
+			wantRuns: 2,
+			wantNext: start.Add(49 * time.Hour),
+		},
+		{
+			name:     "late run without catch up",
+			steps:    []time.Duration{-(time.Hour + 10*time.Minute), time.Hour + 30*time.Minute},
+			wantRuns: 1,
+			wantNext: start.Add(25 * time.Hour),
+		},
+		{
+			name:     "missed run caught up and late run skipped",
+			catchUp:  true,
+			steps:    []time.Duration{-(time.Hour + 10*time.Minute), time.Hour + 30*time.Minute, 25 * time.Hour},
+			wantRuns: 2,
+			wantNext: start.Add(49 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			m := NewScheduleMonitor(5 * time.Minute)
+			m.now = func() time.Time { return now }
+
+			runs := 0
+			fn, err := m.Watch("Calendar", "0 4 * * *", tt.catchUp, func() error { runs++; return nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, step := range tt.steps {
+				if step < 0 {
+					now = start.Add(-step)
+					m.Run()()
+					continue
+				}
+				now = start.Add(step)
+				fn()
+			}
+
+			if runs != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", runs, tt.wantRuns)
+			}
+			if got := m.jobs[0].expected; !got.Equal(tt.wantNext) {
+				t.Errorf("expected = %v, want %v", got, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestScheduleMonitor_Status(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	m := NewScheduleMonitor(5 * time.Minute)
+	m.now = func() time.Time { return now }
+
+	calendar, err := m.Watch("Calendar", "0 4 * * *", false, func() error {
+		now = now.Add(2 * time.Second)
+		return errors.New("fetch failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox, err := m.Watch("Outbox", "1m", false, func() error { return ErrPaused })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Watch("Recap", "0 21 * * *", false, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Hour)
+	if err := calendar(); err == nil {
+		t.Errorf("job function error = nil, want the run error")
+	}
+	if err := outbox(); err != nil {
+		t.Errorf("job function error = %v, want nil for the paused run", err)
+	}
+
+	runAt := time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)
+	want := []JobStatus{
+		{Name: "Outbox", Schedule: "1m", NextRun: runAt.Add(2*time.Second + time.Minute), LastRun: runAt.Add(2 * time.Second), Result: RunPaused},
+		{Name: "Recap", Schedule: "0 21 * * *", NextRun: time.Date(2024, 1, 2, 21, 0, 0, 0, time.UTC), Result: RunPending},
+		{
+			Name: "Calendar", Schedule: "0 4 * * *", NextRun: time.Date(2024, 1, 3, 4, 0, 0, 0, time.UTC),
+			LastRun: runAt, Duration: 2 * time.Second, Result: RunFailed, Error: "fetch failed",
+		},
+	}
+	if got := m.Status(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Status() = %+v, want %+v", got, want)
+	}
+	if ok, _ := m.Health(); !ok {
+		t.Errorf("Health() = false, want true")
+	}
+
+	now = time.Date(2024, 1, 3, 4, 10, 0, 0, time.UTC)
+	if ok, _ := m.Health(); ok {
+		t.Errorf("Health() = true, want false for the overdue jobs")
+	}
+}
+
+func TestScheduleMonitor_failedRun(t *testing.T) {
+	from, _ := nextWeek(time.Now().UTC())
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `[{"ID":1,"EventType":1,"EventName":"Core CPI m/m","Importance":"high","CurrencyCode":"USD","Country":840,"FullDate":%q}]`,
+			from.Add(36*time.Hour).Format("2006-01-02T15:04:05"))
+	}))
+	defer api.Close()
+
+	calendar := &ecal.EconomicCalendar{}
+	calendar.SetURLs(api.URL, api.URL)
+	job := NewWeekAheadJob(calendar, nil, &mockPublisher{failFrom: 1})
+
+	m := NewScheduleMonitor(5 * time.Minute)
+	run, err := m.Watch("WeekAhead", "0 18 * * 0", false, job.Run())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err == nil {
+		t.Errorf("job function error = nil, want the publish error")
+	}
+
+	status := m.Status()
+	if len(status) != 1 || status[0].Result != RunFailed || !strings.Contains(status[0].Error, "bad request") {
+		t.Errorf("Status() = %+v, want the failed run", status)
+	}
+}
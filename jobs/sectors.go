@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/publisher"
+	"strings"
+	"unicode"
+)
+
+// sectorRoutes is a map of the lowercase sector name -> publisher of the sector channel.
+type sectorRoutes map[string]publisher.Publisher
+
+func newSectorRoutes(routes map[string]publisher.Publisher) sectorRoutes {
+	r := make(sectorRoutes, len(routes))
+	for s, p := range routes {
+		if p != nil {
+			r[strings.ToLower(strings.TrimSpace(s))] = p
+		}
+	}
+	return r
+}
+
+// publishers returns distinct publishers routed for the given sectors.
+func (r sectorRoutes) publishers(sectors []string) []publisher.Publisher {
+	var result []publisher.Publisher
+	seen := make(map[publisher.Publisher]bool, len(sectors))
+	for _, s := range sectors {
+		p, ok := r[strings.ToLower(s)]
+		if !ok || seen[p] {
+			continue
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	return result
+}
+
+// sectorHashtag converts the sector name to the hashtag, e.g. "Consumer Discretionary" -> "#ConsumerDiscretionary".
+func sectorHashtag(sector string) string {
+	var sb strings.Builder
+	sb.WriteString("#")
+	for _, w := range strings.FieldsFunc(sector, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		r := []rune(w)
+		sb.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	return sb.String()
+}
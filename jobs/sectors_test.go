@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/publisher"
+	"reflect"
+	"testing"
+)
+
+func Test_sectorHashtag(t *testing.T) {
+	tests := []struct {
+		sector string
+		want   string
+	}{
+		{sector: "Technology", want: "#Technology"},
+		{sector: "Consumer Discretionary", want: "#ConsumerDiscretionary"},
+		{sector: "real estate", want: "#RealEstate"},
+		{sector: "Health-Care & Pharma", want: "#HealthCarePharma"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sector, func(t *testing.T) {
+			if got := sectorHashtag(tt.sector); got != tt.want {
+				t.Errorf("sectorHashtag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sectorRoutes_publishers(t *testing.T) {
+	tech := &publisher.TelegramPublisher{ChannelID: "@tech"}
+	health := &publisher.TelegramPublisher{ChannelID: "@health"}
+	routes := newSectorRoutes(map[string]publisher.Publisher{
+		"Technology":  tech,
+		"Health Care": health,
+		"Energy":      nil,
+	})
+
+	tests := []struct {
+		name    string
+		sectors []string
+		want    []publisher.Publisher
+	}{
+		{name: "case-insensitive", sectors: []string{"technology", "Health Care"}, want: []publisher.Publisher{tech, health}},
+		{name: "not routed", sectors: []string{"Energy", "Finance"}, want: nil},
+		{name: "empty", sectors: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := routes.publishers(tt.sectors); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("publishers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
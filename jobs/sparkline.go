@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"strings"
+)
+
+const (
+	seriesLength    = 12 // number of the last indicator readings rendered in the event update
+	minSeriesLength = 3  // sparkline makes no sense for the shorter series
+	sparklineBlocks = "▁▂▃▄▅▆▇█"
+)
+
+// formatSeries returns the sparkline of the indicator readings (sorted by date in ascending order),
+// e.g. "▁▃▅█ (4 readings)". Returns an empty string if the series is too short.
+func formatSeries(series []*archivist.Event) string {
+	if len(series) < minSeriesLength {
+		return ""
+	}
+
+	values := make([]float64, 0, len(series))
+	for _, e := range series {
+		values = append(values, seriesValue(e.Actual))
+	}
+
+	return fmt.Sprintf("%s (%d readings)", sparkline(values), len(values))
+}
+
+// seriesValue converts the indicator value to the number keeping its sign (e.g. "-0.3%" -> -0.3).
+func seriesValue(value string) float64 {
+	v := utils.StrValueToFloat(value)
+	if strings.HasPrefix(strings.TrimSpace(value), "-") {
+		return -v
+	}
+	return v
+}
+
+// sparkline renders values as a line of block characters scaled between the min and max value.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	blocks := []rune(sparklineBlocks)
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		i := len(blocks) / 2 // flat series is rendered in the middle
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(blocks)-1))
+		}
+		sb.WriteRune(blocks[i])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"testing"
+)
+
+func Test_sparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   string
+	}{
+		{name: "empty", values: nil, want: ""},
+		{name: "growing", values: []float64{1, 2, 3, 4, 5, 6, 7, 8}, want: "▁▂▃▄▅▆▇█"},
+		{name: "negative values", values: []float64{-0.3, 0, 0.3}, want: "▁▄█"},
+		{name: "flat", values: []float64{2, 2, 2}, want: "▅▅▅"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sparkline(tt.values); got != tt.want {
+				t.Errorf("sparkline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatSeries(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []*archivist.Event
+		want   string
+	}{
+		{
+			name:   "too short series",
+			series: []*archivist.Event{{Actual: "0.2%"}, {Actual: "0.3%"}},
+			want:   "",
+		},
+		{
+			name:   "series with negative values",
+			series: []*archivist.Event{{Actual: "-0.3%"}, {Actual: "0.0%"}, {Actual: "0.3%"}},
+			want:   "▁▄█ (3 readings)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSeries(tt.series); got != tt.want {
+				t.Errorf("formatSeries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
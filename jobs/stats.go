@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samber/lo"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StatsJob sends the daily pipeline statistics to the admin chat:
+// how many news were fetched, filtered and published, why the news were filtered out
+// and which markets the published news were about.
+type StatsJob struct {
+	publisher       publisher.Publisher     // publisher that will send stats to the admin chat
+	archivist       *archivist.Archivist    // archivist that will be used to get news stats
+	composerMetrics *composer.Metrics       // composer answers quality counters (optional)
+	queueMetrics    *publisher.QueueMetrics // channel publish queue counters (optional)
+	logger          *slog.Logger            // special logger for the job
+	period          time.Duration           // stats period
+}
+
+// NewStatsJob creates a new StatsJob instance for the last 24 hours.
+func NewStatsJob(publisher publisher.Publisher, archivist *archivist.Archivist) *StatsJob {
+	return &StatsJob{
+		publisher: publisher,
+		archivist: archivist,
+		logger:    slog.Default(),
+		period:    24 * time.Hour,
+	}
+}
+
+// WithComposerMetrics adds the composer answers quality counters (reset on each run) to the stats.
+func (j *StatsJob) WithComposerMetrics(metrics *composer.Metrics) *StatsJob {
+	j.composerMetrics = metrics
+	return j
+}
+
+// WithPublishQueueMetrics adds the channel publish queue counters (reset on each run) to the stats.
+func (j *StatsJob) WithPublishQueueMetrics(metrics *publisher.QueueMetrics) *StatsJob {
+	j.queueMetrics = metrics
+	return j
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *StatsJob) Run() JobFunc {
+	return WithInstrumentation("stats", func(ctx context.Context, r *JobRun) {
+		tx, hub := r.Tx, r.Hub
+		r.SetChannel(j.publisher.Channel())
+
+		since := time.Now().UTC().Add(-j.period)
+
+		span := tx.StartChild("News.CountSince")
+		stats, err := j.archivist.Entities.News.CountSince(ctx, since)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("statsJobCountSinceError", "Error counting news", err)
+			return
+		}
+
+		span = tx.StartChild("News.CountFilteredReasons")
+		reasons, err := j.archivist.Entities.News.CountFilteredReasons(ctx, since)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("statsJobCountFilteredReasonsError", "Error counting filtered reasons", err)
+			return
+		}
+
+		span = tx.StartChild("News.CountMarkets")
+		markets, err := j.archivist.Entities.News.CountMarkets(ctx, since)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("statsJobCountMarketsError", "Error counting markets", err)
+			return
+		}
+
+		span = tx.StartChild("News.CountSectors")
+		sectors, err := j.archivist.Entities.News.CountSectors(ctx, since)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("statsJobCountSectorsError", "Error counting sectors", err)
+			return
+		}
+
+		span = tx.StartChild("Summaries.CountSince")
+		summaries, err := j.archivist.Entities.Summaries.CountSince(ctx, since)
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("statsJobCountSummariesError", "Error counting summaries", err)
+			return
+		}
+
+		span = tx.StartChild("TelegramPublisher.Publish")
+		text := formatStats(stats, reasons, markets, sectors, j.period)
+		text += formatSummaryStats(summaries)
+		text += formatComposerMetrics(j.composerMetrics.Reset())
+		text += formatQueueMetrics(j.queueMetrics.Reset())
+		_, err = j.publisher.Publish(publisher.Markdown(text))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("statsJobPublishError", "Error publishing stats", err)
+			return
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Stats published for %d news", stats.Total),
+			Level:    sentry.LevelInfo,
+		}, nil)
+	})
+}
+
+// formatStats formats news stats, filtered reasons, published markets and sectors for the admin chat.
+func formatStats(stats *archivist.NewsStats, reasons, markets, sectors map[string]int64, period time.Duration) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 #stats for the last %s\n", period))
+	sb.WriteString(fmt.Sprintf("Total: %d\nPublished: %d\nFiltered: %d (%.0f%%)\n",
+		stats.Total, stats.Published, stats.Filtered, stats.FilterRate()*100))
+	if stats.WouldFilter > 0 {
+		sb.WriteString(fmt.Sprintf("Shadow filter: %d (%.0f%%)\n", stats.WouldFilter, stats.WouldFilterRate()*100))
+	}
+
+	writeCounters(&sb, "Filtered by reason", reasons)
+	writeCounters(&sb, "Published by market", markets)
+	writeCounters(&sb, "Published by sector", sectors)
+
+	return strings.TrimSpace(sb.String())
+}
+
+// formatSummaryStats formats published summaries stats (empty if there are none).
+// Headlines included in several summaries are counted once.
+func formatSummaryStats(stats *archivist.SummaryStats) string {
+	if stats == nil || stats.Summaries == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n\nSummaries: %d\nIncluded headlines: %d", stats.Summaries, stats.Included)
+}
+
+// formatComposerMetrics formats non-zero composer answers quality counters (empty if there are none).
+func formatComposerMetrics(metrics map[string]int64) string {
+	counters := lo.PickBy(metrics, func(_ string, v int64) bool { return v > 0 })
+	if len(counters) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	writeCounters(&sb, "Composer answers", counters)
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatQueueMetrics formats non-zero publish queue counters (empty if there are none).
+func formatQueueMetrics(metrics map[string]int64) string {
+	counters := lo.PickBy(metrics, func(_ string, v int64) bool { return v > 0 })
+	if len(counters) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	writeCounters(&sb, "Publish queue", counters)
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeCounters writes the titled list of counters sorted by count (descending). Empty counters are skipped.
+func writeCounters(sb *strings.Builder, title string, counters map[string]int64) {
+	if len(counters) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, k int) bool {
+		if counters[keys[i]] == counters[keys[k]] {
+			return keys[i] < keys[k]
+		}
+		return counters[keys[i]] > counters[keys[k]]
+	})
+
+	sb.WriteString(fmt.Sprintf("\n%s:\n", title))
+	for _, k := range keys {
+		name := k
+		if name == "" {
+			name = "unknown"
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %d\n", name, counters[k]))
+	}
+}
@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"testing"
+	"time"
+)
+
+func Test_formatStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		stats   *archivist.NewsStats
+		reasons map[string]int64
+		markets map[string]int64
+		sectors map[string]int64
+		want    string
+	}{
+		{
+			name:    "no filtered news",
+			stats:   &archivist.NewsStats{Total: 10, Published: 10},
+			reasons: map[string]int64{},
+			want:    "📊 #stats for the last 24h0m0s\nTotal: 10\nPublished: 10\nFiltered: 0 (0%)",
+		},
+		{
+			name:  "sorted by count",
+			stats: &archivist.NewsStats{Total: 10, Published: 4, Filtered: 6},
+			reasons: map[string]int64{
+				"duplicate": 1,
+				"clickbait": 3,
+				"":          1,
+				"low-value": 1,
+			},
+			want: "📊 #stats for the last 24h0m0s\nTotal: 10\nPublished: 4\nFiltered: 6 (60%)\n\n" +
+				"Filtered by reason:\n- clickbait: 3\n- unknown: 1\n- duplicate: 1\n- low-value: 1",
+		},
+		{
+			name:    "with markets",
+			stats:   &archivist.NewsStats{Total: 4, Published: 4},
+			markets: map[string]int64{"NDX": 1, "SPX": 3},
+			want: "📊 #stats for the last 24h0m0s\nTotal: 4\nPublished: 4\nFiltered: 0 (0%)\n\n" +
+				"Published by market:\n- SPX: 3\n- NDX: 1",
+		},
+		{
+			name:  "with shadow filter",
+			stats: &archivist.NewsStats{Total: 10, Published: 8, Filtered: 2, WouldFilter: 5},
+			want:  "📊 #stats for the last 24h0m0s\nTotal: 10\nPublished: 8\nFiltered: 2 (20%)\nShadow filter: 5 (50%)",
+		},
+		{
+			name:    "with sectors",
+			stats:   &archivist.NewsStats{Total: 4, Published: 4},
+			sectors: map[string]int64{"Technology": 3, "Health Care": 1},
+			want: "📊 #stats for the last 24h0m0s\nTotal: 4\nPublished: 4\nFiltered: 0 (0%)\n\n" +
+				"Published by sector:\n- Technology: 3\n- Health Care: 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatStats(tt.stats, tt.reasons, tt.markets, tt.sectors, 24*time.Hour); got != tt.want {
+				t.Errorf("formatStats() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatComposerMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics map[string]int64
+		want    string
+	}{
+		{name: "nil metrics", metrics: nil, want: ""},
+		{name: "zero counters", metrics: map[string]int64{"unknown IDs": 0, "retries": 0}, want: ""},
+		{
+			name:    "non-zero counters",
+			metrics: map[string]int64{"unknown IDs": 3, "retries": 1, "missing IDs": 0},
+			want:    "\n\nComposer answers:\n- unknown IDs: 3\n- retries: 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatComposerMetrics(tt.metrics); got != tt.want {
+				t.Errorf("formatComposerMetrics() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatQueueMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics map[string]int64
+		want    string
+	}{
+		{name: "nil metrics", metrics: nil, want: ""},
+		{name: "zero counters", metrics: map[string]int64{"sent": 0, "throttled": 0}, want: ""},
+		{
+			name:    "non-zero counters",
+			metrics: map[string]int64{"sent": 42, "throttled": 2, "retries": 2, "failed": 0, "max depth": 5},
+			want:    "\n\nPublish queue:\n- sent: 42\n- max depth: 5\n- retries: 2\n- throttled: 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatQueueMetrics(tt.metrics); got != tt.want {
+				t.Errorf("formatQueueMetrics() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatSummaryStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats *archivist.SummaryStats
+		want  string
+	}{
+		{name: "nil", stats: nil, want: ""},
+		{name: "no summaries", stats: &archivist.SummaryStats{}, want: ""},
+		{name: "summaries", stats: &archivist.SummaryStats{Summaries: 2, Included: 15}, want: "\n\nSummaries: 2\nIncluded headlines: 15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSummaryStats(tt.stats); got != tt.want {
+				t.Errorf("formatSummaryStats() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
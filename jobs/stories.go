@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/publisher"
+	"slices"
+	"time"
+)
+
+// minStorySimilarity is the minimal similarity of the news title to the latest title of the story
+// with the common ticker or hashtag to continue the story, e.g. "Apple faces EU fine" and "Apple to appeal EU fine".
+const minStorySimilarity = 0.25
+
+// assignStories links the news to the developing stories of the channel (see Job.TrackStories): the news continues
+// the active story with the common ticker or hashtag and the similar title, otherwise it starts a new story
+// (as its first part). The parts of the continued stories are added right before the publication (see addStoryPart).
+// News without tickers and hashtags are not tracked. Stories are not critical, so errors are only reported
+// and the rest of the news are published without the story.
+func (job *Job) assignStories(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, news []*archivist.News) {
+	if job.options.storyGap <= 0 || job.archivist == nil || len(news) == 0 {
+		return
+	}
+
+	span := tx.StartChild("assignStories.Stories.FindActive")
+	stories, err := job.archivist.Entities.Stories.FindActive(ctx, job.publisher.Channel(), time.Now().Add(-job.options.storyGap))
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][assignStories.Stories.FindActive]: %w", job.name, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobFindStoriesError", hub, e)
+		return
+	}
+
+	for _, n := range news {
+		topics := newsTopics(n)
+		if len(topics) == 0 {
+			continue
+		}
+
+		story := matchStory(stories, n.OriginalTitle, topics)
+		if story == nil {
+			story = &archivist.Story{ChannelID: job.publisher.Channel(), Title: n.OriginalTitle, Topics: topics, Parts: 1}
+			span := tx.StartChild("assignStories.Stories.Create")
+			err = job.archivist.Entities.Stories.Create(ctx, story)
+			span.Finish()
+			if err == nil {
+				stories = append(stories, story)
+				n.StoryPart = 1
+			}
+		} else {
+			story.Title = n.OriginalTitle
+			story.Topics = mergeTopics(story.Topics, topics)
+			span := tx.StartChild("assignStories.Stories.Update")
+			err = job.archivist.Entities.Stories.Update(ctx, story)
+			span.Finish()
+		}
+		if err != nil {
+			e := fmt.Errorf("[%s][assignStories]: %w", job.name, err)
+			job.logger.Warn(e.Error())
+			utils.CaptureSentryException("jobSaveStoryError", hub, e)
+			return
+		}
+
+		n.StoryID = story.ID
+	}
+}
+
+// addStoryPart numbers the news as the next part of its story before the publication (the first part is numbered
+// by assignStories). The news is published without the part if it fails.
+func (job *Job) addStoryPart(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, n *archivist.News) {
+	if n.StoryID == uuid.Nil || n.StoryPart > 0 || job.archivist == nil {
+		return
+	}
+
+	span := tx.StartChild("publish.Stories.AddPart")
+	part, err := job.archivist.Entities.Stories.AddPart(ctx, n.StoryID)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][addStoryPart]: %w", job.name, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobSaveStoryError", hub, e)
+		n.StoryID = uuid.Nil
+		return
+	}
+
+	n.StoryPart = part
+}
+
+// removeStoryPart gives back the part of the news that failed to publish, so the story has no gaps
+// (unless the later part is already published).
+func (job *Job) removeStoryPart(ctx context.Context, tx *sentry.Span, hub *sentry.Hub, n *archivist.News) {
+	if n.StoryID == uuid.Nil || n.StoryPart == 0 || job.archivist == nil {
+		return
+	}
+
+	span := tx.StartChild("publish.Stories.RemovePart")
+	err := job.archivist.Entities.Stories.RemovePart(ctx, n.StoryID, n.StoryPart)
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("[%s][removeStoryPart]: %w", job.name, err)
+		job.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobSaveStoryError", hub, e)
+	}
+
+	n.StoryID, n.StoryPart = uuid.Nil, 0
+}
+
+// matchStory returns the story with the common topic and the most similar title (not less than minStorySimilarity).
+// Returns nil if there is no such story.
+func matchStory(stories []*archivist.Story, title string, topics []string) *archivist.Story {
+	var best *archivist.Story
+	bestScore := 0.0
+	for _, s := range stories {
+		if !slices.ContainsFunc(topics, func(t string) bool { return slices.Contains(s.Topics, t) }) {
+			continue
+		}
+		if score := titleSimilarity(s.Title, title); score >= minStorySimilarity && score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+
+	return best
+}
+
+// newsTopics returns the tickers and hashtags of the composed news meta.
+func newsTopics(n *archivist.News) []string {
+	if n.MetaData == nil {
+		return nil
+	}
+
+	var meta composer.ComposedMeta
+	if err := json.Unmarshal(n.MetaData, &meta); err != nil {
+		return nil
+	}
+
+	return mergeTopics(meta.Tickers, meta.Hashtags)
+}
+
+// mergeTopics returns the sorted unique topics of both lists.
+func mergeTopics(a, b []string) []string {
+	topics := append(slices.Clone(a), b...)
+	slices.Sort(topics)
+	return slices.Compact(topics)
+}
+
+// formatStoryPart returns the line with the part number of the developing story if the news continues one.
+// Returns nil otherwise.
+func formatStoryPart(n *archivist.News) []publisher.Segment {
+	if n.StoryPart < 2 {
+		return nil
+	}
+
+	return []publisher.Segment{{Text: fmt.Sprintf("🧵 Developing: part %d", n.StoryPart)}}
+}
+
+// storyHeadlines returns the headlines of the news for the summary. Only the latest news of each developing story
+// is kept and its headline references the story, so the summary follows the story arcs instead of their parts.
+func storyHeadlines(news []*archivist.News) []*composer.Headline {
+	latest := make(map[uuid.UUID]int)
+	for _, n := range news {
+		if n.StoryID != uuid.Nil {
+			latest[n.StoryID] = max(latest[n.StoryID], n.StoryPart)
+		}
+	}
+
+	headlines := make([]*composer.Headline, 0, len(news))
+	for _, n := range news {
+		if n.StoryID != uuid.Nil && n.StoryPart < latest[n.StoryID] {
+			continue
+		}
+
+		h := n.ToHeadline()
+		if n.StoryPart > 1 {
+			h.Text = fmt.Sprintf("Developing story (part %d): %s", n.StoryPart, h.Text)
+		}
+		headlines = append(headlines, h)
+	}
+
+	return headlines
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"github.com/google/uuid"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"reflect"
+	"testing"
+)
+
+func Test_matchStory(t *testing.T) {
+	fine := &archivist.Story{Title: "Apple faces EU fine over App Store rules", Topics: []string{"AAPL"}}
+	fed := &archivist.Story{Title: "Fed holds rates steady", Topics: []string{"fed", "interestrates"}}
+	stories := []*archivist.Story{fine, fed}
+
+	tests := []struct {
+		name   string
+		title  string
+		topics []string
+		want   *archivist.Story
+	}{
+		{name: "continues the story", title: "Apple to appeal EU fine", topics: []string{"AAPL"}, want: fine},
+		{name: "common topic with different title", title: "Apple beats earnings estimates", topics: []string{"AAPL"}},
+		{name: "similar title without common topic", title: "Google faces EU fine over App Store rules", topics: []string{"GOOGL"}},
+		{name: "common hashtag", title: "Fed holds rates steady again", topics: []string{"fed"}, want: fed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchStory(stories, tt.title, tt.topics); got != tt.want {
+				t.Errorf("matchStory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newsTopics(t *testing.T) {
+	n := &archivist.News{MetaData: []byte(`{"tickers":["MSFT","AAPL"],"markets":["US"],"hashtags":["AI","AAPL"]}`)}
+	if got, want := newsTopics(n), []string{"AAPL", "AI", "MSFT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("newsTopics() = %v, want %v", got, want)
+	}
+	if got := newsTopics(&archivist.News{}); got != nil {
+		t.Errorf("newsTopics() = %v, want nil for the news without meta", got)
+	}
+}
+
+func Test_formatStoryPart(t *testing.T) {
+	if got := formatStoryPart(&archivist.News{StoryPart: 1}); got != nil {
+		t.Errorf("formatStoryPart() = %q, want nil for the first part", got)
+	}
+	if got, want := (publisher.Message{Body: formatStoryPart(&archivist.News{StoryPart: 3})}).Markdown(), "🧵 Developing: part 3"; got != want {
+		t.Errorf("formatStoryPart() = %q, want %q", got, want)
+	}
+}
+
+func Test_storyHeadlines(t *testing.T) {
+	story := uuid.New()
+	news := []*archivist.News{
+		{ID: uuid.New(), OriginalTitle: "Apple faces EU fine", StoryID: story, StoryPart: 2},
+		{ID: uuid.New(), OriginalTitle: "Fed holds rates"},
+		{ID: uuid.New(), OriginalTitle: "Apple to appeal EU fine", StoryID: story, StoryPart: 3},
+		{ID: uuid.New(), OriginalTitle: "Oil drops", StoryID: uuid.New(), StoryPart: 1},
+	}
+
+	var got []string
+	for _, h := range storyHeadlines(news) {
+		got = append(got, h.Text)
+	}
+
+	want := []string{"Fed holds rates", "Developing story (part 3): Apple to appeal EU fine", "Oil drops"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("storyHeadlines() = %v, want %v", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/avast/retry-go"
 	"github.com/getsentry/sentry-go"
@@ -14,174 +15,219 @@ import (
 	"time"
 )
 
+// voiceNarrator converts the text to the OGG/Opus speech for the voice message (e.g. narrator.Narrator).
+type voiceNarrator interface {
+	Narrate(ctx context.Context, text string) ([]byte, error)
+}
+
 type SummaryJob struct {
-	composer  *composer.Composer           // composer that will compose text for the article using OpenAI
-	publisher *publisher.TelegramPublisher // publisher that will publish news to the channel
-	archivist *archivist.Archivist         // archivist that will save news to the database
-	logger    *slog.Logger                 // special logger for the job
+	composer   *composer.Composer    // composer that will compose text for the article using OpenAI
+	publishers []publisher.Publisher // publishers that will publish the summary to their channels
+	archivist  *archivist.Archivist  // archivist that will save news to the database
+	narrator   voiceNarrator         // if set, the summary is also published as the voice message (optional)
+	logger     *slog.Logger          // special logger for the job
 }
 
 func NewSummaryJob(
 	composer *composer.Composer,
-	publisher *publisher.TelegramPublisher,
+	defaultPublisher publisher.Publisher,
 	archivist *archivist.Archivist,
 ) *SummaryJob {
 	return &SummaryJob{
-		composer:  composer,
-		publisher: publisher,
-		archivist: archivist,
-		logger:    slog.Default(),
+		composer:   composer,
+		publishers: []publisher.Publisher{defaultPublisher},
+		archivist:  archivist,
+		logger:     slog.Default(),
 	}
 }
 
+// PublishTo overrides the channels where the summary is published (e.g. a low-noise "daily brief" channel
+// instead of the news stream). The summary is published to each of the publishers.
+func (j *SummaryJob) PublishTo(publishers ...publisher.Publisher) *SummaryJob {
+	if len(publishers) > 0 {
+		j.publishers = publishers
+	}
+	return j
+}
+
+// WithVoice sets the narrator of the audio brief. The summary is read aloud and published as the voice message
+// in reply to the text summary in each channel.
+func (j *SummaryJob) WithVoice(n voiceNarrator) *SummaryJob {
+	j.narrator = n
+	return j
+}
+
 // Run runs the Summary job. From if the time from which events should be processed.
 func (j *SummaryJob) Run(from time.Time) JobFunc {
-	return func() {
-		_ = retry.Do(func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
-			defer cancel()
-
-			tx := sentry.StartTransaction(ctx, "RunSummaryJob")
-			tx.Op = "job-summary"
-
-			// Sentry performance monitoring
-			hub := sentry.GetHubFromContext(ctx)
-			if hub == nil {
-				hub = sentry.CurrentHub().Clone()
-				ctx = sentry.SetHubOnContext(ctx, hub)
-			}
-
-			defer tx.Finish()
-			defer hub.Flush(2 * time.Second)
-			defer hub.Recover(nil)
-
-			// Fetch news from the database
-			span := sentry.StartSpan(ctx, "News.FindAllUntilDate", sentry.WithTransactionName("SummaryJob.Run"))
-			news, err := j.archivist.Entities.News.FindAllUntilDate(ctx, from)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("error fetching news from the database: %w", err)
-				j.logger.Error(e.Error())
-				hub.AddBreadcrumb(&sentry.Breadcrumb{
-					Category: "database",
-					Message:  "Error fetching news from the database",
-					Level:    sentry.LevelError,
-				}, nil)
-				utils.CaptureSentryException("jobSummaryNewsFindAllError", hub, e)
-				return e
-			}
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  fmt.Sprintf("News.FindAllUntilDate returned %d news", len(news)),
-				Level:    sentry.LevelInfo,
-			}, nil)
-
-			// Find all events
-			span = sentry.StartSpan(ctx, "Events.FindAllUntilDate", sentry.WithTransactionName("SummaryJob.Run"))
-			events, err := j.archivist.Entities.Events.FindAllUntilDate(ctx, from)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("error fetching events from the database: %w", err)
-				j.logger.Error(e.Error())
-				hub.AddBreadcrumb(&sentry.Breadcrumb{
-					Category: "database",
-					Message:  "Error fetching events from the database",
-					Level:    sentry.LevelError,
-				}, nil)
-				utils.CaptureSentryException("jobSummaryEventsFindAllError", hub, e)
-				return e
-			}
-
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  fmt.Sprintf("Events.FindAllUntilDate returned %d events", len(events)),
-				Level:    sentry.LevelInfo,
-			}, nil)
-
-			if sum := len(events) + len(news); sum < 5 {
-				j.logger.Info("No news or events to process (or total < 5)")
+	return func() error {
+		return retry.Do(func() error {
+			return runInstrumented("summary", defaultJobTimeout, func(ctx context.Context, r *JobRun) error {
+				hub := r.Hub
+				r.SetChannel(j.channels())
+
+				// Fetch news from the database
+				span := sentry.StartSpan(ctx, "News.FindAllUntilDate", sentry.WithTransactionName("SummaryJob.Run"))
+				news, err := j.archivist.Entities.News.FindAllUntilDate(ctx, from)
+				span.Finish()
+				if err != nil {
+					e := fmt.Errorf("error fetching news from the database: %w", err)
+					j.logger.Error(e.Error())
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "database",
+						Message:  "Error fetching news from the database",
+						Level:    sentry.LevelError,
+					}, nil)
+					utils.CaptureSentryException("jobSummaryNewsFindAllError", hub, e)
+					return e
+				}
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
 					Category: "successful",
-					Message:  fmt.Sprintf("Sum of news & events = %d, which is below summary threshold (5). ", sum),
-					Level:    sentry.LevelDebug,
+					Message:  fmt.Sprintf("News.FindAllUntilDate returned %d news", len(news)),
+					Level:    sentry.LevelInfo,
 				}, nil)
-				return nil
-			}
-
-			var headlines []*composer.Headline
-			for _, e := range events {
-				headlines = append(headlines, e.ToHeadline())
-			}
-			for _, n := range news {
-				headlines = append(headlines, n.ToHeadline())
-			}
-
-			span = sentry.StartSpan(ctx, "Summarise", sentry.WithTransactionName("SummaryJob.Run"))
-			summarised, err := j.composer.Summarise(ctx, headlines, 20, 2048)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("error summarising news: %w", err)
-				j.logger.Error(e.Error())
-				hub.AddBreadcrumb(&sentry.Breadcrumb{
-					Category: "composer",
-					Message:  "Error composing summary",
-					Level:    sentry.LevelError,
-				}, nil)
-				utils.CaptureSentryException("jobSummaryComposerSummariseError", hub, e)
-				return e
-			}
-			if len(summarised) == 0 {
-				j.logger.Info("No summarised news")
+
+				// Find all events
+				span = sentry.StartSpan(ctx, "Events.FindAllUntilDate", sentry.WithTransactionName("SummaryJob.Run"))
+				events, err := j.archivist.Entities.Events.FindAllUntilDate(ctx, from)
+				span.Finish()
+				if err != nil {
+					e := fmt.Errorf("error fetching events from the database: %w", err)
+					j.logger.Error(e.Error())
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "database",
+						Message:  "Error fetching events from the database",
+						Level:    sentry.LevelError,
+					}, nil)
+					utils.CaptureSentryException("jobSummaryEventsFindAllError", hub, e)
+					return e
+				}
+
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
-					Category: "debug",
-					Message:  "No summarised news",
-					Level:    sentry.LevelDebug,
+					Category: "successful",
+					Message:  fmt.Sprintf("Events.FindAllUntilDate returned %d events", len(events)),
+					Level:    sentry.LevelInfo,
 				}, nil)
-				return nil
-			}
 
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  fmt.Sprintf("composer.Summarise returned %d headlines", len(summarised)),
-				Level:    sentry.LevelInfo,
-			}, nil)
+				if sum := len(events) + len(news); sum < 5 {
+					j.logger.Info("No news or events to process (or total < 5)")
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "successful",
+						Message:  fmt.Sprintf("Sum of news & events = %d, which is below summary threshold (5). ", sum),
+						Level:    sentry.LevelDebug,
+					}, nil)
+					return nil
+				}
+
+				var headlines []*composer.Headline
+				for _, e := range events {
+					headlines = append(headlines, e.ToHeadline())
+				}
+				headlines = append(headlines, storyHeadlines(news)...)
+
+				span = sentry.StartSpan(ctx, "Summarise", sentry.WithTransactionName("SummaryJob.Run"))
+				summarised, err := j.composer.Summarise(ctx, headlines, 20, 2048)
+				span.Finish()
+				if err != nil {
+					e := fmt.Errorf("error summarising news: %w", err)
+					j.logger.Error(e.Error())
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "composer",
+						Message:  "Error composing summary",
+						Level:    sentry.LevelError,
+					}, nil)
+					utils.CaptureSentryException("jobSummaryComposerSummariseError", hub, e)
+					return e
+				}
+				if len(summarised) == 0 {
+					j.logger.Info("No summarised news")
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "debug",
+						Message:  "No summarised news",
+						Level:    sentry.LevelDebug,
+					}, nil)
+					return nil
+				}
 
-			message := formatSummary(summarised, from)
-			if message == "" {
-				j.logger.Info("No summary message")
 				hub.AddBreadcrumb(&sentry.Breadcrumb{
-					Category: "debug",
-					Message:  "No summary message",
-					Level:    sentry.LevelDebug,
+					Category: "successful",
+					Message:  fmt.Sprintf("composer.Summarise returned %d headlines", len(summarised)),
+					Level:    sentry.LevelInfo,
 				}, nil)
+
+				message := formatSummary(summarised, from)
+				if message == "" {
+					j.logger.Info("No summary message")
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "debug",
+						Message:  "No summary message",
+						Level:    sentry.LevelDebug,
+					}, nil)
+					return nil
+				}
+
+				audio := j.narrate(ctx, hub, summarised, from)
+
+				// Publish summary to each channel. Channels that failed are not retried,
+				// because Telegram API often hangs up, but somehow publishes the message
+				var publishErr error
+				for _, p := range j.publishers {
+					span = sentry.StartSpan(ctx, "Publish", sentry.WithTransactionName("SummaryJob.Run"))
+					pubID, err := p.Publish(publisher.Markdown(message))
+					span.Finish()
+					if err != nil {
+						e := fmt.Errorf("error publishing summary to %s: %w", p.Channel(), err)
+						j.logger.Error(e.Error())
+						hub.AddBreadcrumb(&sentry.Breadcrumb{
+							Category: "publisher",
+							Message:  "Error publishing summary",
+							Level:    sentry.LevelError,
+						}, nil)
+						utils.CaptureSentryException("jobSummaryPublishError", hub, e)
+						publishErr = errors.Join(publishErr, e)
+						continue
+					}
+
+					hub.AddBreadcrumb(&sentry.Breadcrumb{
+						Category: "successful",
+						Message:  fmt.Sprintf("Summary published successfully to %s", p.Channel()),
+						Level:    sentry.LevelInfo,
+					}, nil)
+
+					// Save the summary with its headlines provenance.
+					// Note: the summary is already published, so the error is not retried
+					summary := archivist.NewSummary(archivist.SummaryBeforeOpen, headlines, summarised)
+					summary.ChannelID = p.Channel()
+					summary.PublicationID = pubID
+					summary.Text = message
+					span = sentry.StartSpan(ctx, "Summaries.Create", sentry.WithTransactionName("SummaryJob.Run"))
+					err = j.archivist.Entities.Summaries.Create(ctx, summary)
+					span.Finish()
+					if err != nil {
+						e := fmt.Errorf("error saving summary: %w", err)
+						j.logger.Error(e.Error())
+						utils.CaptureSentryException("jobSummarySaveError", hub, e)
+					}
+
+					voice, ok := p.(publisher.VoicePublisher)
+					if audio == nil || !ok {
+						continue
+					}
+					span = sentry.StartSpan(ctx, "PublishVoice", sentry.WithTransactionName("SummaryJob.Run"))
+					_, err = voice.PublishVoice(audio, pubID)
+					span.Finish()
+					if err != nil {
+						e := fmt.Errorf("error publishing voice summary to %s: %w", p.Channel(), err)
+						j.logger.Warn(e.Error())
+						utils.CaptureSentryException("jobSummaryVoiceError", hub, e)
+					}
+				}
+
+				if publishErr != nil {
+					return retry.Unrecoverable(publishErr) //nolint:wrapcheck
+				}
+
 				return nil
-			}
-
-			// Publish summary to the channel
-			span = sentry.StartSpan(ctx, "Publish", sentry.WithTransactionName("SummaryJob.Run"))
-			_, err = j.publisher.Publish(message)
-			span.Finish()
-			if err != nil {
-				e := fmt.Errorf("error publishing summary: %w", err)
-				j.logger.Error(e.Error())
-				hub.AddBreadcrumb(&sentry.Breadcrumb{
-					Category: "publisher",
-					Message:  "Error publishing summary",
-					Level:    sentry.LevelError,
-				}, nil)
-				utils.CaptureSentryException("jobSummaryPublishError", hub, e)
-				// Note: Unrecoverable error, because Telegram API often hangs up, but somehow publishes the message
-				return retry.Unrecoverable(e) //nolint:wrapcheck
-			}
-
-			hub.AddBreadcrumb(&sentry.Breadcrumb{
-				Category: "successful",
-				Message:  "Summary published successfully",
-				Level:    sentry.LevelInfo,
-			}, nil)
-
-			// TODO: Save or not to save summary to db?
-			return nil
+			})
 		},
 			retry.Attempts(5),
 			retry.Delay(10*time.Minute),
@@ -189,6 +235,35 @@ func (j *SummaryJob) Run(from time.Time) JobFunc {
 	}
 }
 
+// narrate returns the audio brief of the summary if WithVoice is set. Errors are only logged,
+// because the text summary is published anyway.
+func (j *SummaryJob) narrate(ctx context.Context, hub *sentry.Hub, headlines []*composer.SummarisedHeadline, from time.Time) []byte {
+	if j.narrator == nil {
+		return nil
+	}
+
+	span := sentry.StartSpan(ctx, "Narrate", sentry.WithTransactionName("SummaryJob.Run"))
+	audio, err := j.narrator.Narrate(ctx, formatSpokenSummary(headlines, from))
+	span.Finish()
+	if err != nil {
+		e := fmt.Errorf("error narrating summary: %w", err)
+		j.logger.Warn(e.Error())
+		utils.CaptureSentryException("jobSummaryNarrateError", hub, e)
+		return nil
+	}
+
+	return audio
+}
+
+// channels returns the comma separated IDs of the channels where the summary is published.
+func (j *SummaryJob) channels() string {
+	ids := make([]string, len(j.publishers))
+	for i, p := range j.publishers {
+		ids[i] = p.Channel()
+	}
+	return strings.Join(ids, ",")
+}
+
 func formatSummary(headlines []*composer.SummarisedHeadline, from time.Time) string {
 	if len(headlines) == 0 {
 		return ""
@@ -208,3 +283,18 @@ func formatSummary(headlines []*composer.SummarisedHeadline, from time.Time) str
 
 	return message
 }
+
+// formatSpokenSummary formats the summary as the plain text for the narrator (without hashtags and links).
+func formatSpokenSummary(headlines []*composer.SummarisedHeadline, from time.Time) string {
+	if len(headlines) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("What happened in the last %d hours.\n", int(time.Since(from).Hours())))
+	for _, h := range headlines {
+		sb.WriteString(strings.TrimSuffix(strings.TrimSpace(h.Summary), ".") + ".\n")
+	}
+
+	return sb.String()
+}
@@ -1,7 +1,11 @@
 package jobs
 
 import (
+	"context"
+	"errors"
+	"github.com/getsentry/sentry-go"
 	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/publisher"
 	"testing"
 	"time"
 )
@@ -62,3 +66,80 @@ func Test_formatSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestSummaryJob_PublishTo(t *testing.T) {
+	news := &publisher.TelegramPublisher{ChannelID: "@news"}
+	brief := &publisher.TelegramPublisher{ChannelID: "@brief"}
+
+	j := NewSummaryJob(nil, news, nil)
+	if len(j.publishers) != 1 || j.publishers[0] != news {
+		t.Errorf("NewSummaryJob() publishers = %v, want the news publisher", j.publishers)
+	}
+
+	j.PublishTo()
+	if len(j.publishers) != 1 || j.publishers[0] != news {
+		t.Errorf("PublishTo() without publishers should keep the default publisher, got %v", j.publishers)
+	}
+
+	j.PublishTo(news, brief)
+	if len(j.publishers) != 2 || j.publishers[1] != brief {
+		t.Errorf("PublishTo() publishers = %v, want news and brief", j.publishers)
+	}
+	if got := j.channels(); got != "@news,@brief" {
+		t.Errorf("channels() = %s, want @news,@brief", got)
+	}
+}
+
+func Test_formatSpokenSummary(t *testing.T) {
+	headlines := []*composer.SummarisedHeadline{
+		{ID: "1", Summary: "Someone warns text", Link: "https://t.me/fin_thread/1", Verb: "warns"},
+		{ID: "2", Summary: " Someone else bonks text. "},
+	}
+
+	want := "What happened in the last 7 hours.\n" +
+		"Someone warns text.\n" +
+		"Someone else bonks text.\n"
+	if got := formatSpokenSummary(headlines, time.Now().Add(-7*time.Hour)); got != want {
+		t.Errorf("formatSpokenSummary() = %q, want %q", got, want)
+	}
+
+	if got := formatSpokenSummary(nil, time.Now()); got != "" {
+		t.Errorf("formatSpokenSummary() = %q, want empty text", got)
+	}
+}
+
+type fakeNarrator struct {
+	audio []byte
+	err   error
+	text  string
+}
+
+func (f *fakeNarrator) Narrate(_ context.Context, text string) ([]byte, error) {
+	f.text = text
+	return f.audio, f.err
+}
+
+func TestSummaryJob_narrate(t *testing.T) {
+	ctx := context.Background()
+	hub := sentry.CurrentHub().Clone()
+	headlines := []*composer.SummarisedHeadline{{ID: "1", Summary: "Nvidia rallied after earnings"}}
+	from := time.Now().Add(-12 * time.Hour)
+	j := NewSummaryJob(nil, &publisher.TelegramPublisher{ChannelID: "@news"}, nil)
+
+	if audio := j.narrate(ctx, hub, headlines, from); audio != nil {
+		t.Errorf("narrate() = %v, want nil without narrator", audio)
+	}
+
+	n := &fakeNarrator{audio: []byte("OggS")}
+	if audio := j.WithVoice(n).narrate(ctx, hub, headlines, from); string(audio) != "OggS" {
+		t.Errorf("narrate() = %q, want the narrated audio", audio)
+	}
+	if want := formatSpokenSummary(headlines, from); n.text != want {
+		t.Errorf("narrate() text = %q, want %q", n.text, want)
+	}
+
+	n.err = errors.New("rate limit")
+	if audio := j.narrate(ctx, hub, headlines, from); audio != nil {
+		t.Errorf("narrate() = %v, want nil on the narrator error", audio)
+	}
+}
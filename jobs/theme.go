@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"strings"
+)
+
+// Theme holds the icons of the calendar posts, so channels can customize or disable them.
+// Empty icon is omitted from the post.
+type Theme struct {
+	CalendarHeader string                                  `json:"calendar_header"` // header of the daily plan, e.g. "📅"
+	HighImpact     string                                  `json:"high_impact"`     // high-impact event released with surprise, e.g. "🔥"
+	Surprise       string                                  `json:"surprise"`        // other event released with surprise, e.g. "⚠️"
+	Speech         string                                  `json:"speech"`          // speech events in the daily plan, e.g. "🎙️"
+	Series         string                                  `json:"series"`          // last readings of the indicator, e.g. "📊"
+	Poll           string                                  `json:"poll"`            // forecast poll resolution, e.g. "🗳"
+	FX             string                                  `json:"fx"`              // FX pairs of the currencies impacted by the surprise, e.g. "💱"
+	Countries      map[ecal.EconomicCalendarCountry]string `json:"countries"`       // country icon overrides, "*" overrides all others
+	CountryNames   bool                                    `json:"country_names"`   // if true, countries are written by name instead of the flag
+}
+
+// DefaultTheme returns the theme with the emojis.
+func DefaultTheme() *Theme {
+	return &Theme{
+		CalendarHeader: "📅",
+		HighImpact:     "🔥",
+		Surprise:       "⚠️",
+		Speech:         "🎙️",
+		Series:         "📊",
+		Poll:           "🗳",
+		FX:             "💱",
+	}
+}
+
+// PlainTheme returns the plain-text theme without emojis (e.g. for the screen readers).
+func PlainTheme() *Theme {
+	return &Theme{
+		HighImpact:   "[high impact]",
+		Surprise:     "[surprise]",
+		Speech:       "[speech]",
+		Series:       "Last readings:",
+		Poll:         "Poll:",
+		FX:           "FX:",
+		CountryNames: true,
+	}
+}
+
+// country returns the icon (or name) of the country.
+func (t *Theme) country(c ecal.EconomicCalendarCountry) string {
+	if t.CountryNames {
+		return string(c)
+	}
+	if icon, ok := t.Countries[c]; ok {
+		return icon
+	}
+	if icon, ok := t.Countries["*"]; ok {
+		return icon
+	}
+	return ecal.GetCountryEmoji(c)
+}
+
+// withIcons prepends the non-empty icons to the text separated by spaces.
+func withIcons(text string, icons ...string) string {
+	var parts []string
+	for _, icon := range icons {
+		if icon != "" {
+			parts = append(parts, icon)
+		}
+	}
+	return strings.Join(append(parts, text), " ")
+}
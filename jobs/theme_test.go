@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"testing"
+	"time"
+)
+
+func TestTheme_country(t *testing.T) {
+	tests := []struct {
+		name  string
+		theme *Theme
+		want  string
+	}{
+		{name: "default", theme: DefaultTheme(), want: "🇺🇸"},
+		{name: "names", theme: PlainTheme(), want: "United States"},
+		{
+			name:  "override",
+			theme: &Theme{Countries: map[ecal.EconomicCalendarCountry]string{ecal.EconomicCalendarUnitedStates: "🗽", "*": ""}},
+			want:  "🗽",
+		},
+		{name: "disabled", theme: &Theme{Countries: map[ecal.EconomicCalendarCountry]string{"*": ""}}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.theme.country(ecal.EconomicCalendarUnitedStates); got != tt.want {
+				t.Errorf("country() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTheme_calendarPosts(t *testing.T) {
+	date := time.Date(2024, 1, 2, 13, 30, 0, 0, time.UTC)
+	events := ecal.EconomicCalendarEvents{
+		{DateTime: date, Country: ecal.EconomicCalendarUnitedStates, Impact: ecal.EconomicCalendarImpactHigh, Title: "CPI m/m", Forecast: "0.2%"},
+		{DateTime: date, Country: ecal.EconomicCalendarUnitedStates, Impact: ecal.EconomicCalendarImpactMedium, Title: "Fed Chair Powell Speaks", EventType: ecal.EconomicCalendarTypeSpeech},
+	}
+	update := []*archivist.Event{
+		{Country: ecal.EconomicCalendarUnitedStates, Impact: ecal.EconomicCalendarImpactHigh, Title: "CPI m/m", Actual: "0.4%", Forecast: "0.2%"},
+	}
+	noIcons := &Theme{Countries: map[ecal.EconomicCalendarCountry]string{"*": ""}}
+
+	tests := []struct {
+		name       string
+		theme      *Theme
+		wantDaily  string
+		wantUpdate string
+	}{
+		{
+			name:  "plain",
+			theme: PlainTheme(),
+			wantDaily: "Economic calendar for today\n\n" +
+				"United States 13:30 CPI m/m, forecast: 0.2%\n" +
+				"United States 13:30 [speech] Fed Chair Powell Speaks\n" +
+				"*Time is in UTC*\n#calendar #economy",
+			wantUpdate: "United States #usa\n[high impact] CPI m/m: *0.4%*, forecast: 0.2%",
+		},
+		{
+			name:  "no icons",
+			theme: noIcons,
+			wantDaily: "Economic calendar for today\n\n" +
+				"13:30 CPI m/m, forecast: 0.2%\n" +
+				"13:30 Fed Chair Powell Speaks\n" +
+				"*Time is in UTC*\n#calendar #economy",
+			wantUpdate: "#usa\nCPI m/m: *0.4%*, forecast: 0.2%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDailyEvents(events, tt.theme, false); got != tt.wantDaily {
+				t.Errorf("formatDailyEvents() = %q, want %q", got, tt.wantDaily)
+			}
+			if got := formatEventsUpdate(ecal.EconomicCalendarUnitedStates, update, nil, tt.theme); got != tt.wantUpdate {
+				t.Errorf("formatEventsUpdate() = %q, want %q", got, tt.wantUpdate)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// titleNormalizer translates the economic event titles to the standard English names (e.g. composer.Composer).
+type titleNormalizer interface {
+	NormalizeEventTitles(ctx context.Context, titles []string, opts ...composer.Option) (map[string]string, error)
+}
+
+// eventTitles normalizes the titles of the fetched events, so the updates posts use the same names as the daily plan.
+// Titles are looked up in the mapping table first, unknown ones are normalized by AI (if set) and cached.
+type eventTitles struct {
+	table  map[string]string // lowercase source title -> standard name
+	ai     titleNormalizer   // optional AI fallback for the titles missing in the table
+	mu     sync.Mutex
+	cache  map[string]string // titles normalized by AI
+	logger *slog.Logger
+}
+
+// newEventTitles creates a new eventTitles with the mapping table (source title -> standard name) and AI fallback.
+func newEventTitles(table map[string]string, ai titleNormalizer, logger *slog.Logger) *eventTitles {
+	t := &eventTitles{
+		table:  make(map[string]string, len(table)),
+		ai:     ai,
+		cache:  make(map[string]string),
+		logger: logger,
+	}
+	for k, v := range table {
+		t.table[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return t
+}
+
+// normalize replaces the event titles with their standard names. On AI error the original titles are kept
+// and not cached, so they are retried on the next run.
+func (t *eventTitles) normalize(ctx context.Context, hub *sentry.Hub, events ecal.EconomicCalendarEvents) {
+	var pending ecal.EconomicCalendarEvents
+	var unknown []string
+	t.mu.Lock()
+	for _, e := range events {
+		if name, ok := t.table[strings.ToLower(strings.TrimSpace(e.Title))]; ok {
+			e.Title = name
+			continue
+		}
+		if name, ok := t.cache[e.Title]; ok {
+			e.Title = name
+			continue
+		}
+		if t.ai == nil {
+			continue
+		}
+		pending = append(pending, e)
+		if !slices.Contains(unknown, e.Title) {
+			unknown = append(unknown, e.Title)
+		}
+	}
+	t.mu.Unlock()
+
+	if len(unknown) == 0 {
+		return
+	}
+
+	names, err := t.ai.NormalizeEventTitles(ctx, unknown)
+	if err != nil {
+		t.logger.Warn("[calendar] Error normalizing event titles", "error", err)
+		utils.CaptureSentryException("calendarJobNormalizeTitlesError", hub, err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, title := range unknown {
+		name, ok := names[title]
+		if !ok {
+			// Cache the titles missing in the answer as is, they are most likely standard already
+			name = title
+		}
+		t.cache[title] = name
+	}
+	for _, e := range pending {
+		e.Title = t.cache[e.Title]
+	}
+}
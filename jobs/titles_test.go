@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+type fakeTitleNormalizer struct {
+	names map[string]string
+	err   error
+	calls [][]string
+}
+
+func (f *fakeTitleNormalizer) NormalizeEventTitles(_ context.Context, titles []string, _ ...composer.Option) (map[string]string, error) {
+	f.calls = append(f.calls, titles)
+	return f.names, f.err
+}
+
+func titlesOf(events ecal.EconomicCalendarEvents) []string {
+	titles := make([]string, len(events))
+	for i, e := range events {
+		titles[i] = e.Title
+	}
+	return titles
+}
+
+func newTitleEvents(titles ...string) ecal.EconomicCalendarEvents {
+	events := make(ecal.EconomicCalendarEvents, len(titles))
+	for i, t := range titles {
+		events[i] = &ecal.EconomicCalendarEvent{Title: t}
+	}
+	return events
+}
+
+func TestEventTitles_normalize(t *testing.T) {
+	table := map[string]string{" Verbraucherpreisindex (Jahr) ": "CPI y/y"}
+	hub := sentry.CurrentHub().Clone()
+
+	t.Run("table only", func(t *testing.T) {
+		et := newEventTitles(table, nil, slog.Default())
+		events := newTitleEvents("verbraucherpreisindex (jahr)", "GDP q/q")
+		et.normalize(context.Background(), hub, events)
+		if got, want := titlesOf(events), []string{"CPI y/y", "GDP q/q"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("normalize() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("AI fallback is cached", func(t *testing.T) {
+		ai := &fakeTitleNormalizer{names: map[string]string{"BIP (Quartal)": "GDP q/q"}}
+		et := newEventTitles(table, ai, slog.Default())
+		events := newTitleEvents("Verbraucherpreisindex (Jahr)", "BIP (Quartal)", "BIP (Quartal)", "Retail Sales m/m")
+		et.normalize(context.Background(), hub, events)
+		if got, want := titlesOf(events), []string{"CPI y/y", "GDP q/q", "GDP q/q", "Retail Sales m/m"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("normalize() = %v, want %v", got, want)
+		}
+		if want := [][]string{{"BIP (Quartal)", "Retail Sales m/m"}}; !reflect.DeepEqual(ai.calls, want) {
+			t.Errorf("NormalizeEventTitles() calls = %v, want %v", ai.calls, want)
+		}
+
+		events = newTitleEvents("BIP (Quartal)", "Retail Sales m/m")
+		et.normalize(context.Background(), hub, events)
+		if got, want := titlesOf(events), []string{"GDP q/q", "Retail Sales m/m"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("normalize() = %v, want %v", got, want)
+		}
+		if len(ai.calls) != 1 {
+			t.Errorf("NormalizeEventTitles() called %d times, want cached titles", len(ai.calls))
+		}
+	})
+
+	t.Run("AI error keeps titles", func(t *testing.T) {
+		ai := &fakeTitleNormalizer{err: errors.New("unavailable")}
+		et := newEventTitles(nil, ai, slog.Default())
+		events := newTitleEvents("BIP (Quartal)")
+		et.normalize(context.Background(), hub, events)
+		et.normalize(context.Background(), hub, events)
+		if got, want := titlesOf(events), []string{"BIP (Quartal)"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("normalize() = %v, want %v", got, want)
+		}
+		if len(ai.calls) != 2 {
+			t.Errorf("NormalizeEventTitles() called %d times, want retry after error", len(ai.calls))
+		}
+	})
+}
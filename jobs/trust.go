@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"strings"
+)
+
+const (
+	trustedWeight      = 2.0 // providers with the weight from this value bypass the AI filter and empty meta omission
+	defaultTrustWeight = 1.0 // weight of the unknown providers if it isn't set with the "*" key
+	unknownTrustKey    = "*" // key of the weight for the providers missing in the trust map
+)
+
+// providerTrust holds trust weights of the news providers (by name, case-insensitive) that modulate filtering:
+//   - weight >= 2: trusted provider, its news bypass the AI filter and empty meta omission;
+//   - weight < 1: low-trust provider, its news require tickers in the composed meta to be published;
+//   - weight <= 0: its news are never published.
+//
+// Published news are ordered by the weight of the provider, the most trusted first.
+type providerTrust struct {
+	weights map[string]float64
+	unknown float64 // weight of the providers missing in weights
+}
+
+// newProviderTrust creates a providerTrust from the provider weights, "*" key sets the weight of unknown providers.
+// Returns nil if weights are empty.
+func newProviderTrust(weights map[string]float64) *providerTrust {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	t := &providerTrust{
+		weights: make(map[string]float64, len(weights)),
+		unknown: defaultTrustWeight,
+	}
+	for name, w := range weights {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == unknownTrustKey {
+			t.unknown = w
+			continue
+		}
+		t.weights[name] = w
+	}
+
+	return t
+}
+
+// weight returns the trust weight of the provider (1 if trust is not configured).
+func (t *providerTrust) weight(provider string) float64 {
+	if t == nil {
+		return defaultTrustWeight
+	}
+	if w, ok := t.weights[strings.ToLower(provider)]; ok {
+		return w
+	}
+	return t.unknown
+}
+
+// trusted returns true if news of the provider bypass the AI filter and empty meta omission.
+func (t *providerTrust) trusted(provider string) bool {
+	return t.weight(provider) >= trustedWeight
+}
+
+// accepts returns true if the news of the provider with the composed meta can be published.
+func (t *providerTrust) accepts(provider string, meta *composer.ComposedMeta) bool {
+	w := t.weight(provider)
+	switch {
+	case w <= 0:
+		return false
+	case w < defaultTrustWeight:
+		return len(meta.Tickers) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/composer"
+	"testing"
+)
+
+func Test_providerTrust_accepts(t *testing.T) {
+	trust := newProviderTrust(map[string]float64{" Reuters ": 2, "blog": 0.5, "spam": 0, "*": 0.8})
+	withTickers := &composer.ComposedMeta{Tickers: []string{"AAPL"}}
+	noTickers := &composer.ComposedMeta{Hashtags: []string{"fed"}}
+
+	tests := []struct {
+		name     string
+		trust    *providerTrust
+		provider string
+		meta     *composer.ComposedMeta
+		want     bool
+	}{
+		{name: "trusted provider", trust: trust, provider: "reuters", meta: noTickers, want: true},
+		{name: "low-trust provider with tickers", trust: trust, provider: "Blog", meta: withTickers, want: true},
+		{name: "low-trust provider without tickers", trust: trust, provider: "Blog", meta: noTickers, want: false},
+		{name: "zero weight provider", trust: trust, provider: "Spam", meta: withTickers, want: false},
+		{name: "unknown provider uses * weight", trust: trust, provider: "Other", meta: noTickers, want: false},
+		{name: "trust not configured", trust: nil, provider: "Other", meta: noTickers, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trust.accepts(tt.provider, tt.meta); got != tt.want {
+				t.Errorf("accepts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_providerTrust_trusted(t *testing.T) {
+	trust := newProviderTrust(map[string]float64{"Reuters": 2, "Blog": 0.5})
+
+	if !trust.trusted("REUTERS") {
+		t.Errorf("trusted() should trust Reuters")
+	}
+	if trust.trusted("Blog") || trust.trusted("Other") {
+		t.Errorf("trusted() should not trust Blog and unknown providers")
+	}
+	if newProviderTrust(nil) != nil {
+		t.Errorf("newProviderTrust() should return nil for empty weights")
+	}
+}
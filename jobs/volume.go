@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"slices"
+	"time"
+)
+
+// volumeBaselineDays is the number of the previous days whose same time window is the baseline of the news volume.
+const volumeBaselineDays = 7
+
+// volumeAnomaly is the sudden change of the incoming news volume of the provider.
+type volumeAnomaly struct {
+	provider string  // name of the news provider
+	count    int64   // number of news in the current window
+	average  float64 // average number of news in the same window of the previous days
+}
+
+// silent returns true if the provider stopped sending news (e.g. outage or feed format change).
+func (a volumeAnomaly) silent() bool {
+	return a.count == 0
+}
+
+// detectVolumeAnomalies compares the news count of each provider in the current window with its average in the history
+// windows. Providers with the average of at least minNews that sent nothing are silent, providers that sent at least
+// minNews and more than spike times the average have a spike. Providers without history are skipped.
+// Anomalies are sorted by the provider name.
+func detectVolumeAnomalies(current map[string]int64, history []map[string]int64, spike, minNews float64) []volumeAnomaly {
+	if len(history) == 0 {
+		return nil
+	}
+
+	totals := make(map[string]int64)
+	for _, counts := range history {
+		for provider, n := range counts {
+			totals[provider] += n
+		}
+	}
+
+	var anomalies []volumeAnomaly
+	for provider, total := range totals {
+		average := float64(total) / float64(len(history))
+		count := current[provider]
+
+		switch {
+		case count == 0 && average >= minNews:
+			anomalies = append(anomalies, volumeAnomaly{provider: provider, count: count, average: average})
+		case float64(count) >= minNews && float64(count) > spike*average:
+			anomalies = append(anomalies, volumeAnomaly{provider: provider, count: count, average: average})
+		}
+	}
+
+	slices.SortFunc(anomalies, func(a, b volumeAnomaly) int {
+		if a.provider < b.provider {
+			return -1
+		}
+		if a.provider > b.provider {
+			return 1
+		}
+		return 0
+	})
+
+	return anomalies
+}
+
+// volumeAnomalies finds the anomalies of the news volume in the last window compared to the same window
+// of the previous days.
+func (j *WatchdogJob) volumeAnomalies(ctx context.Context, tx *sentry.Span, now time.Time) ([]volumeAnomaly, error) {
+	window := j.options.volumeWindow
+
+	span := tx.StartChild("News.CountByProvider")
+	defer span.Finish()
+
+	current, err := j.archivist.Entities.News.CountByProvider(ctx, now.Add(-window), now)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]map[string]int64, 0, volumeBaselineDays)
+	for day := 1; day <= volumeBaselineDays; day++ {
+		to := now.AddDate(0, 0, -day)
+		counts, err := j.archivist.Entities.News.CountByProvider(ctx, to.Add(-window), to)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, counts)
+	}
+
+	return detectVolumeAnomalies(current, history, j.options.volumeSpike, j.options.volumeMinNews), nil
+}
+
+// formatVolumeAlert formats the anomaly, e.g. "Reuters sent 0 news in the last 1h0m0s, usually 12.3 (silent)".
+func formatVolumeAlert(a volumeAnomaly, window time.Duration) string {
+	kind := "silent"
+	if !a.silent() {
+		kind = "spike"
+		if a.average > 0 {
+			kind = fmt.Sprintf("spike ×%.1f", float64(a.count)/a.average)
+		}
+	}
+
+	return fmt.Sprintf("%s sent %d news in the last %s, usually %.1f (%s).", a.provider, a.count, window, a.average, kind)
+}
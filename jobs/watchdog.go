@@ -0,0 +1,239 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/samgozman/fin-thread/archivist"
+	"github.com/samgozman/fin-thread/publisher"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchdogJob monitors the news pipeline and alerts the admin chat about silent failures,
+// e.g. when nothing was published for a long time during market hours (empty feeds),
+// when the share of filtered news suddenly jumps (over-aggressive prompt)
+// or when the provider news volume spikes or drops to zero (feed format change or outage).
+type WatchdogJob struct {
+	publisher publisher.Publisher  // publisher that will send alerts to the admin chat
+	archivist *archivist.Archivist // archivist that will be used to get news stats
+	logger    *slog.Logger         // special logger for the job
+	options   *watchdogOptions     // job options
+
+	mu        sync.Mutex                  // guards lastAlert (runs of the job may overlap)
+	lastAlert map[watchdogAlert]time.Time // time of the last sent alert by its kind (to avoid spamming)
+}
+
+// watchdogOptions holds options needed for the WatchdogJob execution.
+type watchdogOptions struct {
+	silencePeriod       time.Duration // alert if no news were published for this period during market hours
+	filterRateThreshold float64       // alert if the share of filtered news (0..1) is above this threshold
+	filterRateMinNews   int64         // minimal number of news in the window to calculate the filter rate
+	marketOpen          time.Duration // market open time (offset from the start of the day in UTC)
+	marketClose         time.Duration // market close time (offset from the start of the day in UTC)
+	volumeWindow        time.Duration // window of the provider news volume compared with the same window of the previous days
+	volumeSpike         float64       // alert if the provider volume is above this multiple of its average (0 disables volume alerts)
+	volumeMinNews       float64       // minimal average volume for the silence alert and minimal volume for the spike alert
+}
+
+// watchdogAlert is a kind of the alert sent by the WatchdogJob.
+type watchdogAlert string
+
+const (
+	watchdogAlertSilence    watchdogAlert = "silence"
+	watchdogAlertFilterRate watchdogAlert = "filter_rate"
+	watchdogAlertVolume     watchdogAlert = "volume" // suffixed with the provider name
+)
+
+// NewWatchdogJob creates a new WatchdogJob instance with default options:
+// 2 hours silence period, 90% filter rate threshold and US market hours (14:30 - 21:00 UTC).
+func NewWatchdogJob(publisher publisher.Publisher, archivist *archivist.Archivist) *WatchdogJob {
+	return &WatchdogJob{
+		publisher: publisher,
+		archivist: archivist,
+		logger:    slog.Default(),
+		options: &watchdogOptions{
+			silencePeriod:       2 * time.Hour,
+			filterRateThreshold: 0.9,
+			filterRateMinNews:   10,
+			marketOpen:          14*time.Hour + 30*time.Minute,
+			marketClose:         21 * time.Hour,
+			volumeWindow:        time.Hour,
+			volumeMinNews:       3,
+		},
+		lastAlert: make(map[watchdogAlert]time.Time),
+	}
+}
+
+// AlertOnSilence sets the period without publications after which the alert will be sent.
+func (j *WatchdogJob) AlertOnSilence(period time.Duration) *WatchdogJob {
+	j.options.silencePeriod = period
+	return j
+}
+
+// AlertOnFilterRate sets the share of filtered news (0..1) above which the alert will be sent.
+func (j *WatchdogJob) AlertOnFilterRate(threshold float64) *WatchdogJob {
+	j.options.filterRateThreshold = threshold
+	return j
+}
+
+// AlertOnProviderVolume enables the alerts about the sudden changes of the incoming news volume of each provider:
+// the number of news in the last window is compared with the average of the same window in the previous 7 days.
+// Providers that usually send news but sent nothing (outage, feed format change) and providers that sent more than
+// spike times the average are reported. Zero spike disables the alerts.
+func (j *WatchdogJob) AlertOnProviderVolume(window time.Duration, spike float64) *WatchdogJob {
+	j.options.volumeWindow = window
+	j.options.volumeSpike = spike
+	return j
+}
+
+// MarketHours sets the market hours (offsets from the start of the day in UTC) when the silence is checked.
+func (j *WatchdogJob) MarketHours(openAt, closeAt time.Duration) *WatchdogJob {
+	j.options.marketOpen = openAt
+	j.options.marketClose = closeAt
+	return j
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *WatchdogJob) Run() JobFunc {
+	return WithInstrumentation("watchdog", func(ctx context.Context, r *JobRun) {
+		tx, hub := r.Tx, r.Hub
+		r.SetChannel(j.publisher.Channel())
+
+		now := time.Now().UTC()
+		var alerts []string
+		var kinds []watchdogAlert // kinds of the alerts to send, released if the publication fails
+		add := func(kind watchdogAlert, message string) {
+			if m := j.alert(kind, now, message); m != "" {
+				alerts = append(alerts, m)
+				kinds = append(kinds, kind)
+			}
+		}
+
+		if isMarketHours(now, j.options.marketOpen, j.options.marketClose) &&
+			now.Sub(j.marketOpenedAt(now)) >= j.options.silencePeriod {
+			span := tx.StartChild("News.FindLastPublished")
+			last, err := j.archivist.Entities.News.FindLastPublished(ctx)
+			span.Finish()
+			if err != nil {
+				_ = r.Fail("watchdogJobFindLastPublishedError", "Error finding last published news", err)
+				return
+			}
+
+			if last == nil || now.Sub(last.PublishedAt) >= j.options.silencePeriod {
+				add(watchdogAlertSilence, formatSilenceAlert(last, now))
+			}
+		}
+
+		span := tx.StartChild("News.CountSince")
+		stats, err := j.archivist.Entities.News.CountSince(ctx, now.Add(-j.options.silencePeriod))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("watchdogJobCountSinceError", "Error counting news", err)
+			return
+		}
+
+		if stats.Total >= j.options.filterRateMinNews && stats.FilterRate() > j.options.filterRateThreshold {
+			add(watchdogAlertFilterRate, formatFilterRateAlert(stats, j.options.silencePeriod))
+		}
+
+		if j.options.volumeSpike > 0 {
+			anomalies, err := j.volumeAnomalies(ctx, tx, now)
+			if err != nil {
+				_ = r.Fail("watchdogJobCountByProviderError", "Error counting news by provider", err)
+				return
+			}
+			for _, a := range anomalies {
+				kind := watchdogAlertVolume + watchdogAlert(":"+a.provider)
+				add(kind, formatVolumeAlert(a, j.options.volumeWindow))
+			}
+		}
+
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "successful",
+			Message:  fmt.Sprintf("Watchdog found %d alerts", len(alerts)),
+			Level:    sentry.LevelInfo,
+		}, nil)
+
+		m := strings.TrimSpace(strings.Join(alerts, "\n"))
+		if m == "" {
+			return
+		}
+
+		span = tx.StartChild("TelegramPublisher.Publish")
+		_, err = j.publisher.Publish(publisher.Markdown(fmt.Sprintf("🚨 #watchdog\n%s", m)))
+		span.Finish()
+		if err != nil {
+			// Alerts are not muted if they were not delivered, so the next run reports them again
+			j.release(kinds, now)
+			_ = r.Fail("watchdogJobPublishError", "Error publishing alert", err)
+			return
+		}
+	})
+}
+
+// alert returns the message for the given alert kind if it wasn't sent during the silence period.
+// The kind is muted for the silence period from now, use release if the alert wasn't published.
+func (j *WatchdogJob) alert(kind watchdogAlert, now time.Time, message string) string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if last, ok := j.lastAlert[kind]; ok && now.Sub(last) < j.options.silencePeriod {
+		return ""
+	}
+
+	j.lastAlert[kind] = now
+	return message
+}
+
+// release unmutes the alert kinds muted by alert at the given time (not by the later runs).
+func (j *WatchdogJob) release(kinds []watchdogAlert, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, kind := range kinds {
+		if j.lastAlert[kind].Equal(now) {
+			delete(j.lastAlert, kind)
+		}
+	}
+}
+
+// marketOpenedAt returns the market open time for the given day.
+func (j *WatchdogJob) marketOpenedAt(now time.Time) time.Time {
+	return now.Truncate(24 * time.Hour).Add(j.options.marketOpen)
+}
+
+// isMarketHours checks if the given time is within the market hours on a weekday.
+func isMarketHours(t time.Time, openAt, closeAt time.Duration) bool {
+	t = t.UTC()
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+
+	sinceMidnight := t.Sub(t.Truncate(24 * time.Hour))
+	return sinceMidnight >= openAt && sinceMidnight < closeAt
+}
+
+func formatSilenceAlert(last *archivist.News, now time.Time) string {
+	if last == nil {
+		return "No news have been published yet during market hours."
+	}
+
+	return fmt.Sprintf(
+		"No news have been published for %s (last at %s UTC).",
+		now.Sub(last.PublishedAt).Truncate(time.Minute),
+		last.PublishedAt.UTC().Format("15:04"),
+	)
+}
+
+func formatFilterRateAlert(stats *archivist.NewsStats, window time.Duration) string {
+	return fmt.Sprintf(
+		"%.0f%% of news were filtered out in the last %s (%d of %d).",
+		stats.FilterRate()*100,
+		window,
+		stats.Filtered,
+		stats.Total,
+	)
+}
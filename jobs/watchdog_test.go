@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/archivist"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_isMarketHours(t *testing.T) {
+	openAt := 14*time.Hour + 30*time.Minute
+	closeAt := 21 * time.Hour
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{
+			name: "weekday during market hours",
+			t:    time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC), // Wednesday
+			want: true,
+		},
+		{
+			name: "weekday right at market open",
+			t:    time.Date(2024, time.March, 13, 14, 30, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "weekday before market open",
+			t:    time.Date(2024, time.March, 13, 14, 29, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "weekday right at market close",
+			t:    time.Date(2024, time.March, 13, 21, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "weekend during market hours",
+			t:    time.Date(2024, time.March, 16, 15, 0, 0, 0, time.UTC), // Saturday
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMarketHours(tt.t, openAt, closeAt); got != tt.want {
+				t.Errorf("isMarketHours() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchdogJob_alert(t *testing.T) {
+	j := NewWatchdogJob(nil, nil).AlertOnSilence(time.Hour)
+	now := time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC)
+
+	if got := j.alert(watchdogAlertSilence, now, "msg"); got != "msg" {
+		t.Errorf("alert() first call = %v, want %v", got, "msg")
+	}
+	if got := j.alert(watchdogAlertSilence, now.Add(30*time.Minute), "msg"); got != "" {
+		t.Errorf("alert() repeated call = %v, want empty", got)
+	}
+	if got := j.alert(watchdogAlertFilterRate, now.Add(30*time.Minute), "rate"); got != "rate" {
+		t.Errorf("alert() other kind = %v, want %v", got, "rate")
+	}
+	if got := j.alert(watchdogAlertSilence, now.Add(time.Hour), "msg"); got != "msg" {
+		t.Errorf("alert() after silence period = %v, want %v", got, "msg")
+	}
+
+	// Alerts that were not published are sent again
+	later := now.Add(2 * time.Hour)
+	if got := j.alert(watchdogAlertFilterRate, later, "rate"); got != "rate" {
+		t.Fatalf("alert() = %v, want %v", got, "rate")
+	}
+	j.release([]watchdogAlert{watchdogAlertFilterRate}, later)
+	if got := j.alert(watchdogAlertFilterRate, later.Add(time.Minute), "rate"); got != "rate" {
+		t.Errorf("alert() after release = %v, want %v", got, "rate")
+	}
+
+	// Overlapping runs send the alert once
+	var sent atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if j.alert(watchdogAlertVolume, now, "volume") != "" {
+				sent.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if sent.Load() != 1 {
+		t.Errorf("alert() concurrent calls sent %d alerts, want 1", sent.Load())
+	}
+}
+
+func Test_formatSilenceAlert(t *testing.T) {
+	now := time.Date(2024, time.March, 13, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		last *archivist.News
+		want string
+	}{
+		{
+			name: "nothing published",
+			last: nil,
+			want: "No news have been published yet during market hours.",
+		},
+		{
+			name: "published long ago",
+			last: &archivist.News{PublishedAt: time.Date(2024, time.March, 13, 15, 30, 0, 0, time.UTC)},
+			want: "No news have been published for 2h30m0s (last at 15:30 UTC).",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSilenceAlert(tt.last, now); got != tt.want {
+				t.Errorf("formatSilenceAlert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatFilterRateAlert(t *testing.T) {
+	stats := &archivist.NewsStats{Total: 20, Filtered: 19, Published: 1}
+	want := "95% of news were filtered out in the last 2h0m0s (19 of 20)."
+	if got := formatFilterRateAlert(stats, 2*time.Hour); got != want {
+		t.Errorf("formatFilterRateAlert() = %v, want %v", got, want)
+	}
+}
+
+func Test_detectVolumeAnomalies(t *testing.T) {
+	history := []map[string]int64{
+		{"reuters": 10, "bloomberg": 2, "yahoo": 5},
+		{"reuters": 12, "bloomberg": 2, "yahoo": 7},
+	}
+
+	tests := []struct {
+		name    string
+		current map[string]int64
+		history []map[string]int64
+		want    []volumeAnomaly
+	}{
+		{
+			name:    "usual volume",
+			current: map[string]int64{"reuters": 11, "bloomberg": 1, "yahoo": 6},
+			history: history,
+			want:    nil,
+		},
+		{
+			name:    "silent provider",
+			current: map[string]int64{"yahoo": 6},
+			history: history,
+			want:    []volumeAnomaly{{provider: "reuters", count: 0, average: 11}},
+		},
+		{
+			name:    "spike",
+			current: map[string]int64{"reuters": 11, "bloomberg": 9, "yahoo": 30},
+			history: history,
+			want: []volumeAnomaly{
+				{provider: "bloomberg", count: 9, average: 2},
+				{provider: "yahoo", count: 30, average: 6},
+			},
+		},
+		{
+			name:    "no history",
+			current: map[string]int64{"reuters": 100},
+			history: nil,
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectVolumeAnomalies(tt.current, tt.history, 4, 3)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectVolumeAnomalies() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatVolumeAlert(t *testing.T) {
+	tests := []struct {
+		name    string
+		anomaly volumeAnomaly
+		want    string
+	}{
+		{
+			name:    "silent",
+			anomaly: volumeAnomaly{provider: "reuters", count: 0, average: 12.3},
+			want:    "reuters sent 0 news in the last 1h0m0s, usually 12.3 (silent).",
+		},
+		{
+			name:    "spike",
+			anomaly: volumeAnomaly{provider: "yahoo", count: 30, average: 6},
+			want:    "yahoo sent 30 news in the last 1h0m0s, usually 6.0 (spike ×5.0).",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatVolumeAlert(tt.anomaly, time.Hour); got != tt.want {
+				t.Errorf("formatVolumeAlert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// watchlist holds tickers whose news bypass the stricter filters (AI filter, empty meta omission)
+// and get a distinctive format. Matchers are used to find the tickers in the original news text.
+type watchlist map[string]*regexp.Regexp
+
+// newWatchlist creates a watchlist from the tickers (case-insensitive, empty values are skipped).
+func newWatchlist(tickers []string) watchlist {
+	w := make(watchlist, len(tickers))
+	for _, t := range tickers {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		// Tickers are matched case-sensitive as whole words to avoid matching common words (e.g. "ON", "ALL")
+		w[t] = regexp.MustCompile(fmt.Sprintf(`(^|[^\w.])\$?%s($|[^\w])`, regexp.QuoteMeta(t)))
+	}
+	return w
+}
+
+// mentionedIn returns true if the text mentions at least one ticker from the watchlist.
+func (w watchlist) mentionedIn(text string) bool {
+	for _, re := range w {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains returns true if at least one of the tickers is in the watchlist.
+func (w watchlist) contains(tickers []string) bool {
+	for _, t := range tickers {
+		if _, ok := w[strings.ToUpper(t)]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import "testing"
+
+func Test_watchlist_mentionedIn(t *testing.T) {
+	w := newWatchlist([]string{"nvda", " ON ", "BRK.B", ""})
+
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "ticker as a word", text: "NVDA shares jump after earnings", want: true},
+		{name: "cashtag", text: "Analysts upgrade $NVDA to buy", want: true},
+		{name: "ticker in parentheses", text: "Berkshire Hathaway (BRK.B) buys more", want: true},
+		{name: "lowercase common word", text: "Stocks move on inflation data", want: false},
+		{name: "part of another word", text: "NVDAX fund rebalances", want: false},
+		{name: "no tickers", text: "Fed keeps rates unchanged", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.mentionedIn(tt.text); got != tt.want {
+				t.Errorf("mentionedIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_watchlist_contains(t *testing.T) {
+	w := newWatchlist([]string{"NVDA", "TSLA"})
+
+	if !w.contains([]string{"AAPL", "tsla"}) {
+		t.Errorf("contains() should find TSLA")
+	}
+	if w.contains([]string{"AAPL"}) {
+		t.Errorf("contains() should not find AAPL")
+	}
+	if newWatchlist(nil).contains([]string{"AAPL"}) {
+		t.Errorf("empty watchlist should not contain anything")
+	}
+}
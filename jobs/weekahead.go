@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/publisher"
+	"github.com/samgozman/fin-thread/scavenger/corpcal"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// WeekAheadJob publishes the preview of the next week: high-impact economic events,
+// the largest companies earnings and upcoming IPOs in one post. It should be run on Sunday.
+type WeekAheadJob struct {
+	calendar      *ecal.EconomicCalendar         // economic calendar (optional, the section is skipped if nil)
+	corporate     *corpcal.CorporateCalendar     // earnings and IPOs calendar (optional, the sections are skipped if nil)
+	publisher     publisher.Publisher            // publisher that will publish the preview to the channel
+	logger        *slog.Logger                   // special logger for the job
+	countries     []ecal.EconomicCalendarCountry // countries of the economic events (all if empty)
+	earningsLimit int                            // max number of the largest companies reporting per day
+}
+
+// NewWeekAheadJob creates a new WeekAheadJob instance with up to 5 earnings per day.
+func NewWeekAheadJob(
+	calendar *ecal.EconomicCalendar,
+	corporate *corpcal.CorporateCalendar,
+	publisher publisher.Publisher,
+) *WeekAheadJob {
+	return &WeekAheadJob{
+		calendar:      calendar,
+		corporate:     corporate,
+		publisher:     publisher,
+		logger:        slog.Default(),
+		earningsLimit: 5,
+	}
+}
+
+// OnlyCountries sets the countries which economic events will be included in the preview (all if empty).
+func (j *WeekAheadJob) OnlyCountries(countries ...ecal.EconomicCalendarCountry) *WeekAheadJob {
+	j.countries = countries
+	return j
+}
+
+// weekAhead holds the data of all sections of the week preview.
+type weekAhead struct {
+	from, to time.Time
+	events   ecal.EconomicCalendarEvents
+	earnings []*corpcal.Earnings
+	ipos     []*corpcal.IPO
+}
+
+// Run return job function that will be executed by the scheduler.
+func (j *WeekAheadJob) Run() JobFunc {
+	return WithInstrumentationTimeout("week-ahead", 60*time.Second, func(ctx context.Context, r *JobRun) {
+		tx := r.Tx
+		r.SetChannel(j.publisher.Channel())
+
+		from, to := nextWeek(time.Now().UTC())
+		week := &weekAhead{from: from, to: to}
+
+		// Each section is optional, so the preview is published even if some sources failed
+		if j.calendar != nil {
+			span := tx.StartChild("EconomicCalendar.Fetch")
+			events, err := j.calendar.Fetch(ctx, from, to)
+			span.Finish()
+			if err != nil {
+				r.Warn("weekAheadJobFetchEventsError", "Error fetching economic events", err)
+			}
+			week.events = events.FilterByCountries(j.countries)
+		}
+
+		if j.corporate != nil {
+			span := tx.StartChild("CorporateCalendar.FetchEarnings")
+			earnings, err := j.corporate.FetchEarnings(ctx, from, to)
+			span.Finish()
+			if err != nil {
+				r.Warn("weekAheadJobFetchEarningsError", "Error fetching earnings", err)
+			}
+			week.earnings = largestEarnings(earnings, j.earningsLimit)
+
+			span = tx.StartChild("CorporateCalendar.FetchIPOs")
+			ipos, err := j.corporate.FetchIPOs(ctx, from, to)
+			span.Finish()
+			if err != nil {
+				r.Warn("weekAheadJobFetchIPOsError", "Error fetching IPOs", err)
+			}
+			week.ipos = ipos
+		}
+
+		m := formatWeekAhead(week)
+		if m == "" {
+			r.Success("Nothing to publish in the week ahead preview")
+			return
+		}
+
+		span := tx.StartChild("TelegramPublisher.Publish")
+		_, err := j.publisher.Publish(publisher.Markdown(m))
+		span.Finish()
+		if err != nil {
+			_ = r.Fail("weekAheadJobPublishError", "Error publishing preview", err)
+			return
+		}
+
+		r.Success("Week ahead published with %d events, %d earnings and %d IPOs", len(week.events), len(week.earnings), len(week.ipos))
+	})
+}
+
+// nextWeek returns the start of the next Monday and the end of the following Friday.
+func nextWeek(now time.Time) (from, to time.Time) {
+	days := (int(time.Monday) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+
+	from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+	to = from.AddDate(0, 0, 5).Add(-time.Second)
+
+	return from, to
+}
+
+// largestEarnings keeps up to limit earnings of the largest companies for each day (earnings must be sorted by date).
+func largestEarnings(earnings []*corpcal.Earnings, limit int) []*corpcal.Earnings {
+	var result []*corpcal.Earnings
+	perDay := make(map[time.Time]int)
+	for _, e := range earnings {
+		if perDay[e.Date] >= limit {
+			continue
+		}
+		perDay[e.Date]++
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// formatWeekAhead formats the week preview with the economic calendar, earnings and IPOs sections.
+// Empty sections are skipped, empty string is returned if all sections are empty.
+func formatWeekAhead(w *weekAhead) string {
+	var sections []string
+
+	var events strings.Builder
+	for _, e := range w.events {
+		if e.Impact != ecal.EconomicCalendarImpactHigh {
+			continue
+		}
+		events.WriteString(fmt.Sprintf("%s %s ", e.DateTime.Format("Mon"), ecal.GetCountryEmoji(e.Country)))
+		if !e.AllDay && !e.Tentative {
+			events.WriteString(e.DateTime.Format("15:04") + " ")
+		}
+		events.WriteString(e.Title)
+		if e.Forecast != "" {
+			events.WriteString(fmt.Sprintf(", forecast: %s", e.Forecast))
+		}
+		events.WriteString("\n")
+	}
+	if events.Len() > 0 {
+		sections = append(sections, "📅 *Economic calendar*\n"+events.String())
+	}
+
+	var earnings strings.Builder
+	for i, e := range w.earnings {
+		if i == 0 || !e.Date.Equal(w.earnings[i-1].Date) {
+			if i > 0 {
+				earnings.WriteString("\n")
+			}
+			earnings.WriteString(e.Date.Format("Mon") + ": ")
+		} else {
+			earnings.WriteString(", ")
+		}
+		earnings.WriteString(e.Ticker)
+		if e.Time != corpcal.EarningsNotSupplied {
+			earnings.WriteString(fmt.Sprintf(" (%s)", e.Time))
+		}
+	}
+	if earnings.Len() > 0 {
+		sections = append(sections, "💼 *Earnings*\n"+earnings.String()+"\n")
+	}
+
+	var ipos strings.Builder
+	for _, ipo := range w.ipos {
+		ipos.WriteString(fmt.Sprintf("%s: %s (%s, %s)", ipo.Date.Format("Mon"), ipo.Ticker, ipo.Name, ipo.Exchange))
+		if ipo.PriceRange != "" {
+			ipos.WriteString(fmt.Sprintf(", $%s", ipo.PriceRange))
+		}
+		ipos.WriteString("\n")
+	}
+	if ipos.Len() > 0 {
+		sections = append(sections, "🚀 *IPOs*\n"+ipos.String())
+	}
+
+	if len(sections) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("🗓 Week ahead: %s – %s\n\n%s\n*Time is in UTC*\n#weekahead #calendar",
+		w.from.Format("Jan 2"), w.to.Format("Jan 2"), strings.Join(sections, "\n"))
+}
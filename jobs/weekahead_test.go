@@ -0,0 +1,131 @@
+package jobs
+
+import (
+	"github.com/samgozman/fin-thread/scavenger/corpcal"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_nextWeek(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "sunday", now: time.Date(2024, 1, 14, 18, 0, 0, 0, time.UTC), want: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "monday", now: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), want: time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)},
+		{name: "friday", now: time.Date(2024, 1, 19, 9, 0, 0, 0, time.UTC), want: time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := nextWeek(tt.now)
+			if !from.Equal(tt.want) {
+				t.Errorf("nextWeek() from = %v, want %v", from, tt.want)
+			}
+			if wantTo := tt.want.AddDate(0, 0, 5).Add(-time.Second); !to.Equal(wantTo) {
+				t.Errorf("nextWeek() to = %v, want %v", to, wantTo)
+			}
+		})
+	}
+}
+
+func Test_largestEarnings(t *testing.T) {
+	mon := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	tue := mon.AddDate(0, 0, 1)
+	earnings := []*corpcal.Earnings{
+		{Date: mon, Ticker: "AAPL"},
+		{Date: mon, Ticker: "MSFT"},
+		{Date: mon, Ticker: "TINY"},
+		{Date: tue, Ticker: "NVDA"},
+	}
+
+	want := []*corpcal.Earnings{earnings[0], earnings[1], earnings[3]}
+	if got := largestEarnings(earnings, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("largestEarnings() = %v, want %v", got, want)
+	}
+}
+
+func Test_formatWeekAhead(t *testing.T) {
+	mon := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	week := func() *weekAhead {
+		return &weekAhead{from: mon, to: mon.AddDate(0, 0, 5).Add(-time.Second)}
+	}
+
+	full := week()
+	full.events = ecal.EconomicCalendarEvents{
+		{
+			DateTime: mon.Add(13*time.Hour + 30*time.Minute),
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Impact:   ecal.EconomicCalendarImpactHigh,
+			Title:    "CPI y/y",
+			Forecast: "3.2%",
+		},
+		{
+			DateTime: mon.AddDate(0, 0, 1),
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Impact:   ecal.EconomicCalendarImpactLow,
+			Title:    "Redbook",
+		},
+		{
+			DateTime: mon.AddDate(0, 0, 2),
+			Country:  ecal.EconomicCalendarUnitedStates,
+			Impact:   ecal.EconomicCalendarImpactHigh,
+			Title:    "Fed Chair Speech",
+			AllDay:   true,
+		},
+	}
+	full.earnings = []*corpcal.Earnings{
+		{Date: mon, Ticker: "AAPL", Time: corpcal.EarningsAfterClose},
+		{Date: mon, Ticker: "JPM", Time: corpcal.EarningsBeforeOpen},
+		{Date: mon.AddDate(0, 0, 3), Ticker: "NFLX"},
+	}
+	full.ipos = []*corpcal.IPO{
+		{Date: mon.AddDate(0, 0, 2), Ticker: "ABC", Name: "ABC Inc", Exchange: "NASDAQ Global", PriceRange: "10.00-12.00"},
+	}
+
+	onlyIPOs := week()
+	onlyIPOs.ipos = []*corpcal.IPO{{Date: mon, Ticker: "ABC", Name: "ABC Inc", Exchange: "NYSE"}}
+
+	tests := []struct {
+		name string
+		week *weekAhead
+		want string
+	}{
+		{
+			name: "all sections",
+			week: full,
+			want: "🗓 Week ahead: Jan 15 – Jan 19\n\n" +
+				"📅 *Economic calendar*\n" +
+				"Mon 🇺🇸 13:30 CPI y/y, forecast: 3.2%\n" +
+				"Wed 🇺🇸 Fed Chair Speech\n" +
+				"\n💼 *Earnings*\n" +
+				"Mon: AAPL (after close), JPM (before open)\n" +
+				"Thu: NFLX\n" +
+				"\n🚀 *IPOs*\n" +
+				"Wed: ABC (ABC Inc, NASDAQ Global), $10.00-12.00\n" +
+				"\n*Time is in UTC*\n#weekahead #calendar",
+		},
+		{
+			name: "only IPOs",
+			week: onlyIPOs,
+			want: "🗓 Week ahead: Jan 15 – Jan 19\n\n" +
+				"🚀 *IPOs*\n" +
+				"Mon: ABC (ABC Inc, NYSE)\n" +
+				"\n*Time is in UTC*\n#weekahead #calendar",
+		},
+		{
+			name: "empty",
+			week: week(),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatWeekAhead(tt.week); got != tt.want {
+				t.Errorf("formatWeekAhead() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
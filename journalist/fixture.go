@@ -0,0 +1,109 @@
+package journalist
+
+import (
+	"context"
+	"github.com/mmcdole/gofeed"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FixtureProvider replays the recorded RSS feed file at accelerated speed.
+// It is used in the sandbox mode to run the whole pipeline locally without any credentials.
+//
+// The feed timeline starts at the date of the oldest item in the file and moves Speed times faster than real time.
+// Each replayed news gets the current date, so it looks fresh for the rest of the pipeline.
+type FixtureProvider struct {
+	Name      string  // Name is used for logging purposes
+	Path      string  // Path to the recorded RSS feed file
+	Speed     float64 // Speed multiplier of the feed timeline (e.g. 60 means 1 hour of the feed per minute)
+	mu        sync.Mutex
+	loaded    bool
+	items     NewsList         // items that are not replayed yet, sorted by date (ascending)
+	origin    time.Time        // date of the oldest item in the feed
+	startedAt time.Time        // time of the first Fetch call
+	now       func() time.Time // current time getter (for tests)
+}
+
+// NewFixtureProvider creates a new FixtureProvider instance.
+func NewFixtureProvider(name, path string, speed float64) *FixtureProvider {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	return &FixtureProvider{
+		Name:  name,
+		Path:  path,
+		Speed: speed,
+		now:   time.Now,
+	}
+}
+
+// Fetch returns the news which "were published" in the feed timeline since the last call (newest first).
+// The until date is ignored because replayed news always get the current date.
+func (f *FixtureProvider) Fetch(_ context.Context, _ time.Time) (NewsList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	if !f.loaded {
+		if err := f.load(); err != nil {
+			return nil, err
+		}
+		f.startedAt = now
+	}
+
+	elapsed := time.Duration(float64(now.Sub(f.startedAt)) * f.Speed)
+	virtualNow := f.origin.Add(elapsed)
+
+	var news NewsList
+	for len(f.items) > 0 && !f.items[0].Date.After(virtualNow) {
+		n := f.items[0]
+		f.items = f.items[1:]
+		n.Date = now.UTC()
+		news = append(NewsList{n}, news...)
+	}
+
+	return news, nil
+}
+
+// load parses the feed file and prepares items for the replay.
+func (f *FixtureProvider) load() error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return newError(errlvl.ERROR, err).WithProvider(f.Name)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	feed, err := gofeed.NewParser().Parse(file)
+	if err != nil {
+		return newError(errlvl.ERROR, err).WithProvider(f.Name)
+	}
+
+	for _, item := range feed.Items {
+		if item.Title == "" || item.Link == "" || item.Published == "" {
+			continue
+		}
+
+		n, err := newNews(item.Title, item.Description, item.Link, item.Published, f.Name)
+		if err != nil {
+			return newError(errlvl.INFO, err).WithProvider(f.Name)
+		}
+		n.GUID = item.GUID
+		f.items = append(f.items, n)
+	}
+
+	sort.SliceStable(f.items, func(i, j int) bool {
+		return f.items[i].Date.Before(f.items[j].Date)
+	})
+	if len(f.items) > 0 {
+		f.origin = f.items[0].Date
+	}
+	f.loaded = true
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package journalist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFixtureProvider_Fetch(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Test</title>
+    <item>
+      <title>First news</title>
+      <link>https://example.com/1</link>
+      <description>First description</description>
+      <pubDate>Wed, 15 Nov 2023 13:00:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Third news</title>
+      <link>https://example.com/3</link>
+      <description>Third description</description>
+      <pubDate>Wed, 15 Nov 2023 15:00:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Second news</title>
+      <link>https://example.com/2</link>
+      <description>Second description</description>
+      <pubDate>Wed, 15 Nov 2023 14:00:00 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := os.WriteFile(path, []byte(feed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.March, 13, 15, 0, 0, 0, time.UTC)
+	f := NewFixtureProvider("test", path, 60) // 1 hour of the feed per minute
+	f.now = func() time.Time { return now }
+
+	steps := []struct {
+		after time.Duration
+		want  []string
+	}{
+		{after: 0, want: []string{"First news"}},
+		{after: 30 * time.Second, want: nil},
+		{after: 30 * time.Second, want: []string{"Second news"}},
+		{after: 2 * time.Minute, want: []string{"Third news"}},
+		{after: time.Hour, want: nil},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		got, err := f.Fetch(context.Background(), time.Time{})
+		if err != nil {
+			t.Fatalf("step %d: FixtureProvider.Fetch() error = %v", i, err)
+		}
+		if len(got) != len(step.want) {
+			t.Fatalf("step %d: FixtureProvider.Fetch() len = %v, want %v", i, len(got), len(step.want))
+		}
+		for j, n := range got {
+			if n.Title != step.want[j] {
+				t.Errorf("step %d: FixtureProvider.Fetch() title = %v, want %v", i, n.Title, step.want[j])
+			}
+			if !n.Date.Equal(now) {
+				t.Errorf("step %d: FixtureProvider.Fetch() date = %v, want %v", i, n.Date, now)
+			}
+			if n.ProviderName != "test" {
+				t.Errorf("step %d: FixtureProvider.Fetch() provider = %v, want test", i, n.ProviderName)
+			}
+		}
+	}
+}
+
+func TestFixtureProvider_Fetch_missingFile(t *testing.T) {
+	f := NewFixtureProvider("test", filepath.Join(t.TempDir(), "missing.xml"), 1)
+	if _, err := f.Fetch(context.Background(), time.Time{}); err == nil {
+		t.Error("FixtureProvider.Fetch() expected error for missing file")
+	}
+}
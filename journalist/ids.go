@@ -0,0 +1,110 @@
+package journalist
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// IDStrategy is the strategy of the News.ID generation. News with the same ID are considered duplicates
+// both between the feeds and with the news saved before (see archivist.News.Hash).
+type IDStrategy string
+
+const (
+	IDByContent IDStrategy = "content" // md5 of the title + description (default)
+	IDByURL     IDStrategy = "url"     // md5 of the normalized link, the article keeps its ID after the title edits
+	IDByGUID    IDStrategy = "guid"    // md5 of the feed item GUID (of the link if the feed has no GUIDs)
+	IDBySimhash IDStrategy = "simhash" // simhash of the title + description words, near-identical texts usually get the same ID
+)
+
+// IDStrategies is the list of all supported ID strategies.
+var IDStrategies = []IDStrategy{IDByContent, IDByURL, IDByGUID, IDBySimhash}
+
+// Validate checks that the strategy is supported (empty strategy means IDByContent).
+func (s IDStrategy) Validate() error {
+	if s != "" && !slices.Contains(IDStrategies, s) {
+		return fmt.Errorf("unknown news ID strategy %q, expected one of %v", s, IDStrategies)
+	}
+	return nil
+}
+
+// NewsID returns the ID of the news by the strategy. Unknown strategy falls back to IDByContent.
+// The ID is at most 32 characters long to fit the archivist.News.Hash column.
+func (s IDStrategy) NewsID(title, description, link, guid string) string {
+	switch s {
+	case IDByURL:
+		return md5Hex(normalizeURL(link))
+	case IDByGUID:
+		if guid = strings.TrimSpace(guid); guid != "" {
+			return md5Hex(guid)
+		}
+		return md5Hex(normalizeURL(link))
+	case IDBySimhash:
+		return fmt.Sprintf("%016x", simhash(title+" "+description))
+	default:
+		return md5Hex(title + description)
+	}
+}
+
+func md5Hex(s string) string {
+	h := md5.Sum([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// normalizeURL removes the parts of the link that don't change the article: scheme, "www." prefix, fragment,
+// trailing slash and tracking (utm_*) query parameters. Invalid links are returned as is.
+func normalizeURL(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(link)
+	}
+
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(strings.ToLower(k), "utm_") {
+			q.Del(k)
+		}
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	if len(q) == 0 {
+		return host + path
+	}
+	return host + path + "?" + q.Encode()
+}
+
+// simhash returns the 64-bit simhash of the lower-cased words of the text.
+// Texts that differ in case, punctuation or a few words of many get the same or a close fingerprint.
+func simhash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var weights [64]int
+	for _, w := range words {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(w))
+		sum := h.Sum64()
+		for i := range weights {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for i, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << i
+		}
+	}
+	return fingerprint
+}
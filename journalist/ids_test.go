@@ -0,0 +1,107 @@
+package journalist
+
+import (
+	"testing"
+)
+
+func TestIDStrategy_NewsID(t *testing.T) {
+	type args struct {
+		title       string
+		description string
+		link        string
+		guid        string
+	}
+	tests := []struct {
+		name     string
+		strategy IDStrategy
+		a, b     args
+		wantSame bool
+	}{
+		{
+			name:     "content: same text from different links",
+			strategy: IDByContent,
+			a:        args{title: "Fed holds rates", description: "No changes", link: "https://a.com/1"},
+			b:        args{title: "Fed holds rates", description: "No changes", link: "https://b.com/2"},
+			wantSame: true,
+		},
+		{
+			name:     "content: edited title",
+			strategy: IDByContent,
+			a:        args{title: "Fed holds rates", link: "https://a.com/1"},
+			b:        args{title: "Fed holds rates steady", link: "https://a.com/1"},
+			wantSame: false,
+		},
+		{
+			name:     "url: edited title with tracking params",
+			strategy: IDByURL,
+			a:        args{title: "Fed holds rates", link: "https://www.a.com/news/1/?utm_source=rss#top"},
+			b:        args{title: "Fed holds rates steady", link: "http://a.com/news/1"},
+			wantSame: true,
+		},
+		{
+			name:     "url: different query",
+			strategy: IDByURL,
+			a:        args{link: "https://a.com/news?id=1"},
+			b:        args{link: "https://a.com/news?id=2"},
+			wantSame: false,
+		},
+		{
+			name:     "guid: same guid with different links",
+			strategy: IDByGUID,
+			a:        args{link: "https://a.com/1", guid: "urn:news:42"},
+			b:        args{link: "https://a.com/1?ref=home", guid: "urn:news:42"},
+			wantSame: true,
+		},
+		{
+			name:     "guid: falls back to link",
+			strategy: IDByGUID,
+			a:        args{link: "https://a.com/1"},
+			b:        args{link: "https://a.com/1/"},
+			wantSame: true,
+		},
+		{
+			name:     "simhash: case and punctuation",
+			strategy: IDBySimhash,
+			a:        args{title: "Fed holds rates steady", description: "Powell says inflation is still too high."},
+			b:        args{title: "FED HOLDS RATES STEADY!", description: "Powell says: inflation is still too high"},
+			wantSame: true,
+		},
+		{
+			name:     "simhash: different news",
+			strategy: IDBySimhash,
+			a:        args{title: "Fed holds rates steady", description: "Powell says inflation is still too high."},
+			b:        args{title: "Apple beats earnings", description: "iPhone sales grew in China."},
+			wantSame: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.strategy.NewsID(tt.a.title, tt.a.description, tt.a.link, tt.a.guid)
+			b := tt.strategy.NewsID(tt.b.title, tt.b.description, tt.b.link, tt.b.guid)
+			if (a == b) != tt.wantSame {
+				t.Errorf("NewsID() = %v and %v, want same %v", a, b, tt.wantSame)
+			}
+			if len(a) > 32 || a == "" {
+				t.Errorf("NewsID() = %v, want non-empty ID up to 32 characters", a)
+			}
+		})
+	}
+}
+
+func TestIDStrategy_Validate(t *testing.T) {
+	for _, s := range append(IDStrategies, "") {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", s, err)
+		}
+	}
+	if err := IDStrategy("sha256").Validate(); err == nil {
+		t.Error("Validate() want error for unknown strategy")
+	}
+}
+
+func TestIDByContent_compatibility(t *testing.T) {
+	// Default IDs must stay the same, otherwise all saved news will be published again
+	if got := IDByContent.NewsID("title", "description", "link", ""); got != "726de2ac36a252f781db6af19c3c8039" {
+		t.Errorf("NewsID() = %v", got)
+	}
+}
@@ -3,6 +3,7 @@ package journalist
 import (
 	"context"
 	"errors"
+	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"golang.org/x/sync/errgroup"
 	"sync"
@@ -13,8 +14,11 @@ import (
 type Journalist struct {
 	Name      string // Name of the journalist (for logging purposes)
 	providers []NewsProvider
-	flagKeys  []string // Keys that will "flag" the news as something that should be double-checked by human
-	limitNews int      // Limit the number of news to fetch from each provider
+	flagKeys  []string   // Keys that will "flag" the news as something that should be double-checked by human
+	limitNews int        // Limit the number of news to fetch from each provider
+	maxDesc   int        // Max length of the news description in characters (DescriptionMaxLength if 0)
+	idBy      IDStrategy // Strategy of the news ID generation (IDByContent if empty)
+	ratings   bool       // If true, analyst rating changes are parsed from the titles and exempt from the keyword flags
 }
 
 // NewJournalist creates a new Journalist instance.
@@ -26,17 +30,42 @@ func NewJournalist(name string, providers []NewsProvider) *Journalist {
 }
 
 // FlagByKeys sets the keys that will "flag" news that contain them by setting News.IsSuspicious to true.
+// Keys can be plain words or phrases, regular expressions in slashes (e.g. `/\d+ reasons/`)
+// or boolean expressions with AND, OR, NOT operators (e.g. `earnings AND (beat OR miss) NOT webinar`).
 func (j *Journalist) FlagByKeys(flagKeys []string) *Journalist {
 	j.flagKeys = flagKeys
 	return j
 }
 
+// DetectRatingChanges sets the flag that parses analyst rating changes (upgrades, downgrades, price targets)
+// from the news titles to News.RatingChange. Rating changes are exempt from the FlagByKeys flags (e.g. "analysts say"),
+// so the jobs decide whether to publish them.
+func (j *Journalist) DetectRatingChanges() *Journalist {
+	j.ratings = true
+	return j
+}
+
 // Limit sets the limit of news to fetch from each provider.
 func (j *Journalist) Limit(limit int) *Journalist {
 	j.limitNews = limit
 	return j
 }
 
+// TruncateDescriptions sets the max length of the news description in characters (DescriptionMaxLength by default).
+// Longer descriptions are truncated by the last word with "…", it also reduces the size of the AI prompts.
+func (j *Journalist) TruncateDescriptions(maxLength int) *Journalist {
+	j.maxDesc = maxLength
+	return j
+}
+
+// IdentifyBy sets the strategy of the news ID generation which is used to find duplicated news (IDByContent by default).
+// Note: news saved with another strategy are not recognized as duplicates, recompute their hashes with
+// archivist.NewsDB.RecomputeHashes after changing it.
+func (j *Journalist) IdentifyBy(strategy IDStrategy) *Journalist {
+	j.idBy = strategy
+	return j
+}
+
 // GetLatestNews fetches the latest news (until date) from all providers and merges them into unified list.
 func (j *Journalist) GetLatestNews(ctx context.Context, until time.Time) (NewsList, error) {
 	// Manage goroutines and errors
@@ -94,8 +123,27 @@ func (j *Journalist) GetLatestNews(ctx context.Context, until time.Time) (NewsLi
 		return nil, newError(errlvl.ERROR, errFetchingNews, err)
 	}
 
+	if j.idBy != "" && j.idBy != IDByContent {
+		for _, n := range results {
+			n.ID = j.idBy.NewsID(n.Title, n.Description, n.Link, n.GUID)
+		}
+	}
+
 	results = results.mapIDs()
 
+	// News are created with the default description limit, so only stricter limits are applied
+	if j.maxDesc > 0 && j.maxDesc < DescriptionMaxLength {
+		for _, n := range results {
+			n.Description = utils.Truncate(n.Description, j.maxDesc)
+		}
+	}
+
+	if j.ratings {
+		for _, n := range results {
+			n.RatingChange = ParseRatingChange(n.Title)
+		}
+	}
+
 	if len(j.flagKeys) > 0 {
 		results.flagByKeywords(j.flagKeys)
 	}
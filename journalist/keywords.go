@@ -0,0 +1,272 @@
+package journalist
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// keywordMatcher matches the lower-cased news text ("title description").
+type keywordMatcher interface {
+	match(text string) bool
+}
+
+// parseKeyword parses the keyword spec used by Journalist.FlagByKeys:
+//   - plain keyword or phrase: `sign up` matches whole words case-insensitive (symbols only keywords like `?` match anywhere);
+//   - regular expression in slashes: `/\d+% (gain|loss)/` (case-insensitive);
+//   - boolean expression of the above with AND, OR, NOT operators (upper case), parentheses and quoted phrases:
+//     `earnings AND (beat OR miss) NOT webinar`. Operands without operator between them are joined with AND.
+func parseKeyword(spec string) (keywordMatcher, error) {
+	tokens, err := tokenizeKeyword(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty keyword")
+	}
+
+	p := &keywordParser{tokens: tokens}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("keyword %q: %w", spec, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("keyword %q: unexpected %q", spec, p.tokens[p.pos].text)
+	}
+
+	return m, nil
+}
+
+// newKeywordMatchers parses the keyword specs. Invalid specs (e.g. broken regexp) are matched as plain phrases.
+func newKeywordMatchers(keywords []string) []keywordMatcher {
+	matchers := make([]keywordMatcher, 0, len(keywords))
+	for _, k := range keywords {
+		m, err := parseKeyword(k)
+		if err != nil {
+			m = newPhraseMatcher(k)
+		}
+		matchers = append(matchers, m)
+	}
+
+	return matchers
+}
+
+// matchAny returns true if any of the matchers matches the news title or description.
+func (n *News) matchAny(matchers []keywordMatcher) bool {
+	s := strings.ToLower(fmt.Sprintf("%s %s", n.Title, n.Description))
+	for _, m := range matchers {
+		if m.match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+type keywordTokenKind int
+
+const (
+	tokenWord keywordTokenKind = iota
+	tokenPhrase
+	tokenRegexp
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type keywordToken struct {
+	kind keywordTokenKind
+	text string
+}
+
+// tokenizeKeyword splits the keyword spec into words, quoted phrases, regexps, operators and parentheses.
+func tokenizeKeyword(spec string) ([]keywordToken, error) {
+	var tokens []keywordToken
+	rs := []rune(spec)
+	for i := 0; i < len(rs); {
+		switch r := rs[i]; {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(':
+			tokens = append(tokens, keywordToken{kind: tokenOpen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, keywordToken{kind: tokenClose, text: ")"})
+			i++
+		case r == '"' || r == '/':
+			end := i + 1
+			for end < len(rs) && (rs[end] != r || rs[end-1] == '\\') {
+				end++
+			}
+			if end == len(rs) {
+				return nil, fmt.Errorf("unclosed %c in %q", r, spec)
+			}
+			kind := tokenPhrase
+			if r == '/' {
+				kind = tokenRegexp
+			}
+			tokens = append(tokens, keywordToken{kind: kind, text: string(rs[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(rs) && !strings.ContainsRune(" \t\n()\"", rs[end]) {
+				end++
+			}
+			word := string(rs[i:end])
+			switch word {
+			case "AND":
+				tokens = append(tokens, keywordToken{kind: tokenAnd, text: word})
+			case "OR":
+				tokens = append(tokens, keywordToken{kind: tokenOr, text: word})
+			case "NOT":
+				tokens = append(tokens, keywordToken{kind: tokenNot, text: word})
+			default:
+				tokens = append(tokens, keywordToken{kind: tokenWord, text: word})
+			}
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// keywordParser is the recursive descent parser of the keyword boolean expression:
+//
+//	or      = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = "NOT" unary | primary
+//	primary = "(" or ")" | word { word } | "phrase" | /regexp/
+type keywordParser struct {
+	tokens []keywordToken
+	pos    int
+}
+
+func (p *keywordParser) peek() (keywordToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return keywordToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *keywordParser) parseOr() (keywordMatcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for t, ok := p.peek(); ok && t.kind == tokenOr; t, ok = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orMatcher{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *keywordParser) parseAnd() (keywordMatcher, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for t, ok := p.peek(); ok && t.kind != tokenOr && t.kind != tokenClose; t, ok = p.peek() {
+		if t.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andMatcher{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *keywordParser) parseUnary() (keywordMatcher, error) {
+	if t, ok := p.peek(); ok && t.kind == tokenNot {
+		p.pos++
+		m, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notMatcher{m}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *keywordParser) parsePrimary() (keywordMatcher, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	switch t.kind {
+	case tokenOpen:
+		p.pos++
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != tokenClose {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return m, nil
+	case tokenWord:
+		words := []string{t.text}
+		for p.pos++; p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord; p.pos++ {
+			words = append(words, p.tokens[p.pos].text)
+		}
+		return newPhraseMatcher(strings.Join(words, " ")), nil
+	case tokenPhrase:
+		p.pos++
+		return newPhraseMatcher(t.text), nil
+	case tokenRegexp:
+		p.pos++
+		re, err := regexp.Compile("(?i)" + t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp: %w", err)
+		}
+		return regexpMatcher{re}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+}
+
+// regexpMatcher matches the text with the regular expression.
+type regexpMatcher struct{ re *regexp.Regexp }
+
+func (m regexpMatcher) match(text string) bool { return m.re.MatchString(text) }
+
+// symbolsMatcherRe matches the keywords with symbols only (for flagging by symbols feature).
+var symbolsMatcherRe = regexp.MustCompile("^[^a-zA-Z0-9]*$")
+
+// newPhraseMatcher creates the case-insensitive matcher of the phrase as whole words.
+func newPhraseMatcher(phrase string) keywordMatcher {
+	pattern := strings.ToLower(regexp.QuoteMeta(phrase))
+	// Don't add word boundaries if the keyword contains only symbols
+	if !symbolsMatcherRe.MatchString(phrase) {
+		pattern = fmt.Sprintf("\\b%s\\b", pattern)
+	}
+
+	return regexpMatcher{regexp.MustCompile(pattern)}
+}
+
+type andMatcher struct{ left, right keywordMatcher }
+
+func (m andMatcher) match(text string) bool { return m.left.match(text) && m.right.match(text) }
+
+type orMatcher struct{ left, right keywordMatcher }
+
+func (m orMatcher) match(text string) bool { return m.left.match(text) || m.right.match(text) }
+
+type notMatcher struct{ m keywordMatcher }
+
+func (m notMatcher) match(text string) bool { return !m.m.match(text) }
@@ -0,0 +1,57 @@
+package journalist
+
+import "testing"
+
+func Test_parseKeyword(t *testing.T) {
+	text := "acme earnings beat estimates, join our webinar? revenue up 12%"
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "plain keyword", spec: "Earnings", want: true},
+		{name: "plain phrase", spec: "earnings beat", want: true},
+		{name: "part of the word", spec: "earn", want: false},
+		{name: "symbol", spec: "?", want: true},
+		{name: "regexp", spec: `/up \d+%/`, want: true},
+		{name: "regexp no match", spec: `/down \d+%/`, want: false},
+		{name: "and", spec: "earnings AND revenue", want: true},
+		{name: "or", spec: "guidance OR revenue", want: true},
+		{name: "not", spec: "NOT webinar", want: false},
+		{name: "nested", spec: "earnings AND (beat OR miss) NOT webinar", want: false},
+		{name: "nested without not", spec: "earnings AND (beat OR miss) NOT podcast", want: true},
+		{name: "quoted phrase", spec: `"beat estimates" AND NOT "missed estimates"`, want: true},
+		{name: "lower case operators are words", spec: "earnings and revenue", want: false},
+		{name: "empty", spec: " ", wantErr: true},
+		{name: "unclosed parenthesis", spec: "(earnings OR revenue", wantErr: true},
+		{name: "unclosed quote", spec: `"earnings`, wantErr: true},
+		{name: "dangling operator", spec: "earnings AND", wantErr: true},
+		{name: "invalid regexp", spec: "/up (/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseKeyword(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseKeyword() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got := m.match(text); got != tt.want {
+				t.Errorf("parseKeyword().match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newKeywordMatchers(t *testing.T) {
+	// Invalid specs are matched as plain phrases
+	matchers := newKeywordMatchers([]string{"AND"})
+	n := &News{Title: "Tom and Jerry"}
+	if !n.matchAny(matchers) {
+		t.Errorf("matchAny() should match the invalid spec as a plain phrase")
+	}
+}
@@ -1,28 +1,37 @@
 package journalist
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"html"
-	"regexp"
-	"strings"
 	"time"
 )
 
+// DescriptionMaxLength is the default maximum length of News.Description in characters.
+// Longer descriptions are truncated by the last word with "…".
+const DescriptionMaxLength = 1024
+
 type News struct {
-	ID           string    // ID is the md5 hash of title + description
+	ID           string    // ID is the md5 hash of title + description (see IDStrategy for other options)
 	Title        string    // Title is the title of the news
 	Description  string    // Description is the description of the news
 	Link         string    // Link is the link to the news
+	GUID         string    // GUID is the unique identifier of the item in the feed (optional)
 	Date         time.Time // Date is the date of the news
 	ProviderName string    // ProviderName is the Name of the provider that fetched the news
 	IsSuspicious bool      // IsSuspicious is true if the news contains keywords that should be checked by human before publishing
 	IsFiltered   bool      // IsFiltered is true if the news was filtered out by others service (e.g. Composer.Filter)
+	// FilteredReason is the reason code why the news was filtered out (e.g. "clickbait"), empty if not filtered
+	FilteredReason string
+	// WouldFilter is true if the shadow filter would filter the news out (not enforced, see jobs.Job.ShadowFilter)
+	WouldFilter bool
+	// WouldFilterReason is the reason code of the shadow filter decision, empty if WouldFilter is false
+	WouldFilterReason string
+	// RatingChange is the analyst rating change parsed from the title (see Journalist.DetectRatingChanges), nil if none
+	RatingChange *RatingChange
 	// TODO: Add creator field if possible
 }
 
@@ -49,14 +58,10 @@ func newNews(title, description, link, date, provider string) (*News, error) {
 	title = utils.ReplaceUnicodeSymbols(title)
 	description = utils.ReplaceUnicodeSymbols(description)
 
-	if len(description) > 1024 {
-		description = description[:1024]
-	}
-
-	hash := md5.Sum([]byte(title + description))
+	description = utils.Truncate(description, DescriptionMaxLength)
 
 	return &News{
-		ID:           hex.EncodeToString(hash[:]),
+		ID:           IDByContent.NewsID(title, description, link, ""),
 		Title:        title,
 		Description:  description,
 		Link:         link,
@@ -66,27 +71,9 @@ func newNews(title, description, link, date, provider string) (*News, error) {
 	}, nil
 }
 
+// contains returns true if the news matches at least one of the keyword specs (see parseKeyword).
 func (n *News) contains(keywords []string) bool {
-	symbolsMatcherRe := regexp.MustCompile("^[^a-zA-Z0-9]*$")
-
-	for _, k := range keywords {
-		ke := strings.ToLower(regexp.QuoteMeta(k))
-
-		var pattern string
-		// Check that the keyword contains only symbols (for lagging by symbols feature)
-		if symbolsMatcherRe.MatchString(k) {
-			pattern = ke // Don't add word boundaries if the keyword contains only symbols
-		} else {
-			pattern = fmt.Sprintf("\\b%s\\b", ke)
-		}
-
-		s := strings.ToLower(fmt.Sprintf("%s %s", n.Title, n.Description))
-		match, _ := regexp.MatchString(pattern, s)
-		if match {
-			return true
-		}
-	}
-	return false
+	return n.matchAny(newKeywordMatchers(keywords))
 }
 
 type NewsList []*News
@@ -129,9 +116,10 @@ func (n NewsList) RemoveFlagged() NewsList {
 
 // filterByKeywords returns only a list of news that contains at least one of the keywords.
 func (n NewsList) filterByKeywords(keywords []string) NewsList {
+	matchers := newKeywordMatchers(keywords)
 	var filteredNews NewsList
 	for _, n := range n {
-		if n.contains(keywords) {
+		if n.matchAny(matchers) {
 			filteredNews = append(filteredNews, n)
 		}
 	}
@@ -140,9 +128,11 @@ func (n NewsList) filterByKeywords(keywords []string) NewsList {
 }
 
 // flagByKeywords sets IsSuspicious to true if the news contains at least one of the keywords.
+// Analyst rating changes are not flagged, because their category is handled by the jobs.
 func (n NewsList) flagByKeywords(keywords []string) {
+	matchers := newKeywordMatchers(keywords)
 	for _, news := range n {
-		if news.contains(keywords) {
+		if news.RatingChange == nil && news.matchAny(matchers) {
 			news.IsSuspicious = true
 		}
 	}
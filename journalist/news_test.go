@@ -2,6 +2,7 @@ package journalist
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -58,6 +59,25 @@ func TestNewNews(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "long description is cut by runes",
+			args: args{
+				title:        "title",
+				description:  strings.Repeat("ä", DescriptionMaxLength+10),
+				link:         "link",
+				date:         "Mon, 02 Jan 2006 15:04:05 MST",
+				providerName: "provider",
+			},
+			want: &News{
+				ID:           "1c2e951c370154e27e87052728b79fb5",
+				Title:        "title",
+				Description:  strings.Repeat("ä", DescriptionMaxLength-1) + "…",
+				Link:         "link",
+				Date:         time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+				ProviderName: "provider",
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid date",
 			args: args{
@@ -246,6 +266,26 @@ func TestNewsList_FlagByKeywords(t *testing.T) {
 			},
 			wantFlaggedLen: 1,
 		},
+		{
+			name: "skip rating changes",
+			n: NewsList{
+				{
+					ID:           "id1",
+					Title:        "Goldman Sachs upgrades Apple to Buy",
+					Description:  "Analysts say the iPhone cycle is strong",
+					RatingChange: &RatingChange{Firm: "Goldman Sachs", Action: RatingUpgrade, Company: "Apple", To: "Buy"},
+				},
+				{
+					ID:          "id2",
+					Title:       "Analysts say the Fed will cut rates",
+					Description: "Read more",
+				},
+			},
+			args: args{
+				keywords: []string{"analysts say"},
+			},
+			wantFlaggedLen: 1,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
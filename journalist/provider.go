@@ -51,6 +51,7 @@ func (r *RssProvider) Fetch(ctx context.Context, until time.Time) (NewsList, err
 		if err != nil {
 			return nil, newError(errlvl.INFO, err).WithProvider(r.Name)
 		}
+		newsItem.GUID = item.GUID
 		news = append(news, newsItem)
 	}
 
@@ -0,0 +1,197 @@
+package journalist
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RatingAction is the kind of the analyst action on the stock.
+type RatingAction string
+
+const (
+	RatingUpgrade     RatingAction = "upgrades"   // Rating is raised, e.g. Neutral → Buy
+	RatingDowngrade   RatingAction = "downgrades" // Rating is lowered, e.g. Buy → Neutral
+	RatingInitiate    RatingAction = "initiates"  // Coverage is started with the rating
+	RatingReiterate   RatingAction = "reiterates" // Rating is kept (reiterated or maintained)
+	RatingTargetRaise RatingAction = "raises"     // Only the price target is raised
+	RatingTargetLower RatingAction = "lowers"     // Only the price target is lowered
+)
+
+// ratingAmount matches the USD amount with the optional thousands separators and cents, e.g. "1,250.50".
+const ratingAmount = `(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?)`
+
+// ratingMaxFirmWords is the max number of words in the firm name, longer ones are likely the regular sentences.
+const ratingMaxFirmWords = 6
+
+// RatingChange is the analyst rating change parsed from the news title,
+// e.g. "Goldman Sachs upgrades Apple (AAPL) to Buy from Neutral, raises PT to $220 from $200".
+type RatingChange struct {
+	Firm        string       // Analyst firm, e.g. "Goldman Sachs"
+	Action      RatingAction // Action of the firm
+	Company     string       // Company name as in the title, e.g. "Apple"
+	Ticker      string       // Ticker if mentioned in the title (optional)
+	From        string       // Previous rating (optional)
+	To          string       // New rating (optional)
+	PriceTarget string       // New price target in USD without the "$" sign, e.g. "1,250" (optional)
+	PriorTarget string       // Previous price target in USD without the "$" sign (optional)
+}
+
+var (
+	ratingActionRegex = regexp.MustCompile(
+		`^(.+?) (?i:(upgrades|upgraded|downgrades|downgraded|initiates coverage (?:on|of)|initiates|initiated|starts coverage (?:on|of)|reiterates|reiterated|maintains|maintained|raises|raised|boosts|lifts|lowers|lowered|cuts|trims)) (.+)$`,
+	)
+	ratingTickerRegex  = regexp.MustCompile(`\(([A-Z][A-Z.]{0,5})\)`)
+	ratingToRegex      = regexp.MustCompile(`\bto ([A-Z][\w-]*(?: [A-Z][\w-]*)*)`)
+	ratingFromRegex    = regexp.MustCompile(`\bfrom ([A-Z][\w-]*(?: [A-Z][\w-]*)*)`)
+	ratingWithRegex    = regexp.MustCompile(`\b(?:with|at) (?:an? )?([A-Z][\w-]*(?: [A-Z][\w-]*)*)(?: rating)?`)
+	ratingTargetRegex  = regexp.MustCompile(`\$` + ratingAmount)
+	ratingTargetFromTo = regexp.MustCompile(`from \$` + ratingAmount + ` to \$` + ratingAmount)
+	ratingTargetToFrom = regexp.MustCompile(`to \$` + ratingAmount + ` from \$` + ratingAmount)
+	ratingTargetWord   = regexp.MustCompile(`(?i:price target|\btarget\b)|\bPT\b`)
+	ratingCompanyEnd   = regexp.MustCompile(` \(| to | from |, | at | with | price target| PT\b|;| - `)
+	ratingWordRegex    = regexp.MustCompile(
+		`(?i)\b(buy|sell|hold|neutral|overweight|underweight|equal[- ]weight|outperform|underperform|perform|accumulate|reduce|positive|negative|in[- ]line)\b`,
+	)
+)
+
+// ratingActions maps the title verbs to the actions.
+var ratingActions = map[string]RatingAction{
+	"upgrades":   RatingUpgrade,
+	"upgraded":   RatingUpgrade,
+	"downgrades": RatingDowngrade,
+	"downgraded": RatingDowngrade,
+	"initiates":  RatingInitiate,
+	"initiated":  RatingInitiate,
+	"reiterates": RatingReiterate,
+	"reiterated": RatingReiterate,
+	"maintains":  RatingReiterate,
+	"maintained": RatingReiterate,
+	"raises":     RatingTargetRaise,
+	"raised":     RatingTargetRaise,
+	"boosts":     RatingTargetRaise,
+	"lifts":      RatingTargetRaise,
+	"lowers":     RatingTargetLower,
+	"lowered":    RatingTargetLower,
+	"cuts":       RatingTargetLower,
+	"trims":      RatingTargetLower,
+}
+
+// ParseRatingChange parses the analyst rating change from the news title. Returns nil if the title
+// is not a rating change, e.g. the firm is not found or neither the rating nor the price target are mentioned.
+// The amounts are parsed as the price target only next to the "price target" or "PT" words and the ratings
+// only if they are the analyst ratings (Buy, Overweight, etc.), so the price, dividend or capex changes are skipped.
+func ParseRatingChange(title string) *RatingChange {
+	m := ratingActionRegex.FindStringSubmatch(strings.TrimSpace(title))
+	if m == nil {
+		return nil
+	}
+
+	firm, verb, rest := strings.TrimSpace(m[1]), strings.ToLower(m[2]), m[3]
+	if firm == "" || firm[0] < 'A' || firm[0] > 'Z' || len(strings.Fields(firm)) > ratingMaxFirmWords {
+		return nil
+	}
+
+	action, ok := ratingActions[strings.Fields(verb)[0]]
+	if strings.HasPrefix(verb, "starts") {
+		action, ok = RatingInitiate, true
+	}
+	if !ok {
+		return nil
+	}
+
+	r := &RatingChange{Firm: firm, Action: action}
+
+	// Price target phrases go before the company, e.g. "raises price target on Tesla to $300"
+	company := rest
+	for _, prefix := range []string{"price target on ", "PT on ", "its price target on ", "the price target on "} {
+		company = strings.TrimPrefix(company, prefix)
+	}
+	if loc := ratingCompanyEnd.FindStringIndex(company); loc != nil {
+		company = company[:loc[0]]
+	}
+	r.Company = strings.TrimSuffix(strings.TrimSpace(company), "'s")
+
+	if t := ratingTickerRegex.FindStringSubmatch(rest); t != nil {
+		r.Ticker = t[1]
+	}
+
+	switch {
+	case !ratingTargetWord.MatchString(rest):
+		// e.g. "Tesla cuts Model Y prices to $39,990"
+	case ratingTargetToFrom.MatchString(rest):
+		t := ratingTargetToFrom.FindStringSubmatch(rest)
+		r.PriceTarget, r.PriorTarget = t[1], t[2]
+	case ratingTargetFromTo.MatchString(rest):
+		t := ratingTargetFromTo.FindStringSubmatch(rest)
+		r.PriorTarget, r.PriceTarget = t[1], t[2]
+	default:
+		if t := ratingTargetRegex.FindStringSubmatch(rest); t != nil {
+			r.PriceTarget = t[1]
+		}
+	}
+
+	if t := ratingToRegex.FindStringSubmatch(rest); t != nil {
+		r.To = t[1]
+	} else if action == RatingInitiate || action == RatingReiterate {
+		// e.g. "initiates coverage on Nvidia with Buy rating"
+		if t := ratingWithRegex.FindStringSubmatch(rest); t != nil {
+			r.To = t[1]
+		}
+	}
+	if t := ratingFromRegex.FindStringSubmatch(rest); t != nil {
+		r.From = t[1]
+	}
+	// e.g. "Microsoft upgrades Teams to New Design"
+	if !ratingWordRegex.MatchString(r.To) {
+		r.To = ""
+	}
+	if !ratingWordRegex.MatchString(r.From) {
+		r.From = ""
+	}
+
+	if r.Company == "" || r.To == "" && r.PriceTarget == "" {
+		return nil
+	}
+	// Price target actions without the target are something else, e.g. "raises guidance"
+	if (action == RatingTargetRaise || action == RatingTargetLower) && r.PriceTarget == "" {
+		return nil
+	}
+
+	return r
+}
+
+// String formats the rating change, e.g. "Goldman Sachs upgrades AAPL: Neutral → Buy, PT $220 (from $200)".
+func (r *RatingChange) String() string {
+	var sb strings.Builder
+	name := r.Company
+	if r.Ticker != "" {
+		name = r.Ticker
+	}
+
+	action := string(r.Action)
+	if r.Action == RatingTargetRaise || r.Action == RatingTargetLower {
+		action += " price target on"
+	}
+	sb.WriteString(fmt.Sprintf("%s %s %s", r.Firm, action, name))
+
+	var details []string
+	switch {
+	case r.From != "" && r.To != "":
+		details = append(details, fmt.Sprintf("%s → %s", r.From, r.To))
+	case r.To != "":
+		details = append(details, r.To)
+	}
+	if r.PriceTarget != "" {
+		pt := "PT $" + r.PriceTarget
+		if r.PriorTarget != "" {
+			pt += fmt.Sprintf(" (from $%s)", r.PriorTarget)
+		}
+		details = append(details, pt)
+	}
+	if len(details) > 0 {
+		sb.WriteString(": " + strings.Join(details, ", "))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package journalist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRatingChange(t *testing.T) {
+	tests := []struct {
+		title string
+		want  *RatingChange
+	}{
+		{
+			title: "Goldman Sachs upgrades Apple (AAPL) to Buy from Neutral, raises PT to $220 from $200",
+			want: &RatingChange{
+				Firm: "Goldman Sachs", Action: RatingUpgrade, Company: "Apple", Ticker: "AAPL",
+				From: "Neutral", To: "Buy", PriceTarget: "220", PriorTarget: "200",
+			},
+		},
+		{
+			title: "Morgan Stanley downgrades Tesla to Equal-Weight",
+			want:  &RatingChange{Firm: "Morgan Stanley", Action: RatingDowngrade, Company: "Tesla", To: "Equal-Weight"},
+		},
+		{
+			title: "JPMorgan initiates coverage on Nvidia with Overweight rating, $150 price target",
+			want:  &RatingChange{Firm: "JPMorgan", Action: RatingInitiate, Company: "Nvidia", To: "Overweight", PriceTarget: "150"},
+		},
+		{
+			title: "Wedbush raises price target on Microsoft to $475.50 from $450",
+			want:  &RatingChange{Firm: "Wedbush", Action: RatingTargetRaise, Company: "Microsoft", PriceTarget: "475.50", PriorTarget: "450"},
+		},
+		{
+			title: "Barclays cuts Intel's price target from $40 to $35",
+			want:  &RatingChange{Firm: "Barclays", Action: RatingTargetLower, Company: "Intel", PriceTarget: "35", PriorTarget: "40"},
+		},
+		{
+			title: "Bernstein raises Booking Holdings PT to $4,500 from $4,200.50",
+			want:  &RatingChange{Firm: "Bernstein", Action: RatingTargetRaise, Company: "Booking Holdings", PriceTarget: "4,500", PriorTarget: "4,200.50"},
+		},
+		{title: "Apple raises dividend by 4%"},
+		{title: "Tesla cuts Model Y prices to $39,990"},
+		{title: "Apple raises quarterly dividend to $0.25 per share"},
+		{title: "Meta raises 2024 capex forecast to $40 billion"},
+		{title: "Exxon initiates $20 billion share buyback"},
+		{title: "Microsoft upgrades Teams to New Design"},
+		{title: "Ford cuts 3,000 jobs in Europe"},
+		{title: "Analysts say the Fed will cut rates in March"},
+		{title: "Why the market upgrades are not what they seem to the average investor today"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := ParseRatingChange(tt.title); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRatingChange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatingChange_String(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *RatingChange
+		want string
+	}{
+		{
+			name: "full",
+			r: &RatingChange{
+				Firm: "Goldman Sachs", Action: RatingUpgrade, Company: "Apple", Ticker: "AAPL",
+				From: "Neutral", To: "Buy", PriceTarget: "220", PriorTarget: "200",
+			},
+			want: "Goldman Sachs upgrades AAPL: Neutral → Buy, PT $220 (from $200)",
+		},
+		{
+			name: "price target only",
+			r:    &RatingChange{Firm: "Wedbush", Action: RatingTargetRaise, Company: "Microsoft", PriceTarget: "475"},
+			want: "Wedbush raises price target on Microsoft: PT $475",
+		},
+		{
+			name: "rating only",
+			r:    &RatingChange{Firm: "JPMorgan", Action: RatingInitiate, Company: "Nvidia", To: "Overweight"},
+			want: "JPMorgan initiates Nvidia: Overweight",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
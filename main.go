@@ -3,6 +3,8 @@ package main
 import (
 	"github.com/getsentry/sentry-go"
 	"github.com/go-playground/validator/v10"
+	"github.com/samgozman/fin-thread/internal/utils"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
 	"log/slog"
 	"os"
 	"time"
@@ -11,49 +13,185 @@ import (
 func main() {
 	l := slog.Default()
 
+	// Values missing in the environment are taken from the mounted config and secrets files or embedded defaults
+	lookup, err := newEnvLookup(os.Getenv("CONFIG_FILE"), os.Getenv("SECRETS_FILE"))
+	if err != nil {
+		l.Error("[main] Error loading config files:", "error", err)
+		os.Exit(1)
+	}
+	getenv := lookup.Get
+
 	env := Env{
-		TelegramChannelID: os.Getenv("TELEGRAM_CHANNEL_ID"),
-		TelegramBotToken:  os.Getenv("TELEGRAM_BOT_TOKEN"),
-		OpenAiToken:       os.Getenv("OPENAI_TOKEN"),
-		TogetherAIToken:   os.Getenv("TOGETHER_AI_TOKEN"),
-		GoogleGeminiToken: os.Getenv("GOOGLE_GEMINI_TOKEN"),
-		PostgresDSN:       os.Getenv("POSTGRES_DSN"),
-		SentryDSN:         os.Getenv("SENTRY_DSN"),
-		StockSymbols:      os.Getenv("STOCK_SYMBOLS"),
-		MarketJournalists: os.Getenv("MARKET_JOURNALISTS"),
-		BroadJournalists:  os.Getenv("BROAD_JOURNALISTS"),
-		ServerName:        os.Getenv("SERVER_NAME"),
-		ShouldPublish:     os.Getenv("SHOULD_PUBLISH") == "true",
+		TelegramChannelID: getenv("TELEGRAM_CHANNEL_ID"),
+		TelegramBotToken:  getenv("TELEGRAM_BOT_TOKEN"),
+		OpenAiToken:       getenv("OPENAI_TOKEN"),
+		TogetherAIToken:   getenv("TOGETHER_AI_TOKEN"),
+		GoogleGeminiToken: getenv("GOOGLE_GEMINI_TOKEN"),
+		PostgresDSN:       getenv("POSTGRES_DSN"),
+		SentryDSN:         getenv("SENTRY_DSN"),
+		SentryEnvironment: getenv("SENTRY_ENVIRONMENT"),
+		SentryTracesRate:  getenv("SENTRY_TRACES_SAMPLE_RATE"),
+		SentryProfileRate: getenv("SENTRY_PROFILES_SAMPLE_RATE"),
+		StockSymbols:      getenv("STOCK_SYMBOLS"),
+		MarketJournalists: getenv("MARKET_JOURNALISTS"),
+		BroadJournalists:  getenv("BROAD_JOURNALISTS"),
+		ServerName:        getenv("SERVER_NAME"),
+		ShouldPublish:     getenv("SHOULD_PUBLISH") == "true",
+		AdminChatID:       getenv("TELEGRAM_ADMIN_CHAT_ID"),
+		WatchdogSilence:   getenv("WATCHDOG_SILENCE_PERIOD"),
+		WatchdogFilter:    getenv("WATCHDOG_FILTER_RATE"),
+		WatchdogSpike:     getenv("WATCHDOG_VOLUME_SPIKE"),
+		AlertLevel:        getenv("ALERT_LEVEL"),
+		AlertWebhookURL:   getenv("ALERT_WEBHOOK_URL"),
+		AlertWindow:       getenv("ALERT_DEDUP_WINDOW"),
+		StartupRetries:    getenv("STARTUP_RETRIES"),
+		StartupDelay:      getenv("STARTUP_RETRY_DELAY"),
+		StartupDegraded:   getenv("STARTUP_DEGRADED") == "true",
+		ScheduleTolerance: getenv("SCHEDULE_TOLERANCE"),
+		ScheduleCatchUp:   getenv("SCHEDULE_CATCH_UP"),
+		Sandbox:           getenv("SANDBOX") == "true",
+		SandboxFixtures:   getenv("SANDBOX_FIXTURES"),
+		SandboxSpeed:      getenv("SANDBOX_SPEED"),
+		SandboxOutput:     getenv("SANDBOX_OUTPUT"),
+		ComposeMaxLength:  getenv("COMPOSE_MAX_LENGTH"),
+		DescMaxLength:     getenv("DESCRIPTION_MAX_LENGTH"),
+		PromptGlossary:    getenv("PROMPT_GLOSSARY"),
+		PromptExamples:    getenv("PROMPT_EXAMPLES_FILE"),
+		RecentTexts:       getenv("RECENT_TEXTS"),
+		Scavengers:        getenv("SCAVENGERS"),
+		CacheRedisURL:     getenv("CACHE_REDIS_URL"),
+		CalendarCountries: getenv("CALENDAR_COUNTRIES"),
+		CalendarPolls:     getenv("CALENDAR_POLLS"),
+		CalendarEditPlan:  getenv("CALENDAR_EDIT_PLAN") == "true",
+		Watchlist:         getenv("WATCHLIST"),
+		SectorChannels:    getenv("SECTOR_CHANNELS"),
+		SummaryChannels:   getenv("SUMMARY_CHANNELS"),
+		MirrorChannelID:   getenv("MIRROR_CHANNEL_ID"),
+		MirrorLanguage:    getenv("MIRROR_LANGUAGE"),
+		SlackWebhookURL:   getenv("SLACK_WEBHOOK_URL"),
+		SlackChannel:      getenv("SLACK_CHANNEL"),
+		SummaryVoice:      getenv("SUMMARY_VOICE"),
+		WebAddr:           getenv("WEB_ADDR"),
+		WebBaseURL:        getenv("WEB_BASE_URL"),
+		BroadMinMarketCap: getenv("BROAD_MIN_MARKET_CAP"),
+		StockCountries:    getenv("STOCK_COUNTRIES"),
+		ShadowPrompt:      getenv("SHADOW_FILTER_PROMPT_FILE"),
+		ShadowModel:       getenv("SHADOW_FILTER_MODEL"),
+		ComposeModels:     getenv("COMPOSE_MODELS"),
+		ComposeModel:      getenv("COMPOSE_MODEL_OVERRIDE"),
+		ModelRegistry:     getenv("MODEL_REGISTRY_FILE"),
+		ETFHoldings:       getenv("ETF_HOLDINGS_FILE"),
+		Schedules:         getenv("SCHEDULES"),
+		JobTimeouts:       getenv("JOB_TIMEOUTS"),
+		NewsButtons:       getenv("NEWS_BUTTONS"),
+		StageAttempts:     getenv("STAGE_ATTEMPTS"),
+		Tenants:           getenv("TENANTS"),
+		ProviderTrust:     getenv("PROVIDER_TRUST"),
+		TagRules:          getenv("TAG_RULES"),
+		NewsIDStrategy:    getenv("NEWS_ID_STRATEGY"),
+		Theme:             getenv("THEME"),
+		ThemeIcons:        getenv("THEME_ICONS"),
+		EventTitles:       getenv("EVENT_TITLES"),
+		EventTitlesAI:     getenv("EVENT_TITLES_AI") == "true",
+		FXThreshold:       getenv("FX_THRESHOLD"),
+		FXQuotes:          getenv("FX_QUOTES") == "true",
+		SECUserAgent:      getenv("SEC_USER_AGENT"),
+		InsiderMinValue:   getenv("INSIDER_MIN_VALUE"),
+		RatingChanges:     getenv("RATING_CHANGES"),
+		OutboxMaxAge:      getenv("OUTBOX_MAX_AGE"),
+		PublishInterval:   getenv("PUBLISH_INTERVAL"),
+		RepublishMaxAge:   getenv("REPUBLISH_MAX_AGE"),
+		StoryGap:          getenv("STORY_GAP"),
+		VerifyPublish:     getenv("VERIFY_PUBLISH") == "true",
+		LinkUTM:           getenv("LINK_UTM"),
+		LinkSecret:        getenv("LINK_SECRET"),
+		LinkShortener:     getenv("LINK_SHORTENER"),
+		AIScrub:           getenv("AI_SCRUB"),
+		VerifyNumbers:     getenv("VERIFY_NUMBERS") == "true",
+		Compliance:        getenv("COMPLIANCE_PROFILES"),
+		ParseModes:        getenv("PARSE_MODES"),
 	}
 	validate := validator.New()
 	if err := validate.Struct(env); err != nil {
-		l.Error("[main] Error validating environment variables:", err)
+		l.Error("[main] Error validating environment variables:", "error", err)
 		return
 	}
 
-	err := sentry.Init(sentry.ClientOptions{
-		Dsn:                env.SentryDSN,
-		EnableTracing:      true,
-		TracesSampleRate:   1.0, // There are not many transactions, so we can afford to send all of them
-		ProfilesSampleRate: 1.0, // Same here
-		ServerName:         env.ServerName,
-	})
-	if err != nil {
-		l.Error("[main] Error initializing Sentry:", err)
-		os.Exit(1)
+	// `fin-thread doctor` verifies each configured dependency and exits
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		cnf, err := NewConfig(&env)
+		if err != nil {
+			l.Error("[main] Error creating Config:", "error", err)
+			os.Exit(1)
+		}
+		if !runDoctor(cnf, os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// `fin-thread rehash` recomputes the saved news hashes after changing NEWS_ID_STRATEGY and exits
+	if len(os.Args) > 1 && os.Args[1] == "rehash" {
+		cnf, err := NewConfig(&env)
+		if err != nil {
+			l.Error("[main] Error creating Config:", "error", err)
+			os.Exit(1)
+		}
+		if !runRehash(cnf, os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// `fin-thread export-finetune` writes the fine-tuning data of the published news and exits
+	if len(os.Args) > 1 && os.Args[1] == "export-finetune" {
+		cnf, err := NewConfig(&env)
+		if err != nil {
+			l.Error("[main] Error creating Config:", "error", err)
+			os.Exit(1)
+		}
+		if !runExportFineTune(cnf, os.Args[2:], os.Stdout) {
+			os.Exit(1)
+		}
+		return
 	}
-	defer sentry.Flush(2 * time.Second)
-	defer sentry.Recover()
 
+	// `fin-thread run <job>` runs the job once with the overrides from the flags and exits
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		cnf, err := NewConfig(&env)
+		if err != nil {
+			l.Error("[main] Error creating Config:", "error", err)
+			os.Exit(1)
+		}
+		if !runJob(cnf, os.Args[2:], os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Config is created before Sentry, because it holds the Sentry options
 	cnf, err := NewConfig(&env)
 	if err != nil {
-		l.Error("[main] Error creating Config:", err)
+		l.Error("[main] Error creating Config:", "error", err)
 		return
 	}
 
+	err = sentry.Init(cnf.sentryOptions())
+	if err != nil {
+		l.Error("[main] Error initializing Sentry:", "error", err)
+		os.Exit(1)
+	}
+	defer sentry.Flush(2 * time.Second)
+	defer sentry.Recover()
+
 	app := &App{
-		cnf,
+		cnf: cnf,
 	}
 
-	app.start()
+	if err := app.start(); err != nil {
+		l.Error("[main] Error starting fin-thread:", "error", err)
+		utils.CaptureSentryException("startupError", sentry.CurrentHub(), errlvl.Wrap(err, errlvl.FATAL))
+		sentry.Flush(2 * time.Second)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,100 @@
+// Package narrator turns the text posts (e.g. the daily summary) into the voice messages using the text-to-speech API.
+package narrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/sashabaranov/go-openai"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxInputLength is the maximum length of the narrated text in characters (OpenAI TTS limit).
+const MaxInputLength = 4096
+
+// speechClient is an interface for the OpenAI text-to-speech API client.
+type speechClient interface {
+	CreateSpeech(ctx context.Context, request openai.CreateSpeechRequest) (openai.RawResponse, error)
+}
+
+// voices holds the supported OpenAI TTS voices.
+var voices = []openai.SpeechVoice{
+	openai.VoiceAlloy,
+	openai.VoiceEcho,
+	openai.VoiceFable,
+	openai.VoiceOnyx,
+	openai.VoiceNova,
+	openai.VoiceShimmer,
+}
+
+// Narrator reads the text aloud with the OpenAI TTS voice and returns the OGG/Opus audio
+// which can be published to Telegram as the voice message.
+type Narrator struct {
+	client speechClient
+	voice  openai.SpeechVoice
+}
+
+// NewNarrator creates a new Narrator with the OpenAI token and the voice name (e.g. "alloy").
+func NewNarrator(oaiToken, voice string) (*Narrator, error) {
+	v, err := ParseVoice(voice)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Narrator{client: openai.NewClient(oaiToken), voice: v}, nil
+}
+
+// ParseVoice parses the OpenAI TTS voice name, e.g. "alloy" or "nova".
+func ParseVoice(s string) (openai.SpeechVoice, error) {
+	v := openai.SpeechVoice(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(voices, v) {
+		return "", fmt.Errorf("unknown voice %q, expected one of %v", s, voices)
+	}
+	return v, nil
+}
+
+// Narrate converts the text to the OGG/Opus speech. Text longer than MaxInputLength is cut by the line boundary.
+func (n *Narrator) Narrate(ctx context.Context, text string) ([]byte, error) {
+	text = truncate(strings.TrimSpace(text), MaxInputLength)
+	if text == "" {
+		return nil, errlvl.Wrap(errors.New("nothing to narrate"), errlvl.WARN)
+	}
+
+	resp, err := n.client.CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:          openai.TTSModel1,
+		Input:          text,
+		Voice:          n.voice,
+		ResponseFormat: openai.SpeechResponseFormatOpus,
+	})
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error creating speech: %w", err), errlvl.WARN)
+	}
+	defer resp.Close()
+
+	audio, err := io.ReadAll(resp)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error reading speech: %w", err), errlvl.WARN)
+	}
+	if len(audio) == 0 {
+		return nil, errlvl.Wrap(errors.New("empty speech"), errlvl.WARN)
+	}
+
+	return audio, nil
+}
+
+// truncate cuts the text to the limit (in characters) by the last line break, or by the limit if there is none.
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	r := []rune(text)[:limit]
+	if i := strings.LastIndex(string(r), "\n"); i > 0 {
+		return strings.TrimSpace(string(r)[:i])
+	}
+	return string(r)
+}
@@ -0,0 +1,109 @@
+package narrator
+
+import (
+	"context"
+	"errors"
+	"github.com/sashabaranov/go-openai"
+	"io"
+	"strings"
+	"testing"
+)
+
+type fakeSpeechClient struct {
+	audio    string
+	err      error
+	requests []openai.CreateSpeechRequest
+}
+
+func (f *fakeSpeechClient) CreateSpeech(_ context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error) {
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return openai.RawResponse{}, f.err
+	}
+	return openai.RawResponse{ReadCloser: io.NopCloser(strings.NewReader(f.audio))}, nil
+}
+
+func TestNarrator_Narrate(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    *fakeSpeechClient
+		text      string
+		want      string
+		wantInput string
+		wantErr   bool
+	}{
+		{
+			name:      "narrated",
+			client:    &fakeSpeechClient{audio: "OggS"},
+			text:      "  What happened in the last 12 hours:\n- Nvidia rallied after earnings\n",
+			want:      "OggS",
+			wantInput: "What happened in the last 12 hours:\n- Nvidia rallied after earnings",
+		},
+		{
+			name:    "empty text",
+			client:  &fakeSpeechClient{audio: "OggS"},
+			text:    " \n",
+			wantErr: true,
+		},
+		{
+			name:    "api error",
+			client:  &fakeSpeechClient{err: errors.New("rate limit")},
+			text:    "Nvidia rallied after earnings",
+			wantErr: true,
+		},
+		{
+			name:    "empty speech",
+			client:  &fakeSpeechClient{},
+			text:    "Nvidia rallied after earnings",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &Narrator{client: tt.client, voice: openai.VoiceAlloy}
+			got, err := n.Narrate(context.Background(), tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Narrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Narrate() = %q, want %q", got, tt.want)
+			}
+			if tt.wantInput == "" {
+				return
+			}
+			req := tt.client.requests[0]
+			if req.Input != tt.wantInput || req.ResponseFormat != openai.SpeechResponseFormatOpus || req.Voice != openai.VoiceAlloy {
+				t.Errorf("Narrate() request = %+v, want opus speech of %q with alloy voice", req, tt.wantInput)
+			}
+		})
+	}
+}
+
+func TestParseVoice(t *testing.T) {
+	if v, err := ParseVoice(" Nova "); err != nil || v != openai.VoiceNova {
+		t.Errorf("ParseVoice() = %v, %v, want nova", v, err)
+	}
+	if _, err := ParseVoice("robot"); err == nil {
+		t.Errorf("ParseVoice() should fail for the unknown voice")
+	}
+}
+
+func Test_truncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{name: "short", text: "- one\n- two", limit: 20, want: "- one\n- two"},
+		{name: "line boundary", text: "- one\n- two\n- three", limit: 14, want: "- one\n- two"},
+		{name: "no line break", text: "одна длинная строка", limit: 5, want: "одна "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncate(tt.text, tt.limit); got != tt.want {
+				t.Errorf("truncate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
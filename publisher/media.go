@@ -0,0 +1,192 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// telegramMediaGroupLimit is the maximum number of the photos in the Telegram media group.
+const telegramMediaGroupLimit = 10
+
+// inputMediaPhoto is the photo of the Telegram media group (tgbotapi.InputMediaPhoto always sends the empty caption).
+type inputMediaPhoto struct {
+	Type      string `json:"type"`
+	Media     string `json:"media"`
+	Caption   string `json:"caption,omitempty"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// PublishMediaGroup publishes the PNG images as the album with the message as a caption of the first one,
+// e.g. the charts of several tickers. Only the first 10 images are published (the Telegram limit).
+// If the message is too long for a caption, it is published as a separate message and the album is sent as a reply.
+// The single image is published as the photo (see PublishPhoto), the message without images as the text.
+// Albums can't have the inline keyboard, so the message buttons are listed in the caption as links.
+func (t *TelegramPublisher) PublishMediaGroup(message Message, images []Media) (pubID string, err error) {
+	pubID, _, err = t.publishMediaGroup(message, images)
+	return pubID, err
+}
+
+// publishMediaGroup publishes the album as PublishMediaGroup and returns the IDs of the images sent as the reply (if any).
+func (t *TelegramPublisher) publishMediaGroup(message Message, images []Media) (pubID string, replyIDs []string, err error) {
+	switch len(images) {
+	case 0:
+		pubID, err = t.publishText(message)
+		return pubID, nil, err
+	case 1:
+		return t.publishPhoto(message, images[0])
+	}
+	images = images[:min(len(images), telegramMediaGroupLimit)]
+
+	msg := t.render(message)
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintln(w, msg)
+		for i, image := range images {
+			_, _ = fmt.Fprintf(w, "[photo %d/%d: %d bytes]\n", i+1, len(images), len(image.Data))
+		}
+		return "", nil, nil
+	}
+
+	media := make([]inputMediaPhoto, len(images))
+	for i := range images {
+		media[i] = inputMediaPhoto{Type: "photo", Media: "attach://" + mediaField(i)}
+	}
+
+	replyTo := 0
+	if utf8.RuneCountInString(msg) <= telegramCaptionLimit {
+		media[0].Caption = msg
+		media[0].ParseMode = t.parseMode()
+	} else {
+		pubID, err = t.publishText(message)
+		if err != nil {
+			return "", nil, err
+		}
+		replyTo, _ = strconv.Atoi(pubID)
+	}
+
+	var messages []tgbotapi.Message
+	err = t.Queue.do(func() (err error) {
+		messages, err = t.sendMediaGroup(media, images, replyTo)
+		return err
+	})
+	if err != nil {
+		return pubID, nil, errlvl.Wrap(fmt.Errorf("failed to send media group to Telegram: %w", err), errlvl.ERROR)
+	}
+	if pubID != "" {
+		for _, m := range messages {
+			replyIDs = append(replyIDs, strconv.Itoa(m.MessageID))
+		}
+		return pubID, replyIDs, nil
+	}
+	return strconv.Itoa(messages[0].MessageID), nil, nil
+}
+
+// sendMediaGroup sends the media group with the images uploaded as the multipart files (tgbotapi uploads
+// only one file per request) and returns the sent messages. Telegram API errors are returned as tgbotapi.Error.
+func (t *TelegramPublisher) sendMediaGroup(media []inputMediaPhoto, images []Media, replyTo int) ([]tgbotapi.Message, error) {
+	mediaJSON, err := json.Marshal(media)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{"chat_id": {t.ChannelID}, "media": {string(mediaJSON)}}
+	if replyTo != 0 {
+		params.Set("reply_to_message_id", strconv.Itoa(replyTo))
+	}
+	files := make(map[string]Media, len(images))
+	for i, image := range images {
+		files[mediaField(i)] = image
+	}
+
+	result, err := t.upload("sendMediaGroup", params, files)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []tgbotapi.Message
+	if err := json.Unmarshal(result, &messages); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("no messages in the media group response")
+	}
+	return messages, nil
+}
+
+// sendFile sends the message with the file (e.g. the photo or the voice) through the Queue and returns it.
+// Telegram API errors are returned as tgbotapi.Error.
+func (t *TelegramPublisher) sendFile(method string, params url.Values, field string, file Media) (m tgbotapi.Message, err error) {
+	err = t.Queue.do(func() error {
+		result, err := t.upload(method, params, map[string]Media{field: file})
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(result, &m)
+	})
+	return m, err
+}
+
+// upload makes the request to the Telegram API method with the files uploaded as the multipart form fields
+// and returns its result. Unlike the uploads of tgbotapi, the API errors are returned as tgbotapi.Error with
+// the response parameters, so the Queue retries the flood control errors after their retry_after.
+func (t *TelegramPublisher) upload(method string, params url.Values, files map[string]Media) (json.RawMessage, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, values := range params {
+		for _, v := range values {
+			if err := mw.WriteField(name, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for field, file := range files {
+		fw, err := mw.CreateFormFile(field, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(file.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf(tgbotapi.APIEndpoint, t.BotAPI.Token, method)
+	resp, err := t.BotAPI.Client.Post(endpoint, mw.FormDataContentType(), &body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp tgbotapi.APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	if !apiResp.Ok {
+		var rp tgbotapi.ResponseParameters
+		if apiResp.Parameters != nil {
+			rp = *apiResp.Parameters
+		}
+		return nil, tgbotapi.Error{Message: apiResp.Description, ResponseParameters: rp}
+	}
+
+	return apiResp.Result, nil
+}
+
+// mediaField returns the name of the multipart field of the media group image.
+func mediaField(i int) string {
+	return "photo" + strconv.Itoa(i)
+}
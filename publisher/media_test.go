@@ -0,0 +1,199 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramPublisher_PublishMediaGroup_sandbox(t *testing.T) {
+	var out bytes.Buffer
+	p := NewSandboxPublisher("@test", &out)
+
+	msg := Markdown("*AAPL* and *MSFT* are up")
+	msg.Media = []Media{{Name: "aapl.png", Data: []byte("png")}, {Name: "msft.png", Data: []byte("png2")}}
+	if _, err := p.Publish(msg); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	want := "*AAPL* and *MSFT* are up\n[photo 1/2: 3 bytes]\n[photo 2/2: 4 bytes]\n"
+	if got := out.String(); got != want {
+		t.Errorf("Publish() output = %q, want %q", got, want)
+	}
+}
+
+func TestTelegramPublisher_PublishMediaGroup(t *testing.T) {
+	type request struct {
+		media   []inputMediaPhoto
+		files   map[string]string
+		replyTo string
+	}
+	var requests []request
+	var texts []string
+	failWith := ""
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			texts = append(texts, r.FormValue("text"))
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":7,"date":0,"chat":{"id":1}}}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMediaGroup") && failWith != "":
+			_, _ = io.WriteString(w, failWith)
+		case strings.HasSuffix(r.URL.Path, "/sendMediaGroup"):
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm() error = %v", err)
+			}
+			req := request{files: map[string]string{}, replyTo: r.FormValue("reply_to_message_id")}
+			_ = json.Unmarshal([]byte(r.FormValue("media")), &req.media)
+			for name, headers := range r.MultipartForm.File {
+				req.files[name] = headers[0].Filename
+			}
+			requests = append(requests, req)
+			_, _ = io.WriteString(w, `{"ok":true,"result":[{"message_id":42,"date":0,"chat":{"id":1}},{"message_id":43,"date":0,"chat":{"id":1}}]}`)
+		default:
+			_, _ = io.WriteString(w, `{"ok":false,"error_code":404,"description":"Not Found"}`)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", &http.Client{Transport: rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &TelegramPublisher{ChannelID: "@test", BotAPI: bot, ShouldPublish: true}
+	images := []Media{{Name: "aapl.png", Data: []byte("png")}, {Name: "msft.png", Data: []byte("png")}}
+
+	pubID, err := p.PublishMediaGroup(Markdown("*AAPL* and *MSFT* are up"), images)
+	if err != nil {
+		t.Fatalf("PublishMediaGroup() error = %v", err)
+	}
+	if pubID != "42" || len(requests) != 1 {
+		t.Fatalf("PublishMediaGroup() = %q with %d requests, want the first message ID of one album", pubID, len(requests))
+	}
+	got := requests[0]
+	if len(got.media) != 2 || got.media[0].Caption != "*AAPL* and *MSFT* are up" || got.media[0].ParseMode != ModeMarkdown ||
+		got.media[1].Caption != "" || got.media[1].Media != "attach://photo1" {
+		t.Errorf("media = %+v, want the caption of the first photo", got.media)
+	}
+	if got.files["photo0"] != "aapl.png" || got.files["photo1"] != "msft.png" {
+		t.Errorf("files = %v, want the images attached by the media names", got.files)
+	}
+
+	// The message too long for a caption is published first, the album replies to it
+	long := Text(strings.Repeat("a", telegramCaptionLimit+1))
+	long.Media = images
+	pubID, replyIDs, err := p.PublishWithReplies(long)
+	if err != nil {
+		t.Fatalf("PublishWithReplies() of the long message error = %v", err)
+	}
+	if pubID != "7" || len(texts) != 1 || requests[1].replyTo != "7" || requests[1].media[0].Caption != "" {
+		t.Errorf("PublishWithReplies() of the long message = %q, requests = %+v, want the album in reply to the text", pubID, requests[1])
+	}
+	if !slices.Equal(replyIDs, []string{"42", "43"}) {
+		t.Errorf("PublishWithReplies() reply IDs = %v, want the album messages", replyIDs)
+	}
+
+	// Flood control errors keep the retry delay for the job retries
+	failWith = `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`
+	_, err = p.PublishMediaGroup(Text("AAPL"), images)
+	var tgErr tgbotapi.Error
+	if !errors.As(err, &tgErr) || tgErr.RetryAfter != 5 {
+		t.Errorf("PublishMediaGroup() error = %v, want tgbotapi.Error with retry after 5s", err)
+	}
+}
+
+func TestTelegramPublisher_upload_floodControl(t *testing.T) {
+	type request struct {
+		method string
+		fields url.Values
+		file   string
+	}
+	var requests []request
+	throttled := map[string]bool{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if method == "getMe" {
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm() error = %v", err)
+		}
+		req := request{method: method, fields: url.Values(r.MultipartForm.Value)}
+		for name, headers := range r.MultipartForm.File {
+			req.file = name + ":" + headers[0].Filename
+		}
+		requests = append(requests, req)
+
+		// The first request of each method is rejected by the flood control
+		if !throttled[method] {
+			throttled[method] = true
+			_, _ = io.WriteString(w, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":42,"date":0,"chat":{"id":1}}}`)
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", &http.Client{Transport: rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewQueue(0)
+	queue.sleep = func(time.Duration) {}
+	p := &TelegramPublisher{ChannelID: "@test", BotAPI: bot, ShouldPublish: true, Queue: queue}
+
+	msg := Markdown("*AAPL* is up")
+	msg.Buttons = []Link{{Title: "Source", URL: "https://example.com"}}
+	pubID, err := p.PublishPhoto(msg, Media{Name: "aapl.png", Data: []byte("png")})
+	if err != nil || pubID != "42" {
+		t.Fatalf("PublishPhoto() = %q, %v, want the photo retried after the flood control", pubID, err)
+	}
+	if pubID, err = p.PublishVoice([]byte("ogg"), "7"); err != nil || pubID != "42" {
+		t.Fatalf("PublishVoice() = %q, %v, want the voice retried after the flood control", pubID, err)
+	}
+
+	if len(requests) != 4 {
+		t.Fatalf("requests = %+v, want 2 attempts of each upload", requests)
+	}
+	photo, voice := requests[1], requests[3]
+	if photo.method != "sendPhoto" || photo.file != "photo:aapl.png" || photo.fields.Get("caption") != "*AAPL* is up" ||
+		photo.fields.Get("parse_mode") != ModeMarkdown || !strings.Contains(photo.fields.Get("reply_markup"), "https://example.com") {
+		t.Errorf("photo request = %+v, want the photo with the caption and the inline keyboard", photo)
+	}
+	if voice.method != "sendVoice" || voice.file != "voice:brief.ogg" || voice.fields.Get("reply_to_message_id") != "7" {
+		t.Errorf("voice request = %+v, want the voice in reply to the message", voice)
+	}
+	if m := queue.Metrics().Reset(); m["throttled"] != 2 || m["sent"] != 2 {
+		t.Errorf("queue metrics = %v, want 2 throttled and 2 sent requests", m)
+	}
+}
+
+// rewriteTransport sends all requests to the target server (Telegram API endpoint can't be changed in tgbotapi).
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = rt.target.Scheme
+	r.URL.Host = rt.target.Host
+	r.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
@@ -0,0 +1,322 @@
+package publisher
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Message is the publisher-agnostic post. Formatters describe the post structure and each publisher renders it
+// natively: Telegram Markdown (see Message.Markdown, Message.MarkdownV2 and Message.HTML), Slack blocks
+// (see Message.SlackBlocks) or plain text (see Message.PlainText).
+type Message struct {
+	Title      string     // Headline of the post (optional)
+	Body       []Segment  // Paragraphs of the post, rendered on separate lines
+	Links      []Link     // Links listed after the body, e.g. the permalink
+	Buttons    []Link     // Buttons under the post (Telegram inline keyboard), other publishers list them as Links
+	Media      []Media    // Images attached to the post, publishers without media publish the text only
+	Tags       []string   // Hashtags without "#" listed after the body
+	Footer     []Segment  // Paragraphs after the tags and links, e.g. the disclaimer
+	Importance Importance // Importance of the post, important ones are marked
+}
+
+// Segment is the paragraph of the Message body with the formatted parts of its text.
+type Segment struct {
+	Text     string
+	Entities []Entity // Formatted parts of the Text, overlapping ones are rendered as plain text
+}
+
+// EntityType is the formatting of the Entity.
+type EntityType string
+
+const (
+	EntityBold   EntityType = "bold"
+	EntityItalic EntityType = "italic"
+	EntityCode   EntityType = "code"
+	EntityPre    EntityType = "pre"
+	EntityLink   EntityType = "link"
+	EntityStrike EntityType = "strike" // e.g. the retracted post, see Message.Markdown for the legacy Markdown
+)
+
+// Entity is the formatted part of the Segment text.
+type Entity struct {
+	Type   EntityType
+	Offset int    // Offset of the part in the Segment.Text (bytes)
+	Length int    // Length of the part (bytes)
+	URL    string // URL of the EntityLink
+}
+
+// Link is the titled link of the Message.
+type Link struct {
+	Title string
+	URL   string
+}
+
+// Media is the image attached to the Message.
+type Media struct {
+	Name string // File name, e.g. "chart.png"
+	Data []byte // PNG image
+}
+
+// Importance is the importance of the Message.
+type Importance int
+
+const (
+	ImportanceNormal Importance = iota
+	ImportanceHigh              // e.g. news of the watched tickers, marked with ⭐️
+)
+
+// importanceMark is the prefix of the important messages.
+const importanceMark = "⭐️ "
+
+// markdownMarkers are the Telegram Markdown markers of the entities.
+var markdownMarkers = map[byte]EntityType{'*': EntityBold, '_': EntityItalic, '`': EntityCode}
+
+var (
+	// markdownV2Escaper escapes the reserved characters of the Telegram MarkdownV2 text.
+	markdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+		">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	// markdownV2CodeEscaper escapes the reserved characters of the Telegram MarkdownV2 code and pre entities.
+	markdownV2CodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	// markdownV2URLEscaper escapes the reserved characters of the Telegram MarkdownV2 link URL.
+	markdownV2URLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+// EscapeMarkdownV2 escapes the reserved characters of the Telegram MarkdownV2, so the dynamic text (e.g. the news
+// title, values or tickers) is displayed as is.
+func EscapeMarkdownV2(s string) string {
+	return markdownV2Escaper.Replace(s)
+}
+
+// Text returns the Message with the plain text body.
+func Text(text string) Message {
+	return Message{Body: []Segment{{Text: text}}}
+}
+
+// Markdown parses the Telegram Markdown text (*bold*, _italic_, `code`, ```pre```, [text](url)) into the Message
+// with the single body segment, so the text formatted for Telegram can be published by any publisher.
+// Unpaired markers are kept as is, so Message.Markdown returns the same text.
+func Markdown(text string) Message {
+	var b strings.Builder
+	var entities []Entity
+	entity := func(t EntityType, content, url string) {
+		entities = append(entities, Entity{Type: t, Offset: b.Len(), Length: len(content), URL: url})
+		b.WriteString(content)
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case strings.HasPrefix(rest, "```"):
+			if end := strings.Index(rest[3:], "```"); end >= 0 {
+				entity(EntityPre, rest[3:3+end], "")
+				i += end + 6
+				continue
+			}
+		case markdownMarkers[rest[0]] != "":
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 {
+				entity(markdownMarkers[rest[0]], rest[1:1+end], "")
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if title, url, n, ok := parseMarkdownLink(rest); ok {
+				entity(EntityLink, title, url)
+				i += n
+				continue
+			}
+		}
+
+		b.WriteByte(text[i])
+		i++
+	}
+
+	return Message{Body: []Segment{{Text: b.String(), Entities: entities}}}
+}
+
+// parseMarkdownLink parses the "[title](url)" link at the start of s and returns its length.
+func parseMarkdownLink(s string) (title, url string, n int, ok bool) {
+	closing := strings.Index(s, "](")
+	if closing < 0 {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[closing+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+
+	return s[1:closing], s[closing+2 : closing+2+end], closing + 3 + end, true
+}
+
+// Markdown renders the Message in the Telegram Markdown: the title, body, hashtags, links and footer on separate lines.
+// The legacy Markdown has no strikethrough, so the EntityStrike text is struck with the combining characters.
+func (m Message) Markdown() string {
+	return m.render(func(s Segment) string {
+		return s.render(func(e Entity, text string) string {
+			switch e.Type {
+			case EntityBold:
+				return "*" + text + "*"
+			case EntityItalic:
+				return "_" + text + "_"
+			case EntityCode:
+				return "`" + text + "`"
+			case EntityPre:
+				return "```" + text + "```"
+			case EntityLink:
+				return fmt.Sprintf("[%s](%s)", text, e.URL)
+			case EntityStrike:
+				return strikeCombining(text)
+			}
+			return text
+		}, asIs)
+	}, func(l Link) string {
+		return fmt.Sprintf("[%s](%s)", l.Title, l.URL)
+	}, asIs)
+}
+
+// MarkdownV2 renders the Message in the Telegram MarkdownV2 with the escaped text, so the reserved characters
+// of the dynamic text (e.g. "_" or "." in the titles) don't break the message.
+func (m Message) MarkdownV2() string {
+	return m.render(func(s Segment) string {
+		return s.render(func(e Entity, text string) string {
+			switch e.Type {
+			case EntityBold:
+				return "*" + EscapeMarkdownV2(text) + "*"
+			case EntityItalic:
+				return "_" + EscapeMarkdownV2(text) + "_"
+			case EntityCode:
+				return "`" + markdownV2CodeEscaper.Replace(text) + "`"
+			case EntityPre:
+				return "```" + markdownV2CodeEscaper.Replace(text) + "```"
+			case EntityLink:
+				return fmt.Sprintf("[%s](%s)", EscapeMarkdownV2(text), markdownV2URLEscaper.Replace(e.URL))
+			case EntityStrike:
+				return "~" + EscapeMarkdownV2(text) + "~"
+			}
+			return EscapeMarkdownV2(text)
+		}, EscapeMarkdownV2)
+	}, func(l Link) string {
+		return fmt.Sprintf("[%s](%s)", EscapeMarkdownV2(l.Title), markdownV2URLEscaper.Replace(l.URL))
+	}, EscapeMarkdownV2)
+}
+
+// HTML renders the Message in the Telegram HTML with the escaped text.
+func (m Message) HTML() string {
+	return m.render(func(s Segment) string {
+		return s.render(func(e Entity, text string) string {
+			text = html.EscapeString(text)
+			switch e.Type {
+			case EntityBold:
+				return "<b>" + text + "</b>"
+			case EntityItalic:
+				return "<i>" + text + "</i>"
+			case EntityCode:
+				return "<code>" + text + "</code>"
+			case EntityPre:
+				return "<pre>" + text + "</pre>"
+			case EntityLink:
+				return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(e.URL), text)
+			case EntityStrike:
+				return "<s>" + text + "</s>"
+			}
+			return text
+		}, html.EscapeString)
+	}, func(l Link) string {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(l.URL), html.EscapeString(l.Title))
+	}, html.EscapeString)
+}
+
+// PlainText renders the Message as the plain text without formatting, links are listed with their URLs.
+func (m Message) PlainText() string {
+	return m.render(func(s Segment) string {
+		return s.Text
+	}, func(l Link) string {
+		return l.Title + ": " + l.URL
+	}, asIs)
+}
+
+// render renders the Message lines with the given renderers of the body segments, links and the plain text
+// of the title and hashtags.
+func (m Message) render(segment func(Segment) string, link func(Link) string, plain func(string) string) string {
+	lines := make([]string, 0, len(m.Body)+len(m.Links)+len(m.Footer)+2)
+	if m.Title != "" {
+		lines = append(lines, plain(m.Title))
+	}
+	for _, s := range m.Body {
+		lines = append(lines, segment(s))
+	}
+	if len(m.Tags) > 0 {
+		lines = append(lines, plain(m.hashtags()))
+	}
+	for _, l := range m.links() {
+		lines = append(lines, link(l))
+	}
+	for _, s := range m.Footer {
+		lines = append(lines, segment(s))
+	}
+
+	text := strings.Join(lines, "\n")
+	if m.Importance == ImportanceHigh {
+		text = importanceMark + text
+	}
+	return text
+}
+
+// strikeCombining strikes through the text with the combining long stroke overlay after each non-space character.
+func strikeCombining(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		b.WriteRune(r)
+		if !unicode.IsSpace(r) {
+			b.WriteRune('\u0336')
+		}
+	}
+	return b.String()
+}
+
+// links returns the Message links followed by the buttons, which are rendered as links without the inline keyboard.
+func (m Message) links() []Link {
+	if len(m.Buttons) == 0 {
+		return m.Links
+	}
+	return append(slices.Clip(m.Links), m.Buttons...)
+}
+
+// asIs returns the text as is.
+func asIs(text string) string {
+	return text
+}
+
+// hashtags returns the Message tags as the line of hashtags.
+func (m Message) hashtags() string {
+	tags := make([]string, len(m.Tags))
+	for i, t := range m.Tags {
+		tags[i] = "#" + t
+	}
+	return strings.Join(tags, " ")
+}
+
+// render renders the segment text with the entities formatted by the given function and the rest of the text
+// by the plain one. Entities out of the text or overlapping the previous ones are rendered as plain text.
+func (s Segment) render(format func(e Entity, text string) string, plain func(text string) string) string {
+	entities := slices.Clone(s.Entities)
+	slices.SortStableFunc(entities, func(a, b Entity) int { return a.Offset - b.Offset })
+
+	var b strings.Builder
+	pos := 0
+	for _, e := range entities {
+		if e.Offset < pos || e.Length < 0 || e.Offset+e.Length > len(s.Text) {
+			continue
+		}
+		b.WriteString(plain(s.Text[pos:e.Offset]))
+		b.WriteString(format(e, s.Text[e.Offset:e.Offset+e.Length]))
+		pos = e.Offset + e.Length
+	}
+	b.WriteString(plain(s.Text[pos:]))
+
+	return b.String()
+}
@@ -0,0 +1,372 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantText     string
+		wantEntities []Entity
+	}{
+		{
+			name:     "entities",
+			text:     "*Fed* holds _rates_, see [AAPL](https://example.com/AAPL) and `SPY`",
+			wantText: "Fed holds rates, see AAPL and SPY",
+			wantEntities: []Entity{
+				{Type: EntityBold, Offset: 0, Length: 3},
+				{Type: EntityItalic, Offset: 10, Length: 5},
+				{Type: EntityLink, Offset: 21, Length: 4, URL: "https://example.com/AAPL"},
+				{Type: EntityCode, Offset: 30, Length: 3},
+			},
+		},
+		{
+			name:         "pre block",
+			text:         "Calendar:\n```\nCPI 3.1%\n```",
+			wantText:     "Calendar:\n\nCPI 3.1%\n",
+			wantEntities: []Entity{{Type: EntityPre, Offset: 10, Length: 10}},
+		},
+		{
+			name:     "unpaired markers",
+			text:     "5 * 3 = 15, snake_case [not a link",
+			wantText: "5 * 3 = 15, snake_case [not a link",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Markdown(tt.text)
+			if len(got.Body) != 1 {
+				t.Fatalf("Markdown() body = %d segments, want 1", len(got.Body))
+			}
+			if got.Body[0].Text != tt.wantText {
+				t.Errorf("Markdown() text = %q, want %q", got.Body[0].Text, tt.wantText)
+			}
+			if !reflect.DeepEqual(got.Body[0].Entities, tt.wantEntities) {
+				t.Errorf("Markdown() entities = %+v, want %+v", got.Body[0].Entities, tt.wantEntities)
+			}
+			if rendered := got.Markdown(); rendered != tt.text {
+				t.Errorf("Markdown().Markdown() = %q, want the original text", rendered)
+			}
+		})
+	}
+}
+
+func TestMessage_render(t *testing.T) {
+	msg := Message{
+		Title: "Apple beats estimates",
+		Body: []Segment{
+			{Text: "AAPL is up 5%", Entities: []Entity{{Type: EntityLink, Offset: 0, Length: 4, URL: "https://example.com/AAPL"}}},
+			{Text: "Developing: part 2"},
+		},
+		Tags:       []string{"Technology", "earnings"},
+		Links:      []Link{{Title: "Permalink", URL: "https://example.com/news/1"}},
+		Footer:     []Segment{{Text: "Not investment advice.", Entities: []Entity{{Type: EntityItalic, Offset: 0, Length: 22}}}},
+		Importance: ImportanceHigh,
+	}
+
+	wantMarkdown := "⭐️ Apple beats estimates\n[AAPL](https://example.com/AAPL) is up 5%\nDeveloping: part 2\n" +
+		"#Technology #earnings\n[Permalink](https://example.com/news/1)\n_Not investment advice._"
+	if got := msg.Markdown(); got != wantMarkdown {
+		t.Errorf("Markdown() = %q, want %q", got, wantMarkdown)
+	}
+
+	wantPlain := "⭐️ Apple beats estimates\nAAPL is up 5%\nDeveloping: part 2\n" +
+		"#Technology #earnings\nPermalink: https://example.com/news/1\nNot investment advice."
+	if got := msg.PlainText(); got != wantPlain {
+		t.Errorf("PlainText() = %q, want %q", got, wantPlain)
+	}
+}
+
+func TestSegment_render_overlappingEntities(t *testing.T) {
+	s := Segment{Text: "AAPL and MSFT", Entities: []Entity{
+		{Type: EntityBold, Offset: 9, Length: 4},
+		{Type: EntityBold, Offset: 0, Length: 8},
+		{Type: EntityLink, Offset: 2, Length: 4, URL: "https://example.com"},
+		{Type: EntityItalic, Offset: 10, Length: 10},
+	}}
+
+	if got, want := (Message{Body: []Segment{s}}).Markdown(), "*AAPL and* *MSFT*"; got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
+}
+
+func TestMessage_strike(t *testing.T) {
+	msg := Message{Body: []Segment{{Text: "AAPL is up", Entities: []Entity{{Type: EntityStrike, Offset: 0, Length: 7}}}}}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "Markdown", got: msg.Markdown(), want: "A\u0336A\u0336P\u0336L\u0336 i\u0336s\u0336 up"},
+		{name: "MarkdownV2", got: msg.MarkdownV2(), want: "~AAPL is~ up"},
+		{name: "HTML", got: msg.HTML(), want: "<s>AAPL is</s> up"},
+		{name: "PlainText", got: msg.PlainText(), want: "AAPL is up"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s() = %q, want %q", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTelegramPublisher_Publish_media(t *testing.T) {
+	var out bytes.Buffer
+	p := NewSandboxPublisher("@test", &out)
+
+	msg := Markdown("*AAPL* is up")
+	msg.Media = []Media{{Name: "chart.png", Data: []byte("png")}}
+	if _, err := p.Publish(msg); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if got, want := out.String(), "*AAPL* is up\n[photo: 3 bytes]\n"; got != want {
+		t.Errorf("Publish() output = %q, want %q", got, want)
+	}
+}
+
+func TestMessage_MarkdownV2(t *testing.T) {
+	msg := Message{
+		Title: "S&P_500 (SPX) hits 5,000.",
+		Body: []Segment{{
+			Text: "AAPL_US is up 2.5% [est. 1.2%], see `a\\b` code",
+			Entities: []Entity{
+				{Type: EntityLink, Offset: 0, Length: 7, URL: "https://example.com/q?s=(AAPL)"},
+				{Type: EntityBold, Offset: 11, Length: 7},
+				{Type: EntityCode, Offset: 36, Length: 5},
+			},
+		}},
+		Tags:  []string{"earnings_season"},
+		Links: []Link{{Title: "Permalink.", URL: "https://example.com/news/1"}},
+	}
+
+	want := "S&P\\_500 \\(SPX\\) hits 5,000\\.\n" +
+		"[AAPL\\_US](https://example.com/q?s=(AAPL\\)) is *up 2\\.5%* \\[est\\. 1\\.2%\\], see `\\`a\\\\b\\`` code\n" +
+		"\\#earnings\\_season\n[Permalink\\.](https://example.com/news/1)"
+	if got := msg.MarkdownV2(); got != want {
+		t.Errorf("MarkdownV2() = %q, want %q", got, want)
+	}
+}
+
+func TestMessage_HTML(t *testing.T) {
+	msg := Markdown("*Q&A* with <CEO>, see [AAPL](https://example.com/?a=1&b=2)")
+	msg.Title = "P&L"
+
+	want := "P&amp;L\n<b>Q&amp;A</b> with &lt;CEO&gt;, see <a href=\"https://example.com/?a=1&amp;b=2\">AAPL</a>"
+	if got := msg.HTML(); got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+}
+
+func TestTelegramPublisher_Publish_parseMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: "", want: "*Fed* holds rates at 5.25_5.5%\n"},
+		{mode: ModeMarkdownV2, want: "*Fed* holds rates at 5\\.25\\_5\\.5%\n"},
+		{mode: ModeHTML, want: "<b>Fed</b> holds rates at 5.25_5.5%\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var out bytes.Buffer
+			p := NewSandboxPublisher("@test", &out)
+			p.ParseMode = tt.mode
+
+			if _, err := p.Publish(Markdown("*Fed* holds rates at 5.25_5.5%")); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Publish() output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTelegramPublisher_Publish_footer(t *testing.T) {
+	var out bytes.Buffer
+	p := NewSandboxPublisher("@test", &out)
+	p.Footer = Markdown("_Not investment advice._").Body
+
+	msg := Message{Title: "Fed holds rates", Footer: []Segment{{Text: "Source: Reuters"}}}
+	if _, err := p.Publish(msg); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := p.Edit("1", msg); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+
+	want := "Fed holds rates\nSource: Reuters\n\n_Not investment advice._\n" +
+		"[edit 1] Fed holds rates\nSource: Reuters\n\n_Not investment advice._\n"
+	if out.String() != want {
+		t.Errorf("Publish() output = %q, want %q", out.String(), want)
+	}
+	if len(msg.Footer) != 1 {
+		t.Errorf("Publish() changed the message footer to %q", msg.Footer)
+	}
+}
+
+func TestTelegramPublisher_Edit(t *testing.T) {
+	var out bytes.Buffer
+	p := NewSandboxPublisher("@test", &out)
+	p.ParseMode = ModeHTML
+
+	if err := p.Edit("42", Markdown("CPI: *0.3%*")); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if got, want := out.String(), "[edit 42] CPI: <b>0.3%</b>\n"; got != want {
+		t.Errorf("Edit() output = %q, want %q", got, want)
+	}
+
+	// Photos have the caption instead of the text
+	var caption string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/editMessageText"):
+			_, _ = io.WriteString(w, `{"ok":false,"error_code":400,"description":"Bad Request: there is no text in the message to edit"}`)
+		case strings.HasSuffix(r.URL.Path, "/editMessageCaption"):
+			caption = r.FormValue("caption")
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":42,"date":0,"chat":{"id":1}}}`)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", &http.Client{Transport: rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = &TelegramPublisher{ChannelID: "@test", BotAPI: bot, ShouldPublish: true}
+
+	if err := p.Edit("42", Text("AAPL is up")); err != nil || caption != "AAPL is up" {
+		t.Errorf("Edit() of the photo = %v with caption %q, want the caption replaced", err, caption)
+	}
+	if err := p.Edit("42", Text(strings.Repeat("a", telegramCaptionLimit+1))); !errors.Is(err, ErrCaptionTooLong) {
+		t.Errorf("Edit() of the photo with the long text error = %v, want %v", err, ErrCaptionTooLong)
+	}
+}
+
+func TestTelegramPublisher_Delete(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewSandboxPublisher("@test", &out).Delete("42"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, want := out.String(), "[delete 42]\n"; got != want {
+		t.Errorf("Delete() output = %q, want %q", got, want)
+	}
+
+	var deleted url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/deleteMessage") && r.FormValue("message_id") == "42":
+			deleted = r.Form
+			_, _ = io.WriteString(w, `{"ok":true,"result":true}`)
+		default:
+			_, _ = io.WriteString(w, `{"ok":false,"error_code":400,"description":"Bad Request: message can't be deleted"}`)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", &http.Client{Transport: rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &TelegramPublisher{ChannelID: "@test", BotAPI: bot, ShouldPublish: true}
+
+	if err := p.Delete("42"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if deleted.Get("chat_id") != "@test" {
+		t.Errorf("Delete() chat_id = %q, want @test", deleted.Get("chat_id"))
+	}
+	if err := p.Delete("7"); err == nil {
+		t.Error("Delete() of the old message error = nil, want the Telegram error")
+	}
+}
+
+func TestTelegramPublisher_Publish_buttons(t *testing.T) {
+	buttons := []Link{
+		{Title: "Source", URL: "https://example.com/news"},
+		{Title: "Chart", URL: "https://example.com/chart"},
+		{Title: "More on $AAPL", URL: "https://example.com/AAPL"},
+		{Title: "Permalink", URL: "https://example.com/news/1"},
+	}
+
+	// The sandbox lists the buttons as links
+	var out bytes.Buffer
+	msg := Text("AAPL is up")
+	msg.Buttons = buttons[:1]
+	if _, err := NewSandboxPublisher("@test", &out).Publish(msg); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got, want := out.String(), "AAPL is up\n[Source](https://example.com/news)\n"; got != want {
+		t.Errorf("Publish() output = %q, want %q", got, want)
+	}
+
+	var text, markup string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			text, markup = r.FormValue("text"), r.FormValue("reply_markup")
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":7,"date":0,"chat":{"id":1}}}`)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", &http.Client{Transport: rewriteTransport{target: target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &TelegramPublisher{ChannelID: "@test", BotAPI: bot, ShouldPublish: true}
+
+	msg.Buttons = buttons
+	if _, err := p.Publish(msg); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if text != "AAPL is up" {
+		t.Errorf("Publish() text = %q, want the buttons out of the text", text)
+	}
+
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(markup), &keyboard); err != nil {
+		t.Fatalf("reply_markup %q: %v", markup, err)
+	}
+	rows := keyboard.InlineKeyboard
+	if len(rows) != 2 || len(rows[0]) != 3 || len(rows[1]) != 1 ||
+		rows[0][2].Text != "More on $AAPL" || *rows[0][2].URL != "https://example.com/AAPL" {
+		t.Errorf("reply_markup = %s, want 4 URL buttons in rows of 3", markup)
+	}
+
+	// Messages without buttons have no keyboard
+	if _, err := p.Publish(Text("MSFT is up")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if markup != "" {
+		t.Errorf("reply_markup = %q, want none without buttons", markup)
+	}
+}
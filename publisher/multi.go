@@ -0,0 +1,125 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var _ AttachmentPublisher = (*MultiPublisher)(nil)
+
+// MultiPublisher publishes the same message to several targets (e.g. Telegram and Slack).
+// The first target is the primary one: its channel and publication IDs are returned, so the news is
+// bound to the primary publication. Errors of the targets are collected into MultiError.
+//
+// The message is published to the other targets only after the primary one, so the message retried after
+// the failure of the primary target isn't duplicated in the others, and the failed other targets are never retried
+// (see MultiError.Partial).
+type MultiPublisher struct {
+	Targets []Publisher // primary target first
+}
+
+// NewMultiPublisher creates a new MultiPublisher with the primary target and the others.
+func NewMultiPublisher(primary Publisher, others ...Publisher) *MultiPublisher {
+	return &MultiPublisher{Targets: append([]Publisher{primary}, others...)}
+}
+
+// TargetError is the error of the MultiPublisher target.
+type TargetError struct {
+	Target  int    // index of the target in MultiPublisher.Targets (0 is the primary one)
+	Channel string // channel ID of the target
+	Err     error
+}
+
+// MultiError is the error of the MultiPublisher publication with the errors of the failed targets.
+type MultiError struct {
+	Errors []TargetError // errors of the failed targets in their order
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, te := range e.Errors {
+		msgs[i] = fmt.Sprintf("%s: %s", te.Channel, te.Err)
+	}
+	return fmt.Sprintf("failed to publish to %d targets: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the targets, so errors.Is and errors.As find the cause (e.g. the network error).
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, te := range e.Errors {
+		errs[i] = te.Err
+	}
+	return errs
+}
+
+// Partial returns true if the message was published to the primary target, but not to some others.
+func (e *MultiError) Partial() bool {
+	for _, te := range e.Errors {
+		if te.Target == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPartial returns true if err is the MultiError of the message published to the primary target only partially
+// (see MultiError.Partial), so it must not be published again.
+func IsPartial(err error) bool {
+	var multiErr *MultiError
+	return errors.As(err, &multiErr) && multiErr.Partial()
+}
+
+// Channel returns the channel ID of the primary target.
+func (m *MultiPublisher) Channel() string {
+	return m.Targets[0].Channel()
+}
+
+// Primary returns the primary target.
+func (m *MultiPublisher) Primary() Publisher {
+	return m.Targets[0]
+}
+
+// Publish publishes the message to the primary target, then to the others concurrently (each target renders it
+// natively) and returns the publication ID of the primary target. Returns *MultiError if any target failed.
+func (m *MultiPublisher) Publish(msg Message) (pubID string, err error) {
+	pubID, _, err = m.PublishWithReplies(msg)
+	return pubID, err
+}
+
+// PublishWithReplies publishes the message as Publish and returns the IDs of the images sent as the replies
+// to the primary publication (if the primary target is the AttachmentPublisher).
+func (m *MultiPublisher) PublishWithReplies(msg Message) (pubID string, replyIDs []string, err error) {
+	if p, ok := m.Targets[0].(AttachmentPublisher); ok {
+		pubID, replyIDs, err = p.PublishWithReplies(msg)
+	} else {
+		pubID, err = m.Targets[0].Publish(msg)
+	}
+	if err != nil {
+		return pubID, nil, &MultiError{Errors: []TargetError{{Target: 0, Channel: m.Targets[0].Channel(), Err: err}}}
+	}
+
+	errs := make([]error, len(m.Targets))
+	var wg sync.WaitGroup
+	for i, p := range m.Targets[1:] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i+1] = p.Publish(msg)
+		}()
+	}
+	wg.Wait()
+
+	var multiErr MultiError
+	for i, err := range errs {
+		if err != nil {
+			multiErr.Errors = append(multiErr.Errors, TargetError{Target: i, Channel: m.Targets[i].Channel(), Err: err})
+		}
+	}
+
+	if len(multiErr.Errors) > 0 {
+		return pubID, replyIDs, &multiErr
+	}
+	return pubID, replyIDs, nil
+}
@@ -0,0 +1,86 @@
+package publisher
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type fakeTarget struct {
+	channel string
+	err     error
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (f *fakeTarget) Publish(msg Message) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, msg.Markdown())
+	return f.channel + "-1", nil
+}
+
+func (f *fakeTarget) Channel() string {
+	return f.channel
+}
+
+func TestMultiPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name        string
+		primaryErr  error
+		otherErr    error
+		wantID      string
+		wantErr     bool
+		wantPartial bool
+	}{
+		{name: "published to all targets", wantID: "@main-1"},
+		{name: "failed other target", otherErr: errors.New("rate limited"), wantID: "@main-1", wantErr: true, wantPartial: true},
+		{name: "failed primary target", primaryErr: errors.New("bad request"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeTarget{channel: "@main", err: tt.primaryErr}
+			other := &fakeTarget{channel: "discord", err: tt.otherErr}
+			m := NewMultiPublisher(primary, other)
+
+			id, err := m.Publish(Text("Apple beats estimates"))
+			if id != tt.wantID {
+				t.Errorf("Publish() id = %q, want %q", id, tt.wantID)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := IsPartial(err); got != tt.wantPartial {
+				t.Errorf("IsPartial() = %v, want %v", got, tt.wantPartial)
+			}
+			if tt.primaryErr == nil && tt.otherErr == nil && (len(primary.messages) != 1 || len(other.messages) != 1) {
+				t.Errorf("Publish() messages = %v and %v, want the message in both targets", primary.messages, other.messages)
+			}
+			if tt.primaryErr != nil && len(other.messages) != 0 {
+				t.Errorf("Publish() messages = %v, want nothing in the other target after the failed primary", other.messages)
+			}
+			if m.Channel() != "@main" {
+				t.Errorf("Channel() = %s, want the primary channel", m.Channel())
+			}
+		})
+	}
+}
+
+func TestMultiError_Error(t *testing.T) {
+	err := &MultiError{Errors: []TargetError{
+		{Target: 0, Channel: "@main", Err: errors.New("bad request")},
+		{Target: 1, Channel: "discord", Err: errors.New("rate limited")},
+	}}
+
+	want := "failed to publish to 2 targets: @main: bad request; discord: rate limited"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if err.Partial() {
+		t.Errorf("Partial() = true, want false for the failed primary target")
+	}
+}
@@ -1,16 +1,105 @@
 package publisher
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"io"
+	"net/url"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// Publisher publishes messages to the channel. Jobs depend on it rather than on the TelegramPublisher,
+// so other publishers can be plugged in and publishing can be mocked in tests.
+type Publisher interface {
+	// Publish renders the message natively for the channel, publishes it and returns its ID in the channel.
+	Publish(msg Message) (pubID string, err error)
+	// Channel returns the ID of the channel where the messages are published.
+	Channel() string
+}
+
+// ReplyPublisher is the Publisher that can reply to the previously published messages.
+type ReplyPublisher interface {
+	Publisher
+	PublishReply(msg Message, replyToID string) (pubID string, err error)
+}
+
+// EditPublisher is the Publisher that can edit the previously published messages.
+type EditPublisher interface {
+	Publisher
+	Edit(pubID string, msg Message) error
+}
+
+// RetractPublisher is the EditPublisher that can delete the previously published messages.
+type RetractPublisher interface {
+	EditPublisher
+	Delete(pubID string) error
+}
+
+// VoicePublisher is the Publisher that can publish the voice messages.
+type VoicePublisher interface {
+	Publisher
+	PublishVoice(audio []byte, replyToID string) (pubID string, err error)
+}
+
+// MediaPublisher is the Publisher that can publish the images with the message as a caption.
+type MediaPublisher interface {
+	Publisher
+	PublishPhoto(msg Message, image Media) (pubID string, err error)
+	PublishMediaGroup(msg Message, images []Media) (pubID string, err error)
+}
+
+// AttachmentPublisher is the Publisher that also returns the IDs of the images sent as the replies to the message
+// (if it's too long for a caption), so they can be deleted along with the message.
+type AttachmentPublisher interface {
+	Publisher
+	PublishWithReplies(msg Message) (pubID string, replyIDs []string, err error)
+}
+
+// ErrCaptionTooLong is returned when the edited text doesn't fit the caption of the published photo.
+var ErrCaptionTooLong = errors.New("text is too long for the photo caption")
+
+// PollPublisher is the ReplyPublisher that can publish and close the polls.
+type PollPublisher interface {
+	ReplyPublisher
+	PublishPoll(question string, options []string) (pubID string, err error)
+	StopPoll(pubID string) error
+}
+
+var (
+	_ VoicePublisher      = (*TelegramPublisher)(nil)
+	_ PollPublisher       = (*TelegramPublisher)(nil)
+	_ EditPublisher       = (*TelegramPublisher)(nil)
+	_ MediaPublisher      = (*TelegramPublisher)(nil)
+	_ RetractPublisher    = (*TelegramPublisher)(nil)
+	_ AttachmentPublisher = (*TelegramPublisher)(nil)
+)
+
+// Telegram parse modes of the messages.
+const (
+	ModeMarkdown   = tgbotapi.ModeMarkdown // legacy Markdown, the text is published as formatted by the jobs
+	ModeMarkdownV2 = "MarkdownV2"          // MarkdownV2 with the escaped text (see Message.MarkdownV2)
+	ModeHTML       = tgbotapi.ModeHTML     // HTML with the escaped text (see Message.HTML)
+)
+
+// ParseModes are the supported Telegram parse modes.
+var ParseModes = []string{ModeMarkdown, ModeMarkdownV2, ModeHTML}
+
 type TelegramPublisher struct {
 	ChannelID     string // Telegram channel id (e.g. @my_channel)
 	BotAPI        *tgbotapi.BotAPI
-	ShouldPublish bool // If false, will print the message to the console (for development)
+	ShouldPublish bool      // If false, will print the message to the console (for development)
+	Output        io.Writer // Where to print the message if ShouldPublish is false (os.Stdout by default)
+	Verifier      *Verifier // Confirms whether the message was published after the ambiguous error (optional)
+	ParseMode     string    // Parse mode of the messages, one of ParseModes (ModeMarkdown by default)
+	Queue         *Queue    // Spaces the requests and retries the flood control errors (optional)
+	Footer        []Segment // Paragraphs appended to every message after the empty line, e.g. the disclaimer (optional)
 }
 
 func NewTelegramPublisher(channelID string, token string, shouldPublish bool) (*TelegramPublisher, error) {
@@ -25,19 +114,396 @@ func NewTelegramPublisher(channelID string, token string, shouldPublish bool) (*
 	}, nil
 }
 
-func (t *TelegramPublisher) Publish(msg string) (pubID string, err error) {
+// NewSandboxPublisher creates a TelegramPublisher that never calls Telegram API and writes all messages to w instead.
+// It is used in the sandbox mode to run the whole pipeline locally without any credentials.
+func NewSandboxPublisher(channelID string, w io.Writer) *TelegramPublisher {
+	return &TelegramPublisher{
+		ChannelID:     channelID,
+		ShouldPublish: false,
+		Output:        w,
+	}
+}
+
+// send sends the request to Telegram through the Queue (right away without it).
+func (t *TelegramPublisher) send(c tgbotapi.Chattable) (m tgbotapi.Message, err error) {
+	err = t.Queue.do(func() (err error) {
+		m, err = t.BotAPI.Send(c)
+		return err
+	})
+	return m, err
+}
+
+// request makes the request to the Telegram API method through the Queue (right away without it).
+func (t *TelegramPublisher) request(endpoint string, params url.Values) (resp tgbotapi.APIResponse, err error) {
+	err = t.Queue.do(func() (err error) {
+		resp, err = t.BotAPI.MakeRequest(endpoint, params)
+		return err
+	})
+	return resp, err
+}
+
+// Channel returns the Telegram channel ID.
+func (t *TelegramPublisher) Channel() string {
+	return t.ChannelID
+}
+
+// parseMode returns the parse mode of the messages.
+func (t *TelegramPublisher) parseMode() string {
+	if t.ParseMode == "" {
+		return ModeMarkdown
+	}
+	return t.ParseMode
+}
+
+// render renders the message with the Footer in the parse mode of the publisher.
+func (t *TelegramPublisher) render(msg Message) string {
+	if len(t.Footer) > 0 {
+		msg.Footer = append(append(slices.Clone(msg.Footer), Segment{}), t.Footer...)
+	}
+
+	switch t.parseMode() {
+	case ModeMarkdownV2:
+		return msg.MarkdownV2()
+	case ModeHTML:
+		return msg.HTML()
+	default:
+		return msg.Markdown()
+	}
+}
+
+// Publish publishes the message in the parse mode of the publisher. The message with the media is published
+// as the photo or the album with the caption (see PublishPhoto and PublishMediaGroup).
+func (t *TelegramPublisher) Publish(msg Message) (pubID string, err error) {
+	pubID, _, err = t.PublishWithReplies(msg)
+	return pubID, err
+}
+
+// PublishWithReplies publishes the message as Publish and returns the IDs of the images sent as the replies to it.
+func (t *TelegramPublisher) PublishWithReplies(msg Message) (pubID string, replyIDs []string, err error) {
+	if len(msg.Media) > 0 {
+		return t.publishMediaGroup(msg, msg.Media)
+	}
+	pubID, err = t.publishText(msg)
+	return pubID, nil, err
+}
+
+// inlineKeyboard returns the message without the buttons and the inline keyboard of them to be sent with the message.
+// The message is returned as is without the keyboard if it has no buttons or it's not published (the buttons
+// are printed as links).
+func (t *TelegramPublisher) inlineKeyboard(message Message) (Message, *tgbotapi.InlineKeyboardMarkup) {
+	if len(message.Buttons) == 0 || !t.ShouldPublish {
+		return message, nil
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, b := range message.Buttons {
+		if i%telegramButtonsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], tgbotapi.NewInlineKeyboardButtonURL(b.Title, b.URL))
+	}
+	message.Buttons = nil
+
+	return message, &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// publishText publishes the message text with the buttons as the inline keyboard.
+func (t *TelegramPublisher) publishText(message Message) (pubID string, err error) {
+	message, keyboard := t.inlineKeyboard(message)
+	msg := t.render(message)
 	if !t.ShouldPublish {
-		fmt.Println(msg)
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintln(w, msg)
 		return "", nil
 	}
 
 	tgMsg := tgbotapi.NewMessageToChannel(t.ChannelID, msg)
-	tgMsg.ParseMode = tgbotapi.ModeMarkdown
+	tgMsg.ParseMode = t.parseMode()
 	tgMsg.DisableWebPagePreview = true
+	if keyboard != nil {
+		tgMsg.ReplyMarkup = keyboard
+	}
+
+	m, err := t.send(tgMsg)
+	if err != nil {
+		id, vErr := t.verify(message.Markdown(), err)
+		if id != "" {
+			return id, nil
+		}
+		return "", errlvl.Wrap(errors.Join(fmt.Errorf("failed to send message to Telegram: %w", err), vErr), errlvl.ERROR)
+	}
+	return strconv.Itoa(m.MessageID), nil
+}
+
+// PublishReply publishes the message as a reply to the previously published message with the given ID.
+func (t *TelegramPublisher) PublishReply(message Message, replyToID string) (pubID string, err error) {
+	message, keyboard := t.inlineKeyboard(message)
+	msg := t.render(message)
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "[reply to %s] %s\n", replyToID, msg)
+		return "", nil
+	}
+
+	replyTo, err := strconv.Atoi(replyToID)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("invalid message ID to reply %q: %w", replyToID, err), errlvl.ERROR)
+	}
+
+	tgMsg := tgbotapi.NewMessageToChannel(t.ChannelID, msg)
+	tgMsg.ParseMode = t.parseMode()
+	tgMsg.DisableWebPagePreview = true
+	tgMsg.ReplyToMessageID = replyTo
+	if keyboard != nil {
+		tgMsg.ReplyMarkup = keyboard
+	}
+
+	m, err := t.send(tgMsg)
+	if err != nil {
+		id, vErr := t.verify(message.Markdown(), err)
+		if id != "" {
+			return id, nil
+		}
+		return "", errlvl.Wrap(errors.Join(fmt.Errorf("failed to send reply to Telegram: %w", err), vErr), errlvl.ERROR)
+	}
+	return strconv.Itoa(m.MessageID), nil
+}
 
-	m, err := t.BotAPI.Send(tgMsg)
+// Edit replaces the text of the previously published message with the given ID (e.g. the daily calendar plan
+// with the actual values of the events). The inline keyboard is replaced with the message buttons (removed without them).
+// The caption is replaced if the message is the photo, ErrCaptionTooLong is returned if the text doesn't fit it.
+func (t *TelegramPublisher) Edit(pubID string, message Message) error {
+	message, keyboard := t.inlineKeyboard(message)
+	msg := t.render(message)
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "[edit %s] %s\n", pubID, msg)
+		return nil
+	}
+
+	id, err := strconv.Atoi(pubID)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("invalid message ID to edit %q: %w", pubID, err), errlvl.ERROR)
+	}
+
+	edit := tgbotapi.EditMessageTextConfig{
+		BaseEdit:              tgbotapi.BaseEdit{ChannelUsername: t.ChannelID, MessageID: id, ReplyMarkup: keyboard},
+		Text:                  msg,
+		ParseMode:             t.parseMode(),
+		DisableWebPagePreview: true,
+	}
+	_, err = t.send(edit)
+	if isNoTextError(err) {
+		if utf8.RuneCountInString(msg) > telegramCaptionLimit {
+			return errlvl.Wrap(fmt.Errorf("failed to edit photo %s in Telegram: %w", pubID, ErrCaptionTooLong), errlvl.ERROR)
+		}
+		_, err = t.send(tgbotapi.EditMessageCaptionConfig{
+			BaseEdit:  tgbotapi.BaseEdit{ChannelUsername: t.ChannelID, MessageID: id, ReplyMarkup: keyboard},
+			Caption:   msg,
+			ParseMode: t.parseMode(),
+		})
+	}
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to edit message %s in Telegram: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// isNoTextError returns true if Telegram refused to edit the text of the message without it (e.g. the photo).
+func isNoTextError(err error) bool {
+	var tgErr tgbotapi.Error
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Message, "no text in the message")
+}
+
+// telegramCaptionLimit is the maximum length of the photo caption in Telegram.
+const telegramCaptionLimit = 1024
+
+// telegramButtonsPerRow is the number of the message buttons in a row of the inline keyboard.
+const telegramButtonsPerRow = 3
+
+// PublishPhoto publishes the PNG image with the message as a caption and the buttons as the inline keyboard.
+// If the message is too long for a caption, it is published as a separate message and the image is sent as a reply.
+func (t *TelegramPublisher) PublishPhoto(message Message, image Media) (pubID string, err error) {
+	pubID, _, err = t.publishPhoto(message, image)
+	return pubID, err
+}
+
+// publishPhoto publishes the photo as PublishPhoto and returns the ID of the image sent as the reply (if any).
+func (t *TelegramPublisher) publishPhoto(message Message, image Media) (pubID string, replyIDs []string, err error) {
+	original := message
+	message, keyboard := t.inlineKeyboard(message)
+	msg := t.render(message)
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "%s\n[photo: %d bytes]\n", msg, len(image.Data))
+		return "", nil, nil
+	}
+
+	params := url.Values{"chat_id": {t.ChannelID}}
+	if utf8.RuneCountInString(msg) <= telegramCaptionLimit {
+		params.Set("caption", msg)
+		params.Set("parse_mode", t.parseMode())
+		if keyboard != nil {
+			markup, err := json.Marshal(keyboard)
+			if err != nil {
+				return "", nil, errlvl.Wrap(fmt.Errorf("failed to encode inline keyboard: %w", err), errlvl.ERROR)
+			}
+			params.Set("reply_markup", string(markup))
+		}
+	} else {
+		pubID, err = t.publishText(original)
+		if err != nil {
+			return "", nil, err
+		}
+		params.Set("reply_to_message_id", pubID)
+	}
+
+	m, err := t.sendFile("sendPhoto", params, "photo", image)
+	if err != nil {
+		return pubID, nil, errlvl.Wrap(fmt.Errorf("failed to send photo to Telegram: %w", err), errlvl.ERROR)
+	}
+	if pubID != "" {
+		return pubID, []string{strconv.Itoa(m.MessageID)}, nil
+	}
+	return strconv.Itoa(m.MessageID), nil, nil
+}
+
+// PublishVoice publishes the OGG/Opus audio as the voice message in reply to the previously published message
+// with the given ID (e.g. the narrated text brief). Empty replyToID publishes the voice message on its own.
+func (t *TelegramPublisher) PublishVoice(audio []byte, replyToID string) (pubID string, err error) {
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "[voice in reply to %s: %d bytes]\n", replyToID, len(audio))
+		return "", nil
+	}
+
+	params := url.Values{"chat_id": {t.ChannelID}}
+	if replyToID != "" {
+		if _, err := strconv.Atoi(replyToID); err != nil {
+			return "", errlvl.Wrap(fmt.Errorf("invalid message ID to reply %q: %w", replyToID, err), errlvl.ERROR)
+		}
+		params.Set("reply_to_message_id", replyToID)
+	}
+
+	m, err := t.sendFile("sendVoice", params, "voice", Media{Name: "brief.ogg", Data: audio})
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send voice to Telegram: %w", err), errlvl.ERROR)
+	}
+	return strconv.Itoa(m.MessageID), nil
+}
+
+// PublishPoll publishes the anonymous poll with the question and the answer options (2-10).
+func (t *TelegramPublisher) PublishPoll(question string, options []string) (pubID string, err error) {
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "[poll] %s\n- %s\n", question, strings.Join(options, "\n- "))
+		return "", nil
+	}
+
+	opts, err := json.Marshal(options)
 	if err != nil {
-		return "", errlvl.Wrap(fmt.Errorf("failed to send message to Telegram: %w", err), errlvl.ERROR)
+		return "", errlvl.Wrap(fmt.Errorf("failed to encode poll options: %w", err), errlvl.ERROR)
+	}
+
+	resp, err := t.request("sendPoll", url.Values{
+		"chat_id":  {t.ChannelID},
+		"question": {question},
+		"options":  {string(opts)},
+	})
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send poll to Telegram: %w", err), errlvl.ERROR)
+	}
+
+	var m tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &m); err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to decode sent poll: %w", err), errlvl.ERROR)
 	}
 	return strconv.Itoa(m.MessageID), nil
 }
+
+// StopPoll closes the previously published poll with the given ID, so it can't be voted anymore.
+func (t *TelegramPublisher) StopPoll(pubID string) error {
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "[stop poll %s]\n", pubID)
+		return nil
+	}
+
+	_, err := t.request("stopPoll", url.Values{
+		"chat_id":    {t.ChannelID},
+		"message_id": {pubID},
+	})
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to stop poll %s: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// Delete deletes the published message with the given ID, e.g. the retracted news. Telegram deletes the channel
+// messages only within 48 hours after the publication, older ones can only be edited (see Edit).
+func (t *TelegramPublisher) Delete(pubID string) error {
+	if !t.ShouldPublish {
+		w := t.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		_, _ = fmt.Fprintf(w, "[delete %s]\n", pubID)
+		return nil
+	}
+
+	_, err := t.request("deleteMessage", url.Values{
+		"chat_id":    {t.ChannelID},
+		"message_id": {pubID},
+	})
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to delete message %s in Telegram: %w", pubID, err), errlvl.ERROR)
+	}
+	return nil
+}
+
+// CheckPermissions verifies that the bot is reachable and is allowed to post messages to the channel.
+func (t *TelegramPublisher) CheckPermissions() error {
+	me, err := t.BotAPI.GetMe()
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to get bot info: %w", err), errlvl.ERROR)
+	}
+
+	chatConfig := tgbotapi.ChatConfigWithUser{UserID: me.ID}
+	if id, err := strconv.ParseInt(t.ChannelID, 10, 64); err == nil {
+		chatConfig.ChatID = id
+	} else {
+		chatConfig.SuperGroupUsername = t.ChannelID
+	}
+
+	member, err := t.BotAPI.GetChatMember(chatConfig)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("failed to get bot membership in %s: %w", t.ChannelID, err), errlvl.ERROR)
+	}
+
+	if !member.IsCreator() && !member.CanPostMessages && !member.CanSendMessages {
+		return errlvl.Wrap(fmt.Errorf("bot @%s can't post messages to %s (status: %s)", me.UserName, t.ChannelID, member.Status), errlvl.ERROR)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package publisher
+
+import (
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	queueAttempts      = 3                // attempts of the request failed with the retryable error
+	queueMaxRetryAfter = 30 * time.Second // longer flood control waits are returned to the caller
+	queueRetryDelay    = time.Second      // delay before retrying the request failed with the connection error
+)
+
+// Queue sends the Telegram API requests of the publisher one by one with the minimal interval between them,
+// so the burst of the news doesn't hit the Telegram flood control (429 Too Many Requests). Requests rejected by
+// the flood control are retried after the requested retry_after (up to 30s), requests failed to connect (DNS,
+// refused connection) are retried after a second, up to 3 attempts in total. Ambiguous errors (see IsAmbiguous)
+// are never retried, because the message could be published anyway.
+type Queue struct {
+	interval time.Duration
+	metrics  QueueMetrics
+
+	mu   sync.Mutex // held by the sending request, others wait in the queue
+	next time.Time  // time the next request can be sent at
+
+	sleep func(d time.Duration) // time.Sleep, replaced in tests
+}
+
+// QueueMetrics holds the counters of the Queue requests.
+type QueueMetrics struct {
+	depth     atomic.Int64 // requests waiting for the turn
+	maxDepth  atomic.Int64 // max depth since the last reset
+	sent      atomic.Int64 // successful requests
+	throttled atomic.Int64 // requests rejected by the flood control
+	retries   atomic.Int64 // retried requests
+	failed    atomic.Int64 // requests failed after all attempts
+
+	mu      sync.Mutex           // guards waiting and seq
+	waiting map[uint64]time.Time // times the waiting requests were queued at by their sequence numbers
+	seq     uint64               // sequence number of the last queued request
+}
+
+// NewQueue creates the Queue that sends the requests at least the interval apart.
+func NewQueue(interval time.Duration) *Queue {
+	return &Queue{interval: interval, sleep: time.Sleep}
+}
+
+// Metrics returns the counters of the queue requests. Nil queue has no metrics.
+func (q *Queue) Metrics() *QueueMetrics {
+	if q == nil {
+		return nil
+	}
+	return &q.metrics
+}
+
+// Depth returns the number of requests waiting for the turn.
+func (m *QueueMetrics) Depth() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.depth.Load()
+}
+
+// Oldest returns the time the oldest of the requests waiting for the turn was queued at (zero if none wait).
+func (m *QueueMetrics) Oldest() time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var oldest time.Time
+	for _, t := range m.waiting {
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+	return oldest
+}
+
+// wait registers the request waiting for the turn and returns the function that unregisters it.
+func (m *QueueMetrics) wait() (done func()) {
+	depth := m.depth.Add(1)
+	for {
+		maxDepth := m.maxDepth.Load()
+		if depth <= maxDepth || m.maxDepth.CompareAndSwap(maxDepth, depth) {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.waiting == nil {
+		m.waiting = make(map[uint64]time.Time)
+	}
+	m.seq++
+	seq := m.seq
+	m.waiting[seq] = time.Now()
+
+	return func() {
+		m.depth.Add(-1)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.waiting, seq)
+	}
+}
+
+// Reset returns the current counters by name and sets them to zero (the max depth to the current depth).
+func (m *QueueMetrics) Reset() map[string]int64 {
+	if m == nil {
+		return nil
+	}
+
+	return map[string]int64{
+		"sent":      m.sent.Swap(0),
+		"throttled": m.throttled.Swap(0),
+		"retries":   m.retries.Swap(0),
+		"failed":    m.failed.Swap(0),
+		"max depth": m.maxDepth.Swap(m.depth.Load()),
+	}
+}
+
+// do sends the request when its turn comes and retries it while it fails with the retryable error.
+// Nil queue sends the request right away.
+func (q *Queue) do(send func() error) error {
+	if q == nil {
+		return send()
+	}
+
+	done := q.metrics.wait()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	done()
+
+	for attempt := 1; ; attempt++ {
+		if wait := time.Until(q.next); wait > 0 {
+			q.sleep(wait)
+		}
+
+		err := send()
+		q.next = time.Now().Add(q.interval)
+		if err == nil {
+			q.metrics.sent.Add(1)
+			return nil
+		}
+
+		var tgErr tgbotapi.Error
+		if errors.As(err, &tgErr) && tgErr.RetryAfter > 0 {
+			q.metrics.throttled.Add(1)
+		}
+
+		delay, ok := queueRetryAfter(err)
+		if !ok || attempt >= queueAttempts {
+			q.metrics.failed.Add(1)
+			return err
+		}
+
+		q.metrics.retries.Add(1)
+		if next := time.Now().Add(delay); next.After(q.next) {
+			q.next = next
+		}
+	}
+}
+
+// queueRetryAfter returns the delay before retrying the request and true if the error is retryable:
+// the flood control error with retry_after up to queueMaxRetryAfter or the connection error.
+func queueRetryAfter(err error) (time.Duration, bool) {
+	var tgErr tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		delay := time.Duration(tgErr.RetryAfter) * time.Second
+		return delay, delay > 0 && delay <= queueMaxRetryAfter
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && !IsAmbiguous(err) {
+		return queueRetryDelay, true
+	}
+
+	return 0, false
+}
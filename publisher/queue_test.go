@@ -0,0 +1,166 @@
+package publisher
+
+import (
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue_do(t *testing.T) {
+	floodErr := tgbotapi.Error{Message: "Too Many Requests: retry after 5", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5}}
+	tests := []struct {
+		name      string
+		errs      []error // errors of the consecutive attempts, nil for success
+		wantErr   bool
+		wantCalls int
+		wantSleep time.Duration // min sleep before the last attempt
+		want      map[string]int64
+	}{
+		{
+			name:      "success",
+			errs:      []error{nil},
+			wantCalls: 1,
+			want:      map[string]int64{"sent": 1, "throttled": 0, "retries": 0, "failed": 0, "max depth": 1},
+		},
+		{
+			name:      "flood control retried after retry_after",
+			errs:      []error{floodErr, nil},
+			wantCalls: 2,
+			wantSleep: 4 * time.Second,
+			want:      map[string]int64{"sent": 1, "throttled": 1, "retries": 1, "failed": 0, "max depth": 1},
+		},
+		{
+			name:      "long flood control wait is not retried",
+			errs:      []error{tgbotapi.Error{Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 60}}},
+			wantErr:   true,
+			wantCalls: 1,
+			want:      map[string]int64{"sent": 0, "throttled": 1, "retries": 0, "failed": 1, "max depth": 1},
+		},
+		{
+			name:      "connection error retried",
+			errs:      []error{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, nil},
+			wantCalls: 2,
+			wantSleep: queueRetryDelay / 2,
+			want:      map[string]int64{"sent": 1, "throttled": 0, "retries": 1, "failed": 0, "max depth": 1},
+		},
+		{
+			name:      "ambiguous error is not retried",
+			errs:      []error{io.ErrUnexpectedEOF},
+			wantErr:   true,
+			wantCalls: 1,
+			want:      map[string]int64{"sent": 0, "throttled": 0, "retries": 0, "failed": 1, "max depth": 1},
+		},
+		{
+			name:      "API error is not retried",
+			errs:      []error{tgbotapi.Error{Message: "Bad Request: chat not found"}},
+			wantErr:   true,
+			wantCalls: 1,
+			want:      map[string]int64{"sent": 0, "throttled": 0, "retries": 0, "failed": 1, "max depth": 1},
+		},
+		{
+			name:      "attempts exhausted",
+			errs:      []error{floodErr, floodErr, floodErr},
+			wantErr:   true,
+			wantCalls: queueAttempts,
+			wantSleep: 4 * time.Second,
+			want:      map[string]int64{"sent": 0, "throttled": 3, "retries": 2, "failed": 1, "max depth": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueue(0)
+			var slept time.Duration
+			q.sleep = func(d time.Duration) { slept = d }
+
+			calls := 0
+			err := q.do(func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("do() calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if slept < tt.wantSleep {
+				t.Errorf("do() slept %s before the last attempt, want at least %s", slept, tt.wantSleep)
+			}
+			if got := q.Metrics().Reset(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Metrics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueue_do_interval(t *testing.T) {
+	q := NewQueue(time.Minute)
+	var sleeps []time.Duration
+	q.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	for range 2 {
+		if err := q.do(func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sleeps) != 1 || sleeps[0] < 59*time.Second {
+		t.Errorf("do() sleeps = %v, want the second request to wait for the interval", sleeps)
+	}
+}
+
+func TestQueue_do_depth(t *testing.T) {
+	q := NewQueue(0)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = q.do(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = q.do(func() error { return nil })
+		}()
+	}
+	for q.Metrics().Depth() < 2 || q.Metrics().Oldest().IsZero() {
+		time.Sleep(time.Millisecond)
+	}
+	if age := time.Since(q.Metrics().Oldest()); age < 0 || age > time.Minute {
+		t.Errorf("Oldest() = %s ago, want the time the waiting request was queued at", age)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := q.Metrics().Reset(); got["max depth"] != 2 || got["sent"] != 3 {
+		t.Errorf("Metrics() = %v, want 3 sent with max depth 2", got)
+	}
+	if q.Metrics().Depth() != 0 || !q.Metrics().Oldest().IsZero() {
+		t.Errorf("Depth() = %d, Oldest() = %s, want none waiting after all requests are sent", q.Metrics().Depth(), q.Metrics().Oldest())
+	}
+}
+
+func TestQueue_nil(t *testing.T) {
+	var q *Queue
+	calls := 0
+	if err := q.do(func() error { calls++; return nil }); err != nil || calls != 1 {
+		t.Errorf("do() of nil queue = %v with %d calls, want the request sent once", err, calls)
+	}
+	if q.Metrics().Reset() != nil || q.Metrics().Depth() != 0 {
+		t.Error("Metrics() of nil queue has counters, want none")
+	}
+}
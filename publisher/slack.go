@@ -0,0 +1,158 @@
+package publisher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	slackTimeout      = 10 * time.Second
+	slackHeaderLimit  = 150  // max length of the header block text
+	slackSectionLimit = 3000 // max length of the section block text
+)
+
+// slackEscaper escapes the control characters of the Slack mrkdwn text.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var _ Publisher = (*SlackPublisher)(nil)
+
+// SlackPublisher publishes the messages to the Slack channel of the incoming webhook as Block Kit blocks.
+// Webhooks can't upload files, so Message.Media is not published.
+type SlackPublisher struct {
+	WebhookURL  string // URL of the incoming webhook
+	ChannelName string // Name of the webhook channel, e.g. "#markets" (used as the channel ID)
+	Client      *http.Client
+}
+
+// NewSlackPublisher creates a new SlackPublisher of the incoming webhook.
+func NewSlackPublisher(webhookURL, channelName string) *SlackPublisher {
+	return &SlackPublisher{
+		WebhookURL:  webhookURL,
+		ChannelName: channelName,
+		Client:      &http.Client{Timeout: slackTimeout},
+	}
+}
+
+// Channel returns the name of the webhook channel.
+func (s *SlackPublisher) Channel() string {
+	return s.ChannelName
+}
+
+// Publish posts the message blocks with the plain text fallback (for notifications) to the webhook.
+// Webhooks don't return the message ID, so pubID is always empty.
+func (s *SlackPublisher) Publish(msg Message) (pubID string, err error) {
+	payload, err := json.Marshal(map[string]any{
+		"text":   msg.PlainText(),
+		"blocks": msg.SlackBlocks(),
+	})
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to encode Slack message: %w", err), errlvl.ERROR)
+	}
+
+	resp, err := s.Client.Post(s.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("failed to send message to Slack: %w", err), errlvl.ERROR)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return "", errlvl.Wrap(fmt.Errorf("failed to send message to Slack: status %d: %s", resp.StatusCode, body), errlvl.ERROR)
+	}
+
+	return "", nil
+}
+
+// SlackBlock is the Block Kit layout block.
+type SlackBlock struct {
+	Type     string      `json:"type"`               // "header", "section" or "context"
+	Text     *SlackText  `json:"text,omitempty"`     // text of the header and section blocks
+	Elements []SlackText `json:"elements,omitempty"` // texts of the context block
+}
+
+// SlackText is the Block Kit text object.
+type SlackText struct {
+	Type string `json:"type"` // "plain_text" or "mrkdwn"
+	Text string `json:"text"`
+}
+
+// SlackBlocks renders the Message as the Block Kit blocks: the title as the header, the body as the section
+// in Slack mrkdwn, the hashtags with the links and the footer as the context.
+func (m Message) SlackBlocks() []SlackBlock {
+	var blocks []SlackBlock
+
+	title := m.Title
+	if m.Importance == ImportanceHigh && title != "" {
+		title = importanceMark + title
+	}
+	if title != "" {
+		blocks = append(blocks, SlackBlock{Type: "header", Text: &SlackText{Type: "plain_text", Text: truncate(title, slackHeaderLimit)}})
+	}
+
+	paragraphs := make([]string, len(m.Body))
+	for i, s := range m.Body {
+		paragraphs[i] = s.slackMarkdown()
+	}
+	body := strings.Join(paragraphs, "\n")
+	if m.Importance == ImportanceHigh && m.Title == "" {
+		body = importanceMark + body
+	}
+	if strings.TrimSpace(body) != "" {
+		blocks = append(blocks, SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: truncate(body, slackSectionLimit)}})
+	}
+
+	var context []SlackText
+	if len(m.Tags) > 0 {
+		context = append(context, SlackText{Type: "mrkdwn", Text: slackEscaper.Replace(m.hashtags())})
+	}
+	for _, l := range m.links() {
+		context = append(context, SlackText{Type: "mrkdwn", Text: fmt.Sprintf("<%s|%s>", l.URL, slackEscaper.Replace(l.Title))})
+	}
+	for _, s := range m.Footer {
+		if strings.TrimSpace(s.Text) != "" {
+			context = append(context, SlackText{Type: "mrkdwn", Text: s.slackMarkdown()})
+		}
+	}
+	if len(context) > 0 {
+		blocks = append(blocks, SlackBlock{Type: "context", Elements: context})
+	}
+
+	return blocks
+}
+
+// slackMarkdown renders the segment in Slack mrkdwn.
+func (s Segment) slackMarkdown() string {
+	return s.render(func(e Entity, text string) string {
+		text = slackEscaper.Replace(text)
+		switch e.Type {
+		case EntityBold:
+			return "*" + text + "*"
+		case EntityItalic:
+			return "_" + text + "_"
+		case EntityCode:
+			return "`" + text + "`"
+		case EntityPre:
+			return "```" + text + "```"
+		case EntityLink:
+			return fmt.Sprintf("<%s|%s>", e.URL, text)
+		case EntityStrike:
+			return "~" + text + "~"
+		}
+		return text
+	}, slackEscaper.Replace)
+}
+
+// truncate returns s cut to the limit of characters with the ellipsis.
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit-1]) + "…"
+}
@@ -0,0 +1,71 @@
+package publisher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMessage_SlackBlocks(t *testing.T) {
+	msg := Message{
+		Title: "Apple beats estimates",
+		Body: []Segment{{
+			Text:     "AAPL is up, P&L <best> ever",
+			Entities: []Entity{{Type: EntityLink, Offset: 0, Length: 4, URL: "https://example.com/AAPL"}, {Type: EntityBold, Offset: 12, Length: 3}},
+		}},
+		Tags:   []string{"Technology"},
+		Links:  []Link{{Title: "Permalink", URL: "https://example.com/news/1"}},
+		Footer: []Segment{{}, {Text: "Not investment advice."}},
+	}
+
+	want := []SlackBlock{
+		{Type: "header", Text: &SlackText{Type: "plain_text", Text: "Apple beats estimates"}},
+		{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: "<https://example.com/AAPL|AAPL> is up, *P&amp;L* &lt;best&gt; ever"}},
+		{Type: "context", Elements: []SlackText{
+			{Type: "mrkdwn", Text: "#Technology"},
+			{Type: "mrkdwn", Text: "<https://example.com/news/1|Permalink>"},
+			{Type: "mrkdwn", Text: "Not investment advice."},
+		}},
+	}
+	got, _ := json.Marshal(msg.SlackBlocks())
+	if wantJSON, _ := json.Marshal(want); string(got) != string(wantJSON) {
+		t.Errorf("SlackBlocks() = %s, want %s", got, wantJSON)
+	}
+}
+
+func TestSlackPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "published", status: http.StatusOK},
+		{name: "invalid payload", status: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload struct {
+				Text   string       `json:"text"`
+				Blocks []SlackBlock `json:"blocks"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			p := NewSlackPublisher(srv.URL, "#markets")
+			_, err := p.Publish(Markdown("*Fed* holds rates"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if payload.Text != "Fed holds rates" || len(payload.Blocks) != 1 || payload.Blocks[0].Text.Text != "*Fed* holds rates" {
+				t.Errorf("Publish() payload = %+v, want the plain text fallback and the section block", payload)
+			}
+			if p.Channel() != "#markets" {
+				t.Errorf("Channel() = %s, want #markets", p.Channel())
+			}
+		})
+	}
+}
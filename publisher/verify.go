@@ -0,0 +1,185 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	verifyAttempts   = 3               // web preview of the channel is updated with a delay
+	verifyDelay      = 3 * time.Second // delay between the verification attempts
+	verifyTimeout    = 10 * time.Second
+	verifyPrefixSize = 64 // number of the normalized message characters compared with the posts
+)
+
+var (
+	postRegexp     = regexp.MustCompile(`(?s)^[^"/]+/(\d+)".*?class="tgme_widget_message_text[^"]*"[^>]*>(.*?)</div>`)
+	tagRegexp      = regexp.MustCompile(`<[^>]+>`)
+	mdLinkRegexp   = regexp.MustCompile(`\[([^\]]*)]\([^)]*\)`)
+	spaceRegexp    = regexp.MustCompile(`\s+`)
+	markdownMarker = strings.NewReplacer("*", "", "_", "", "`", "")
+)
+
+// Verifier confirms whether the message actually landed in the public channel after the ambiguous publish error
+// (Telegram sometimes hangs up, but publishes the message anyway), so the message is not published twice on retry.
+// Bot API can't list the channel messages, so the recent posts are read from the channel web preview (t.me/s/).
+// Private channels (numeric IDs) can't be verified.
+type Verifier struct {
+	URL    string // base URL of the channel web preview
+	Client *http.Client
+}
+
+// NewVerifier creates a new Verifier of the t.me web preview.
+func NewVerifier() *Verifier {
+	return &Verifier{
+		URL:    "https://t.me/s/",
+		Client: &http.Client{Timeout: verifyTimeout},
+	}
+}
+
+// Find returns the ID of the recent channel post with the same text as the Markdown message.
+// Returns empty ID if the message is not found after a few attempts or the channel is private.
+func (v *Verifier) Find(ctx context.Context, channelID, msg string) (string, error) {
+	username, ok := strings.CutPrefix(channelID, "@")
+	if !ok {
+		return "", nil
+	}
+
+	want := normalizeMarkdown(msg)
+	if want == "" {
+		return "", nil
+	}
+
+	for attempt := 0; attempt < verifyAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(verifyDelay):
+			}
+		}
+
+		posts, err := v.recentPosts(ctx, username)
+		if err != nil {
+			return "", err
+		}
+		// The latest posts are at the end of the page
+		for i := len(posts) - 1; i >= 0; i-- {
+			if strings.HasPrefix(posts[i].text, want) {
+				return posts[i].id, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// post is the channel post of the web preview.
+type post struct {
+	id   string
+	text string // normalized text (see normalizeHTML)
+}
+
+// recentPosts returns the recent posts of the public channel in the page order (oldest first).
+func (v *Verifier) recentPosts(ctx context.Context, username string) ([]post, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.URL+username, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating verification request: %w", err)
+	}
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching channel preview: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected channel preview response status %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading channel preview: %w", err)
+	}
+
+	// Each post is matched separately, so the text of the next post is never attributed to the post without text
+	var posts []post
+	for _, chunk := range strings.Split(string(body), `data-post="`)[1:] {
+		if m := postRegexp.FindStringSubmatch(chunk); m != nil {
+			posts = append(posts, post{id: m[1], text: normalizeHTML(m[2])})
+		}
+	}
+
+	return posts, nil
+}
+
+// normalizeMarkdown returns the first characters of the Markdown message as they are displayed:
+// without links URLs, formatting markers and repeated whitespaces.
+func normalizeMarkdown(msg string) string {
+	msg = mdLinkRegexp.ReplaceAllString(msg, "$1")
+	msg = markdownMarker.Replace(msg)
+	return truncateRunes(strings.TrimSpace(spaceRegexp.ReplaceAllString(msg, " ")), verifyPrefixSize)
+}
+
+// normalizeHTML returns the text of the web preview post without tags and repeated whitespaces.
+func normalizeHTML(s string) string {
+	s = strings.ReplaceAll(s, "<br/>", " ")
+	s = html.UnescapeString(tagRegexp.ReplaceAllString(s, ""))
+	return strings.TrimSpace(spaceRegexp.ReplaceAllString(s, " "))
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// verify returns the ID of the message published despite the ambiguous error (see Verifier).
+// Returns empty ID if the error is not ambiguous, the message is not found or the verification is disabled.
+func (t *TelegramPublisher) verify(msg string, sendErr error) (string, error) {
+	if t.Verifier == nil || !IsAmbiguous(sendErr) {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyAttempts*(verifyDelay+verifyTimeout))
+	defer cancel()
+
+	id, err := t.Verifier.Find(ctx, t.ChannelID, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify the message: %w", err)
+	}
+	if id != "" {
+		slog.Default().Warn("[publisher] Message was published despite the error", "channel", t.ChannelID, "id", id, "error", sendErr)
+	}
+
+	return id, nil
+}
+
+// IsAmbiguous returns true if the request could have reached Telegram before the error (timeout, dropped connection),
+// so the message may be published despite the error. Errors of the Telegram API response and connection errors
+// (DNS, refused connection) are not ambiguous.
+func IsAmbiguous(err error) bool {
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	return !errors.As(err, &dnsErr)
+}
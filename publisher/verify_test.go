@@ -0,0 +1,105 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const channelPreview = `<html><body>
+<div class="tgme_widget_message" data-post="my_channel/41">
+  <div class="tgme_widget_message_text js-message_text" dir="auto">Older post</div>
+</div>
+<div class="tgme_widget_message" data-post="my_channel/42">
+  <div class="tgme_widget_message_photo_wrap"></div>
+</div>
+<div class="tgme_widget_message" data-post="my_channel/43">
+  <div class="tgme_widget_message_text js-message_text" dir="auto"><b>Apple</b> beats earnings estimates &amp; raises guidance<br/><a href="https://t.me/my_channel?q=%23AAPL">#AAPL</a></div>
+</div>
+</body></html>`
+
+func TestVerifier_Find(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/s/my_channel" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(channelPreview))
+	}))
+	t.Cleanup(server.Close)
+
+	v := &Verifier{URL: server.URL + "/s/", Client: server.Client()}
+
+	tests := []struct {
+		name      string
+		channelID string
+		msg       string
+		want      string
+	}{
+		{
+			name:      "published",
+			channelID: "@my_channel",
+			msg:       "*Apple* beats earnings estimates & raises guidance\n[#AAPL](https://t.me/my_channel?q=%23AAPL)",
+			want:      "43",
+		},
+		{
+			name:      "private channel",
+			channelID: "-100123",
+			msg:       "Apple beats earnings estimates",
+			want:      "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Find(context.Background(), tt.channelID, tt.msg)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAmbiguous(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "dropped connection",
+			err:  fmt.Errorf("send: %w", &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: io.EOF}),
+			want: true,
+		},
+		{
+			name: "refused connection",
+			err:  &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			want: false,
+		},
+		{
+			name: "DNS error",
+			err:  &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.DNSError{Err: "no such host"}},
+			want: false,
+		},
+		{
+			name: "API error",
+			err:  errors.New("Bad Request: can't parse entities"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAmbiguous(tt.err); got != tt.want {
+				t.Errorf("IsAmbiguous() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/samgozman/fin-thread/archivist"
+	"io"
+	"time"
+)
+
+// runRehash recomputes the hashes of the saved news of the main channel and tenants with the configured
+// NEWS_ID_STRATEGY and prints a report to w. Returns false if any of the databases failed.
+//
+// Saved news don't have the original description if it was truncated with a stricter DESCRIPTION_MAX_LENGTH,
+// so "content" and "simhash" hashes of such news may differ from the ones of the news fetched again.
+func runRehash(cnf *Config, w io.Writer) bool {
+	dsns := map[string]string{cnf.env.TelegramChannelID: cnf.env.PostgresDSN}
+	for chatID, dsn := range cnf.tenants {
+		dsns[chatID] = dsn
+	}
+
+	hash := func(n *archivist.News) string {
+		return cnf.newsIDStrategy.NewsID(n.OriginalTitle, n.OriginalDesc, n.URL, n.GUID)
+	}
+
+	ok := true
+	for chatID, dsn := range dsns {
+		a, err := archivist.NewArchivist(dsn)
+		if err != nil {
+			ok = false
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", chatID, err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		updated, conflicts, err := a.Entities.News.RecomputeHashes(ctx, hash)
+		cancel()
+		if err != nil {
+			ok = false
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: %s (%d updated before the error)\n", chatID, err, updated)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "[DONE] %s: %d news updated to %q hashes, %d kept the old hash because of conflicts\n",
+			chatID, updated, cnf.newsIDStrategy, conflicts)
+	}
+
+	return ok
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/samgozman/fin-thread/composer"
+	"github.com/samgozman/fin-thread/jobs"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// runOnce is the single run of the job requested by `fin-thread run` instead of the standing schedule.
+// App.start builds the jobs as usual, but App.scheduleJob keeps the function of the requested job instead of scheduling it.
+type runOnce struct {
+	key     string       // Key of the job (see Config.schedules), e.g. "market"
+	from    time.Time    // Start of the fixed fetch window of the news jobs (the usual window if zero)
+	to      time.Time    // End of the fixed fetch window
+	dryRun  bool         // If true, messages are written to the console and the database changes are rolled back
+	channel string       // Chat ID the messages of the main channel are published to (the main channel if empty)
+	fn      jobs.JobFunc // Function of the job, set by App.scheduleJob
+}
+
+// newsJobs are the keys of the jobs the fetch window can be fixed for.
+var newsJobs = []string{"market", "broad"}
+
+// capture keeps the function of the requested job of the main channel (the first one with the key).
+func (o *runOnce) capture(key string, fn jobs.JobFunc) {
+	if key == o.key && o.fn == nil {
+		o.fn = fn
+	}
+}
+
+// run runs the captured job function once.
+func (o *runOnce) run() error {
+	if o.fn == nil {
+		return fmt.Errorf("job %s is not scheduled with the current configuration", o.key)
+	}
+	return o.fn()
+}
+
+// runJob runs the job of the main channel once with the overrides from the arguments and without touching the schedule
+// of the running instance, e.g. to debug the production issue. Prints the result to w.
+// Returns false if the arguments are invalid or the run failed.
+//
+// Arguments are the job key (see SCHEDULES) followed by the flags:
+//   - -since: start of the fetch window of the market and broad news jobs, e.g. 3h ago (the usual window if 0);
+//   - -until: end of the fetch window, e.g. 1h ago (now by default);
+//   - -dry-run: write the messages to the console and roll back the database changes (the migration included);
+//   - -channel: chat ID to publish the messages of the main channel to instead (the other channels are skipped);
+//   - -model: OpenAI model of the -tasks (the routed or default models if empty);
+//   - -tasks: comma-separated composer tasks of the -model ("compose" by default).
+func runJob(cnf *Config, args []string, w io.Writer) bool {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		_, _ = fmt.Fprintln(w, "[FAIL] usage: fin-thread run <job> [flags], e.g. fin-thread run market -since 3h -dry-run")
+		return false
+	}
+	key := args[0]
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(w)
+	since := fs.Duration("since", 0, "start of the fetch window of the news jobs, ago")
+	until := fs.Duration("until", 0, "end of the fetch window of the news jobs, ago")
+	dryRun := fs.Bool("dry-run", false, "write the messages to the console and roll back the database changes")
+	channel := fs.String("channel", "", "chat ID to publish the messages of the main channel to")
+	model := fs.String("model", "", "OpenAI model of the tasks")
+	tasks := fs.String("tasks", composer.TaskCompose, "comma-separated composer tasks of the model")
+	if err := fs.Parse(args[1:]); err != nil {
+		return false
+	}
+
+	once, err := newRunOnce(cnf, key, *since, *until, time.Now())
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", key, err)
+		return false
+	}
+	once.dryRun = *dryRun
+	once.channel = *channel
+
+	if *model != "" {
+		cnf.modelRegistry, err = cnf.modelRegistry.Override(*model, strings.Split(*tasks, ",")...)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "[FAIL] %s: model %s: %s\n", key, *model, err)
+			return false
+		}
+	}
+
+	app := &App{cnf: cnf, once: once}
+	if err := app.start(); err != nil {
+		_, _ = fmt.Fprintf(w, "[FAIL] %s: %s\n", key, err)
+		return false
+	}
+
+	_, _ = fmt.Fprintf(w, "[OK] %s\n", key)
+	return true
+}
+
+// newRunOnce returns the run of the job by its key with the fetch window since..until ago.
+func newRunOnce(cnf *Config, key string, since, until time.Duration, now time.Time) (*runOnce, error) {
+	if _, ok := cnf.schedules[key]; !ok || key == "schedule-monitor" {
+		return nil, errors.New("unknown job, see SCHEDULES for the job keys")
+	}
+
+	once := &runOnce{key: key}
+	if since == 0 && until == 0 {
+		return once, nil
+	}
+
+	if !slices.Contains(newsJobs, key) {
+		return nil, fmt.Errorf("fetch window is supported only by %s jobs", strings.Join(newsJobs, " and "))
+	}
+	if since <= until {
+		return nil, fmt.Errorf("-since %s must be longer ago than -until %s", since, until)
+	}
+	once.from, once.to = now.Add(-since), now.Add(-until)
+
+	return once, nil
+}
@@ -0,0 +1,60 @@
+// Package cache provides a shared cache for the scavenger sources responses,
+// so repeated requests don't re-hit upstream endpoints (which are often rate-limited).
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cache is a key-value storage with TTL for the serialized scavenger responses.
+type Cache interface {
+	// Get returns the value by the key. Second value is false if the key is not found or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value by the key for the ttl duration.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Key builds the cache key for the scavenger source and its request params.
+func Key(source string, params ...any) string {
+	parts := make([]string, 0, len(params)+1)
+	parts = append(parts, source)
+	for _, p := range params {
+		if t, ok := p.(time.Time); ok {
+			p = t.UTC().Format(time.RFC3339)
+		}
+		parts = append(parts, fmt.Sprint(p))
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// Fetch returns the cached value by the key or calls fn and caches its result for the ttl duration.
+// Cache is optional (nil means no cache), and cache errors are ignored, so the upstream is always
+// used as a fallback. Errors returned by fn are not cached.
+func Fetch[T any](ctx context.Context, c Cache, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	if c == nil || ttl <= 0 {
+		return fn()
+	}
+
+	if b, ok, err := c.Get(ctx, key); err == nil && ok {
+		var cached T
+		if err := json.Unmarshal(b, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	value, err := fn()
+	if err != nil {
+		return value, err
+	}
+
+	if b, err := json.Marshal(value); err == nil {
+		_ = c.Set(ctx, key, b, ttl)
+	}
+
+	return value, nil
+}
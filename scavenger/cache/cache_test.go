@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	from := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	want := "mql5-calendar:2024-03-01T17:00:00Z:7"
+	if got := Key("mql5-calendar", from, 7); got != want {
+		t.Errorf("Key() = %v, want %v", got, want)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	calls := 0
+	fn := func() ([]string, error) {
+		calls++
+		return []string{"a", "b"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := Fetch(ctx, m, "key", time.Minute, fn)
+		if err != nil || len(got) != 2 {
+			t.Fatalf("Fetch() = %v, %v", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Fetch() should use cached value, upstream calls = %d", calls)
+	}
+
+	// Expired value should be fetched again
+	now = now.Add(time.Minute)
+	if _, err := Fetch(ctx, m, "key", time.Minute, fn); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("Fetch() should fetch expired value, upstream calls = %d", calls)
+	}
+
+	// Errors should not be cached
+	failing := errors.New("rate limited")
+	for i := 0; i < 2; i++ {
+		_, err := Fetch(ctx, m, "failing", time.Minute, func() ([]string, error) {
+			calls++
+			return nil, failing
+		})
+		if !errors.Is(err, failing) {
+			t.Errorf("Fetch() error = %v, want %v", err, failing)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("Fetch() should not cache errors, upstream calls = %d", calls)
+	}
+
+	// Nil cache should always call upstream
+	if _, err := Fetch[[]string](ctx, nil, "key", time.Minute, fn); err != nil || calls != 5 {
+		t.Errorf("Fetch() without cache = %v, upstream calls = %d", err, calls)
+	}
+}
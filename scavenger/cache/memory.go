@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Cache implementation. Expired items are removed lazily on access.
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	now   func() time.Time // current time getter (for tests)
+}
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory creates a new in-memory cache.
+func NewMemory() *Memory {
+	return &Memory{
+		items: make(map[string]memoryItem),
+		now:   time.Now,
+	}
+}
+
+// Get returns the value by the key if it's not expired.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !m.now().Before(item.expiresAt) {
+		delete(m.items, key)
+		return nil, false, nil
+	}
+
+	return item.value, true, nil
+}
+
+// Set stores the value by the key for the ttl duration.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items[key] = memoryItem{
+		value:     value,
+		expiresAt: m.now().Add(ttl),
+	}
+
+	return nil
+}
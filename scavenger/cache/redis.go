@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Redis is a Cache implementation backed by Redis, so the cache can be shared between instances.
+type Redis struct {
+	client *redis.Client
+	prefix string // prefix for all keys to avoid collisions with other apps
+}
+
+// NewRedis creates a new Redis cache from the connection URL (e.g. "redis://localhost:6379/0").
+func NewRedis(ctx context.Context, url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing redis url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("error connecting to redis: %w", err)
+	}
+
+	return &Redis{
+		client: client,
+		prefix: "fin-thread:scavenger:",
+	}, nil
+}
+
+// Get returns the value by the key.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting value from redis: %w", err)
+	}
+
+	return b, true, nil
+}
+
+// Set stores the value by the key for the ttl duration.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("error setting value to redis: %w", err)
+	}
+
+	return nil
+}
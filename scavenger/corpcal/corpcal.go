@@ -0,0 +1,291 @@
+package corpcal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/cache"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SourceName is the name of the CorporateCalendar in the scavenger registry.
+	SourceName = "nasdaq-corporate-calendar"
+	nasdaqURL  = "https://api.nasdaq.com/api"
+)
+
+// CorporateCalendar is the struct to fetch upcoming earnings reports and IPOs from the Nasdaq calendar API.
+type CorporateCalendar struct {
+	cache    cache.Cache   // optional cache for the calendar responses
+	cacheTTL time.Duration // how long the calendar responses are cached
+	apiURL   string        // Nasdaq API URL (nasdaqURL if empty)
+}
+
+// SetCache sets the cache for the calendar responses.
+func (c *CorporateCalendar) SetCache(cache cache.Cache, ttl time.Duration) {
+	c.cache = cache
+	c.cacheTTL = ttl
+}
+
+// Name returns the name of the source.
+func (c *CorporateCalendar) Name() string {
+	return SourceName
+}
+
+// Init does nothing because the calendar doesn't need any preparation.
+func (c *CorporateCalendar) Init(_ context.Context) error {
+	return nil
+}
+
+// HealthCheck fetches today's earnings to verify that the calendar API is reachable.
+func (c *CorporateCalendar) HealthCheck(ctx context.Context) error {
+	_, err := c.fetchEarnings(ctx, time.Now().UTC())
+	return err
+}
+
+// EarningsTime is the time of the earnings report relative to the trading session.
+type EarningsTime string
+
+const (
+	EarningsBeforeOpen  EarningsTime = "before open"
+	EarningsAfterClose  EarningsTime = "after close"
+	EarningsNotSupplied EarningsTime = ""
+)
+
+// Earnings is a single upcoming earnings report.
+type Earnings struct {
+	Date        time.Time    // Date of the report
+	Ticker      string       // Ticker of the company
+	Name        string       // Company name
+	Time        EarningsTime // Time of the report relative to the trading session
+	EPSForecast string       // Consensus EPS forecast (e.g. "$1.23"), empty if unknown
+	MarketCap   float64      // Market cap of the company in USD (0 if unknown)
+}
+
+// IPO is a single upcoming initial public offering.
+type IPO struct {
+	Date       time.Time // Expected pricing date
+	Ticker     string    // Proposed ticker
+	Name       string    // Company name
+	Exchange   string    // Proposed exchange (e.g. "NASDAQ Global")
+	PriceRange string    // Proposed share price range (e.g. "10.00-12.00")
+	DealSize   string    // Dollar value of the shares offered (e.g. "$100,000,000")
+}
+
+// FetchEarnings fetches earnings reports between the dates (inclusive) sorted by date and market cap (descending).
+func (c *CorporateCalendar) FetchEarnings(ctx context.Context, from, to time.Time) ([]*Earnings, error) {
+	var result []*Earnings
+	for day := truncateDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		earnings, err := c.fetchEarnings(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, earnings...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Date.Equal(result[j].Date) {
+			return result[i].Date.Before(result[j].Date)
+		}
+		return result[i].MarketCap > result[j].MarketCap
+	})
+
+	return result, nil
+}
+
+// FetchIPOs fetches upcoming IPOs expected to be priced between the dates (inclusive) sorted by date.
+func (c *CorporateCalendar) FetchIPOs(ctx context.Context, from, to time.Time) ([]*IPO, error) {
+	var result []*IPO
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(to); month = month.AddDate(0, 1, 0) {
+		ipos, err := c.fetchIPOs(ctx, month)
+		if err != nil {
+			return nil, err
+		}
+		for _, ipo := range ipos {
+			if !ipo.Date.Before(truncateDay(from)) && !ipo.Date.After(to) {
+				result = append(result, ipo)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result, nil
+}
+
+func (c *CorporateCalendar) fetchEarnings(ctx context.Context, day time.Time) ([]*Earnings, error) {
+	date := day.Format(time.DateOnly)
+	return cache.Fetch(ctx, c.cache, cache.Key(SourceName, "earnings", date), c.cacheTTL, func() ([]*Earnings, error) {
+		var resp nasdaqEarningsResponse
+		if err := c.get(ctx, "/calendar/earnings", url.Values{"date": {date}}, &resp); err != nil {
+			return nil, err
+		}
+		return resp.toEarnings(day), nil
+	})
+}
+
+func (c *CorporateCalendar) fetchIPOs(ctx context.Context, month time.Time) ([]*IPO, error) {
+	date := month.Format("2006-01")
+	return cache.Fetch(ctx, c.cache, cache.Key(SourceName, "ipos", date), c.cacheTTL, func() ([]*IPO, error) {
+		var resp nasdaqIPOResponse
+		if err := c.get(ctx, "/ipo/calendar", url.Values{"date": {date}}, &resp); err != nil {
+			return nil, err
+		}
+		return resp.toIPOs(), nil
+	})
+}
+
+// get sends the request to the Nasdaq API and unmarshals the response into v.
+func (c *CorporateCalendar) get(ctx context.Context, path string, query url.Values, v any) error {
+	apiURL := c.apiURL
+	if apiURL == "" {
+		apiURL = nasdaqURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("error creating corporate calendar request: %w", err), errlvl.ERROR)
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("error sending corporate calendar request: %w", err), errlvl.WARN)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("error reading corporate calendar response: %w", err), errlvl.ERROR)
+	}
+	err = res.Body.Close()
+	if err != nil {
+		return errlvl.Wrap(fmt.Errorf("error closing corporate calendar response body: %w", err), errlvl.ERROR)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return errlvl.Wrap(fmt.Errorf("unexpected corporate calendar response status %d for %s", res.StatusCode, path), errlvl.WARN)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return errlvl.Wrap(fmt.Errorf("error unmarshalling corporate calendar response: %w", err), errlvl.ERROR)
+	}
+
+	return nil
+}
+
+// Nasdaq earnings calendar API response.
+type nasdaqEarningsResponse struct {
+	Data *struct {
+		Rows []struct {
+			Symbol      string `json:"symbol"`
+			Name        string `json:"name"`
+			MarketCap   string `json:"marketCap"`
+			Time        string `json:"time"`
+			EPSForecast string `json:"epsForecast"`
+		} `json:"rows"`
+	} `json:"data"`
+}
+
+func (r *nasdaqEarningsResponse) toEarnings(day time.Time) []*Earnings {
+	if r.Data == nil {
+		return nil
+	}
+
+	result := make([]*Earnings, 0, len(r.Data.Rows))
+	for _, row := range r.Data.Rows {
+		if row.Symbol == "" {
+			continue
+		}
+
+		var t EarningsTime
+		switch row.Time {
+		case "time-pre-market":
+			t = EarningsBeforeOpen
+		case "time-after-hours":
+			t = EarningsAfterClose
+		}
+
+		result = append(result, &Earnings{
+			Date:        truncateDay(day),
+			Ticker:      strings.ReplaceAll(row.Symbol, "/", "."),
+			Name:        row.Name,
+			Time:        t,
+			EPSForecast: row.EPSForecast,
+			MarketCap:   parseUSD(row.MarketCap),
+		})
+	}
+
+	return result
+}
+
+// Nasdaq IPO calendar API response.
+type nasdaqIPOResponse struct {
+	Data *struct {
+		Upcoming struct {
+			UpcomingTable struct {
+				Rows []struct {
+					Symbol     string `json:"proposedTickerSymbol"`
+					Name       string `json:"companyName"`
+					Exchange   string `json:"proposedExchange"`
+					SharePrice string `json:"proposedSharePrice"`
+					DealSize   string `json:"dollarValueOfSharesOffered"`
+					Date       string `json:"expectedPriceDate"`
+				} `json:"rows"`
+			} `json:"upcomingTable"`
+		} `json:"upcoming"`
+	} `json:"data"`
+}
+
+func (r *nasdaqIPOResponse) toIPOs() []*IPO {
+	if r.Data == nil {
+		return nil
+	}
+
+	rows := r.Data.Upcoming.UpcomingTable.Rows
+	result := make([]*IPO, 0, len(rows))
+	for _, row := range rows {
+		date, err := time.Parse("01/02/2006", row.Date)
+		if err != nil {
+			continue // IPOs without the expected date are not scheduled yet
+		}
+
+		result = append(result, &IPO{
+			Date:       date,
+			Ticker:     row.Symbol,
+			Name:       row.Name,
+			Exchange:   row.Exchange,
+			PriceRange: row.SharePrice,
+			DealSize:   row.DealSize,
+		})
+	}
+
+	return result
+}
+
+// parseUSD parses USD amount (e.g. "$3,001,298,596,040") to the number (0 if it can't be parsed).
+func parseUSD(s string) float64 {
+	v, err := strconv.ParseFloat(strings.NewReplacer("$", "", ",", "").Replace(strings.TrimSpace(s)), 64)
+	if err != nil {
+		return 0
+	}
+
+	return v
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,88 @@
+package corpcal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const earningsResponse = `{"data":{"rows":[
+	{"symbol":"ORCL","name":"Oracle Corporation","marketCap":"$313,218,440,220","time":"time-after-hours","epsForecast":"$1.35"},
+	{"symbol":"BRK/B","name":"Berkshire Hathaway","marketCap":"$800,000,000,000","time":"time-not-supplied","epsForecast":""},
+	{"symbol":"ADBE","name":"Adobe Inc.","marketCap":"$263,003,478,640","time":"time-pre-market","epsForecast":"$4.38"}
+]}}`
+
+const ipoResponse = `{"data":{"upcoming":{"upcomingTable":{"rows":[
+	{"proposedTickerSymbol":"LATE","companyName":"Late Corp","proposedExchange":"NYSE","proposedSharePrice":"15.00","dollarValueOfSharesOffered":"$50,000,000","expectedPriceDate":"01/31/2024"},
+	{"proposedTickerSymbol":"ABC","companyName":"ABC Inc","proposedExchange":"NASDAQ Global","proposedSharePrice":"10.00-12.00","dollarValueOfSharesOffered":"$100,000,000","expectedPriceDate":"01/17/2024"},
+	{"proposedTickerSymbol":"TBA","companyName":"TBA Corp","proposedExchange":"NYSE","proposedSharePrice":"","dollarValueOfSharesOffered":"","expectedPriceDate":""}
+]}}}}`
+
+func TestCorporateCalendar_FetchEarnings(t *testing.T) {
+	var dates []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/calendar/earnings" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		dates = append(dates, r.URL.Query().Get("date"))
+		if r.URL.Query().Get("date") != "2024-01-15" {
+			_, _ = w.Write([]byte(`{"data":{"rows":null}}`))
+			return
+		}
+		_, _ = w.Write([]byte(earningsResponse))
+	}))
+	defer api.Close()
+
+	c := &CorporateCalendar{apiURL: api.URL}
+	from := time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC) // Saturday
+	got, err := c.FetchEarnings(context.Background(), from, from.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dates) != 2 || dates[0] != "2024-01-15" || dates[1] != "2024-01-16" {
+		t.Errorf("FetchEarnings() requested dates %v, want only weekdays", dates)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("FetchEarnings() returned %d earnings, want 3", len(got))
+	}
+	if got[0].Ticker != "BRK.B" || got[1].Ticker != "ORCL" || got[2].Ticker != "ADBE" {
+		t.Errorf("FetchEarnings() should be sorted by market cap, got %s, %s, %s", got[0].Ticker, got[1].Ticker, got[2].Ticker)
+	}
+	if got[1].Time != EarningsAfterClose || got[2].Time != EarningsBeforeOpen || got[0].Time != EarningsNotSupplied {
+		t.Errorf("FetchEarnings() wrong times: %q, %q, %q", got[0].Time, got[1].Time, got[2].Time)
+	}
+	if got[1].MarketCap != 313218440220 || got[1].EPSForecast != "$1.35" {
+		t.Errorf("FetchEarnings() = %+v", got[1])
+	}
+}
+
+func TestCorporateCalendar_FetchIPOs(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ipo/calendar" || r.URL.Query().Get("date") != "2024-01" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(ipoResponse))
+	}))
+	defer api.Close()
+
+	c := &CorporateCalendar{apiURL: api.URL}
+	from := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	got, err := c.FetchIPOs(context.Background(), from, from.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Ticker != "ABC" || got[0].PriceRange != "10.00-12.00" {
+		t.Errorf("FetchIPOs() = %+v, want only ABC in the range", got)
+	}
+
+	if _, err := c.FetchIPOs(context.Background(), from.AddDate(0, 1, 0), from.AddDate(0, 1, 5)); err == nil {
+		t.Errorf("FetchIPOs() should return error for unexpected status")
+	}
+}
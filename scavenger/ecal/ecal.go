@@ -4,26 +4,77 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/samgozman/fin-thread/internal/utils"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/cache"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
 	economicCalendarURL = "https://www.mql5.com/en/economic-calendar/content"
+	// SourceName is the name of the EconomicCalendar in the scavenger registry.
+	SourceName = "mql5-calendar"
 )
 
 // EconomicCalendar is the struct for economics calendar fetcher.
-type EconomicCalendar struct{}
+type EconomicCalendar struct {
+	cache    cache.Cache   // optional cache for the calendar responses
+	cacheTTL time.Duration // how long the calendar responses are cached
+	apiURL   string        // JSON endpoint URL (economicCalendarURL if empty)
+	pageURL  string        // public HTML page URL used as a fallback (economicCalendarPageURL if empty)
+}
+
+// SetCache sets the cache for the calendar responses.
+// TTL should be shorter than the calendar updates interval, otherwise the actual values will be delayed.
+func (c *EconomicCalendar) SetCache(cache cache.Cache, ttl time.Duration) {
+	c.cache = cache
+	c.cacheTTL = ttl
+}
+
+// SetURLs sets the JSON endpoint and the fallback HTML page URLs (e.g. to use a proxy or a fake server in tests).
+// Empty URL means the default one.
+func (c *EconomicCalendar) SetURLs(apiURL, pageURL string) {
+	c.apiURL = apiURL
+	c.pageURL = pageURL
+}
+
+// Name returns the name of the source.
+func (c *EconomicCalendar) Name() string {
+	return SourceName
+}
 
-// Fetch fetches economics events for the specified period.
+// Init does nothing because the calendar doesn't need any preparation.
+func (c *EconomicCalendar) Init(_ context.Context) error {
+	return nil
+}
+
+// HealthCheck fetches today's events to verify that the calendar is reachable.
+func (c *EconomicCalendar) HealthCheck(ctx context.Context) error {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	_, err := c.Fetch(ctx, from, from.Add(24*time.Hour-time.Second))
+	return err
+}
+
+// Fetch fetches economics events for the specified period (from the cache if it's set).
 func (c *EconomicCalendar) Fetch(ctx context.Context, from, to time.Time) (EconomicCalendarEvents, error) {
+	return cache.Fetch(ctx, c.cache, cache.Key(SourceName, from, to), c.cacheTTL, func() (EconomicCalendarEvents, error) {
+		return c.fetch(ctx, from, to)
+	})
+}
+
+// fetch fetches economics events for the specified period from the MQL5 calendar.
+func (c *EconomicCalendar) fetch(ctx context.Context, from, to time.Time) (EconomicCalendarEvents, error) {
 	if from.IsZero() || to.IsZero() {
 		return nil, fmt.Errorf("invalid date range: from %v, to %v", from, to)
 	}
@@ -37,22 +88,21 @@ func (c *EconomicCalendar) Fetch(ctx context.Context, from, to time.Time) (Econo
 	}
 
 	// Create request body with the specified date range
-	f := from.Format("2006-01-02T15:04:05")
-	t := to.Format("2006-01-02T15:04:05")
-
 	payload := &bytes.Buffer{}
 	writer := multipart.NewWriter(payload)
-	_ = writer.WriteField("date_mode", "1")
-	_ = writer.WriteField("from", f)
-	_ = writer.WriteField("to", t)
-	_ = writer.WriteField("importance", "13")    // importance=13 - high impact, holidays and medium
-	_ = writer.WriteField("currencies", "65743") // currencies=65743 - CHF, EUR, GBP, JPY, USD, CNY, INR
+	for _, p := range calendarParams(from, to) {
+		_ = writer.WriteField(p[0], p[1])
+	}
 	err := writer.Close()
 	if err != nil {
 		return nil, errlvl.Wrap(fmt.Errorf("error closing multipart writer: %w", err), errlvl.ERROR)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, economicCalendarURL, payload)
+	apiURL := c.apiURL
+	if apiURL == "" {
+		apiURL = economicCalendarURL
+	}
+	req, err := http.NewRequest(http.MethodPost, apiURL, payload)
 	if err != nil {
 		return nil, errlvl.Wrap(fmt.Errorf("error creating calendar request: %w", err), errlvl.ERROR)
 	}
@@ -67,10 +117,6 @@ func (c *EconomicCalendar) Fetch(ctx context.Context, from, to time.Time) (Econo
 		return nil, errlvl.Wrap(fmt.Errorf("error sending calendar request: %w", err), errlvl.ERROR)
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, errlvl.Wrap(fmt.Errorf("invalid status code error: %d, value %s", res.StatusCode, res.Status), errlvl.ERROR)
-	}
-
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, errlvl.Wrap(fmt.Errorf("error reading response body: %w", err), errlvl.ERROR)
@@ -80,12 +126,79 @@ func (c *EconomicCalendar) Fetch(ctx context.Context, from, to time.Time) (Econo
 		return nil, errlvl.Wrap(fmt.Errorf("error closing response body: %w", err), errlvl.ERROR)
 	}
 
+	// The endpoint occasionally returns an HTML error page instead of JSON
+	contentType := res.Header.Get("Content-Type")
+	if res.StatusCode != http.StatusOK || !isJSONResponse(contentType, body) {
+		return c.fetchFallback(ctx, from, to, newResponseError(res.StatusCode, contentType, body, nil))
+	}
+
 	// Unmarshal the response
 	var mql5Events []mql5Calendar
 	if err := json.Unmarshal(body, &mql5Events); err != nil {
-		return nil, errlvl.Wrap(fmt.Errorf("error unmarshalling response body: %w", err), errlvl.ERROR)
+		return c.fetchFallback(ctx, from, to, newResponseError(res.StatusCode, contentType, body, err))
+	}
+
+	return buildEvents(mql5Events, from, to)
+}
+
+// calendarParams returns the request parameters of the calendar for the specified date range (in the order of the
+// form fields). They are shared by the JSON endpoint and the HTML page.
+func calendarParams(from, to time.Time) [][2]string {
+	return [][2]string{
+		{"date_mode", "1"},
+		{"from", from.Format("2006-01-02T15:04:05")},
+		{"to", to.Format("2006-01-02T15:04:05")},
+		{"importance", "13"},    // importance=13 - high impact, holidays and medium
+		{"currencies", "65743"}, // currencies=65743 - CHF, EUR, GBP, JPY, USD, CNY, INR
+	}
+}
+
+// fetchFallback fetches events from the public HTML calendar page if the JSON endpoint failed with respErr.
+func (c *EconomicCalendar) fetchFallback(ctx context.Context, from, to time.Time, respErr *ResponseError) (EconomicCalendarEvents, error) {
+	pageURL := c.pageURL
+	if pageURL == "" {
+		pageURL = economicCalendarPageURL
 	}
 
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, errlvl.Wrap(errors.Join(respErr, fmt.Errorf("error parsing calendar page url: %w", err)), errlvl.ERROR)
+	}
+	// The page shows the current week by default, so request the same range and filters as the JSON endpoint
+	q := u.Query()
+	for _, p := range calendarParams(from, to) {
+		q.Set(p[0], p[1])
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errlvl.Wrap(errors.Join(respErr, fmt.Errorf("error creating calendar page request: %w", err)), errlvl.ERROR)
+	}
+	req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errlvl.Wrap(errors.Join(respErr, fmt.Errorf("error sending calendar page request: %w", err)), errlvl.ERROR)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errlvl.Wrap(errors.Join(respErr, fmt.Errorf("invalid calendar page status code: %d", res.StatusCode)), errlvl.ERROR)
+	}
+
+	mql5Events, err := parseHTMLCalendar(res.Body)
+	if err != nil {
+		return nil, errlvl.Wrap(errors.Join(respErr, err), errlvl.ERROR)
+	}
+
+	return buildEvents(mql5Events, from, to)
+}
+
+// buildEvents parses MQL5 events and keeps only distinct events in the specified date range sorted by date.
+func buildEvents(mql5Events []mql5Calendar, from, to time.Time) (EconomicCalendarEvents, error) {
 	var events EconomicCalendarEvents
 	for _, event := range mql5Events {
 		e, err := parseEvent(event)
@@ -114,7 +227,20 @@ func parseEvent(event mql5Calendar) (*EconomicCalendarEvent, error) {
 		return nil, errlvl.Wrap(err, errlvl.ERROR)
 	}
 
-	country := parseCountry(event)
+	country, ok := parseCountry(event)
+	if !ok {
+		// Keep the event, but make the unknown country visible instead of silently grouping it under ""
+		slog.Default().Warn("[ecal] unknown country code", "code", event.Country, "event", event.EventName)
+		country = fmt.Sprintf("Country #%d", event.Country)
+	}
+
+	if expected, ok := countryCurrencies[country]; ok && expected != currency {
+		// Mismatched pairs break the matching of the stored events with the updated ones,
+		// so the currency of the country is used instead of the one from the feed
+		slog.Default().Warn("[ecal] currency doesn't match country",
+			"country", country, "currency", currency, "expected", expected, "event", event.EventName)
+		currency = expected
+	}
 
 	impact, err := parseImpact(event)
 	if err != nil {
@@ -131,7 +257,16 @@ func parseEvent(event mql5Calendar) (*EconomicCalendarEvent, error) {
 		return nil, errlvl.Wrap(fmt.Errorf("error parsing date: %w, value %v", err, event.ReleaseDate), errlvl.ERROR)
 	}
 
+	allDay, tentative := parseTimeMode(event)
+	eventType := parseEventType(event)
+
+	var id string
+	if event.ID != 0 {
+		id = strconv.Itoa(event.ID)
+	}
+
 	e := &EconomicCalendarEvent{
+		ID:        id,
 		DateTime:  dt,
 		EventTime: et,
 		Country:   country,
@@ -141,6 +276,9 @@ func parseEvent(event mql5Calendar) (*EconomicCalendarEvent, error) {
 		Actual:    strings.ReplaceAll(strings.ToLower(event.ActualValue), "\u00a0", ""), // Remove nbsp symbol, convert to lowercase
 		Forecast:  strings.ReplaceAll(strings.ToLower(event.ForecastValue), "\u00a0", ""),
 		Previous:  strings.ReplaceAll(strings.ToLower(event.PreviousValue), "\u00a0", ""),
+		EventType: eventType,
+		AllDay:    allDay,
+		Tentative: tentative,
 	}
 
 	return e, nil
@@ -167,10 +305,65 @@ func parseImpact(event mql5Calendar) (EconomicCalendarImpact, error) {
 	return impact, nil
 }
 
-func parseCountry(event mql5Calendar) EconomicCalendarCountry { //nolint:gocyclo
+// MQL5 event types (ENUM_CALENDAR_EVENT_TYPE).
+const (
+	mql5EventTypeEvent     = 0 // events without values (speeches, meetings, etc.)
+	mql5EventTypeIndicator = 1 // indicators with actual, forecast and previous values
+	mql5EventTypeHoliday   = 2 // holidays
+)
+
+// speechKeywords are used to find speeches and press conferences among MQL5 events without values.
+var speechKeywords = []string{"speech", "speaks", "press conference", "testimony", "remarks"}
+
+// parseEventType classifies the event by the MQL5 event type and its title.
+func parseEventType(event mql5Calendar) EconomicCalendarEventType {
+	switch event.EventType {
+	case mql5EventTypeIndicator:
+		return EconomicCalendarTypeIndicator
+	case mql5EventTypeHoliday:
+		return EconomicCalendarTypeHoliday
+	case mql5EventTypeEvent:
+		title := strings.ToLower(event.EventName)
+		for _, k := range speechKeywords {
+			if strings.Contains(title, k) {
+				return EconomicCalendarTypeSpeech
+			}
+		}
+		return EconomicCalendarTypeEvent
+	default:
+		return EconomicCalendarTypeIndicator
+	}
+}
+
+// MQL5 event time modes (ENUM_CALENDAR_EVENT_TIMEMODE).
+const (
+	mql5TimeModeDateTime  = 0 // the exact time is known
+	mql5TimeModeDate      = 1 // the event takes the whole day
+	mql5TimeModeNoTime    = 2 // the time is not published
+	mql5TimeModeTentative = 3 // the day is known, but the exact time is not announced yet
+)
+
+// parseTimeMode returns whether the event takes the whole day or its time is not announced yet.
+func parseTimeMode(event mql5Calendar) (allDay, tentative bool) {
+	switch event.TimeMode {
+	case mql5TimeModeDate, mql5TimeModeNoTime:
+		return true, false
+	case mql5TimeModeTentative:
+		return false, true
+	case mql5TimeModeDateTime:
+		return false, false
+	default:
+		return false, false // treat unknown modes as the exact time to keep the old behaviour
+	}
+}
+
+// parseCountry maps MQL5 country code to the EconomicCalendarCountry. Returns false for unknown codes.
+func parseCountry(event mql5Calendar) (EconomicCalendarCountry, bool) { //nolint:gocyclo
 	// Parse country
 	var country EconomicCalendarCountry
 	switch event.Country {
+	case 0:
+		country = EconomicCalendarWorldwide
 	case 36:
 		country = EconomicCalendarAustralia
 	case 76:
@@ -216,9 +409,9 @@ func parseCountry(event mql5Calendar) EconomicCalendarCountry { //nolint:gocyclo
 	case 840:
 		country = EconomicCalendarUnitedStates
 	default:
-		country = ""
+		return "", false
 	}
-	return country
+	return country, true
 }
 
 func parseCurrency(event mql5Calendar) (EconomicCalendarCurrency, error) {
@@ -292,8 +485,81 @@ const (
 	EconomicCalendarSwitzerland   EconomicCalendarCountry = "Switzerland"
 	EconomicCalendarUnitedKingdom EconomicCalendarCountry = "United Kingdom"
 	EconomicCalendarUnitedStates  EconomicCalendarCountry = "United States"
+	EconomicCalendarWorldwide     EconomicCalendarCountry = "Worldwide" // Global events (e.g. OPEC meetings)
 )
 
+// allCountries is the list of all known calendar countries.
+var allCountries = []EconomicCalendarCountry{
+	EconomicCalendarAustralia,
+	EconomicCalendarBrazil,
+	EconomicCalendarCanada,
+	EconomicCalendarChina,
+	EconomicCalendarEuropeanUnion,
+	EconomicCalendarFrance,
+	EconomicCalendarGermany,
+	EconomicCalendarHongKong,
+	EconomicCalendarIndia,
+	EconomicCalendarItaly,
+	EconomicCalendarJapan,
+	EconomicCalendarMexico,
+	EconomicCalendarNewZealand,
+	EconomicCalendarNorway,
+	EconomicCalendarSingapore,
+	EconomicCalendarSouthAfrica,
+	EconomicCalendarSouthKorea,
+	EconomicCalendarSpain,
+	EconomicCalendarSweden,
+	EconomicCalendarSwitzerland,
+	EconomicCalendarUnitedKingdom,
+	EconomicCalendarUnitedStates,
+	EconomicCalendarWorldwide,
+}
+
+// countryCurrencies holds the currency of the country, events of the country are expected to have it.
+// Countries with the currencies unsupported by parseCurrency are not checked.
+var countryCurrencies = map[EconomicCalendarCountry]EconomicCalendarCurrency{
+	EconomicCalendarAustralia:     EconomicCalendarAUD,
+	EconomicCalendarChina:         EconomicCalendarCNY,
+	EconomicCalendarEuropeanUnion: EconomicCalendarEUR,
+	EconomicCalendarFrance:        EconomicCalendarEUR,
+	EconomicCalendarGermany:       EconomicCalendarEUR,
+	EconomicCalendarIndia:         EconomicCalendarINR,
+	EconomicCalendarItaly:         EconomicCalendarEUR,
+	EconomicCalendarJapan:         EconomicCalendarJPY,
+	EconomicCalendarNewZealand:    EconomicCalendarNZD,
+	EconomicCalendarSpain:         EconomicCalendarEUR,
+	EconomicCalendarSwitzerland:   EconomicCalendarCHF,
+	EconomicCalendarUnitedKingdom: EconomicCalendarGBP,
+	EconomicCalendarUnitedStates:  EconomicCalendarUSD,
+	EconomicCalendarWorldwide:     EconomicCalendarALL,
+}
+
+// ParseCountries parses the list of countries by their names or hashtags (case-insensitive),
+// e.g. "United States" or "usa". Returns an error for unknown countries.
+func ParseCountries(list []string) ([]EconomicCalendarCountry, error) {
+	countries := make([]EconomicCalendarCountry, 0, len(list))
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		found := false
+		for _, c := range allCountries {
+			if strings.EqualFold(item, c) || strings.EqualFold(item, GetCountryHashtag(c)) {
+				countries = append(countries, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown calendar country: %s", item)
+		}
+	}
+
+	return countries, nil
+}
+
 // GetCountryHashtag returns the country hashtag for the specified country.
 func GetCountryHashtag(country EconomicCalendarCountry) string {
 	m := map[EconomicCalendarCountry]string{
@@ -319,6 +585,7 @@ func GetCountryHashtag(country EconomicCalendarCountry) string {
 		EconomicCalendarSwitzerland:   "switzerland",
 		EconomicCalendarUnitedKingdom: "uk",
 		EconomicCalendarUnitedStates:  "usa",
+		EconomicCalendarWorldwide:     "world",
 	}
 	return m[country]
 }
@@ -348,8 +615,12 @@ func GetCountryEmoji(country EconomicCalendarCountry) string {
 		EconomicCalendarSwitzerland:   "🇨🇭",
 		EconomicCalendarUnitedKingdom: "🇬🇧",
 		EconomicCalendarUnitedStates:  "🇺🇸",
+		EconomicCalendarWorldwide:     "🌍",
 	}
-	return m[country]
+	if emoji, ok := m[country]; ok {
+		return emoji
+	}
+	return "🏳️"
 }
 
 // EconomicCalendarImpact impact of the event on the market (low, medium, high, holiday, none).
@@ -363,17 +634,36 @@ const (
 	EconomicCalendarImpactNone    EconomicCalendarImpact = "None"     // No impact event
 )
 
+// EconomicCalendarEventType is the type of the event (indicator, speech, holiday, etc.).
+type EconomicCalendarEventType = string
+
+const (
+	EconomicCalendarTypeIndicator EconomicCalendarEventType = "Indicator" // Indicator with actual, forecast and previous values
+	EconomicCalendarTypeSpeech    EconomicCalendarEventType = "Speech"    // Speech or press conference (never has values)
+	EconomicCalendarTypeEvent     EconomicCalendarEventType = "Event"     // Other event without values (e.g. meeting)
+	EconomicCalendarTypeHoliday   EconomicCalendarEventType = "Holiday"   // Holiday
+)
+
+// TypesWithoutValues returns event types that never have actual, forecast and previous values.
+func TypesWithoutValues() []EconomicCalendarEventType {
+	return []EconomicCalendarEventType{EconomicCalendarTypeSpeech, EconomicCalendarTypeEvent, EconomicCalendarTypeHoliday}
+}
+
 // EconomicCalendarEvent is the struct for economics calendar event object.
 type EconomicCalendarEvent struct {
-	DateTime  time.Time                // Date of the event
-	EventTime time.Time                // Time of the event (if available)
-	Country   EconomicCalendarCountry  // Country of the event
-	Currency  EconomicCalendarCurrency // Currency impacted by the event
-	Impact    EconomicCalendarImpact   // Impact of the event on the market
-	Title     string                   // Event title
-	Actual    string                   // Actual value of the event (if available)
-	Forecast  string                   // Forecasted value of the event (if available)
-	Previous  string                   // Previous value of the event (if available)
+	ID        string                    // ID of the event in the provider (empty if unknown), stable across title changes
+	DateTime  time.Time                 // Date of the event
+	EventTime time.Time                 // Time of the event (if available)
+	Country   EconomicCalendarCountry   // Country of the event
+	Currency  EconomicCalendarCurrency  // Currency impacted by the event
+	Impact    EconomicCalendarImpact    // Impact of the event on the market
+	Title     string                    // Event title
+	Actual    string                    // Actual value of the event (if available)
+	Forecast  string                    // Forecasted value of the event (if available)
+	Previous  string                    // Previous value of the event (if available)
+	EventType EconomicCalendarEventType // Type of the event
+	AllDay    bool                      // Event takes the whole day, DateTime has no meaningful time
+	Tentative bool                      // Event time is not announced yet, DateTime has no meaningful time
 }
 
 // MQL5 calendar event object.
@@ -402,6 +692,22 @@ type mql5Calendar struct {
 // EconomicCalendarEvents is the slice of economics calendar events.
 type EconomicCalendarEvents []*EconomicCalendarEvent
 
+// FilterByCountries keeps only events of the given countries, returns new slice.
+// All events are returned if the countries list is empty.
+func (e EconomicCalendarEvents) FilterByCountries(countries []EconomicCalendarCountry) EconomicCalendarEvents {
+	if len(countries) == 0 {
+		return e
+	}
+
+	var filtered EconomicCalendarEvents
+	for _, v := range e {
+		if slices.Contains(countries, v.Country) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
 // FilterByDateRange filters events by date range, returns new slice.
 func (e EconomicCalendarEvents) FilterByDateRange(from, to time.Time) EconomicCalendarEvents {
 	var filtered EconomicCalendarEvents
@@ -17,17 +17,20 @@ func Test_parseEvent(t *testing.T) {
 		{
 			name: "case 1 - regular event",
 			event: mql5Calendar{
+				ID:            138712,
 				ActualValue:   "0.2%",
 				CurrencyCode:  "USD",
 				Country:       840,
 				ForecastValue: "0.2\u00A0%",
 				Importance:    "high",
+				EventType:     1,
 				PreviousValue: "0.3\u00A0%",
 				EventName:     "Core CPI m/m",
 				FullDate:      "2023-11-13T12:58:48",
 				ReleaseDate:   1702450800000,
 			},
 			want: &EconomicCalendarEvent{
+				ID:        "138712",
 				Actual:    "0.2%",
 				Currency:  EconomicCalendarUSD,
 				Country:   EconomicCalendarUnitedStates,
@@ -37,6 +40,7 @@ func Test_parseEvent(t *testing.T) {
 				Impact:    EconomicCalendarImpactHigh,
 				Previous:  "0.3%",
 				Title:     "Core CPI m/m",
+				EventType: EconomicCalendarTypeIndicator,
 			},
 			wantErr: false,
 		},
@@ -64,6 +68,95 @@ func Test_parseEvent(t *testing.T) {
 				Impact:    EconomicCalendarImpactHoliday,
 				Previous:  "",
 				Title:     "The Day of Flying Spaghetti Monster",
+				EventType: EconomicCalendarTypeHoliday,
+			},
+			wantErr: false,
+		},
+		{
+			name: "case 3 - all day event",
+			event: mql5Calendar{
+				CurrencyCode: "JPY",
+				Country:      392,
+				Importance:   "medium",
+				EventType:    1,
+				TimeMode:     1,
+				EventName:    "BoJ Monetary Policy Statement",
+				FullDate:     "2023-11-13T00:00:00",
+			},
+			want: &EconomicCalendarEvent{
+				Currency:  EconomicCalendarJPY,
+				Country:   EconomicCalendarJapan,
+				DateTime:  time.Date(2023, 11, 13, 0, 0, 0, 0, time.UTC),
+				EventTime: time.Time{},
+				Impact:    EconomicCalendarImpactMedium,
+				Title:     "BoJ Monetary Policy Statement",
+				EventType: EconomicCalendarTypeIndicator,
+				AllDay:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "case 4 - tentative event",
+			event: mql5Calendar{
+				CurrencyCode: "USD",
+				Country:      840,
+				Importance:   "high",
+				EventType:    1,
+				TimeMode:     3,
+				EventName:    "Treasury Refunding Announcement",
+				FullDate:     "2023-11-13T00:00:00",
+			},
+			want: &EconomicCalendarEvent{
+				Currency:  EconomicCalendarUSD,
+				Country:   EconomicCalendarUnitedStates,
+				DateTime:  time.Date(2023, 11, 13, 0, 0, 0, 0, time.UTC),
+				EventTime: time.Time{},
+				Impact:    EconomicCalendarImpactHigh,
+				Title:     "Treasury Refunding Announcement",
+				EventType: EconomicCalendarTypeIndicator,
+				Tentative: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "case 5 - speech",
+			event: mql5Calendar{
+				CurrencyCode: "USD",
+				Country:      840,
+				Importance:   "high",
+				EventType:    0,
+				EventName:    "Fed Chair Powell Speech",
+				FullDate:     "2023-11-13T14:00:00",
+			},
+			want: &EconomicCalendarEvent{
+				Currency:  EconomicCalendarUSD,
+				Country:   EconomicCalendarUnitedStates,
+				DateTime:  time.Date(2023, 11, 13, 14, 0, 0, 0, time.UTC),
+				EventTime: time.Time{},
+				Impact:    EconomicCalendarImpactHigh,
+				Title:     "Fed Chair Powell Speech",
+				EventType: EconomicCalendarTypeSpeech,
+			},
+			wantErr: false,
+		},
+		{
+			name: "case 6 - event without values",
+			event: mql5Calendar{
+				CurrencyCode: "EUR",
+				Country:      999,
+				Importance:   "medium",
+				EventType:    0,
+				EventName:    "Eurogroup Meeting",
+				FullDate:     "2023-11-13T14:00:00",
+			},
+			want: &EconomicCalendarEvent{
+				Currency:  EconomicCalendarEUR,
+				Country:   EconomicCalendarEuropeanUnion,
+				DateTime:  time.Date(2023, 11, 13, 14, 0, 0, 0, time.UTC),
+				EventTime: time.Time{},
+				Impact:    EconomicCalendarImpactMedium,
+				Title:     "Eurogroup Meeting",
+				EventType: EconomicCalendarTypeEvent,
 			},
 			wantErr: false,
 		},
@@ -141,3 +234,99 @@ func TestEconomicCalendar_Fetch(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCountries(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    []string
+		want    []EconomicCalendarCountry
+		wantErr bool
+	}{
+		{
+			name: "Should parse names and hashtags",
+			list: []string{"usa", " European Union", "UK", ""},
+			want: []EconomicCalendarCountry{EconomicCalendarUnitedStates, EconomicCalendarEuropeanUnion, EconomicCalendarUnitedKingdom},
+		},
+		{
+			name:    "Should return error for unknown country",
+			list:    []string{"usa", "atlantis"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCountries(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCountries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCountries() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEconomicCalendarEvents_FilterByCountries(t *testing.T) {
+	events := EconomicCalendarEvents{
+		{Title: "CPI", Country: EconomicCalendarUnitedStates},
+		{Title: "ECB", Country: EconomicCalendarEuropeanUnion},
+		{Title: "BoJ", Country: EconomicCalendarJapan},
+	}
+
+	if got := events.FilterByCountries(nil); len(got) != 3 {
+		t.Errorf("FilterByCountries() without countries len = %d, want 3", len(got))
+	}
+
+	got := events.FilterByCountries([]EconomicCalendarCountry{EconomicCalendarUnitedStates, EconomicCalendarJapan})
+	if len(got) != 2 || got[0].Title != "CPI" || got[1].Title != "BoJ" {
+		t.Errorf("FilterByCountries() = %v", got)
+	}
+}
+
+func Test_parseEvent_unknownCountry(t *testing.T) {
+	e, err := parseEvent(mql5Calendar{
+		CurrencyCode: "USD",
+		Country:      123,
+		Importance:   "low",
+		EventName:    "Some Event",
+		FullDate:     "2023-11-13T12:58:48",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Country != "Country #123" {
+		t.Errorf("parseEvent() country = %q, want %q", e.Country, "Country #123")
+	}
+}
+
+func Test_parseEvent_currencyMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		country  int
+		want     EconomicCalendarCurrency
+	}{
+		{name: "consistent", currency: "EUR", country: 276, want: EconomicCalendarEUR},
+		{name: "mismatch", currency: "EUR", country: 840, want: EconomicCalendarUSD},
+		{name: "unchecked country", currency: "USD", country: 124, want: EconomicCalendarUSD},
+		{name: "unknown country", currency: "USD", country: 123, want: EconomicCalendarUSD},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := parseEvent(mql5Calendar{
+				CurrencyCode: tt.currency,
+				Country:      tt.country,
+				Importance:   "low",
+				EventName:    "Some Event",
+				FullDate:     "2023-11-13T12:58:48",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Currency != tt.want {
+				t.Errorf("parseEvent() currency = %q, want %q", e.Currency, tt.want)
+			}
+		})
+	}
+}
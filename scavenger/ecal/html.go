@@ -0,0 +1,216 @@
+package ecal
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// economicCalendarPageURL is the public HTML calendar page used as a fallback for the JSON endpoint.
+	economicCalendarPageURL = "https://www.mql5.com/en/economic-calendar"
+	// responsePrefixSize is the number of the response body bytes included in ResponseError.
+	responsePrefixSize = 256
+)
+
+// ResponseError is returned when the calendar endpoint responds with something other than the expected JSON
+// (e.g. an HTML error page). It holds the first bytes of the body to make such errors debuggable.
+type ResponseError struct {
+	StatusCode  int
+	ContentType string
+	BodyPrefix  string
+	Err         error // underlying parsing error (optional)
+}
+
+func newResponseError(statusCode int, contentType string, body []byte, err error) *ResponseError {
+	prefix := body
+	if len(prefix) > responsePrefixSize {
+		prefix = prefix[:responsePrefixSize]
+	}
+
+	return &ResponseError{
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		BodyPrefix:  strings.ToValidUTF8(string(prefix), ""),
+		Err:         err,
+	}
+}
+
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("unexpected calendar response (status %d, content-type %q): %q", e.StatusCode, e.ContentType, e.BodyPrefix)
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// isJSONResponse detects if the response is JSON by its content type or (if it's missing) by the body itself.
+func isJSONResponse(contentType string, body []byte) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "json") {
+		return true
+	}
+	if strings.Contains(contentType, "html") {
+		return false
+	}
+
+	trimmed := strings.TrimSpace(string(body[:min(len(body), responsePrefixSize)]))
+	return strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{")
+}
+
+// parseHTMLCalendar parses events from the public HTML calendar page.
+//
+// Each event is a row element with the "ec-table__item" class. Event meta is stored in the row data attributes
+// (data-event-type, data-importance, data-currency, data-country, data-date, data-release) and the title and values
+// are stored in the child columns with "ec-table__col_event", "ec-table__col_actual", "ec-table__col_forecast"
+// and "ec-table__col_previous" classes.
+func parseHTMLCalendar(r io.Reader) ([]mql5Calendar, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing calendar html: %w", err)
+	}
+
+	var events []mql5Calendar
+	var walk func(n *html.Node) error
+	walk = func(n *html.Node) error {
+		if n.Type == html.ElementNode && hasClass(n, "ec-table__item") {
+			event, err := parseHTMLEvent(n)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+			return nil
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := walk(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(doc); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events found in calendar html")
+	}
+
+	return events, nil
+}
+
+// parseHTMLEvent parses a single calendar row into the same structure as the JSON endpoint returns.
+func parseHTMLEvent(n *html.Node) (mql5Calendar, error) {
+	event := mql5Calendar{
+		Importance:   attr(n, "data-importance"),
+		CurrencyCode: attr(n, "data-currency"),
+		FullDate:     attr(n, "data-date"),
+	}
+
+	var err error
+	eventType := attr(n, "data-event-type")
+	if eventType != "" {
+		if event.EventType, err = strconv.Atoi(eventType); err != nil {
+			return event, fmt.Errorf("error parsing event type %q: %w", eventType, err)
+		}
+	}
+	if v := attr(n, "data-time-mode"); v != "" {
+		if event.TimeMode, err = strconv.Atoi(v); err != nil {
+			return event, fmt.Errorf("error parsing time mode %q: %w", v, err)
+		}
+	}
+	if v := attr(n, "data-country"); v != "" {
+		if event.Country, err = strconv.Atoi(v); err != nil {
+			return event, fmt.Errorf("error parsing country %q: %w", v, err)
+		}
+	}
+	if v := attr(n, "data-release"); v != "" {
+		if event.ReleaseDate, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return event, fmt.Errorf("error parsing release date %q: %w", v, err)
+		}
+	}
+
+	var walk func(c *html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode {
+			switch {
+			case hasClass(c, "ec-table__col_event"):
+				event.EventName = text(c)
+			case hasClass(c, "ec-table__col_actual"):
+				event.ActualValue = text(c)
+			case hasClass(c, "ec-table__col_forecast"):
+				event.ForecastValue = text(c)
+			case hasClass(c, "ec-table__col_previous"):
+				event.PreviousValue = text(c)
+			}
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+
+	if event.EventName == "" || event.FullDate == "" {
+		return event, fmt.Errorf("calendar row without title or date")
+	}
+	if eventType == "" {
+		event.EventType = htmlEventType(event)
+	}
+
+	return event, nil
+}
+
+// htmlEventType guesses the MQL5 event type of the row without the data-event-type attribute,
+// so it is classified by parseEventType the same way as the JSON events.
+func htmlEventType(event mql5Calendar) int {
+	switch {
+	case event.ActualValue != "" || event.ForecastValue != "" || event.PreviousValue != "":
+		return mql5EventTypeIndicator
+	case event.Importance == "none" && strings.Contains(strings.ToLower(event.EventName), "holiday"):
+		return mql5EventTypeHoliday
+	default:
+		return mql5EventTypeEvent
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// text returns the trimmed text content of the node.
+func text(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(c *html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,157 @@
+package ecal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const calendarPageHTML = `<!DOCTYPE html>
+<html><body><div class="ec-table">
+<div class="ec-table__item" data-event-type="1" data-importance="high" data-currency="USD" data-country="840"
+     data-date="2023-11-13T12:30:00" data-release="1702450800000">
+  <div class="ec-table__col ec-table__col_event"><a href="/en/economic-calendar/united-states/core-cpi">Core CPI m/m</a></div>
+  <div class="ec-table__col ec-table__col_actual">0.2%</div>
+  <div class="ec-table__col ec-table__col_forecast">0.2&nbsp;%</div>
+  <div class="ec-table__col ec-table__col_previous">0.3&nbsp;%</div>
+</div>
+<div class="ec-table__item" data-event-type="2" data-importance="none" data-currency="EUR" data-country="999"
+     data-date="2023-11-14T00:00:00">
+  <div class="ec-table__col ec-table__col_event">Bank Holiday</div>
+</div>
+<div class="ec-table__item" data-importance="medium" data-currency="USD" data-country="840" data-date="2023-11-13T14:00:00">
+  <div class="ec-table__col ec-table__col_event">Retail Sales m/m</div>
+  <div class="ec-table__col ec-table__col_actual"></div>
+  <div class="ec-table__col ec-table__col_forecast">0.3%</div>
+  <div class="ec-table__col ec-table__col_previous">0.7%</div>
+</div>
+<div class="ec-table__item" data-importance="none" data-currency="USD" data-country="840" data-date="2023-11-23T00:00:00">
+  <div class="ec-table__col ec-table__col_event">Thanksgiving Day Holiday</div>
+</div>
+<div class="ec-table__item" data-importance="high" data-currency="USD" data-country="840" data-date="2023-11-13T16:00:00">
+  <div class="ec-table__col ec-table__col_event">Fed Chair Powell Speech</div>
+</div>
+</div></body></html>`
+
+func Test_parseHTMLCalendar(t *testing.T) {
+	events, err := parseHTMLCalendar(strings.NewReader(calendarPageHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("parseHTMLCalendar() len = %d, want 5", len(events))
+	}
+
+	e, err := parseEvent(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &EconomicCalendarEvent{
+		DateTime:  time.Date(2023, 11, 13, 12, 30, 0, 0, time.UTC),
+		EventTime: time.Date(2023, 12, 13, 7, 0, 0, 0, time.UTC),
+		Country:   EconomicCalendarUnitedStates,
+		Currency:  EconomicCalendarUSD,
+		Impact:    EconomicCalendarImpactHigh,
+		Title:     "Core CPI m/m",
+		Actual:    "0.2%",
+		Forecast:  "0.2%",
+		Previous:  "0.3%",
+		EventType: EconomicCalendarTypeIndicator,
+	}
+	if *e != *want {
+		t.Errorf("parseEvent() = %+v, want %+v", e, want)
+	}
+
+	holiday, err := parseEvent(events[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holiday.Impact != EconomicCalendarImpactHoliday {
+		t.Errorf("parseEvent() impact = %v, want %v", holiday.Impact, EconomicCalendarImpactHoliday)
+	}
+
+	// Rows without data-event-type are classified like the JSON events
+	for i, want := range []EconomicCalendarEventType{
+		EconomicCalendarTypeIndicator,
+		EconomicCalendarTypeHoliday,
+		EconomicCalendarTypeSpeech,
+	} {
+		e, err := parseEvent(events[i+2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.EventType != want {
+			t.Errorf("parseEvent(%q) type = %v, want %v", e.Title, e.EventType, want)
+		}
+	}
+
+	if _, err := parseHTMLCalendar(strings.NewReader("<html><body>Service unavailable</body></html>")); err == nil {
+		t.Errorf("parseHTMLCalendar() should return error for page without events")
+	}
+}
+
+func Test_isJSONResponse(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        bool
+	}{
+		{contentType: "application/json; charset=utf-8", body: "[]", want: true},
+		{contentType: "text/html; charset=utf-8", body: "[]", want: false},
+		{contentType: "", body: "  [{\"ID\":1}]", want: true},
+		{contentType: "", body: "<!DOCTYPE html>", want: false},
+	}
+	for _, tt := range tests {
+		if got := isJSONResponse(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("isJSONResponse(%q, %q) = %v, want %v", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestEconomicCalendar_fetchFallback(t *testing.T) {
+	errorPage := "<html><body>" + strings.Repeat("Service temporarily unavailable. ", 20) + "</body></html>"
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(errorPage))
+	}))
+	defer api.Close()
+
+	var query url.Values
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(calendarPageHTML))
+	}))
+	defer page.Close()
+
+	from := time.Date(2023, 11, 13, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24*time.Hour - time.Second)
+
+	c := &EconomicCalendar{apiURL: api.URL, pageURL: page.URL}
+	events, err := c.Fetch(context.Background(), from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0].Title != "Core CPI m/m" {
+		t.Errorf("Fetch() = %v, want fallback events for the date range", events)
+	}
+	if query.Get("from") != "2023-11-13T00:00:00" || query.Get("to") != "2023-11-13T23:59:59" {
+		t.Errorf("Fetch() page query = %v, want the requested date range", query)
+	}
+
+	// Structured error if the fallback also failed
+	c.pageURL = api.URL
+	_, err = c.Fetch(context.Background(), from, to)
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("Fetch() error = %v, want ResponseError", err)
+	}
+	if respErr.ContentType != "text/html" || len(respErr.BodyPrefix) != responsePrefixSize {
+		t.Errorf("ResponseError = %+v", respErr)
+	}
+}
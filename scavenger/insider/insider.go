@@ -0,0 +1,318 @@
+// Package insider fetches the insider transactions (SEC Form 4 filings) of the companies from the SEC EDGAR.
+package insider
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/cache"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SourceName is the name of the Insider in the scavenger registry.
+	SourceName = "sec-insider"
+	wwwURL     = "https://www.sec.gov"
+	dataURL    = "https://data.sec.gov"
+	// defaultUserAgent is sent if the user agent is not set. SEC requires the user agent to identify the requester.
+	defaultUserAgent = "fin-thread (https://github.com/samgozman/fin-thread)"
+)
+
+// Insider is the struct to fetch the insider transactions from the SEC EDGAR filings.
+type Insider struct {
+	cache     cache.Cache   // optional cache for the EDGAR responses
+	cacheTTL  time.Duration // how long the EDGAR responses are cached
+	userAgent string        // user agent with the contact of the requester (defaultUserAgent if empty)
+	wwwURL    string        // EDGAR archives URL (wwwURL if empty)
+	dataURL   string        // EDGAR API URL (dataURL if empty)
+}
+
+// SetCache sets the cache for the EDGAR responses.
+func (i *Insider) SetCache(cache cache.Cache, ttl time.Duration) {
+	i.cache = cache
+	i.cacheTTL = ttl
+}
+
+// SetUserAgent sets the user agent of the EDGAR requests, SEC asks to declare it as "Company Name admin@example.com".
+func (i *Insider) SetUserAgent(userAgent string) {
+	i.userAgent = userAgent
+}
+
+// Name returns the name of the source.
+func (i *Insider) Name() string {
+	return SourceName
+}
+
+// Init does nothing because the source doesn't need any preparation.
+func (i *Insider) Init(_ context.Context) error {
+	return nil
+}
+
+// HealthCheck fetches the tickers list to verify that the EDGAR is reachable.
+func (i *Insider) HealthCheck(ctx context.Context) error {
+	_, err := i.fetchCIKs(ctx)
+	return err
+}
+
+// TradeCode is the code of the transaction in the Form 4.
+type TradeCode string
+
+const (
+	Purchase TradeCode = "P" // Open market or private purchase
+	Sale     TradeCode = "S" // Open market or private sale
+)
+
+// Trade is a single transaction of the insider.
+type Trade struct {
+	Date   time.Time // Date of the transaction
+	Code   TradeCode // Purchase or sale
+	Shares float64   // Number of shares
+	Price  float64   // Price per share in USD (0 if not reported)
+}
+
+// Filing is the Form 4 filing with the insider purchases and sales.
+type Filing struct {
+	AccessionNumber string    // ID of the filing in the EDGAR, e.g. "0000320193-24-000001"
+	Ticker          string    // Ticker of the company
+	Insider         string    // Name of the reporting owner
+	Title           string    // Relationship of the owner to the company, e.g. "CEO" or "Director"
+	FiledAt         time.Time // Filing date
+	Trades          []Trade   // Purchases and sales of the filing
+}
+
+// Total returns the number of the shares and the value (USD) of the trades with the code.
+func (f *Filing) Total(code TradeCode) (shares, value float64) {
+	for _, t := range f.Trades {
+		if t.Code == code {
+			shares += t.Shares
+			value += t.Shares * t.Price
+		}
+	}
+	return shares, value
+}
+
+// FetchFilings fetches Form 4 filings of the ticker filed between the dates (inclusive) sorted by the filing date.
+// Only filings with the purchases or sales are returned, others (e.g. grants or option exercises) are skipped.
+func (i *Insider) FetchFilings(ctx context.Context, ticker string, from, to time.Time) ([]*Filing, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	ciks, err := i.fetchCIKs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cik, ok := ciks[ticker]
+	if !ok {
+		return nil, errlvl.Wrap(fmt.Errorf("unknown EDGAR ticker %s", ticker), errlvl.WARN)
+	}
+
+	var submissions edgarSubmissions
+	if err := i.getJSON(ctx, i.apiURL()+fmt.Sprintf("/submissions/CIK%010d.json", cik), &submissions); err != nil {
+		return nil, err
+	}
+
+	var result []*Filing
+	recent := submissions.Filings.Recent
+	for n := range recent.Form {
+		if recent.Form[n] != "4" || n >= len(recent.FilingDate) || n >= len(recent.AccessionNumber) || n >= len(recent.PrimaryDocument) {
+			continue
+		}
+		filedAt, err := time.Parse(time.DateOnly, recent.FilingDate[n])
+		if err != nil || filedAt.Before(from.Truncate(24*time.Hour)) || filedAt.After(to) {
+			continue
+		}
+
+		f, err := i.fetchFiling(ctx, cik, recent.AccessionNumber[n], recent.PrimaryDocument[n])
+		if err != nil {
+			return nil, err
+		}
+		if len(f.Trades) == 0 {
+			continue
+		}
+		f.Ticker = ticker
+		f.FiledAt = filedAt
+		result = append(result, f)
+	}
+
+	sort.SliceStable(result, func(a, b int) bool {
+		return result[a].FiledAt.Before(result[b].FiledAt)
+	})
+
+	return result, nil
+}
+
+// fetchCIKs fetches the map of the tickers to the SEC company IDs (CIK).
+func (i *Insider) fetchCIKs(ctx context.Context) (map[string]int, error) {
+	return cache.Fetch(ctx, i.cache, cache.Key(SourceName, "tickers"), i.cacheTTL, func() (map[string]int, error) {
+		var tickers map[string]struct {
+			CIK    int    `json:"cik_str"`
+			Ticker string `json:"ticker"`
+		}
+		if err := i.getJSON(ctx, i.archivesURL()+"/files/company_tickers.json", &tickers); err != nil {
+			return nil, err
+		}
+
+		ciks := make(map[string]int, len(tickers))
+		for _, t := range tickers {
+			ciks[strings.ToUpper(t.Ticker)] = t.CIK
+		}
+		return ciks, nil
+	})
+}
+
+// fetchFiling fetches and parses the Form 4 XML document of the filing.
+func (i *Insider) fetchFiling(ctx context.Context, cik int, accessionNumber, primaryDocument string) (*Filing, error) {
+	// Primary document is the rendered HTML in the "xslF345X05/" directory, the raw XML has the same name in the root
+	link := fmt.Sprintf("%s/Archives/edgar/data/%d/%s/%s",
+		i.archivesURL(), cik, strings.ReplaceAll(accessionNumber, "-", ""), path.Base(primaryDocument))
+
+	body, err := i.get(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc form4
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error unmarshalling Form 4 %s: %w", accessionNumber, err), errlvl.ERROR)
+	}
+
+	return doc.toFiling(accessionNumber), nil
+}
+
+func (i *Insider) getJSON(ctx context.Context, link string, v any) error {
+	body, err := i.get(ctx, link)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return errlvl.Wrap(fmt.Errorf("error unmarshalling EDGAR response: %w", err), errlvl.ERROR)
+	}
+
+	return nil
+}
+
+// get sends the request to the EDGAR and returns the response body.
+func (i *Insider) get(ctx context.Context, link string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error creating EDGAR request: %w", err), errlvl.ERROR)
+	}
+	userAgent := i.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("user-agent", userAgent)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error sending EDGAR request: %w", err), errlvl.WARN)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error reading EDGAR response: %w", err), errlvl.ERROR)
+	}
+	err = res.Body.Close()
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error closing EDGAR response body: %w", err), errlvl.ERROR)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errlvl.Wrap(fmt.Errorf("unexpected EDGAR response status %d for %s", res.StatusCode, link), errlvl.WARN)
+	}
+
+	return body, nil
+}
+
+func (i *Insider) archivesURL() string {
+	if i.wwwURL != "" {
+		return i.wwwURL
+	}
+	return wwwURL
+}
+
+func (i *Insider) apiURL() string {
+	if i.dataURL != "" {
+		return i.dataURL
+	}
+	return dataURL
+}
+
+// EDGAR submissions API response (recent filings are stored as the parallel arrays).
+type edgarSubmissions struct {
+	Filings struct {
+		Recent struct {
+			AccessionNumber []string `json:"accessionNumber"`
+			FilingDate      []string `json:"filingDate"`
+			Form            []string `json:"form"`
+			PrimaryDocument []string `json:"primaryDocument"`
+		} `json:"recent"`
+	} `json:"filings"`
+}
+
+type form4Value struct {
+	Value string `xml:"value"`
+}
+
+// Form 4 XML document (only the used fields).
+type form4 struct {
+	Owners []struct {
+		Name         string `xml:"reportingOwnerId>rptOwnerName"`
+		Relationship struct {
+			IsDirector        string `xml:"isDirector"`
+			IsOfficer         string `xml:"isOfficer"`
+			IsTenPercentOwner string `xml:"isTenPercentOwner"`
+			OfficerTitle      string `xml:"officerTitle"`
+		} `xml:"reportingOwnerRelationship"`
+	} `xml:"reportingOwner"`
+	Transactions []struct {
+		Date   form4Value `xml:"transactionDate"`
+		Code   string     `xml:"transactionCoding>transactionCode"`
+		Shares form4Value `xml:"transactionAmounts>transactionShares"`
+		Price  form4Value `xml:"transactionAmounts>transactionPricePerShare"`
+	} `xml:"nonDerivativeTable>nonDerivativeTransaction"`
+}
+
+func (f *form4) toFiling(accessionNumber string) *Filing {
+	filing := &Filing{AccessionNumber: accessionNumber}
+	if len(f.Owners) > 0 {
+		owner := f.Owners[0]
+		filing.Insider = owner.Name
+		switch r := owner.Relationship; {
+		case isTrue(r.IsOfficer) && r.OfficerTitle != "":
+			filing.Title = r.OfficerTitle
+		case isTrue(r.IsDirector):
+			filing.Title = "Director"
+		case isTrue(r.IsTenPercentOwner):
+			filing.Title = "10% Owner"
+		}
+	}
+
+	for _, t := range f.Transactions {
+		code := TradeCode(strings.TrimSpace(t.Code))
+		if code != Purchase && code != Sale {
+			continue
+		}
+		// Transaction date may have the timezone suffix, e.g. "2024-01-02-05:00"
+		date := strings.TrimSpace(t.Date.Value)
+		tradedAt, _ := time.Parse(time.DateOnly, date[:min(len(date), len(time.DateOnly))])
+		shares, _ := strconv.ParseFloat(strings.TrimSpace(t.Shares.Value), 64)
+		price, _ := strconv.ParseFloat(strings.TrimSpace(t.Price.Value), 64)
+		filing.Trades = append(filing.Trades, Trade{Date: tradedAt, Code: code, Shares: shares, Price: price})
+	}
+
+	return filing
+}
+
+// isTrue parses the boolean flags of the Form 4 ("1" or "true").
+func isTrue(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "1" || strings.EqualFold(s, "true")
+}
@@ -0,0 +1,126 @@
+package insider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const tickersResponse = `{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."},"1":{"cik_str":789019,"ticker":"MSFT","title":"MICROSOFT CORP"}}`
+
+const submissionsResponse = `{"cik":"320193","filings":{"recent":{
+	"accessionNumber":["0000320193-24-000003","0000320193-24-000002","0000320193-24-000001","0000320193-23-000009"],
+	"filingDate":["2024-01-20","2024-01-12","2024-01-10","2023-12-01"],
+	"form":["4","8-K","4","4"],
+	"primaryDocument":["xslF345X05/wf-form4_1.xml","aapl-8k.htm","xslF345X05/wf-form4_2.xml","xslF345X05/wf-form4_3.xml"]
+}}}`
+
+const form4Sale = `<?xml version="1.0"?>
+<ownershipDocument>
+	<reportingOwner>
+		<reportingOwnerId><rptOwnerName>Cook Timothy D</rptOwnerName></reportingOwnerId>
+		<reportingOwnerRelationship><isDirector>1</isDirector><isOfficer>1</isOfficer><officerTitle>Chief Executive Officer</officerTitle></reportingOwnerRelationship>
+	</reportingOwner>
+	<nonDerivativeTable>
+		<nonDerivativeTransaction>
+			<transactionDate><value>2024-01-08</value></transactionDate>
+			<transactionCoding><transactionCode>M</transactionCode></transactionCoding>
+			<transactionAmounts><transactionShares><value>1000</value></transactionShares><transactionPricePerShare><value>0</value></transactionPricePerShare></transactionAmounts>
+		</nonDerivativeTransaction>
+		<nonDerivativeTransaction>
+			<transactionDate><value>2024-01-08-05:00</value></transactionDate>
+			<transactionCoding><transactionCode>S</transactionCode></transactionCoding>
+			<transactionAmounts><transactionShares><value>500</value></transactionShares><transactionPricePerShare><value>185.5</value></transactionPricePerShare></transactionAmounts>
+		</nonDerivativeTransaction>
+		<nonDerivativeTransaction>
+			<transactionDate><value>2024-01-09</value></transactionDate>
+			<transactionCoding><transactionCode>S</transactionCode></transactionCoding>
+			<transactionAmounts><transactionShares><value>100</value></transactionShares><transactionPricePerShare><value>186</value></transactionPricePerShare></transactionAmounts>
+		</nonDerivativeTransaction>
+	</nonDerivativeTable>
+</ownershipDocument>`
+
+const form4Grant = `<?xml version="1.0"?>
+<ownershipDocument>
+	<reportingOwner>
+		<reportingOwnerId><rptOwnerName>Levinson Arthur D</rptOwnerName></reportingOwnerId>
+		<reportingOwnerRelationship><isDirector>1</isDirector></reportingOwnerRelationship>
+	</reportingOwner>
+	<nonDerivativeTable>
+		<nonDerivativeTransaction>
+			<transactionDate><value>2024-01-18</value></transactionDate>
+			<transactionCoding><transactionCode>A</transactionCode></transactionCoding>
+			<transactionAmounts><transactionShares><value>1000</value></transactionShares></transactionAmounts>
+		</nonDerivativeTransaction>
+	</nonDerivativeTable>
+</ownershipDocument>`
+
+func TestInsider_FetchFilings(t *testing.T) {
+	var userAgents []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("user-agent"))
+		switch r.URL.Path {
+		case "/files/company_tickers.json":
+			_, _ = w.Write([]byte(tickersResponse))
+		case "/submissions/CIK0000320193.json":
+			_, _ = w.Write([]byte(submissionsResponse))
+		case "/Archives/edgar/data/320193/000032019324000001/wf-form4_2.xml":
+			_, _ = w.Write([]byte(form4Sale))
+		case "/Archives/edgar/data/320193/000032019324000003/wf-form4_1.xml":
+			_, _ = w.Write([]byte(form4Grant))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	i := &Insider{wwwURL: api.URL, dataURL: api.URL}
+	i.SetUserAgent("Test admin@example.com")
+	from := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	got, err := i.FetchFilings(context.Background(), "aapl", from, from.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ua := range userAgents {
+		if ua != "Test admin@example.com" {
+			t.Errorf("FetchFilings() sent user agent %q", ua)
+		}
+	}
+
+	// Grant-only filing and filings outside the period are skipped
+	if len(got) != 1 {
+		t.Fatalf("FetchFilings() returned %d filings, want 1", len(got))
+	}
+
+	f := got[0]
+	if f.AccessionNumber != "0000320193-24-000001" || f.Ticker != "AAPL" || f.Insider != "Cook Timothy D" ||
+		f.Title != "Chief Executive Officer" || !f.FiledAt.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("FetchFilings() = %+v", f)
+	}
+	if len(f.Trades) != 2 || !f.Trades[0].Date.Equal(time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("FetchFilings() trades = %+v, want 2 sales", f.Trades)
+	}
+
+	shares, value := f.Total(Sale)
+	if shares != 600 || value != 500*185.5+100*186 {
+		t.Errorf("Total(Sale) = %v, %v", shares, value)
+	}
+	if shares, value := f.Total(Purchase); shares != 0 || value != 0 {
+		t.Errorf("Total(Purchase) = %v, %v, want zeros", shares, value)
+	}
+}
+
+func TestInsider_FetchFilings_unknownTicker(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(tickersResponse))
+	}))
+	defer api.Close()
+
+	i := &Insider{wwwURL: api.URL, dataURL: api.URL}
+	if _, err := i.FetchFilings(context.Background(), "NOPE", time.Now(), time.Now()); err == nil {
+		t.Error("FetchFilings() expected error for unknown ticker")
+	}
+}
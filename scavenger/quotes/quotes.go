@@ -0,0 +1,199 @@
+package quotes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/cache"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// SourceName is the name of the Quotes in the scavenger registry.
+	SourceName = "yahoo-quotes"
+	chartURL   = "https://query1.finance.yahoo.com/v8/finance/chart/"
+)
+
+// Quotes is the struct to fetch stock prices from the Yahoo Finance chart API.
+type Quotes struct {
+	cache    cache.Cache   // optional cache for the quotes responses
+	cacheTTL time.Duration // how long the quotes responses are cached
+	apiURL   string        // chart API URL (chartURL if empty)
+}
+
+// SetCache sets the cache for the quotes responses.
+func (q *Quotes) SetCache(cache cache.Cache, ttl time.Duration) {
+	q.cache = cache
+	q.cacheTTL = ttl
+}
+
+// Name returns the name of the source.
+func (q *Quotes) Name() string {
+	return SourceName
+}
+
+// Init does nothing because the quotes source doesn't need any preparation.
+func (q *Quotes) Init(_ context.Context) error {
+	return nil
+}
+
+// HealthCheck fetches SPY intraday prices to verify that the quotes API is reachable.
+func (q *Quotes) HealthCheck(ctx context.Context) error {
+	_, err := q.FetchIntraday(ctx, "SPY")
+	return err
+}
+
+// Point is a single price of the ticker at the given time.
+type Point struct {
+	Time  time.Time
+	Price float64
+}
+
+// Intraday holds intraday prices of the ticker for the last trading day.
+type Intraday struct {
+	Ticker        string
+	PreviousClose float64 // close price of the previous trading day
+	Points        []Point // prices sorted by time in ascending order
+}
+
+// Last returns the latest known price (0 if there are no prices).
+func (i *Intraday) Last() float64 {
+	if len(i.Points) == 0 {
+		return 0
+	}
+	return i.Points[len(i.Points)-1].Price
+}
+
+// Change returns the percentage change of the last price from the previous close.
+func (i *Intraday) Change() float64 {
+	if i.PreviousClose == 0 || len(i.Points) == 0 {
+		return 0
+	}
+	return (i.Last()/i.PreviousClose - 1) * 100
+}
+
+// PriceAt returns the last known price at the given time (false if there are no prices before it).
+func (i *Intraday) PriceAt(t time.Time) (float64, bool) {
+	price, found := 0.0, false
+	for _, p := range i.Points {
+		if p.Time.After(t) {
+			break
+		}
+		price, found = p.Price, true
+	}
+	return price, found
+}
+
+// FetchIntraday fetches 5-minute prices of the ticker for the last trading day (from the cache if it's set).
+func (q *Quotes) FetchIntraday(ctx context.Context, ticker string) (*Intraday, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	return cache.Fetch(ctx, q.cache, cache.Key(SourceName, "intraday", ticker), q.cacheTTL, func() (*Intraday, error) {
+		return q.fetchIntraday(ctx, ticker)
+	})
+}
+
+// FetchFX fetches 5-minute quotes of the FX pair (e.g. "EURUSD") for the last trading day.
+func (q *Quotes) FetchFX(ctx context.Context, pair string) (*Intraday, error) {
+	return q.FetchIntraday(ctx, strings.TrimSpace(pair)+"=X")
+}
+
+func (q *Quotes) fetchIntraday(ctx context.Context, ticker string) (*Intraday, error) {
+	if ticker == "" {
+		return nil, errlvl.Wrap(fmt.Errorf("empty ticker"), errlvl.ERROR)
+	}
+
+	apiURL := q.apiURL
+	if apiURL == "" {
+		apiURL = chartURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+url.PathEscape(ticker)+"?interval=5m&range=1d", nil)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error creating quotes request: %w", err), errlvl.ERROR)
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error sending quotes request: %w", err), errlvl.ERROR)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error reading quotes response: %w", err), errlvl.ERROR)
+	}
+	err = res.Body.Close()
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error closing quotes response body: %w", err), errlvl.ERROR)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errlvl.Wrap(fmt.Errorf("unexpected quotes response status %d for %s", res.StatusCode, ticker), errlvl.WARN)
+	}
+
+	var chart yahooChart
+	if err := json.Unmarshal(body, &chart); err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error unmarshalling quotes response: %w", err), errlvl.ERROR)
+	}
+
+	return chart.toIntraday(ticker)
+}
+
+// Yahoo Finance chart API response.
+type yahooChart struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				ChartPreviousClose float64 `json:"chartPreviousClose"`
+				PreviousClose      float64 `json:"previousClose"`
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Close []*float64 `json:"close"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// toIntraday converts the response to Intraday skipping empty prices.
+func (c *yahooChart) toIntraday(ticker string) (*Intraday, error) {
+	if c.Chart.Error != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("quotes error for %s: %s", ticker, c.Chart.Error.Description), errlvl.WARN)
+	}
+	if len(c.Chart.Result) == 0 || len(c.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, errlvl.Wrap(fmt.Errorf("no quotes found for %s", ticker), errlvl.WARN)
+	}
+
+	r := c.Chart.Result[0]
+	closes := r.Indicators.Quote[0].Close
+
+	result := &Intraday{
+		Ticker:        ticker,
+		PreviousClose: r.Meta.ChartPreviousClose,
+		Points:        make([]Point, 0, len(r.Timestamp)),
+	}
+	if result.PreviousClose == 0 {
+		result.PreviousClose = r.Meta.PreviousClose
+	}
+
+	for i, ts := range r.Timestamp {
+		if i >= len(closes) || closes[i] == nil {
+			continue
+		}
+		result.Points = append(result.Points, Point{Time: time.Unix(ts, 0).UTC(), Price: *closes[i]})
+	}
+
+	return result, nil
+}
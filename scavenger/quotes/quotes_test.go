@@ -0,0 +1,73 @@
+package quotes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const chartResponse = `{"chart":{"result":[{
+	"meta":{"chartPreviousClose":100.0,"previousClose":99.0},
+	"timestamp":[1700000000,1700000300,1700000600],
+	"indicators":{"quote":[{"close":[101.0,null,104.3]}]}
+}],"error":null}}`
+
+func TestQuotes_FetchFX(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/EURUSD=X" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(chartResponse))
+	}))
+	defer api.Close()
+
+	q := &Quotes{apiURL: api.URL + "/"}
+	got, err := q.FetchFX(context.Background(), "eurusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Ticker != "EURUSD=X" || len(got.Points) != 2 {
+		t.Errorf("FetchFX() = %+v", got)
+	}
+}
+
+func TestQuotes_FetchIntraday(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/NVDA" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(chartResponse))
+	}))
+	defer api.Close()
+
+	q := &Quotes{apiURL: api.URL + "/"}
+	got, err := q.FetchIntraday(context.Background(), "nvda")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Ticker != "NVDA" || got.PreviousClose != 100 || len(got.Points) != 2 {
+		t.Fatalf("FetchIntraday() = %+v", got)
+	}
+	if got.Last() != 104.3 {
+		t.Errorf("Last() = %v, want 104.3", got.Last())
+	}
+	if change := got.Change(); change < 4.29 || change > 4.31 {
+		t.Errorf("Change() = %v, want 4.3", change)
+	}
+	if price, ok := got.PriceAt(time.Unix(1700000400, 0)); !ok || price != 101 {
+		t.Errorf("PriceAt() = %v, %v, want 101, true", price, ok)
+	}
+	if _, ok := got.PriceAt(time.Unix(1600000000, 0)); ok {
+		t.Errorf("PriceAt() should return false before the first price")
+	}
+
+	if _, err := q.FetchIntraday(context.Background(), "UNKNOWN"); err == nil {
+		t.Errorf("FetchIntraday() should return error for unknown ticker")
+	}
+}
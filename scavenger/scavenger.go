@@ -1,16 +1,209 @@
 package scavenger
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/scavenger/cache"
+	"github.com/samgozman/fin-thread/scavenger/corpcal"
 	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/insider"
+	"github.com/samgozman/fin-thread/scavenger/quotes"
 	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"github.com/samgozman/fin-thread/scavenger/wayback"
+	"slices"
+	"time"
 )
 
+// Source is a single data source of the Scavenger.
+type Source interface {
+	// Name returns the unique name of the source which is used to enable it in config.
+	Name() string
+	// Init prepares the source before the first use.
+	Init(ctx context.Context) error
+	// HealthCheck verifies that the upstream of the source is reachable.
+	HealthCheck(ctx context.Context) error
+}
+
+// Cacheable is a Source which responses can be cached.
+type Cacheable interface {
+	Source
+	// SetCache sets the cache for the source responses with the given TTL.
+	SetCache(cache cache.Cache, ttl time.Duration)
+}
+
+// defaultCacheTTL holds the default responses cache TTL for the built-in sources by their names.
+var defaultCacheTTL = map[string]time.Duration{
+	ecal.SourceName:    60 * time.Second, // shorter than the calendar updates interval (90s)
+	stocks.SourceName:  12 * time.Hour,
+	quotes.SourceName:  2 * time.Minute,
+	corpcal.SourceName: 6 * time.Hour,
+	insider.SourceName: 6 * time.Hour,
+}
+
+// builtinSources holds constructors of all available sources by their names.
+var builtinSources = map[string]func() Source{
+	ecal.SourceName:    func() Source { return &ecal.EconomicCalendar{} },
+	stocks.SourceName:  func() Source { return &stocks.Screener{} },
+	quotes.SourceName:  func() Source { return &quotes.Quotes{} },
+	corpcal.SourceName: func() Source { return &corpcal.CorporateCalendar{} },
+	wayback.SourceName: func() Source { return &wayback.Wayback{} },
+	insider.SourceName: func() Source { return &insider.Insider{} },
+}
+
 // Scavenger is the struct that fetches some custom data from defined sources.
-// The Scavenger will hold all available sources and will fetch the data from them.
+// The Scavenger is a registry that holds all enabled sources and gives typed access to them.
 //
 // It shouldn't be used as journalist.Journalist to get news. The main purpose of this struct is to
 // fetch custom unstructured data for different purposes. For example to fetch info updates or parse calendar events.
 type Scavenger struct {
-	EconomicCalendar *ecal.EconomicCalendar
-	Screener         *stocks.Screener
+	sources map[string]Source
+	order   []string // sources names in the registration order
+}
+
+// NewScavenger creates a new Scavenger with the enabled built-in sources by their names.
+// All built-in data sources are enabled if the list is empty, the optional ones (wayback, sec-insider) must be enabled explicitly.
+func NewScavenger(enabled ...string) (*Scavenger, error) {
+	if len(enabled) == 0 {
+		enabled = []string{ecal.SourceName, stocks.SourceName, quotes.SourceName, corpcal.SourceName}
+	}
+
+	s := &Scavenger{sources: make(map[string]Source)}
+	for _, name := range enabled {
+		constructor, ok := builtinSources[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scavenger source: %s", name)
+		}
+		if err := s.Register(constructor()); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Register adds the source to the registry. Source names must be unique.
+func (s *Scavenger) Register(source Source) error {
+	if s.sources == nil {
+		s.sources = make(map[string]Source)
+	}
+	if _, ok := s.sources[source.Name()]; ok {
+		return fmt.Errorf("scavenger source %s is already registered", source.Name())
+	}
+
+	s.sources[source.Name()] = source
+	s.order = append(s.order, source.Name())
+
+	return nil
+}
+
+// Remove removes the source from the registry by its name (e.g. if it failed to initialize).
+func (s *Scavenger) Remove(name string) {
+	if _, ok := s.sources[name]; !ok {
+		return
+	}
+
+	delete(s.sources, name)
+	s.order = slices.DeleteFunc(s.order, func(n string) bool { return n == name })
+}
+
+// WithCache sets the shared cache for all registered Cacheable sources with their default TTLs.
+// TTL can be overridden for each source by its name.
+func (s *Scavenger) WithCache(c cache.Cache, ttl map[string]time.Duration) *Scavenger {
+	for _, source := range s.Sources() {
+		cacheable, ok := source.(Cacheable)
+		if !ok {
+			continue
+		}
+
+		t, ok := ttl[source.Name()]
+		if !ok {
+			t = defaultCacheTTL[source.Name()]
+		}
+		cacheable.SetCache(c, t)
+	}
+
+	return s
+}
+
+// Get returns the registered source by its name.
+func (s *Scavenger) Get(name string) (Source, bool) {
+	source, ok := s.sources[name]
+	return source, ok
+}
+
+// Sources returns all registered sources in the registration order.
+func (s *Scavenger) Sources() []Source {
+	result := make([]Source, 0, len(s.order))
+	for _, name := range s.order {
+		result = append(result, s.sources[name])
+	}
+	return result
+}
+
+// Init initializes all registered sources.
+func (s *Scavenger) Init(ctx context.Context) error {
+	var errs []error
+	for _, source := range s.Sources() {
+		if err := source.Init(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] init: %w", source.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// HealthCheck checks all registered sources and returns the result by the source name.
+func (s *Scavenger) HealthCheck(ctx context.Context) map[string]error {
+	result := make(map[string]error, len(s.sources))
+	for _, source := range s.Sources() {
+		result[source.Name()] = source.HealthCheck(ctx)
+	}
+	return result
+}
+
+// EconomicCalendar returns the economic calendar source or nil if it's disabled.
+func (s *Scavenger) EconomicCalendar() *ecal.EconomicCalendar {
+	return getTyped[*ecal.EconomicCalendar](s, ecal.SourceName)
+}
+
+// Screener returns the stocks screener source or nil if it's disabled.
+func (s *Scavenger) Screener() *stocks.Screener {
+	return getTyped[*stocks.Screener](s, stocks.SourceName)
+}
+
+// Quotes returns the stock quotes source or nil if it's disabled.
+func (s *Scavenger) Quotes() *quotes.Quotes {
+	return getTyped[*quotes.Quotes](s, quotes.SourceName)
+}
+
+// CorporateCalendar returns the earnings and IPOs calendar source or nil if it's disabled.
+func (s *Scavenger) CorporateCalendar() *corpcal.CorporateCalendar {
+	return getTyped[*corpcal.CorporateCalendar](s, corpcal.SourceName)
+}
+
+// Wayback returns the web pages archive source or nil if it's disabled.
+func (s *Scavenger) Wayback() *wayback.Wayback {
+	return getTyped[*wayback.Wayback](s, wayback.SourceName)
+}
+
+// Insider returns the SEC insider transactions source or nil if it's disabled.
+func (s *Scavenger) Insider() *insider.Insider {
+	return getTyped[*insider.Insider](s, insider.SourceName)
+}
+
+// getTyped returns the registered source of the given type or zero value.
+func getTyped[T Source](s *Scavenger, name string) T {
+	var zero T
+	source, ok := s.Get(name)
+	if !ok {
+		return zero
+	}
+
+	typed, ok := source.(T)
+	if !ok {
+		return zero
+	}
+
+	return typed
 }
@@ -0,0 +1,119 @@
+package scavenger
+
+import (
+	"context"
+	"errors"
+	"github.com/samgozman/fin-thread/scavenger/cache"
+	"github.com/samgozman/fin-thread/scavenger/ecal"
+	"github.com/samgozman/fin-thread/scavenger/stocks"
+	"github.com/samgozman/fin-thread/scavenger/wayback"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	name string
+	err  error
+}
+
+func (f *fakeSource) Name() string                        { return f.name }
+func (f *fakeSource) Init(_ context.Context) error        { return f.err }
+func (f *fakeSource) HealthCheck(_ context.Context) error { return f.err }
+
+func TestNewScavenger(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      []string
+		wantCalendar bool
+		wantScreener bool
+		wantWayback  bool
+		wantErr      bool
+	}{
+		{
+			name:         "Should enable all sources by default",
+			wantCalendar: true,
+			wantScreener: true,
+		},
+		{
+			name:         "Should enable only listed sources",
+			enabled:      []string{ecal.SourceName},
+			wantCalendar: true,
+		},
+		{
+			name:        "Should enable optional sources explicitly",
+			enabled:     []string{wayback.SourceName},
+			wantWayback: true,
+		},
+		{
+			name:    "Should return error for unknown source",
+			enabled: []string{"unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "Should return error for duplicated source",
+			enabled: []string{stocks.SourceName, stocks.SourceName},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScavenger(tt.enabled...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewScavenger() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.EconomicCalendar() != nil; got != tt.wantCalendar {
+				t.Errorf("EconomicCalendar() enabled = %v, want %v", got, tt.wantCalendar)
+			}
+			if got := s.Screener() != nil; got != tt.wantScreener {
+				t.Errorf("Screener() enabled = %v, want %v", got, tt.wantScreener)
+			}
+			if got := s.Wayback() != nil; got != tt.wantWayback {
+				t.Errorf("Wayback() enabled = %v, want %v", got, tt.wantWayback)
+			}
+		})
+	}
+}
+
+func TestScavenger_InitAndHealthCheck(t *testing.T) {
+	failing := errors.New("upstream is down")
+	s := &Scavenger{}
+	_ = s.Register(&fakeSource{name: "ok"})
+	_ = s.Register(&fakeSource{name: "failing", err: failing})
+
+	if err := s.Init(context.Background()); !errors.Is(err, failing) {
+		t.Errorf("Init() error = %v, want %v", err, failing)
+	}
+
+	health := s.HealthCheck(context.Background())
+	if health["ok"] != nil || !errors.Is(health["failing"], failing) {
+		t.Errorf("HealthCheck() = %v", health)
+	}
+
+	// Wrong type should not panic
+	if s.Screener() != nil {
+		t.Errorf("Screener() should be nil if not registered")
+	}
+
+	s.Remove("failing")
+	if err := s.Init(context.Background()); err != nil {
+		t.Errorf("Init() after Remove() error = %v", err)
+	}
+	if _, ok := s.Get("failing"); ok || len(s.Sources()) != 1 {
+		t.Errorf("Remove() left the source registered: %v", s.Sources())
+	}
+}
+
+func TestScavenger_WithCache(t *testing.T) {
+	s, err := NewScavenger()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Should not panic for non-cacheable sources
+	_ = s.Register(&fakeSource{name: "fake"})
+	s.WithCache(cache.NewMemory(), map[string]time.Duration{ecal.SourceName: time.Second})
+}
@@ -0,0 +1,149 @@
+package stocks
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ETF holds the info about the exchange traded fund and its largest holdings.
+type ETF struct {
+	Name     string    `json:"name"`     // full name of the fund
+	Index    string    `json:"index"`    // canonical ID of the tracked index (as in composer.Market), empty for non-index funds
+	Holdings []Holding `json:"holdings"` // largest holdings of the fund sorted by weight (descending), empty if unknown
+}
+
+// Holding is a single constituent of the ETF.
+type Holding struct {
+	Ticker       string  `json:"ticker"`         // ticker of the stock
+	Weight       float64 `json:"weight"`         // weight of the stock in the fund (%)
+	ShareClassOf string  `json:"share_class_of"` // ticker of the other share class of the same company (e.g. GOOGL for GOOG)
+}
+
+// ETFMap is a map of `ticker` -> ETF.
+type ETFMap map[string]ETF
+
+// etfsJSON is the default list of the popular US ETFs with approximate weights of the largest holdings.
+//
+//go:embed etfs.json
+var etfsJSON []byte
+
+// DefaultETFs returns the default list of the popular US ETFs with approximate weights of the largest holdings
+// (etfs.json). It is used to distinguish ETFs from stocks among the composed tickers and to list the mega-cap
+// constituents of the markets mentioned in the news. Weights are only used for ordering, so the approximation is fine.
+func DefaultETFs() *ETFMap {
+	m, err := parseETFs(etfsJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default ETFs: %v", err))
+	}
+	return m
+}
+
+// LoadETFs reads the ETFs from the JSON file in the format of etfs.json (the ticker to the ETF with the holdings),
+// e.g. the holdings updated from the fund provider:
+//
+//	{"SPY": {"name": "SPDR S&P 500 ETF Trust", "index": "SPX", "holdings": [{"ticker": "MSFT", "weight": 7.1}]}}
+func LoadETFs(path string) (*ETFMap, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error reading ETFs file: %w", err), errlvl.ERROR)
+	}
+
+	m, err := parseETFs(b)
+	if err != nil {
+		return nil, errlvl.Wrap(fmt.Errorf("error parsing ETFs file %s: %w", path, err), errlvl.ERROR)
+	}
+	return m, nil
+}
+
+// parseETFs parses the ETFs JSON with the upper case tickers and the holdings sorted by weight (descending).
+func parseETFs(b []byte) (*ETFMap, error) {
+	var etfs map[string]ETF
+	if err := json.Unmarshal(b, &etfs); err != nil {
+		return nil, err
+	}
+
+	m := make(ETFMap, len(etfs))
+	for ticker, etf := range etfs {
+		if strings.TrimSpace(ticker) == "" || slices.ContainsFunc(etf.Holdings, func(h Holding) bool { return h.Ticker == "" }) {
+			return nil, errors.New("tickers must not be empty")
+		}
+		etf.Index = strings.ToUpper(etf.Index)
+		slices.SortStableFunc(etf.Holdings, func(a, b Holding) int { return cmp.Compare(b.Weight, a.Weight) })
+		m[strings.ToUpper(ticker)] = etf
+	}
+
+	return &m, nil
+}
+
+// IsETF returns true if the ticker is a known ETF.
+func (m *ETFMap) IsETF(ticker string) bool {
+	if m == nil {
+		return false
+	}
+
+	_, ok := (*m)[strings.ToUpper(ticker)]
+	return ok
+}
+
+// SplitTickers splits tickers into stocks and ETFs keeping the original order.
+func (m *ETFMap) SplitTickers(tickers []string) (stocks, etfs []string) {
+	for _, t := range tickers {
+		if m.IsETF(t) {
+			etfs = append(etfs, t)
+		} else {
+			stocks = append(stocks, t)
+		}
+	}
+
+	return stocks, etfs
+}
+
+// TopHoldings returns up to n largest holdings tickers of the ETF (by its ticker) or of the tracked index
+// (by its canonical ID, e.g. "SPX"). The ETF without its own holdings has the holdings of its index.
+// Only the first share class of the company is listed. Returns nil if holdings are unknown.
+func (m *ETFMap) TopHoldings(tickerOrIndex string, n int) []string {
+	if m == nil || n <= 0 {
+		return nil
+	}
+
+	key := strings.ToUpper(tickerOrIndex)
+	etf, ok := (*m)[key]
+	if ok && len(etf.Holdings) == 0 && etf.Index != "" {
+		key, ok = etf.Index, false
+	}
+	if !ok {
+		// Deterministic search by index: sorted ETF tickers
+		tickers := make([]string, 0, len(*m))
+		for t, e := range *m {
+			if e.Index == key && len(e.Holdings) > 0 {
+				tickers = append(tickers, t)
+			}
+		}
+		if len(tickers) == 0 {
+			return nil
+		}
+		sort.Strings(tickers)
+		etf = (*m)[tickers[0]]
+	}
+
+	result := make([]string, 0, n)
+	for _, h := range etf.Holdings {
+		if h.ShareClassOf != "" && slices.Contains(result, h.ShareClassOf) {
+			continue
+		}
+		result = append(result, h.Ticker)
+		if len(result) == n {
+			break
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,102 @@
+package stocks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestETFMap_SplitTickers(t *testing.T) {
+	gotStocks, gotETFs := DefaultETFs().SplitTickers([]string{"AAPL", "spy", "XLK", "NVDA"})
+	if want := []string{"AAPL", "NVDA"}; !reflect.DeepEqual(gotStocks, want) {
+		t.Errorf("SplitTickers() stocks = %v, want %v", gotStocks, want)
+	}
+	if want := []string{"spy", "XLK"}; !reflect.DeepEqual(gotETFs, want) {
+		t.Errorf("SplitTickers() etfs = %v, want %v", gotETFs, want)
+	}
+}
+
+func TestETFMap_TopHoldings(t *testing.T) {
+	tests := []struct {
+		name          string
+		m             *ETFMap
+		tickerOrIndex string
+		n             int
+		want          []string
+	}{
+		{
+			name:          "by ETF ticker",
+			m:             DefaultETFs(),
+			tickerOrIndex: "qqq",
+			n:             3,
+			want:          []string{"MSFT", "AAPL", "NVDA"},
+		},
+		{
+			name:          "by index skipping share classes",
+			m:             DefaultETFs(),
+			tickerOrIndex: "SPX",
+			n:             7,
+			want:          []string{"MSFT", "AAPL", "NVDA", "AMZN", "META", "GOOGL", "BRK.B"},
+		},
+		{
+			name:          "holdings of the ETF index",
+			m:             DefaultETFs(),
+			tickerOrIndex: "VOO",
+			n:             2,
+			want:          []string{"MSFT", "AAPL"},
+		},
+		{
+			name:          "unknown holdings",
+			m:             DefaultETFs(),
+			tickerOrIndex: "RUT",
+			n:             3,
+			want:          nil,
+		},
+		{
+			name:          "nil map",
+			m:             nil,
+			tickerOrIndex: "SPY",
+			n:             3,
+			want:          nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.TopHoldings(tt.tickerOrIndex, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopHoldings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadETFs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "etfs.json")
+	data := `{"spy": {"name": "SPDR S&P 500 ETF Trust", "index": "spx", "holdings": [
+		{"ticker": "AAPL", "weight": 6.6},
+		{"ticker": "GOOG", "weight": 1.9, "share_class_of": "GOOGL"},
+		{"ticker": "MSFT", "weight": 7.1},
+		{"ticker": "GOOGL", "weight": 2.2}
+	]}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadETFs(path)
+	if err != nil {
+		t.Fatalf("LoadETFs() error = %v", err)
+	}
+	if !m.IsETF("SPY") || m.IsETF("QQQ") {
+		t.Errorf("LoadETFs() = %v, want only the ETFs of the file", *m)
+	}
+	if got, want := m.TopHoldings("SPX", 4), []string{"MSFT", "AAPL", "GOOGL"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopHoldings() = %v, want %v sorted by weight without the other share class", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"SPY": {"holdings": [{"weight": 1}]}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadETFs(path); err == nil {
+		t.Errorf("LoadETFs() error = nil, want the error of the holding without the ticker")
+	}
+}
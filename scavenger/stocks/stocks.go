@@ -5,13 +5,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"github.com/samgozman/fin-thread/scavenger/cache"
 	"io"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// SourceName is the name of the Screener in the scavenger registry.
+const SourceName = "stocks-screener"
+
 // Screener is a struct to fetch all available Stocks from external API.
-type Screener struct{}
+type Screener struct {
+	cache    cache.Cache   // optional cache for the screener responses
+	cacheTTL time.Duration // how long the screener responses are cached
+}
+
+// SetCache sets the cache for the screener responses.
+func (f *Screener) SetCache(cache cache.Cache, ttl time.Duration) {
+	f.cache = cache
+	f.cacheTTL = ttl
+}
+
+// Name returns the name of the source.
+func (f *Screener) Name() string {
+	return SourceName
+}
+
+// Init does nothing because the screener doesn't need any preparation.
+func (f *Screener) Init(_ context.Context) error {
+	return nil
+}
+
+// HealthCheck fetches stocks from nasdaq to verify that the screener is reachable.
+func (f *Screener) HealthCheck(ctx context.Context) error {
+	_, err := f.FetchFromNasdaq(ctx)
+	return err
+}
 
 // FetchFromString fetches all available Stocks from string (env STOCK_SYMBOLS) separated with | (pipe)
 // and returns them as a map of `ticker` -> Stock.
@@ -34,6 +66,12 @@ func (f *Screener) FetchFromString(str string) *StockMap {
 // and returns them as a map of `ticker` -> Stock.
 // ! NOTE: nasdaq is not available in EU region yet.
 func (f *Screener) FetchFromNasdaq(ctx context.Context) (*StockMap, error) {
+	return cache.Fetch(ctx, f.cache, cache.Key(SourceName, "nasdaq"), f.cacheTTL, func() (*StockMap, error) {
+		return f.fetchFromNasdaq(ctx)
+	})
+}
+
+func (f *Screener) fetchFromNasdaq(ctx context.Context) (*StockMap, error) {
 	url := "https://api.nasdaq.com/api/screener/stocks?tableonly=true&limit=25&offset=0&download=true"
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -87,9 +125,39 @@ type Stock struct {
 	Sector    string `json:"sector"`
 }
 
+// MarketCapUSD parses Stock.MarketCap (e.g. "3001298596040.00") to the number of USD.
+// Returns false if the market cap is empty, zero or can't be parsed.
+func (s Stock) MarketCapUSD() (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.ReplaceAll(s.MarketCap, ",", "")), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+
+	return v, true
+}
+
 // StockMap is a map of `ticker` -> Stock.
 type StockMap map[string]Stock
 
+// Sectors returns distinct sectors of the given tickers in order of their appearance.
+// Unknown tickers and tickers without a sector are skipped.
+func (m *StockMap) Sectors(tickers []string) []string {
+	if m == nil {
+		return nil
+	}
+
+	var sectors []string
+	for _, t := range tickers {
+		s, ok := (*m)[t]
+		if !ok || s.Sector == "" || slices.Contains(sectors, s.Sector) {
+			continue
+		}
+		sectors = append(sectors, s.Sector)
+	}
+
+	return sectors
+}
+
 type nasdaqScreenerResponse struct {
 	Data struct {
 		AsOf    string `json:"asOf"`    // unnecessary, but keeping it for JSON unmarshalling
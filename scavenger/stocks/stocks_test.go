@@ -0,0 +1,70 @@
+package stocks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStock_MarketCapUSD(t *testing.T) {
+	tests := []struct {
+		marketCap string
+		want      float64
+		wantOk    bool
+	}{
+		{marketCap: "3001298596040.00", want: 3001298596040, wantOk: true},
+		{marketCap: "12,500,000", want: 12500000, wantOk: true},
+		{marketCap: "0.00", want: 0, wantOk: false},
+		{marketCap: "", want: 0, wantOk: false},
+		{marketCap: "NA", want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.marketCap, func(t *testing.T) {
+			got, ok := Stock{MarketCap: tt.marketCap}.MarketCapUSD()
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("MarketCapUSD() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestStockMap_Sectors(t *testing.T) {
+	m := &StockMap{
+		"AAPL": {Sector: "Technology"},
+		"MSFT": {Sector: "Technology"},
+		"JNJ":  {Sector: "Health Care"},
+		"SPAC": {},
+	}
+
+	tests := []struct {
+		name    string
+		m       *StockMap
+		tickers []string
+		want    []string
+	}{
+		{
+			name:    "distinct sectors in order",
+			m:       m,
+			tickers: []string{"AAPL", "JNJ", "MSFT"},
+			want:    []string{"Technology", "Health Care"},
+		},
+		{
+			name:    "unknown tickers and empty sectors are skipped",
+			m:       m,
+			tickers: []string{"SPAC", "TSLA"},
+			want:    nil,
+		},
+		{
+			name:    "nil map",
+			m:       nil,
+			tickers: []string{"AAPL"},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Sectors(tt.tickers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sectors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
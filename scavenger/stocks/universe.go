@@ -0,0 +1,47 @@
+package stocks
+
+import "sync"
+
+// Universe is the set of the listed tickers, which can be replaced (e.g. by the weekly listings refresh)
+// while the jobs use it.
+type Universe struct {
+	mu      sync.RWMutex
+	tickers map[string]bool
+}
+
+// NewUniverse creates a new Universe with the tickers of the stock map (empty if nil).
+func NewUniverse(m *StockMap) *Universe {
+	u := &Universe{tickers: make(map[string]bool)}
+	if m != nil {
+		for t := range *m {
+			u.tickers[t] = true
+		}
+	}
+	return u
+}
+
+// Listed returns true if the ticker is in the universe.
+func (u *Universe) Listed(ticker string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.tickers[ticker]
+}
+
+// Len returns the number of the tickers in the universe.
+func (u *Universe) Len() int {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return len(u.tickers)
+}
+
+// Replace replaces the tickers of the universe with the tickers of the stock map.
+func (u *Universe) Replace(m *StockMap) {
+	tickers := make(map[string]bool, len(*m))
+	for t := range *m {
+		tickers[t] = true
+	}
+
+	u.mu.Lock()
+	u.tickers = tickers
+	u.mu.Unlock()
+}
@@ -0,0 +1,19 @@
+package stocks
+
+import "testing"
+
+func TestUniverse(t *testing.T) {
+	u := NewUniverse(&StockMap{"AAPL": {}, "TWTR": {}})
+	if !u.Listed("AAPL") || !u.Listed("TWTR") || u.Listed("RDDT") || u.Len() != 2 {
+		t.Fatalf("NewUniverse() tickers = %v, want AAPL and TWTR", u.tickers)
+	}
+
+	u.Replace(&StockMap{"AAPL": {}, "RDDT": {}, "ARM": {}})
+	if !u.Listed("AAPL") || u.Listed("TWTR") || !u.Listed("RDDT") || u.Len() != 3 {
+		t.Errorf("Replace() tickers = %v, want AAPL, RDDT and ARM", u.tickers)
+	}
+
+	if NewUniverse(nil).Len() != 0 {
+		t.Errorf("NewUniverse(nil) should be empty")
+	}
+}
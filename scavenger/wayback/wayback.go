@@ -0,0 +1,150 @@
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/samgozman/fin-thread/pkg/errlvl"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SourceName is the name of the Wayback in the scavenger registry.
+	SourceName      = "wayback"
+	saveURL         = "https://web.archive.org/save/"
+	availabilityURL = "https://archive.org/wayback/available"
+	snapshotURL     = "https://web.archive.org"
+)
+
+// snapshotPathRe matches the path of the Wayback Machine snapshot: /web/<timestamp>/<original url>.
+var snapshotPathRe = regexp.MustCompile(`^/web/\d{14}/`)
+
+// Wayback captures snapshots of the web pages with the Internet Archive Wayback Machine ("Save Page Now"),
+// so the published news have a fallback link when the original one rots.
+type Wayback struct {
+	saveURL         string // Save Page Now URL (saveURL if empty)
+	availabilityURL string // availability API URL (availabilityURL if empty)
+}
+
+// Name returns the name of the source.
+func (w *Wayback) Name() string {
+	return SourceName
+}
+
+// Init does nothing because the Wayback doesn't need any preparation.
+func (w *Wayback) Init(_ context.Context) error {
+	return nil
+}
+
+// HealthCheck looks up the latest snapshot of the example page to verify that the Wayback Machine is reachable.
+func (w *Wayback) HealthCheck(ctx context.Context) error {
+	_, err := w.Latest(ctx, "https://example.com")
+	return err
+}
+
+// Snapshot captures the new snapshot of the page and returns its URL. If the capture failed (e.g. the rate limit
+// of the Save Page Now is exceeded), the latest existing snapshot is returned instead.
+// Note: capture takes up to a minute, so it shouldn't be called in the time-limited jobs.
+func (w *Wayback) Snapshot(ctx context.Context, link string) (string, error) {
+	snapshot, saveErr := w.save(ctx, link)
+	if saveErr == nil {
+		return snapshot, nil
+	}
+
+	snapshot, err := w.Latest(ctx, link)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error capturing snapshot of %s: %w, %w", link, saveErr, err), errlvl.WARN)
+	}
+
+	return snapshot, nil
+}
+
+// save requests the capture of the page with the Save Page Now and returns the snapshot URL.
+func (w *Wayback) save(ctx context.Context, link string) (string, error) {
+	api := w.saveURL
+	if api == "" {
+		api = saveURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api+link, nil)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error creating save request: %w", err), errlvl.ERROR)
+	}
+	req.Header.Set("user-agent", "Mozilla/5.0 (compatible; fin-thread; +https://github.com/samgozman/fin-thread)")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error sending save request: %w", err), errlvl.WARN)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	if err := res.Body.Close(); err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error closing save response body: %w", err), errlvl.ERROR)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", errlvl.Wrap(fmt.Errorf("unexpected save response status %d", res.StatusCode), errlvl.WARN)
+	}
+
+	// The snapshot path is in the Content-Location header or in the final URL after redirects
+	if path := res.Header.Get("Content-Location"); snapshotPathRe.MatchString(path) {
+		return snapshotURL + path, nil
+	}
+	if snapshotPathRe.MatchString(res.Request.URL.Path) {
+		return snapshotURL + res.Request.URL.RequestURI(), nil
+	}
+
+	return "", errlvl.Wrap(fmt.Errorf("snapshot location of %s not found in save response", link), errlvl.WARN)
+}
+
+// Latest returns the URL of the latest existing snapshot of the page.
+func (w *Wayback) Latest(ctx context.Context, link string) (string, error) {
+	api := w.availabilityURL
+	if api == "" {
+		api = availabilityURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api+"?url="+url.QueryEscape(link), nil)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error creating availability request: %w", err), errlvl.ERROR)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error sending availability request: %w", err), errlvl.WARN)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error reading availability response: %w", err), errlvl.ERROR)
+	}
+	if err := res.Body.Close(); err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error closing availability response body: %w", err), errlvl.ERROR)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", errlvl.Wrap(fmt.Errorf("unexpected availability response status %d", res.StatusCode), errlvl.WARN)
+	}
+
+	var availability struct {
+		ArchivedSnapshots struct {
+			Closest *struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.Unmarshal(body, &availability); err != nil {
+		return "", errlvl.Wrap(fmt.Errorf("error unmarshalling availability response: %w", err), errlvl.ERROR)
+	}
+
+	closest := availability.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available || closest.URL == "" {
+		return "", errlvl.Wrap(fmt.Errorf("no snapshots of %s found", link), errlvl.INFO)
+	}
+
+	return strings.Replace(closest.URL, "http://", "https://", 1), nil
+}
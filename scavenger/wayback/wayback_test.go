@@ -0,0 +1,80 @@
+package wayback
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWayback_Snapshot(t *testing.T) {
+	const link = "https://example.com/news/1"
+
+	tests := []struct {
+		name    string
+		save    http.HandlerFunc
+		latest  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "content location",
+			save: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Location", "/web/20240102030405/"+link)
+			},
+			want: "https://web.archive.org/web/20240102030405/" + link,
+		},
+		{
+			name: "redirect to snapshot",
+			save: func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/web/") {
+					return
+				}
+				w.Header().Set("Location", "/web/20240102030405/example.com/news/1")
+				w.WriteHeader(http.StatusFound)
+			},
+			want: "https://web.archive.org/web/20240102030405/example.com/news/1",
+		},
+		{
+			name: "latest snapshot if rate limited",
+			save: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			latest: `{"archived_snapshots":{"closest":{"available":true,"status":"200",` +
+				`"url":"http://web.archive.org/web/20231201000000/https://example.com/news/1","timestamp":"20231201000000"}}}`,
+			want: "https://web.archive.org/web/20231201000000/" + link,
+		},
+		{
+			name: "no snapshots",
+			save: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			latest:  `{"archived_snapshots":{}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			save := httptest.NewServer(tt.save)
+			defer save.Close()
+			available := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("url") != link {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				_, _ = w.Write([]byte(tt.latest))
+			}))
+			defer available.Close()
+
+			w := &Wayback{saveURL: save.URL + "/save/", availabilityURL: available.URL}
+			got, err := w.Snapshot(context.Background(), link)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Snapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Snapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}